@@ -23,19 +23,48 @@ import (
 )
 
 const (
+	AmalgamateDefault = false
+	AmalgamateUsage   = `whether to write a single wuffs.h file, holding every package's header and, behind a WUFFS_IMPLEMENTATION #ifdef, its implementation, instead of compiling libraries`
+
+	CcompilerDefault = "gcc"
+	CcompilerUsage   = `the C compiler whose genlib output to install, e.g. "gcc"`
+
 	CcompilersDefault = "clang-5.0,gcc"
 	CcompilersUsage   = `comma-separated list of C compilers, e.g. "clang-5.0,gcc"`
 
+	DCEReportDefault = false
+	DCEReportUsage   = `whether to print the functions that were eliminated as unreachable, and the code size of those that were not`
+
+	EntryDefault = ""
+	EntryUsage   = `comma-separated list of public functions, e.g. "decoder.decode", to generate along with the functions that they call, instead of every public function`
+
 	FocusDefault = ""
 	FocusUsage   = `comma-separated list of tests or benchmarks (name prefixes) to focus on, e.g. "wuffs_gif_decode"`
 
+	FreestandingDefault = false
+	FreestandingUsage   = `whether to generate C code that needs no C library, only the <stdbool.h>, <stddef.h> and <stdint.h> headers, so that it can be compiled with -ffreestanding -nostdlib`
+
 	MimicDefault = false
 	MimicUsage   = `whether to compare Wuffs' output with other libraries' output`
 
+	OnlyDefault = ""
+	OnlyUsage   = `comma-separated list of packages, e.g. "std/gzip", to build libraries for, along with the packages that they transitively use, instead of every package`
+
+	PrefixDefault = "/usr/local"
+	PrefixUsage   = `the installation prefix, e.g. "/usr/local", with libraries going into its lib directory and headers into its include/wuffs directory`
+
 	RepsDefault = 5
 	RepsMin     = 0
 	RepsMax     = 1000000
 	RepsUsage   = `the number of repetitions per benchmark`
+
+	TransitivityDepthDefault = 0
+	TransitivityDepthMin     = 0
+	TransitivityDepthMax     = 4
+	TransitivityDepthUsage   = `the maximum chain length when searching the facts to prove an assertion by transitivity, or 0 to disable the search, unless a package opts in with a "// !! wuffs transitivity_depth: N" comment`
+
+	TransitivityReportDefault = false
+	TransitivityReportUsage   = `whether to print the chain of facts for each assertion proven by transitivity`
 )
 
 // TODO: do IsAlphaNumericIsh and IsValidUsePath belong in a separate package,
@@ -15,11 +15,14 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	cf "github.com/google/wuffs/cmd/commonflags"
@@ -27,8 +30,10 @@ import (
 
 func doGenlib(args []string) error {
 	flags := flag.FlagSet{}
+	amalgamateFlag := flags.Bool("amalgamate", cf.AmalgamateDefault, cf.AmalgamateUsage)
 	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
 	dstdirFlag := flags.String("dstdir", "", "directory containing the object files ")
+	onlyFlag := flags.String("only", cf.OnlyDefault, cf.OnlyUsage)
 	srcdirFlag := flags.String("srcdir", "", "directory containing the C source files")
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -42,6 +47,38 @@ func doGenlib(args []string) error {
 		return fmt.Errorf("empty -srcdir flag")
 	}
 
+	if *onlyFlag != "" {
+		if len(args) != 0 {
+			return fmt.Errorf("the -only flag replaces, and cannot be combined with, package arguments")
+		}
+		args = strings.Split(*onlyFlag, ",")
+	}
+	for _, arg := range args {
+		if !cf.IsValidUsePath(arg) {
+			return fmt.Errorf("invalid package path %q", arg)
+		}
+	}
+
+	if *amalgamateFlag {
+		if err := os.MkdirAll(*dstdirFlag, 0755); err != nil {
+			return err
+		}
+		return genAmalgamation(*dstdirFlag, *srcdirFlag, args)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("no packages given")
+	}
+	uses := packageUses{}
+	args, err := uses.closure(*srcdirFlag, ".c", args)
+	if err != nil {
+		return fmt.Errorf("genlib: %v", err)
+	}
+	v, err := readVersion(filepath.Join(*srcdirFlag, filepath.FromSlash(args[0])+".c"))
+	if err != nil {
+		return err
+	}
+
 	for _, cc := range strings.Split(*ccompilersFlag, ",") {
 		cc = strings.TrimSpace(cc)
 		if cc == "" {
@@ -56,9 +93,17 @@ func doGenlib(args []string) error {
 			if err := genObj(outDir, *srcdirFlag, cc, dynamism, args); err != nil {
 				return err
 			}
-			if err := genLib(outDir, cc, dynamism, args); err != nil {
+			if err := genLib(outDir, cc, dynamism, v, "libwuffs", args, nil); err != nil {
 				return err
 			}
+			// The closure lists each package after the ones it uses, so
+			// that the per-package shared libraries can link against them.
+			for _, filename := range args {
+				if err := genLib(outDir, cc, dynamism, v, pkgLibName(filename),
+					[]string{filename}, uses[filename]); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil
@@ -99,22 +144,36 @@ func genObj(outDir string, inDir string, cc string, dynamism string, filenames [
 	return nil
 }
 
-func genLib(outDir string, cc string, dynamism string, filenames []string) error {
+// genLib builds the library named lib, such as "libwuffs", from the object
+// files for filenames. A shared library is also linked against the shared
+// libraries for the packages in deps, so that it records those dependencies.
+func genLib(outDir string, cc string, dynamism string, v version, lib string, filenames []string, deps []string) error {
 	args := []string(nil)
+	out := filepath.Join(outDir, lib+libExtensions[dynamism])
 	switch dynamism {
 	case "dynamic":
-		// TODO: add a "-Wl,-soname,libwuffs.so.1.2.3" argument?
-		args = append(args, "-shared", "-fPIC", "-o")
+		out = filepath.Join(outDir, v.realname(lib))
+		args = append(args, "-shared", "-fPIC", "-Wl,-soname,"+v.soname(lib), "-o")
 	case "static":
 		cc = "ar"
 		args = append(args, "rc")
+		// "ar rc" adds to an existing archive, which could hold packages
+		// that are no longer asked for, so start afresh.
+		if err := os.Remove(out); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
-	out := filepath.Join(outDir, "libwuffs"+libExtensions[dynamism])
 	args = append(args, out)
 
 	for _, filename := range filenames {
 		args = append(args, genlibOutFilename(outDir, dynamism, filename))
 	}
+	if dynamism == "dynamic" && len(deps) > 0 {
+		args = append(args, "-L"+outDir)
+		for _, dep := range deps {
+			args = append(args, "-l"+strings.TrimPrefix(pkgLibName(dep), "lib"))
+		}
+	}
 
 	cmd := exec.Command(cc, args...)
 	cmd.Stdout = os.Stdout
@@ -123,6 +182,85 @@ func genLib(outDir string, cc string, dynamism string, filenames []string) error
 		return err
 	}
 	fmt.Printf("genlib: %s\n", out)
+
+	if dynamism == "dynamic" {
+		return symlinkLib(outDir, lib, v)
+	}
+	return nil
+}
+
+// pkgLibName returns the name of the library for just one package, such as
+// "libwuffs-std-gzip" for "std/gzip".
+func pkgLibName(filename string) string {
+	return "libwuffs-" + strings.Replace(filename, "/", "-", -1)
+}
+
+// version is the WUFFS_VERSION number of the generated C code.
+type version struct {
+	major uint32
+	minor uint32
+}
+
+func (v version) String() string { return fmt.Sprintf("%d.%d", v.major, v.minor) }
+
+// soname is the name, that programs link against, of the shared library lib,
+// such as "libwuffs". As per the base header's WUFFS_VERSION comment, the API
+// and ABI are unstable before version 1.0, so the minor version is part of the
+// soname until then.
+func (v version) soname(lib string) string {
+	if v.major == 0 {
+		return fmt.Sprintf("%s.so.0.%d", lib, v.minor)
+	}
+	return fmt.Sprintf("%s.so.%d", lib, v.major)
+}
+
+// realname is the file name of the shared library lib.
+func (v version) realname(lib string) string {
+	return fmt.Sprintf("%s.so.%d.%d", lib, v.major, v.minor)
+}
+
+var wuffsVersionPrefix = []byte("#define WUFFS_VERSION (")
+
+// readVersion reads the WUFFS_VERSION from a generated C or header file.
+func readVersion(filename string) (version, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return version{}, err
+	}
+	i := bytes.Index(src, wuffsVersionPrefix)
+	if i < 0 {
+		return version{}, fmt.Errorf("%s: missing WUFFS_VERSION", filename)
+	}
+	src = src[i+len(wuffsVersionPrefix):]
+	if i = bytes.IndexByte(src, ')'); i < 0 {
+		return version{}, fmt.Errorf("%s: bad WUFFS_VERSION", filename)
+	}
+	x, err := strconv.ParseUint(string(src[:i]), 0, 32)
+	if err != nil {
+		return version{}, fmt.Errorf("%s: bad WUFFS_VERSION: %v", filename, err)
+	}
+	return version{major: uint32(x >> 16), minor: uint32(x & 0xFFFF)}, nil
+}
+
+// symlinkLib points the soname and the unversioned name, such as
+// "libwuffs.so", at the shared library lib in dir.
+func symlinkLib(dir string, lib string, v version) error {
+	links := [...][2]string{
+		{v.soname(lib), v.realname(lib)},
+		{lib + libExtensions["dynamic"], v.soname(lib)},
+	}
+	for _, l := range links {
+		if l[0] == l[1] {
+			continue
+		}
+		name := filepath.Join(dir, l[0])
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Symlink(l[1], name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -132,3 +270,196 @@ func genlibOutFilename(outDir string, dynamism string, filename string) string {
 	filename = filepath.Join(outDir, filename+objExtensions[dynamism])
 	return filename
 }
+
+var (
+	cHeaderEndsHere = []byte("\n// C HEADER ENDS HERE.\n\n")
+
+	wuffsBaseHeaderHStart = []byte("#ifndef WUFFS_BASE_HEADER_H\n")
+	wuffsBaseHeaderHEnd   = []byte("#endif  // WUFFS_BASE_HEADER_H\n")
+	wuffsBaseImplHStart   = []byte("#ifndef WUFFS_BASE_IMPL_H\n")
+	wuffsBaseImplHEnd     = []byte("#endif  // WUFFS_BASE_IMPL_H\n")
+
+	threeNewLines = []byte("\n\n\n")
+
+	beginUsePrefix = []byte("// ---------------- BEGIN USE ")
+	endUsePrefix   = []byte("// ---------------- END   USE ")
+)
+
+// amalgamatedPackage is a package's generated C code, split into its header
+// and implementation, minus their copies of the base header and
+// implementation.
+type amalgamatedPackage struct {
+	filename string
+	hdr      []byte
+	impl     []byte
+}
+
+// genAmalgamation writes a single wuffs.h file to outDir. It holds the base
+// header and every package's header and, behind a WUFFS_IMPLEMENTATION
+// #ifdef, the base implementation and every package's implementation. The
+// packages used by filenames are also included, each package comes after the
+// packages it uses, and the copies of used packages' headers that wuffs-c
+// inlines into each header are dropped.
+func genAmalgamation(outDir string, inDir string, filenames []string) error {
+	filenames, err := packageUses{}.closure(inDir, ".c", filenames)
+	if err != nil {
+		return fmt.Errorf("genlib: %v", err)
+	}
+
+	baseHeader, baseImpl := []byte(nil), []byte(nil)
+	sorted := []*amalgamatedPackage(nil)
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filepath.Join(inDir, filepath.FromSlash(filename)+".c"))
+		if err != nil {
+			return err
+		}
+		p := &amalgamatedPackage{filename: filename}
+		i := bytes.Index(src, cHeaderEndsHere)
+		if i < 0 {
+			return fmt.Errorf("genlib: %s.c did not contain %q", filename, cHeaderEndsHere)
+		}
+		p.hdr, p.impl = src[:i+1], src[i+len(cHeaderEndsHere):]
+
+		x, y := []byte(nil), []byte(nil)
+		if p.hdr, x, err = cutSection(p.hdr, wuffsBaseHeaderHStart, wuffsBaseHeaderHEnd); err != nil {
+			return fmt.Errorf("genlib: %s.c: %v", filename, err)
+		}
+		if p.impl, y, err = cutSection(p.impl, wuffsBaseImplHStart, wuffsBaseImplHEnd); err != nil {
+			return fmt.Errorf("genlib: %s.c: %v", filename, err)
+		}
+		if baseHeader == nil {
+			baseHeader, baseImpl = x, y
+		}
+		if p.hdr, _, err = cutUses(p.hdr); err != nil {
+			return fmt.Errorf("genlib: %s.c: %v", filename, err)
+		}
+		sorted = append(sorted, p)
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("#ifndef WUFFS_H\n#define WUFFS_H\n\n")
+	out.WriteString("// Code generated by \"wuffs-c genlib -amalgamate\". DO NOT EDIT.\n//\n")
+	out.WriteString("// This file holds the following packages:\n")
+	for _, p := range sorted {
+		fmt.Fprintf(out, "//  - %s\n", p.filename)
+	}
+	out.WriteString("//\n")
+	out.WriteString("// Include it like any other header file. In exactly one C file, also\n")
+	out.WriteString("// #define WUFFS_IMPLEMENTATION before the #include, to compile the\n")
+	out.WriteString("// implementations.\n\n")
+	out.Write(baseHeader)
+	out.WriteString("\n")
+	for _, p := range sorted {
+		out.Write(p.hdr)
+		out.WriteString("\n")
+	}
+	out.WriteString("#ifdef WUFFS_IMPLEMENTATION\n\n")
+	out.Write(baseImpl)
+	out.WriteString("\n")
+	for _, p := range sorted {
+		out.Write(p.impl)
+	}
+	out.WriteString("#endif  // WUFFS_IMPLEMENTATION\n\n")
+	out.WriteString("#endif  // WUFFS_H\n")
+
+	// Cutting sections can leave runs of blank lines.
+	amalgamation := out.Bytes()
+	for bytes.Contains(amalgamation, threeNewLines) {
+		amalgamation = bytes.Replace(amalgamation, threeNewLines, threeNewLines[1:], -1)
+	}
+
+	outFilename := filepath.Join(outDir, "wuffs.h")
+	if err := ioutil.WriteFile(outFilename, amalgamation, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("genlib: %s\n", outFilename)
+	return nil
+}
+
+// cutSection returns src without its first section that starts with start and
+// ends with end, and that section.
+func cutSection(src []byte, start []byte, end []byte) (rest []byte, section []byte, err error) {
+	i := bytes.Index(src, start)
+	if i < 0 {
+		return nil, nil, fmt.Errorf("missing %q", start)
+	}
+	j := bytes.Index(src[i:], end)
+	if j < 0 {
+		return nil, nil, fmt.Errorf("missing %q", end)
+	}
+	j += i + len(end)
+	rest = append(append([]byte(nil), src[:i]...), src[j:]...)
+	return rest, src[i:j], nil
+}
+
+// cutUses returns hdr without the used packages' headers that wuffs-c inlines
+// between "BEGIN USE" and "END USE" lines, and those packages' names.
+func cutUses(hdr []byte) (rest []byte, uses []string, err error) {
+	for {
+		i := bytes.Index(hdr, beginUsePrefix)
+		if i < 0 {
+			return hdr, uses, nil
+		}
+		n := bytes.IndexByte(hdr[i:], '\n')
+		if n < 0 {
+			return nil, nil, fmt.Errorf("bad %q line", beginUsePrefix)
+		}
+		quoted := string(hdr[i+len(beginUsePrefix) : i+n])
+		use, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad %q line: %v", beginUsePrefix, err)
+		}
+		endLine := append(append([]byte(nil), endUsePrefix...), quoted+"\n"...)
+		if hdr, _, err = cutSection(hdr, hdr[i:i+n+1], endLine); err != nil {
+			return nil, nil, err
+		}
+		uses = append(uses, use)
+	}
+}
+
+// packageUses maps packages, such as "std/gzip", to the packages that they
+// directly use, such as "std/crc32" and "std/deflate".
+type packageUses map[string][]string
+
+// closure returns filenames and every package that they transitively use,
+// each one after the packages that it uses. Packages' uses are read, and
+// recorded in m, from their generated C code (if ext is ".c") or C header (if
+// ext is ".h") in inDir.
+func (m packageUses) closure(inDir string, ext string, filenames []string) ([]string, error) {
+	sorted := []string(nil)
+	visited := map[string]bool{}
+	var visit func(filename string) error
+	visit = func(filename string) error {
+		if visited[filename] {
+			return nil
+		}
+		visited[filename] = true
+		uses, ok := m[filename]
+		if !ok {
+			src, err := ioutil.ReadFile(filepath.Join(inDir, filepath.FromSlash(filename)+ext))
+			if err != nil {
+				return err
+			}
+			if i := bytes.Index(src, cHeaderEndsHere); i >= 0 {
+				src = src[:i]
+			}
+			if _, uses, err = cutUses(src); err != nil {
+				return fmt.Errorf("%s%s: %v", filename, ext, err)
+			}
+			m[filename] = uses
+		}
+		for _, u := range uses {
+			if err := visit(u); err != nil {
+				return err
+			}
+		}
+		sorted = append(sorted, filename)
+		return nil
+	}
+	for _, filename := range filenames {
+		if err := visit(filename); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
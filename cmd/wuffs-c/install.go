@@ -0,0 +1,282 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+func doInstall(args []string) error {
+	flags := flag.FlagSet{}
+	ccompilerFlag := flags.String("ccompiler", cf.CcompilerDefault, cf.CcompilerUsage)
+	hdrdirFlag := flags.String("hdrdir", "", "directory containing the C header files")
+	libdirFlag := flags.String("libdir", "", "directory containing the genlib output")
+	prefixFlag := flags.String("prefix", cf.PrefixDefault, cf.PrefixUsage)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	if *hdrdirFlag == "" {
+		return fmt.Errorf("empty -hdrdir flag")
+	}
+	if *libdirFlag == "" {
+		return fmt.Errorf("empty -libdir flag")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no packages given")
+	}
+	prefix, err := filepath.Abs(*prefixFlag)
+	if err != nil {
+		return err
+	}
+	cc := *ccompilerFlag
+
+	// Also install the packages that the given packages use, so that every
+	// installed package's uses are installed too, and check the CMake target
+	// names, all before writing anything, so that an error does not leave a
+	// half-populated prefix.
+	uses := packageUses{}
+	args, err = uses.closure(*hdrdirFlag, ".h", args)
+	if err != nil {
+		return fmt.Errorf("install: %v", err)
+	}
+	if _, err := cmakeTargets(args); err != nil {
+		return err
+	}
+	v, err := readVersion(filepath.Join(*hdrdirFlag, filepath.FromSlash(args[0])+".h"))
+	if err != nil {
+		return err
+	}
+
+	// Install the headers, as e.g. "include/wuffs/std/gzip.h".
+	for _, filename := range args {
+		for _, ext := range []string{".h", ".hpp"} {
+			src := filepath.Join(*hdrdirFlag, filepath.FromSlash(filename)+ext)
+			dst := filepath.Join(prefix, "include", "wuffs", filepath.FromSlash(filename)+ext)
+			if ext == ".hpp" {
+				// The C++ header is optional, only generated by -langs=c,cpp.
+				if _, err := os.Stat(src); os.IsNotExist(err) {
+					continue
+				}
+			}
+			if _, err := installFile(dst, src); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Install the libraries: libwuffs, holding every package, and one library
+	// per package, such as libwuffs-std-gzip.
+	libDir := filepath.Join(prefix, "lib")
+	if err := installLib(libDir, *libdirFlag, cc, v, "libwuffs"); err != nil {
+		return err
+	}
+	for _, filename := range args {
+		if err := installLib(libDir, *libdirFlag, cc, v, pkgLibName(filename)); err != nil {
+			return err
+		}
+	}
+
+	if err := installPkgConfig(prefix, v, args, uses); err != nil {
+		return err
+	}
+	return installCMake(prefix, v, args, uses)
+}
+
+// installLib installs the static and shared library lib, built by genlib in
+// libdir, and the shared library's symlinks.
+func installLib(dstDir string, libdir string, cc string, v version, lib string) error {
+	if _, err := installFile(filepath.Join(dstDir, lib+libExtensions["static"]),
+		filepath.Join(libdir, cc+"-static", lib+libExtensions["static"])); err != nil {
+		return err
+	}
+	if _, err := installFile(filepath.Join(dstDir, v.realname(lib)),
+		filepath.Join(libdir, cc+"-dynamic", v.realname(lib))); err != nil {
+		return err
+	}
+	return symlinkLib(dstDir, lib, v)
+}
+
+// installFile copies src to dst, creating dst's directory if necessary, and
+// returns the file contents.
+func installFile(dst string, src string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeInstalledFile(dst, contents); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+func writeInstalledFile(dst string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(dst, contents, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("install: %s\n", dst)
+	return nil
+}
+
+// installPkgConfig writes a wuffs.pc pkg-config file for libwuffs and, per
+// package, a file such as wuffs-std-gzip.pc for libwuffs-std-gzip, which
+// requires the files for the packages that it uses.
+func installPkgConfig(prefix string, v version, filenames []string, uses packageUses) error {
+	pcDir := filepath.Join(prefix, "lib", "pkgconfig")
+	if err := writePkgConfig(pcDir, prefix, v, "libwuffs",
+		"Wrangling Untrusted File Formats Safely", nil); err != nil {
+		return err
+	}
+	for _, filename := range filenames {
+		requires := []string(nil)
+		for _, u := range uses[filename] {
+			requires = append(requires, strings.TrimPrefix(pkgLibName(u), "lib"))
+		}
+		if err := writePkgConfig(pcDir, prefix, v, pkgLibName(filename),
+			fmt.Sprintf("Wuffs's %s package", filename), requires); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePkgConfig(pcDir string, prefix string, v version, lib string, description string, requires []string) error {
+	name := strings.TrimPrefix(lib, "lib")
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# Generated by \"wuffs install\". DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "prefix=%s\n", prefix)
+	fmt.Fprintf(b, "includedir=${prefix}/include\n")
+	fmt.Fprintf(b, "libdir=${prefix}/lib\n\n")
+	fmt.Fprintf(b, "Name: %s\n", name)
+	fmt.Fprintf(b, "Description: %s\n", description)
+	fmt.Fprintf(b, "Version: %v\n", v)
+	if len(requires) > 0 {
+		fmt.Fprintf(b, "Requires: %s\n", strings.Join(requires, ", "))
+	}
+	fmt.Fprintf(b, "Cflags: -I${includedir}\n")
+	fmt.Fprintf(b, "Libs: -L${libdir} -l%s\n", name)
+	return writeInstalledFile(filepath.Join(pcDir, name+".pc"), b.Bytes())
+}
+
+// installCMake writes a CMake package configuration. It has a Wuffs::wuffs
+// imported target for the shared libwuffs library, Wuffs::wuffs_static for the
+// static one and, per package, targets such as Wuffs::gzip and
+// Wuffs::gzip_static for the libwuffs-std-gzip libraries, which link the
+// targets for the packages that they use.
+//
+// Every package that filenames use must also be in filenames, each after the
+// packages that it uses.
+func installCMake(prefix string, v version, filenames []string, uses packageUses) error {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# Generated by \"wuffs install\". DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "get_filename_component(_wuffs_prefix \"${CMAKE_CURRENT_LIST_DIR}/../../..\" ABSOLUTE)\n\n")
+	fmt.Fprintf(b, "if(NOT TARGET Wuffs::wuffs)\n")
+	fmt.Fprintf(b, "  add_library(Wuffs::wuffs SHARED IMPORTED)\n")
+	fmt.Fprintf(b, "  set_target_properties(Wuffs::wuffs PROPERTIES\n")
+	fmt.Fprintf(b, "    IMPORTED_LOCATION \"${_wuffs_prefix}/lib/%s\"\n", v.realname("libwuffs"))
+	fmt.Fprintf(b, "    IMPORTED_SONAME \"%s\"\n", v.soname("libwuffs"))
+	fmt.Fprintf(b, "    INTERFACE_INCLUDE_DIRECTORIES \"${_wuffs_prefix}/include\")\n\n")
+	fmt.Fprintf(b, "  add_library(Wuffs::wuffs_static STATIC IMPORTED)\n")
+	fmt.Fprintf(b, "  set_target_properties(Wuffs::wuffs_static PROPERTIES\n")
+	fmt.Fprintf(b, "    IMPORTED_LOCATION \"${_wuffs_prefix}/lib/libwuffs%s\"\n", libExtensions["static"])
+	fmt.Fprintf(b, "    INTERFACE_INCLUDE_DIRECTORIES \"${_wuffs_prefix}/include\")\n")
+	for _, filename := range filenames {
+		lib := pkgLibName(filename)
+		target := "Wuffs::" + path.Base(filename)
+		libs, staticLibs := []string(nil), []string(nil)
+		for _, u := range uses[filename] {
+			libs = append(libs, "Wuffs::"+path.Base(u))
+			staticLibs = append(staticLibs, "Wuffs::"+path.Base(u)+"_static")
+		}
+
+		fmt.Fprintf(b, "\n  # %s is the %q package, whose header is <wuffs/%s.h>.\n", target, filename, filename)
+		fmt.Fprintf(b, "  add_library(%s SHARED IMPORTED)\n", target)
+		fmt.Fprintf(b, "  set_target_properties(%s PROPERTIES\n", target)
+		fmt.Fprintf(b, "    IMPORTED_LOCATION \"${_wuffs_prefix}/lib/%s\"\n", v.realname(lib))
+		fmt.Fprintf(b, "    IMPORTED_SONAME \"%s\"\n", v.soname(lib))
+		if len(libs) > 0 {
+			fmt.Fprintf(b, "    INTERFACE_LINK_LIBRARIES \"%s\"\n", strings.Join(libs, ";"))
+		}
+		fmt.Fprintf(b, "    INTERFACE_INCLUDE_DIRECTORIES \"${_wuffs_prefix}/include\")\n\n")
+		fmt.Fprintf(b, "  add_library(%s_static STATIC IMPORTED)\n", target)
+		fmt.Fprintf(b, "  set_target_properties(%s_static PROPERTIES\n", target)
+		fmt.Fprintf(b, "    IMPORTED_LOCATION \"${_wuffs_prefix}/lib/%s%s\"\n", lib, libExtensions["static"])
+		if len(staticLibs) > 0 {
+			fmt.Fprintf(b, "    INTERFACE_LINK_LIBRARIES \"%s\"\n", strings.Join(staticLibs, ";"))
+		}
+		fmt.Fprintf(b, "    INTERFACE_INCLUDE_DIRECTORIES \"${_wuffs_prefix}/include\")\n")
+	}
+	fmt.Fprintf(b, "endif()\n\n")
+	fmt.Fprintf(b, "unset(_wuffs_prefix)\n")
+
+	cmakeDir := filepath.Join(prefix, "lib", "cmake", "Wuffs")
+	if err := writeInstalledFile(filepath.Join(cmakeDir, "WuffsConfig.cmake"), b.Bytes()); err != nil {
+		return err
+	}
+
+	// Before version 1.0, any minor version change can be incompatible.
+	b = &bytes.Buffer{}
+	fmt.Fprintf(b, "# Generated by \"wuffs install\". DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "set(PACKAGE_VERSION \"%v\")\n\n", v)
+	fmt.Fprintf(b, "if(PACKAGE_FIND_VERSION VERSION_GREATER PACKAGE_VERSION)\n")
+	fmt.Fprintf(b, "  set(PACKAGE_VERSION_COMPATIBLE FALSE)\n")
+	fmt.Fprintf(b, "elseif(NOT PACKAGE_FIND_VERSION_MAJOR EQUAL %d)\n", v.major)
+	fmt.Fprintf(b, "  set(PACKAGE_VERSION_COMPATIBLE FALSE)\n")
+	if v.major == 0 {
+		fmt.Fprintf(b, "elseif(NOT PACKAGE_FIND_VERSION_MINOR EQUAL %d)\n", v.minor)
+		fmt.Fprintf(b, "  set(PACKAGE_VERSION_COMPATIBLE FALSE)\n")
+	}
+	fmt.Fprintf(b, "else()\n")
+	fmt.Fprintf(b, "  set(PACKAGE_VERSION_COMPATIBLE TRUE)\n")
+	fmt.Fprintf(b, "  if(PACKAGE_FIND_VERSION VERSION_EQUAL PACKAGE_VERSION)\n")
+	fmt.Fprintf(b, "    set(PACKAGE_VERSION_EXACT TRUE)\n")
+	fmt.Fprintf(b, "  endif()\n")
+	fmt.Fprintf(b, "endif()\n")
+	return writeInstalledFile(filepath.Join(cmakeDir, "WuffsConfigVersion.cmake"), b.Bytes())
+}
+
+// cmakeTargets maps the CMake target names, such as "gzip" for Wuffs::gzip, to
+// the packages, such as "std/gzip". It returns an error if two packages would
+// have the same target name, or if a package's target name is taken by the
+// libwuffs targets.
+func cmakeTargets(filenames []string) (map[string]string, error) {
+	targets := map[string]string{}
+	for _, filename := range filenames {
+		base := path.Base(filename)
+		if base == "wuffs" {
+			return nil, fmt.Errorf("install: package %s maps to the reserved CMake target Wuffs::%s",
+				filename, base)
+		}
+		if other, ok := targets[base]; ok {
+			return nil, fmt.Errorf("install: packages %s and %s both map to the CMake target Wuffs::%s",
+				other, filename, base)
+		}
+		targets[base] = filename
+	}
+	return targets, nil
+}
@@ -17,9 +17,16 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
 #include <stdbool.h>
+#include <stddef.h>
 #include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
 #include <string.h>
+#endif
 
 // Wuffs requires a word size of at least 32 bits because it assumes that
 // converting a u32 to usize will never overflow. For example, the size of a
@@ -28,11 +35,22 @@
 //
 // Similarly, the word size is at most 64 bits because it assumes that
 // converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
 #if __WORDSIZE < 32
 #error "Wuffs requires a word size of at least 32 bits"
 #elif __WORDSIZE > 64
 #error "Wuffs requires a word size of at most 64 bits"
 #endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
 
 // WUFFS_VERSION is the major.minor version number as a uint32. The major
 // number is the high 16 bits. The minor number is the low 16 bits.
@@ -106,6 +124,66 @@ typedef struct {
 
 // ---------------- Images
 
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
 typedef struct {
   // Do not access the private_impl's fields directly. There is no API/ABI
   // compatibility or safety guarantee if you do so.
@@ -113,8 +191,11 @@ typedef struct {
     uint32_t flags;
     uint32_t w;
     uint32_t h;
-    // TODO: color model, including both packed RGBA and planar,
-    // chroma-subsampled YCbCr.
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
   } private_impl;
 } wuffs_base__image_config;
 
@@ -130,9 +211,22 @@ static inline bool wuffs_base__image_config__valid(
   if (!c || !(c->private_impl.flags & 1)) {
     return false;
   }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
   uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-  // TODO: handle things other than 1 byte per pixel.
-  return wh <= ((uint64_t)SIZE_MAX);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
 }
 
 static inline uint32_t wuffs_base__image_config__width(
@@ -145,31 +239,273 @@ static inline uint32_t wuffs_base__image_config__height(
   return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
-static inline size_t wuffs_base__image_config__pixbuf_size(
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
     wuffs_base__image_config* c) {
-  if (wuffs_base__image_config__valid(c)) {
-    uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
   }
-  return 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
 }
 
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
     uint32_t width,
     uint32_t height,
-    uint32_t TODO_color_model) {
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
   if (!c) {
     return;
   }
   c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
   c->private_impl.w = width;
   c->private_impl.h = height;
-  // TODO: color model.
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
 }
 
 #endif  // WUFFS_BASE_HEADER_H
@@ -98,6 +98,54 @@ typedef struct {
 // inline attribute to guide optimizations such as inlining, to avoid the
 // -Wunused-function warning, and we like to compile with -Wall -Werror.
 
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
 static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
   return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
 }
@@ -172,7 +220,8 @@ static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
 }
 
 // wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
-// length) where length is the minimum of dst.len and src.len.
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
 //
 // Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
 // slice) is valid and results in a no-op.
@@ -181,11 +230,86 @@ static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
     wuffs_base__slice_u8 src) {
   size_t length = dst.len < src.len ? dst.len : src.len;
   if (length > 0) {
-    memmove(dst.ptr, src.ptr, length);
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
   }
   return length;
 }
 
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x = (uint16_t)(((uint16_t)(r >> 3) << 11) |
+                                ((uint16_t)(g >> 2) << 5) |
+                                ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
 static inline uint32_t wuffs_base__writer1__copy_from_history32(
     uint8_t** ptr_ptr,
     uint8_t* start,  // May be NULL, meaning an unmarked writer1.
@@ -272,7 +396,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_reader32(
     n = rend - rptr;
   }
   if (n > 0) {
-    memmove(wptr, rptr, n);
+    wuffs_base__memmove(wptr, rptr, n);
     *ptr_wptr += n;
     *ptr_rptr += n;
   }
@@ -289,7 +413,7 @@ static inline uint64_t wuffs_base__writer1__copy_from_slice(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -309,7 +433,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_slice32(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -18,6 +18,7 @@ package cgen
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -32,6 +33,8 @@ import (
 	"github.com/google/wuffs/lang/check"
 	"github.com/google/wuffs/lang/generate"
 
+	cf "github.com/google/wuffs/cmd/commonflags"
+
 	a "github.com/google/wuffs/lang/ast"
 	t "github.com/google/wuffs/lang/token"
 )
@@ -60,19 +63,25 @@ const (
 //
 // The generated program is written to stdout.
 func Do(args []string) error {
-	return generate.Do(args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
-		g := &gen{
-			PKGPREFIX: "WUFFS_" + strings.ToUpper(pkgName) + "__",
-			pkgPrefix: "wuffs_" + pkgName + "__",
-			pkgName:   pkgName,
-			tm:        tm,
-			checker:   c,
-			files:     files,
+	flags := flag.FlagSet{}
+	dceReportFlag := flags.Bool("dce_report", cf.DCEReportDefault, cf.DCEReportUsage)
+	entryFlag := flags.String("entry", cf.EntryDefault, cf.EntryUsage)
+	freestandingFlag := flags.Bool("freestanding", cf.FreestandingDefault, cf.FreestandingUsage)
+	return generate.Do(&flags, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		g := newGen(pkgName, tm, c, files)
+		g.freestanding = *freestandingFlag
+		if *entryFlag != "" {
+			g.entries = strings.Split(*entryFlag, ",")
 		}
 		unformatted, err := g.generate()
 		if err != nil {
 			return nil, err
 		}
+		if *dceReportFlag {
+			if err := g.writeDCEReport(os.Stderr); err != nil {
+				return nil, err
+			}
+		}
 		stdout := &bytes.Buffer{}
 		cmd := exec.Command("clang-format-5.0", "-style=Chromium")
 		cmd.Stdin = bytes.NewReader(unformatted)
@@ -85,6 +94,17 @@ func Do(args []string) error {
 	})
 }
 
+func newGen(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) *gen {
+	return &gen{
+		PKGPREFIX: "WUFFS_" + strings.ToUpper(pkgName) + "__",
+		pkgPrefix: "wuffs_" + pkgName + "__",
+		pkgName:   pkgName,
+		tm:        tm,
+		checker:   c,
+		files:     files,
+	}
+}
+
 const (
 	maxNamespacedStatusCode  = 255
 	statusCodeNamespaceMask  = 1<<base38.MaxBits - 1
@@ -171,6 +191,15 @@ type gen struct {
 	usesList   []string
 	usesMap    map[string]struct{}
 
+	// entries are the -entry flag's function names, or nil for every public
+	// function. reachable are the functions reachable from those entries.
+	entries   []string
+	reachable map[*a.Func]bool
+
+	// freestanding is whether the generated code defines
+	// WUFFS_CONFIG__FREESTANDING, so that it needs no C library.
+	freestanding bool
+
 	currFunk  funk
 	funks     map[t.QQID]funk
 	wuffsRoot string
@@ -203,6 +232,9 @@ func (g *gen) generate() ([]byte, error) {
 		g.structMap[n.QID()] = n
 	}
 
+	if err := g.findReachableFuncs(); err != nil {
+		return nil, err
+	}
 	g.funks = map[t.QQID]funk{}
 	if err := g.forEachFunc(nil, bothPubPri, (*gen).gatherFuncImpl); err != nil {
 		return nil, err
@@ -223,6 +255,9 @@ func (g *gen) genHeader(b *buffer) error {
 	b.printf("#ifndef %s\n#define %s\n\n", includeGuard, includeGuard)
 
 	b.printf("// Code generated by wuffs-c. DO NOT EDIT.\n\n")
+	if g.freestanding {
+		b.writes("#ifndef WUFFS_CONFIG__FREESTANDING\n#define WUFFS_CONFIG__FREESTANDING\n#endif\n\n")
+	}
 	b.writes(baseHeader)
 	b.writeb('\n')
 
@@ -391,7 +426,8 @@ func (g *gen) forEachFunc(b *buffer, v visibility, f func(*gen, *buffer, *a.Func
 		for _, tld := range file.TopLevelDecls() {
 			if tld.Kind() != a.KFunc ||
 				(v == pubOnly && tld.Raw().Flags()&a.FlagsPublic == 0) ||
-				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) {
+				(v == priOnly && tld.Raw().Flags()&a.FlagsPublic != 0) ||
+				g.checker.CompileTimeOnly(tld.Func()) || !g.reachable[tld.Func()] {
 				continue
 			}
 			if err := f(g, b, tld.Func()); err != nil {
@@ -558,33 +594,19 @@ func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
 
 	if n.Suspendible() {
 		b.writeb('\n')
-		for _, file := range g.files {
-			for _, tld := range file.TopLevelDecls() {
-				if tld.Kind() != a.KFunc {
-					continue
+		for _, o := range g.coroFrameFuncs(n) {
+			k := g.funks[o.QQID()]
+			b.writes("struct {\n")
+			if k.coroSuspPoint != 0 {
+				b.writes("uint32_t coro_susp_point;\n")
+				if err := g.writeVars(b, o.Body(), true, true); err != nil {
+					return err
 				}
-				o := tld.Func()
-				if o.Receiver() != n.QID() || !o.Suspendible() {
-					continue
-				}
-				k := g.funks[o.QQID()]
-				if k.coroSuspPoint == 0 && !k.usesScratch {
-					continue
-				}
-				// TODO: allow max depth > 1 for recursive coroutines.
-				const maxDepth = 1
-				b.writes("struct {\n")
-				if k.coroSuspPoint != 0 {
-					b.writes("uint32_t coro_susp_point;\n")
-					if err := g.writeVars(b, o.Body(), true, true); err != nil {
-						return err
-					}
-				}
-				if k.usesScratch {
-					b.writes("uint64_t scratch;\n")
-				}
-				b.printf("} %s%s[%d];\n", cPrefix, o.FuncName().Str(g.tm), maxDepth)
 			}
+			if k.usesScratch {
+				b.writes("uint64_t scratch;\n")
+			}
+			b.printf("} %s%s[%d];\n", cPrefix, o.FuncName().Str(g.tm), g.checker.RecursionDepth(o))
 		}
 	}
 
@@ -592,6 +614,28 @@ func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
 	return nil
 }
 
+// coroFrameFuncs returns the methods of n that need coroutine state, saved
+// across suspensions, in n's private_impl.
+func (g *gen) coroFrameFuncs(n *a.Struct) []*a.Func {
+	funcs := []*a.Func(nil)
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc {
+				continue
+			}
+			o := tld.Func()
+			if o.Receiver() != n.QID() || !o.Suspendible() {
+				continue
+			}
+			if k := g.funks[o.QQID()]; k.coroSuspPoint == 0 && !k.usesScratch {
+				continue
+			}
+			funcs = append(funcs, o)
+		}
+	}
+	return funcs
+}
+
 var (
 	wuffsBaseHeaderHStart = []byte("#ifndef WUFFS_BASE_HEADER_H\n")
 	wuffsBaseHeaderHEnd   = []byte("#endif  // WUFFS_BASE_HEADER_H\n")
@@ -688,28 +732,54 @@ func (g *gen) writeInitializerImpl(b *buffer, n *a.Struct) error {
 	b.printf("}\n")
 
 	b.writes("if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {" +
-		"memset(self, 0, sizeof(*self)); }\n")
+		"wuffs_base__memset(self, 0, sizeof(*self)); }\n")
 	b.writes("self->private_impl.magic = WUFFS_BASE__MAGIC;\n")
 
 	for _, f := range n.Fields() {
 		f := f.Field()
-		if dv := f.DefaultValue(); dv != nil {
-			// TODO: set default values for array types.
+		dv := f.DefaultValue()
+		if dv == nil {
+			continue
+		}
+		x := f.XType()
+		if x.Decorator() == 0 {
 			b.printf("self->private_impl.%s%s = %d;\n", fPrefix, f.Name().Str(g.tm), dv.ConstValue())
+			continue
+		}
+
+		if dv.Operator().Key() == t.KeyDollar {
+			// The checker has verified that dv's length matches the field's
+			// (one-dimensional) array type.
+			b.writes("{\nstatic const ")
+			if err := g.writeCTypeName(b, x, "", "defaults"); err != nil {
+				return err
+			}
+			b.writes(" = {")
+			for i, o := range dv.Args() {
+				if i != 0 {
+					b.writes(", ")
+				}
+				b.printf("%v", o.Expr().ConstValue())
+			}
+			b.writes("};\n")
+			b.printf("wuffs_base__memcpy(self->private_impl.%s%s, defaults, sizeof(defaults));\n}\n",
+				fPrefix, f.Name().Str(g.tm))
+			continue
 		}
+
+		lhs, loops := g.writeArrayLoops(b, "self->private_impl."+fPrefix+f.Name().Str(g.tm), x)
+		b.printf("%s = %d;\n", lhs, dv.ConstValue())
+		b.writes(strings.Repeat("}\n", loops))
 	}
 
-	// Call any ctors on sub-structs.
+	// Call any ctors on sub-structs, including on each element of arrays of
+	// sub-structs.
 	for _, f := range n.Fields() {
 		f := f.Field()
 		x := f.XType()
-		if x != x.Innermost() {
-			// TODO: arrays of sub-structs.
-			continue
-		}
 
 		prefix := g.pkgPrefix
-		qid := x.QID()
+		qid := x.Innermost().QID()
 		if qid[0] != 0 {
 			// See gen.writeCTypeName for a related TODO with otherPkg.
 			otherPkg := g.tm.ByID(qid[0])
@@ -719,10 +789,27 @@ func (g *gen) writeInitializerImpl(b *buffer, n *a.Struct) error {
 			continue
 		}
 
-		b.printf("%s%s__initialize(&self->private_impl.%s%s, WUFFS_VERSION, WUFFS_BASE__ALREADY_ZEROED);\n",
-			prefix, qid[1].Str(g.tm), fPrefix, f.Name().Str(g.tm))
+		lhs, loops := g.writeArrayLoops(b, "self->private_impl."+fPrefix+f.Name().Str(g.tm), x)
+		b.printf("%s%s__initialize(&%s, WUFFS_VERSION, WUFFS_BASE__ALREADY_ZEROED);\n",
+			prefix, qid[1].Str(g.tm), lhs)
+		b.writes(strings.Repeat("}\n", loops))
 	}
 
 	b.writes("}\n\n")
 	return nil
 }
+
+// writeArrayLoops writes the opening of nested C for loops that visit every
+// element of the array typed lhs, one loop per array dimension of typ. It
+// returns the C expression for each element and the number of loops (and
+// hence closing braces) written.
+func (g *gen) writeArrayLoops(b *buffer, lhs string, typ *a.TypeExpr) (string, int) {
+	loops := 0
+	for ; typ.Decorator().Key() == t.KeyOpenBracket; typ = typ.Inner() {
+		b.printf("{\nsize_t i%d;\nfor (i%d = 0; i%d < %v; i%d++) {\n",
+			loops, loops, loops, typ.ArrayLength().ConstValue(), loops)
+		lhs = fmt.Sprintf("%s[i%d]", lhs, loops)
+		loops++
+	}
+	return lhs, 2 * loops
+}
@@ -17,12 +17,25 @@
 package cgen
 
 const baseHeader = "" +
-	"#ifndef WUFFS_BASE_HEADER_H\n#define WUFFS_BASE_HEADER_H\n\n// Copyright 2017 The Wuffs Authors.\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//    https://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\n#include <stdbool.h>\n#include <stdint.h>\n#include <string.h>\n\n// Wuffs requires a word size of at least 32 bits because it assumes that\n// converting a u32 to usize will never overflow. For example, the size of a\n// decoded image is often represented, explicitly or implicitly in an image\n// file, as a u32, and it is convenient to compare that to a buffer size.\n//\n// Si" +
-	"milarly, the word size is at most 64 bits because it assumes that\n// converting a usize to u64 will never overflow.\n#if __WORDSIZE < 32\n#error \"Wuffs requires a word size of at least 32 bits\"\n#elif __WORDSIZE > 64\n#error \"Wuffs requires a word size of at most 64 bits\"\n#endif\n\n// WUFFS_VERSION is the major.minor version number as a uint32. The major\n// number is the high 16 bits. The minor number is the low 16 bits.\n//\n// The intention is to bump the version number at least on every API / ABI\n// backwards incompatible change.\n//\n// For now, the API and ABI are simply unstable and can change at any time.\n//\n// TODO: don't hard code this in base-header.h.\n#define WUFFS_VERSION (0x00001)\n\n// ---------------- I/O\n\n// wuffs_base__slice_u8 is a 1-dimensional buffer (a pointer and length).\n//\n// A value with all fields NULL or zero is a valid, empty slice.\ntypedef struct {\n  uint8_t* ptr;\n  size_t len;\n} wuffs_base__slice_u8;\n\n// wuffs_base__buf1 is a 1-dimensional buffer (a pointer and length), plus\n// additional in" +
-	"dexes into that buffer, plus an opened / closed flag.\n//\n// A value with all fields NULL or zero is a valid, empty buffer.\ntypedef struct {\n  uint8_t* ptr;  // Pointer.\n  size_t len;    // Length.\n  size_t wi;     // Write index. Invariant: wi <= len.\n  size_t ri;     // Read  index. Invariant: ri <= wi.\n  bool closed;   // No further writes are expected.\n} wuffs_base__buf1;\n\n// wuffs_base__limit1 provides a limited view of a 1-dimensional byte stream:\n// its first N bytes. That N can be greater than a buffer's current read or\n// write capacity. N decreases naturally over time as bytes are read from or\n// written to the stream.\n//\n// A value with all fields NULL or zero is a valid, unlimited view.\ntypedef struct wuffs_base__limit1 {\n  uint64_t* ptr_to_len;             // Pointer to N.\n  struct wuffs_base__limit1* next;  // Linked list of limits.\n} wuffs_base__limit1;\n\ntypedef struct {\n  // TODO: move buf into private_impl? As it is, it looks like users can modify\n  // the buf field to point to a different buf" +
-	"fer, which can turn the limit and\n  // mark fields into dangling pointers.\n  wuffs_base__buf1* buf;\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__limit1 limit;\n    uint8_t* mark;\n  } private_impl;\n} wuffs_base__reader1;\n\ntypedef struct {\n  // TODO: move buf into private_impl? As it is, it looks like users can modify\n  // the buf field to point to a different buffer, which can turn the limit and\n  // mark fields into dangling pointers.\n  wuffs_base__buf1* buf;\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__limit1 limit;\n    uint8_t* mark;\n  } private_impl;\n} wuffs_base__writer1;\n\n// ---------------- Images\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    uint32_t flags;\n    uint32_t w;\n " +
-	"   uint32_t h;\n    // TODO: color model, including both packed RGBA and planar,\n    // chroma-subsampled YCbCr.\n  } private_impl;\n} wuffs_base__image_config;\n\nstatic inline void wuffs_base__image_config__invalidate(\n    wuffs_base__image_config* c) {\n  if (c) {\n    *c = ((wuffs_base__image_config){});\n  }\n}\n\nstatic inline bool wuffs_base__image_config__valid(\n    wuffs_base__image_config* c) {\n  if (!c || !(c->private_impl.flags & 1)) {\n    return false;\n  }\n  uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);\n  // TODO: handle things other than 1 byte per pixel.\n  return wh <= ((uint64_t)SIZE_MAX);\n}\n\nstatic inline uint32_t wuffs_base__image_config__width(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c) ? c->private_impl.w : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__height(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;\n}\n\n// TODO: this is the right API for planar (not packed) pixbuf" +
-	"s? Should it allow\n// decoding into a color model different from the format's intrinsic one? For\n// example, decoding a JPEG image straight to RGBA instead of to YCbCr?\nstatic inline size_t wuffs_base__image_config__pixbuf_size(\n    wuffs_base__image_config* c) {\n  if (wuffs_base__image_config__valid(c)) {\n    uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);\n    // TODO: handle things other than 1 byte per pixel.\n    return (size_t)wh;\n  }\n  return 0;\n}\n\nstatic inline void wuffs_base__image_config__initialize(\n    wuffs_base__image_config* c,\n    uint32_t width,\n    uint32_t height,\n    uint32_t TODO_color_model) {\n  if (!c) {\n    return;\n  }\n  c->private_impl.flags = 1;\n  c->private_impl.w = width;\n  c->private_impl.h = height;\n  // TODO: color model.\n}\n\n#endif  // WUFFS_BASE_HEADER_H\n" +
+	"#ifndef WUFFS_BASE_HEADER_H\n#define WUFFS_BASE_HEADER_H\n\n// Copyright 2017 The Wuffs Authors.\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//    https://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\n// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only\n// these three headers, which even a freestanding C implementation provides.\n// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and\n// memset.\n#include <stdbool.h>\n#include <stddef.h>\n#include <stdint.h>\n#ifndef WUFFS_CONFIG__FREESTANDING\n#include <string.h>\n#endif\n\n// Wuf" +
+	"fs requires a word size of at least 32 bits because it assumes that\n// converting a u32 to usize will never overflow. For example, the size of a\n// decoded image is often represented, explicitly or implicitly in an image\n// file, as a u32, and it is convenient to compare that to a buffer size.\n//\n// Similarly, the word size is at most 64 bits because it assumes that\n// converting a usize to u64 will never overflow.\n//\n// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does\n// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.\n#if defined(__WORDSIZE)\n#if __WORDSIZE < 32\n#error \"Wuffs requires a word size of at least 32 bits\"\n#elif __WORDSIZE > 64\n#error \"Wuffs requires a word size of at most 64 bits\"\n#endif\n#elif defined(__SIZEOF_SIZE_T__)\n#if __SIZEOF_SIZE_T__ < 4\n#error \"Wuffs requires a word size of at least 32 bits\"\n#elif __SIZEOF_SIZE_T__ > 8\n#error \"Wuffs requires a word size of at most 64 bits\"\n#endif\n#endif\n\n// WUFFS_VERSION is the major.minor version number as a uint" +
+	"32. The major\n// number is the high 16 bits. The minor number is the low 16 bits.\n//\n// The intention is to bump the version number at least on every API / ABI\n// backwards incompatible change.\n//\n// For now, the API and ABI are simply unstable and can change at any time.\n//\n// TODO: don't hard code this in base-header.h.\n#define WUFFS_VERSION (0x00001)\n\n// ---------------- I/O\n\n// wuffs_base__slice_u8 is a 1-dimensional buffer (a pointer and length).\n//\n// A value with all fields NULL or zero is a valid, empty slice.\ntypedef struct {\n  uint8_t* ptr;\n  size_t len;\n} wuffs_base__slice_u8;\n\n// wuffs_base__buf1 is a 1-dimensional buffer (a pointer and length), plus\n// additional indexes into that buffer, plus an opened / closed flag.\n//\n// A value with all fields NULL or zero is a valid, empty buffer.\ntypedef struct {\n  uint8_t* ptr;  // Pointer.\n  size_t len;    // Length.\n  size_t wi;     // Write index. Invariant: wi <= len.\n  size_t ri;     // Read  index. Invariant: ri <= wi.\n  bool closed;   // No further " +
+	"writes are expected.\n} wuffs_base__buf1;\n\n// wuffs_base__limit1 provides a limited view of a 1-dimensional byte stream:\n// its first N bytes. That N can be greater than a buffer's current read or\n// write capacity. N decreases naturally over time as bytes are read from or\n// written to the stream.\n//\n// A value with all fields NULL or zero is a valid, unlimited view.\ntypedef struct wuffs_base__limit1 {\n  uint64_t* ptr_to_len;             // Pointer to N.\n  struct wuffs_base__limit1* next;  // Linked list of limits.\n} wuffs_base__limit1;\n\ntypedef struct {\n  // TODO: move buf into private_impl? As it is, it looks like users can modify\n  // the buf field to point to a different buffer, which can turn the limit and\n  // mark fields into dangling pointers.\n  wuffs_base__buf1* buf;\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__limit1 limit;\n    uint8_t* mark;\n  } private_impl;\n} wuffs_base__reader1;\n\ntypedef" +
+	" struct {\n  // TODO: move buf into private_impl? As it is, it looks like users can modify\n  // the buf field to point to a different buffer, which can turn the limit and\n  // mark fields into dangling pointers.\n  wuffs_base__buf1* buf;\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    wuffs_base__limit1 limit;\n    uint8_t* mark;\n  } private_impl;\n} wuffs_base__writer1;\n\n// ---------------- Images\n\n// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in\n// memory. Most are packed, one pixel after another:\n//  - INDEXED is 1 byte per pixel, a palette index.\n//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.\n//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.\n//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits\n//    are R, middle 6 bits are G and low 5 bits are B.\n//  - Y is 1 byte per pixel, a luma (gray) value.\n//\n// Others are plan" +
+	"ar, one plane after another, each plane holding one byte per\n// sample, one sample after another:\n//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr\n//    planes may be chroma subsampled, as per the image config's sampling\n//    factors.\n#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0\n#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1\n#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2\n#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3\n#define WUFFS_BASE__PIXEL_FORMAT__Y 4\n#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5\n\n// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per\n// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For\n// planar pixel formats, it is the number of bytes per sample in each plane.\nstatic inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(\n    uint32_t pixel_format) {\n  switch (pixel_format) {\n    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:\n    case WUFFS_BASE__PIXEL_FORMAT__Y:\n    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:\n      return 1;\n    case WUFFS_BA" +
+	"SE__PIXEL_FORMAT__RGBA:\n    case WUFFS_BASE__PIXEL_FORMAT__BGRA:\n      return 4;\n    case WUFFS_BASE__PIXEL_FORMAT__RGB565:\n      return 2;\n  }\n  return 0;\n}\n\n// wuffs_base__pixel_format__num_planes returns the number of planes of a\n// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,\n// or 0 if it is not one.\nstatic inline uint32_t wuffs_base__pixel_format__num_planes(\n    uint32_t pixel_format) {\n  switch (pixel_format) {\n    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:\n    case WUFFS_BASE__PIXEL_FORMAT__RGBA:\n    case WUFFS_BASE__PIXEL_FORMAT__BGRA:\n    case WUFFS_BASE__PIXEL_FORMAT__RGB565:\n    case WUFFS_BASE__PIXEL_FORMAT__Y:\n      return 1;\n    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:\n      return 3;\n  }\n  return 0;\n}\n\n#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4\n\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    uint32_t flags;\n    uint32_t w;\n    uint32_t h;\n    uint32_t pix" +
+	"fmt;\n    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and\n    // vertical sampling factors, each in the range [1, 4]. A plane whose\n    // factors are the maximum over all planes has one sample per pixel.\n    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];\n  } private_impl;\n} wuffs_base__image_config;\n\nstatic inline void wuffs_base__image_config__invalidate(\n    wuffs_base__image_config* c) {\n  if (c) {\n    *c = ((wuffs_base__image_config){});\n  }\n}\n\nstatic inline bool wuffs_base__image_config__valid(\n    wuffs_base__image_config* c) {\n  if (!c || !(c->private_impl.flags & 1)) {\n    return false;\n  }\n  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);\n  uint32_t p;\n  for (p = 0; p < n; p++) {\n    uint32_t h = c->private_impl.sampling[p] >> 4;\n    uint32_t v = c->private_impl.sampling[p] & 15;\n    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {\n      return false;\n    }\n  }\n  uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);\n  " +
+	"uint64_t bpp =\n      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);\n  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4\n  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a\n  // uint64_t.\n  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));\n}\n\nstatic inline uint32_t wuffs_base__image_config__width(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c) ? c->private_impl.w : 0;\n}\n\nstatic inline uint32_t wuffs_base__image_config__height(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;\n}\n\n// wuffs_base__image_config__pixel_format returns the\n// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.\nstatic inline uint32_t wuffs_base__image_config__pixel_format(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;\n}\n\n// wuffs_base__image_config__num_planes returns the number of planes in th" +
+	"e\n// pixbuf, which is 1 for packed pixel formats.\nstatic inline uint32_t wuffs_base__image_config__num_planes(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__valid(c)\n             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)\n             : 0;\n}\n\n// wuffs_base__image_config__plane_width returns the width, in samples, of the\n// p'th plane. A chroma subsampled plane's width is the image's width times the\n// plane's horizontal sampling factor divided by the maximum horizontal\n// sampling factor, rounded up. It returns 0 if there is no such plane.\nstatic inline uint32_t wuffs_base__image_config__plane_width(\n    wuffs_base__image_config* c,\n    uint32_t p) {\n  if (p >= wuffs_base__image_config__num_planes(c)) {\n    return 0;\n  }\n  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);\n  uint32_t max = 1;\n  uint32_t i;\n  for (i = 0; i < n; i++) {\n    uint32_t h = c->private_impl.sampling[i] >> 4;\n    max = (max > h) ? max : h;\n  }\n  uint64_t h = c->privat" +
+	"e_impl.sampling[p] >> 4;\n  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);\n}\n\n// wuffs_base__image_config__plane_height is like\n// wuffs_base__image_config__plane_width, but for the vertical dimension.\nstatic inline uint32_t wuffs_base__image_config__plane_height(\n    wuffs_base__image_config* c,\n    uint32_t p) {\n  if (p >= wuffs_base__image_config__num_planes(c)) {\n    return 0;\n  }\n  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);\n  uint32_t max = 1;\n  uint32_t i;\n  for (i = 0; i < n; i++) {\n    uint32_t v = c->private_impl.sampling[i] & 15;\n    max = (max > v) ? max : v;\n  }\n  uint64_t v = c->private_impl.sampling[p] & 15;\n  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);\n}\n\n// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the\n// p'th plane in the pixbuf. The planes are consecutive, with no padding, and\n// each plane's rows are consecutive, with no padding.\nstatic inline size_t wuffs_base__image_config__plane_offset(\n    " +
+	"wuffs_base__image_config* c,\n    uint32_t p) {\n  uint32_t n = wuffs_base__image_config__num_planes(c);\n  if (p > n) {\n    return 0;\n  }\n  uint64_t bpp =\n      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);\n  uint64_t offset = 0;\n  uint32_t i;\n  for (i = 0; i < p; i++) {\n    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *\n              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;\n  }\n  return (size_t)offset;\n}\n\n// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the\n// pixbuf, summed over all of its planes.\nstatic inline size_t wuffs_base__image_config__pixbuf_size(\n    wuffs_base__image_config* c) {\n  return wuffs_base__image_config__plane_offset(\n      c, wuffs_base__image_config__num_planes(c));\n}\n\n// wuffs_base__image_config__initialize sets the image config. An unknown\n// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives\n// an invalid image config. Every plane is given sampling factors of 1, so that\n// " +
+	"no plane is subsampled.\nstatic inline void wuffs_base__image_config__initialize(\n    wuffs_base__image_config* c,\n    uint32_t width,\n    uint32_t height,\n    uint32_t pixel_format) {\n  if (!c) {\n    return;\n  }\n  c->private_impl.flags = 1;\n  c->private_impl.w = width;\n  c->private_impl.h = height;\n  c->private_impl.pixfmt = pixel_format;\n  int i;\n  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {\n    c->private_impl.sampling[i] = 0x11;\n  }\n}\n\n// wuffs_base__image_config__initialize_planar is like\n// wuffs_base__image_config__initialize, but also sets the planes' sampling\n// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from\n// the least significant bit, are the p'th plane's factors, arranged like a\n// JPEG SOF marker's component sampling factors: the high 4 bits are the\n// horizontal factor and the low 4 bits are the vertical factor. Factors\n// outside the range [1, 4] give an invalid image config.\nstatic inline void wuffs_base__image_config__initialize_planar(\n    wuffs_ba" +
+	"se__image_config* c,\n    uint32_t width,\n    uint32_t height,\n    uint32_t pixel_format,\n    uint32_t sampling) {\n  if (!c) {\n    return;\n  }\n  wuffs_base__image_config__initialize(c, width, height, pixel_format);\n  int i;\n  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {\n    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));\n  }\n}\n\n// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation\n// frame's pixels, after showing the frame and before showing the next one:\n//  - NONE means to leave them in place, so that the next frame is drawn on\n//    top of them.\n//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.\n//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before\n//    the frame was drawn.\n#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0\n#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1\n#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2\n\n// wuffs_base__frame_config is the configuration of " +
+	"one frame of a (possibly\n// animated) image: its rect within the image, how long to show it and how to\n// dispose of it, and its palette of 256 (R, G, B) entries.\ntypedef struct {\n  // Do not access the private_impl's fields directly. There is no API/ABI\n  // compatibility or safety guarantee if you do so.\n  struct {\n    uint32_t flags;\n    uint32_t x;\n    uint32_t y;\n    uint32_t w;\n    uint32_t h;\n    uint32_t delay_ms;\n    uint8_t disposal;\n    uint8_t transparent_index;\n    uint8_t palette[3 * 256];\n  } private_impl;\n} wuffs_base__frame_config;\n\nstatic inline void wuffs_base__frame_config__invalidate(\n    wuffs_base__frame_config* c) {\n  if (c) {\n    *c = ((wuffs_base__frame_config){});\n  }\n}\n\nstatic inline bool wuffs_base__frame_config__valid(\n    wuffs_base__frame_config* c) {\n  return c && (c->private_impl.flags & 1);\n}\n\nstatic inline uint32_t wuffs_base__frame_config__x(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;\n}\n\nstatic inline uint32_t wu" +
+	"ffs_base__frame_config__y(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;\n}\n\nstatic inline uint32_t wuffs_base__frame_config__width(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;\n}\n\nstatic inline uint32_t wuffs_base__frame_config__height(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;\n}\n\n// wuffs_base__frame_config__delay_ms returns how long to show the frame for,\n// in milliseconds, before showing the next frame.\nstatic inline uint32_t wuffs_base__frame_config__delay_ms(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;\n}\n\n// wuffs_base__frame_config__disposal returns one of the\n// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.\nstatic inline uint8_t wuffs_base__frame_config__disposal(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) ? c->private_imp" +
+	"l.disposal : 0;\n}\n\nstatic inline bool wuffs_base__frame_config__has_transparent_index(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);\n}\n\n// wuffs_base__frame_config__transparent_index returns the palette index of\n// the transparent color. It is only meaningful if\n// wuffs_base__frame_config__has_transparent_index returns true.\nstatic inline uint8_t wuffs_base__frame_config__transparent_index(\n    wuffs_base__frame_config* c) {\n  return wuffs_base__frame_config__has_transparent_index(c)\n             ? c->private_impl.transparent_index\n             : 0;\n}\n\n// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,\n// B) entries, 3 bytes each.\nstatic inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(\n    wuffs_base__frame_config* c) {\n  if (!wuffs_base__frame_config__valid(c)) {\n    return ((wuffs_base__slice_u8){});\n  }\n  return ((wuffs_base__slice_u8){\n      .ptr = c->private_impl.palette,\n      .len = sizeof(c->priva" +
+	"te_impl.palette),\n  });\n}\n\n// wuffs_base__frame_config__initialize sets the frame config. A\n// transparent_index of 256 or more means that the frame has no transparent\n// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,\n// the remaining entries are black.\nstatic inline void wuffs_base__frame_config__initialize(\n    wuffs_base__frame_config* c,\n    uint32_t x,\n    uint32_t y,\n    uint32_t width,\n    uint32_t height,\n    uint32_t delay_ms,\n    uint32_t disposal,\n    uint32_t transparent_index,\n    wuffs_base__slice_u8 palette) {\n  if (!c) {\n    return;\n  }\n  c->private_impl.flags = 1;\n  c->private_impl.x = x;\n  c->private_impl.y = y;\n  c->private_impl.w = width;\n  c->private_impl.h = height;\n  c->private_impl.delay_ms = delay_ms;\n  c->private_impl.disposal = (uint8_t)(disposal);\n  c->private_impl.transparent_index = 0;\n  if (transparent_index < 256) {\n    c->private_impl.flags |= 2;\n    c->private_impl.transparent_index = (uint8_t)(transparent_index);\n  }\n  size_t i;\n  for (i = 0; i <" +
+	" sizeof(c->private_impl.palette); i++) {\n    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;\n  }\n}\n\n#endif  // WUFFS_BASE_HEADER_H\n" +
 	""
 
 const baseImpl = "" +
@@ -30,14 +43,17 @@ const baseImpl = "" +
 	"roof, given C doesn't automatically zero memory before use,\n// but it should catch 99.99% of cases.\n//\n// Its (non-zero) value is arbitrary, based on md5sum(\"wuffs\").\n#define WUFFS_BASE__MAGIC (0x3CCB6C71U)\n\n// WUFFS_BASE__ALREADY_ZEROED is passed from a container struct's initializer\n// to a containee struct's initializer when the container has already zeroed\n// the containee's memory.\n//\n// Its (non-zero) value is arbitrary, based on md5sum(\"zeroed\").\n#define WUFFS_BASE__ALREADY_ZEROED (0x68602EF1U)\n\n// Denote intentional fallthroughs for -Wimplicit-fallthrough.\n//\n// The order matters here. Clang also defines \"__GNUC__\".\n#if defined(__clang__) && __cplusplus >= 201103L\n#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]\n#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)\n#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))\n#else\n#define WUFFS_BASE__FALLTHROUGH\n#endif\n\n// Use switch cases for coroutine suspension points, similar to the technique\n// in https://www.chiark.greenend.org" +
 	".uk/~sgtatham/coroutines.html\n//\n// We use trivial macros instead of an explicit assignment and case statement\n// so that clang-format doesn't get confused by the unusual \"case\"s.\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \\\n  coro_susp_point = n;                            \\\n  WUFFS_BASE__FALLTHROUGH;                        \\\n  case n:;\n\n#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \\\n  if (status < 0) {                                             \\\n    goto exit;                                                  \\\n  } else if (status == 0) {                                     \\\n    goto ok;                                                    \\\n  }                                                             \\\n  coro_susp_point = n;                                          \\\n  goto suspend;                                                 \\\n  case n:;\n\n// Clang also defines \"__GNUC__\".\n#if defined(__GNUC__)\n#define WUFFS_BASE__LI" +
 	"KELY(expr) (__builtin_expect(!!(expr), 1))\n#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))\n#else\n#define WUFFS_BASE__LIKELY(expr) (expr)\n#define WUFFS_BASE__UNLIKELY(expr) (expr)\n#endif\n\n// Uncomment this #include for printf-debugging.\n// #include <stdio.h>\n\n// ---------------- Static Inline Functions\n//\n// The helpers below are functions, instead of macros, because their arguments\n// can be an expression that we shouldn't evaluate more than once.\n//\n// They are in base-impl.h and hence copy/pasted into every generated C file,\n// instead of being in some \"base.c\" file, since a design goal is that users of\n// the generated C code can often just #include a single .c file, such as\n// \"gif.c\", without having to additionally include or otherwise build and link\n// a \"base.c\" file.\n//\n// They are static, so that linking multiple wuffs .o files won't complain about\n// duplicate function definitions.\n//\n// They are explicitly marked inline, even if modern compilers don't use the\n// inline attribute " +
-	"to guide optimizations such as inlining, to avoid the\n// -Wunused-function warning, and we like to compile with -Wall -Werror.\n\nstatic inline uint16_t wuffs_base__load_u16be(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);\n}\n\nstatic inline uint16_t wuffs_base__load_u16le(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);\n}\n\nstatic inline uint32_t wuffs_base__load_u32be(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |\n         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);\n}\n\nstatic inline uint32_t wuffs_base__load_u32le(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |\n         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(\n    wuffs_base__slice_u8 s,\n    uint64_t i) {\n  if ((i <= SIZE_MAX) && (i <= s.len)) {\n    return ((wuffs_base__slice_u8){\n        .ptr = s.ptr + i,\n        .len = s.len - i,\n    });\n  }\n  return ((wuffs_base_" +
-	"_slice_u8){});\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(\n    wuffs_base__slice_u8 s,\n    uint64_t j) {\n  if ((j <= SIZE_MAX) && (j <= s.len)) {\n    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});\n  }\n  return ((wuffs_base__slice_u8){});\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(\n    wuffs_base__slice_u8 s,\n    uint64_t i,\n    uint64_t j) {\n  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {\n    return ((wuffs_base__slice_u8){\n        .ptr = s.ptr + i,\n        .len = j - i,\n    });\n  }\n  return ((wuffs_base__slice_u8){});\n}\n\n// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(\n    wuffs_base__slice_u8 s,\n    uint64_t up_to) {\n  if ((uint64_t)(s.len) > up_to) {\n    s.len = up_to;\n  }\n  return s;\n}\n\n// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(\n    wuffs_base__slice_u8 " +
-	"s,\n    uint64_t up_to) {\n  if ((uint64_t)(s.len) > up_to) {\n    s.ptr += (uint64_t)(s.len) - up_to;\n    s.len = up_to;\n  }\n  return s;\n}\n\n// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,\n// length) where length is the minimum of dst.len and src.len.\n//\n// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty\n// slice) is valid and results in a no-op.\nstatic inline uint64_t wuffs_base__slice_u8__copy_from_slice(\n    wuffs_base__slice_u8 dst,\n    wuffs_base__slice_u8 src) {\n  size_t length = dst.len < src.len ? dst.len : src.len;\n  if (length > 0) {\n    memmove(dst.ptr, src.ptr, length);\n  }\n  return length;\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_history32(\n    uint8_t** ptr_ptr,\n    uint8_t* start,  // May be NULL, meaning an unmarked writer1.\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  if (!start || !distance) {\n    return 0;\n  }\n  uint8_t* ptr = *ptr_ptr;\n  if ((size_t)(ptr - start) < (size_t)(distance)) {\n    return 0;\n  }" +
-	"\n  start = ptr - distance;\n  size_t n = end - ptr;\n  if ((size_t)(length) > n) {\n    length = n;\n  } else {\n    n = length;\n  }\n  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that\n  // is mostly because 3 is the minimum length for the deflate format. This\n  // function implementation shouldn't overfit to that one format. Perhaps the\n  // copy_from_history32 Wuffs method should also take an unroll hint argument,\n  // and the cgen can look if that argument is the constant expression '3'.\n  //\n  // See also wuffs_base__writer1__copy_from_history32__bco below.\n  //\n  // Alternatively, or additionally, have a sloppy_copy_from_history32 method\n  // that copies 8 bytes at a time, possibly writing more than length bytes?\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\n// wuffs_base__writer1__copy_from_history32__bco is a Bounds Check Optimized\n// version of " +
-	"the wuffs_base__writer1__copy_from_history32 function above. The\n// caller needs to prove that:\n//  - start    != NULL\n//  - distance >  0\n//  - distance <= (*ptr_ptr - start)\n//  - length   <= (end      - *ptr_ptr)\nstatic inline uint32_t wuffs_base__writer1__copy_from_history32__bco(\n    uint8_t** ptr_ptr,\n    uint8_t* start,\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  uint8_t* ptr = *ptr_ptr;\n  start = ptr - distance;\n  uint32_t n = length;\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_reader32(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    uint8_t** ptr_rptr,\n    uint8_t* rend,\n    uint32_t length) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = length;\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  uint8_t* rptr = *ptr_rptr;\n  if (n > rend - rptr) {\n    n = rend - rptr;\n  }\n  if (n > 0) {\n    " +
-	"memmove(wptr, rptr, n);\n    *ptr_wptr += n;\n    *ptr_rptr += n;\n  }\n  return n;\n}\n\nstatic inline uint64_t wuffs_base__writer1__copy_from_slice(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    wuffs_base__slice_u8 src) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = src.len;\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  if (n > 0) {\n    memmove(wptr, src.ptr, n);\n    *ptr_wptr += n;\n  }\n  return n;\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_slice32(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    wuffs_base__slice_u8 src,\n    uint32_t length) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = src.len;\n  if (n > length) {\n    n = length;\n  }\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  if (n > 0) {\n    memmove(wptr, src.ptr, n);\n    *ptr_wptr += n;\n  }\n  return n;\n}\n\n// Note that the *__limit and *__mark methods are private (in base-impl.h) not\n// public (in base-header.h). We assume that, at the boundary between user code\n// and Wuffs code, the reader1 and writer1's private_impl fields (including" +
-	"\n// limit and mark) are NULL. Otherwise, some internal assumptions break down.\n// For example, limits could be represented as pointers, even though\n// conceptually they are counts, but that pointer-to-count correspondence\n// becomes invalid if a buffer is re-used (e.g. on resuming a coroutine).\n//\n// Admittedly, some of the Wuffs test code calls these methods, but that test\n// code is still Wuffs code, not user code. Other Wuffs test code modifies\n// private_impl fields directly.\n\nstatic inline wuffs_base__reader1 wuffs_base__reader1__limit(\n    wuffs_base__reader1* o,\n    uint64_t* ptr_to_len) {\n  wuffs_base__reader1 ret = *o;\n  ret.private_impl.limit.ptr_to_len = ptr_to_len;\n  ret.private_impl.limit.next = &o->private_impl.limit;\n  return ret;\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__reader1__mark(\n    wuffs_base__reader1* o,\n    uint8_t* mark) {\n  o->private_impl.mark = mark;\n  return ((wuffs_base__empty_struct){});\n}\n\n// TODO: static inline wuffs_base__writer1 wuffs_base__writer1__limit()\n\nst" +
-	"atic inline wuffs_base__empty_struct wuffs_base__writer1__mark(\n    wuffs_base__writer1* o,\n    uint8_t* mark) {\n  o->private_impl.mark = mark;\n  return ((wuffs_base__empty_struct){});\n}\n" +
+	"to guide optimizations such as inlining, to avoid the\n// -Wunused-function warning, and we like to compile with -Wall -Werror.\n\n// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and\n// wuffs_base__memset instead of calling <string.h>'s functions directly. When\n// WUFFS_CONFIG__FREESTANDING is defined, such as by \"wuffs gen -freestanding\",\n// they are simple loops, so that the code needs no C library and can be\n// compiled with \"-ffreestanding -nostdlib\". Otherwise, they are <string.h>'s\n// (typically well optimized) functions.\n#ifdef WUFFS_CONFIG__FREESTANDING\n\nstatic inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {\n  uint8_t* d = (uint8_t*)(dst);\n  const uint8_t* s = (const uint8_t*)(src);\n  for (; n > 0; n--) {\n    *d++ = *s++;\n  }\n  return dst;\n}\n\nstatic inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {\n  uint8_t* d = (uint8_t*)(dst);\n  const uint8_t* s = (const uint8_t*)(src);\n  if (d <= s) {\n    for (; n > 0; n--) {\n      *d++ = *s++;\n    }\n" +
+	"  } else {\n    for (d += n, s += n; n > 0; n--) {\n      *--d = *--s;\n    }\n  }\n  return dst;\n}\n\nstatic inline void* wuffs_base__memset(void* dst, int c, size_t n) {\n  uint8_t* d = (uint8_t*)(dst);\n  for (; n > 0; n--) {\n    *d++ = (uint8_t)(c);\n  }\n  return dst;\n}\n\n#else\n\n#define wuffs_base__memcpy memcpy\n#define wuffs_base__memmove memmove\n#define wuffs_base__memset memset\n\n#endif  // WUFFS_CONFIG__FREESTANDING\n\nstatic inline uint16_t wuffs_base__load_u16be(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);\n}\n\nstatic inline uint16_t wuffs_base__load_u16le(uint8_t* p) {\n  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);\n}\n\nstatic inline uint32_t wuffs_base__load_u32be(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |\n         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);\n}\n\nstatic inline uint32_t wuffs_base__load_u32le(uint8_t* p) {\n  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |\n         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) " +
+	"<< 24);\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(\n    wuffs_base__slice_u8 s,\n    uint64_t i) {\n  if ((i <= SIZE_MAX) && (i <= s.len)) {\n    return ((wuffs_base__slice_u8){\n        .ptr = s.ptr + i,\n        .len = s.len - i,\n    });\n  }\n  return ((wuffs_base__slice_u8){});\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(\n    wuffs_base__slice_u8 s,\n    uint64_t j) {\n  if ((j <= SIZE_MAX) && (j <= s.len)) {\n    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});\n  }\n  return ((wuffs_base__slice_u8){});\n}\n\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(\n    wuffs_base__slice_u8 s,\n    uint64_t i,\n    uint64_t j) {\n  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {\n    return ((wuffs_base__slice_u8){\n        .ptr = s.ptr + i,\n        .len = j - i,\n    });\n  }\n  return ((wuffs_base__slice_u8){});\n}\n\n// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8__pref" +
+	"ix(\n    wuffs_base__slice_u8 s,\n    uint64_t up_to) {\n  if ((uint64_t)(s.len) > up_to) {\n    s.len = up_to;\n  }\n  return s;\n}\n\n// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.\nstatic inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(\n    wuffs_base__slice_u8 s,\n    uint64_t up_to) {\n  if ((uint64_t)(s.len) > up_to) {\n    s.ptr += (uint64_t)(s.len) - up_to;\n    s.len = up_to;\n  }\n  return s;\n}\n\n// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,\n// length), via wuffs_base__memmove, where length is the minimum of dst.len\n// and src.len.\n//\n// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty\n// slice) is valid and results in a no-op.\nstatic inline uint64_t wuffs_base__slice_u8__copy_from_slice(\n    wuffs_base__slice_u8 dst,\n    wuffs_base__slice_u8 src) {\n  size_t length = dst.len < src.len ? dst.len : src.len;\n  if (length > 0) {\n    wuffs_base__memmove(dst.ptr, src.ptr, length);\n  }\n  return length;\n}\n\n// wuffs_base__slice_u8__swizzl" +
+	"e_from_palette converts the palette indexes in\n// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.\n// It converts n pixels, where n is the minimum of src.len and the number of\n// whole pixels that fit in dst, and returns n. An unknown pixel_format\n// converts no pixels.\n//\n// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is\n// shorter, the remaining entries are black.\n//\n// For the INDEXED pixel format, the indexes are copied as is. For the other\n// pixel formats, a pixel whose index is transparent_index is left unchanged,\n// so that it shows what was drawn there before. A transparent_index of 256 or\n// more means that there is no transparent color.\nstatic inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(\n    wuffs_base__slice_u8 dst,\n    wuffs_base__slice_u8 src,\n    wuffs_base__slice_u8 palette,\n    uint32_t transparent_index,\n    uint32_t pixel_format) {\n  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);\n  if (bpp == 0) {\n    ret" +
+	"urn 0;\n  }\n  size_t n = dst.len / bpp;\n  if (n > src.len) {\n    n = src.len;\n  }\n  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {\n    if (n > 0) {\n      wuffs_base__memmove(dst.ptr, src.ptr, n);\n    }\n    return n;\n  }\n\n  uint8_t* d = dst.ptr;\n  size_t i;\n  for (i = 0; i < n; i++, d += bpp) {\n    uint32_t index = src.ptr[i];\n    if (index == transparent_index) {\n      continue;\n    }\n    uint8_t r = 0;\n    uint8_t g = 0;\n    uint8_t b = 0;\n    if ((3 * (size_t)(index)) + 2 < palette.len) {\n      r = palette.ptr[3 * index + 0];\n      g = palette.ptr[3 * index + 1];\n      b = palette.ptr[3 * index + 2];\n    }\n    switch (pixel_format) {\n      case WUFFS_BASE__PIXEL_FORMAT__RGBA:\n        d[0] = r;\n        d[1] = g;\n        d[2] = b;\n        d[3] = 0xFF;\n        break;\n      case WUFFS_BASE__PIXEL_FORMAT__BGRA:\n        d[0] = b;\n        d[1] = g;\n        d[2] = r;\n        d[3] = 0xFF;\n        break;\n      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {\n        uint16_t x = (uint16_t)(((uint16_t)(r >> 3) << 11)" +
+	" |\n                                ((uint16_t)(g >> 2) << 5) |\n                                ((uint16_t)(b >> 3) << 0));\n        d[0] = (uint8_t)(x >> 0);\n        d[1] = (uint8_t)(x >> 8);\n        break;\n      }\n    }\n  }\n  return n;\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_history32(\n    uint8_t** ptr_ptr,\n    uint8_t* start,  // May be NULL, meaning an unmarked writer1.\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  if (!start || !distance) {\n    return 0;\n  }\n  uint8_t* ptr = *ptr_ptr;\n  if ((size_t)(ptr - start) < (size_t)(distance)) {\n    return 0;\n  }\n  start = ptr - distance;\n  size_t n = end - ptr;\n  if ((size_t)(length) > n) {\n    length = n;\n  } else {\n    n = length;\n  }\n  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that\n  // is mostly because 3 is the minimum length for the deflate format. This\n  // function implementation shouldn't overfit to that one format. Perhaps the\n  // copy_from_history32 Wuffs method should also take an unrol" +
+	"l hint argument,\n  // and the cgen can look if that argument is the constant expression '3'.\n  //\n  // See also wuffs_base__writer1__copy_from_history32__bco below.\n  //\n  // Alternatively, or additionally, have a sloppy_copy_from_history32 method\n  // that copies 8 bytes at a time, possibly writing more than length bytes?\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\n// wuffs_base__writer1__copy_from_history32__bco is a Bounds Check Optimized\n// version of the wuffs_base__writer1__copy_from_history32 function above. The\n// caller needs to prove that:\n//  - start    != NULL\n//  - distance >  0\n//  - distance <= (*ptr_ptr - start)\n//  - length   <= (end      - *ptr_ptr)\nstatic inline uint32_t wuffs_base__writer1__copy_from_history32__bco(\n    uint8_t** ptr_ptr,\n    uint8_t* start,\n    uint8_t* end,\n    uint32_t distance,\n    uint32_t length) {\n  uint8_t* ptr = *ptr_ptr;\n  st" +
+	"art = ptr - distance;\n  uint32_t n = length;\n  for (; n >= 3; n -= 3) {\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n    *ptr++ = *start++;\n  }\n  for (; n; n--) {\n    *ptr++ = *start++;\n  }\n  *ptr_ptr = ptr;\n  return length;\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_reader32(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    uint8_t** ptr_rptr,\n    uint8_t* rend,\n    uint32_t length) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = length;\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  uint8_t* rptr = *ptr_rptr;\n  if (n > rend - rptr) {\n    n = rend - rptr;\n  }\n  if (n > 0) {\n    wuffs_base__memmove(wptr, rptr, n);\n    *ptr_wptr += n;\n    *ptr_rptr += n;\n  }\n  return n;\n}\n\nstatic inline uint64_t wuffs_base__writer1__copy_from_slice(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    wuffs_base__slice_u8 src) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = src.len;\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  if (n > 0) {\n    wuffs_base__memmove(wptr, src.ptr, n);\n    *ptr_wptr += n;\n  }\n  return n" +
+	";\n}\n\nstatic inline uint32_t wuffs_base__writer1__copy_from_slice32(\n    uint8_t** ptr_wptr,\n    uint8_t* wend,\n    wuffs_base__slice_u8 src,\n    uint32_t length) {\n  uint8_t* wptr = *ptr_wptr;\n  size_t n = src.len;\n  if (n > length) {\n    n = length;\n  }\n  if (n > wend - wptr) {\n    n = wend - wptr;\n  }\n  if (n > 0) {\n    wuffs_base__memmove(wptr, src.ptr, n);\n    *ptr_wptr += n;\n  }\n  return n;\n}\n\n// Note that the *__limit and *__mark methods are private (in base-impl.h) not\n// public (in base-header.h). We assume that, at the boundary between user code\n// and Wuffs code, the reader1 and writer1's private_impl fields (including\n// limit and mark) are NULL. Otherwise, some internal assumptions break down.\n// For example, limits could be represented as pointers, even though\n// conceptually they are counts, but that pointer-to-count correspondence\n// becomes invalid if a buffer is re-used (e.g. on resuming a coroutine).\n//\n// Admittedly, some of the Wuffs test code calls these methods, but that test\n// code is " +
+	"still Wuffs code, not user code. Other Wuffs test code modifies\n// private_impl fields directly.\n\nstatic inline wuffs_base__reader1 wuffs_base__reader1__limit(\n    wuffs_base__reader1* o,\n    uint64_t* ptr_to_len) {\n  wuffs_base__reader1 ret = *o;\n  ret.private_impl.limit.ptr_to_len = ptr_to_len;\n  ret.private_impl.limit.next = &o->private_impl.limit;\n  return ret;\n}\n\nstatic inline wuffs_base__empty_struct wuffs_base__reader1__mark(\n    wuffs_base__reader1* o,\n    uint8_t* mark) {\n  o->private_impl.mark = mark;\n  return ((wuffs_base__empty_struct){});\n}\n\n// TODO: static inline wuffs_base__writer1 wuffs_base__writer1__limit()\n\nstatic inline wuffs_base__empty_struct wuffs_base__writer1__mark(\n    wuffs_base__writer1* o,\n    uint8_t* mark) {\n  o->private_impl.mark = mark;\n  return ((wuffs_base__empty_struct){});\n}\n" +
 	""
 
 type template_args_short_read struct {
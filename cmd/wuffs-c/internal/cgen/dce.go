@@ -0,0 +1,92 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"fmt"
+	"io"
+
+	a "github.com/google/wuffs/lang/ast"
+)
+
+// findReachableFuncs sets g.reachable to the functions that are reachable,
+// via calls, from the entry points. Those are the public functions or, if
+// the -entry flag was given, the public functions that it names, such as
+// "decoder.decode". Only reachable functions are generated.
+func (g *gen) findReachableFuncs() error {
+	roots := []*a.Func(nil)
+	byName := map[string]*a.Func{}
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc {
+				continue
+			}
+			f := tld.Func()
+			byName[f.QQID().Str(g.tm)] = f
+			if g.entries == nil && f.Public() {
+				roots = append(roots, f)
+			}
+		}
+	}
+	for _, e := range g.entries {
+		f := byName[e]
+		if f == nil {
+			return fmt.Errorf("cgen: -entry %q does not name a function in package %q", e, g.pkgName)
+		}
+		if !f.Public() {
+			return fmt.Errorf("cgen: -entry %q names a private function", e)
+		}
+		roots = append(roots, f)
+	}
+
+	g.reachable = map[*a.Func]bool{}
+	for len(roots) > 0 {
+		f := roots[len(roots)-1]
+		roots = roots[:len(roots)-1]
+		if g.reachable[f] {
+			continue
+		}
+		g.reachable[f] = true
+		roots = append(roots, g.checker.Callees(f)...)
+	}
+	return nil
+}
+
+// writeDCEReport lists the functions that were eliminated, and the size of
+// the (unformatted) C implementation of each function that was not.
+func (g *gen) writeDCEReport(w io.Writer) error {
+	total := 0
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc || g.checker.CompileTimeOnly(tld.Func()) {
+				continue
+			}
+			f := tld.Func()
+			name := f.QQID().Str(g.tm)
+			if !g.reachable[f] {
+				fmt.Fprintf(w, "dce: %s: eliminated %s\n", g.pkgName, name)
+				continue
+			}
+			b := new(buffer)
+			if err := g.writeFuncImpl(b, f); err != nil {
+				return err
+			}
+			total += len(*b)
+			fmt.Fprintf(w, "dce: %s: %7d bytes  %s\n", g.pkgName, len(*b), name)
+		}
+	}
+	fmt.Fprintf(w, "dce: %s: %7d bytes  total\n", g.pkgName, total)
+	return nil
+}
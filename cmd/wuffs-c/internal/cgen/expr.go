@@ -284,6 +284,21 @@ func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, pp pare
 			b.writes(")\n")
 			return nil
 		}
+		if isThatMethod(g.tm, n, g.tm.ByName("swizzle_from_palette").Key(), 4) {
+			b.writes("wuffs_base__slice_u8__swizzle_from_palette(")
+			receiver := n.LHS().Expr().LHS().Expr()
+			if err := g.writeExpr(b, receiver, rp, parenthesesOptional, depth); err != nil {
+				return err
+			}
+			for _, o := range n.Args() {
+				b.writeb(',')
+				if err := g.writeExpr(b, o.Arg().Value(), rp, parenthesesOptional, depth); err != nil {
+					return err
+				}
+			}
+			b.writes(")\n")
+			return nil
+		}
 		if isThatMethod(g.tm, n, t.KeyLength, 0) {
 			if pp == parenthesesMandatory {
 				b.writeb('(')
@@ -337,6 +352,15 @@ func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, pp pare
 			b.writes(")\n")
 			return nil
 		}
+		if isThatMethod(g.tm, n, g.tm.ByName("reset").Key(), 0) {
+			// TODO: don't hard-code the class name or this.checksum.
+			class := "wuffs_crc32__ieee"
+			if g.pkgName == "zlib" {
+				class = "wuffs_zlib__adler32"
+			}
+			b.printf("%s__reset(&self->private_impl.f_checksum)", class)
+			return nil
+		}
 		if isThatMethod(g.tm, n, g.tm.ByName("set_literal_width").Key(), 1) {
 			// TODO: don't hard-code lzw.
 			b.printf("%slzw_decoder__set_literal_width(&self->private_impl.f_lzw, ", g.pkgPrefix)
@@ -374,6 +398,58 @@ func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, pp pare
 			b.printf(")")
 			return nil
 		}
+		if isThatMethod(g.tm, n, g.tm.ByName("initialize_planar").Key(), 4) {
+			// TODO: don't hard-code a_dst.
+			b.printf("wuffs_base__image_config__initialize_planar(a_dst")
+			for _, o := range n.Args() {
+				b.writeb(',')
+				if err := g.writeExpr(b, o.Arg().Value(), rp, parenthesesOptional, depth); err != nil {
+					return err
+				}
+			}
+			b.printf(")")
+			return nil
+		}
+		if f := g.thisMethod(n, false); f != nil {
+			b.printf("%s(self", g.funcCName(f))
+			for _, o := range n.Args() {
+				b.writeb(',')
+				if err := g.writeExpr(b, o.Arg().Value(), rp, parenthesesOptional, depth); err != nil {
+					return err
+				}
+			}
+			b.writeb(')')
+			return nil
+		}
+		if cName := g.thisFieldMethodCName(n); cName != "" {
+			b.printf("%s(&", cName)
+			if err := g.writeExpr(b, n.LHS().Expr().LHS().Expr(), rp, parenthesesMandatory, depth); err != nil {
+				return err
+			}
+			for _, o := range n.Args() {
+				b.writeb(',')
+				if err := g.writeExpr(b, o.Arg().Value(), rp, parenthesesOptional, depth); err != nil {
+					return err
+				}
+			}
+			b.writeb(')')
+			return nil
+		}
+		if isThatMethod(g.tm, n, g.tm.ByName("initialize").Key(), 8) {
+			b.printf("wuffs_base__frame_config__initialize(")
+			receiver := n.LHS().Expr().LHS().Expr()
+			if err := g.writeExpr(b, receiver, rp, parenthesesOptional, depth); err != nil {
+				return err
+			}
+			for _, o := range n.Args() {
+				b.writeb(',')
+				if err := g.writeExpr(b, o.Arg().Value(), rp, parenthesesOptional, depth); err != nil {
+					return err
+				}
+			}
+			b.printf(")")
+			return nil
+		}
 		// TODO.
 
 	case t.KeyOpenBracket:
@@ -472,6 +548,20 @@ func (g *gen) writeExprOther(b *buffer, n *a.Expr, rp replacementPolicy, pp pare
 }
 
 func (g *gen) writeExprUnaryOp(b *buffer, n *a.Expr, rp replacementPolicy, pp parenthesesPolicy, depth uint32) error {
+	if n.Operator().Key() == t.KeyXUnaryTilde {
+		// C's integer promotion means that, for a uint8_t x, ~x is a negative
+		// int. Cast it back to Wuffs' unsigned type.
+		b.writes("((")
+		if err := g.writeCTypeName(b, n.MType(), "", ""); err != nil {
+			return err
+		}
+		b.writes(")(~")
+		if err := g.writeExpr(b, n.RHS().Expr(), rp, parenthesesMandatory, depth); err != nil {
+			return err
+		}
+		b.writes("))")
+		return nil
+	}
 	b.writes(cOpNames[0xFF&n.Operator().Key()])
 	return g.writeExpr(b, n.RHS().Expr(), rp, parenthesesMandatory, depth)
 }
@@ -598,8 +688,8 @@ func (g *gen) writeCTypeName(b *buffer, n *a.TypeExpr, varNamePrefix string, var
 			prefix = "wuffs_" + otherPkg + "__"
 		}
 		// TODO: remove this hack when "image_config" in Wuffs code becomes
-		// "base.image_config".
-		if qid[1] == t.IDImageConfig {
+		// "base.image_config", and likewise for "frame_config".
+		if qid[1] == t.IDImageConfig || qid[1] == t.IDFrameConfig {
 			prefix = "wuffs_base__"
 		}
 		b.printf("%s%s", prefix, qid[1].Str(g.tm))
@@ -660,6 +750,7 @@ var cOpNames = [256]string{
 	t.KeyXUnaryNot:   " ! ",
 	t.KeyXUnaryRef:   " & ",
 	t.KeyXUnaryDeref: " * ",
+	t.KeyXUnaryTilde: " ~ ",
 
 	t.KeyXBinaryPlus:        " + ",
 	t.KeyXBinaryMinus:       " - ",
@@ -31,6 +31,7 @@ type funk struct {
 
 	astFunc       *a.Func
 	cName         string
+	coroFrame     string
 	derivedVars   map[t.ID]struct{}
 	jumpTargets   map[a.Loop]uint32
 	coroSuspPoint uint32
@@ -141,9 +142,15 @@ func (g *gen) gatherFuncImpl(_ *buffer, n *a.Func) error {
 	g.currFunk = funk{
 		astFunc:     n,
 		cName:       g.funcCName(n),
+		coroFrame:   "0",
 		public:      n.Public(),
 		suspendible: n.Suspendible(),
 	}
+	if g.checker.RecursionDepth(n) > 1 {
+		// Each activation of a recursive coroutine has its own coroutine
+		// state, indexed by its depth argument.
+		g.currFunk.coroFrame = aPrefix + "depth"
+	}
 
 	if err := g.writeFuncImplHeader(&g.currFunk.bHeader); err != nil {
 		return err
@@ -168,6 +175,17 @@ func (g *gen) gatherFuncImpl(_ *buffer, n *a.Func) error {
 	return nil
 }
 
+// zeroValue returns the C expression for a public function's zero-valued
+// return value, when its receiver is invalid.
+//
+// TODO: handle struct return types.
+func zeroValue(n *a.TypeExpr) string {
+	if n.IsSliceType() {
+		return "((wuffs_base__slice_u8){0})"
+	}
+	return "0"
+}
+
 func (g *gen) writeFuncImplHeader(b *buffer) error {
 	// Check the previous status and the "self" arg.
 	if g.currFunk.public && !g.currFunk.astFunc.Receiver().IsZero() {
@@ -179,8 +197,7 @@ func (g *gen) writeFuncImplHeader(b *buffer) error {
 		} else if len(outFields) == 0 {
 			b.printf("return;")
 		} else if len(outFields) == 1 {
-			// TODO: don't assume that the return type is an integer.
-			b.printf("return 0;")
+			b.printf("return %s;", zeroValue(outFields[0].Field().XType()))
 		} else {
 			return fmt.Errorf("TODO: handle structured return types")
 		}
@@ -195,8 +212,7 @@ func (g *gen) writeFuncImplHeader(b *buffer) error {
 		} else if len(outFields) == 0 {
 			b.writes("return;")
 		} else if len(outFields) == 1 {
-			// TODO: don't assume that the return type is an integer.
-			b.writes("return 0;")
+			b.printf("return %s;", zeroValue(outFields[0].Field().XType()))
 		} else {
 			return fmt.Errorf("TODO: handle structured return types")
 		}
@@ -237,9 +253,8 @@ func (g *gen) writeFuncImplHeader(b *buffer) error {
 
 func (g *gen) writeFuncImplBodyResume(b *buffer) error {
 	if g.currFunk.suspendible {
-		// TODO: don't hard-code [0], and allow recursive coroutines.
-		b.printf("uint32_t coro_susp_point = self->private_impl.%s%s[0].coro_susp_point;\n",
-			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+		b.printf("uint32_t coro_susp_point = self->private_impl.%s%s[%s].coro_susp_point;\n",
+			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame)
 		b.printf("if (coro_susp_point) {\n")
 		if err := g.writeResumeSuspend(b, g.currFunk.astFunc.Body(), false, false); err != nil {
 			return err
@@ -274,15 +289,15 @@ func (g *gen) writeFuncImplBodySuspend(b *buffer) error {
 		// the top.
 		b.writes("\ngoto ok;\n") // Avoid the "unused label" warning.
 		b.writes("ok:\n")
-		b.printf("self->private_impl.%s%s[0].coro_susp_point = 0;\n",
-			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+		b.printf("self->private_impl.%s%s[%s].coro_susp_point = 0;\n",
+			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame)
 		b.writes("goto exit; }\n\n") // Close the coroutine switch.
 
 		b.writes("goto suspend;\n") // Avoid the "unused label" warning.
 		b.writes("suspend:\n")
 
-		b.printf("self->private_impl.%s%s[0].coro_susp_point = coro_susp_point;\n",
-			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+		b.printf("self->private_impl.%s%s[%s].coro_susp_point = coro_susp_point;\n",
+			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame)
 		if err := g.writeResumeSuspend(b, g.currFunk.astFunc.Body(), true, false); err != nil {
 			return err
 		}
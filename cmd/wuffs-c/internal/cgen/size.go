@@ -0,0 +1,187 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgen
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// sizeOptLevels are the optimization levels that DoSize compiles with.
+var sizeOptLevels = []string{"-O2", "-Os", "-O3"}
+
+// sizeProbePrefix starts the names of the char arrays, one per public struct
+// and per coroutine frame, whose sizes in the object file are those structs'
+// and frames' sizes.
+const sizeProbePrefix = "wuffs_size__"
+
+// DoSize transpiles a Wuffs program to C, compiles it with each C compiler at
+// each optimization level, and reports the sizes of the compiled functions
+// and of the public structs, including their coroutine frames.
+//
+// The arguments list the source Wuffs files, as for Do.
+//
+// The report is written to stdout, one "cc opt kind name size" line per size,
+// where kind is "text" for a function, "sizeof" for a struct or frame, and
+// "total" for the sum of the package's functions' sizes.
+func DoSize(args []string) error {
+	flags := flag.FlagSet{}
+	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
+	return generate.Do(&flags, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		g := newGen(pkgName, tm, c, files)
+		src, err := g.generate()
+		if err != nil {
+			return nil, err
+		}
+		src = append(src, g.sizeProbes()...)
+
+		dir, err := ioutil.TempDir("", "wuffs-c-size")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		srcFilename := filepath.Join(dir, pkgName+".c")
+		if err := ioutil.WriteFile(srcFilename, src, 0644); err != nil {
+			return nil, err
+		}
+
+		out := &bytes.Buffer{}
+		for _, cc := range strings.Split(*ccompilersFlag, ",") {
+			cc = strings.TrimSpace(cc)
+			if cc == "" {
+				continue
+			}
+			for _, opt := range sizeOptLevels {
+				objFilename := filepath.Join(dir, pkgName+"-"+cc+opt+".o")
+				cmd := exec.Command(cc, opt, "-std=c99", "-c", "-o", objFilename, srcFilename)
+				cmd.Stdout = os.Stdout
+				cmd.Stderr = os.Stderr
+				if err := cmd.Run(); err != nil {
+					return nil, fmt.Errorf("%s %s: %v", cc, opt, err)
+				}
+				if err := g.writeSizes(out, cc+" "+opt, objFilename); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return out.Bytes(), nil
+	})
+}
+
+// sizeProbes returns C code that defines, for each public struct and for each
+// of its coroutine frames, a char array of that struct's or frame's size.
+func (g *gen) sizeProbes() []byte {
+	b := new(buffer)
+	b.writes("\n// ---------------- Size Probes\n\n")
+	for _, n := range g.structList {
+		if !n.Public() {
+			continue
+		}
+		structName := g.pkgPrefix + n.QID().Str(g.tm)
+		b.printf("char %s%s[sizeof(%s)];\n", sizeProbePrefix, structName, structName)
+		for _, o := range g.coroFrameFuncs(n) {
+			frame := cPrefix + o.FuncName().Str(g.tm)
+			b.printf("char %s%s__%s[sizeof(((%s*)0)->private_impl.%s)];\n",
+				sizeProbePrefix, structName, frame, structName, frame)
+		}
+	}
+	return *b
+}
+
+// writeSizes reads the symbol sizes from the object file named objFilename,
+// using nm, and writes a report line for each of this package's functions and
+// size probes, prefixed by ccOpt, such as "gcc -O2".
+func (g *gen) writeSizes(w *bytes.Buffer, ccOpt string, objFilename string) error {
+	nmOut, err := exec.Command("nm", "-S", "--defined-only", objFilename).Output()
+	if err != nil {
+		return fmt.Errorf("nm %s: %v", objFilename, err)
+	}
+
+	texts := map[string]uint64{}
+	sizeofs := map[string]uint64{}
+	s := bufio.NewScanner(bytes.NewReader(nmOut))
+	for s.Scan() {
+		// Each line is "address size type name". Symbols without a size,
+		// such as section names, have only three fields.
+		fields := strings.Fields(s.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[1], 16, 64)
+		if err != nil {
+			return fmt.Errorf("nm %s: bad line %q", objFilename, s.Text())
+		}
+		name := fields[3]
+		switch {
+		case strings.HasPrefix(name, sizeProbePrefix):
+			name = name[len(sizeProbePrefix):]
+			// Name frames like "wuffs_gif__decoder.c_decode_frame".
+			if i := strings.Index(name, "__"+cPrefix); i >= 0 {
+				name = name[:i] + "." + name[i+2:]
+			}
+			sizeofs[name] = size
+		case fields[2] == "t" || fields[2] == "T":
+			if !strings.HasPrefix(name, g.pkgPrefix) {
+				continue
+			}
+			// Compilers can split or specialize a function into symbols
+			// like "foo.cold" or "foo.constprop.0". Count them as "foo".
+			if i := strings.IndexByte(name, '.'); i >= 0 {
+				name = name[:i]
+			}
+			texts[name] += size
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	total := uint64(0)
+	for _, name := range sortedKeys(texts) {
+		fmt.Fprintf(w, "%s text %s %d\n", ccOpt, name, texts[name])
+		total += texts[name]
+	}
+	fmt.Fprintf(w, "%s total %s %d\n", ccOpt, strings.TrimSuffix(g.pkgPrefix, "__"), total)
+	for _, name := range sortedKeys(sizeofs) {
+		fmt.Fprintf(w, "%s sizeof %s %d\n", ccOpt, name, sizeofs[name])
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
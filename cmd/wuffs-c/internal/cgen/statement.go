@@ -17,6 +17,7 @@ package cgen
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	a "github.com/google/wuffs/lang/ast"
@@ -268,6 +269,9 @@ func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 		b.writeb(';')
 		return nil
 
+	case a.KSwitch:
+		return g.writeStatementSwitch(b, n.Switch(), depth)
+
 	case a.KVar:
 		n := n.Var()
 		if v := n.Value(); v != nil {
@@ -286,7 +290,7 @@ func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 			}
 			// TODO: arrays of arrays.
 			name := n.Name().Str(g.tm)
-			b.printf("memset(%s%s, 0, sizeof(%s%s));\n", vPrefix, name, vPrefix, name)
+			b.printf("wuffs_base__memset(%s%s, 0, sizeof(%s%s));\n", vPrefix, name, vPrefix, name)
 
 		} else {
 			b.printf("%s%s = ", vPrefix, n.Name().Str(g.tm))
@@ -339,6 +343,106 @@ func (g *gen) writeStatement(b *buffer, n *a.Node, depth uint32) error {
 	return fmt.Errorf("unrecognized ast.Kind (%s) for writeStatement", n.Kind())
 }
 
+// maxSwitchCaseLabels is the maximum number of C case labels that a single
+// Wuffs case range expands to. Wider ranges are instead tested by an if-else
+// chain in the C switch's default case.
+const maxSwitchCaseLabels = 64
+
+func (g *gen) writeStatementSwitch(b *buffer, n *a.Switch, depth uint32) error {
+	subject := n.Subject()
+	if err := g.writeSuspendibles(b, subject, depth); err != nil {
+		return err
+	}
+	subjectStr := buffer(nil)
+	if err := g.writeExpr(&subjectStr, subject, replaceCallSuspendibles, parenthesesMandatory, 0); err != nil {
+		return err
+	}
+
+	// Write each case body (and the else body) to its own buffer first, so
+	// that we can see whether any of them contain coroutine suspension points.
+	cases := n.Cases()
+	bodies := make([]buffer, len(cases)+1)
+	coroSuspPoint := g.currFunk.coroSuspPoint
+	for i, o := range cases {
+		for _, p := range o.Case().Body() {
+			if err := g.writeStatement(&bodies[i], p, depth); err != nil {
+				return err
+			}
+		}
+	}
+	for _, o := range n.BodyElse() {
+		if err := g.writeStatement(&bodies[len(cases)], o, depth); err != nil {
+			return err
+		}
+	}
+
+	// The coroutine suspension points are themselves C case labels, within
+	// the function-wide "switch (coro_susp_point)". A nested C switch would
+	// capture them, so if any body can suspend, we fall back to an if-else
+	// chain.
+	if g.currFunk.coroSuspPoint != coroSuspPoint {
+		if !subject.Pure() {
+			return fmt.Errorf("TODO: switch statement with an impure subject and a suspendible body")
+		}
+		for i, o := range cases {
+			b.writes("if (")
+			writeCaseCondition(b, subjectStr, o.Case())
+			b.writes(") {\n")
+			b.writex(bodies[i])
+			b.writes("} else ")
+		}
+		b.writes("{\n")
+		b.writex(bodies[len(cases)])
+		b.writes("}\n")
+		return nil
+	}
+
+	b.writes("switch (")
+	b.writex(subjectStr)
+	b.writes(") {\n")
+	wide := []int(nil)
+	for i, o := range cases {
+		o := o.Case()
+		cMin, cMax := o.Min().ConstValue(), o.Max().ConstValue()
+		if span := big.NewInt(0).Sub(cMax, cMin); span.Cmp(big.NewInt(maxSwitchCaseLabels)) >= 0 {
+			wide = append(wide, i)
+			continue
+		}
+		for x := big.NewInt(0).Set(cMin); x.Cmp(cMax) <= 0; x.Add(x, one) {
+			b.printf("case %v:\n", x)
+		}
+		b.writes("{\n")
+		b.writex(bodies[i])
+		b.writes("break;\n}\n")
+	}
+	b.writes("default:\n")
+	for _, i := range wide {
+		b.writes("if (")
+		writeCaseCondition(b, subjectStr, cases[i].Case())
+		b.writes(") {\n")
+		b.writex(bodies[i])
+		b.writes("} else ")
+	}
+	b.writes("{\n")
+	b.writex(bodies[len(cases)])
+	b.writes("}\n")
+	b.writes("}\n")
+	return nil
+}
+
+func writeCaseCondition(b *buffer, subject []byte, n *a.Case) {
+	if !n.IsRange() {
+		b.writex(subject)
+		b.printf(" == %v", n.Min().ConstValue())
+		return
+	}
+	b.writes("(")
+	b.writex(subject)
+	b.printf(" >= %v) && (", n.Min().ConstValue())
+	b.writex(subject)
+	b.printf(" <= %v)", n.Max().ConstValue())
+}
+
 func (g *gen) writeCoroSuspPoint(b *buffer, maybeSuspend bool) error {
 	const maxCoroSuspPoint = 0xFFFFFFFF
 	g.currFunk.coroSuspPoint++
@@ -484,9 +588,8 @@ func (g *gen) writeCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
 
 	} else if isInSrc(g.tm, n, t.KeySkip32, 1) {
 		g.currFunk.usesScratch = true
-		// TODO: don't hard-code [0], and allow recursive coroutines.
-		scratchName := fmt.Sprintf("self->private_impl.%s%s[0].scratch",
-			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+		scratchName := fmt.Sprintf("self->private_impl.%s%s[%s].scratch",
+			cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame)
 
 		b.printf("%s = ", scratchName)
 		x := n.Args()[0].Arg().Value()
@@ -646,53 +749,49 @@ func (g *gen) writeCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
 		}
 		b.writes("if (status) { goto suspend; }\n")
 
-	} else if isThatMethod(g.tm, n, g.tm.ByName("decode").Key(), 2) ||
-		isThatMethod(g.tm, n, g.tm.ByName("decode").Key(), 3) {
+	} else if cName := g.thisFieldMethodCName(n); cName != "" {
+		// TODO: don't hard code being inside a try call.
+		if g.currFunk.tempW > maxTemp {
+			return fmt.Errorf("too many temporary variables required")
+		}
+		temp := g.currFunk.tempW
+		g.currFunk.tempW++
 
-		switch g.pkgName {
-		case "gzip", "zlib":
-			// TODO: don't hard code being inside a try call.
-			if g.currFunk.tempW > maxTemp {
-				return fmt.Errorf("too many temporary variables required")
-			}
-			temp := g.currFunk.tempW
-			g.currFunk.tempW++
-
-			// TODO: don't hard-code the receiver being from the "deflate"
-			// package.
-			//
-			// TODO: don't hard-code a_dst or a_src.
-			b.printf("%sstatus %s%d = wuffs_deflate__decoder__decode("+
-				"&self->private_impl.f_flate, %sdst, %ssrc);\n",
-				g.pkgPrefix, tPrefix, temp,
-				aPrefix, aPrefix)
-			if err := g.writeLoadExprDerivedVars(b, n); err != nil {
-				return err
-			}
-			// TODO: check if tPrefix_temp is an error, and return?
+		args, wiField, err := g.writeFieldMethodArgs(b, n, temp, depth)
+		if err != nil {
+			return err
+		}
+		b.printf("%sstatus %s%d = %s(&", g.pkgPrefix, tPrefix, temp, cName)
+		if err := g.writeExpr(b, n.LHS().Expr().LHS().Expr(), replaceNothing, parenthesesMandatory, depth); err != nil {
+			return err
+		}
+		for _, arg := range args {
+			b.printf(", %s", arg)
+		}
+		b.writes(");\n")
+		if wiField != "" {
+			b.printf("self->private_impl.%s%s = l_wbuf%d.wi;\n", fPrefix, wiField, temp)
+		}
+		if err := g.writeLoadExprDerivedVars(b, n); err != nil {
+			return err
+		}
+		// TODO: check if tPrefix_temp is an error, and return?
 
-		case "gif":
-			// TODO: don't hard code being inside a try call.
-			if g.currFunk.tempW > maxTemp {
-				return fmt.Errorf("too many temporary variables required")
-			}
-			temp := g.currFunk.tempW
-			g.currFunk.tempW++
-
-			// TODO: don't hard-code a_dst or v_r or l_rlimit or v_block_size.
-			b.writes("uint64_t l_rlimit0 = v_block_size;\n")
-			b.printf("%sstatus %s%d = %slzw_decoder__decode(&self->private_impl.f_lzw, %sdst,"+
-				"wuffs_base__reader1__limit(&%sr, &l_rlimit0));\n",
-				g.pkgPrefix, tPrefix, temp,
-				g.pkgPrefix, aPrefix, vPrefix)
-			if err := g.writeLoadExprDerivedVars(b, n); err != nil {
+	} else if f := g.thisSuspendibleMethod(n); f != nil {
+		// This includes a recursive coroutine calling itself, whose depth
+		// argument selects the callee's coroutine state.
+		b.printf("status = %s(self", g.funcCName(f))
+		for _, o := range n.Args() {
+			b.writes(",")
+			if err := g.writeExpr(b, o.Arg().Value(), replaceNothing, parenthesesMandatory, depth); err != nil {
 				return err
 			}
-			// TODO: check if tPrefix_temp is an error, and return?
-
-		default:
-			return fmt.Errorf("cannot convert Wuffs call %q to C", n.Str(g.tm))
 		}
+		b.writes(");\n")
+		if err := g.writeLoadExprDerivedVars(b, n); err != nil {
+			return err
+		}
+		b.writes("if (status) { goto suspend; }\n")
 
 	} else {
 		// TODO: fix this.
@@ -703,6 +802,159 @@ func (g *gen) writeCallSuspendibles(b *buffer, n *a.Expr, depth uint32) error {
 	return nil
 }
 
+// thisFieldMethodCName returns the C function name of the method called by
+// the this.foo.bar(etc) call n, where this.foo is a struct-typed field of the
+// current function's receiver. That struct type can be from a used package,
+// such as a "zlib.decoder" field in the "png" package. It returns "" if n is
+// not such a call.
+func (g *gen) thisFieldMethodCName(n *a.Expr) string {
+	if k := n.Operator().Key(); k != t.KeyOpenParen && k != t.KeyTry {
+		return ""
+	}
+	method := n.LHS().Expr()
+	if method.Operator().Key() != t.KeyDot {
+		return ""
+	}
+	field := method.LHS().Expr()
+	if field.Operator().Key() != t.KeyDot {
+		return ""
+	}
+	if this := field.LHS().Expr(); this.Operator() != 0 || this.Ident().Key() != t.KeyThis {
+		return ""
+	}
+	typ := field.MType()
+	if typ == nil || typ.Decorator() != 0 {
+		return ""
+	}
+
+	prefix := g.pkgPrefix
+	qid := typ.QID()
+	if qid[0] != 0 {
+		// See gen.writeCTypeName for a related TODO with otherPkg.
+		otherPkg := g.tm.ByID(qid[0])
+		prefix = "wuffs_" + otherPkg + "__"
+	} else if g.structMap[qid] == nil {
+		return ""
+	}
+	return prefix + qid[1].Str(g.tm) + "__" + method.Ident().Str(g.tm)
+}
+
+// writeFieldMethodArgs writes any local variables needed by the arguments of
+// the this.foo.bar?(etc) call n, and returns those arguments as C expressions.
+//
+// An r.limit(l:etc) argument becomes a limited view of the reader r. A slice
+// argument, such as this.buf[:], becomes a writer1 over that slice, and the
+// returned wiField names the field, such as buf_wi, that is to hold how much
+// of the slice was written. A "dummy" argument is dropped: it exists only so
+// that its derived variables are saved and loaded around the call.
+func (g *gen) writeFieldMethodArgs(b *buffer, n *a.Expr, temp uint32, depth uint32) (
+	args []string, wiField string, retErr error) {
+
+	for _, o := range n.Args() {
+		o := o.Arg()
+		if o.Name().Str(g.tm) == "dummy" {
+			continue
+		}
+		v := o.Value()
+		arg := buffer(nil)
+
+		if isThatMethod(g.tm, v, t.KeyLimit, 1) {
+			b.printf("uint64_t l_rlimit%d = ", temp)
+			if err := g.writeExpr(b, v.Args()[0].Arg().Value(), replaceNothing, parenthesesOptional, depth); err != nil {
+				return nil, "", err
+			}
+			b.writes(";\n")
+			arg.writes("wuffs_base__reader1__limit(&")
+			if err := g.writeExpr(&arg, v.LHS().Expr().LHS().Expr(), replaceNothing, parenthesesMandatory, depth); err != nil {
+				return nil, "", err
+			}
+			arg.printf(", &l_rlimit%d)", temp)
+
+		} else if v.Operator().Key() == t.KeyColon {
+			if wiField != "" {
+				return nil, "", fmt.Errorf("cannot convert Wuffs call %q to C: more than one slicing argument", n.Str(g.tm))
+			}
+			wiField = g.sliceWIField(v)
+			if wiField == "" {
+				return nil, "", fmt.Errorf("cannot convert Wuffs call %q to C: "+
+					"the %q argument is not this.foo[etc] with a matching this.foo_wi field",
+					n.Str(g.tm), o.Name().Str(g.tm))
+			}
+			b.printf("wuffs_base__slice_u8 l_wslice%d = ", temp)
+			if err := g.writeExpr(b, v, replaceNothing, parenthesesOptional, depth); err != nil {
+				return nil, "", err
+			}
+			b.writes(";\n")
+			b.printf("wuffs_base__buf1 l_wbuf%d = {.ptr = l_wslice%d.ptr, .len = l_wslice%d.len};\n",
+				temp, temp, temp)
+			b.printf("wuffs_base__writer1 l_w%d = {.buf = &l_wbuf%d};\n", temp, temp)
+			arg.printf("l_w%d", temp)
+
+		} else if err := g.writeExpr(&arg, v, replaceNothing, parenthesesOptional, depth); err != nil {
+			return nil, "", err
+		}
+		args = append(args, string(arg))
+	}
+	return args, wiField, nil
+}
+
+// sliceWIField returns the name of the "foo_wi" field, of the current
+// function's receiver, for the this.foo[etc] slicing expression n. It returns
+// "" if n is not this.foo[etc] or there is no such field.
+func (g *gen) sliceWIField(n *a.Expr) string {
+	n = n.LHS().Expr()
+	if n.Operator().Key() != t.KeyDot {
+		return ""
+	}
+	if this := n.LHS().Expr(); this.Operator() != 0 || this.Ident().Key() != t.KeyThis {
+		return ""
+	}
+	wiField := n.Ident().Str(g.tm) + "_wi"
+	s := g.structMap[g.currFunk.astFunc.Receiver()]
+	if s == nil {
+		return ""
+	}
+	for _, f := range s.Fields() {
+		if f.Field().Name().Str(g.tm) == wiField {
+			return wiField
+		}
+	}
+	return ""
+}
+
+// thisSuspendibleMethod returns the suspendible method, of the current
+// function's receiver, called by the this.foo?(etc) call n. It returns nil if
+// n is not such a call.
+func (g *gen) thisSuspendibleMethod(n *a.Expr) *a.Func {
+	return g.thisMethod(n, true)
+}
+
+// thisMethod returns the method, of the current function's receiver, called
+// by the this.foo?(etc) or this.foo!(etc) call n, depending on suspendible. It
+// returns nil if n is not such a call.
+func (g *gen) thisMethod(n *a.Expr, suspendible bool) *a.Func {
+	if n.Operator().Key() != t.KeyOpenParen {
+		return nil
+	}
+	lhs := n.LHS().Expr()
+	if lhs.Operator().Key() != t.KeyDot {
+		return nil
+	}
+	if this := lhs.LHS().Expr(); this.Operator() != 0 || this.Ident().Key() != t.KeyThis {
+		return nil
+	}
+	qqid := g.currFunk.astFunc.QQID()
+	qqid[2] = lhs.Ident()
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() == a.KFunc && tld.Func().QQID() == qqid && tld.Func().Suspendible() == suspendible {
+				return tld.Func()
+			}
+		}
+	}
+	return nil
+}
+
 func (g *gen) writeReadUXX(b *buffer, n *a.Expr, name string, size uint32, endianness string) error {
 	if size != 16 && size != 32 {
 		return fmt.Errorf("internal error: bad writeReadUXX size %d", size)
@@ -728,9 +980,8 @@ func (g *gen) writeReadUXX(b *buffer, n *a.Expr, name string, size uint32, endia
 	b.writes(";")
 
 	g.currFunk.usesScratch = true
-	// TODO: don't hard-code [0], and allow recursive coroutines.
-	scratchName := fmt.Sprintf("self->private_impl.%s%s[0].scratch",
-		cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm))
+	scratchName := fmt.Sprintf("self->private_impl.%s%s[%s].scratch",
+		cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame)
 
 	b.printf("if (WUFFS_BASE__LIKELY(%srend_src - %srptr_src >= %d)) {", bPrefix, bPrefix, size/8)
 	b.printf("%s%d = wuffs_base__load_u%d%s(%srptr_src);\n", tPrefix, temp1, size, endianness, bPrefix)
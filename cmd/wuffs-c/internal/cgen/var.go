@@ -32,6 +32,15 @@ func (g *gen) needDerivedVar(name t.ID) bool {
 				return nil
 			}
 			q := p.Expr()
+			// TODO: delete this hack that also matches "try foo.decode?(etc,
+			// dummy:in.name)", along with the dummy param.
+			if q.Operator().Key() == t.KeyTry {
+				for _, o := range q.Args() {
+					if o := o.Arg(); o.Name().Str(g.tm) == "dummy" && isIn(o.Value(), name) {
+						return errNeedDerivedVar
+					}
+				}
+			}
 			if q.Operator().Key() != t.KeyOpenParen {
 				return nil
 			}
@@ -56,6 +65,15 @@ func (g *gen) needDerivedVar(name t.ID) bool {
 	return false
 }
 
+// isIn returns whether n is "in.name".
+func isIn(n *a.Expr, name t.ID) bool {
+	if n.Operator().Key() != t.KeyDot || n.Ident() != name {
+		return false
+	}
+	n = n.LHS().Expr()
+	return n.Operator() == 0 && n.Ident().Key() == t.KeyIn
+}
+
 func (g *gen) findDerivedVars() {
 	for _, o := range g.currFunk.astFunc.In().Fields() {
 		o := o.Field()
@@ -274,6 +292,16 @@ func (g *gen) visitVars(b *buffer, block []*a.Node, depth uint32, f func(*gen, *
 				}
 			}
 
+		case a.KSwitch:
+			for _, c := range o.Switch().Cases() {
+				if err := g.visitVars(b, c.Case().Body(), depth, f); err != nil {
+					return err
+				}
+			}
+			if err := g.visitVars(b, o.Switch().BodyElse(), depth, f); err != nil {
+				return err
+			}
+
 		case a.KVar:
 			if err := f(g, b, o.Var()); err != nil {
 				return err
@@ -324,9 +352,9 @@ func (g *gen) writeResumeSuspend1(b *buffer, n *a.Var, suspend bool, initBoolTyp
 	} else {
 		lhs := local
 		rhs := ""
-		// TODO: don't hard-code [0], and allow recursive coroutines.
 		if !initBoolTypedVars {
-			rhs = fmt.Sprintf("self->private_impl.%s%s[0].%s", cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), lhs)
+			rhs = fmt.Sprintf("self->private_impl.%s%s[%s].%s",
+				cPrefix, g.currFunk.astFunc.FuncName().Str(g.tm), g.currFunk.coroFrame, lhs)
 		} else if typ.QID() != (t.QID{0, t.IDBool}) {
 			return nil
 		} else if typ.Decorator() != 0 {
@@ -373,7 +401,7 @@ func (g *gen) writeResumeSuspend1(b *buffer, n *a.Var, suspend bool, initBoolTyp
 			}
 			switch qid[1].Key() {
 			case t.KeyU8, t.KeyU16, t.KeyU32, t.KeyU64:
-				b.printf("memcpy(%s, %s, sizeof(%s));\n", lhs, rhs, local)
+				b.printf("wuffs_base__memcpy(%s, %s, sizeof(%s));\n", lhs, rhs, local)
 				return nil
 			}
 		}
@@ -41,6 +41,10 @@ func main1() error {
 		return cgen.Do(args)
 	case "genlib":
 		return doGenlib(args)
+	case "install":
+		return doInstall(args)
+	case "size":
+		return cgen.DoSize(args)
 	case "test":
 		return doTest(args)
 	}
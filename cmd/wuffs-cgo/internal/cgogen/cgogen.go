@@ -0,0 +1,580 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cgogen generates a Go package that uses cgo to wrap the C code
+// generated by wuffs-c.
+//
+// Each public struct with a "decode?(dst writer1, src reader1)()" method
+// becomes an io.Reader, much like the standard library's compress/gzip.Reader.
+// If the struct also has a "multi_member()(multi_member bool)" method that
+// returns true, the io.Reader decodes every member of a multi-member stream.
+// Its other public methods whose arguments are all booleans or integers
+// become Go methods. Other functions are not wrapped.
+package cgogen
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// bufferSize is the size of the C buffers that a generated Reader decodes
+// from and to.
+const bufferSize = 32 * 1024
+
+// Do transpiles a Wuffs program to a Go package.
+//
+// The arguments list the source Wuffs files, which must be under the Wuffs
+// root directory, as the generated Go code includes the C code generated for
+// that directory.
+//
+// The generated program is written to stdout.
+func Do(args []string) error {
+	return generate.Do(&flag.FlagSet{}, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		dirname, err := packageDirname(files)
+		if err != nil {
+			return nil, err
+		}
+		g := &gen{
+			PKGPREFIX: "WUFFS_" + strings.ToUpper(pkgName) + "__",
+			pkgPrefix: "wuffs_" + pkgName + "__",
+			pkgName:   pkgName,
+			dirname:   dirname,
+			tm:        tm,
+			checker:   c,
+			files:     files,
+		}
+		unformatted, err := g.generate()
+		if err != nil {
+			return nil, err
+		}
+		return format.Source(unformatted)
+	})
+}
+
+// packageDirname returns the directory of the Wuffs files, relative to the
+// Wuffs root directory, such as "std/gzip".
+func packageDirname(files []*a.File) (string, error) {
+	if len(files) == 0 {
+		return "", fmt.Errorf("no input files")
+	}
+	wuffsRoot, err := generate.WuffsRoot()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(filepath.Dir(files[0].Filename()))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wuffsRoot, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("input file %q is not under the Wuffs root directory %q",
+			files[0].Filename(), wuffsRoot)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+type buffer []byte
+
+func (b *buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func (b *buffer) printf(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+func (b *buffer) writes(s string)                           { *b = append(*b, s...) }
+
+type gen struct {
+	PKGPREFIX string // e.g. "WUFFS_JPEG__"
+	pkgPrefix string // e.g. "wuffs_jpeg__"
+	pkgName   string // e.g. "jpeg"
+	dirname   string // e.g. "std/jpeg"
+
+	tm      *t.Map
+	checker *check.Checker
+	files   []*a.File
+}
+
+func (g *gen) generate() ([]byte, error) {
+	b := new(buffer)
+
+	b.writes("// Code generated by wuffs-cgo. DO NOT EDIT.\n\n")
+	b.printf("// Package %s wraps the C code generated for the Wuffs %s package.\n", g.pkgName, g.dirname)
+	b.printf("package %s\n\n", g.pkgName)
+
+	// The generated Go package lives in gen/cgo/std/foo, so its relative
+	// path to gen/c/std/foo.c goes up one more directory than dirname has
+	// elements.
+	up := strings.Repeat("../", strings.Count(g.dirname, "/")+2)
+	b.writes("/*\n")
+	b.writes("#include <stdlib.h>\n\n")
+	b.printf("#include \"%sc/%s.c\"\n", up, g.dirname)
+	b.writes("*/\n")
+	b.writes("import \"C\"\n\n")
+
+	readers := []*a.Struct(nil)
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStruct {
+				continue
+			}
+			if n := tld.Struct(); n.Public() && n.Suspendible() && g.hasDecodeMethod(n) {
+				readers = append(readers, n)
+			}
+		}
+	}
+
+	imports := []string(nil)
+	if len(readers) > 0 {
+		imports = append(imports, `"errors"`, `"io"`, `"runtime"`, `"unsafe"`, ``)
+	}
+	if err := g.forEachUse(func(useDirname string) error {
+		// The used package's C symbols, such as its status__string
+		// function, are defined by its own cgo package.
+		imports = append(imports, `_ "github.com/google/wuffs/gen/cgo/`+useDirname+`"`)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(imports) > 0 {
+		b.printf("import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+
+	if err := g.writeErrors(b); err != nil {
+		return nil, err
+	}
+
+	if len(readers) > 0 {
+		b.printf("const bufferSize = %d\n\n", bufferSize)
+		for _, n := range readers {
+			if err := g.writeReader(b, n); err != nil {
+				return nil, err
+			}
+		}
+		b.writes(readerHelpers)
+	}
+	return *b, nil
+}
+
+func (g *gen) forEachUse(f func(useDirname string) error) error {
+	seen := map[string]bool{}
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KUse {
+				continue
+			}
+			useDirname, _ := t.Unescape(g.tm.ByID(tld.Use().Path()))
+			if seen[useDirname] {
+				continue
+			}
+			seen[useDirname] = true
+			if err := f(useDirname); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goName converts a Wuffs name or status message, such as "set_ignore_checksum"
+// or "bad Huffman code (over-subscribed)", to an exported Go identifier, such
+// as "SetIgnoreChecksum" or "BadHuffmanCodeOverSubscribed".
+func goName(name string) string {
+	s := []byte(nil)
+	upper := true
+	for _, r := range name {
+		if ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			s = append(s, byte(r))
+			upper = false
+		} else if 'a' <= r && r <= 'z' {
+			if upper {
+				r += 'A' - 'a'
+			}
+			s = append(s, byte(r))
+			upper = false
+		} else {
+			upper = true
+		}
+	}
+	return string(s)
+}
+
+func (g *gen) writeErrors(b *buffer) error {
+	b.printf("// Error is a %s package error, such as a corrupt input.\n", g.dirname)
+	b.writes("type Error int32\n\n")
+	b.writes("func (e Error) Error() string {\n")
+	b.printf("return C.GoString(C.%sstatus__string(C.%sstatus(e)))\n", g.pkgPrefix, g.pkgPrefix)
+	b.writes("}\n\n")
+
+	consts := []string(nil)
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStatus {
+				continue
+			}
+			n := tld.Status()
+			if !n.Public() || n.Keyword().Key() != t.KeyError {
+				continue
+			}
+			raw := n.QID()[1].Str(g.tm)
+			msg, ok := t.Unescape(raw)
+			if !ok {
+				return fmt.Errorf("bad status message %q", raw)
+			}
+			consts = append(consts, fmt.Sprintf("Error%s = Error(C.%s)\n",
+				goName(msg), strings.ToUpper(g.pkgPrefix+cName(msg, "error "))))
+		}
+	}
+	if len(consts) > 0 {
+		b.writes("const (\n")
+		for _, c := range consts {
+			b.writes(c)
+		}
+		b.writes(")\n\n")
+	}
+	return nil
+}
+
+// cName matches the C code generator's naming of status codes, so that
+// ("bad GIF block", "error ") becomes "error_bad_gif_block".
+func cName(msg string, prefix string) string {
+	s := []byte(nil)
+	underscore := true
+	for _, r := range prefix + msg {
+		if 'A' <= r && r <= 'Z' {
+			s = append(s, byte(r+'a'-'A'))
+			underscore = false
+		} else if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			s = append(s, byte(r))
+			underscore = false
+		} else if !underscore {
+			s = append(s, '_')
+			underscore = true
+		}
+	}
+	if underscore && len(s) > 0 {
+		s = s[:len(s)-1]
+	}
+	return string(s)
+}
+
+// isDecodeMethod returns whether n is "decode?(dst writer1, src reader1)()".
+func isDecodeMethod(tm *t.Map, n *a.Func) bool {
+	if !n.Public() || !n.Suspendible() || n.FuncName().Str(tm) != "decode" || len(n.Out().Fields()) != 0 {
+		return false
+	}
+	in := n.In().Fields()
+	return len(in) == 2 &&
+		in[0].Field().Name().Str(tm) == "dst" && in[0].Field().XType().QID() == t.QID{0, t.IDWriter1} &&
+		in[1].Field().Name().Str(tm) == "src" && in[1].Field().XType().QID() == t.QID{0, t.IDReader1}
+}
+
+// isMultiMemberMethod returns whether n is "multi_member()(multi_member
+// bool)". A struct with that method declares whether its decode method decodes
+// one member of a multi-member stream, and is called again for the next
+// member. Like the standard library's compress/gzip.Reader, the generated
+// Reader then decodes every member, not just the first.
+func isMultiMemberMethod(tm *t.Map, n *a.Func) bool {
+	if !n.Public() || n.Suspendible() || n.FuncName().Str(tm) != "multi_member" || len(n.In().Fields()) != 0 {
+		return false
+	}
+	out := n.Out().Fields()
+	return len(out) == 1 &&
+		out[0].Field().XType().Decorator() == 0 && out[0].Field().XType().QID() == t.QID{0, t.IDBool}
+}
+
+func (g *gen) hasMultiMemberMethod(n *a.Struct) bool {
+	found := false
+	g.forEachMethod(n, func(o *a.Func) {
+		found = found || isMultiMemberMethod(g.tm, o)
+	})
+	return found
+}
+
+func (g *gen) hasDecodeMethod(n *a.Struct) bool {
+	found := false
+	g.forEachMethod(n, func(o *a.Func) {
+		found = found || isDecodeMethod(g.tm, o)
+	})
+	return found
+}
+
+func (g *gen) forEachMethod(n *a.Struct, f func(*a.Func)) {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() == a.KFunc && tld.Func().Receiver() == n.QID() {
+				f(tld.Func())
+			}
+		}
+	}
+}
+
+func (g *gen) writeReader(b *buffer, n *a.Struct) error {
+	structName := n.QID().Str(g.tm)
+	cStructName := g.pkgPrefix + structName
+	readerName := "Reader"
+	if structName != "decoder" {
+		readerName = goName(structName) + "Reader"
+	}
+	multiMember := g.hasMultiMemberMethod(n)
+
+	b.printf("// %s is an io.Reader that decodes the bytes of another io.Reader, using\n", readerName)
+	b.printf("// a %s.\n", cStructName)
+	b.printf("type %s struct {\n", readerName)
+	b.writes("r   io.Reader\n")
+	b.writes("err error\n\n")
+	b.writes("// The decoder and its buffers are allocated in C memory, as cgo does not\n")
+	b.writes("// allow C code to keep pointers to Go memory.\n")
+	b.printf("dec *C.%s\n", cStructName)
+	b.writes("dst *C.wuffs_base__buf1\n")
+	b.writes("src *C.wuffs_base__buf1\n")
+	b.writes("}\n\n")
+
+	b.printf("// New%s returns a %s that decodes r's bytes. Call Close to\n", readerName, readerName)
+	b.writes("// release its C memory.\n")
+	b.printf("func New%s(r io.Reader) (*%s, error) {\n", readerName, readerName)
+	b.printf("z := &%s{\n", readerName)
+	b.printf("dec: (*C.%s)(C.calloc(1, C.sizeof_%s)),\n", cStructName, cStructName)
+	b.writes("dst: newBuf1(),\n")
+	b.writes("src: newBuf1(),\n")
+	b.writes("}\n")
+	b.printf("runtime.SetFinalizer(z, (*%s).Close)\n", readerName)
+	b.writes("if err := z.Reset(r); err != nil {\n")
+	b.writes("z.Close()\n")
+	b.writes("return nil, err\n")
+	b.writes("}\n")
+	b.writes("return z, nil\n")
+	b.writes("}\n\n")
+
+	b.writes("// Reset discards the decoder's state and makes it decode r's bytes, as if\n")
+	b.printf("// it were a new %s.\n", readerName)
+	b.printf("func (z *%s) Reset(r io.Reader) error {\n", readerName)
+	b.writes("if z.dec == nil {\n")
+	b.writes("return errClosed\n")
+	b.writes("}\n")
+	b.writes("z.r = r\n")
+	b.writes("z.err = nil\n")
+	b.writes("z.dst.wi, z.dst.ri, z.dst.closed = 0, 0, false\n")
+	b.writes("z.src.wi, z.src.ri, z.src.closed = 0, 0, false\n")
+	b.printf("C.%s__initialize(z.dec, C.WUFFS_VERSION, 0)\n", cStructName)
+	b.writes("if s := z.dec.private_impl.status; s != 0 {\n")
+	b.writes("return Error(s)\n")
+	b.writes("}\n")
+	b.writes("return nil\n")
+	b.writes("}\n\n")
+
+	b.writes("// Read implements io.Reader.\n")
+	b.printf("func (z *%s) Read(p []byte) (int, error) {\n", readerName)
+	b.writes("if z.dec == nil {\n")
+	b.writes("return 0, errClosed\n")
+	b.writes("}\n")
+	b.writes("for len(p) > 0 {\n")
+	b.writes("if z.dst.ri < z.dst.wi {\n")
+	b.writes("n := copy(p, bufBytes(z.dst)[z.dst.ri:z.dst.wi])\n")
+	b.writes("z.dst.ri += C.size_t(n)\n")
+	b.writes("return n, nil\n")
+	b.writes("}\n")
+	b.writes("if z.err != nil {\n")
+	b.writes("return 0, z.err\n")
+	b.writes("}\n\n")
+	b.writes("z.dst.wi, z.dst.ri = 0, 0\n")
+	b.printf("s := C.%s__decode(z.dec,\n", cStructName)
+	b.writes("C.wuffs_base__writer1{buf: z.dst},\n")
+	b.writes("C.wuffs_base__reader1{buf: z.src})\n")
+	b.writes("switch s {\n")
+	b.printf("case C.%sSTATUS_OK:\n", g.PKGPREFIX)
+	if multiMember {
+		b.printf("if bool(C.%s__multi_member(z.dec)) {\n", cStructName)
+		b.writes("z.err = z.nextMember()\n")
+		b.writes("} else {\n")
+		b.writes("z.err = io.EOF\n")
+		b.writes("}\n")
+	} else {
+		b.writes("z.err = io.EOF\n")
+	}
+	b.printf("case C.%sSUSPENSION_SHORT_WRITE:\n", g.PKGPREFIX)
+	b.writes("// No-op. The next loop iteration returns the decoded bytes.\n")
+	b.printf("case C.%sSUSPENSION_SHORT_READ:\n", g.PKGPREFIX)
+	b.writes("z.err = z.fill()\n")
+	b.printf("case C.%sERROR_UNEXPECTED_EOF:\n", g.PKGPREFIX)
+	b.writes("z.err = io.ErrUnexpectedEOF\n")
+	b.writes("default:\n")
+	b.writes("z.err = Error(s)\n")
+	b.writes("}\n")
+	b.writes("}\n")
+	b.writes("return 0, nil\n")
+	b.writes("}\n\n")
+
+	if multiMember {
+		b.writes("// nextMember returns io.EOF if the source has no more bytes, after a\n")
+		b.writes("// member was decoded, or nil if it does, so that the next decode call\n")
+		b.writes("// decodes the next member.\n")
+		b.printf("func (z *%s) nextMember() error {\n", readerName)
+		b.writes("for z.src.ri == z.src.wi {\n")
+		b.writes("if z.src.closed {\n")
+		b.writes("return io.EOF\n")
+		b.writes("}\n")
+		b.writes("if err := z.fill(); err != nil {\n")
+		b.writes("return err\n")
+		b.writes("}\n")
+		b.writes("}\n")
+		b.writes("return nil\n")
+		b.writes("}\n\n")
+	}
+
+	b.writes("// fill moves any unread source bytes to the start of the source buffer and\n")
+	b.writes("// reads more bytes after them. It marks the buffer closed at the end of the\n")
+	b.writes("// underlying io.Reader.\n")
+	b.printf("func (z *%s) fill() error {\n", readerName)
+	b.writes("if z.src.closed {\n")
+	b.writes("return io.ErrUnexpectedEOF\n")
+	b.writes("}\n")
+	b.writes("src := bufBytes(z.src)\n")
+	b.writes("n := copy(src, src[z.src.ri:z.src.wi])\n")
+	b.writes("z.src.wi, z.src.ri = C.size_t(n), 0\n")
+	b.writes("if n == len(src) {\n")
+	b.writes("// The decoder asked for more bytes but did not consume any.\n")
+	b.writes("return io.ErrShortBuffer\n")
+	b.writes("}\n")
+	b.writes("m, err := z.r.Read(src[n:])\n")
+	b.writes("z.src.wi += C.size_t(m)\n")
+	b.writes("if err == io.EOF {\n")
+	b.writes("z.src.closed = true\n")
+	b.writes("return nil\n")
+	b.writes("}\n")
+	b.writes("return err\n")
+	b.writes("}\n\n")
+
+	b.writes("// Close releases the C memory. It does not close the underlying io.Reader.\n")
+	b.printf("func (z *%s) Close() error {\n", readerName)
+	b.writes("if z.dec == nil {\n")
+	b.writes("return nil\n")
+	b.writes("}\n")
+	b.writes("C.free(unsafe.Pointer(z.dec))\n")
+	b.writes("freeBuf1(z.dst)\n")
+	b.writes("freeBuf1(z.src)\n")
+	b.writes("z.dec, z.dst, z.src = nil, nil, nil\n")
+	b.writes("return nil\n")
+	b.writes("}\n\n")
+
+	var err error
+	g.forEachMethod(n, func(o *a.Func) {
+		if err == nil && o.Public() && !o.Suspendible() {
+			err = g.writeMethod(b, readerName, cStructName, o)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readerHelpers is the code shared by every generated Reader type.
+const readerHelpers = `
+var errClosed = errors.New("wuffs: use of closed Reader")
+
+func newBuf1() *C.wuffs_base__buf1 {
+	b := (*C.wuffs_base__buf1)(C.calloc(1, C.sizeof_wuffs_base__buf1))
+	b.ptr = (*C.uint8_t)(C.malloc(bufferSize))
+	b.len = bufferSize
+	return b
+}
+
+func freeBuf1(b *C.wuffs_base__buf1) {
+	C.free(unsafe.Pointer(b.ptr))
+	C.free(unsafe.Pointer(b))
+}
+
+func bufBytes(b *C.wuffs_base__buf1) []byte {
+	return (*[bufferSize]byte)(unsafe.Pointer(b.ptr))[:b.len:b.len]
+}
+`
+
+// writeMethod writes a Go method for a non-suspendible function n whose
+// arguments and result, if any, are booleans or integers. It writes nothing
+// for other functions.
+func (g *gen) writeMethod(b *buffer, readerName string, cStructName string, n *a.Func) error {
+	params := []string(nil)
+	args := []string{"z.dec"}
+	for _, o := range n.In().Fields() {
+		o := o.Field()
+		goType, ok := goTypeNames[o.XType().QID()]
+		if !ok || o.XType().Decorator() != 0 {
+			return nil
+		}
+		name := o.Name().Str(g.tm)
+		params = append(params, name+" "+goType)
+		args = append(args, fmt.Sprintf("C.%s(%s)", cTypeNames[goType], name))
+	}
+	retType := ""
+	switch outFields := n.Out().Fields(); len(outFields) {
+	case 0:
+	case 1:
+		o := outFields[0].Field()
+		goType, ok := goTypeNames[o.XType().QID()]
+		if !ok || o.XType().Decorator() != 0 {
+			return nil
+		}
+		retType = goType
+	default:
+		return nil
+	}
+
+	funcName := n.FuncName().Str(g.tm)
+	b.printf("// %s calls %s__%s.\n", goName(funcName), cStructName, funcName)
+	b.printf("func (z *%s) %s(%s) %s {\n", readerName, goName(funcName), strings.Join(params, ", "), retType)
+	call := fmt.Sprintf("C.%s__%s(%s)", cStructName, funcName, strings.Join(args, ", "))
+	if retType == "" {
+		b.printf("%s\n", call)
+	} else {
+		b.printf("return %s(%s)\n", retType, call)
+	}
+	b.writes("}\n\n")
+	return nil
+}
+
+var goTypeNames = map[t.QID]string{
+	{0, t.IDI8}:    "int8",
+	{0, t.IDI16}:   "int16",
+	{0, t.IDI32}:   "int32",
+	{0, t.IDI64}:   "int64",
+	{0, t.IDU8}:    "uint8",
+	{0, t.IDU16}:   "uint16",
+	{0, t.IDU32}:   "uint32",
+	{0, t.IDU64}:   "uint64",
+	{0, t.IDUsize}: "uint",
+	{0, t.IDBool}:  "bool",
+}
+
+var cTypeNames = map[string]string{
+	"int8":   "int8_t",
+	"int16":  "int16_t",
+	"int32":  "int32_t",
+	"int64":  "int64_t",
+	"uint8":  "uint8_t",
+	"uint16": "uint16_t",
+	"uint32": "uint32_t",
+	"uint64": "uint64_t",
+	"uint":   "size_t",
+	"bool":   "bool",
+}
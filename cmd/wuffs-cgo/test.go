@@ -0,0 +1,105 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+// cgoTestTag is the build tag of the Go tests under test/cgo. Those tests
+// import the generated gen/cgo packages, which are not checked in, so plain
+// "go test ./..." skips them.
+const cgoTestTag = "wuffs_cgo"
+
+func doBench(args []string) error { return doBenchTest(args, true) }
+func doTest(args []string) error  { return doBenchTest(args, false) }
+
+func doBenchTest(args []string, bench bool) error {
+	flags := flag.FlagSet{}
+	focusFlag := flags.String("focus", cf.FocusDefault, cf.FocusUsage)
+	mimicFlag := flags.Bool("mimic", cf.MimicDefault, cf.MimicUsage)
+	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !cf.IsAlphaNumericIsh(*focusFlag) {
+		return fmt.Errorf("bad -focus flag value %q", *focusFlag)
+	}
+	if *repsFlag < cf.RepsMin || cf.RepsMax < *repsFlag {
+		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]", *repsFlag, cf.RepsMin, cf.RepsMax)
+	}
+	// There are no other libraries to mimic, as the Go tests compare with
+	// golden files instead.
+	_ = *mimicFlag
+
+	args = flags.Args()
+
+	failed := false
+	for _, arg := range args {
+		f, err := doBenchTest1(arg, bench, *focusFlag, *repsFlag)
+		if err != nil {
+			return err
+		}
+		failed = failed || f
+	}
+	if failed {
+		s := "tests"
+		if bench {
+			s = "benchmarks"
+		}
+		return fmt.Errorf("%s: some %s failed", os.Args[0], s)
+	}
+	return nil
+}
+
+// doBenchTest1 runs "go test" in dirname, such as "test/cgo/std/gzip". Not
+// every package has Go tests, so a missing dirname is not an error.
+func doBenchTest1(dirname string, bench bool, focus string, reps int) (failed bool, err error) {
+	if _, err := os.Stat(dirname); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	goArgs := []string{"test", "-tags=" + cgoTestTag}
+	// The focus is a comma-separated list of name prefixes, such as
+	// "TestReader,TestWriter".
+	pattern := "^(" + strings.Replace(focus, ",", "|", -1) + ")"
+	if bench {
+		goArgs = append(goArgs, "-run=^$", "-bench="+pattern, fmt.Sprintf("-count=%d", reps))
+	} else if focus != "" {
+		goArgs = append(goArgs, "-run="+pattern)
+	}
+	goArgs = append(goArgs, ".")
+
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir = dirname
+	if err := cmd.Run(); err == nil {
+		// No-op.
+	} else if _, ok := err.(*exec.ExitError); ok {
+		failed = true
+	} else {
+		return false, err
+	}
+	return failed, nil
+}
@@ -0,0 +1,119 @@
+// After editing this file, run "go generate" in this directory.
+
+#ifndef WUFFS_BASE_HPP
+#define WUFFS_BASE_HPP
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <cstddef>
+#include <cstdint>
+#include <span>
+#include <string>
+#include <system_error>
+#include <type_traits>
+
+// The C++ wrappers use std::span, which is new in C++20.
+#if __cplusplus < 202002L
+#error "Wuffs' C++ wrappers require C++20 or later"
+#endif
+
+namespace wuffs {
+
+// ---------------- I/O
+
+// reader adapts a span of bytes to the wuffs_base__reader1 that Wuffs
+// functions read from.
+//
+// Pass closed = false if more bytes may follow in a later span, in which case
+// a decoder can suspend with a short read. Once it does, call remaining() to
+// see the unread bytes, if any, and start the next reader with those bytes
+// followed by the new ones.
+//
+// A reader is not copyable, as the C reader refers to its buffer.
+class reader {
+ public:
+  explicit reader(std::span<uint8_t> s, bool closed = true)
+      : buf_{s.data(), s.size(), s.size(), 0, closed} {}
+  reader(const reader&) = delete;
+  reader& operator=(const reader&) = delete;
+
+  // consumed returns the number of bytes read so far.
+  size_t consumed() const { return buf_.ri; }
+
+  // remaining returns the bytes not read so far.
+  std::span<uint8_t> remaining() const {
+    return std::span<uint8_t>(buf_.ptr + buf_.ri, buf_.wi - buf_.ri);
+  }
+
+  // c returns the C reader, for calling the C API directly.
+  wuffs_base__reader1 c() {
+    wuffs_base__reader1 r = {};
+    r.buf = &buf_;
+    return r;
+  }
+
+ private:
+  wuffs_base__buf1 buf_;
+};
+
+// writer adapts a span of bytes to the wuffs_base__writer1 that Wuffs
+// functions write to.
+//
+// If a decoder suspends with a short write, call written() to see the bytes
+// written so far and then reset() to make room for more.
+//
+// A writer is not copyable, as the C writer refers to its buffer.
+class writer {
+ public:
+  explicit writer(std::span<uint8_t> s) : buf_{s.data(), s.size(), 0, 0, false} {}
+  writer(const writer&) = delete;
+  writer& operator=(const writer&) = delete;
+
+  // written returns the bytes written so far.
+  std::span<uint8_t> written() const {
+    return std::span<uint8_t>(buf_.ptr, buf_.wi);
+  }
+
+  // reset discards the bytes written so far, so that the whole span can be
+  // written to again.
+  void reset() {
+    buf_.wi = 0;
+    buf_.ri = 0;
+  }
+
+  // c returns the C writer, for calling the C API directly.
+  wuffs_base__writer1 c() {
+    wuffs_base__writer1 w = {};
+    w.buf = &buf_;
+    return w;
+  }
+
+ private:
+  wuffs_base__buf1 buf_;
+};
+
+// slice_u8 adapts a span of bytes to a wuffs_base__slice_u8.
+inline wuffs_base__slice_u8 slice_u8(std::span<uint8_t> s) {
+  return wuffs_base__slice_u8{s.data(), s.size()};
+}
+
+// span_u8 adapts a wuffs_base__slice_u8 to a span of bytes.
+inline std::span<uint8_t> span_u8(wuffs_base__slice_u8 s) {
+  return std::span<uint8_t>(s.ptr, s.len);
+}
+
+}  // namespace wuffs
+
+#endif  // WUFFS_BASE_HPP
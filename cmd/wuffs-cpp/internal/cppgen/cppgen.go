@@ -0,0 +1,400 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate go run gen.go
+
+// Package cppgen generates a C++ header that wraps the C code generated by
+// wuffs-c. Each public struct becomes a class that initializes itself on
+// construction, and each public function becomes a method that takes and
+// returns C++ types.
+package cppgen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/wuffs/lang/builtin"
+	"github.com/google/wuffs/lang/check"
+	"github.com/google/wuffs/lang/generate"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// aPrefix is prepended to function argument names, as C++ keywords such as
+// "new" or "delete" are valid Wuffs variable names.
+const aPrefix = "a_"
+
+// Do transpiles a Wuffs program to a C++ header.
+//
+// The arguments list the source Wuffs files. If no arguments are given, it
+// reads from stdin.
+//
+// The generated header is written to stdout.
+func Do(args []string) error {
+	return generate.Do(&flag.FlagSet{}, args, func(pkgName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error) {
+		g := &gen{
+			PKGPREFIX: "WUFFS_" + strings.ToUpper(pkgName) + "__",
+			pkgPrefix: "wuffs_" + pkgName + "__",
+			pkgName:   pkgName,
+			tm:        tm,
+			checker:   c,
+			files:     files,
+		}
+		unformatted, err := g.generate()
+		if err != nil {
+			return nil, err
+		}
+		stdout := &bytes.Buffer{}
+		cmd := exec.Command("clang-format-5.0", "-style=Chromium")
+		cmd.Stdin = bytes.NewReader(unformatted)
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, err
+		}
+		return stdout.Bytes(), nil
+	})
+}
+
+type buffer []byte
+
+func (b *buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func (b *buffer) printf(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+func (b *buffer) writeb(x byte)                             { *b = append(*b, x) }
+func (b *buffer) writes(s string)                           { *b = append(*b, s...) }
+
+type gen struct {
+	PKGPREFIX string // e.g. "WUFFS_JPEG__"
+	pkgPrefix string // e.g. "wuffs_jpeg__"
+	pkgName   string // e.g. "jpeg"
+
+	tm      *t.Map
+	checker *check.Checker
+	files   []*a.File
+}
+
+func (g *gen) generate() ([]byte, error) {
+	b := new(buffer)
+
+	includeGuard := "WUFFS_" + strings.ToUpper(g.pkgName) + "_HPP"
+	b.printf("#ifndef %s\n#define %s\n\n", includeGuard, includeGuard)
+
+	b.printf("// Code generated by wuffs-cpp. DO NOT EDIT.\n\n")
+	b.printf("#include \"%s.h\"\n\n", g.pkgName)
+	b.writes(baseHeader)
+	b.writeb('\n')
+
+	b.printf("namespace wuffs {\nnamespace %s {\n\n", g.pkgName)
+
+	b.writes("// ---------------- Status Codes\n\n")
+	if err := g.writeStatuses(b); err != nil {
+		return nil, err
+	}
+
+	b.writes("// ---------------- Public Structs\n\n")
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStruct || !tld.Struct().Public() {
+				continue
+			}
+			if err := g.writeStruct(b, tld.Struct()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	b.writes("// ---------------- Public Functions\n\n")
+	if err := g.forEachPublicFunc(t.QID{}, func(n *a.Func) error {
+		b.writes("inline ")
+		return g.writeFunc(b, n)
+	}); err != nil {
+		return nil, err
+	}
+
+	b.printf("}  // namespace %s\n}  // namespace wuffs\n\n", g.pkgName)
+
+	b.writes("namespace std {\n")
+	b.printf("template <>\nstruct is_error_code_enum<wuffs::%s::status> : true_type {};\n", g.pkgName)
+	b.writes("}  // namespace std\n\n")
+
+	b.printf("#endif  // %s\n", includeGuard)
+	return *b, nil
+}
+
+// forEachPublicFunc calls f for each public function whose receiver is r. A
+// zero r means free-standing functions.
+func (g *gen) forEachPublicFunc(r t.QID, f func(*a.Func) error) error {
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KFunc {
+				continue
+			}
+			n := tld.Func()
+			if !n.Public() || n.Receiver() != r {
+				continue
+			}
+			if err := f(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cppName converts a Wuffs status message like "bad GIF block" to a C++
+// identifier like "bad_gif_block". It matches the C code generator's naming,
+// so that "error bad GIF block" corresponds to the C macro
+// WUFFS_GIF__ERROR_BAD_GIF_BLOCK.
+func cppName(name string) string {
+	s := []byte(nil)
+	underscore := true
+	for _, r := range name {
+		if 'A' <= r && r <= 'Z' {
+			s = append(s, byte(r+'a'-'A'))
+			underscore = false
+		} else if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			s = append(s, byte(r))
+			underscore = false
+		} else if !underscore {
+			s = append(s, '_')
+			underscore = true
+		}
+	}
+	if underscore && len(s) > 0 {
+		s = s[:len(s)-1]
+	}
+	return string(s)
+}
+
+func (g *gen) writeStatuses(b *buffer) error {
+	names := []string(nil)
+	for _, z := range builtin.StatusList {
+		names = append(names, cppName(z.String()))
+	}
+	for _, file := range g.files {
+		for _, tld := range file.TopLevelDecls() {
+			if tld.Kind() != a.KStatus {
+				continue
+			}
+			n := tld.Status()
+			raw := n.QID()[1].Str(g.tm)
+			msg, ok := t.Unescape(raw)
+			if !ok {
+				return fmt.Errorf("bad status message %q", raw)
+			}
+			prefix := "suspension "
+			if n.Keyword().Key() == t.KeyError {
+				prefix = "error "
+			}
+			names = append(names, cppName(prefix+msg))
+		}
+	}
+
+	b.printf("// status is a %sstatus, as a C++ enum. Its values are non-negative\n", g.pkgPrefix)
+	b.writes("// for success or suspension, and negative for errors.\n")
+	b.writes("//\n")
+	b.writes("// It is also a std::error_code enum, whose category is status_category().\n")
+	b.printf("enum class status : %sstatus {\n", g.pkgPrefix)
+	for _, s := range names {
+		b.printf("%s = %s%s,\n", s, g.PKGPREFIX, strings.ToUpper(s))
+	}
+	b.writes("};\n\n")
+
+	b.writes("inline bool status__is_error(status s) {\n")
+	b.printf("return %sstatus__is_error(static_cast<%sstatus>(s));\n", g.pkgPrefix, g.pkgPrefix)
+	b.writes("}\n\n")
+
+	b.writes("inline const char* status__string(status s) {\n")
+	b.printf("return %sstatus__string(static_cast<%sstatus>(s));\n", g.pkgPrefix, g.pkgPrefix)
+	b.writes("}\n\n")
+
+	b.writes("class status_category_impl : public std::error_category {\n")
+	b.writes("public:\n")
+	b.printf("const char* name() const noexcept override { return \"wuffs_%s\"; }\n", g.pkgName)
+	b.writes("std::string message(int s) const override {\n")
+	b.writes("return status__string(static_cast<status>(s));\n")
+	b.writes("}\n")
+	b.writes("};\n\n")
+
+	b.writes("inline const std::error_category& status_category() {\n")
+	b.writes("static const status_category_impl c;\n")
+	b.writes("return c;\n")
+	b.writes("}\n\n")
+
+	b.writes("inline std::error_code make_error_code(status s) {\n")
+	b.writes("return std::error_code(static_cast<int>(s), status_category());\n")
+	b.writes("}\n\n")
+	return nil
+}
+
+func (g *gen) writeStruct(b *buffer, n *a.Struct) error {
+	structName := n.QID().Str(g.tm)
+	cStructName := g.pkgPrefix + structName
+
+	b.printf("// %s wraps a %s.\n", structName, cStructName)
+	if n.Suspendible() {
+		b.writes("//\n")
+		b.writes("// Its constructor calls the C initializer, so that its methods can be called\n")
+		b.writes("// straight away.\n")
+	}
+	b.printf("class %s {\n", structName)
+	b.writes("public:\n")
+	if n.Suspendible() {
+		b.printf("%s() { %s__initialize(&c_, WUFFS_VERSION, 0); }\n", structName, cStructName)
+	} else {
+		b.printf("%s() : c_() {}\n", structName)
+	}
+	b.printf("%s(const %s&) = delete;\n", structName, structName)
+	b.printf("%s& operator=(const %s&) = delete;\n\n", structName, structName)
+
+	if err := g.forEachPublicFunc(n.QID(), func(o *a.Func) error {
+		return g.writeFunc(b, o)
+	}); err != nil {
+		return err
+	}
+
+	b.writes("// c returns the C struct, for calling the C API directly.\n")
+	b.printf("%s* c() { return &c_; }\n\n", cStructName)
+	b.writes("private:\n")
+	b.printf("%s c_;\n", cStructName)
+	b.writes("};\n\n")
+	return nil
+}
+
+// writeFunc writes a C++ function that calls the C function for n, converting
+// each argument and the return value.
+func (g *gen) writeFunc(b *buffer, n *a.Func) error {
+	funcName := n.FuncName().Str(g.tm)
+	cFuncName := g.pkgPrefix + funcName
+	if r := n.Receiver(); !r.IsZero() {
+		cFuncName = g.pkgPrefix + r.Str(g.tm) + "__" + funcName
+	}
+
+	retType, retConv := "", "%s"
+	if n.Suspendible() {
+		retType, retConv = "status", "static_cast<status>(%s)"
+	} else if outFields := n.Out().Fields(); len(outFields) == 0 {
+		retType = "void"
+	} else if len(outFields) == 1 {
+		typ, _, fromC, err := g.cppType(outFields[0].Field().XType())
+		if err != nil {
+			return err
+		}
+		retType, retConv = typ, fromC
+	} else {
+		return fmt.Errorf("TODO: multiple return values")
+	}
+
+	params := []string(nil)
+	args := []string(nil)
+	if !n.Receiver().IsZero() {
+		args = append(args, "&c_")
+	}
+	for _, o := range n.In().Fields() {
+		o := o.Field()
+		name := aPrefix + o.Name().Str(g.tm)
+		typ, toC, _, err := g.cppType(o.XType())
+		if err != nil {
+			return err
+		}
+		params = append(params, typ+" "+name)
+		args = append(args, fmt.Sprintf(toC, name))
+	}
+
+	b.printf("%s %s(%s) {\n", retType, funcName, strings.Join(params, ", "))
+	call := fmt.Sprintf(retConv, fmt.Sprintf("%s(%s)", cFuncName, strings.Join(args, ", ")))
+	if retType == "void" {
+		b.printf("%s;\n", call)
+	} else {
+		b.printf("return %s;\n", call)
+	}
+	b.writes("}\n\n")
+	return nil
+}
+
+// cppType returns the C++ type for a Wuffs type, plus two format strings. toC
+// converts a value of that C++ type to the C type that the C API takes. fromC
+// converts a value of that C type, returned by the C API, to the C++ type.
+func (g *gen) cppType(n *a.TypeExpr) (typ string, toC string, fromC string, err error) {
+	if n.IsSliceType() {
+		if o := n.Inner(); o.Decorator() == 0 && o.QID() == (t.QID{0, t.IDU8}) {
+			return "std::span<uint8_t>", "slice_u8(%s)", "span_u8(%s)", nil
+		}
+		return "", "", "", fmt.Errorf("cannot convert Wuffs type %q to C++", n.Str(g.tm))
+	}
+
+	numPointers := 0
+	for ; n.Decorator().Key() == t.KeyPtr; n = n.Inner() {
+		numPointers++
+	}
+	if n.Decorator() != 0 {
+		return "", "", "", fmt.Errorf("cannot convert Wuffs type %q to C++", n.Str(g.tm))
+	}
+
+	qid := n.QID()
+	if qid[0] == 0 {
+		if key := qid[1].Key(); key < t.Key(len(cppTypeNames)) {
+			if s := cppTypeNames[key]; s != "" {
+				switch key {
+				case t.KeyReader1, t.KeyWriter1:
+					if numPointers != 0 {
+						return "", "", "", fmt.Errorf("cannot convert Wuffs type %q to C++", n.Str(g.tm))
+					}
+					return s + "&", "%s.c()", "%s", nil
+				}
+				return s + strings.Repeat("*", numPointers), "%s", "%s", nil
+			}
+		}
+	}
+
+	// Other types, such as structs, are only passed by pointer, as the C++
+	// wrapper classes are not copyable.
+	if numPointers == 0 {
+		return "", "", "", fmt.Errorf("cannot convert Wuffs type %q to C++", n.Str(g.tm))
+	}
+	prefix := g.pkgPrefix
+	if qid[0] != 0 {
+		prefix = "wuffs_" + qid[0].Str(g.tm) + "__"
+	}
+	// TODO: remove this hack when "image_config" in Wuffs code becomes
+	// "base.image_config", and likewise for "frame_config".
+	if qid[1] == t.IDImageConfig || qid[1] == t.IDFrameConfig {
+		prefix = "wuffs_base__"
+	}
+	return prefix + qid[1].Str(g.tm) + strings.Repeat("*", numPointers), "%s", "%s", nil
+}
+
+var cppTypeNames = [...]string{
+	t.KeyI8:      "int8_t",
+	t.KeyI16:     "int16_t",
+	t.KeyI32:     "int32_t",
+	t.KeyI64:     "int64_t",
+	t.KeyU8:      "uint8_t",
+	t.KeyU16:     "uint16_t",
+	t.KeyU32:     "uint32_t",
+	t.KeyU64:     "uint64_t",
+	t.KeyUsize:   "size_t",
+	t.KeyBool:    "bool",
+	t.KeyReader1: "reader",
+	t.KeyWriter1: "writer",
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// gen.go converts base-header.hpp to data.go.
+//
+// Invoke it via "go generate".
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+)
+
+const columns = 1024
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func main1() error {
+	out := &bytes.Buffer{}
+	out.WriteString("// Code generated by running \"go generate\". DO NOT EDIT.\n")
+	out.WriteString("\n")
+	out.WriteString("// Copyright 2017 The Wuffs Authors.\n")
+	out.WriteString("//\n")
+	out.WriteString("// Licensed under the Apache License, Version 2.0 (the \"License\");\n")
+	out.WriteString("// you may not use this file except in compliance with the License.\n")
+	out.WriteString("// You may obtain a copy of the License at\n")
+	out.WriteString("//\n")
+	out.WriteString("//    https://www.apache.org/licenses/LICENSE-2.0\n")
+	out.WriteString("//\n")
+	out.WriteString("// Unless required by applicable law or agreed to in writing, software\n")
+	out.WriteString("// distributed under the License is distributed on an \"AS IS\" BASIS,\n")
+	out.WriteString("// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n")
+	out.WriteString("// See the License for the specific language governing permissions and\n")
+	out.WriteString("// limitations under the License.\n")
+	out.WriteString("\n")
+	out.WriteString("package cppgen\n")
+	out.WriteString("\n")
+
+	if err := genBase(out); err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile("data.go", formatted, 0644)
+}
+
+func genBase(out *bytes.Buffer) error {
+	files := []struct {
+		filename, varname string
+	}{
+		{"base-header.hpp", "baseHeader"},
+	}
+
+	for _, f := range files {
+		in, err := ioutil.ReadFile(f.filename)
+		if err != nil {
+			return err
+		}
+
+		const afterEditing = "// After editing this file,"
+		if !bytes.HasPrefix(in, []byte(afterEditing)) {
+			return fmt.Errorf("%s's contents do not start with %q", f.filename, afterEditing)
+		}
+		if i := bytes.Index(in, []byte("\n\n")); i >= 0 {
+			in = in[i+2:]
+		}
+
+		fmt.Fprintf(out, "const %s = \"\" +\n", f.varname)
+		for len(in) > 0 {
+			s := in
+			if len(s) > columns {
+				s = s[:columns]
+			}
+			in = in[len(s):]
+			fmt.Fprintf(out, "%q +\n", s)
+		}
+		out.WriteString("\"\"\n\n")
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-// Copyright 2018 The Wuffs Authors.
+// Copyright 2017 The Wuffs Authors.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -12,18 +12,17 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build ignore
-
-package main
-
-// print-crc32-magic-numbers.go prints the std/crc32 magic number tables.
+// wuffs-cpp handles the C++ language specific parts of the wuffs tool.
 //
-// Usage: go run print-crc32-magic-numbers.go
+// It generates a header that wraps the C code generated by wuffs-c, so the
+// "cpp" language needs the "c" one.
+package main
 
 import (
 	"fmt"
-	"hash/crc32"
 	"os"
+
+	"github.com/google/wuffs/cmd/wuffs-cpp/internal/cppgen"
 )
 
 func main() {
@@ -34,11 +33,13 @@ func main() {
 }
 
 func main1() error {
-	for i, x := range crc32.MakeTable(crc32.IEEE) {
-		fmt.Printf("0x%08X,", x)
-		if i&7 == 7 {
-			fmt.Println()
-		}
+	if len(os.Args) < 2 {
+		return fmt.Errorf("no sub-command given")
+	}
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "gen":
+		return cppgen.Do(args)
 	}
-	return nil
+	return fmt.Errorf("bad sub-command %q", os.Args[1])
 }
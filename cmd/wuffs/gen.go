@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -39,25 +40,78 @@ func doGenlib(wuffsRoot string, args []string) error { return doGenGenlib(wuffsR
 
 func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	amalgamateFlag := flags.Bool("amalgamate", cf.AmalgamateDefault, cf.AmalgamateUsage)
+	dceReportFlag := flags.Bool("dce_report", cf.DCEReportDefault, cf.DCEReportUsage)
+	entryFlag := flags.String("entry", cf.EntryDefault, cf.EntryUsage)
+	freestandingFlag := flags.Bool("freestanding", cf.FreestandingDefault, cf.FreestandingUsage)
 	langsFlag := flags.String("langs", langsDefault, langsUsage)
+	onlyFlag := flags.String("only", cf.OnlyDefault, cf.OnlyUsage)
 	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	transitivityDepthFlag := flags.Int("transitivity_depth", cf.TransitivityDepthDefault, cf.TransitivityDepthUsage)
 
 	if err := flags.Parse(args); err != nil {
 		return err
 	}
+	if *amalgamateFlag && !genlib {
+		return fmt.Errorf("the -amalgamate flag is only for genlib, not gen")
+	}
+	if *onlyFlag != "" && !genlib {
+		return fmt.Errorf("the -only flag is only for genlib, not gen")
+	}
 	langs, err := parseLangs(*langsFlag)
 	if err != nil {
 		return err
 	}
+	if err := checkLangsNeedC(langs); err != nil {
+		return err
+	}
+	if err := checkTransitivityDepth(*transitivityDepthFlag); err != nil {
+		return err
+	}
+	if *freestandingFlag {
+		for _, lang := range langs {
+			if lang == "cgo" || lang == "cpp" {
+				return fmt.Errorf("the -freestanding flag cannot be combined with lang %q, "+
+					"whose wrappers need a C or C++ library", lang)
+			}
+		}
+	}
 	args = flags.Args()
+	if *entryFlag != "" {
+		// Entry points are per-package, so they only apply to one named
+		// package, not to its dependencies.
+		if len(args) != 1 || strings.HasSuffix(args[0], "/...") {
+			return fmt.Errorf("the -entry flag needs exactly one package, e.g. std/gif")
+		}
+		for _, lang := range langs {
+			if lang == "cgo" || lang == "cpp" {
+				return fmt.Errorf("the -entry flag cannot be combined with lang %q, "+
+					"whose wrappers need every public function", lang)
+			}
+		}
+		if !cf.IsAlphaNumericIsh(*entryFlag) {
+			return fmt.Errorf("bad -entry flag value %q", *entryFlag)
+		}
+	}
 	if len(args) == 0 {
-		args = []string{"std/..."}
+		if *onlyFlag != "" {
+			args = strings.Split(*onlyFlag, ",")
+		} else {
+			args = []string{"std/..."}
+		}
 	}
 
 	h := genHelper{
-		wuffsRoot:   wuffsRoot,
-		langs:       langs,
-		skipgendeps: *skipgendepsFlag,
+		wuffsRoot:         wuffsRoot,
+		amalgamate:        *amalgamateFlag,
+		dceReport:         *dceReportFlag,
+		entry:             *entryFlag,
+		entryDirname:      args[0],
+		freestanding:      *freestandingFlag,
+		langs:             langs,
+		only:              *onlyFlag,
+		skipgendeps:       *skipgendepsFlag,
+		transitivityDepth: *transitivityDepthFlag,
 	}
 
 	for _, arg := range args {
@@ -79,16 +133,49 @@ func doGenGenlib(wuffsRoot string, args []string, genlib bool) error {
 	return nil
 }
 
+// checkLangsNeedC checks that, if "cpp" or "cgo" is one of the langs, then "c"
+// is an earlier one, as their generated code wraps the generated C code.
+func checkLangsNeedC(langs []string) error {
+	for _, lang := range langs {
+		switch lang {
+		case "c":
+			return nil
+		case "cgo", "cpp":
+			return fmt.Errorf(`lang %q needs an earlier lang "c", e.g. -langs=c,%s`, lang, lang)
+		}
+	}
+	return nil
+}
+
 type genHelper struct {
-	wuffsRoot   string
-	langs       []string
-	skipgendeps bool
+	wuffsRoot         string
+	amalgamate        bool
+	ccompilers        string
+	dceReport         bool
+	entry             string
+	entryDirname      string
+	freestanding      bool
+	langs             []string
+	only              string
+	skipgendeps       bool
+	transitivityDepth int
+
+	// progressW, if non-nil, is where to print progress messages, instead
+	// of stdout, such as when stdout is for a report.
+	progressW io.Writer
 
 	affected []string
 	seen     map[string]struct{}
 	tm       t.Map
 }
 
+func (h *genHelper) progress() io.Writer {
+	if h.progressW != nil {
+		return h.progressW
+	}
+	return os.Stdout
+}
+
 func (h *genHelper) gen(dirname string, recursive bool) error {
 	if h.seen == nil {
 		h.seen = map[string]struct{}{}
@@ -138,11 +225,25 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 			return err
 		}
 	}
-	cmdArgs := []string{"gen", "-package_name", packageName}
-	cmdArgs = append(cmdArgs, qualifiedFilenames...)
-
 	for _, lang := range h.langs {
 		command := "wuffs-" + lang
+		cmdArgs := []string{"gen", "-package_name", packageName,
+			fmt.Sprintf("-transitivity_depth=%d", h.transitivityDepth)}
+		if lang == "c" {
+			if h.dceReport {
+				cmdArgs = append(cmdArgs, "-dce_report")
+			}
+			// The -entry flag only applies to the package named on the
+			// command line, not to its dependencies.
+			if h.entry != "" && h.entryDirname == dirname {
+				cmdArgs = append(cmdArgs, "-entry", h.entry)
+			}
+			if h.freestanding {
+				cmdArgs = append(cmdArgs, "-freestanding")
+			}
+		}
+		cmdArgs = append(cmdArgs, qualifiedFilenames...)
+
 		stdout := &bytes.Buffer{}
 		cmd := exec.Command(command, cmdArgs...)
 		cmd.Stdin = nil
@@ -156,7 +257,26 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 			return err
 		}
 		out := stdout.Bytes()
-		if err := h.genFile(dirname, lang, out); err != nil {
+
+		// Special-case the "cpp" generator, whose output is a header that
+		// includes the "c" generator's .h file, so it lives alongside it.
+		if lang == "cpp" {
+			if err := h.genFile(dirname, "h", "hpp", out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Special-case the "cgo" generator, whose output is a Go package and
+		// so needs its own directory, e.g. "gen/cgo/std/gzip/gzip.go".
+		if lang == "cgo" {
+			if err := h.genFile(dirname+"/"+packageName, "cgo", "go", out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := h.genFile(dirname, lang, lang, out); err != nil {
 			return err
 		}
 
@@ -169,7 +289,7 @@ func (h *genHelper) genDir(dirname string, filenames []string) error {
 		} else {
 			out = out[:i]
 		}
-		if err := h.genFile(dirname, "h", out); err != nil {
+		if err := h.genFile(dirname, "h", "h", out); err != nil {
 			return err
 		}
 	}
@@ -203,10 +323,10 @@ func (h *genHelper) genDirDependencies(qualifiedFilenames []string) error {
 	return nil
 }
 
-func (h *genHelper) genFile(dirname string, lang string, out []byte) error {
-	outFilename := filepath.Join(h.wuffsRoot, "gen", lang, filepath.FromSlash(dirname)+"."+lang)
+func (h *genHelper) genFile(dirname string, lang string, ext string, out []byte) error {
+	outFilename := filepath.Join(h.wuffsRoot, "gen", lang, filepath.FromSlash(dirname)+"."+ext)
 	if existing, err := ioutil.ReadFile(outFilename); err == nil && bytes.Equal(existing, out) {
-		fmt.Println("gen unchanged: ", outFilename)
+		fmt.Fprintln(h.progress(), "gen unchanged: ", outFilename)
 		return nil
 	}
 	if err := os.MkdirAll(filepath.Dir(outFilename), 0755); err != nil {
@@ -215,7 +335,7 @@ func (h *genHelper) genFile(dirname string, lang string, out []byte) error {
 	if err := ioutil.WriteFile(outFilename, out, 0644); err != nil {
 		return err
 	}
-	fmt.Println("gen wrote:     ", outFilename)
+	fmt.Fprintln(h.progress(), "gen wrote:     ", outFilename)
 	return nil
 }
 
@@ -288,6 +408,19 @@ func (h *genHelper) genWuffs(dirname string, qualifiedFilenames []string) error
 				}
 				fmt.Fprintf(out, ") { }\n")
 
+			case a.KLemma:
+				n := n.Lemma()
+				if !n.Public() {
+					continue
+				}
+				// The proof steps are not written. The lemma was proven when
+				// checking this package.
+				fmt.Fprintf(out, "pub lemma %s: %s", n.Name().Str(&h.tm), n.Conclusion().Str(&h.tm))
+				for _, o := range n.Premises() {
+					fmt.Fprintf(out, "; %s", o.Expr().Str(&h.tm))
+				}
+				fmt.Fprintf(out, "\n")
+
 			case a.KStatus:
 				n := n.Status()
 				if !n.Public() {
@@ -308,16 +441,31 @@ func (h *genHelper) genWuffs(dirname string, qualifiedFilenames []string) error
 			}
 		}
 	}
-	return h.genFile(dirname, "wuffs", out.Bytes())
+	return h.genFile(dirname, "wuffs", "wuffs", out.Bytes())
 }
 
 func (h *genHelper) genlibAffected() error {
 	for _, lang := range h.langs {
+		// The "cgo" and "cpp" generators' output wraps the "c" generator's
+		// code, and has no library of its own.
+		if lang == "cgo" || lang == "cpp" {
+			continue
+		}
 		command := "wuffs-" + lang
 		args := []string{"genlib"}
+		if h.amalgamate {
+			args = append(args, "-amalgamate")
+		}
+		if h.ccompilers != "" {
+			args = append(args, "-ccompilers", h.ccompilers)
+		}
 		args = append(args, "-dstdir", filepath.Join(h.wuffsRoot, "gen", "lib", lang))
 		args = append(args, "-srcdir", filepath.Join(h.wuffsRoot, "gen", lang))
-		args = append(args, h.affected...)
+		if h.only != "" {
+			args = append(args, "-only", h.only)
+		} else {
+			args = append(args, h.affected...)
+		}
 		cmd := exec.Command(command, args...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
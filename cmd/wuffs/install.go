@@ -0,0 +1,90 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+func doInstall(wuffsRoot string, args []string) error {
+	flags := flag.NewFlagSet("install", flag.ExitOnError)
+	ccompilerFlag := flags.String("ccompiler", cf.CcompilerDefault, cf.CcompilerUsage)
+	prefixFlag := flags.String("prefix", cf.PrefixDefault, cf.PrefixUsage)
+	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	transitivityDepthFlag := flags.Int("transitivity_depth", cf.TransitivityDepthDefault, cf.TransitivityDepthUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !cf.IsAlphaNumericIsh(*ccompilerFlag) || strings.Contains(*ccompilerFlag, ",") {
+		return fmt.Errorf("bad -ccompiler flag value %q", *ccompilerFlag)
+	}
+	if *prefixFlag == "" {
+		return fmt.Errorf("empty -prefix flag")
+	}
+	if err := checkTransitivityDepth(*transitivityDepthFlag); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		args = []string{"std/..."}
+	}
+
+	h := genHelper{
+		wuffsRoot:         wuffsRoot,
+		ccompilers:        *ccompilerFlag,
+		langs:             []string{"c"},
+		skipgendeps:       *skipgendepsFlag,
+		transitivityDepth: *transitivityDepthFlag,
+	}
+
+	for _, arg := range args {
+		recursive := strings.HasSuffix(arg, "/...")
+		if recursive {
+			arg = arg[:len(arg)-4]
+		}
+		if arg == "" {
+			continue
+		}
+		if err := h.gen(arg, recursive); err != nil {
+			return err
+		}
+	}
+	if len(h.affected) == 0 {
+		return fmt.Errorf("no packages to install")
+	}
+	if err := h.genlibAffected(); err != nil {
+		return err
+	}
+
+	cmdArgs := []string{"install",
+		"-ccompiler", *ccompilerFlag,
+		"-hdrdir", filepath.Join(wuffsRoot, "gen", "h"),
+		"-libdir", filepath.Join(wuffsRoot, "gen", "lib", "c"),
+		"-prefix", *prefixFlag,
+	}
+	cmdArgs = append(cmdArgs, h.affected...)
+	cmd := exec.Command("wuffs-c", cmdArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -24,6 +24,8 @@ import (
 	"strings"
 
 	"github.com/google/wuffs/lang/generate"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
 )
 
 var commands = []struct {
@@ -33,6 +35,8 @@ var commands = []struct {
 	{"bench", doBench},
 	{"gen", doGen},
 	{"genlib", doGenlib},
+	{"install", doInstall},
+	{"size", doSize},
 	{"test", doTest},
 }
 
@@ -48,6 +52,8 @@ The commands are:
 	bench   benchmark packages
 	gen     generate code for packages and dependencies
 	genlib  generate software libraries
+	install install software libraries, headers and build system files
+	size    report code and struct sizes of packages
 	test    test packages
 `)
 }
@@ -117,6 +123,14 @@ const (
 	skipgendepsUsage   = `whether to skip automatically generating packages' dependencies`
 )
 
+func checkTransitivityDepth(depth int) error {
+	if depth < cf.TransitivityDepthMin || cf.TransitivityDepthMax < depth {
+		return fmt.Errorf("bad -transitivity_depth flag value %d, outside the range [%d..%d]",
+			depth, cf.TransitivityDepthMin, cf.TransitivityDepthMax)
+	}
+	return nil
+}
+
 func parseLangs(commaSeparated string) ([]string, error) {
 	ret := []string(nil)
 	for _, s := range strings.Split(commaSeparated, ",") {
@@ -0,0 +1,239 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cf "github.com/google/wuffs/cmd/commonflags"
+)
+
+const (
+	baselineDefault = ""
+	baselineUsage   = `the filename of a previous "wuffs size" report to compare against`
+)
+
+func doSize(wuffsRoot string, args []string) error {
+	flags := flag.NewFlagSet("size", flag.ExitOnError)
+	baselineFlag := flags.String("baseline", baselineDefault, baselineUsage)
+	ccompilersFlag := flags.String("ccompilers", cf.CcompilersDefault, cf.CcompilersUsage)
+	skipgenFlag := flags.Bool("skipgen", skipgenDefault, skipgenUsage)
+	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	transitivityDepthFlag := flags.Int("transitivity_depth", cf.TransitivityDepthDefault, cf.TransitivityDepthUsage)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !cf.IsAlphaNumericIsh(*ccompilersFlag) {
+		return fmt.Errorf("bad -ccompilers flag value %q", *ccompilersFlag)
+	}
+	if err := checkTransitivityDepth(*transitivityDepthFlag); err != nil {
+		return err
+	}
+
+	baseline := (*sizeReport)(nil)
+	if *baselineFlag != "" {
+		src, err := ioutil.ReadFile(*baselineFlag)
+		if err != nil {
+			return err
+		}
+		if baseline, err = parseSizeReport(src); err != nil {
+			return fmt.Errorf("%s: %v", *baselineFlag, err)
+		}
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		args = []string{"std/..."}
+	}
+
+	h := sizeHelper{
+		wuffsRoot:         wuffsRoot,
+		ccompilers:        *ccompilersFlag,
+		transitivityDepth: *transitivityDepthFlag,
+	}
+	for _, arg := range args {
+		recursive := strings.HasSuffix(arg, "/...")
+		if recursive {
+			arg = arg[:len(arg)-4]
+		}
+
+		// Generate the packages' dependencies, whose headers the generated
+		// code includes. Stdout is for the report, so print progress to
+		// stderr.
+		if !*skipgenFlag {
+			gh := genHelper{
+				wuffsRoot:         wuffsRoot,
+				langs:             []string{"c"},
+				skipgendeps:       *skipgendepsFlag,
+				transitivityDepth: *transitivityDepthFlag,
+				progressW:         os.Stderr,
+			}
+			if err := gh.gen(arg, recursive); err != nil {
+				return err
+			}
+		}
+
+		if err := h.size(arg, recursive); err != nil {
+			return err
+		}
+	}
+
+	if baseline == nil {
+		_, err := os.Stdout.Write(h.report.Bytes())
+		return err
+	}
+	current, err := parseSizeReport(h.report.Bytes())
+	if err != nil {
+		return err
+	}
+	writeSizeDiff(os.Stdout, baseline, current)
+	return nil
+}
+
+type sizeHelper struct {
+	wuffsRoot         string
+	ccompilers        string
+	transitivityDepth int
+
+	report bytes.Buffer
+}
+
+func (h *sizeHelper) size(dirname string, recursive bool) error {
+	filenames, dirnames, err := listDir(h.wuffsRoot, dirname, recursive)
+	if err != nil {
+		return err
+	}
+	if len(filenames) > 0 {
+		if err := h.sizeDir(dirname, filenames); err != nil {
+			return err
+		}
+	}
+	for _, d := range dirnames {
+		if err := h.size(path.Join(dirname, d), recursive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *sizeHelper) sizeDir(dirname string, filenames []string) error {
+	packageName := path.Base(dirname)
+	if !validName(packageName) {
+		return fmt.Errorf(`invalid package %q, not in [a-z0-9]+`, packageName)
+	}
+	cmdArgs := []string{"size", "-package_name", packageName,
+		fmt.Sprintf("-ccompilers=%s", h.ccompilers),
+		fmt.Sprintf("-transitivity_depth=%d", h.transitivityDepth)}
+	for _, filename := range filenames {
+		cmdArgs = append(cmdArgs, filepath.Join(h.wuffsRoot, filepath.FromSlash(dirname), filename))
+	}
+
+	cmd := exec.Command("wuffs-c", cmdArgs...)
+	cmd.Stdout = &h.report
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err == nil {
+		// No-op.
+	} else if _, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("wuffs-c: size %s: failed", dirname)
+	} else {
+		return err
+	}
+	return nil
+}
+
+// sizeReport holds the sizes of a "wuffs size" report, whose lines are "cc
+// opt kind name size". The sizes are keyed by the rest of their line, and the
+// keys are in report order.
+type sizeReport struct {
+	sizes map[string]uint64
+	keys  []string
+}
+
+func parseSizeReport(src []byte) (*sizeReport, error) {
+	r := &sizeReport{sizes: map[string]uint64{}}
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		line := s.Text()
+		if len(strings.Fields(line)) != 5 {
+			return nil, fmt.Errorf("bad size report line %q", line)
+		}
+		i := strings.LastIndexByte(line, ' ')
+		size, err := strconv.ParseUint(line[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad size report line %q", line)
+		}
+		key := line[:i]
+		if _, ok := r.sizes[key]; !ok {
+			r.keys = append(r.keys, key)
+		}
+		r.sizes[key] = size
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// writeSizeDiff writes the sizes that differ between the baseline and current
+// reports, as "key old -> new (delta)" lines, where a missing size is "-".
+func writeSizeDiff(w io.Writer, baseline *sizeReport, current *sizeReport) {
+	unchanged := 0
+	for _, key := range current.keys {
+		c := current.sizes[key]
+		if b, ok := baseline.sizes[key]; !ok {
+			fmt.Fprintf(w, "%s - -> %d\n", key, c)
+		} else if b != c {
+			fmt.Fprintf(w, "%s %d -> %d (%+d)\n", key, b, c, int64(c)-int64(b))
+		} else {
+			unchanged++
+		}
+	}
+	// Report the baseline's sizes that are no longer present, such as for
+	// eliminated or inlined functions, but only for the C compilers and
+	// packages in the current report. The baseline can hold more of those.
+	groups := map[string]bool{}
+	for _, key := range current.keys {
+		groups[sizeKeyGroup(key)] = true
+	}
+	for _, key := range baseline.keys {
+		if _, ok := current.sizes[key]; !ok && groups[sizeKeyGroup(key)] {
+			fmt.Fprintf(w, "%s %d -> -\n", key, baseline.sizes[key])
+		}
+	}
+	fmt.Fprintf(w, "%d sizes unchanged\n", unchanged)
+}
+
+// sizeKeyGroup returns the C compiler and package, such as "gcc wuffs_gif", of
+// a size report key, such as "gcc -O2 text wuffs_gif__decoder__decode_frame".
+func sizeKeyGroup(key string) string {
+	cc := key[:strings.IndexByte(key, ' ')]
+	name := key[strings.LastIndexByte(key, ' ')+1:]
+	if i := strings.Index(name, "__"); i >= 0 {
+		name = name[:i]
+	}
+	return cc + " " + name
+}
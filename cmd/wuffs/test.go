@@ -17,6 +17,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -37,6 +38,7 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	repsFlag := flags.Int("reps", cf.RepsDefault, cf.RepsUsage)
 	skipgenFlag := flags.Bool("skipgen", skipgenDefault, skipgenUsage)
 	skipgendepsFlag := flags.Bool("skipgendeps", skipgendepsDefault, skipgendepsUsage)
+	transitivityDepthFlag := flags.Int("transitivity_depth", cf.TransitivityDepthDefault, cf.TransitivityDepthUsage)
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -55,6 +57,9 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	if *repsFlag < cf.RepsMin || cf.RepsMax < *repsFlag {
 		return fmt.Errorf("bad -reps flag value %d, outside the range [%d..%d]", *repsFlag, cf.RepsMin, cf.RepsMax)
 	}
+	if err := checkTransitivityDepth(*transitivityDepthFlag); err != nil {
+		return err
+	}
 
 	args = flags.Args()
 	if len(args) == 0 {
@@ -77,6 +82,7 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 	h := testHelper{
 		wuffsRoot:  wuffsRoot,
 		langs:      langs,
+		bench:      bench,
 		cmdArgs:    cmdArgs,
 		ccompilers: *ccompilersFlag,
 	}
@@ -91,9 +97,10 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 		// Ensure that we are testing the latest version of the generated code.
 		if !*skipgenFlag {
 			gh := genHelper{
-				wuffsRoot:   wuffsRoot,
-				langs:       langs,
-				skipgendeps: *skipgendepsFlag,
+				wuffsRoot:         wuffsRoot,
+				langs:             langs,
+				skipgendeps:       *skipgendepsFlag,
+				transitivityDepth: *transitivityDepthFlag,
 			}
 			if err := gh.gen(arg, recursive); err != nil {
 				return err
@@ -120,6 +127,7 @@ func doBenchTest(wuffsRoot string, args []string, bench bool) error {
 type testHelper struct {
 	wuffsRoot  string
 	langs      []string
+	bench      bool
 	cmdArgs    []string
 	ccompilers string
 }
@@ -154,6 +162,27 @@ func (h *testHelper) benchTestDir(dirname string) (failed bool, err error) {
 	}
 
 	for _, lang := range h.langs {
+		if lang == "c" && !h.bench {
+			f, err := h.checkFreestanding(dirname)
+			if err != nil {
+				return false, err
+			}
+			failed = failed || f
+		}
+
+		// There are no C++ tests as such, as the generated C++ header only
+		// wraps the C API, but check that the header compiles.
+		if lang == "cpp" {
+			if !h.bench {
+				f, err := h.checkCppHeader(dirname)
+				if err != nil {
+					return false, err
+				}
+				failed = failed || f
+			}
+			continue
+		}
+
 		command := "wuffs-" + lang
 		args := []string(nil)
 		args = append(args, h.cmdArgs...)
@@ -174,3 +203,107 @@ func (h *testHelper) benchTestDir(dirname string) (failed bool, err error) {
 	}
 	return failed, nil
 }
+
+// checkCppHeader checks that the package's generated C++ header compiles, for
+// the C++ counterpart of each C compiler, such as g++ for gcc.
+func (h *testHelper) checkCppHeader(dirname string) (failed bool, err error) {
+	in := filepath.Join(h.wuffsRoot, "gen", "h", filepath.FromSlash(dirname)+".hpp")
+	for _, cc := range strings.Split(h.ccompilers, ",") {
+		cc = strings.TrimSpace(cc)
+		if cc == "" {
+			continue
+		}
+		cxx := cppCompiler(cc)
+		// Print like the C tests, e.g. "std/gif.hpp     g++     PASS".
+		prefix := fmt.Sprintf("%-16s%-8s", dirname+".hpp", cxx)
+
+		// The header uses std::span, which needs C++20.
+		cxxCmd := exec.Command(cxx, "-std=c++20", "-Wall", "-Werror",
+			"-fsyntax-only", "-x", "c++", in)
+		cxxCmd.Stdout = os.Stdout
+		cxxCmd.Stderr = os.Stderr
+		if err := cxxCmd.Run(); err == nil {
+			fmt.Printf("%sPASS (compiles)\n", prefix)
+		} else if _, ok := err.(*exec.ExitError); ok {
+			fmt.Printf("%sFAIL: could not compile\n", prefix)
+			failed = true
+		} else {
+			return false, err
+		}
+	}
+	return failed, nil
+}
+
+// cppCompiler returns the C++ compiler that accompanies the C compiler cc.
+func cppCompiler(cc string) string {
+	switch {
+	case strings.HasSuffix(cc, "gcc"):
+		return cc[:len(cc)-3] + "g++"
+	case strings.HasSuffix(cc, "clang"):
+		return cc + "++"
+	case strings.HasSuffix(cc, "cc"):
+		return cc[:len(cc)-2] + "++"
+	}
+	return cc
+}
+
+// checkFreestanding checks that the package's generated C code, with
+// WUFFS_CONFIG__FREESTANDING defined, as by "wuffs gen -freestanding", builds
+// with -ffreestanding -nostdlib and needs no symbols other than Wuffs' own,
+// such as those of the packages that it uses.
+func (h *testHelper) checkFreestanding(dirname string) (failed bool, err error) {
+	workDir, err := ioutil.TempDir("", "wuffs")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(workDir)
+
+	in := filepath.Join(h.wuffsRoot, "gen", "c", filepath.FromSlash(dirname)+".c")
+	out := filepath.Join(workDir, "freestanding.so")
+	for _, cc := range strings.Split(h.ccompilers, ",") {
+		cc = strings.TrimSpace(cc)
+		if cc == "" {
+			continue
+		}
+		// Print like the C tests, e.g. "std/gif.c       gcc     PASS".
+		prefix := fmt.Sprintf("%-16s%-8s", dirname+".c", cc)
+
+		ccCmd := exec.Command(cc, "-std=c99", "-Wall", "-Werror",
+			"-ffreestanding", "-nostdlib", "-DWUFFS_CONFIG__FREESTANDING",
+			"-shared", "-fPIC", "-o", out, in)
+		ccCmd.Stdout = os.Stdout
+		ccCmd.Stderr = os.Stderr
+		if err := ccCmd.Run(); err == nil {
+			// No-op.
+		} else if _, ok := err.(*exec.ExitError); ok {
+			fmt.Printf("%sFAIL freestanding: could not compile\n", prefix)
+			failed = true
+			continue
+		} else {
+			return false, err
+		}
+
+		nmOut, err := exec.Command("nm", "-u", out).Output()
+		if err != nil {
+			return false, fmt.Errorf("nm %s: %v", out, err)
+		}
+		undefined := []string(nil)
+		for _, line := range strings.Split(string(nmOut), "\n") {
+			// Each line is like "U name", the name of an undefined symbol.
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			if name := fields[len(fields)-1]; !strings.HasPrefix(name, "wuffs_") {
+				undefined = append(undefined, name)
+			}
+		}
+		if len(undefined) > 0 {
+			fmt.Printf("%sFAIL freestanding: undefined symbols %s\n", prefix, strings.Join(undefined, ", "))
+			failed = true
+			continue
+		}
+		fmt.Printf("%sPASS (freestanding)\n", prefix)
+	}
+	return failed, nil
+}
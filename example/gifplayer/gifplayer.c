@@ -74,6 +74,10 @@ const char* play() {
       .ptr = src_buffer, .len = src_len, .wi = src_len, .closed = true};
   wuffs_base__reader1 src_reader = {.buf = &src};
 
+  // Decode to BGRA pixels, instead of palette indexes, so that this program
+  // does not have to look up each frame's palette.
+  wuffs_gif__decoder__set_pixel_format(&dec, WUFFS_BASE__PIXEL_FORMAT__BGRA);
+
   wuffs_base__image_config ic = {{0}};
   wuffs_gif__status s =
       wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
@@ -90,7 +94,7 @@ const char* play() {
   }
   if (!dst_buffer) {
     dst_len = wuffs_base__image_config__pixbuf_size(&ic);
-    dst_buffer = malloc(dst_len);
+    dst_buffer = calloc(dst_len, 1);
     if (!dst_buffer) {
       return "could not allocate dst buffer";
     }
@@ -111,13 +115,14 @@ const char* play() {
   }
 
   while (true) {
-    wuffs_base__buf1 dst = {.ptr = dst_buffer, .len = dst_len};
-    wuffs_base__writer1 dst_writer = {.buf = &dst};
-    // TODO: provide API and support for when the frame rect is different from
-    // the image rect.
-    s = wuffs_gif__decoder__decode_frame(&dec, dst_writer, src_reader);
+    // Each frame is decoded onto the previous frames, as dst_buffer holds the
+    // whole image.
+    //
+    // TODO: apply each frame's disposal method.
+    wuffs_base__slice_u8 dst = {.ptr = dst_buffer, .len = dst_len};
+    s = wuffs_gif__decoder__decode_frame(&dec, dst, src_reader);
     if (s) {
-      if (s == WUFFS_GIF__SUSPENSION_END_OF_DATA) {
+      if (s == WUFFS_GIF__SUSPENSION_END_OF_ANIMATION) {
         break;
       }
       return wuffs_gif__status__string(s);
@@ -136,10 +141,15 @@ const char* play() {
     for (y = 0; y < height; y++) {
       uint32_t x;
       for (x = 0; x < width; x++) {
-        uint8_t palette_index = *d++;
-        // TODO: translate the palette_index into an (R, G, B) triple, and then
-        // to a uint8_t grayscale value.
-        *p++ = "-+X@"[palette_index >> 6];
+        // Convert the (B, G, R, A) pixel to a grayscale value, ignoring A,
+        // using the JFIF luma weights (0.299, 0.587 and 0.114), scaled by
+        // 65536.
+        uint32_t gray =
+            (19595 * (uint32_t)(d[2]) + 38470 * (uint32_t)(d[1]) +
+             7471 * (uint32_t)(d[0]) + 32768) >>
+            16;
+        d += 4;
+        *p++ = "-+X@"[gray >> 6];
       }
       *p++ = '\n';
     }
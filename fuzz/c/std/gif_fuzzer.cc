@@ -64,17 +64,10 @@ void fuzz(wuffs_base__reader1 src_reader, uint32_t hash) {
       goto exit;
     }
 
-    wuffs_base__buf1 dst = {.ptr = (uint8_t*)(pixbuf), .len = pixbuf_size};
-    wuffs_base__writer1 dst_writer = {.buf = &dst};
+    wuffs_base__slice_u8 dst = {.ptr = (uint8_t*)(pixbuf), .len = pixbuf_size};
 
     while (true) {
-      // TODO: handle the frame rect being larger than the image rect. The
-      // GIF89a spec doesn't disallow this and the Wuffs code tolerates it, in
-      // that it returns a "short write" (because the dst buffer is too small)
-      // and will not e.g. write past the buffer bounds. But the Wuffs GIF API
-      // should somehow pass a subset of pixbuf to decode_frame.
-      dst.wi = 0;
-      s = wuffs_gif__decoder__decode_frame(&dec, dst_writer, src_reader);
+      s = wuffs_gif__decoder__decode_frame(&dec, dst, src_reader);
       if (s) {
         break;
       }
@@ -0,0 +1,92 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Silence the nested slash-star warning for the next comment's command line.
+#pragma clang diagnostic push
+#pragma clang diagnostic ignored "-Wcomment"
+
+/*
+This fuzzer (the fuzz function) is typically run indirectly, by a framework
+such as https://github.com/google/oss-fuzz calling LLVMFuzzerTestOneInput.
+
+When working on the fuzz implementation, or as a sanity check, defining
+WUFFS_CONFIG__FUZZLIB_MAIN will let you manually run fuzz over a set of files:
+
+g++ -DWUFFS_CONFIG__FUZZLIB_MAIN jpeg_fuzzer.cc
+./a.out ../../../test/data/*.jpeg
+rm -f ./a.out
+
+It should print "PASS", amongst other information, and exit(0).
+*/
+
+#pragma clang diagnostic pop
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/jpeg.c"
+#include "../fuzzlib/fuzzlib.cc"
+
+void fuzz(wuffs_base__reader1 src_reader, uint32_t hash) {
+  void* pixbuf = NULL;
+  void* workbuf = NULL;
+
+  // Use a {} code block so that "goto exit" doesn't trigger "jump bypasses
+  // variable initialization" warnings.
+  {
+    wuffs_jpeg__status s;
+    wuffs_jpeg__decoder dec;
+    wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+    wuffs_base__image_config ic = {{0}};
+    s = wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+    if (s || !wuffs_base__image_config__valid(&ic)) {
+      goto exit;
+    }
+
+    size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+    // Don't try to allocate more than 64 MiB.
+    if (pixbuf_size > 64 * 1024 * 1024) {
+      goto exit;
+    }
+    pixbuf = malloc(pixbuf_size);
+    if (!pixbuf) {
+      goto exit;
+    }
+
+    // Progressive JPEGs need a workbuf, 2 bytes per DCT coefficient. Again,
+    // don't try to allocate more than 64 MiB.
+    uint64_t workbuf_size = wuffs_jpeg__decoder__workbuf_size(&dec);
+    if (workbuf_size > 64 * 1024 * 1024) {
+      goto exit;
+    }
+    workbuf = malloc(workbuf_size ? workbuf_size : 1);
+    if (!workbuf) {
+      goto exit;
+    }
+
+    wuffs_base__slice_u8 dst = {.ptr = (uint8_t*)(pixbuf), .len = pixbuf_size};
+    wuffs_base__slice_u8 work = {.ptr = (uint8_t*)(workbuf),
+                                 .len = (size_t)(workbuf_size)};
+    s = wuffs_jpeg__decoder__decode_frame(&dec, dst, work, src_reader);
+  }
+
+exit:
+  if (workbuf) {
+    free(workbuf);
+  }
+  if (pixbuf) {
+    free(pixbuf);
+  }
+}
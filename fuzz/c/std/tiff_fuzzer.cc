@@ -0,0 +1,109 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Silence the nested slash-star warning for the next comment's command line.
+#pragma clang diagnostic push
+#pragma clang diagnostic ignored "-Wcomment"
+
+/*
+This fuzzer (the fuzz function) is typically run indirectly, by a framework
+such as https://github.com/google/oss-fuzz calling LLVMFuzzerTestOneInput.
+
+When working on the fuzz implementation, or as a sanity check, defining
+WUFFS_CONFIG__FUZZLIB_MAIN will let you manually run fuzz over a set of files:
+
+g++ -DWUFFS_CONFIG__FUZZLIB_MAIN tiff_fuzzer.cc
+./a.out ../../../test/data/*.tiff ../../../test/data/artificial/*.tiff
+rm -f ./a.out
+
+It should print "PASS", amongst other information, and exit(0).
+*/
+
+#pragma clang diagnostic pop
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/tiff.c"
+#include "../fuzzlib/fuzzlib.cc"
+
+// seek re-positions src_reader after a "mispositioned read" suspension. All
+// of the input is in memory, so this only has to change the read index.
+bool seek(wuffs_tiff__decoder* dec, wuffs_base__reader1 src_reader) {
+  uint64_t pos = wuffs_tiff__decoder__seek_position(dec);
+  if (pos > src_reader.buf->wi) {
+    return false;
+  }
+  src_reader.buf->ri = pos;
+  return true;
+}
+
+void fuzz(wuffs_base__reader1 src_reader, uint32_t hash) {
+  void* pixbuf = NULL;
+
+  // Use a {} code block so that "goto exit" doesn't trigger "jump bypasses
+  // variable initialization" warnings.
+  {
+    wuffs_tiff__status s;
+    wuffs_tiff__decoder dec;
+    wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+    // Vary the pixel format, so that each of them is fuzzed.
+    wuffs_tiff__decoder__set_pixel_format(&dec, hash & 3);
+
+    wuffs_base__image_config ic = {{0}};
+    while (true) {
+      s = wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+      if ((s != WUFFS_TIFF__SUSPENSION_MISPOSITIONED_READ) ||
+          !seek(&dec, src_reader)) {
+        break;
+      }
+    }
+    if (s || !wuffs_base__image_config__valid(&ic)) {
+      goto exit;
+    }
+
+    size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+    // Don't try to allocate more than 64 MiB.
+    if (pixbuf_size > 64 * 1024 * 1024) {
+      goto exit;
+    }
+    pixbuf = malloc(pixbuf_size);
+    if (!pixbuf) {
+      goto exit;
+    }
+
+    wuffs_base__slice_u8 dst = {.ptr = (uint8_t*)(pixbuf), .len = pixbuf_size};
+
+    while (true) {
+      s = wuffs_tiff__decoder__decode_frame(&dec, dst, src_reader);
+      if (s == WUFFS_TIFF__SUSPENSION_MISPOSITIONED_READ) {
+        if (!seek(&dec, src_reader)) {
+          break;
+        }
+      } else if (s) {
+        break;
+      }
+    }
+  }
+
+exit:
+  if (pixbuf) {
+    free(pixbuf);
+  }
+}
@@ -0,0 +1,4307 @@
+#ifndef WUFFS_BMP_H
+#define WUFFS_BMP_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+#ifndef WUFFS_BASE_HEADER_H
+#define WUFFS_BASE_HEADER_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
+#include <string.h>
+#endif
+
+// Wuffs requires a word size of at least 32 bits because it assumes that
+// converting a u32 to usize will never overflow. For example, the size of a
+// decoded image is often represented, explicitly or implicitly in an image
+// file, as a u32, and it is convenient to compare that to a buffer size.
+//
+// Similarly, the word size is at most 64 bits because it assumes that
+// converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
+#if __WORDSIZE < 32
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __WORDSIZE > 64
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
+
+// WUFFS_VERSION is the major.minor version number as a uint32. The major
+// number is the high 16 bits. The minor number is the low 16 bits.
+//
+// The intention is to bump the version number at least on every API / ABI
+// backwards incompatible change.
+//
+// For now, the API and ABI are simply unstable and can change at any time.
+//
+// TODO: don't hard code this in base-header.h.
+#define WUFFS_VERSION (0x00001)
+
+// ---------------- I/O
+
+// wuffs_base__slice_u8 is a 1-dimensional buffer (a pointer and length).
+//
+// A value with all fields NULL or zero is a valid, empty slice.
+typedef struct {
+  uint8_t* ptr;
+  size_t len;
+} wuffs_base__slice_u8;
+
+// wuffs_base__buf1 is a 1-dimensional buffer (a pointer and length), plus
+// additional indexes into that buffer, plus an opened / closed flag.
+//
+// A value with all fields NULL or zero is a valid, empty buffer.
+typedef struct {
+  uint8_t* ptr;  // Pointer.
+  size_t len;    // Length.
+  size_t wi;     // Write index. Invariant: wi <= len.
+  size_t ri;     // Read  index. Invariant: ri <= wi.
+  bool closed;   // No further writes are expected.
+} wuffs_base__buf1;
+
+// wuffs_base__limit1 provides a limited view of a 1-dimensional byte stream:
+// its first N bytes. That N can be greater than a buffer's current read or
+// write capacity. N decreases naturally over time as bytes are read from or
+// written to the stream.
+//
+// A value with all fields NULL or zero is a valid, unlimited view.
+typedef struct wuffs_base__limit1 {
+  uint64_t* ptr_to_len;             // Pointer to N.
+  struct wuffs_base__limit1* next;  // Linked list of limits.
+} wuffs_base__limit1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__reader1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__writer1;
+
+// ---------------- Images
+
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t w;
+    uint32_t h;
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
+  } private_impl;
+} wuffs_base__image_config;
+
+static inline void wuffs_base__image_config__invalidate(
+    wuffs_base__image_config* c) {
+  if (c) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline bool wuffs_base__image_config__valid(
+    wuffs_base__image_config* c) {
+  if (!c || !(c->private_impl.flags & 1)) {
+    return false;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
+  uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
+}
+
+static inline uint32_t wuffs_base__image_config__width(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__height(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
+static inline void wuffs_base__image_config__initialize(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
+}
+
+#endif  // WUFFS_BASE_HEADER_H
+
+  // ---------------- Use Declarations
+
+  // ---------------- BEGIN USE "std/png"
+
+#ifndef WUFFS_PNG_H
+#define WUFFS_PNG_H
+
+  // Code generated by wuffs-c. DO NOT EDIT.
+
+  // ---------------- Use Declarations
+
+  // ---------------- BEGIN USE "std/crc32"
+
+#ifndef WUFFS_CRC32_H
+#define WUFFS_CRC32_H
+
+  // Code generated by wuffs-c. DO NOT EDIT.
+
+  // ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_crc32__status__is_error instead.
+typedef int32_t wuffs_crc32__status;
+
+#define wuffs_crc32__packageid 810620  // 0x000C5E7C
+
+#define WUFFS_CRC32__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_CRC32__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_CRC32__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_CRC32__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_CRC32__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_CRC32__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_CRC32__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_CRC32__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_CRC32__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_CRC32__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_CRC32__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_CRC32__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_CRC32__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_CRC32__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+bool wuffs_crc32__status__is_error(wuffs_crc32__status s);
+
+const char* wuffs_crc32__status__string(wuffs_crc32__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_crc32__ieee__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_crc32__status status;
+    uint32_t magic;
+
+    uint32_t f_state;
+
+  } private_impl;
+} wuffs_crc32__ieee;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_crc32__ieee__initialize is an initializer function.
+//
+// It should be called before any other wuffs_crc32__ieee__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_crc32__ieee__initialize(wuffs_crc32__ieee* self,
+                                   uint32_t wuffs_version,
+                                   uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+uint32_t wuffs_crc32__ieee__update(wuffs_crc32__ieee* self,
+                                   wuffs_base__slice_u8 a_x);
+
+void wuffs_crc32__ieee__reset(wuffs_crc32__ieee* self);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_CRC32_H
+
+// ---------------- END   USE "std/crc32"
+
+// ---------------- BEGIN USE "std/zlib"
+
+#ifndef WUFFS_ZLIB_H
+#define WUFFS_ZLIB_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+// ---------------- Use Declarations
+
+// ---------------- BEGIN USE "std/deflate"
+
+#ifndef WUFFS_DEFLATE_H
+#define WUFFS_DEFLATE_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+// ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_deflate__status__is_error instead.
+typedef int32_t wuffs_deflate__status;
+
+#define wuffs_deflate__packageid 848533  // 0x000CF295
+
+#define WUFFS_DEFLATE__STATUS_OK 0                               // 0x00000000
+#define WUFFS_DEFLATE__ERROR_BAD_WUFFS_VERSION -2147483647       // 0x80000001
+#define WUFFS_DEFLATE__ERROR_BAD_RECEIVER -2147483646            // 0x80000002
+#define WUFFS_DEFLATE__ERROR_BAD_ARGUMENT -2147483645            // 0x80000003
+#define WUFFS_DEFLATE__ERROR_INITIALIZER_NOT_CALLED -2147483644  // 0x80000004
+#define WUFFS_DEFLATE__ERROR_INVALID_I_O_OPERATION -2147483643   // 0x80000005
+#define WUFFS_DEFLATE__ERROR_CLOSED_FOR_WRITES -2147483642       // 0x80000006
+#define WUFFS_DEFLATE__ERROR_UNEXPECTED_EOF -2147483641          // 0x80000007
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_READ 8                   // 0x00000008
+#define WUFFS_DEFLATE__SUSPENSION_SHORT_WRITE 9                  // 0x00000009
+#define WUFFS_DEFLATE__ERROR_CANNOT_RETURN_A_SUSPENSION \
+  -2147483638                                                   // 0x8000000A
+#define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483637  // 0x8000000B
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 12                // 0x0000000C
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_ANIMATION 13           // 0x0000000D
+
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED \
+  -1278585856  // 0xB3CA5400
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED \
+  -1278585855  // 0xB3CA5401
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_COUNT \
+  -1278585854  // 0xB3CA5402
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_LENGTH_REPETITION \
+  -1278585853                                              // 0xB3CA5403
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE -1278585852  // 0xB3CA5404
+#define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_MINIMUM_CODE_LENGTH \
+  -1278585851                                                     // 0xB3CA5405
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE -1278585850             // 0xB3CA5406
+#define WUFFS_DEFLATE__ERROR_BAD_DISTANCE_CODE_COUNT -1278585849  // 0xB3CA5407
+#define WUFFS_DEFLATE__ERROR_BAD_FLATE_BLOCK -1278585848          // 0xB3CA5408
+#define WUFFS_DEFLATE__ERROR_BAD_LITERAL_LENGTH_CODE_COUNT \
+  -1278585847  // 0xB3CA5409
+#define WUFFS_DEFLATE__ERROR_INCONSISTENT_STORED_BLOCK_LENGTH \
+  -1278585846  // 0xB3CA540A
+#define WUFFS_DEFLATE__ERROR_MISSING_END_OF_BLOCK_CODE \
+  -1278585845                                              // 0xB3CA540B
+#define WUFFS_DEFLATE__ERROR_NO_HUFFMAN_CODES -1278585844  // 0xB3CA540C
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE \
+  -1278585843  // 0xB3CA540D
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK \
+  -1278585842  // 0xB3CA540E
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE \
+  -1278585841  // 0xB3CA540F
+#define WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_N_BITS \
+  -1278585840  // 0xB3CA5410
+
+bool wuffs_deflate__status__is_error(wuffs_deflate__status s);
+
+const char* wuffs_deflate__status__string(wuffs_deflate__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_deflate__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_deflate__status status;
+    uint32_t magic;
+
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint32_t f_huffs[2][1234];
+    uint32_t f_n_huffs_bits[2];
+    uint8_t f_history[32768];
+    uint32_t f_history_index;
+    uint8_t f_code_lengths[320];
+    bool f_end_of_block;
+
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_deflate__status v_z;
+    } c_decode[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_final;
+      uint32_t v_type;
+    } c_decode_blocks[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_length;
+      uint32_t v_n_copied;
+      uint64_t scratch;
+    } c_decode_uncompressed[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+    } c_init_fixed_huffman[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_bits;
+      uint32_t v_n_bits;
+      uint32_t v_n_lit;
+      uint32_t v_n_dist;
+      uint32_t v_n_clen;
+      uint32_t v_i;
+      uint32_t v_mask;
+      uint32_t v_table_entry;
+      uint32_t v_table_entry_n_bits;
+      uint32_t v_n_extra_bits;
+      uint8_t v_rep_symbol;
+      uint32_t v_rep_count;
+    } c_init_dynamic_huffman[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_bits;
+      uint32_t v_n_bits;
+      uint32_t v_table_entry;
+      uint32_t v_table_entry_n_bits;
+      uint32_t v_lmask;
+      uint32_t v_dmask;
+      uint32_t v_redir_top;
+      uint32_t v_redir_mask;
+      uint32_t v_length;
+      uint32_t v_dist_minus_1;
+      uint32_t v_n_copied;
+      uint32_t v_hlen;
+      uint32_t v_hdist;
+    } c_decode_huffman_slow[1];
+  } private_impl;
+} wuffs_deflate__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_deflate__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_deflate__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_deflate__decoder__initialize(wuffs_deflate__decoder* self,
+                                        uint32_t wuffs_version,
+                                        uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+wuffs_deflate__status wuffs_deflate__decoder__decode(
+    wuffs_deflate__decoder* self,
+    wuffs_base__writer1 a_dst,
+    wuffs_base__reader1 a_src);
+
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+                                         wuffs_base__slice_u8 a_hist);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_DEFLATE_H
+
+// ---------------- END   USE "std/deflate"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_zlib__status__is_error instead.
+typedef int32_t wuffs_zlib__status;
+
+#define wuffs_zlib__packageid 2064249  // 0x001F7F79
+
+#define WUFFS_ZLIB__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_ZLIB__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_ZLIB__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_ZLIB__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_ZLIB__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_ZLIB__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_ZLIB__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_ZLIB__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_ZLIB__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_ZLIB__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_ZLIB__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_ZLIB__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_ZLIB__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_ZLIB__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+#define WUFFS_ZLIB__ERROR_BAD_ZLIB_DICTIONARY -33692672  // 0xFDFDE400
+#define WUFFS_ZLIB__ERROR_CHECKSUM_MISMATCH -33692671    // 0xFDFDE401
+#define WUFFS_ZLIB__ERROR_INVALID_ZLIB_COMPRESSION_METHOD \
+  -33692670  // 0xFDFDE402
+#define WUFFS_ZLIB__ERROR_INVALID_ZLIB_COMPRESSION_WINDOW_SIZE \
+  -33692669                                                     // 0xFDFDE403
+#define WUFFS_ZLIB__ERROR_INVALID_ZLIB_PARITY_CHECK -33692668   // 0xFDFDE404
+#define WUFFS_ZLIB__ERROR_MISSING_ZLIB_DICTIONARY -33692667     // 0xFDFDE405
+#define WUFFS_ZLIB__ERROR_UNEXPECTED_ZLIB_DICTIONARY -33692666  // 0xFDFDE406
+
+bool wuffs_zlib__status__is_error(wuffs_zlib__status s);
+
+const char* wuffs_zlib__status__string(wuffs_zlib__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_zlib__adler32__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_zlib__status status;
+    uint32_t magic;
+
+    uint32_t f_state;
+
+  } private_impl;
+} wuffs_zlib__adler32;
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_zlib__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_zlib__status status;
+    uint32_t magic;
+
+    wuffs_deflate__decoder f_flate;
+    wuffs_zlib__adler32 f_checksum;
+    bool f_ignore_checksum;
+    bool f_have_dict;
+    uint32_t f_dict_id;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint16_t v_x;
+      uint32_t v_dict_id_want;
+      uint32_t v_checksum_got;
+      wuffs_zlib__status v_z;
+      uint32_t v_checksum_want;
+      uint64_t scratch;
+    } c_decode[1];
+  } private_impl;
+} wuffs_zlib__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_zlib__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_zlib__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_zlib__decoder__initialize(wuffs_zlib__decoder* self,
+                                     uint32_t wuffs_version,
+                                     uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_zlib__decoder__set_ignore_checksum(wuffs_zlib__decoder* self,
+                                              bool a_ic);
+
+void wuffs_zlib__decoder__set_dictionary(wuffs_zlib__decoder* self,
+                                         wuffs_base__slice_u8 a_dict);
+
+wuffs_zlib__status wuffs_zlib__decoder__decode(wuffs_zlib__decoder* self,
+                                               wuffs_base__writer1 a_dst,
+                                               wuffs_base__reader1 a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_ZLIB_H
+
+// ---------------- END   USE "std/zlib"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_png__status__is_error instead.
+typedef int32_t wuffs_png__status;
+
+#define wuffs_png__packageid 1518328  // 0x00172AF8
+
+#define WUFFS_PNG__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_PNG__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_PNG__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_PNG__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_PNG__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_PNG__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_PNG__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_PNG__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_PNG__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_PNG__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_PNG__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_PNG__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_PNG__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+#define WUFFS_PNG__ERROR_BAD_PNG_CHUNK -592715776              // 0xDCABE000
+#define WUFFS_PNG__ERROR_BAD_PNG_FILTER -592715775             // 0xDCABE001
+#define WUFFS_PNG__ERROR_BAD_PNG_HEADER -592715774             // 0xDCABE002
+#define WUFFS_PNG__ERROR_BAD_PNG_SIGNATURE -592715773          // 0xDCABE003
+#define WUFFS_PNG__ERROR_CHECKSUM_MISMATCH -592715772          // 0xDCABE004
+#define WUFFS_PNG__ERROR_NOT_ENOUGH_PNG_IMAGE_DATA -592715771  // 0xDCABE005
+#define WUFFS_PNG__ERROR_UNSUPPORTED_PNG_CRITICAL_CHUNK \
+  -592715770                                                      // 0xDCABE006
+#define WUFFS_PNG__ERROR_UNSUPPORTED_PNG_PIXEL_FORMAT -592715769  // 0xDCABE007
+#define WUFFS_PNG__ERROR_TODO_UNSUPPORTED_PNG_IMAGE_WIDTH \
+  -592715768  // 0xDCABE008
+#define WUFFS_PNG__ERROR_INTERNAL_ERROR_INCONSISTENT_LIMITED_READ \
+  -592715767  // 0xDCABE009
+
+bool wuffs_png__status__is_error(wuffs_png__status s);
+
+const char* wuffs_png__status__string(wuffs_png__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_png__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_png__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint32_t f_pixel_format;
+    uint32_t f_bytes_per_pixel;
+    uint8_t f_call_sequence;
+    bool f_ignore_checksum;
+    uint32_t f_depth;
+    uint8_t f_color_type;
+    bool f_interlace;
+    uint32_t f_bits_per_pixel;
+    uint32_t f_filter_bpp;
+    uint64_t f_chunk_length;
+    uint32_t f_chunk_type;
+    uint32_t f_checksum_got;
+    wuffs_crc32__ieee f_checksum;
+    bool f_use_palette;
+    bool f_seen_plte;
+    bool f_seen_idat;
+    uint8_t f_palette[768];
+    uint8_t f_palette_alpha[256];
+    bool f_have_trns;
+    uint32_t f_trns_r;
+    uint32_t f_trns_g;
+    uint32_t f_trns_b;
+    wuffs_zlib__decoder f_zlib;
+    uint8_t f_zbuf[8192];
+    uint64_t f_zbuf_wi;
+    bool f_zlib_done;
+    bool f_bad_filter;
+    uint32_t f_pass;
+    uint32_t f_pass_width;
+    uint32_t f_pass_height;
+    uint32_t f_pass_y;
+    uint32_t f_row_bytes;
+    uint32_t f_row_x;
+    bool f_have_filter;
+    uint8_t f_filter;
+    uint8_t f_curr_row[32784];
+    uint8_t f_prev_row[32784];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_frame_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n;
+      uint8_t v_c[4];
+      uint32_t v_i;
+      uint64_t scratch;
+    } c_decode_chunk_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_checksum_want;
+      uint64_t scratch;
+    } c_decode_chunk_checksum[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+      uint64_t v_a;
+      uint32_t v_n;
+      uint64_t scratch;
+    } c_skip_chunk_data[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c[13];
+      uint32_t v_i;
+      uint32_t v_width;
+      uint32_t v_height;
+      uint32_t v_depth;
+      uint32_t v_channels;
+      uint32_t v_scale;
+      uint32_t v_n;
+      uint8_t v_g;
+    } c_decode_ihdr[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_other_chunk[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint64_t v_i;
+    } c_decode_plte[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_i;
+      uint64_t v_n;
+      uint64_t v_k;
+      uint8_t v_c[6];
+      uint32_t v_v;
+    } c_decode_trns[1];
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_png__status v_z;
+    } c_decode_idats[1];
+  } private_impl;
+} wuffs_png__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_png__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_png__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_png__decoder__initialize(wuffs_png__decoder* self,
+                                    uint32_t wuffs_version,
+                                    uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_png__decoder__set_pixel_format(wuffs_png__decoder* self,
+                                          uint32_t a_pixel_format);
+
+void wuffs_png__decoder__set_ignore_checksum(wuffs_png__decoder* self,
+                                             bool a_ic);
+
+wuffs_png__status wuffs_png__decoder__decode_config(
+    wuffs_png__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_png__status wuffs_png__decoder__decode_frame_config(
+    wuffs_png__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_png__status wuffs_png__decoder__decode_frame(wuffs_png__decoder* self,
+                                                   wuffs_base__slice_u8 a_dst,
+                                                   wuffs_base__reader1 a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_PNG_H
+
+// ---------------- END   USE "std/png"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_bmp__status__is_error instead.
+typedef int32_t wuffs_bmp__status;
+
+#define wuffs_bmp__packageid 749018  // 0x000B6DDA
+
+#define WUFFS_BMP__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_BMP__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_BMP__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_BMP__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_BMP__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_BMP__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_BMP__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_BMP__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_BMP__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_BMP__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_BMP__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_BMP__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_BMP__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+#define WUFFS_BMP__ERROR_BAD_BMP_HEADER -1380489216                // 0xADB76800
+#define WUFFS_BMP__ERROR_BAD_BMP_SIGNATURE -1380489215             // 0xADB76801
+#define WUFFS_BMP__ERROR_BAD_ICO_HEADER -1380489214                // 0xADB76802
+#define WUFFS_BMP__ERROR_UNSUPPORTED_BMP_COMPRESSION -1380489213   // 0xADB76803
+#define WUFFS_BMP__ERROR_UNSUPPORTED_BMP_HEADER -1380489212        // 0xADB76804
+#define WUFFS_BMP__ERROR_UNSUPPORTED_BMP_PIXEL_FORMAT -1380489211  // 0xADB76805
+
+bool wuffs_bmp__status__is_error(wuffs_bmp__status s);
+
+const char* wuffs_bmp__status__string(wuffs_bmp__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_bmp__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_bmp__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint32_t f_pixel_format;
+    uint32_t f_bytes_per_pixel;
+    uint8_t f_call_sequence;
+    bool f_is_ico;
+    bool f_is_png;
+    wuffs_png__decoder f_png;
+    bool f_top_down;
+    uint32_t f_bits_per_pixel;
+    uint32_t f_compression;
+    uint32_t f_dib_length;
+    uint8_t f_palette[768];
+    uint32_t f_mask[4];
+    uint32_t f_mask_shift[4];
+    uint32_t f_mask_max[4];
+    bool f_bad_mask;
+    uint32_t f_channel[4];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_magic;
+      uint32_t v_offset;
+      uint32_t v_n;
+      uint8_t v_c;
+      wuffs_bmp__status v_z;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      wuffs_bmp__status v_z;
+    } c_decode_frame_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint64_t v_i;
+      wuffs_bmp__status v_z;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_t;
+      uint32_t v_count;
+      uint32_t v_i;
+      uint32_t v_w;
+      uint32_t v_h;
+      uint32_t v_bpp;
+      uint32_t v_offset;
+      uint32_t v_best_area;
+      uint32_t v_best_bpp;
+      uint32_t v_best_offset;
+      uint32_t v_n;
+      uint64_t scratch;
+    } c_decode_ico_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_header_size;
+      uint32_t v_width;
+      uint32_t v_height;
+      uint32_t v_bpp;
+      uint32_t v_compression;
+      uint32_t v_num_colors;
+      uint32_t v_nc;
+      uint32_t v_length;
+      uint32_t v_i;
+      uint8_t v_b;
+      uint8_t v_g;
+      uint8_t v_r;
+      uint64_t scratch;
+    } c_decode_dib_header[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_bpp;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint32_t v_pad;
+      uint32_t v_index_mask;
+      uint32_t v_s;
+      uint8_t v_c;
+      uint32_t v_v;
+      uint64_t scratch;
+    } c_decode_pixels[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint64_t v_i;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint32_t v_count;
+      uint32_t v_value;
+      uint32_t v_j;
+      uint32_t v_dx;
+      uint32_t v_dy;
+      uint8_t v_c;
+      uint64_t scratch;
+    } c_decode_rle[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_row_bytes;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint32_t v_i;
+      uint32_t v_s;
+      uint8_t v_c;
+    } c_decode_and_mask[1];
+  } private_impl;
+} wuffs_bmp__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_bmp__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_bmp__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_bmp__decoder__initialize(wuffs_bmp__decoder* self,
+                                    uint32_t wuffs_version,
+                                    uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_bmp__decoder__set_pixel_format(wuffs_bmp__decoder* self,
+                                          uint32_t a_pixel_format);
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_config(
+    wuffs_bmp__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_frame_config(
+    wuffs_bmp__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_frame(wuffs_bmp__decoder* self,
+                                                   wuffs_base__slice_u8 a_dst,
+                                                   wuffs_base__reader1 a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_BMP_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// wuffs_base__empty_struct is used when a Wuffs function returns an empty
+// struct. In C, if a function f returns void, you can't say "x = f()", but in
+// Wuffs, if a function g returns empty, you can say "y = g()".
+typedef struct {
+} wuffs_base__empty_struct;
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
+// It's not foolproof, given C doesn't automatically zero memory before use,
+// but it should catch 99.99% of cases.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("wuffs").
+#define WUFFS_BASE__MAGIC (0x3CCB6C71U)
+
+// WUFFS_BASE__ALREADY_ZEROED is passed from a container struct's initializer
+// to a containee struct's initializer when the container has already zeroed
+// the containee's memory.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("zeroed").
+#define WUFFS_BASE__ALREADY_ZEROED (0x68602EF1U)
+
+// Denote intentional fallthroughs for -Wimplicit-fallthrough.
+//
+// The order matters here. Clang also defines "__GNUC__".
+#if defined(__clang__) && __cplusplus >= 201103L
+#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]
+#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)
+#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))
+#else
+#define WUFFS_BASE__FALLTHROUGH
+#endif
+
+// Use switch cases for coroutine suspension points, similar to the technique
+// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html
+//
+// We use trivial macros instead of an explicit assignment and case statement
+// so that clang-format doesn't get confused by the unusual "case"s.
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \
+  coro_susp_point = n;                            \
+  WUFFS_BASE__FALLTHROUGH;                        \
+  case n:;
+
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \
+  if (status < 0) {                                             \
+    goto exit;                                                  \
+  } else if (status == 0) {                                     \
+    goto ok;                                                    \
+  }                                                             \
+  coro_susp_point = n;                                          \
+  goto suspend;                                                 \
+  case n:;
+
+// Clang also defines "__GNUC__".
+#if defined(__GNUC__)
+#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))
+#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))
+#else
+#define WUFFS_BASE__LIKELY(expr) (expr)
+#define WUFFS_BASE__UNLIKELY(expr) (expr)
+#endif
+
+// Uncomment this #include for printf-debugging.
+// #include <stdio.h>
+
+// ---------------- Static Inline Functions
+//
+// The helpers below are functions, instead of macros, because their arguments
+// can be an expression that we shouldn't evaluate more than once.
+//
+// They are in base-impl.h and hence copy/pasted into every generated C file,
+// instead of being in some "base.c" file, since a design goal is that users of
+// the generated C code can often just #include a single .c file, such as
+// "gif.c", without having to additionally include or otherwise build and link
+// a "base.c" file.
+//
+// They are static, so that linking multiple wuffs .o files won't complain about
+// duplicate function definitions.
+//
+// They are explicitly marked inline, even if modern compilers don't use the
+// inline attribute to guide optimizations such as inlining, to avoid the
+// -Wunused-function warning, and we like to compile with -Wall -Werror.
+
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
+static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
+}
+
+static inline uint16_t wuffs_base__load_u16le(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);
+}
+
+static inline uint32_t wuffs_base__load_u32be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |
+         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u32le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(
+    wuffs_base__slice_u8 s,
+    uint64_t i) {
+  if ((i <= SIZE_MAX) && (i <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = s.len - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(
+    wuffs_base__slice_u8 s,
+    uint64_t j) {
+  if ((j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(
+    wuffs_base__slice_u8 s,
+    uint64_t i,
+    uint64_t j) {
+  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = j - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.ptr += (uint64_t)(s.len) - up_to;
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
+//
+// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
+// slice) is valid and results in a no-op.
+static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src) {
+  size_t length = dst.len < src.len ? dst.len : src.len;
+  if (length > 0) {
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
+  }
+  return length;
+}
+
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x =
+            (uint16_t)(((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                       ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_history32(
+    uint8_t** ptr_ptr,
+    uint8_t* start,  // May be NULL, meaning an unmarked writer1.
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  if (!start || !distance) {
+    return 0;
+  }
+  uint8_t* ptr = *ptr_ptr;
+  if ((size_t)(ptr - start) < (size_t)(distance)) {
+    return 0;
+  }
+  start = ptr - distance;
+  size_t n = end - ptr;
+  if ((size_t)(length) > n) {
+    length = n;
+  } else {
+    n = length;
+  }
+  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that
+  // is mostly because 3 is the minimum length for the deflate format. This
+  // function implementation shouldn't overfit to that one format. Perhaps the
+  // copy_from_history32 Wuffs method should also take an unroll hint argument,
+  // and the cgen can look if that argument is the constant expression '3'.
+  //
+  // See also wuffs_base__writer1__copy_from_history32__bco below.
+  //
+  // Alternatively, or additionally, have a sloppy_copy_from_history32 method
+  // that copies 8 bytes at a time, possibly writing more than length bytes?
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+// wuffs_base__writer1__copy_from_history32__bco is a Bounds Check Optimized
+// version of the wuffs_base__writer1__copy_from_history32 function above. The
+// caller needs to prove that:
+//  - start    != NULL
+//  - distance >  0
+//  - distance <= (*ptr_ptr - start)
+//  - length   <= (end      - *ptr_ptr)
+static inline uint32_t wuffs_base__writer1__copy_from_history32__bco(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  uint8_t* ptr = *ptr_ptr;
+  start = ptr - distance;
+  uint32_t n = length;
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_reader32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    uint8_t** ptr_rptr,
+    uint8_t* rend,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = length;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  uint8_t* rptr = *ptr_rptr;
+  if (n > rend - rptr) {
+    n = rend - rptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, rptr, n);
+    *ptr_wptr += n;
+    *ptr_rptr += n;
+  }
+  return n;
+}
+
+static inline uint64_t wuffs_base__writer1__copy_from_slice(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_slice32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > length) {
+    n = length;
+  }
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+// Note that the *__limit and *__mark methods are private (in base-impl.h) not
+// public (in base-header.h). We assume that, at the boundary between user code
+// and Wuffs code, the reader1 and writer1's private_impl fields (including
+// limit and mark) are NULL. Otherwise, some internal assumptions break down.
+// For example, limits could be represented as pointers, even though
+// conceptually they are counts, but that pointer-to-count correspondence
+// becomes invalid if a buffer is re-used (e.g. on resuming a coroutine).
+//
+// Admittedly, some of the Wuffs test code calls these methods, but that test
+// code is still Wuffs code, not user code. Other Wuffs test code modifies
+// private_impl fields directly.
+
+static inline wuffs_base__reader1 wuffs_base__reader1__limit(
+    wuffs_base__reader1* o,
+    uint64_t* ptr_to_len) {
+  wuffs_base__reader1 ret = *o;
+  ret.private_impl.limit.ptr_to_len = ptr_to_len;
+  ret.private_impl.limit.next = &o->private_impl.limit;
+  return ret;
+}
+
+static inline wuffs_base__empty_struct wuffs_base__reader1__mark(
+    wuffs_base__reader1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+// TODO: static inline wuffs_base__writer1 wuffs_base__writer1__limit()
+
+static inline wuffs_base__empty_struct wuffs_base__writer1__mark(
+    wuffs_base__writer1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+static const char* wuffs_base__status__strings[14] = {
+    "ok",
+    "bad wuffs version",
+    "bad receiver",
+    "bad argument",
+    "initializer not called",
+    "invalid I/O operation",
+    "closed for writes",
+    "unexpected EOF",
+    "short read",
+    "short write",
+    "cannot return a suspension",
+    "invalid call sequence",
+    "end of data",
+    "end of animation",
+};
+
+#endif  // WUFFS_BASE_IMPL_H
+
+// ---------------- Status Codes Implementations
+
+bool wuffs_bmp__status__is_error(wuffs_bmp__status s) {
+  return s < 0;
+}
+
+const char* wuffs_bmp__status__strings[6] = {
+    "bmp: bad BMP header",         "bmp: bad BMP signature",
+    "bmp: bad ICO header",         "bmp: unsupported BMP compression",
+    "bmp: unsupported BMP header", "bmp: unsupported BMP pixel format",
+};
+
+const char* wuffs_bmp__status__string(wuffs_bmp__status s) {
+  const char** a = NULL;
+  uint32_t n = 0;
+  switch ((s >> 10) & 0x1FFFFF) {
+    case 0:
+      a = wuffs_base__status__strings;
+      n = 14;
+      break;
+    case wuffs_bmp__packageid:
+      a = wuffs_bmp__status__strings;
+      n = 6;
+      break;
+    case wuffs_png__packageid:
+      return wuffs_png__status__string(s);
+  }
+  uint32_t i = s & 0xFF;
+  return i < n ? a[i] : "unknown status";
+}
+
+// ---------------- Private Consts
+
+// ---------------- Private Initializer Prototypes
+
+// ---------------- Private Function Prototypes
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_ico_header(
+    wuffs_bmp__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_dib_header(
+    wuffs_bmp__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_bmp__decoder__decode_mask(wuffs_bmp__decoder* self,
+                                            uint32_t a_i);
+
+static void wuffs_bmp__decoder__decode_channels(wuffs_bmp__decoder* self,
+                                                uint32_t a_v);
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_pixels(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src);
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_rle(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src,
+    bool a_rle4);
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_and_mask(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_bmp__decoder__put_index(wuffs_bmp__decoder* self,
+                                          wuffs_base__slice_u8 a_dst,
+                                          uint32_t a_x,
+                                          uint32_t a_y,
+                                          uint32_t a_idx);
+
+static void wuffs_bmp__decoder__put_pixel(wuffs_bmp__decoder* self,
+                                          wuffs_base__slice_u8 a_dst,
+                                          uint32_t a_x,
+                                          uint32_t a_y,
+                                          uint32_t a_idx,
+                                          uint32_t a_r,
+                                          uint32_t a_g,
+                                          uint32_t a_b,
+                                          uint32_t a_a);
+
+static void wuffs_bmp__decoder__clear_alpha(wuffs_bmp__decoder* self,
+                                            wuffs_base__slice_u8 a_dst,
+                                            uint32_t a_x,
+                                            uint32_t a_y);
+
+// ---------------- Initializer Implementations
+
+void wuffs_bmp__decoder__initialize(wuffs_bmp__decoder* self,
+                                    uint32_t wuffs_version,
+                                    uint32_t for_internal_use_only) {
+  if (!self) {
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_BMP__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
+    wuffs_base__memset(self, 0, sizeof(*self));
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  self->private_impl.f_pixel_format = 1;
+  self->private_impl.f_bytes_per_pixel = 4;
+  self->private_impl.f_bits_per_pixel = 1;
+  wuffs_png__decoder__initialize(&self->private_impl.f_png, WUFFS_VERSION,
+                                 WUFFS_BASE__ALREADY_ZEROED);
+}
+
+// ---------------- Function Implementations
+
+void wuffs_bmp__decoder__set_pixel_format(wuffs_bmp__decoder* self,
+                                          uint32_t a_pixel_format) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_BMP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+  if (a_pixel_format > 3) {
+    self->private_impl.status = WUFFS_BMP__ERROR_BAD_ARGUMENT;
+    return;
+  }
+
+  if (self->private_impl.f_call_sequence != 0) {
+    return;
+  }
+  self->private_impl.f_pixel_format = a_pixel_format;
+  if (a_pixel_format == 0) {
+    self->private_impl.f_bytes_per_pixel = 1;
+  } else if (a_pixel_format == 3) {
+    self->private_impl.f_bytes_per_pixel = 2;
+  } else {
+    self->private_impl.f_bytes_per_pixel = 4;
+  }
+}
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_config(
+    wuffs_bmp__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_BMP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_BMP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_BMP__ERROR_BAD_ARGUMENT;
+    return WUFFS_BMP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint32_t v_magic;
+  uint32_t v_offset;
+  uint32_t v_n;
+  uint8_t v_c;
+  wuffs_bmp__status v_z;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_magic = self->private_impl.c_decode_config[0].v_magic;
+    v_offset = self->private_impl.c_decode_config[0].v_offset;
+    v_n = self->private_impl.c_decode_config[0].v_n;
+    v_c = self->private_impl.c_decode_config[0].v_c;
+    v_z = self->private_impl.c_decode_config[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_magic = 0;
+    v_offset = 0;
+    v_n = 0;
+    v_c = 0;
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16le(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 = self->private_impl.c_decode_config[0].scratch >> 56;
+          self->private_impl.c_decode_config[0].scratch <<= 8;
+          self->private_impl.c_decode_config[0].scratch >>= 8;
+          self->private_impl.c_decode_config[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_0;
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_config[0].scratch;
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_0))
+                                                           << 56;
+        }
+      }
+      v_magic = ((uint32_t)(t_1));
+    }
+    if (v_magic == 19778) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      self->private_impl.c_decode_config[0].scratch = 8;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      if (self->private_impl.c_decode_config[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        uint32_t t_3;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+          t_3 = wuffs_base__load_u32le(b_rptr_src);
+          b_rptr_src += 4;
+        } else {
+          self->private_impl.c_decode_config[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_2 = self->private_impl.c_decode_config[0].scratch >> 56;
+            self->private_impl.c_decode_config[0].scratch <<= 8;
+            self->private_impl.c_decode_config[0].scratch >>= 8;
+            self->private_impl.c_decode_config[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_2;
+            if (t_2 == 24) {
+              t_3 = self->private_impl.c_decode_config[0].scratch;
+              break;
+            }
+            t_2 += 8;
+            self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_2))
+                                                             << 56;
+          }
+        }
+        v_offset = t_3;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_bmp__decoder__decode_dib_header(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      v_n = (14 + self->private_impl.f_dib_length);
+      if (v_offset < v_n) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      self->private_impl.c_decode_config[0].scratch = (v_offset - v_n);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      if (self->private_impl.c_decode_config[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+    } else if (v_magic == 0) {
+      self->private_impl.f_is_ico = true;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_bmp__decoder__decode_ico_header(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_4 = *b_rptr_src++;
+        v_c = t_4;
+      }
+      if (b_rptr_src == b_rstart_src) {
+        status = WUFFS_BMP__ERROR_INVALID_I_O_OPERATION;
+        goto exit;
+      }
+      b_rptr_src--;
+      if (v_c == 137) {
+        self->private_impl.f_is_png = true;
+        wuffs_png__decoder__set_pixel_format(&self->private_impl.f_png,
+                                             self->private_impl.f_pixel_format);
+        while (true) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+            if (a_src.buf) {
+              size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+              a_src.buf->ri += n;
+              wuffs_base__limit1* lim;
+              for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+                if (lim->ptr_to_len) {
+                  *lim->ptr_to_len -= n;
+                }
+              }
+            }
+            wuffs_bmp__status t_5 = wuffs_png__decoder__decode_config(
+                &self->private_impl.f_png, a_dst, a_src);
+            if (a_src.buf) {
+              b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+            }
+            v_z = t_5;
+          }
+          if (v_z == 0) {
+            goto label_0_break;
+          }
+          status = v_z;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(13);
+        }
+      label_0_break:;
+        self->private_impl.f_call_sequence = 1;
+        status = WUFFS_BMP__STATUS_OK;
+        goto ok;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_bmp__decoder__decode_dib_header(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      status = WUFFS_BMP__ERROR_BAD_BMP_SIGNATURE;
+      goto exit;
+    }
+    if ((self->private_impl.f_pixel_format == 0) &&
+        (self->private_impl.f_bits_per_pixel > 8)) {
+      status = WUFFS_BMP__ERROR_UNSUPPORTED_BMP_PIXEL_FORMAT;
+      goto exit;
+    }
+    wuffs_base__image_config__initialize(a_dst, self->private_impl.f_width,
+                                         self->private_impl.f_height,
+                                         self->private_impl.f_pixel_format);
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_config[0].v_magic = v_magic;
+  self->private_impl.c_decode_config[0].v_offset = v_offset;
+  self->private_impl.c_decode_config[0].v_n = v_n;
+  self->private_impl.c_decode_config[0].v_c = v_c;
+  self->private_impl.c_decode_config[0].v_z = v_z;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_frame_config(
+    wuffs_bmp__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_BMP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_BMP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_BMP__ERROR_BAD_ARGUMENT;
+    return WUFFS_BMP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  wuffs_bmp__status v_z;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_z = self->private_impl.c_decode_frame_config[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_is_png) {
+      while (true) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          wuffs_bmp__status t_0 = wuffs_png__decoder__decode_frame_config(
+              &self->private_impl.f_png, a_dst, a_src);
+          v_z = t_0;
+        }
+        if (v_z == 0) {
+          goto label_0_break;
+        }
+        status = v_z;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+      }
+    label_0_break:;
+      status = WUFFS_BMP__STATUS_OK;
+      goto ok;
+    }
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_BMP__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+      }
+    } else if (self->private_impl.f_call_sequence != 1) {
+      status = WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    wuffs_base__frame_config__initialize(
+        a_dst, 0, 0, self->private_impl.f_width, self->private_impl.f_height, 0,
+        0, 256,
+        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palette,
+                                .len = 768}));
+    self->private_impl.f_call_sequence = 2;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame_config[0].v_z = v_z;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+wuffs_bmp__status wuffs_bmp__decoder__decode_frame(wuffs_bmp__decoder* self,
+                                                   wuffs_base__slice_u8 a_dst,
+                                                   wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_BMP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_BMP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint64_t v_n;
+  uint64_t v_i;
+  wuffs_bmp__status v_z;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_decode_frame[0].v_n;
+    v_i = self->private_impl.c_decode_frame[0].v_i;
+    v_z = self->private_impl.c_decode_frame[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n = 0;
+    v_i = 0;
+    if (self->private_impl.f_is_png) {
+      while (true) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          wuffs_bmp__status t_0 = wuffs_png__decoder__decode_frame(
+              &self->private_impl.f_png, a_dst, a_src);
+          v_z = t_0;
+        }
+        if (v_z == 0) {
+          goto label_0_break;
+        }
+        status = v_z;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+      }
+    label_0_break:;
+      status = WUFFS_BMP__STATUS_OK;
+      goto ok;
+    }
+    v_n = (((uint64_t)(self->private_impl.f_width)) *
+           ((uint64_t)(self->private_impl.f_height)) *
+           ((uint64_t)(self->private_impl.f_bytes_per_pixel)));
+    if (((uint64_t)(a_dst.len)) < v_n) {
+      status = WUFFS_BMP__ERROR_BAD_ARGUMENT;
+      goto exit;
+    }
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_BMP__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
+      }
+    } else if ((self->private_impl.f_call_sequence != 1) &&
+               (self->private_impl.f_call_sequence != 2)) {
+      status = WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    if (self->private_impl.f_compression == 1) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_bmp__decoder__decode_rle(self, a_dst, a_src, false);
+      if (status) {
+        goto suspend;
+      }
+    } else if (self->private_impl.f_compression == 2) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_bmp__decoder__decode_rle(self, a_dst, a_src, true);
+      if (status) {
+        goto suspend;
+      }
+    } else {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      status = wuffs_bmp__decoder__decode_pixels(self, a_dst, a_src);
+      if (status) {
+        goto suspend;
+      }
+    }
+    if (self->private_impl.f_is_ico &&
+        (self->private_impl.f_bits_per_pixel < 32) &&
+        ((self->private_impl.f_pixel_format == 1) ||
+         (self->private_impl.f_pixel_format == 2))) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      status = wuffs_bmp__decoder__decode_and_mask(self, a_dst, a_src);
+      if (status) {
+        goto suspend;
+      }
+    }
+    self->private_impl.f_call_sequence = 3;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame[0].v_n = v_n;
+  self->private_impl.c_decode_frame[0].v_i = v_i;
+  self->private_impl.c_decode_frame[0].v_z = v_z;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_ico_header(
+    wuffs_bmp__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint32_t v_t;
+  uint32_t v_count;
+  uint32_t v_i;
+  uint32_t v_w;
+  uint32_t v_h;
+  uint32_t v_bpp;
+  uint32_t v_offset;
+  uint32_t v_best_area;
+  uint32_t v_best_bpp;
+  uint32_t v_best_offset;
+  uint32_t v_n;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_ico_header[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_t = self->private_impl.c_decode_ico_header[0].v_t;
+    v_count = self->private_impl.c_decode_ico_header[0].v_count;
+    v_i = self->private_impl.c_decode_ico_header[0].v_i;
+    v_w = self->private_impl.c_decode_ico_header[0].v_w;
+    v_h = self->private_impl.c_decode_ico_header[0].v_h;
+    v_bpp = self->private_impl.c_decode_ico_header[0].v_bpp;
+    v_offset = self->private_impl.c_decode_ico_header[0].v_offset;
+    v_best_area = self->private_impl.c_decode_ico_header[0].v_best_area;
+    v_best_bpp = self->private_impl.c_decode_ico_header[0].v_best_bpp;
+    v_best_offset = self->private_impl.c_decode_ico_header[0].v_best_offset;
+    v_n = self->private_impl.c_decode_ico_header[0].v_n;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_t = 0;
+    v_count = 0;
+    v_i = 0;
+    v_w = 0;
+    v_h = 0;
+    v_bpp = 0;
+    v_offset = 0;
+    v_best_area = 0;
+    v_best_bpp = 0;
+    v_best_offset = 0;
+    v_n = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16le(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_ico_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 =
+              self->private_impl.c_decode_ico_header[0].scratch >> 56;
+          self->private_impl.c_decode_ico_header[0].scratch <<= 8;
+          self->private_impl.c_decode_ico_header[0].scratch >>= 8;
+          self->private_impl.c_decode_ico_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_0;
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_ico_header[0].scratch;
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_ico_header[0].scratch |= ((uint64_t)(t_0))
+                                                               << 56;
+        }
+      }
+      v_t = ((uint32_t)(t_1));
+    }
+    if ((v_t != 1) && (v_t != 2)) {
+      status = WUFFS_BMP__ERROR_BAD_ICO_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint16_t t_3;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_3 = wuffs_base__load_u16le(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_ico_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_2 =
+              self->private_impl.c_decode_ico_header[0].scratch >> 56;
+          self->private_impl.c_decode_ico_header[0].scratch <<= 8;
+          self->private_impl.c_decode_ico_header[0].scratch >>= 8;
+          self->private_impl.c_decode_ico_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_2;
+          if (t_2 == 8) {
+            t_3 = self->private_impl.c_decode_ico_header[0].scratch;
+            break;
+          }
+          t_2 += 8;
+          self->private_impl.c_decode_ico_header[0].scratch |= ((uint64_t)(t_2))
+                                                               << 56;
+        }
+      }
+      v_count = ((uint32_t)(t_3));
+    }
+    if (v_count == 0) {
+      status = WUFFS_BMP__ERROR_BAD_ICO_HEADER;
+      goto exit;
+    }
+    while (v_i < v_count) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_4 = *b_rptr_src++;
+        v_w = ((uint32_t)(t_4));
+      }
+      if (v_w == 0) {
+        v_w = 256;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_5 = *b_rptr_src++;
+        v_h = ((uint32_t)(t_5));
+      }
+      if (v_h == 0) {
+        v_h = 256;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      self->private_impl.c_decode_ico_header[0].scratch = 4;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (self->private_impl.c_decode_ico_header[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_ico_header[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_ico_header[0].scratch;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+        uint16_t t_7;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+          t_7 = wuffs_base__load_u16le(b_rptr_src);
+          b_rptr_src += 2;
+        } else {
+          self->private_impl.c_decode_ico_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_6 =
+                self->private_impl.c_decode_ico_header[0].scratch >> 56;
+            self->private_impl.c_decode_ico_header[0].scratch <<= 8;
+            self->private_impl.c_decode_ico_header[0].scratch >>= 8;
+            self->private_impl.c_decode_ico_header[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_6;
+            if (t_6 == 8) {
+              t_7 = self->private_impl.c_decode_ico_header[0].scratch;
+              break;
+            }
+            t_6 += 8;
+            self->private_impl.c_decode_ico_header[0].scratch |=
+                ((uint64_t)(t_6)) << 56;
+          }
+        }
+        v_bpp = ((uint32_t)(t_7));
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+      self->private_impl.c_decode_ico_header[0].scratch = 4;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+      if (self->private_impl.c_decode_ico_header[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_ico_header[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_ico_header[0].scratch;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+        uint32_t t_9;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+          t_9 = wuffs_base__load_u32le(b_rptr_src);
+          b_rptr_src += 4;
+        } else {
+          self->private_impl.c_decode_ico_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_8 =
+                self->private_impl.c_decode_ico_header[0].scratch >> 56;
+            self->private_impl.c_decode_ico_header[0].scratch <<= 8;
+            self->private_impl.c_decode_ico_header[0].scratch >>= 8;
+            self->private_impl.c_decode_ico_header[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_8;
+            if (t_8 == 24) {
+              t_9 = self->private_impl.c_decode_ico_header[0].scratch;
+              break;
+            }
+            t_8 += 8;
+            self->private_impl.c_decode_ico_header[0].scratch |=
+                ((uint64_t)(t_8)) << 56;
+          }
+        }
+        v_offset = t_9;
+      }
+      if (((v_w * v_h) > v_best_area) ||
+          (((v_w * v_h) == v_best_area) && (v_bpp > v_best_bpp))) {
+        v_best_area = (v_w * v_h);
+        v_best_bpp = v_bpp;
+        v_best_offset = v_offset;
+      }
+      v_i += 1;
+    }
+    v_n = (6 + (16 * v_count));
+    if (v_best_offset < v_n) {
+      status = WUFFS_BMP__ERROR_BAD_ICO_HEADER;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+    self->private_impl.c_decode_ico_header[0].scratch = (v_best_offset - v_n);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+    if (self->private_impl.c_decode_ico_header[0].scratch >
+        b_rend_src - b_rptr_src) {
+      self->private_impl.c_decode_ico_header[0].scratch -=
+          b_rend_src - b_rptr_src;
+      b_rptr_src = b_rend_src;
+      goto short_read_src;
+    }
+    b_rptr_src += self->private_impl.c_decode_ico_header[0].scratch;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_ico_header[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_ico_header[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_ico_header[0].v_t = v_t;
+  self->private_impl.c_decode_ico_header[0].v_count = v_count;
+  self->private_impl.c_decode_ico_header[0].v_i = v_i;
+  self->private_impl.c_decode_ico_header[0].v_w = v_w;
+  self->private_impl.c_decode_ico_header[0].v_h = v_h;
+  self->private_impl.c_decode_ico_header[0].v_bpp = v_bpp;
+  self->private_impl.c_decode_ico_header[0].v_offset = v_offset;
+  self->private_impl.c_decode_ico_header[0].v_best_area = v_best_area;
+  self->private_impl.c_decode_ico_header[0].v_best_bpp = v_best_bpp;
+  self->private_impl.c_decode_ico_header[0].v_best_offset = v_best_offset;
+  self->private_impl.c_decode_ico_header[0].v_n = v_n;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_dib_header(
+    wuffs_bmp__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint32_t v_header_size;
+  uint32_t v_width;
+  uint32_t v_height;
+  uint32_t v_bpp;
+  uint32_t v_compression;
+  uint32_t v_num_colors;
+  uint32_t v_nc;
+  uint32_t v_length;
+  uint32_t v_i;
+  uint8_t v_b;
+  uint8_t v_g;
+  uint8_t v_r;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_dib_header[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_header_size = self->private_impl.c_decode_dib_header[0].v_header_size;
+    v_width = self->private_impl.c_decode_dib_header[0].v_width;
+    v_height = self->private_impl.c_decode_dib_header[0].v_height;
+    v_bpp = self->private_impl.c_decode_dib_header[0].v_bpp;
+    v_compression = self->private_impl.c_decode_dib_header[0].v_compression;
+    v_num_colors = self->private_impl.c_decode_dib_header[0].v_num_colors;
+    v_nc = self->private_impl.c_decode_dib_header[0].v_nc;
+    v_length = self->private_impl.c_decode_dib_header[0].v_length;
+    v_i = self->private_impl.c_decode_dib_header[0].v_i;
+    v_b = self->private_impl.c_decode_dib_header[0].v_b;
+    v_g = self->private_impl.c_decode_dib_header[0].v_g;
+    v_r = self->private_impl.c_decode_dib_header[0].v_r;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_header_size = 0;
+    v_width = 0;
+    v_height = 0;
+    v_bpp = 0;
+    v_compression = 0;
+    v_num_colors = 0;
+    v_nc = 0;
+    v_length = 0;
+    v_i = 0;
+    v_b = 0;
+    v_g = 0;
+    v_r = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint32_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_1 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_0;
+          if (t_0 == 24) {
+            t_1 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |= ((uint64_t)(t_0))
+                                                               << 56;
+        }
+      }
+      v_header_size = t_1;
+    }
+    if ((v_header_size != 40) && (v_header_size != 108) &&
+        (v_header_size != 124)) {
+      status = WUFFS_BMP__ERROR_UNSUPPORTED_BMP_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint32_t t_3;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_3 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_2 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_2;
+          if (t_2 == 24) {
+            t_3 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_2 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |= ((uint64_t)(t_2))
+                                                               << 56;
+        }
+      }
+      v_width = t_3;
+    }
+    if (v_width == 0) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    if (v_width > 2147483647) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_width = v_width;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      uint32_t t_5;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_5 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_4 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_4;
+          if (t_4 == 24) {
+            t_5 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_4 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |= ((uint64_t)(t_4))
+                                                               << 56;
+        }
+      }
+      v_height = t_5;
+    }
+    if (v_height > 2147483648) {
+      if (self->private_impl.f_is_ico) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+      v_height = ((4294967295 - v_height) + 1);
+      self->private_impl.f_top_down = true;
+    } else if (v_height == 2147483648) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    if (self->private_impl.f_is_ico) {
+      v_height = (v_height / 2);
+    }
+    if (v_height == 0) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    if (v_height > 2147483647) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_height = v_height;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    uint16_t t_7;
+    if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+      t_7 = wuffs_base__load_u16le(b_rptr_src);
+      b_rptr_src += 2;
+    } else {
+      self->private_impl.c_decode_dib_header[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint32_t t_6 = self->private_impl.c_decode_dib_header[0].scratch >> 56;
+        self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+        self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+        self->private_impl.c_decode_dib_header[0].scratch |=
+            ((uint64_t)(*b_rptr_src++)) << t_6;
+        if (t_6 == 8) {
+          t_7 = self->private_impl.c_decode_dib_header[0].scratch;
+          break;
+        }
+        t_6 += 8;
+        self->private_impl.c_decode_dib_header[0].scratch |= ((uint64_t)(t_6))
+                                                             << 56;
+      }
+    }
+    if (t_7 != 1) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      uint16_t t_9;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_9 = wuffs_base__load_u16le(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_8 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_8;
+          if (t_8 == 8) {
+            t_9 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_8 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |= ((uint64_t)(t_8))
+                                                               << 56;
+        }
+      }
+      v_bpp = ((uint32_t)(t_9));
+    }
+    if (v_bpp < 1) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    if (v_bpp > 32) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_bits_per_pixel = v_bpp;
+    if ((v_bpp != 1) && (v_bpp != 4) && (v_bpp != 8) && (v_bpp != 16) &&
+        (v_bpp != 24) && (v_bpp != 32)) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+      uint32_t t_11;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_11 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_10 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_10;
+          if (t_10 == 24) {
+            t_11 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_10 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(t_10)) << 56;
+        }
+      }
+      v_compression = t_11;
+    }
+    if (v_compression > 3) {
+      status = WUFFS_BMP__ERROR_UNSUPPORTED_BMP_COMPRESSION;
+      goto exit;
+    }
+    self->private_impl.f_compression = v_compression;
+    if (v_compression == 1) {
+      if (v_bpp != 8) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+    } else if (v_compression == 2) {
+      if (v_bpp != 4) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+    } else if (v_compression == 3) {
+      if ((v_bpp != 16) && (v_bpp != 32)) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+    }
+    if (self->private_impl.f_top_down &&
+        ((v_compression == 1) || (v_compression == 2))) {
+      status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+    self->private_impl.c_decode_dib_header[0].scratch = 12;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+    if (self->private_impl.c_decode_dib_header[0].scratch >
+        b_rend_src - b_rptr_src) {
+      self->private_impl.c_decode_dib_header[0].scratch -=
+          b_rend_src - b_rptr_src;
+      b_rptr_src = b_rend_src;
+      goto short_read_src;
+    }
+    b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+      uint32_t t_13;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_13 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_dib_header[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_12 =
+              self->private_impl.c_decode_dib_header[0].scratch >> 56;
+          self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+          self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_12;
+          if (t_12 == 24) {
+            t_13 = self->private_impl.c_decode_dib_header[0].scratch;
+            break;
+          }
+          t_12 += 8;
+          self->private_impl.c_decode_dib_header[0].scratch |=
+              ((uint64_t)(t_12)) << 56;
+        }
+      }
+      v_num_colors = t_13;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+    self->private_impl.c_decode_dib_header[0].scratch = 4;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(18);
+    if (self->private_impl.c_decode_dib_header[0].scratch >
+        b_rend_src - b_rptr_src) {
+      self->private_impl.c_decode_dib_header[0].scratch -=
+          b_rend_src - b_rptr_src;
+      b_rptr_src = b_rend_src;
+      goto short_read_src;
+    }
+    b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+    v_length = 40;
+    if (v_bpp == 16) {
+      self->private_impl.f_mask[0] = 31744;
+      self->private_impl.f_mask[1] = 992;
+      self->private_impl.f_mask[2] = 31;
+      self->private_impl.f_mask[3] = 0;
+    } else {
+      self->private_impl.f_mask[0] = 16711680;
+      self->private_impl.f_mask[1] = 65280;
+      self->private_impl.f_mask[2] = 255;
+      self->private_impl.f_mask[3] = 0;
+      if (self->private_impl.f_is_ico && (v_bpp == 32)) {
+        self->private_impl.f_mask[3] = 4278190080;
+      }
+    }
+    if (v_header_size > 40) {
+      if (v_compression == 3) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(19);
+          uint32_t t_15;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_15 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_dib_header[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(20);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_14 =
+                  self->private_impl.c_decode_dib_header[0].scratch >> 56;
+              self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+              self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_14;
+              if (t_14 == 24) {
+                t_15 = self->private_impl.c_decode_dib_header[0].scratch;
+                break;
+              }
+              t_14 += 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(t_14)) << 56;
+            }
+          }
+          self->private_impl.f_mask[0] = t_15;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(21);
+          uint32_t t_17;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_17 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_dib_header[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(22);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_16 =
+                  self->private_impl.c_decode_dib_header[0].scratch >> 56;
+              self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+              self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_16;
+              if (t_16 == 24) {
+                t_17 = self->private_impl.c_decode_dib_header[0].scratch;
+                break;
+              }
+              t_16 += 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(t_16)) << 56;
+            }
+          }
+          self->private_impl.f_mask[1] = t_17;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(23);
+          uint32_t t_19;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_19 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_dib_header[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(24);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_18 =
+                  self->private_impl.c_decode_dib_header[0].scratch >> 56;
+              self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+              self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_18;
+              if (t_18 == 24) {
+                t_19 = self->private_impl.c_decode_dib_header[0].scratch;
+                break;
+              }
+              t_18 += 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(t_18)) << 56;
+            }
+          }
+          self->private_impl.f_mask[2] = t_19;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(25);
+          uint32_t t_21;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_21 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_dib_header[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(26);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_20 =
+                  self->private_impl.c_decode_dib_header[0].scratch >> 56;
+              self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+              self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_20;
+              if (t_20 == 24) {
+                t_21 = self->private_impl.c_decode_dib_header[0].scratch;
+                break;
+              }
+              t_20 += 8;
+              self->private_impl.c_decode_dib_header[0].scratch |=
+                  ((uint64_t)(t_20)) << 56;
+            }
+          }
+          self->private_impl.f_mask[3] = t_21;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(27);
+        self->private_impl.c_decode_dib_header[0].scratch = 16;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(28);
+        if (self->private_impl.c_decode_dib_header[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_dib_header[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+      }
+      if (v_header_size == 108) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(29);
+        self->private_impl.c_decode_dib_header[0].scratch = 52;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(30);
+        if (self->private_impl.c_decode_dib_header[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_dib_header[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+        v_length = 108;
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(31);
+        self->private_impl.c_decode_dib_header[0].scratch = 68;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(32);
+        if (self->private_impl.c_decode_dib_header[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_dib_header[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+        v_length = 124;
+      }
+    } else if (v_compression == 3) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(33);
+        uint32_t t_23;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+          t_23 = wuffs_base__load_u32le(b_rptr_src);
+          b_rptr_src += 4;
+        } else {
+          self->private_impl.c_decode_dib_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(34);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_22 =
+                self->private_impl.c_decode_dib_header[0].scratch >> 56;
+            self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+            self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_22;
+            if (t_22 == 24) {
+              t_23 = self->private_impl.c_decode_dib_header[0].scratch;
+              break;
+            }
+            t_22 += 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(t_22)) << 56;
+          }
+        }
+        self->private_impl.f_mask[0] = t_23;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(35);
+        uint32_t t_25;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+          t_25 = wuffs_base__load_u32le(b_rptr_src);
+          b_rptr_src += 4;
+        } else {
+          self->private_impl.c_decode_dib_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(36);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_24 =
+                self->private_impl.c_decode_dib_header[0].scratch >> 56;
+            self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+            self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_24;
+            if (t_24 == 24) {
+              t_25 = self->private_impl.c_decode_dib_header[0].scratch;
+              break;
+            }
+            t_24 += 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(t_24)) << 56;
+          }
+        }
+        self->private_impl.f_mask[1] = t_25;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(37);
+        uint32_t t_27;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+          t_27 = wuffs_base__load_u32le(b_rptr_src);
+          b_rptr_src += 4;
+        } else {
+          self->private_impl.c_decode_dib_header[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(38);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_26 =
+                self->private_impl.c_decode_dib_header[0].scratch >> 56;
+            self->private_impl.c_decode_dib_header[0].scratch <<= 8;
+            self->private_impl.c_decode_dib_header[0].scratch >>= 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_26;
+            if (t_26 == 24) {
+              t_27 = self->private_impl.c_decode_dib_header[0].scratch;
+              break;
+            }
+            t_26 += 8;
+            self->private_impl.c_decode_dib_header[0].scratch |=
+                ((uint64_t)(t_26)) << 56;
+          }
+        }
+        self->private_impl.f_mask[2] = t_27;
+      }
+      self->private_impl.f_mask[3] = 0;
+      v_length = 52;
+    }
+    if (v_bpp >= 16) {
+      wuffs_bmp__decoder__decode_mask(self, 0);
+      wuffs_bmp__decoder__decode_mask(self, 1);
+      wuffs_bmp__decoder__decode_mask(self, 2);
+      wuffs_bmp__decoder__decode_mask(self, 3);
+      if (self->private_impl.f_bad_mask) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+    }
+    if (v_bpp <= 8) {
+      if (v_num_colors == 0) {
+        v_num_colors = (((uint32_t)(1)) << v_bpp);
+      }
+      if (v_num_colors > 256) {
+        status = WUFFS_BMP__ERROR_BAD_BMP_HEADER;
+        goto exit;
+      }
+      v_nc = v_num_colors;
+      while (v_i < v_nc) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(39);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_28 = *b_rptr_src++;
+          v_b = t_28;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(40);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_29 = *b_rptr_src++;
+          v_g = t_29;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(41);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_30 = *b_rptr_src++;
+          v_r = t_30;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(42);
+        self->private_impl.c_decode_dib_header[0].scratch = 1;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(43);
+        if (self->private_impl.c_decode_dib_header[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_dib_header[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_dib_header[0].scratch;
+        self->private_impl.f_palette[(3 * v_i) + 0] = v_r;
+        self->private_impl.f_palette[(3 * v_i) + 1] = v_g;
+        self->private_impl.f_palette[(3 * v_i) + 2] = v_b;
+        v_i += 1;
+      }
+    }
+    self->private_impl.f_dib_length = (v_length + (4 * v_nc));
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dib_header[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dib_header[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_dib_header[0].v_header_size = v_header_size;
+  self->private_impl.c_decode_dib_header[0].v_width = v_width;
+  self->private_impl.c_decode_dib_header[0].v_height = v_height;
+  self->private_impl.c_decode_dib_header[0].v_bpp = v_bpp;
+  self->private_impl.c_decode_dib_header[0].v_compression = v_compression;
+  self->private_impl.c_decode_dib_header[0].v_num_colors = v_num_colors;
+  self->private_impl.c_decode_dib_header[0].v_nc = v_nc;
+  self->private_impl.c_decode_dib_header[0].v_length = v_length;
+  self->private_impl.c_decode_dib_header[0].v_i = v_i;
+  self->private_impl.c_decode_dib_header[0].v_b = v_b;
+  self->private_impl.c_decode_dib_header[0].v_g = v_g;
+  self->private_impl.c_decode_dib_header[0].v_r = v_r;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_bmp__decoder__decode_mask(wuffs_bmp__decoder* self,
+                                            uint32_t a_i) {
+  uint32_t v_m;
+  uint32_t v_s;
+
+  v_m = self->private_impl.f_mask[a_i];
+  v_s = 0;
+  if (v_m != 0) {
+    while ((v_s < 31) && (((v_m >> v_s) & 1) == 0)) {
+      v_s += 1;
+    }
+    v_m = (v_m >> v_s);
+    if (((((uint64_t)(v_m)) + 1) & ((uint64_t)(v_m))) != 0) {
+      self->private_impl.f_bad_mask = true;
+    }
+  }
+  self->private_impl.f_mask_shift[a_i] = v_s;
+  self->private_impl.f_mask_max[a_i] = v_m;
+}
+
+static void wuffs_bmp__decoder__decode_channels(wuffs_bmp__decoder* self,
+                                                uint32_t a_v) {
+  uint32_t v_i;
+  uint64_t v_m;
+  uint64_t v_c;
+
+  v_i = 0;
+  v_m = 0;
+  v_c = 0;
+  while (v_i < 4) {
+    v_m = ((uint64_t)(self->private_impl.f_mask_max[v_i]));
+    if (v_m > 0) {
+      v_c = ((uint64_t)(((a_v & self->private_impl.f_mask[v_i]) >>
+                         self->private_impl.f_mask_shift[v_i])));
+      self->private_impl.f_channel[v_i] =
+          ((uint32_t)(((((v_c * 255) + (v_m / 2)) / v_m) & 255)));
+    } else if (v_i < 3) {
+      self->private_impl.f_channel[v_i] = 0;
+    } else {
+      self->private_impl.f_channel[v_i] = 255;
+    }
+    v_i += 1;
+  }
+}
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_pixels(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src) {
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint32_t v_bpp;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_pad;
+  uint32_t v_index_mask;
+  uint32_t v_s;
+  uint8_t v_c;
+  uint32_t v_v;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_pixels[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_bpp = self->private_impl.c_decode_pixels[0].v_bpp;
+    v_x = self->private_impl.c_decode_pixels[0].v_x;
+    v_y = self->private_impl.c_decode_pixels[0].v_y;
+    v_pad = self->private_impl.c_decode_pixels[0].v_pad;
+    v_index_mask = self->private_impl.c_decode_pixels[0].v_index_mask;
+    v_s = self->private_impl.c_decode_pixels[0].v_s;
+    v_c = self->private_impl.c_decode_pixels[0].v_c;
+    v_v = self->private_impl.c_decode_pixels[0].v_v;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_bpp = self->private_impl.f_bits_per_pixel;
+    v_x = 0;
+    v_y = 0;
+    v_pad = 0;
+    v_index_mask = 0;
+    v_s = 0;
+    v_c = 0;
+    v_v = 0;
+    v_pad = ((4 - ((uint32_t)(((((((uint64_t)(self->private_impl.f_width)) *
+                                  ((uint64_t)(v_bpp))) +
+                                 7) >>
+                                3) &
+                               3)))) &
+             3);
+    if (v_bpp <= 8) {
+      v_index_mask = (((((uint32_t)(1)) << v_bpp) - 1) & 255);
+    }
+    while (v_y < self->private_impl.f_height) {
+      v_x = 0;
+    label_0_continue:;
+      while (v_x < self->private_impl.f_width) {
+        if (v_bpp <= 8) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_0 = *b_rptr_src++;
+            v_c = t_0;
+          }
+          v_s = 8;
+          while ((v_s >= v_bpp) && (v_x < self->private_impl.f_width)) {
+            v_s -= v_bpp;
+            wuffs_bmp__decoder__put_index(
+                self, a_dst, v_x, v_y,
+                (((uint32_t)(v_c)) >> v_s) & v_index_mask);
+            v_x += 1;
+          }
+          goto label_0_continue;
+        }
+        if (v_bpp == 16) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+            uint16_t t_2;
+            if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+              t_2 = wuffs_base__load_u16le(b_rptr_src);
+              b_rptr_src += 2;
+            } else {
+              self->private_impl.c_decode_pixels[0].scratch = 0;
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+              while (true) {
+                if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                  goto short_read_src;
+                }
+                uint32_t t_1 =
+                    self->private_impl.c_decode_pixels[0].scratch >> 56;
+                self->private_impl.c_decode_pixels[0].scratch <<= 8;
+                self->private_impl.c_decode_pixels[0].scratch >>= 8;
+                self->private_impl.c_decode_pixels[0].scratch |=
+                    ((uint64_t)(*b_rptr_src++)) << t_1;
+                if (t_1 == 8) {
+                  t_2 = self->private_impl.c_decode_pixels[0].scratch;
+                  break;
+                }
+                t_1 += 8;
+                self->private_impl.c_decode_pixels[0].scratch |=
+                    ((uint64_t)(t_1)) << 56;
+              }
+            }
+            v_v = ((uint32_t)(t_2));
+          }
+        } else if (v_bpp == 24) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_3 = *b_rptr_src++;
+            v_v = ((uint32_t)(t_3));
+          }
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_4 = *b_rptr_src++;
+            v_v |= (((uint32_t)(t_4)) << 8);
+          }
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_5 = *b_rptr_src++;
+            v_v |= (((uint32_t)(t_5)) << 16);
+          }
+        } else {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+            uint32_t t_7;
+            if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+              t_7 = wuffs_base__load_u32le(b_rptr_src);
+              b_rptr_src += 4;
+            } else {
+              self->private_impl.c_decode_pixels[0].scratch = 0;
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+              while (true) {
+                if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                  goto short_read_src;
+                }
+                uint32_t t_6 =
+                    self->private_impl.c_decode_pixels[0].scratch >> 56;
+                self->private_impl.c_decode_pixels[0].scratch <<= 8;
+                self->private_impl.c_decode_pixels[0].scratch >>= 8;
+                self->private_impl.c_decode_pixels[0].scratch |=
+                    ((uint64_t)(*b_rptr_src++)) << t_6;
+                if (t_6 == 24) {
+                  t_7 = self->private_impl.c_decode_pixels[0].scratch;
+                  break;
+                }
+                t_6 += 8;
+                self->private_impl.c_decode_pixels[0].scratch |=
+                    ((uint64_t)(t_6)) << 56;
+              }
+            }
+            v_v = t_7;
+          }
+        }
+        wuffs_bmp__decoder__decode_channels(self, v_v);
+        wuffs_bmp__decoder__put_pixel(
+            self, a_dst, v_x, v_y, 0, self->private_impl.f_channel[0],
+            self->private_impl.f_channel[1], self->private_impl.f_channel[2],
+            self->private_impl.f_channel[3]);
+        if (v_x < self->private_impl.f_width) {
+          v_x += 1;
+        }
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      self->private_impl.c_decode_pixels[0].scratch = v_pad;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+      if (self->private_impl.c_decode_pixels[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_pixels[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_pixels[0].scratch;
+      if (v_y < self->private_impl.f_height) {
+        v_y += 1;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_pixels[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_pixels[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_pixels[0].v_bpp = v_bpp;
+  self->private_impl.c_decode_pixels[0].v_x = v_x;
+  self->private_impl.c_decode_pixels[0].v_y = v_y;
+  self->private_impl.c_decode_pixels[0].v_pad = v_pad;
+  self->private_impl.c_decode_pixels[0].v_index_mask = v_index_mask;
+  self->private_impl.c_decode_pixels[0].v_s = v_s;
+  self->private_impl.c_decode_pixels[0].v_c = v_c;
+  self->private_impl.c_decode_pixels[0].v_v = v_v;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_rle(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src,
+    bool a_rle4) {
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint64_t v_n;
+  uint64_t v_i;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_count;
+  uint32_t v_value;
+  uint32_t v_j;
+  uint32_t v_dx;
+  uint32_t v_dy;
+  uint8_t v_c;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_rle[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_decode_rle[0].v_n;
+    v_i = self->private_impl.c_decode_rle[0].v_i;
+    v_x = self->private_impl.c_decode_rle[0].v_x;
+    v_y = self->private_impl.c_decode_rle[0].v_y;
+    v_count = self->private_impl.c_decode_rle[0].v_count;
+    v_value = self->private_impl.c_decode_rle[0].v_value;
+    v_j = self->private_impl.c_decode_rle[0].v_j;
+    v_dx = self->private_impl.c_decode_rle[0].v_dx;
+    v_dy = self->private_impl.c_decode_rle[0].v_dy;
+    v_c = self->private_impl.c_decode_rle[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n = 0;
+    v_i = 0;
+    v_x = 0;
+    v_y = 0;
+    v_count = 0;
+    v_value = 0;
+    v_j = 0;
+    v_dx = 0;
+    v_dy = 0;
+    v_c = 0;
+    v_n = (((uint64_t)(self->private_impl.f_width)) *
+           ((uint64_t)(self->private_impl.f_height)) *
+           ((uint64_t)(self->private_impl.f_bytes_per_pixel)));
+    while ((v_i < v_n) && (v_i < ((uint64_t)(a_dst.len)))) {
+      a_dst.ptr[v_i] = 0;
+      v_i += 1;
+    }
+    while (v_y < self->private_impl.f_height) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_0 = *b_rptr_src++;
+        v_count = ((uint32_t)(t_0));
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_1 = *b_rptr_src++;
+        v_value = ((uint32_t)(t_1));
+      }
+      if (v_count > 0) {
+        v_j = 0;
+        while (v_j < v_count) {
+          if (!a_rle4) {
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y, v_value);
+          } else if ((v_j & 1) == 0) {
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y, v_value >> 4);
+          } else {
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y, v_value & 15);
+          }
+          if (v_x < self->private_impl.f_width) {
+            v_x += 1;
+          }
+          v_j += 1;
+        }
+      } else if (v_value == 0) {
+        v_x = 0;
+        if (v_y < self->private_impl.f_height) {
+          v_y += 1;
+        }
+      } else if (v_value == 1) {
+        goto label_0_break;
+      } else if (v_value == 2) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_2 = *b_rptr_src++;
+          v_dx = ((uint32_t)(t_2));
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_3 = *b_rptr_src++;
+          v_dy = ((uint32_t)(t_3));
+        }
+        if (v_x < self->private_impl.f_width) {
+          v_x += v_dx;
+          if (v_x > self->private_impl.f_width) {
+            v_x = self->private_impl.f_width;
+          }
+        }
+        if (v_y < self->private_impl.f_height) {
+          v_y += v_dy;
+        }
+      } else {
+        v_j = 0;
+        while (v_j < v_value) {
+          if (!a_rle4) {
+            {
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint8_t t_4 = *b_rptr_src++;
+              v_c = t_4;
+            }
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y,
+                                          ((uint32_t)(v_c)));
+          } else if ((v_j & 1) == 0) {
+            {
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint8_t t_5 = *b_rptr_src++;
+              v_c = t_5;
+            }
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y,
+                                          ((uint32_t)(v_c)) >> 4);
+          } else {
+            wuffs_bmp__decoder__put_index(self, a_dst, v_x, v_y,
+                                          ((uint32_t)(v_c)) & 15);
+          }
+          if (v_x < self->private_impl.f_width) {
+            v_x += 1;
+          }
+          v_j += 1;
+        }
+        if (a_rle4) {
+          v_j = ((v_value + 1) / 2);
+        } else {
+          v_j = v_value;
+        }
+        if ((v_j & 1) != 0) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+          self->private_impl.c_decode_rle[0].scratch = 1;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+          if (self->private_impl.c_decode_rle[0].scratch >
+              b_rend_src - b_rptr_src) {
+            self->private_impl.c_decode_rle[0].scratch -=
+                b_rend_src - b_rptr_src;
+            b_rptr_src = b_rend_src;
+            goto short_read_src;
+          }
+          b_rptr_src += self->private_impl.c_decode_rle[0].scratch;
+        }
+      }
+    }
+  label_0_break:;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_rle[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_rle[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_rle[0].v_n = v_n;
+  self->private_impl.c_decode_rle[0].v_i = v_i;
+  self->private_impl.c_decode_rle[0].v_x = v_x;
+  self->private_impl.c_decode_rle[0].v_y = v_y;
+  self->private_impl.c_decode_rle[0].v_count = v_count;
+  self->private_impl.c_decode_rle[0].v_value = v_value;
+  self->private_impl.c_decode_rle[0].v_j = v_j;
+  self->private_impl.c_decode_rle[0].v_dx = v_dx;
+  self->private_impl.c_decode_rle[0].v_dy = v_dy;
+  self->private_impl.c_decode_rle[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_bmp__status wuffs_bmp__decoder__decode_and_mask(
+    wuffs_bmp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src) {
+  wuffs_bmp__status status = WUFFS_BMP__STATUS_OK;
+
+  uint32_t v_row_bytes;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_i;
+  uint32_t v_s;
+  uint8_t v_c;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_and_mask[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_row_bytes = self->private_impl.c_decode_and_mask[0].v_row_bytes;
+    v_x = self->private_impl.c_decode_and_mask[0].v_x;
+    v_y = self->private_impl.c_decode_and_mask[0].v_y;
+    v_i = self->private_impl.c_decode_and_mask[0].v_i;
+    v_s = self->private_impl.c_decode_and_mask[0].v_s;
+    v_c = self->private_impl.c_decode_and_mask[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_row_bytes = 0;
+    v_x = 0;
+    v_y = 0;
+    v_i = 0;
+    v_s = 0;
+    v_c = 0;
+    v_row_bytes = (((self->private_impl.f_width + 31) >> 5) << 2);
+    while (v_y < self->private_impl.f_height) {
+      v_x = 0;
+      v_i = 0;
+      while (v_i < v_row_bytes) {
+        v_i += 1;
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_0 = *b_rptr_src++;
+          v_c = t_0;
+        }
+        v_s = 8;
+        while (v_s > 0) {
+          v_s -= 1;
+          if (((v_c >> v_s) & 1) != 0) {
+            wuffs_bmp__decoder__clear_alpha(self, a_dst, v_x, v_y);
+          }
+          if (v_x < self->private_impl.f_width) {
+            v_x += 1;
+          }
+        }
+      }
+      if (v_y < self->private_impl.f_height) {
+        v_y += 1;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_and_mask[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_and_mask[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_and_mask[0].v_row_bytes = v_row_bytes;
+  self->private_impl.c_decode_and_mask[0].v_x = v_x;
+  self->private_impl.c_decode_and_mask[0].v_y = v_y;
+  self->private_impl.c_decode_and_mask[0].v_i = v_i;
+  self->private_impl.c_decode_and_mask[0].v_s = v_s;
+  self->private_impl.c_decode_and_mask[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_BMP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_BMP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_bmp__decoder__put_index(wuffs_bmp__decoder* self,
+                                          wuffs_base__slice_u8 a_dst,
+                                          uint32_t a_x,
+                                          uint32_t a_y,
+                                          uint32_t a_idx) {
+  wuffs_bmp__decoder__put_pixel(
+      self, a_dst, a_x, a_y, a_idx,
+      ((uint32_t)(self->private_impl.f_palette[(3 * a_idx) + 0])),
+      ((uint32_t)(self->private_impl.f_palette[(3 * a_idx) + 1])),
+      ((uint32_t)(self->private_impl.f_palette[(3 * a_idx) + 2])), 255);
+}
+
+static void wuffs_bmp__decoder__put_pixel(wuffs_bmp__decoder* self,
+                                          wuffs_base__slice_u8 a_dst,
+                                          uint32_t a_x,
+                                          uint32_t a_y,
+                                          uint32_t a_idx,
+                                          uint32_t a_r,
+                                          uint32_t a_g,
+                                          uint32_t a_b,
+                                          uint32_t a_a) {
+  uint64_t v_width;
+  uint64_t v_bpp;
+  uint8_t v_px[4];
+  uint64_t v_y;
+  uint32_t v_c;
+  uint64_t v_o;
+
+  v_width = ((uint64_t)(self->private_impl.f_width));
+  v_bpp = ((uint64_t)(self->private_impl.f_bytes_per_pixel));
+  wuffs_base__memset(v_px, 0, sizeof(v_px));
+  if (a_x >= self->private_impl.f_width) {
+    return;
+  }
+  if (self->private_impl.f_height <= a_y) {
+    return;
+  }
+  v_y = ((uint64_t)(a_y));
+  if (!self->private_impl.f_top_down) {
+    v_y = ((uint64_t)(((self->private_impl.f_height - a_y) - 1)));
+  }
+  if (self->private_impl.f_pixel_format == 0) {
+    v_px[0] = ((uint8_t)(a_idx));
+  } else if (self->private_impl.f_pixel_format == 1) {
+    v_px[0] = ((uint8_t)(a_r));
+    v_px[1] = ((uint8_t)(a_g));
+    v_px[2] = ((uint8_t)(a_b));
+    v_px[3] = ((uint8_t)(a_a));
+  } else if (self->private_impl.f_pixel_format == 2) {
+    v_px[0] = ((uint8_t)(a_b));
+    v_px[1] = ((uint8_t)(a_g));
+    v_px[2] = ((uint8_t)(a_r));
+    v_px[3] = ((uint8_t)(a_a));
+  } else {
+    v_c = (((a_r >> 3) << 11) | ((a_g >> 2) << 5) | (a_b >> 3));
+    v_px[0] = ((uint8_t)((v_c & 255)));
+    v_px[1] = ((uint8_t)((v_c >> 8)));
+  }
+  v_o = ((((v_y & 2147483647) * v_width) + (((uint64_t)(a_x)) & 2147483647)) *
+         v_bpp);
+  if (v_o <= ((uint64_t)(a_dst.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(a_dst, v_o),
+        wuffs_base__slice_u8__subslice_j(
+            ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}), v_bpp));
+  }
+}
+
+static void wuffs_bmp__decoder__clear_alpha(wuffs_bmp__decoder* self,
+                                            wuffs_base__slice_u8 a_dst,
+                                            uint32_t a_x,
+                                            uint32_t a_y) {
+  uint64_t v_width;
+  uint64_t v_y;
+  uint64_t v_o;
+
+  v_width = ((uint64_t)(self->private_impl.f_width));
+  if (a_x >= self->private_impl.f_width) {
+    return;
+  }
+  if (self->private_impl.f_height <= a_y) {
+    return;
+  }
+  v_y = ((uint64_t)(a_y));
+  if (!self->private_impl.f_top_down) {
+    v_y = ((uint64_t)(((self->private_impl.f_height - a_y) - 1)));
+  }
+  v_o = (((((v_y & 2147483647) * v_width) + (((uint64_t)(a_x)) & 2147483647)) *
+          4) +
+         3);
+  if (v_o < ((uint64_t)(a_dst.len))) {
+    a_dst.ptr[v_o] = 0;
+  }
+}
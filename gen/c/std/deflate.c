@@ -20,9 +20,16 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
 #include <stdbool.h>
+#include <stddef.h>
 #include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
 #include <string.h>
+#endif
 
 // Wuffs requires a word size of at least 32 bits because it assumes that
 // converting a u32 to usize will never overflow. For example, the size of a
@@ -31,11 +38,22 @@
 //
 // Similarly, the word size is at most 64 bits because it assumes that
 // converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
 #if __WORDSIZE < 32
 #error "Wuffs requires a word size of at least 32 bits"
 #elif __WORDSIZE > 64
 #error "Wuffs requires a word size of at most 64 bits"
 #endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
 
 // WUFFS_VERSION is the major.minor version number as a uint32. The major
 // number is the high 16 bits. The minor number is the low 16 bits.
@@ -109,6 +127,66 @@ typedef struct {
 
 // ---------------- Images
 
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
 typedef struct {
   // Do not access the private_impl's fields directly. There is no API/ABI
   // compatibility or safety guarantee if you do so.
@@ -116,8 +194,11 @@ typedef struct {
     uint32_t flags;
     uint32_t w;
     uint32_t h;
-    // TODO: color model, including both packed RGBA and planar,
-    // chroma-subsampled YCbCr.
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
   } private_impl;
 } wuffs_base__image_config;
 
@@ -133,9 +214,22 @@ static inline bool wuffs_base__image_config__valid(
   if (!c || !(c->private_impl.flags & 1)) {
     return false;
   }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
   uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-  // TODO: handle things other than 1 byte per pixel.
-  return wh <= ((uint64_t)SIZE_MAX);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
 }
 
 static inline uint32_t wuffs_base__image_config__width(
@@ -148,31 +242,273 @@ static inline uint32_t wuffs_base__image_config__height(
   return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
-static inline size_t wuffs_base__image_config__pixbuf_size(
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
     wuffs_base__image_config* c) {
-  if (wuffs_base__image_config__valid(c)) {
-    uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
   }
-  return 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
 }
 
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
     uint32_t width,
     uint32_t height,
-    uint32_t TODO_color_model) {
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
   if (!c) {
     return;
   }
   c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
   c->private_impl.w = width;
   c->private_impl.h = height;
-  // TODO: color model.
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
 }
 
 #endif  // WUFFS_BASE_HEADER_H
@@ -211,6 +547,7 @@ typedef int32_t wuffs_deflate__status;
   -2147483638                                                   // 0x8000000A
 #define WUFFS_DEFLATE__ERROR_INVALID_CALL_SEQUENCE -2147483637  // 0x8000000B
 #define WUFFS_DEFLATE__SUSPENSION_END_OF_DATA 12                // 0x0000000C
+#define WUFFS_DEFLATE__SUSPENSION_END_OF_ANIMATION 13           // 0x0000000D
 
 #define WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_OVER_SUBSCRIBED \
   -1278585856  // 0xB3CA5400
@@ -274,8 +611,6 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
       wuffs_deflate__status v_z;
-      uint64_t v_n_copied;
-      uint32_t v_already_full;
     } c_decode[1];
     struct {
       uint32_t coro_susp_point;
@@ -344,6 +679,9 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
     wuffs_base__writer1 a_dst,
     wuffs_base__reader1 a_src);
 
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+                                         wuffs_base__slice_u8 a_hist);
+
 #ifdef __cplusplus
 }  // extern "C"
 #endif
@@ -453,6 +791,54 @@ typedef struct {
 // inline attribute to guide optimizations such as inlining, to avoid the
 // -Wunused-function warning, and we like to compile with -Wall -Werror.
 
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
 static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
   return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
 }
@@ -527,7 +913,8 @@ static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
 }
 
 // wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
-// length) where length is the minimum of dst.len and src.len.
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
 //
 // Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
 // slice) is valid and results in a no-op.
@@ -536,11 +923,86 @@ static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
     wuffs_base__slice_u8 src) {
   size_t length = dst.len < src.len ? dst.len : src.len;
   if (length > 0) {
-    memmove(dst.ptr, src.ptr, length);
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
   }
   return length;
 }
 
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x =
+            (uint16_t)(((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                       ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
 static inline uint32_t wuffs_base__writer1__copy_from_history32(
     uint8_t** ptr_ptr,
     uint8_t* start,  // May be NULL, meaning an unmarked writer1.
@@ -627,7 +1089,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_reader32(
     n = rend - rptr;
   }
   if (n > 0) {
-    memmove(wptr, rptr, n);
+    wuffs_base__memmove(wptr, rptr, n);
     *ptr_wptr += n;
     *ptr_rptr += n;
   }
@@ -644,7 +1106,7 @@ static inline uint64_t wuffs_base__writer1__copy_from_slice(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -664,7 +1126,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_slice32(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -707,7 +1169,7 @@ static inline wuffs_base__empty_struct wuffs_base__writer1__mark(
   return ((wuffs_base__empty_struct){});
 }
 
-static const char* wuffs_base__status__strings[13] = {
+static const char* wuffs_base__status__strings[14] = {
     "ok",
     "bad wuffs version",
     "bad receiver",
@@ -721,6 +1183,7 @@ static const char* wuffs_base__status__strings[13] = {
     "cannot return a suspension",
     "invalid call sequence",
     "end of data",
+    "end of animation",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -757,7 +1220,7 @@ const char* wuffs_deflate__status__string(wuffs_deflate__status s) {
   switch ((s >> 10) & 0x1FFFFF) {
     case 0:
       a = wuffs_base__status__strings;
-      n = 13;
+      n = 14;
       break;
     case wuffs_deflate__packageid:
       a = wuffs_deflate__status__strings;
@@ -862,7 +1325,7 @@ void wuffs_deflate__decoder__initialize(wuffs_deflate__decoder* self,
     return;
   }
   if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
-    memset(self, 0, sizeof(*self));
+    wuffs_base__memset(self, 0, sizeof(*self));
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
 }
@@ -885,9 +1348,6 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
   wuffs_deflate__status status = WUFFS_DEFLATE__STATUS_OK;
 
   wuffs_deflate__status v_z;
-  wuffs_base__slice_u8 v_written;
-  uint64_t v_n_copied;
-  uint32_t v_already_full;
 
   uint8_t* b_wptr_dst = NULL;
   uint8_t* b_wstart_dst = NULL;
@@ -911,9 +1371,6 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
   uint32_t coro_susp_point = self->private_impl.c_decode[0].coro_susp_point;
   if (coro_susp_point) {
     v_z = self->private_impl.c_decode[0].v_z;
-    v_written = ((wuffs_base__slice_u8){});
-    v_n_copied = self->private_impl.c_decode[0].v_n_copied;
-    v_already_full = self->private_impl.c_decode[0].v_already_full;
   } else {
   }
   switch (coro_susp_point) {
@@ -949,44 +1406,13 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
         }
         goto exit;
       }
-      v_written = ((wuffs_base__slice_u8){
-          .ptr = a_dst.private_impl.mark,
-          .len = a_dst.private_impl.mark
-                     ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
-                     : 0,
-      });
-      if (((uint64_t)(v_written.len)) >= 32768) {
-        v_written = wuffs_base__slice_u8_suffix(v_written, 32768);
-        wuffs_base__slice_u8__copy_from_slice(
-            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                    .len = 32768}),
-            v_written);
-        self->private_impl.f_history_index = 32768;
-      } else {
-        v_n_copied = wuffs_base__slice_u8__copy_from_slice(
-            wuffs_base__slice_u8__subslice_i(
-                ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                        .len = 32768}),
-                self->private_impl.f_history_index & 32767),
-            v_written);
-        if (v_n_copied < ((uint64_t)(v_written.len))) {
-          v_written = wuffs_base__slice_u8__subslice_i(v_written, v_n_copied);
-          v_n_copied = wuffs_base__slice_u8__copy_from_slice(
-              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
-                                      .len = 32768}),
-              v_written);
-          self->private_impl.f_history_index =
-              (((uint32_t)((v_n_copied & 32767))) + 32768);
-        } else {
-          v_already_full = 0;
-          if (self->private_impl.f_history_index >= 32768) {
-            v_already_full = 32768;
-          }
-          self->private_impl.f_history_index =
-              ((self->private_impl.f_history_index & 32767) +
-               ((uint32_t)((v_n_copied & 32767))) + v_already_full);
-        }
-      }
+      wuffs_deflate__decoder__add_history(
+          self, ((wuffs_base__slice_u8){
+                    .ptr = a_dst.private_impl.mark,
+                    .len = a_dst.private_impl.mark
+                               ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
+                               : 0,
+                }));
       status = v_z;
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
     }
@@ -1001,8 +1427,6 @@ wuffs_deflate__status wuffs_deflate__decoder__decode(
 suspend:
   self->private_impl.c_decode[0].coro_susp_point = coro_susp_point;
   self->private_impl.c_decode[0].v_z = v_z;
-  self->private_impl.c_decode[0].v_n_copied = v_n_copied;
-  self->private_impl.c_decode[0].v_already_full = v_already_full;
 
   goto exit;
 exit:
@@ -1023,6 +1447,55 @@ exit:
   return status;
 }
 
+void wuffs_deflate__decoder__add_history(wuffs_deflate__decoder* self,
+                                         wuffs_base__slice_u8 a_hist) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_DEFLATE__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+
+  uint64_t v_n_copied;
+  uint32_t v_already_full;
+
+  if (((uint64_t)(a_hist.len)) >= 32768) {
+    a_hist = wuffs_base__slice_u8_suffix(a_hist, 32768);
+    wuffs_base__slice_u8__copy_from_slice(
+        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
+                                .len = 32768}),
+        a_hist);
+    self->private_impl.f_history_index = 32768;
+  } else {
+    v_n_copied = wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(
+            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
+                                    .len = 32768}),
+            self->private_impl.f_history_index & 32767),
+        a_hist);
+    if (v_n_copied < ((uint64_t)(a_hist.len))) {
+      a_hist = wuffs_base__slice_u8__subslice_i(a_hist, v_n_copied);
+      v_n_copied = wuffs_base__slice_u8__copy_from_slice(
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_history,
+                                  .len = 32768}),
+          a_hist);
+      self->private_impl.f_history_index =
+          (((uint32_t)((v_n_copied & 32767))) + 32768);
+    } else {
+      v_already_full = 0;
+      if (self->private_impl.f_history_index >= 32768) {
+        v_already_full = 32768;
+      }
+      self->private_impl.f_history_index =
+          ((self->private_impl.f_history_index & 32767) +
+           ((uint32_t)((v_n_copied & 32767))) + v_already_full);
+    }
+  }
+}
+
 static wuffs_deflate__status wuffs_deflate__decoder__decode_blocks(
     wuffs_deflate__decoder* self,
     wuffs_base__writer1 a_dst,
@@ -1174,6 +1647,9 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_blocks(
           WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_END_OF_BLOCK;
       goto exit;
     }
+    self->private_impl.f_bits = 0;
+    self->private_impl.f_n_bits = 0;
+    self->private_impl.f_history_index = 0;
 
     goto ok;
   ok:
@@ -1741,7 +2217,7 @@ static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
   uint32_t v_high_bits;
   uint32_t v_delta;
 
-  memset(v_counts, 0, sizeof(v_counts));
+  wuffs_base__memset(v_counts, 0, sizeof(v_counts));
   v_i = a_n_codes0;
   while (v_i < a_n_codes1) {
     if (v_counts[self->private_impl.f_code_lengths[v_i]] >= 320) {
@@ -1773,10 +2249,13 @@ static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
     v_i += 1;
   }
   if (v_remaining != 0) {
-    status = WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
-    goto exit;
+    if ((a_which != 1) || (v_counts[1] != 1) || (v_remaining != 16384)) {
+      status = WUFFS_DEFLATE__ERROR_BAD_HUFFMAN_CODE_UNDER_SUBSCRIBED;
+      goto exit;
+    }
+    self->private_impl.f_huffs[1][1] = 134217729;
   }
-  memset(v_offsets, 0, sizeof(v_offsets));
+  wuffs_base__memset(v_offsets, 0, sizeof(v_offsets));
   v_n_symbols = 0;
   v_i = 1;
   while (v_i <= 15) {
@@ -1795,7 +2274,7 @@ static wuffs_deflate__status wuffs_deflate__decoder__init_huff(
         WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
     goto exit;
   }
-  memset(v_symbols, 0, sizeof(v_symbols));
+  wuffs_base__memset(v_symbols, 0, sizeof(v_symbols));
   v_i = a_n_codes0;
   while (v_i < a_n_codes1) {
     if (v_i < a_n_codes0) {
@@ -1860,9 +2339,9 @@ label_1_break:;
   if (v_max_cl < 9) {
     v_initial_high_bits = (((uint32_t)(1)) << v_max_cl);
   }
-  v_prev_cl = ((uint32_t)(
-      self->private_impl
-          .f_code_lengths[a_n_codes0 + ((uint32_t)(v_symbols[0]))]));
+  v_prev_cl = ((
+      uint32_t)(self->private_impl
+                    .f_code_lengths[a_n_codes0 + ((uint32_t)(v_symbols[0]))]));
   v_prev_redirect_key = 4294967295;
   v_top = 0;
   v_next_top = 512;
@@ -1875,9 +2354,9 @@ label_1_break:;
           WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_DECODER_STATE;
       goto exit;
     }
-    v_cl = ((uint32_t)(
-        self->private_impl
-            .f_code_lengths[a_n_codes0 + ((uint32_t)(v_symbols[v_i]))]));
+    v_cl = ((uint32_t)(self->private_impl
+                           .f_code_lengths[a_n_codes0 +
+                                           ((uint32_t)(v_symbols[v_i]))]));
     if (v_cl > v_prev_cl) {
       v_code <<= (v_cl - v_prev_cl);
       if (v_code >= 32768) {
@@ -2243,25 +2722,25 @@ label_0_continue:;
     v_n_copied = 0;
     while (true) {
       if (((uint64_t)((v_dist_minus_1 + 1))) >
-          ((uint64_t)(
-              ((wuffs_base__slice_u8){
-                   .ptr = a_dst.private_impl.mark,
-                   .len = a_dst.private_impl.mark
-                              ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
-                              : 0,
-               })
-                  .len))) {
+          ((uint64_t)(((wuffs_base__slice_u8){
+                           .ptr = a_dst.private_impl.mark,
+                           .len = a_dst.private_impl.mark
+                                      ? (size_t)(b_wptr_dst -
+                                                 a_dst.private_impl.mark)
+                                      : 0,
+                       })
+                          .len))) {
         v_hlen = 0;
         v_hdist = ((uint32_t)((
             ((uint64_t)((v_dist_minus_1 + 1))) -
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = a_dst.private_impl.mark,
-                     .len = a_dst.private_impl.mark
-                                ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
-                                : 0,
-                 })
-                    .len)))));
+            ((uint64_t)(((wuffs_base__slice_u8){
+                             .ptr = a_dst.private_impl.mark,
+                             .len = a_dst.private_impl.mark
+                                        ? (size_t)(b_wptr_dst -
+                                                   a_dst.private_impl.mark)
+                                        : 0,
+                         })
+                            .len)))));
         if (v_length > v_hdist) {
           v_length -= v_hdist;
           v_hlen = v_hdist;
@@ -2303,14 +2782,14 @@ label_0_continue:;
           goto label_0_continue;
         }
         if (((uint64_t)((v_dist_minus_1 + 1))) >
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = a_dst.private_impl.mark,
-                     .len = a_dst.private_impl.mark
-                                ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
-                                : 0,
-                 })
-                    .len))) {
+            ((uint64_t)(((wuffs_base__slice_u8){
+                             .ptr = a_dst.private_impl.mark,
+                             .len = a_dst.private_impl.mark
+                                        ? (size_t)(b_wptr_dst -
+                                                   a_dst.private_impl.mark)
+                                        : 0,
+                         })
+                            .len))) {
           status = WUFFS_DEFLATE__ERROR_INTERNAL_ERROR_INCONSISTENT_DISTANCE;
           goto exit;
         }
@@ -2650,25 +3129,25 @@ static wuffs_deflate__status wuffs_deflate__decoder__decode_huffman_slow(
       v_n_copied = 0;
       while (true) {
         if (((uint64_t)((v_dist_minus_1 + 1))) >
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = a_dst.private_impl.mark,
-                     .len = a_dst.private_impl.mark
-                                ? (size_t)(b_wptr_dst - a_dst.private_impl.mark)
-                                : 0,
-                 })
-                    .len))) {
+            ((uint64_t)(((wuffs_base__slice_u8){
+                             .ptr = a_dst.private_impl.mark,
+                             .len = a_dst.private_impl.mark
+                                        ? (size_t)(b_wptr_dst -
+                                                   a_dst.private_impl.mark)
+                                        : 0,
+                         })
+                            .len))) {
           v_hlen = 0;
-          v_hdist = ((uint32_t)(
-              (((uint64_t)((v_dist_minus_1 + 1))) -
-               ((uint64_t)(((wuffs_base__slice_u8){
-                                .ptr = a_dst.private_impl.mark,
-                                .len = a_dst.private_impl.mark
-                                           ? (size_t)(b_wptr_dst -
-                                                      a_dst.private_impl.mark)
-                                           : 0,
-                            })
-                               .len)))));
+          v_hdist = ((uint32_t)((
+              ((uint64_t)((v_dist_minus_1 + 1))) -
+              ((uint64_t)(((wuffs_base__slice_u8){
+                               .ptr = a_dst.private_impl.mark,
+                               .len = a_dst.private_impl.mark
+                                          ? (size_t)(b_wptr_dst -
+                                                     a_dst.private_impl.mark)
+                                          : 0,
+                           })
+                              .len)))));
           if (v_length > v_hdist) {
             v_length -= v_hdist;
             v_hlen = v_hdist;
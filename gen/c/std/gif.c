@@ -20,9 +20,16 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
 #include <stdbool.h>
+#include <stddef.h>
 #include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
 #include <string.h>
+#endif
 
 // Wuffs requires a word size of at least 32 bits because it assumes that
 // converting a u32 to usize will never overflow. For example, the size of a
@@ -31,11 +38,22 @@
 //
 // Similarly, the word size is at most 64 bits because it assumes that
 // converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
 #if __WORDSIZE < 32
 #error "Wuffs requires a word size of at least 32 bits"
 #elif __WORDSIZE > 64
 #error "Wuffs requires a word size of at most 64 bits"
 #endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
 
 // WUFFS_VERSION is the major.minor version number as a uint32. The major
 // number is the high 16 bits. The minor number is the low 16 bits.
@@ -109,6 +127,66 @@ typedef struct {
 
 // ---------------- Images
 
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
 typedef struct {
   // Do not access the private_impl's fields directly. There is no API/ABI
   // compatibility or safety guarantee if you do so.
@@ -116,8 +194,11 @@ typedef struct {
     uint32_t flags;
     uint32_t w;
     uint32_t h;
-    // TODO: color model, including both packed RGBA and planar,
-    // chroma-subsampled YCbCr.
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
   } private_impl;
 } wuffs_base__image_config;
 
@@ -133,9 +214,22 @@ static inline bool wuffs_base__image_config__valid(
   if (!c || !(c->private_impl.flags & 1)) {
     return false;
   }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
   uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-  // TODO: handle things other than 1 byte per pixel.
-  return wh <= ((uint64_t)SIZE_MAX);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
 }
 
 static inline uint32_t wuffs_base__image_config__width(
@@ -148,31 +242,273 @@ static inline uint32_t wuffs_base__image_config__height(
   return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
 }
 
-// TODO: this is the right API for planar (not packed) pixbufs? Should it allow
-// decoding into a color model different from the format's intrinsic one? For
-// example, decoding a JPEG image straight to RGBA instead of to YCbCr?
-static inline size_t wuffs_base__image_config__pixbuf_size(
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
     wuffs_base__image_config* c) {
-  if (wuffs_base__image_config__valid(c)) {
-    uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
-    // TODO: handle things other than 1 byte per pixel.
-    return (size_t)wh;
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
   }
-  return 0;
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
 }
 
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
 static inline void wuffs_base__image_config__initialize(
     wuffs_base__image_config* c,
     uint32_t width,
     uint32_t height,
-    uint32_t TODO_color_model) {
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
   if (!c) {
     return;
   }
   c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
   c->private_impl.w = width;
   c->private_impl.h = height;
-  // TODO: color model.
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
 }
 
 #endif  // WUFFS_BASE_HEADER_H
@@ -210,15 +546,17 @@ typedef int32_t wuffs_gif__status;
 #define WUFFS_GIF__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
 #define WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
 #define WUFFS_GIF__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_GIF__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
 
 #define WUFFS_GIF__ERROR_BAD_GIF_BLOCK -1105848320            // 0xBE161800
 #define WUFFS_GIF__ERROR_BAD_GIF_EXTENSION_LABEL -1105848319  // 0xBE161801
-#define WUFFS_GIF__ERROR_BAD_GIF_HEADER -1105848318           // 0xBE161802
-#define WUFFS_GIF__ERROR_BAD_LZW_LITERAL_WIDTH -1105848317    // 0xBE161803
+#define WUFFS_GIF__ERROR_BAD_GIF_GRAPHIC_CONTROL -1105848318  // 0xBE161802
+#define WUFFS_GIF__ERROR_BAD_GIF_HEADER -1105848317           // 0xBE161803
+#define WUFFS_GIF__ERROR_BAD_LZW_LITERAL_WIDTH -1105848316    // 0xBE161804
 #define WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_LIMITED_READ \
-  -1105848316                                                      // 0xBE161804
-#define WUFFS_GIF__ERROR_LZW_CODE_IS_OUT_OF_RANGE -1105848315      // 0xBE161805
-#define WUFFS_GIF__ERROR_LZW_PREFIX_CHAIN_IS_CYCLICAL -1105848314  // 0xBE161806
+  -1105848315                                                      // 0xBE161805
+#define WUFFS_GIF__ERROR_LZW_CODE_IS_OUT_OF_RANGE -1105848314      // 0xBE161806
+#define WUFFS_GIF__ERROR_LZW_PREFIX_CHAIN_IS_CYCLICAL -1105848313  // 0xBE161807
 
 bool wuffs_gif__status__is_error(wuffs_gif__status s);
 
@@ -241,9 +579,11 @@ typedef struct {
     uint32_t magic;
 
     uint32_t f_literal_width;
+    uint32_t f_output_wi;
     uint8_t f_stack[4096];
     uint8_t f_suffixes[4096];
     uint16_t f_prefixes[4096];
+    uint8_t f_output[8192];
 
     struct {
       uint32_t coro_susp_point;
@@ -252,12 +592,12 @@ typedef struct {
       uint32_t v_save_code;
       uint32_t v_prev_code;
       uint32_t v_width;
+      uint32_t v_output_wi;
       uint32_t v_bits;
       uint32_t v_n_bits;
       uint32_t v_code;
       uint32_t v_s;
       uint32_t v_c;
-      uint64_t v_n_copied;
     } c_decode[1];
   } private_impl;
 } wuffs_gif__lzw_decoder;
@@ -276,19 +616,28 @@ typedef struct {
 
     uint32_t f_width;
     uint32_t f_height;
+    uint32_t f_pixel_format;
+    uint32_t f_bytes_per_pixel;
     uint8_t f_call_sequence;
+    bool f_end_of_animation;
     uint8_t f_background_color_index;
     uint8_t f_block_type;
     bool f_peek_block_type;
     bool f_have_gct;
     bool f_have_lct;
-    bool f_interlace;
     bool f_seen_num_loops;
     uint32_t f_num_loops;
+    bool f_gc_has_transparent_index;
+    uint8_t f_gc_transparent_index;
+    uint8_t f_gc_disposal;
+    uint32_t f_gc_delay;
     uint32_t f_frame_top;
     uint32_t f_frame_left;
     uint32_t f_frame_width;
     uint32_t f_frame_height;
+    uint32_t f_dst_x;
+    uint32_t f_dst_y;
+    uint32_t f_interlace;
     uint8_t f_gct[768];
     uint8_t f_lct[768];
     wuffs_gif__lzw_decoder f_lzw;
@@ -296,9 +645,22 @@ typedef struct {
     struct {
       uint32_t coro_susp_point;
     } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_x0;
+      uint32_t v_x1;
+      uint32_t v_w;
+      uint32_t v_y0;
+      uint32_t v_y1;
+      uint32_t v_h;
+      uint32_t v_transparent_index;
+    } c_decode_frame_config[1];
     struct {
       uint32_t coro_susp_point;
     } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_up_to_id[1];
     struct {
       uint32_t coro_susp_point;
       uint8_t v_c[6];
@@ -319,6 +681,12 @@ typedef struct {
       uint8_t v_block_size;
       uint64_t scratch;
     } c_skip_blocks[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+      uint8_t v_flags;
+      uint64_t scratch;
+    } c_decode_gc[1];
     struct {
       uint32_t coro_susp_point;
       uint8_t v_c;
@@ -332,11 +700,14 @@ typedef struct {
       uint8_t v_flags;
       uint32_t v_lct_size;
       uint32_t v_i;
+      uint64_t scratch;
+    } c_decode_id[1];
+    struct {
+      uint32_t coro_susp_point;
       uint8_t v_lw;
       uint64_t v_block_size;
       wuffs_gif__status v_z;
-      uint64_t scratch;
-    } c_decode_id[1];
+    } c_decode_tbid[1];
   } private_impl;
 } wuffs_gif__decoder;
 
@@ -353,13 +724,21 @@ void wuffs_gif__decoder__initialize(wuffs_gif__decoder* self,
 
 // ---------------- Public Function Prototypes
 
+void wuffs_gif__decoder__set_pixel_format(wuffs_gif__decoder* self,
+                                          uint32_t a_pixel_format);
+
 wuffs_gif__status wuffs_gif__decoder__decode_config(
     wuffs_gif__decoder* self,
     wuffs_base__image_config* a_dst,
     wuffs_base__reader1 a_src);
 
+wuffs_gif__status wuffs_gif__decoder__decode_frame_config(
+    wuffs_gif__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src);
+
 wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
-                                                   wuffs_base__writer1 a_dst,
+                                                   wuffs_base__slice_u8 a_dst,
                                                    wuffs_base__reader1 a_src);
 
 #ifdef __cplusplus
@@ -471,6 +850,54 @@ typedef struct {
 // inline attribute to guide optimizations such as inlining, to avoid the
 // -Wunused-function warning, and we like to compile with -Wall -Werror.
 
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
 static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
   return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
 }
@@ -545,7 +972,8 @@ static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
 }
 
 // wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
-// length) where length is the minimum of dst.len and src.len.
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
 //
 // Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
 // slice) is valid and results in a no-op.
@@ -554,11 +982,86 @@ static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
     wuffs_base__slice_u8 src) {
   size_t length = dst.len < src.len ? dst.len : src.len;
   if (length > 0) {
-    memmove(dst.ptr, src.ptr, length);
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
   }
   return length;
 }
 
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x =
+            (uint16_t)(((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                       ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
 static inline uint32_t wuffs_base__writer1__copy_from_history32(
     uint8_t** ptr_ptr,
     uint8_t* start,  // May be NULL, meaning an unmarked writer1.
@@ -645,7 +1148,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_reader32(
     n = rend - rptr;
   }
   if (n > 0) {
-    memmove(wptr, rptr, n);
+    wuffs_base__memmove(wptr, rptr, n);
     *ptr_wptr += n;
     *ptr_rptr += n;
   }
@@ -662,7 +1165,7 @@ static inline uint64_t wuffs_base__writer1__copy_from_slice(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -682,7 +1185,7 @@ static inline uint32_t wuffs_base__writer1__copy_from_slice32(
     n = wend - wptr;
   }
   if (n > 0) {
-    memmove(wptr, src.ptr, n);
+    wuffs_base__memmove(wptr, src.ptr, n);
     *ptr_wptr += n;
   }
   return n;
@@ -725,7 +1228,7 @@ static inline wuffs_base__empty_struct wuffs_base__writer1__mark(
   return ((wuffs_base__empty_struct){});
 }
 
-static const char* wuffs_base__status__strings[13] = {
+static const char* wuffs_base__status__strings[14] = {
     "ok",
     "bad wuffs version",
     "bad receiver",
@@ -739,6 +1242,7 @@ static const char* wuffs_base__status__strings[13] = {
     "cannot return a suspension",
     "invalid call sequence",
     "end of data",
+    "end of animation",
 };
 
 #endif  // WUFFS_BASE_IMPL_H
@@ -749,9 +1253,10 @@ bool wuffs_gif__status__is_error(wuffs_gif__status s) {
   return s < 0;
 }
 
-const char* wuffs_gif__status__strings[7] = {
+const char* wuffs_gif__status__strings[8] = {
     "gif: bad GIF block",
     "gif: bad GIF extension label",
+    "gif: bad GIF graphic control",
     "gif: bad GIF header",
     "gif: bad LZW literal width",
     "gif: internal error: inconsistent limited read",
@@ -765,11 +1270,11 @@ const char* wuffs_gif__status__string(wuffs_gif__status s) {
   switch ((s >> 10) & 0x1FFFFF) {
     case 0:
       a = wuffs_base__status__strings;
-      n = 13;
+      n = 14;
       break;
     case wuffs_gif__packageid:
       a = wuffs_gif__status__strings;
-      n = 7;
+      n = 8;
       break;
   }
   uint32_t i = s & 0xFF;
@@ -786,6 +1291,14 @@ static const uint8_t wuffs_gif__netscape2dot0[11] = {
     78, 69, 84, 83, 67, 65, 80, 69, 50, 46, 48,
 };
 
+static const uint32_t wuffs_gif__interlace_start[5] = {
+    4294967295, 1, 2, 4, 0,
+};
+
+static const uint8_t wuffs_gif__interlace_delta[5] = {
+    1, 2, 4, 8, 8,
+};
+
 // ---------------- Private Initializer Prototypes
 
 void wuffs_gif__lzw_decoder__initialize(wuffs_gif__lzw_decoder* self,
@@ -794,6 +1307,10 @@ void wuffs_gif__lzw_decoder__initialize(wuffs_gif__lzw_decoder* self,
 
 // ---------------- Private Function Prototypes
 
+static wuffs_gif__status wuffs_gif__decoder__decode_up_to_id(
+    wuffs_gif__decoder* self,
+    wuffs_base__reader1 a_src);
+
 static wuffs_gif__status wuffs_gif__decoder__decode_header(
     wuffs_gif__decoder* self,
     wuffs_base__reader1 a_src);
@@ -810,22 +1327,33 @@ static wuffs_gif__status wuffs_gif__decoder__skip_blocks(
     wuffs_gif__decoder* self,
     wuffs_base__reader1 a_src);
 
+static wuffs_gif__status wuffs_gif__decoder__decode_gc(
+    wuffs_gif__decoder* self,
+    wuffs_base__reader1 a_src);
+
 static wuffs_gif__status wuffs_gif__decoder__decode_ae(
     wuffs_gif__decoder* self,
     wuffs_base__reader1 a_src);
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id(
     wuffs_gif__decoder* self,
-    wuffs_base__writer1 a_dst,
     wuffs_base__reader1 a_src);
 
+static wuffs_gif__status wuffs_gif__decoder__decode_tbid(
+    wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_gif__decoder__copy_to_dst(wuffs_gif__decoder* self,
+                                            wuffs_base__slice_u8 a_dst,
+                                            wuffs_base__slice_u8 a_src);
+
 static void wuffs_gif__lzw_decoder__set_literal_width(
     wuffs_gif__lzw_decoder* self,
     uint32_t a_lw);
 
 static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
     wuffs_gif__lzw_decoder* self,
-    wuffs_base__writer1 a_dst,
     wuffs_base__reader1 a_src);
 
 // ---------------- Initializer Implementations
@@ -841,7 +1369,7 @@ void wuffs_gif__lzw_decoder__initialize(wuffs_gif__lzw_decoder* self,
     return;
   }
   if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
-    memset(self, 0, sizeof(*self));
+    wuffs_base__memset(self, 0, sizeof(*self));
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
   self->private_impl.f_literal_width = 8;
@@ -858,9 +1386,10 @@ void wuffs_gif__decoder__initialize(wuffs_gif__decoder* self,
     return;
   }
   if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
-    memset(self, 0, sizeof(*self));
+    wuffs_base__memset(self, 0, sizeof(*self));
   }
   self->private_impl.magic = WUFFS_BASE__MAGIC;
+  self->private_impl.f_bytes_per_pixel = 1;
   self->private_impl.f_num_loops = 1;
   wuffs_gif__lzw_decoder__initialize(&self->private_impl.f_lzw, WUFFS_VERSION,
                                      WUFFS_BASE__ALREADY_ZEROED);
@@ -868,6 +1397,35 @@ void wuffs_gif__decoder__initialize(wuffs_gif__decoder* self,
 
 // ---------------- Function Implementations
 
+void wuffs_gif__decoder__set_pixel_format(wuffs_gif__decoder* self,
+                                          uint32_t a_pixel_format) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+  if (a_pixel_format > 3) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+    return;
+  }
+
+  if (self->private_impl.f_call_sequence != 0) {
+    return;
+  }
+  self->private_impl.f_pixel_format = a_pixel_format;
+  if (a_pixel_format == 0) {
+    self->private_impl.f_bytes_per_pixel = 1;
+  } else if (a_pixel_format == 3) {
+    self->private_impl.f_bytes_per_pixel = 2;
+  } else {
+    self->private_impl.f_bytes_per_pixel = 4;
+  }
+}
+
 wuffs_gif__status wuffs_gif__decoder__decode_config(
     wuffs_gif__decoder* self,
     wuffs_base__image_config* a_dst,
@@ -986,7 +1544,8 @@ wuffs_gif__status wuffs_gif__decoder__decode_config(
     }
   label_0_break:;
     wuffs_base__image_config__initialize(a_dst, self->private_impl.f_width,
-                                         self->private_impl.f_height, 0);
+                                         self->private_impl.f_height,
+                                         self->private_impl.f_pixel_format);
     self->private_impl.f_call_sequence = 1;
 
     goto ok;
@@ -1026,9 +1585,10 @@ short_read_src:
   goto suspend;
 }
 
-wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
-                                                   wuffs_base__writer1 a_dst,
-                                                   wuffs_base__reader1 a_src) {
+wuffs_gif__status wuffs_gif__decoder__decode_frame_config(
+    wuffs_gif__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src) {
   if (!self) {
     return WUFFS_GIF__ERROR_BAD_RECEIVER;
   }
@@ -1038,23 +1598,132 @@ wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
   if (self->private_impl.status < 0) {
     return self->private_impl.status;
   }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
+    return WUFFS_GIF__ERROR_BAD_ARGUMENT;
+  }
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
-  uint8_t* b_rptr_src = NULL;
-  uint8_t* b_rstart_src = NULL;
-  uint8_t* b_rend_src = NULL;
-  if (a_src.buf) {
-    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
-    b_rstart_src = b_rptr_src;
-    uint64_t len = a_src.buf->wi - a_src.buf->ri;
-    wuffs_base__limit1* lim;
-    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
-      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
-        len = *lim->ptr_to_len;
+  uint32_t v_x0;
+  uint32_t v_x1;
+  uint32_t v_w;
+  uint32_t v_y0;
+  uint32_t v_y1;
+  uint32_t v_h;
+  uint32_t v_transparent_index;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_x0 = self->private_impl.c_decode_frame_config[0].v_x0;
+    v_x1 = self->private_impl.c_decode_frame_config[0].v_x1;
+    v_w = self->private_impl.c_decode_frame_config[0].v_w;
+    v_y0 = self->private_impl.c_decode_frame_config[0].v_y0;
+    v_y1 = self->private_impl.c_decode_frame_config[0].v_y1;
+    v_h = self->private_impl.c_decode_frame_config[0].v_h;
+    v_transparent_index =
+        self->private_impl.c_decode_frame_config[0].v_transparent_index;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence != 1) {
+      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_gif__decoder__decode_up_to_id(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    if (self->private_impl.f_end_of_animation) {
+      while (true) {
+        status = WUFFS_GIF__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
       }
     }
-    b_rend_src = b_rptr_src + len;
+    v_x0 = self->private_impl.f_frame_left;
+    if (v_x0 > self->private_impl.f_width) {
+      v_x0 = self->private_impl.f_width;
+    }
+    v_x1 = (self->private_impl.f_frame_left + self->private_impl.f_frame_width);
+    if (v_x1 > self->private_impl.f_width) {
+      v_x1 = self->private_impl.f_width;
+    }
+    v_w = 0;
+    if (v_x1 > v_x0) {
+      v_w = (v_x1 - v_x0);
+    }
+    v_y0 = self->private_impl.f_frame_top;
+    if (v_y0 > self->private_impl.f_height) {
+      v_y0 = self->private_impl.f_height;
+    }
+    v_y1 = (self->private_impl.f_frame_top + self->private_impl.f_frame_height);
+    if (v_y1 > self->private_impl.f_height) {
+      v_y1 = self->private_impl.f_height;
+    }
+    v_h = 0;
+    if (v_y1 > v_y0) {
+      v_h = (v_y1 - v_y0);
+    }
+    v_transparent_index = 256;
+    if (self->private_impl.f_gc_has_transparent_index) {
+      v_transparent_index =
+          ((uint32_t)(self->private_impl.f_gc_transparent_index));
+    }
+    if (self->private_impl.f_have_lct) {
+      wuffs_base__frame_config__initialize(
+          a_dst, v_x0, v_y0, v_w, v_h, self->private_impl.f_gc_delay * 10,
+          ((uint32_t)(self->private_impl.f_gc_disposal)), v_transparent_index,
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_lct,
+                                  .len = 768}));
+    } else {
+      wuffs_base__frame_config__initialize(
+          a_dst, v_x0, v_y0, v_w, v_h, self->private_impl.f_gc_delay * 10,
+          ((uint32_t)(self->private_impl.f_gc_disposal)), v_transparent_index,
+          ((wuffs_base__slice_u8){.ptr = self->private_impl.f_gct,
+                                  .len = 768}));
+    }
+    self->private_impl.f_call_sequence = 2;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame_config[0].v_x0 = v_x0;
+  self->private_impl.c_decode_frame_config[0].v_x1 = v_x1;
+  self->private_impl.c_decode_frame_config[0].v_w = v_w;
+  self->private_impl.c_decode_frame_config[0].v_y0 = v_y0;
+  self->private_impl.c_decode_frame_config[0].v_y1 = v_y1;
+  self->private_impl.c_decode_frame_config[0].v_h = v_h;
+  self->private_impl.c_decode_frame_config[0].v_transparent_index =
+      v_transparent_index;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
+                                                   wuffs_base__slice_u8 a_dst,
+                                                   wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_GIF__ERROR_BAD_RECEIVER;
   }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_GIF__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint32_t coro_susp_point =
       self->private_impl.c_decode_frame[0].coro_susp_point;
@@ -1064,10 +1733,85 @@ wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
-    if (self->private_impl.f_call_sequence < 1) {
-      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+    if (((uint64_t)(a_dst.len)) <
+        (((uint64_t)(self->private_impl.f_width)) *
+         ((uint64_t)(self->private_impl.f_height)) *
+         ((uint64_t)(self->private_impl.f_bytes_per_pixel)))) {
+      status = WUFFS_GIF__ERROR_BAD_ARGUMENT;
       goto exit;
     }
+    if (self->private_impl.f_call_sequence == 1) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_gif__decoder__decode_up_to_id(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+    } else if (self->private_impl.f_call_sequence != 2) {
+      status = WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    if (self->private_impl.f_end_of_animation) {
+      while (true) {
+        status = WUFFS_GIF__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+      }
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_gif__decoder__decode_tbid(self, a_dst, a_src);
+    if (status) {
+      goto suspend;
+    }
+    self->private_impl.f_gc_has_transparent_index = false;
+    self->private_impl.f_gc_transparent_index = 0;
+    self->private_impl.f_gc_disposal = 0;
+    self->private_impl.f_gc_delay = 0;
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+static wuffs_gif__status wuffs_gif__decoder__decode_up_to_id(
+    wuffs_gif__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_up_to_id[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
     while (true) {
       if (self->private_impl.f_peek_block_type) {
         self->private_impl.f_peek_block_type = false;
@@ -1112,7 +1856,7 @@ wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
             }
           }
         }
-        status = wuffs_gif__decoder__decode_id(self, a_dst, a_src);
+        status = wuffs_gif__decoder__decode_id(self, a_src);
         if (a_src.buf) {
           b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
         }
@@ -1122,27 +1866,24 @@ wuffs_gif__status wuffs_gif__decoder__decode_frame(wuffs_gif__decoder* self,
         status = WUFFS_GIF__STATUS_OK;
         goto ok;
       } else if (self->private_impl.f_block_type == 59) {
-        goto label_0_break;
+        self->private_impl.f_end_of_animation = true;
+        status = WUFFS_GIF__STATUS_OK;
+        goto ok;
       } else {
         status = WUFFS_GIF__ERROR_BAD_GIF_BLOCK;
         goto exit;
       }
     }
-  label_0_break:;
-    while (true) {
-      status = WUFFS_GIF__SUSPENSION_END_OF_DATA;
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(4);
-    }
 
     goto ok;
   ok:
-    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    self->private_impl.c_decode_up_to_id[0].coro_susp_point = 0;
     goto exit;
   }
 
   goto suspend;
 suspend:
-  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_up_to_id[0].coro_susp_point = coro_susp_point;
 
   goto exit;
 exit:
@@ -1159,7 +1900,6 @@ exit:
     WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
   }
 
-  self->private_impl.status = status;
   return status;
 
 short_read_src:
@@ -1198,14 +1938,15 @@ static wuffs_gif__status wuffs_gif__decoder__decode_header(
   uint32_t coro_susp_point =
       self->private_impl.c_decode_header[0].coro_susp_point;
   if (coro_susp_point) {
-    memcpy(v_c, self->private_impl.c_decode_header[0].v_c, sizeof(v_c));
+    wuffs_base__memcpy(v_c, self->private_impl.c_decode_header[0].v_c,
+                       sizeof(v_c));
     v_i = self->private_impl.c_decode_header[0].v_i;
   } else {
   }
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
-    memset(v_c, 0, sizeof(v_c));
+    wuffs_base__memset(v_c, 0, sizeof(v_c));
     v_i = 0;
     while (v_i < 6) {
       {
@@ -1233,7 +1974,8 @@ static wuffs_gif__status wuffs_gif__decoder__decode_header(
   goto suspend;
 suspend:
   self->private_impl.c_decode_header[0].coro_susp_point = coro_susp_point;
-  memcpy(self->private_impl.c_decode_header[0].v_c, v_c, sizeof(v_c));
+  wuffs_base__memcpy(self->private_impl.c_decode_header[0].v_c, v_c,
+                     sizeof(v_c));
   self->private_impl.c_decode_header[0].v_i = v_i;
 
   goto exit;
@@ -1289,7 +2031,8 @@ static wuffs_gif__status wuffs_gif__decoder__decode_lsd(
 
   uint32_t coro_susp_point = self->private_impl.c_decode_lsd[0].coro_susp_point;
   if (coro_susp_point) {
-    memcpy(v_c, self->private_impl.c_decode_lsd[0].v_c, sizeof(v_c));
+    wuffs_base__memcpy(v_c, self->private_impl.c_decode_lsd[0].v_c,
+                       sizeof(v_c));
     v_i = self->private_impl.c_decode_lsd[0].v_i;
     v_gct_size = self->private_impl.c_decode_lsd[0].v_gct_size;
   } else {
@@ -1297,7 +2040,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_lsd(
   switch (coro_susp_point) {
     WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
 
-    memset(v_c, 0, sizeof(v_c));
+    wuffs_base__memset(v_c, 0, sizeof(v_c));
     v_i = 0;
     while (v_i < 7) {
       {
@@ -1357,7 +2100,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_lsd(
   goto suspend;
 suspend:
   self->private_impl.c_decode_lsd[0].coro_susp_point = coro_susp_point;
-  memcpy(self->private_impl.c_decode_lsd[0].v_c, v_c, sizeof(v_c));
+  wuffs_base__memcpy(self->private_impl.c_decode_lsd[0].v_c, v_c, sizeof(v_c));
   self->private_impl.c_decode_lsd[0].v_i = v_i;
   self->private_impl.c_decode_lsd[0].v_gct_size = v_gct_size;
 
@@ -1428,7 +2171,6 @@ static wuffs_gif__status wuffs_gif__decoder__decode_extension(
       v_label = t_0;
     }
     if (v_label == 249) {
-    } else if (v_label == 255) {
       WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
       if (a_src.buf) {
         size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
@@ -1440,6 +2182,27 @@ static wuffs_gif__status wuffs_gif__decoder__decode_extension(
           }
         }
       }
+      status = wuffs_gif__decoder__decode_gc(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      status = WUFFS_GIF__STATUS_OK;
+      goto ok;
+    } else if (v_label == 255) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
       status = wuffs_gif__decoder__decode_ae(self, a_src);
       if (a_src.buf) {
         b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
@@ -1450,7 +2213,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_extension(
       status = WUFFS_GIF__STATUS_OK;
       goto ok;
     }
-    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
     if (a_src.buf) {
       size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
       a_src.buf->ri += n;
@@ -1600,6 +2363,155 @@ short_read_src:
   goto suspend;
 }
 
+static wuffs_gif__status wuffs_gif__decoder__decode_gc(
+    wuffs_gif__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t v_c;
+  uint8_t v_flags;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_gc[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_gc[0].v_c;
+    v_flags = self->private_impl.c_decode_gc[0].v_flags;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_0 = *b_rptr_src++;
+      v_c = t_0;
+    }
+    if (v_c != 4) {
+      status = WUFFS_GIF__ERROR_BAD_GIF_GRAPHIC_CONTROL;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_1 = *b_rptr_src++;
+      v_flags = t_1;
+    }
+    self->private_impl.f_gc_has_transparent_index = ((v_flags & 1) != 0);
+    v_flags = ((v_flags >> 2) & 7);
+    if (v_flags == 2) {
+      self->private_impl.f_gc_disposal = 1;
+    } else if (v_flags == 3) {
+      self->private_impl.f_gc_disposal = 2;
+    } else {
+      self->private_impl.f_gc_disposal = 0;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint16_t t_3;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_3 = wuffs_base__load_u16le(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_gc[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_2 = self->private_impl.c_decode_gc[0].scratch >> 56;
+          self->private_impl.c_decode_gc[0].scratch <<= 8;
+          self->private_impl.c_decode_gc[0].scratch >>= 8;
+          self->private_impl.c_decode_gc[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_2;
+          if (t_2 == 8) {
+            t_3 = self->private_impl.c_decode_gc[0].scratch;
+            break;
+          }
+          t_2 += 8;
+          self->private_impl.c_decode_gc[0].scratch |= ((uint64_t)(t_2)) << 56;
+        }
+      }
+      self->private_impl.f_gc_delay = ((uint32_t)(t_3));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_4 = *b_rptr_src++;
+      self->private_impl.f_gc_transparent_index = t_4;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_5 = *b_rptr_src++;
+      v_c = t_5;
+    }
+    if (v_c != 0) {
+      status = WUFFS_GIF__ERROR_BAD_GIF_GRAPHIC_CONTROL;
+      goto exit;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_gc[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_gc[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_gc[0].v_c = v_c;
+  self->private_impl.c_decode_gc[0].v_flags = v_flags;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_GIF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_GIF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
 static wuffs_gif__status wuffs_gif__decoder__decode_ae(
     wuffs_gif__decoder* self,
     wuffs_base__reader1 a_src) {
@@ -1827,17 +2739,12 @@ short_read_src:
 
 static wuffs_gif__status wuffs_gif__decoder__decode_id(
     wuffs_gif__decoder* self,
-    wuffs_base__writer1 a_dst,
     wuffs_base__reader1 a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
   uint8_t v_flags;
   uint32_t v_lct_size;
   uint32_t v_i;
-  uint8_t v_lw;
-  uint64_t v_block_size;
-  wuffs_base__reader1 v_r;
-  wuffs_gif__status v_z;
 
   uint8_t* b_rptr_src = NULL;
   uint8_t* b_rstart_src = NULL;
@@ -1860,10 +2767,6 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
     v_flags = self->private_impl.c_decode_id[0].v_flags;
     v_lct_size = self->private_impl.c_decode_id[0].v_lct_size;
     v_i = self->private_impl.c_decode_id[0].v_i;
-    v_lw = self->private_impl.c_decode_id[0].v_lw;
-    v_block_size = self->private_impl.c_decode_id[0].v_block_size;
-    v_r = ((wuffs_base__reader1){});
-    v_z = self->private_impl.c_decode_id[0].v_z;
   } else {
   }
   switch (coro_susp_point) {
@@ -1989,7 +2892,11 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
       uint8_t t_8 = *b_rptr_src++;
       v_flags = t_8;
     }
-    self->private_impl.f_interlace = ((v_flags & 64) != 0);
+    if ((v_flags & 64) != 0) {
+      self->private_impl.f_interlace = 4;
+    } else {
+      self->private_impl.f_interlace = 0;
+    }
     self->private_impl.f_have_lct = ((v_flags & 128) != 0);
     if (self->private_impl.f_have_lct) {
       v_lct_size = (((uint32_t)(1)) << (1 + (v_flags & 7)));
@@ -2022,13 +2929,92 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
         v_i += 1;
       }
     }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_id[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_id[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_id[0].v_flags = v_flags;
+  self->private_impl.c_decode_id[0].v_lct_size = v_lct_size;
+  self->private_impl.c_decode_id[0].v_i = v_i;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_GIF__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_GIF__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_gif__status wuffs_gif__decoder__decode_tbid(
+    wuffs_gif__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__reader1 a_src) {
+  wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
+
+  uint8_t v_lw;
+  uint64_t v_block_size;
+  wuffs_base__reader1 v_r;
+  wuffs_gif__status v_z;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_tbid[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_lw = self->private_impl.c_decode_tbid[0].v_lw;
+    v_block_size = self->private_impl.c_decode_tbid[0].v_block_size;
+    v_r = ((wuffs_base__reader1){});
+    v_z = self->private_impl.c_decode_tbid[0].v_z;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
     {
-      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
       if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
         goto short_read_src;
       }
-      uint8_t t_12 = *b_rptr_src++;
-      v_lw = t_12;
+      uint8_t t_0 = *b_rptr_src++;
+      v_lw = t_0;
     }
     if ((v_lw < 2) || (8 < v_lw)) {
       status = WUFFS_GIF__ERROR_BAD_LZW_LITERAL_WIDTH;
@@ -2036,14 +3022,16 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
     }
     wuffs_gif__lzw_decoder__set_literal_width(&self->private_impl.f_lzw,
                                               ((uint32_t)(v_lw)));
+    self->private_impl.f_dst_x = 0;
+    self->private_impl.f_dst_y = 0;
     while (true) {
       {
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
         if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
           goto short_read_src;
         }
-        uint8_t t_13 = *b_rptr_src++;
-        v_block_size = ((uint64_t)(t_13));
+        uint8_t t_1 = *b_rptr_src++;
+        v_block_size = ((uint64_t)(t_1));
       }
       if (v_block_size == 0) {
         goto label_0_break;
@@ -2052,7 +3040,7 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
         v_r = a_src;
         wuffs_base__reader1__mark(&v_r, b_rptr_src);
         {
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
           if (a_src.buf) {
             size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
             a_src.buf->ri += n;
@@ -2063,43 +3051,55 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
               }
             }
           }
-          uint64_t l_rlimit0 = v_block_size;
-          wuffs_gif__status t_14 = wuffs_gif__lzw_decoder__decode(
-              &self->private_impl.f_lzw, a_dst,
-              wuffs_base__reader1__limit(&v_r, &l_rlimit0));
+          uint64_t l_rlimit2 = v_block_size;
+          wuffs_gif__status t_2 = wuffs_gif__lzw_decoder__decode(
+              &self->private_impl.f_lzw,
+              wuffs_base__reader1__limit(&v_r, &l_rlimit2));
           if (a_src.buf) {
             b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
           }
-          v_z = t_14;
+          v_z = t_2;
         }
-        if (v_z == 0) {
-          goto label_1_break;
+        if ((v_z == 0) || (v_z == WUFFS_GIF__SUSPENSION_SHORT_WRITE)) {
+          wuffs_gif__decoder__copy_to_dst(
+              self, a_dst,
+              wuffs_base__slice_u8__subslice_j(
+                  ((wuffs_base__slice_u8){
+                      .ptr = self->private_impl.f_lzw.private_impl.f_output,
+                      .len = 8192}),
+                  self->private_impl.f_lzw.private_impl.f_output_wi));
+          if (v_z == 0) {
+            goto label_1_break;
+          }
         }
         if (v_block_size <
-            ((uint64_t)(
-                ((wuffs_base__slice_u8){
-                     .ptr = v_r.private_impl.mark,
-                     .len = v_r.private_impl.mark
-                                ? (size_t)(b_rptr_src - v_r.private_impl.mark)
-                                : 0,
-                 })
-                    .len))) {
+            ((uint64_t)(((wuffs_base__slice_u8){
+                             .ptr = v_r.private_impl.mark,
+                             .len = v_r.private_impl.mark
+                                        ? (size_t)(b_rptr_src -
+                                                   v_r.private_impl.mark)
+                                        : 0,
+                         })
+                            .len))) {
           status = WUFFS_GIF__ERROR_INTERNAL_ERROR_INCONSISTENT_LIMITED_READ;
           goto exit;
         }
-        v_block_size -= ((uint64_t)(
-            ((wuffs_base__slice_u8){
-                 .ptr = v_r.private_impl.mark,
-                 .len = v_r.private_impl.mark
-                            ? (size_t)(b_rptr_src - v_r.private_impl.mark)
-                            : 0,
-             })
-                .len));
+        v_block_size -=
+            ((uint64_t)(((wuffs_base__slice_u8){
+                             .ptr = v_r.private_impl.mark,
+                             .len = v_r.private_impl.mark
+                                        ? (size_t)(b_rptr_src -
+                                                   v_r.private_impl.mark)
+                                        : 0,
+                         })
+                            .len));
         if ((v_block_size == 0) && (v_z == WUFFS_GIF__SUSPENSION_SHORT_READ)) {
           goto label_1_break;
         }
-        status = v_z;
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(16);
+        if (v_z != WUFFS_GIF__SUSPENSION_SHORT_WRITE) {
+          status = v_z;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(4);
+        }
       }
     label_1_break:;
     }
@@ -2107,19 +3107,16 @@ static wuffs_gif__status wuffs_gif__decoder__decode_id(
 
     goto ok;
   ok:
-    self->private_impl.c_decode_id[0].coro_susp_point = 0;
+    self->private_impl.c_decode_tbid[0].coro_susp_point = 0;
     goto exit;
   }
 
   goto suspend;
 suspend:
-  self->private_impl.c_decode_id[0].coro_susp_point = coro_susp_point;
-  self->private_impl.c_decode_id[0].v_flags = v_flags;
-  self->private_impl.c_decode_id[0].v_lct_size = v_lct_size;
-  self->private_impl.c_decode_id[0].v_i = v_i;
-  self->private_impl.c_decode_id[0].v_lw = v_lw;
-  self->private_impl.c_decode_id[0].v_block_size = v_block_size;
-  self->private_impl.c_decode_id[0].v_z = v_z;
+  self->private_impl.c_decode_tbid[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_tbid[0].v_lw = v_lw;
+  self->private_impl.c_decode_tbid[0].v_block_size = v_block_size;
+  self->private_impl.c_decode_tbid[0].v_z = v_z;
 
   goto exit;
 exit:
@@ -2147,6 +3144,86 @@ short_read_src:
   goto suspend;
 }
 
+static void wuffs_gif__decoder__copy_to_dst(wuffs_gif__decoder* self,
+                                            wuffs_base__slice_u8 a_dst,
+                                            wuffs_base__slice_u8 a_src) {
+  wuffs_base__slice_u8 v_palette;
+  uint32_t v_transparent_index;
+  uint64_t v_bpp;
+  wuffs_base__slice_u8 v_s;
+  uint64_t v_n;
+  uint64_t v_y;
+  uint64_t v_x0;
+  uint64_t v_x1;
+  uint64_t v_i;
+  uint64_t v_j;
+  uint32_t v_dst_y;
+
+  v_palette =
+      ((wuffs_base__slice_u8){.ptr = self->private_impl.f_gct, .len = 768});
+  if (self->private_impl.f_have_lct) {
+    v_palette =
+        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_lct, .len = 768});
+  }
+  v_transparent_index = 256;
+  if (self->private_impl.f_gc_has_transparent_index) {
+    v_transparent_index =
+        ((uint32_t)(self->private_impl.f_gc_transparent_index));
+  }
+  v_bpp = ((uint64_t)(self->private_impl.f_bytes_per_pixel));
+  v_s = a_src;
+  while (((uint64_t)(v_s.len)) > 0) {
+    if ((self->private_impl.f_frame_width <= self->private_impl.f_dst_x) ||
+        (self->private_impl.f_frame_height <= self->private_impl.f_dst_y)) {
+      return;
+    }
+    v_n = ((uint64_t)((self->private_impl.f_frame_width -
+                       self->private_impl.f_dst_x)));
+    if (v_n > ((uint64_t)(v_s.len))) {
+      v_n = (((uint64_t)(v_s.len)) & 65535);
+    }
+    v_y = (((uint64_t)(self->private_impl.f_frame_top)) +
+           ((uint64_t)(self->private_impl.f_dst_y)));
+    v_x0 = (((uint64_t)(self->private_impl.f_frame_left)) +
+            ((uint64_t)(self->private_impl.f_dst_x)));
+    v_x1 = (v_x0 + v_n);
+    if (v_x1 > ((uint64_t)(self->private_impl.f_width))) {
+      v_x1 = ((uint64_t)(self->private_impl.f_width));
+    }
+    if ((v_y < ((uint64_t)(self->private_impl.f_height))) && (v_x1 > v_x0)) {
+      v_i = (((v_y * ((uint64_t)(self->private_impl.f_width))) + v_x0) * v_bpp);
+      v_j = (((v_y * ((uint64_t)(self->private_impl.f_width))) + v_x1) * v_bpp);
+      if ((v_i <= v_j) && (v_j <= ((uint64_t)(a_dst.len)))) {
+        wuffs_base__slice_u8__swizzle_from_palette(
+            wuffs_base__slice_u8__subslice_ij(a_dst, v_i, v_j), v_s, v_palette,
+            v_transparent_index, self->private_impl.f_pixel_format);
+      }
+    }
+    if (v_n > ((uint64_t)(v_s.len))) {
+      return;
+    }
+    v_s = wuffs_base__slice_u8__subslice_i(v_s, v_n);
+    self->private_impl.f_dst_x = ((
+        uint32_t)(((((uint64_t)(self->private_impl.f_dst_x)) + v_n) & 65535)));
+    if (self->private_impl.f_dst_x >= self->private_impl.f_frame_width) {
+      self->private_impl.f_dst_x = 0;
+      v_dst_y = (self->private_impl.f_dst_y +
+                 ((uint32_t)(wuffs_gif__interlace_delta[self->private_impl
+                                                            .f_interlace])));
+      while ((self->private_impl.f_interlace > 0) &&
+             (v_dst_y >= self->private_impl.f_frame_height)) {
+        self->private_impl.f_interlace -= 1;
+        v_dst_y = wuffs_gif__interlace_start[self->private_impl.f_interlace];
+      }
+      if (v_dst_y < self->private_impl.f_frame_height) {
+        self->private_impl.f_dst_y = (v_dst_y & 65535);
+      } else {
+        self->private_impl.f_dst_y = self->private_impl.f_frame_height;
+      }
+    }
+  }
+}
+
 static void wuffs_gif__lzw_decoder__set_literal_width(
     wuffs_gif__lzw_decoder* self,
     uint32_t a_lw) {
@@ -2155,7 +3232,6 @@ static void wuffs_gif__lzw_decoder__set_literal_width(
 
 static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
     wuffs_gif__lzw_decoder* self,
-    wuffs_base__writer1 a_dst,
     wuffs_base__reader1 a_src) {
   wuffs_gif__status status = WUFFS_GIF__STATUS_OK;
 
@@ -2164,32 +3240,13 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
   uint32_t v_save_code;
   uint32_t v_prev_code;
   uint32_t v_width;
+  uint32_t v_output_wi;
   uint32_t v_bits;
   uint32_t v_n_bits;
   uint32_t v_code;
   uint32_t v_s;
   uint32_t v_c;
-  wuffs_base__slice_u8 v_expansion;
-  uint64_t v_n_copied;
-
-  uint8_t* b_wptr_dst = NULL;
-  uint8_t* b_wstart_dst = NULL;
-  uint8_t* b_wend_dst = NULL;
-  if (a_dst.buf) {
-    b_wptr_dst = a_dst.buf->ptr + a_dst.buf->wi;
-    b_wstart_dst = b_wptr_dst;
-    b_wend_dst = b_wptr_dst;
-    if (!a_dst.buf->closed) {
-      uint64_t len = a_dst.buf->len - a_dst.buf->wi;
-      wuffs_base__limit1* lim;
-      for (lim = &a_dst.private_impl.limit; lim; lim = lim->next) {
-        if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
-          len = *lim->ptr_to_len;
-        }
-      }
-      b_wend_dst += len;
-    }
-  }
+
   uint8_t* b_rptr_src = NULL;
   uint8_t* b_rstart_src = NULL;
   uint8_t* b_rend_src = NULL;
@@ -2213,13 +3270,12 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
     v_save_code = self->private_impl.c_decode[0].v_save_code;
     v_prev_code = self->private_impl.c_decode[0].v_prev_code;
     v_width = self->private_impl.c_decode[0].v_width;
+    v_output_wi = self->private_impl.c_decode[0].v_output_wi;
     v_bits = self->private_impl.c_decode[0].v_bits;
     v_n_bits = self->private_impl.c_decode[0].v_n_bits;
     v_code = self->private_impl.c_decode[0].v_code;
     v_s = self->private_impl.c_decode[0].v_s;
     v_c = self->private_impl.c_decode[0].v_c;
-    v_expansion = ((wuffs_base__slice_u8){});
-    v_n_copied = self->private_impl.c_decode[0].v_n_copied;
   } else {
   }
   switch (coro_susp_point) {
@@ -2230,6 +3286,7 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
     v_save_code = v_end_code;
     v_prev_code = 0;
     v_width = (self->private_impl.f_literal_width + 1);
+    v_output_wi = 0;
     v_bits = 0;
     v_n_bits = 0;
   label_0_continue:;
@@ -2248,13 +3305,15 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
       v_code = ((v_bits) & ((1 << (v_width)) - 1));
       v_bits >>= v_width;
       v_n_bits -= v_width;
+      while (v_output_wi > 4095) {
+        self->private_impl.f_output_wi = v_output_wi;
+        status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(2);
+        v_output_wi = 0;
+      }
       if (v_code < v_clear_code) {
-        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
-        if (b_wptr_dst == b_wend_dst) {
-          status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
-          goto suspend;
-        }
-        *b_wptr_dst++ = ((uint8_t)(v_code));
+        self->private_impl.f_output[v_output_wi] = ((uint8_t)(v_code));
+        v_output_wi += 1;
         if (v_save_code <= 4095) {
           self->private_impl.f_suffixes[v_save_code] = ((uint8_t)(v_code));
           self->private_impl.f_prefixes[v_save_code] =
@@ -2266,6 +3325,7 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
         v_width = (self->private_impl.f_literal_width + 1);
         goto label_0_continue;
       } else if (v_code == v_end_code) {
+        self->private_impl.f_output_wi = v_output_wi;
         status = WUFFS_GIF__STATUS_OK;
         goto ok;
       } else if (v_code <= v_save_code) {
@@ -2288,21 +3348,16 @@ static wuffs_gif__status wuffs_gif__lzw_decoder__decode(
         if (v_code == v_save_code) {
           self->private_impl.f_stack[4095] = ((uint8_t)(v_c));
         }
-        while (true) {
-          v_expansion = wuffs_base__slice_u8__subslice_i(
-              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_stack,
-                                      .len = 4096}),
-              v_s);
-          v_n_copied = wuffs_base__writer1__copy_from_slice(
-              &b_wptr_dst, b_wend_dst, v_expansion);
-          if (v_n_copied == ((uint64_t)(v_expansion.len))) {
-            goto label_1_break;
-          }
-          v_s = ((v_s + ((uint32_t)((v_n_copied & 4095)))) & 4095);
-          status = WUFFS_GIF__SUSPENSION_SHORT_WRITE;
-          WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(3);
-        }
-      label_1_break:;
+        wuffs_base__slice_u8__copy_from_slice(
+            wuffs_base__slice_u8__subslice_i(
+                ((wuffs_base__slice_u8){.ptr = self->private_impl.f_output,
+                                        .len = 8192}),
+                v_output_wi),
+            wuffs_base__slice_u8__subslice_i(
+                ((wuffs_base__slice_u8){.ptr = self->private_impl.f_stack,
+                                        .len = 4096}),
+                v_s));
+        v_output_wi += (4096 - v_s);
         if (v_save_code <= 4095) {
           self->private_impl.f_suffixes[v_save_code] = ((uint8_t)(v_c));
           self->private_impl.f_prefixes[v_save_code] =
@@ -2335,27 +3390,15 @@ suspend:
   self->private_impl.c_decode[0].v_save_code = v_save_code;
   self->private_impl.c_decode[0].v_prev_code = v_prev_code;
   self->private_impl.c_decode[0].v_width = v_width;
+  self->private_impl.c_decode[0].v_output_wi = v_output_wi;
   self->private_impl.c_decode[0].v_bits = v_bits;
   self->private_impl.c_decode[0].v_n_bits = v_n_bits;
   self->private_impl.c_decode[0].v_code = v_code;
   self->private_impl.c_decode[0].v_s = v_s;
   self->private_impl.c_decode[0].v_c = v_c;
-  self->private_impl.c_decode[0].v_n_copied = v_n_copied;
 
   goto exit;
 exit:
-  if (a_dst.buf) {
-    size_t n = b_wptr_dst - (a_dst.buf->ptr + a_dst.buf->wi);
-    a_dst.buf->wi += n;
-    wuffs_base__limit1* lim;
-    for (lim = &a_dst.private_impl.limit; lim; lim = lim->next) {
-      if (lim->ptr_to_len) {
-        *lim->ptr_to_len -= n;
-      }
-    }
-    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_wstart_dst);
-    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_wend_dst);
-  }
   if (a_src.buf) {
     size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
     a_src.buf->ri += n;
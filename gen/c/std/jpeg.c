@@ -0,0 +1,4801 @@
+#ifndef WUFFS_JPEG_H
+#define WUFFS_JPEG_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+#ifndef WUFFS_BASE_HEADER_H
+#define WUFFS_BASE_HEADER_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
+#include <string.h>
+#endif
+
+// Wuffs requires a word size of at least 32 bits because it assumes that
+// converting a u32 to usize will never overflow. For example, the size of a
+// decoded image is often represented, explicitly or implicitly in an image
+// file, as a u32, and it is convenient to compare that to a buffer size.
+//
+// Similarly, the word size is at most 64 bits because it assumes that
+// converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
+#if __WORDSIZE < 32
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __WORDSIZE > 64
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
+
+// WUFFS_VERSION is the major.minor version number as a uint32. The major
+// number is the high 16 bits. The minor number is the low 16 bits.
+//
+// The intention is to bump the version number at least on every API / ABI
+// backwards incompatible change.
+//
+// For now, the API and ABI are simply unstable and can change at any time.
+//
+// TODO: don't hard code this in base-header.h.
+#define WUFFS_VERSION (0x00001)
+
+// ---------------- I/O
+
+// wuffs_base__slice_u8 is a 1-dimensional buffer (a pointer and length).
+//
+// A value with all fields NULL or zero is a valid, empty slice.
+typedef struct {
+  uint8_t* ptr;
+  size_t len;
+} wuffs_base__slice_u8;
+
+// wuffs_base__buf1 is a 1-dimensional buffer (a pointer and length), plus
+// additional indexes into that buffer, plus an opened / closed flag.
+//
+// A value with all fields NULL or zero is a valid, empty buffer.
+typedef struct {
+  uint8_t* ptr;  // Pointer.
+  size_t len;    // Length.
+  size_t wi;     // Write index. Invariant: wi <= len.
+  size_t ri;     // Read  index. Invariant: ri <= wi.
+  bool closed;   // No further writes are expected.
+} wuffs_base__buf1;
+
+// wuffs_base__limit1 provides a limited view of a 1-dimensional byte stream:
+// its first N bytes. That N can be greater than a buffer's current read or
+// write capacity. N decreases naturally over time as bytes are read from or
+// written to the stream.
+//
+// A value with all fields NULL or zero is a valid, unlimited view.
+typedef struct wuffs_base__limit1 {
+  uint64_t* ptr_to_len;             // Pointer to N.
+  struct wuffs_base__limit1* next;  // Linked list of limits.
+} wuffs_base__limit1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__reader1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__writer1;
+
+// ---------------- Images
+
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t w;
+    uint32_t h;
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
+  } private_impl;
+} wuffs_base__image_config;
+
+static inline void wuffs_base__image_config__invalidate(
+    wuffs_base__image_config* c) {
+  if (c) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline bool wuffs_base__image_config__valid(
+    wuffs_base__image_config* c) {
+  if (!c || !(c->private_impl.flags & 1)) {
+    return false;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
+  uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
+}
+
+static inline uint32_t wuffs_base__image_config__width(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__height(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
+static inline void wuffs_base__image_config__initialize(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
+}
+
+#endif  // WUFFS_BASE_HEADER_H
+
+  // ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_jpeg__status__is_error instead.
+typedef int32_t wuffs_jpeg__status;
+
+#define wuffs_jpeg__packageid 1191926  // 0x00122FF6
+
+#define WUFFS_JPEG__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_JPEG__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_JPEG__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_JPEG__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_JPEG__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_JPEG__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_JPEG__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_JPEG__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_JPEG__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_JPEG__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_JPEG__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_JPEG__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+#define WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER -926951424         // 0xC8BFD800
+#define WUFFS_JPEG__ERROR_BAD_JPEG_DQT_MARKER -926951423         // 0xC8BFD801
+#define WUFFS_JPEG__ERROR_BAD_JPEG_DRI_MARKER -926951422         // 0xC8BFD802
+#define WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE -926951421       // 0xC8BFD803
+#define WUFFS_JPEG__ERROR_BAD_JPEG_RST_MARKER -926951420         // 0xC8BFD804
+#define WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER -926951419         // 0xC8BFD805
+#define WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER -926951418         // 0xC8BFD806
+#define WUFFS_JPEG__ERROR_BAD_JPEG_COEFFICIENT -926951417        // 0xC8BFD807
+#define WUFFS_JPEG__ERROR_BAD_JPEG_HEADER -926951416             // 0xC8BFD808
+#define WUFFS_JPEG__ERROR_BAD_JPEG_MARKER -926951415             // 0xC8BFD809
+#define WUFFS_JPEG__ERROR_MISSING_JPEG_HUFFMAN_TABLE -926951414  // 0xC8BFD80A
+#define WUFFS_JPEG__ERROR_MISSING_JPEG_QUANTIZATION_TABLE \
+  -926951413                                                      // 0xC8BFD80B
+#define WUFFS_JPEG__ERROR_NOT_ENOUGH_JPEG_IMAGE_DATA -926951412   // 0xC8BFD80C
+#define WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_SOF_MARKER -926951411  // 0xC8BFD80D
+#define WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_COMPONENT_COUNT \
+  -926951410                                                     // 0xC8BFD80E
+#define WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_PRECISION -926951409  // 0xC8BFD80F
+
+bool wuffs_jpeg__status__is_error(wuffs_jpeg__status s);
+
+const char* wuffs_jpeg__status__string(wuffs_jpeg__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_jpeg__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_jpeg__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint8_t f_call_sequence;
+    bool f_progressive;
+    uint32_t f_num_components;
+    uint8_t f_comp_id[3];
+    uint32_t f_comp_h[3];
+    uint32_t f_comp_v[3];
+    uint32_t f_comp_q[3];
+    uint32_t f_max_h;
+    uint32_t f_max_v;
+    uint32_t f_mcus_x;
+    uint32_t f_mcus_y;
+    uint32_t f_blocks_w[3];
+    uint32_t f_blocks_h[3];
+    uint32_t f_plane_w[3];
+    uint32_t f_plane_h[3];
+    uint64_t f_plane_offset[4];
+    uint64_t f_workbuf_offset[4];
+    uint16_t f_quant[4][64];
+    bool f_seen_quant[4];
+    bool f_seen_huff[8];
+    uint32_t f_huff_counts[16];
+    uint8_t f_huff_values[8][256];
+    uint32_t f_huff_min[8][17];
+    uint32_t f_huff_end[8][17];
+    uint32_t f_huff_valptr[8][17];
+    uint16_t f_huff_lut[8][256];
+    bool f_bad_huffman;
+    uint32_t f_restart_interval;
+    uint32_t f_scan_num_components;
+    uint32_t f_scan_comp[3];
+    uint32_t f_scan_td[3];
+    uint32_t f_scan_ta[3];
+    uint32_t f_ss;
+    uint32_t f_se;
+    uint32_t f_ah;
+    uint32_t f_al;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint8_t f_marker;
+    int32_t f_dc_pred[3];
+    uint32_t f_eob_run;
+    uint32_t f_restarts_to_go;
+    uint32_t f_next_restart;
+    uint32_t f_symbol;
+    uint32_t f_taken;
+    int32_t f_value;
+    int32_t f_block[64];
+    int32_t f_coefficients[64];
+    int64_t f_ws[64];
+    uint8_t f_pixels[64];
+    uint8_t f_wbuf[128];
+
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_m;
+      uint32_t v_sampling;
+      uint32_t v_pixel_format;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_frame_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_m;
+      bool v_seen_sos;
+      uint64_t v_i;
+      uint64_t v_n;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_read_marker[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_n;
+      uint64_t scratch;
+    } c_decode_other_marker[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint8_t v_c;
+      uint32_t v_h;
+      uint32_t v_v;
+      uint32_t v_q;
+      uint32_t v_n;
+      uint32_t v_height;
+      uint32_t v_width;
+      uint64_t scratch;
+    } c_decode_sof[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+      uint32_t v_t;
+      bool v_wide;
+      uint32_t v_i;
+      uint32_t v_x;
+      uint32_t v_n;
+      uint64_t scratch;
+    } c_decode_dqt[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t scratch;
+    } c_decode_dri[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+      uint32_t v_t;
+      uint32_t v_i;
+      uint32_t v_total;
+      uint32_t v_num_codes;
+      uint32_t v_n;
+      uint64_t scratch;
+    } c_decode_dht[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+      uint32_t v_i;
+      uint32_t v_k;
+      uint32_t v_x;
+      uint32_t v_n;
+      uint32_t v_ss;
+      uint32_t v_se;
+      uint32_t v_ah;
+      uint32_t v_al;
+      uint64_t scratch;
+    } c_decode_sos[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_c;
+      uint32_t v_n_x;
+      uint32_t v_n_y;
+      uint32_t v_mx;
+      uint32_t v_my;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint32_t v_i;
+      uint32_t v_si;
+      uint32_t v_sv;
+      uint32_t v_h;
+      uint32_t v_v;
+      uint32_t v_hc;
+      uint32_t v_vc;
+    } c_decode_scan[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_restart[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_c;
+      uint64_t v_o;
+    } c_decode_block[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_k;
+      uint32_t v_r;
+      uint32_t v_s;
+    } c_decode_block_sequential[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_dc_first[1];
+    struct {
+      uint32_t coro_susp_point;
+      int32_t v_v;
+      int32_t v_p1;
+    } c_decode_dc_refine[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_k;
+      uint32_t v_r;
+      uint32_t v_s;
+    } c_decode_ac_first[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_k;
+      uint32_t v_r;
+      uint32_t v_s;
+      uint32_t v_z;
+      int32_t v_p1;
+      int32_t v_nv;
+    } c_decode_ac_refine[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_bits[1];
+  } private_impl;
+} wuffs_jpeg__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_jpeg__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_jpeg__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_jpeg__decoder__initialize(wuffs_jpeg__decoder* self,
+                                     uint32_t wuffs_version,
+                                     uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_config(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_frame_config(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+uint64_t wuffs_jpeg__decoder__workbuf_size(wuffs_jpeg__decoder* self);
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_frame(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_JPEG_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// wuffs_base__empty_struct is used when a Wuffs function returns an empty
+// struct. In C, if a function f returns void, you can't say "x = f()", but in
+// Wuffs, if a function g returns empty, you can say "y = g()".
+typedef struct {
+} wuffs_base__empty_struct;
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
+// It's not foolproof, given C doesn't automatically zero memory before use,
+// but it should catch 99.99% of cases.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("wuffs").
+#define WUFFS_BASE__MAGIC (0x3CCB6C71U)
+
+// WUFFS_BASE__ALREADY_ZEROED is passed from a container struct's initializer
+// to a containee struct's initializer when the container has already zeroed
+// the containee's memory.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("zeroed").
+#define WUFFS_BASE__ALREADY_ZEROED (0x68602EF1U)
+
+// Denote intentional fallthroughs for -Wimplicit-fallthrough.
+//
+// The order matters here. Clang also defines "__GNUC__".
+#if defined(__clang__) && __cplusplus >= 201103L
+#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]
+#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)
+#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))
+#else
+#define WUFFS_BASE__FALLTHROUGH
+#endif
+
+// Use switch cases for coroutine suspension points, similar to the technique
+// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html
+//
+// We use trivial macros instead of an explicit assignment and case statement
+// so that clang-format doesn't get confused by the unusual "case"s.
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \
+  coro_susp_point = n;                            \
+  WUFFS_BASE__FALLTHROUGH;                        \
+  case n:;
+
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \
+  if (status < 0) {                                             \
+    goto exit;                                                  \
+  } else if (status == 0) {                                     \
+    goto ok;                                                    \
+  }                                                             \
+  coro_susp_point = n;                                          \
+  goto suspend;                                                 \
+  case n:;
+
+// Clang also defines "__GNUC__".
+#if defined(__GNUC__)
+#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))
+#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))
+#else
+#define WUFFS_BASE__LIKELY(expr) (expr)
+#define WUFFS_BASE__UNLIKELY(expr) (expr)
+#endif
+
+// Uncomment this #include for printf-debugging.
+// #include <stdio.h>
+
+// ---------------- Static Inline Functions
+//
+// The helpers below are functions, instead of macros, because their arguments
+// can be an expression that we shouldn't evaluate more than once.
+//
+// They are in base-impl.h and hence copy/pasted into every generated C file,
+// instead of being in some "base.c" file, since a design goal is that users of
+// the generated C code can often just #include a single .c file, such as
+// "gif.c", without having to additionally include or otherwise build and link
+// a "base.c" file.
+//
+// They are static, so that linking multiple wuffs .o files won't complain about
+// duplicate function definitions.
+//
+// They are explicitly marked inline, even if modern compilers don't use the
+// inline attribute to guide optimizations such as inlining, to avoid the
+// -Wunused-function warning, and we like to compile with -Wall -Werror.
+
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
+static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
+}
+
+static inline uint16_t wuffs_base__load_u16le(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);
+}
+
+static inline uint32_t wuffs_base__load_u32be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |
+         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u32le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(
+    wuffs_base__slice_u8 s,
+    uint64_t i) {
+  if ((i <= SIZE_MAX) && (i <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = s.len - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(
+    wuffs_base__slice_u8 s,
+    uint64_t j) {
+  if ((j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(
+    wuffs_base__slice_u8 s,
+    uint64_t i,
+    uint64_t j) {
+  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = j - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.ptr += (uint64_t)(s.len) - up_to;
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
+//
+// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
+// slice) is valid and results in a no-op.
+static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src) {
+  size_t length = dst.len < src.len ? dst.len : src.len;
+  if (length > 0) {
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
+  }
+  return length;
+}
+
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x =
+            (uint16_t)(((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                       ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_history32(
+    uint8_t** ptr_ptr,
+    uint8_t* start,  // May be NULL, meaning an unmarked writer1.
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  if (!start || !distance) {
+    return 0;
+  }
+  uint8_t* ptr = *ptr_ptr;
+  if ((size_t)(ptr - start) < (size_t)(distance)) {
+    return 0;
+  }
+  start = ptr - distance;
+  size_t n = end - ptr;
+  if ((size_t)(length) > n) {
+    length = n;
+  } else {
+    n = length;
+  }
+  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that
+  // is mostly because 3 is the minimum length for the deflate format. This
+  // function implementation shouldn't overfit to that one format. Perhaps the
+  // copy_from_history32 Wuffs method should also take an unroll hint argument,
+  // and the cgen can look if that argument is the constant expression '3'.
+  //
+  // See also wuffs_base__writer1__copy_from_history32__bco below.
+  //
+  // Alternatively, or additionally, have a sloppy_copy_from_history32 method
+  // that copies 8 bytes at a time, possibly writing more than length bytes?
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+// wuffs_base__writer1__copy_from_history32__bco is a Bounds Check Optimized
+// version of the wuffs_base__writer1__copy_from_history32 function above. The
+// caller needs to prove that:
+//  - start    != NULL
+//  - distance >  0
+//  - distance <= (*ptr_ptr - start)
+//  - length   <= (end      - *ptr_ptr)
+static inline uint32_t wuffs_base__writer1__copy_from_history32__bco(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  uint8_t* ptr = *ptr_ptr;
+  start = ptr - distance;
+  uint32_t n = length;
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_reader32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    uint8_t** ptr_rptr,
+    uint8_t* rend,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = length;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  uint8_t* rptr = *ptr_rptr;
+  if (n > rend - rptr) {
+    n = rend - rptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, rptr, n);
+    *ptr_wptr += n;
+    *ptr_rptr += n;
+  }
+  return n;
+}
+
+static inline uint64_t wuffs_base__writer1__copy_from_slice(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_slice32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > length) {
+    n = length;
+  }
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+// Note that the *__limit and *__mark methods are private (in base-impl.h) not
+// public (in base-header.h). We assume that, at the boundary between user code
+// and Wuffs code, the reader1 and writer1's private_impl fields (including
+// limit and mark) are NULL. Otherwise, some internal assumptions break down.
+// For example, limits could be represented as pointers, even though
+// conceptually they are counts, but that pointer-to-count correspondence
+// becomes invalid if a buffer is re-used (e.g. on resuming a coroutine).
+//
+// Admittedly, some of the Wuffs test code calls these methods, but that test
+// code is still Wuffs code, not user code. Other Wuffs test code modifies
+// private_impl fields directly.
+
+static inline wuffs_base__reader1 wuffs_base__reader1__limit(
+    wuffs_base__reader1* o,
+    uint64_t* ptr_to_len) {
+  wuffs_base__reader1 ret = *o;
+  ret.private_impl.limit.ptr_to_len = ptr_to_len;
+  ret.private_impl.limit.next = &o->private_impl.limit;
+  return ret;
+}
+
+static inline wuffs_base__empty_struct wuffs_base__reader1__mark(
+    wuffs_base__reader1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+// TODO: static inline wuffs_base__writer1 wuffs_base__writer1__limit()
+
+static inline wuffs_base__empty_struct wuffs_base__writer1__mark(
+    wuffs_base__writer1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+static const char* wuffs_base__status__strings[14] = {
+    "ok",
+    "bad wuffs version",
+    "bad receiver",
+    "bad argument",
+    "initializer not called",
+    "invalid I/O operation",
+    "closed for writes",
+    "unexpected EOF",
+    "short read",
+    "short write",
+    "cannot return a suspension",
+    "invalid call sequence",
+    "end of data",
+    "end of animation",
+};
+
+#endif  // WUFFS_BASE_IMPL_H
+
+// ---------------- Status Codes Implementations
+
+bool wuffs_jpeg__status__is_error(wuffs_jpeg__status s) {
+  return s < 0;
+}
+
+const char* wuffs_jpeg__status__strings[16] = {
+    "jpeg: bad JPEG DHT marker",
+    "jpeg: bad JPEG DQT marker",
+    "jpeg: bad JPEG DRI marker",
+    "jpeg: bad JPEG Huffman code",
+    "jpeg: bad JPEG RST marker",
+    "jpeg: bad JPEG SOF marker",
+    "jpeg: bad JPEG SOS marker",
+    "jpeg: bad JPEG coefficient",
+    "jpeg: bad JPEG header",
+    "jpeg: bad JPEG marker",
+    "jpeg: missing JPEG Huffman table",
+    "jpeg: missing JPEG quantization table",
+    "jpeg: not enough JPEG image data",
+    "jpeg: unsupported JPEG SOF marker",
+    "jpeg: unsupported JPEG component count",
+    "jpeg: unsupported JPEG precision",
+};
+
+const char* wuffs_jpeg__status__string(wuffs_jpeg__status s) {
+  const char** a = NULL;
+  uint32_t n = 0;
+  switch ((s >> 10) & 0x1FFFFF) {
+    case 0:
+      a = wuffs_base__status__strings;
+      n = 14;
+      break;
+    case wuffs_jpeg__packageid:
+      a = wuffs_jpeg__status__strings;
+      n = 16;
+      break;
+  }
+  uint32_t i = s & 0xFF;
+  return i < n ? a[i] : "unknown status";
+}
+
+// ---------------- Private Consts
+
+static const uint8_t wuffs_jpeg__zigzag[64] = {
+    0,  1,  8,  16, 9,  2,  3,  10, 17, 24, 32, 25, 18, 11, 4,  5,
+    12, 19, 26, 33, 40, 48, 41, 34, 27, 20, 13, 6,  7,  14, 21, 28,
+    35, 42, 49, 56, 57, 50, 43, 36, 29, 22, 15, 23, 30, 37, 44, 51,
+    58, 59, 52, 45, 38, 31, 39, 46, 53, 60, 61, 54, 47, 55, 62, 63,
+};
+
+// ---------------- Private Initializer Prototypes
+
+// ---------------- Private Function Prototypes
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__read_marker(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_other_marker(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint8_t a_marker);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_sof(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_jpeg__decoder__compute_dimensions(wuffs_jpeg__decoder* self);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dqt(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dri(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dht(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_jpeg__decoder__build_huffman(wuffs_jpeg__decoder* self,
+                                               uint32_t a_t);
+
+static void wuffs_jpeg__decoder__fill_lut(wuffs_jpeg__decoder* self,
+                                          uint32_t a_t,
+                                          uint32_t a_p,
+                                          uint32_t a_n,
+                                          uint16_t a_x);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_sos(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_scan(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_restart(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_block(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint32_t a_i,
+    uint32_t a_bx,
+    uint32_t a_by);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_block_sequential(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_c,
+    uint32_t a_td,
+    uint32_t a_ta);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dc_first(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_c,
+    uint32_t a_td);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dc_refine(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_ac_first(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_ta);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_ac_refine(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_ta);
+
+static void wuffs_jpeg__decoder__refine(wuffs_jpeg__decoder* self,
+                                        uint32_t a_i);
+
+static void wuffs_jpeg__decoder__set_coefficient(wuffs_jpeg__decoder* self,
+                                                 uint32_t a_i,
+                                                 int32_t a_v);
+
+static void wuffs_jpeg__decoder__set_dc_pred(wuffs_jpeg__decoder* self,
+                                             uint32_t a_c,
+                                             int32_t a_v);
+
+static void wuffs_jpeg__decoder__load_block(wuffs_jpeg__decoder* self,
+                                            wuffs_base__slice_u8 a_workbuf,
+                                            uint64_t a_offset);
+
+static void wuffs_jpeg__decoder__store_block(wuffs_jpeg__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset);
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__fill_bits(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_jpeg__decoder__decode_huffman(wuffs_jpeg__decoder* self,
+                                                uint32_t a_t);
+
+static void wuffs_jpeg__decoder__take_bits(wuffs_jpeg__decoder* self,
+                                           uint32_t a_n);
+
+static void wuffs_jpeg__decoder__receive_extend(wuffs_jpeg__decoder* self,
+                                                uint32_t a_s);
+
+static void wuffs_jpeg__decoder__render_progressive(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_jpeg__decoder__idct(wuffs_jpeg__decoder* self,
+                                      wuffs_base__slice_u8 a_dst,
+                                      uint32_t a_c,
+                                      uint32_t a_bx,
+                                      uint32_t a_by);
+
+static void wuffs_jpeg__decoder__idct_column(wuffs_jpeg__decoder* self,
+                                             uint32_t a_x);
+
+static void wuffs_jpeg__decoder__idct_row(wuffs_jpeg__decoder* self,
+                                          uint32_t a_y);
+
+static void wuffs_jpeg__decoder__put_pixel(wuffs_jpeg__decoder* self,
+                                           uint32_t a_i,
+                                           int64_t a_v);
+
+// ---------------- Initializer Implementations
+
+void wuffs_jpeg__decoder__initialize(wuffs_jpeg__decoder* self,
+                                     uint32_t wuffs_version,
+                                     uint32_t for_internal_use_only) {
+  if (!self) {
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
+    wuffs_base__memset(self, 0, sizeof(*self));
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  {
+    size_t i0;
+    for (i0 = 0; i0 < 3; i0++) {
+      self->private_impl.f_comp_h[i0] = 1;
+    }
+  }
+  {
+    size_t i0;
+    for (i0 = 0; i0 < 3; i0++) {
+      self->private_impl.f_comp_v[i0] = 1;
+    }
+  }
+  self->private_impl.f_max_h = 1;
+  self->private_impl.f_max_v = 1;
+}
+
+// ---------------- Function Implementations
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_config(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_JPEG__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+    return WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_m;
+  uint32_t v_sampling;
+  uint32_t v_pixel_format;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_m = self->private_impl.c_decode_config[0].v_m;
+    v_sampling = self->private_impl.c_decode_config[0].v_sampling;
+    v_pixel_format = self->private_impl.c_decode_config[0].v_pixel_format;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_m = 0;
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    uint16_t t_1;
+    if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+      t_1 = wuffs_base__load_u16be(b_rptr_src);
+      b_rptr_src += 2;
+    } else {
+      self->private_impl.c_decode_config[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint32_t t_0 = self->private_impl.c_decode_config[0].scratch & 0xFF;
+        self->private_impl.c_decode_config[0].scratch >>= 8;
+        self->private_impl.c_decode_config[0].scratch <<= 8;
+        self->private_impl.c_decode_config[0].scratch |=
+            ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+        if (t_0 == 8) {
+          t_1 = self->private_impl.c_decode_config[0].scratch >> (64 - 16);
+          break;
+        }
+        t_0 += 8;
+        self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_0));
+      }
+    }
+    if (t_1 != 65496) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_HEADER;
+      goto exit;
+    }
+    while (true) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_jpeg__decoder__read_marker(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+      v_m = self->private_impl.f_marker;
+      self->private_impl.f_marker = 0;
+      if ((v_m == 192) || (v_m == 193) || (v_m == 194)) {
+        self->private_impl.f_progressive = (v_m == 194);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        if (a_src.buf) {
+          size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+          a_src.buf->ri += n;
+          wuffs_base__limit1* lim;
+          for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+            if (lim->ptr_to_len) {
+              *lim->ptr_to_len -= n;
+            }
+          }
+        }
+        status = wuffs_jpeg__decoder__decode_sof(self, a_src);
+        if (a_src.buf) {
+          b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+        }
+        if (status) {
+          goto suspend;
+        }
+        goto label_0_break;
+      } else if ((195 <= v_m) && (v_m <= 207) && (v_m != 196) && (v_m != 200) &&
+                 (v_m != 204)) {
+        status = WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_jpeg__decoder__decode_other_marker(self, a_src, v_m);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    }
+  label_0_break:;
+    v_sampling = 0;
+    v_pixel_format = 4;
+    if (self->private_impl.f_num_components == 3) {
+      v_pixel_format = 5;
+      v_sampling = ((((self->private_impl.f_comp_h[0] << 4) |
+                      self->private_impl.f_comp_v[0])
+                     << 0) |
+                    (((self->private_impl.f_comp_h[1] << 4) |
+                      self->private_impl.f_comp_v[1])
+                     << 8) |
+                    (((self->private_impl.f_comp_h[2] << 4) |
+                      self->private_impl.f_comp_v[2])
+                     << 16));
+    } else {
+      v_sampling = 17;
+    }
+    wuffs_base__image_config__initialize_planar(
+        a_dst, self->private_impl.f_width, self->private_impl.f_height,
+        v_pixel_format, v_sampling);
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_config[0].v_m = v_m;
+  self->private_impl.c_decode_config[0].v_sampling = v_sampling;
+  self->private_impl.c_decode_config[0].v_pixel_format = v_pixel_format;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_frame_config(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_JPEG__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+    return WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame_config[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+      }
+    } else if (self->private_impl.f_call_sequence != 1) {
+      status = WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    wuffs_base__frame_config__initialize(
+        a_dst, 0, 0, self->private_impl.f_width, self->private_impl.f_height, 0,
+        0, 256,
+        wuffs_base__slice_u8__subslice_j(
+            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_pixels,
+                                    .len = 64}),
+            0));
+    self->private_impl.f_call_sequence = 2;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame_config[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+uint64_t wuffs_jpeg__decoder__workbuf_size(wuffs_jpeg__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  if (self->private_impl.f_progressive) {
+    return self->private_impl
+        .f_workbuf_offset[self->private_impl.f_num_components];
+  }
+  return 0;
+}
+
+wuffs_jpeg__status wuffs_jpeg__decoder__decode_frame(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_JPEG__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_JPEG__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_m;
+  bool v_seen_sos;
+  uint64_t v_i;
+  uint64_t v_n;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_m = self->private_impl.c_decode_frame[0].v_m;
+    v_seen_sos = self->private_impl.c_decode_frame[0].v_seen_sos;
+    v_i = self->private_impl.c_decode_frame[0].v_i;
+    v_n = self->private_impl.c_decode_frame[0].v_n;
+  } else {
+    v_seen_sos = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_m = 0;
+    v_seen_sos = 0;
+    v_i = 0;
+    v_n = 0;
+    if (((uint64_t)(a_dst.len)) <
+        self->private_impl
+            .f_plane_offset[self->private_impl.f_num_components]) {
+      status = WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+      goto exit;
+    }
+    if (self->private_impl.f_progressive &&
+        (((uint64_t)(a_workbuf.len)) <
+         self->private_impl
+             .f_workbuf_offset[self->private_impl.f_num_components])) {
+      status = WUFFS_JPEG__ERROR_BAD_ARGUMENT;
+      goto exit;
+    }
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+      }
+    } else if ((self->private_impl.f_call_sequence != 1) &&
+               (self->private_impl.f_call_sequence != 2)) {
+      status = WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    if (self->private_impl.f_progressive) {
+      v_n = self->private_impl
+                .f_workbuf_offset[self->private_impl.f_num_components];
+      while ((v_i < v_n) && (v_i < ((uint64_t)(a_workbuf.len)))) {
+        a_workbuf.ptr[v_i] = 0;
+        v_i += 1;
+      }
+    }
+    while (true) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_jpeg__decoder__read_marker(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      v_m = self->private_impl.f_marker;
+      self->private_impl.f_marker = 0;
+      if (v_m == 217) {
+        goto label_0_break;
+      } else if (v_m == 218) {
+        v_seen_sos = true;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_jpeg__decoder__decode_sos(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status =
+            wuffs_jpeg__decoder__decode_scan(self, a_dst, a_workbuf, a_src);
+        if (status) {
+          goto suspend;
+        }
+      } else if ((192 <= v_m) && (v_m <= 207) && (v_m != 196) && (v_m != 200) &&
+                 (v_m != 204)) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        status = wuffs_jpeg__decoder__decode_other_marker(self, a_src, v_m);
+        if (status) {
+          goto suspend;
+        }
+      }
+    }
+  label_0_break:;
+    if (!v_seen_sos) {
+      status = WUFFS_JPEG__ERROR_NOT_ENOUGH_JPEG_IMAGE_DATA;
+      goto exit;
+    }
+    if (self->private_impl.f_progressive) {
+      wuffs_jpeg__decoder__render_progressive(self, a_dst, a_workbuf);
+    }
+    self->private_impl.f_call_sequence = 3;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame[0].v_m = v_m;
+  self->private_impl.c_decode_frame[0].v_seen_sos = v_seen_sos;
+  self->private_impl.c_decode_frame[0].v_i = v_i;
+  self->private_impl.c_decode_frame[0].v_n = v_n;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__read_marker(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_c;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_read_marker[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_read_marker[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    if (self->private_impl.f_marker != 0) {
+      status = WUFFS_JPEG__STATUS_OK;
+      goto ok;
+    }
+  label_0_continue:;
+    while (true) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_0 = *b_rptr_src++;
+        v_c = t_0;
+      }
+      if (v_c != 255) {
+        goto label_0_continue;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_1 = *b_rptr_src++;
+        v_c = t_1;
+      }
+      while (v_c == 255) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_2 = *b_rptr_src++;
+          v_c = t_2;
+        }
+      }
+      if (v_c != 0) {
+        goto label_0_break;
+      }
+    }
+  label_0_break:;
+    self->private_impl.f_marker = v_c;
+
+    goto ok;
+  ok:
+    self->private_impl.c_read_marker[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_read_marker[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_read_marker[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_other_marker(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint8_t a_marker) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_n;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_other_marker[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_decode_other_marker[0].v_n;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (a_marker == 196) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_jpeg__decoder__decode_dht(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else if (a_marker == 219) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_jpeg__decoder__decode_dqt(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else if (a_marker == 221) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (a_src.buf) {
+        size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+        a_src.buf->ri += n;
+        wuffs_base__limit1* lim;
+        for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+          if (lim->ptr_to_len) {
+            *lim->ptr_to_len -= n;
+          }
+        }
+      }
+      status = wuffs_jpeg__decoder__decode_dri(self, a_src);
+      if (a_src.buf) {
+        b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+      }
+      if (status) {
+        goto suspend;
+      }
+    } else if (a_marker == 1) {
+      status = WUFFS_JPEG__STATUS_OK;
+      goto ok;
+    } else if ((208 <= a_marker) && (a_marker <= 218)) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_MARKER;
+      goto exit;
+    } else {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        uint16_t t_1;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+          t_1 = wuffs_base__load_u16be(b_rptr_src);
+          b_rptr_src += 2;
+        } else {
+          self->private_impl.c_decode_other_marker[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_0 =
+                self->private_impl.c_decode_other_marker[0].scratch & 0xFF;
+            self->private_impl.c_decode_other_marker[0].scratch >>= 8;
+            self->private_impl.c_decode_other_marker[0].scratch <<= 8;
+            self->private_impl.c_decode_other_marker[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+            if (t_0 == 8) {
+              t_1 = self->private_impl.c_decode_other_marker[0].scratch >>
+                    (64 - 16);
+              break;
+            }
+            t_0 += 8;
+            self->private_impl.c_decode_other_marker[0].scratch |=
+                ((uint64_t)(t_0));
+          }
+        }
+        v_n = ((uint32_t)(t_1));
+      }
+      if (v_n < 2) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_MARKER;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      self->private_impl.c_decode_other_marker[0].scratch = (v_n - 2);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      if (self->private_impl.c_decode_other_marker[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_other_marker[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_other_marker[0].scratch;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_other_marker[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_other_marker[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_other_marker[0].v_n = v_n;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_sof(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_i;
+  uint8_t v_c;
+  uint32_t v_h;
+  uint32_t v_v;
+  uint32_t v_q;
+  uint32_t v_n;
+  uint32_t v_height;
+  uint32_t v_width;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_sof[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_i = self->private_impl.c_decode_sof[0].v_i;
+    v_c = self->private_impl.c_decode_sof[0].v_c;
+    v_h = self->private_impl.c_decode_sof[0].v_h;
+    v_v = self->private_impl.c_decode_sof[0].v_v;
+    v_q = self->private_impl.c_decode_sof[0].v_q;
+    v_n = self->private_impl.c_decode_sof[0].v_n;
+    v_height = self->private_impl.c_decode_sof[0].v_height;
+    v_width = self->private_impl.c_decode_sof[0].v_width;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_i = 0;
+    v_c = 0;
+    v_h = 0;
+    v_v = 0;
+    v_q = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_sof[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 = self->private_impl.c_decode_sof[0].scratch & 0xFF;
+          self->private_impl.c_decode_sof[0].scratch >>= 8;
+          self->private_impl.c_decode_sof[0].scratch <<= 8;
+          self->private_impl.c_decode_sof[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_sof[0].scratch >> (64 - 16);
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_sof[0].scratch |= ((uint64_t)(t_0));
+        }
+      }
+      v_n = ((uint32_t)(t_1));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_2 = *b_rptr_src++;
+      v_c = t_2;
+    }
+    if (v_c != 8) {
+      status = WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_PRECISION;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      uint16_t t_4;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_4 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_sof[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_3 = self->private_impl.c_decode_sof[0].scratch & 0xFF;
+          self->private_impl.c_decode_sof[0].scratch >>= 8;
+          self->private_impl.c_decode_sof[0].scratch <<= 8;
+          self->private_impl.c_decode_sof[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_3);
+          if (t_3 == 8) {
+            t_4 = self->private_impl.c_decode_sof[0].scratch >> (64 - 16);
+            break;
+          }
+          t_3 += 8;
+          self->private_impl.c_decode_sof[0].scratch |= ((uint64_t)(t_3));
+        }
+      }
+      v_height = ((uint32_t)(t_4));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      uint16_t t_6;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_6 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_sof[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_5 = self->private_impl.c_decode_sof[0].scratch & 0xFF;
+          self->private_impl.c_decode_sof[0].scratch >>= 8;
+          self->private_impl.c_decode_sof[0].scratch <<= 8;
+          self->private_impl.c_decode_sof[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_5);
+          if (t_5 == 8) {
+            t_6 = self->private_impl.c_decode_sof[0].scratch >> (64 - 16);
+            break;
+          }
+          t_5 += 8;
+          self->private_impl.c_decode_sof[0].scratch |= ((uint64_t)(t_5));
+        }
+      }
+      v_width = ((uint32_t)(t_6));
+    }
+    if ((v_width == 0) || (v_height == 0)) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+      goto exit;
+    }
+    if ((v_width > 65535) || (v_height > 65535)) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+      goto exit;
+    }
+    self->private_impl.f_width = v_width;
+    self->private_impl.f_height = v_height;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_7 = *b_rptr_src++;
+      v_c = t_7;
+    }
+    if ((v_c != 1) && (v_c != 3)) {
+      status = WUFFS_JPEG__ERROR_UNSUPPORTED_JPEG_COMPONENT_COUNT;
+      goto exit;
+    }
+    self->private_impl.f_num_components = ((uint32_t)((v_c & 3)));
+    if (v_n != (8 + (3 * self->private_impl.f_num_components))) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+      goto exit;
+    }
+    while (v_i < self->private_impl.f_num_components) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_8 = *b_rptr_src++;
+        self->private_impl.f_comp_id[v_i] = t_8;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_9 = *b_rptr_src++;
+        v_c = t_9;
+      }
+      v_h = ((uint32_t)((v_c >> 4)));
+      v_v = ((uint32_t)((v_c & 15)));
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_10 = *b_rptr_src++;
+        v_c = t_10;
+      }
+      v_q = ((uint32_t)((v_c & 15)));
+      if (v_h < 1) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      if (v_h > 4) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      if (v_v < 1) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      if (v_v > 4) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      if (v_q > 3) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      self->private_impl.f_comp_h[v_i] = v_h;
+      self->private_impl.f_comp_v[v_i] = v_v;
+      self->private_impl.f_comp_q[v_i] = v_q;
+      v_i += 1;
+    }
+    if (self->private_impl.f_num_components == 1) {
+      self->private_impl.f_comp_h[0] = 1;
+      self->private_impl.f_comp_v[0] = 1;
+    } else {
+      if ((self->private_impl.f_comp_id[0] ==
+           self->private_impl.f_comp_id[1]) ||
+          (self->private_impl.f_comp_id[0] ==
+           self->private_impl.f_comp_id[2]) ||
+          (self->private_impl.f_comp_id[1] ==
+           self->private_impl.f_comp_id[2])) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+      if (((self->private_impl.f_comp_h[0] * self->private_impl.f_comp_v[0]) +
+           (self->private_impl.f_comp_h[1] * self->private_impl.f_comp_v[1]) +
+           (self->private_impl.f_comp_h[2] * self->private_impl.f_comp_v[2])) >
+          10) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOF_MARKER;
+        goto exit;
+      }
+    }
+    wuffs_jpeg__decoder__compute_dimensions(self);
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_sof[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_sof[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_sof[0].v_i = v_i;
+  self->private_impl.c_decode_sof[0].v_c = v_c;
+  self->private_impl.c_decode_sof[0].v_h = v_h;
+  self->private_impl.c_decode_sof[0].v_v = v_v;
+  self->private_impl.c_decode_sof[0].v_q = v_q;
+  self->private_impl.c_decode_sof[0].v_n = v_n;
+  self->private_impl.c_decode_sof[0].v_height = v_height;
+  self->private_impl.c_decode_sof[0].v_width = v_width;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_jpeg__decoder__compute_dimensions(wuffs_jpeg__decoder* self) {
+  uint32_t v_i;
+  uint32_t v_max_h;
+  uint32_t v_max_v;
+  uint32_t v_h;
+  uint32_t v_v;
+  uint32_t v_n;
+  uint64_t v_offset;
+  uint64_t v_woffset;
+
+  v_i = 0;
+  v_max_h = 1;
+  v_max_v = 1;
+  v_h = 1;
+  v_v = 1;
+  v_n = 0;
+  v_offset = 0;
+  v_woffset = 0;
+  while (v_i < self->private_impl.f_num_components) {
+    v_h = self->private_impl.f_comp_h[v_i];
+    v_v = self->private_impl.f_comp_v[v_i];
+    if (v_max_h < v_h) {
+      v_max_h = v_h;
+    }
+    if (v_max_v < v_v) {
+      v_max_v = v_v;
+    }
+    v_i += 1;
+  }
+  self->private_impl.f_max_h = v_max_h;
+  self->private_impl.f_max_v = v_max_v;
+  v_n = ((((self->private_impl.f_width + 7) / 8) + (v_max_h - 1)) / v_max_h);
+  if (v_n <= 8192) {
+    self->private_impl.f_mcus_x = v_n;
+  }
+  v_n = ((((self->private_impl.f_height + 7) / 8) + (v_max_v - 1)) / v_max_v);
+  if (v_n <= 8192) {
+    self->private_impl.f_mcus_y = v_n;
+  }
+  v_i = 0;
+  while (v_i < self->private_impl.f_num_components) {
+    v_h = self->private_impl.f_comp_h[v_i];
+    v_v = self->private_impl.f_comp_v[v_i];
+    self->private_impl.f_blocks_w[v_i] = (self->private_impl.f_mcus_x * v_h);
+    self->private_impl.f_blocks_h[v_i] = (self->private_impl.f_mcus_y * v_v);
+    v_n = (((self->private_impl.f_width * v_h) + (v_max_h - 1)) / v_max_h);
+    if (v_n <= 65535) {
+      self->private_impl.f_plane_w[v_i] = v_n;
+    }
+    v_n = (((self->private_impl.f_height * v_v) + (v_max_v - 1)) / v_max_v);
+    if (v_n <= 65535) {
+      self->private_impl.f_plane_h[v_i] = v_n;
+    }
+    self->private_impl.f_plane_offset[v_i] = v_offset;
+    v_offset = ((v_offset + (((uint64_t)(self->private_impl.f_plane_w[v_i])) *
+                             ((uint64_t)(self->private_impl.f_plane_h[v_i])))) &
+                17179869183);
+    self->private_impl.f_workbuf_offset[v_i] = v_woffset;
+    v_woffset = ((v_woffset +
+                  (((uint64_t)(self->private_impl.f_blocks_w[v_i])) *
+                   ((uint64_t)(self->private_impl.f_blocks_h[v_i])) * 128)) &
+                 34359738367);
+    v_i += 1;
+  }
+  self->private_impl.f_plane_offset[self->private_impl.f_num_components] =
+      v_offset;
+  self->private_impl.f_workbuf_offset[self->private_impl.f_num_components] =
+      v_woffset;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dqt(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_c;
+  uint32_t v_t;
+  bool v_wide;
+  uint32_t v_i;
+  uint32_t v_x;
+  uint32_t v_n;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_dqt[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_dqt[0].v_c;
+    v_t = self->private_impl.c_decode_dqt[0].v_t;
+    v_wide = self->private_impl.c_decode_dqt[0].v_wide;
+    v_i = self->private_impl.c_decode_dqt[0].v_i;
+    v_x = self->private_impl.c_decode_dqt[0].v_x;
+    v_n = self->private_impl.c_decode_dqt[0].v_n;
+  } else {
+    v_wide = false;
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    v_t = 0;
+    v_wide = 0;
+    v_i = 0;
+    v_x = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_dqt[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 = self->private_impl.c_decode_dqt[0].scratch & 0xFF;
+          self->private_impl.c_decode_dqt[0].scratch >>= 8;
+          self->private_impl.c_decode_dqt[0].scratch <<= 8;
+          self->private_impl.c_decode_dqt[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_dqt[0].scratch >> (64 - 16);
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_dqt[0].scratch |= ((uint64_t)(t_0));
+        }
+      }
+      v_n = ((uint32_t)(t_1));
+    }
+    if (v_n < 2) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_DQT_MARKER;
+      goto exit;
+    }
+    v_n -= 2;
+    while (v_n > 0) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_2 = *b_rptr_src++;
+        v_c = t_2;
+      }
+      if (((v_c >> 4) > 1) || ((v_c & 15) > 3)) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_DQT_MARKER;
+        goto exit;
+      }
+      v_t = ((uint32_t)((v_c & 3)));
+      v_wide = ((v_c >> 4) == 1);
+      if (v_wide) {
+        if (v_n < 129) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_DQT_MARKER;
+          goto exit;
+        }
+        v_n -= 129;
+      } else {
+        if (v_n < 65) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_DQT_MARKER;
+          goto exit;
+        }
+        v_n -= 65;
+      }
+      v_i = 0;
+      while (v_i < 64) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_3 = *b_rptr_src++;
+          v_x = ((uint32_t)(t_3));
+        }
+        if (v_wide) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_4 = *b_rptr_src++;
+            v_x = (((v_x & 255) << 8) | ((uint32_t)(t_4)));
+          }
+        }
+        self->private_impl.f_quant[v_t][wuffs_jpeg__zigzag[v_i]] =
+            ((uint16_t)(v_x));
+        v_i += 1;
+      }
+      self->private_impl.f_seen_quant[v_t] = true;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dqt[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dqt[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_dqt[0].v_c = v_c;
+  self->private_impl.c_decode_dqt[0].v_t = v_t;
+  self->private_impl.c_decode_dqt[0].v_wide = v_wide;
+  self->private_impl.c_decode_dqt[0].v_i = v_i;
+  self->private_impl.c_decode_dqt[0].v_x = v_x;
+  self->private_impl.c_decode_dqt[0].v_n = v_n;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dri(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_dri[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    uint16_t t_1;
+    if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+      t_1 = wuffs_base__load_u16be(b_rptr_src);
+      b_rptr_src += 2;
+    } else {
+      self->private_impl.c_decode_dri[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint32_t t_0 = self->private_impl.c_decode_dri[0].scratch & 0xFF;
+        self->private_impl.c_decode_dri[0].scratch >>= 8;
+        self->private_impl.c_decode_dri[0].scratch <<= 8;
+        self->private_impl.c_decode_dri[0].scratch |=
+            ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+        if (t_0 == 8) {
+          t_1 = self->private_impl.c_decode_dri[0].scratch >> (64 - 16);
+          break;
+        }
+        t_0 += 8;
+        self->private_impl.c_decode_dri[0].scratch |= ((uint64_t)(t_0));
+      }
+    }
+    if (t_1 != 4) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_DRI_MARKER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      uint16_t t_3;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_3 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_dri[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_2 = self->private_impl.c_decode_dri[0].scratch & 0xFF;
+          self->private_impl.c_decode_dri[0].scratch >>= 8;
+          self->private_impl.c_decode_dri[0].scratch <<= 8;
+          self->private_impl.c_decode_dri[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_2);
+          if (t_2 == 8) {
+            t_3 = self->private_impl.c_decode_dri[0].scratch >> (64 - 16);
+            break;
+          }
+          t_2 += 8;
+          self->private_impl.c_decode_dri[0].scratch |= ((uint64_t)(t_2));
+        }
+      }
+      self->private_impl.f_restart_interval = ((uint32_t)(t_3));
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dri[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dri[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dht(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_c;
+  uint32_t v_t;
+  uint32_t v_i;
+  uint32_t v_total;
+  uint32_t v_num_codes;
+  uint32_t v_n;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_dht[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_dht[0].v_c;
+    v_t = self->private_impl.c_decode_dht[0].v_t;
+    v_i = self->private_impl.c_decode_dht[0].v_i;
+    v_total = self->private_impl.c_decode_dht[0].v_total;
+    v_num_codes = self->private_impl.c_decode_dht[0].v_num_codes;
+    v_n = self->private_impl.c_decode_dht[0].v_n;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    v_t = 0;
+    v_i = 0;
+    v_total = 0;
+    v_num_codes = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_dht[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 = self->private_impl.c_decode_dht[0].scratch & 0xFF;
+          self->private_impl.c_decode_dht[0].scratch >>= 8;
+          self->private_impl.c_decode_dht[0].scratch <<= 8;
+          self->private_impl.c_decode_dht[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_dht[0].scratch >> (64 - 16);
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_dht[0].scratch |= ((uint64_t)(t_0));
+        }
+      }
+      v_n = ((uint32_t)(t_1));
+    }
+    if (v_n < 2) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+      goto exit;
+    }
+    v_n -= 2;
+    while (v_n > 0) {
+      if (v_n < 17) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+        goto exit;
+      }
+      v_n -= 17;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_2 = *b_rptr_src++;
+        v_c = t_2;
+      }
+      if (((v_c >> 4) > 1) || ((v_c & 15) > 3)) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+        goto exit;
+      }
+      v_t = ((uint32_t)(((((v_c >> 4) & 1) * 4) + (v_c & 3))));
+      v_total = 0;
+      v_i = 0;
+      while (v_i < 16) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_3 = *b_rptr_src++;
+          v_c = t_3;
+        }
+        self->private_impl.f_huff_counts[v_i] = ((uint32_t)(v_c));
+        v_total = ((v_total + ((uint32_t)(v_c))) & 4095);
+        v_i += 1;
+      }
+      if ((v_total == 0) || (v_total > 256) || (v_n < v_total)) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+        goto exit;
+      }
+      v_n -= v_total;
+      v_num_codes = v_total;
+      v_i = 0;
+      while (v_i < v_num_codes) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_4 = *b_rptr_src++;
+          v_c = t_4;
+        }
+        if ((v_t < 4) && (v_c > 15)) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+          goto exit;
+        }
+        self->private_impl.f_huff_values[v_t][v_i] = v_c;
+        v_i += 1;
+      }
+      wuffs_jpeg__decoder__build_huffman(self, v_t);
+      if (self->private_impl.f_bad_huffman) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_DHT_MARKER;
+        goto exit;
+      }
+      self->private_impl.f_seen_huff[v_t] = true;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dht[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dht[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_dht[0].v_c = v_c;
+  self->private_impl.c_decode_dht[0].v_t = v_t;
+  self->private_impl.c_decode_dht[0].v_i = v_i;
+  self->private_impl.c_decode_dht[0].v_total = v_total;
+  self->private_impl.c_decode_dht[0].v_num_codes = v_num_codes;
+  self->private_impl.c_decode_dht[0].v_n = v_n;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_jpeg__decoder__build_huffman(wuffs_jpeg__decoder* self,
+                                               uint32_t a_t) {
+  uint32_t v_i;
+  uint32_t v_j;
+  uint32_t v_l;
+  uint32_t v_count;
+  uint32_t v_code;
+  uint32_t v_end;
+  uint32_t v_k;
+  uint32_t v_shift;
+  uint32_t v_n;
+  uint16_t v_x;
+
+  v_i = 0;
+  v_j = 0;
+  v_l = 1;
+  v_count = 0;
+  v_code = 0;
+  v_end = 0;
+  v_k = 0;
+  v_shift = 0;
+  v_n = 0;
+  v_x = 0;
+  while (v_i < 256) {
+    self->private_impl.f_huff_lut[a_t][v_i] = 0;
+    v_i += 1;
+  }
+  v_i = 0;
+  while (v_i < 16) {
+    v_l = (v_i + 1);
+    v_count = self->private_impl.f_huff_counts[v_i];
+    v_end = (v_code + v_count);
+    if (v_end > (((uint32_t)(1)) << v_l)) {
+      self->private_impl.f_bad_huffman = true;
+      return;
+    }
+    self->private_impl.f_huff_min[a_t][v_l] = v_code;
+    self->private_impl.f_huff_end[a_t][v_l] = v_end;
+    self->private_impl.f_huff_valptr[a_t][v_l] = (v_k & 255);
+    if (v_l <= 8) {
+      v_shift = (8 - v_l);
+      v_n = (((uint32_t)(1)) << v_shift);
+      v_j = 0;
+      while (v_j < v_count) {
+        v_x = ((uint16_t)((
+            (v_l << 8) |
+            ((uint32_t)(self->private_impl
+                            .f_huff_values[a_t][(v_k + v_j) & 255])))));
+        wuffs_jpeg__decoder__fill_lut(
+            self, a_t, ((v_code + v_j) & 255) << v_shift, v_n, v_x);
+        v_j += 1;
+      }
+    }
+    v_k = ((v_k + v_count) & 511);
+    v_code = ((v_end & 131071) << 1);
+    v_i = v_l;
+  }
+}
+
+static void wuffs_jpeg__decoder__fill_lut(wuffs_jpeg__decoder* self,
+                                          uint32_t a_t,
+                                          uint32_t a_p,
+                                          uint32_t a_n,
+                                          uint16_t a_x) {
+  uint32_t v_i;
+
+  v_i = 0;
+  while (v_i < a_n) {
+    self->private_impl.f_huff_lut[a_t][(a_p + v_i) & 255] = a_x;
+    v_i += 1;
+  }
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_sos(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_c;
+  uint32_t v_i;
+  uint32_t v_k;
+  uint32_t v_x;
+  uint32_t v_n;
+  uint32_t v_ss;
+  uint32_t v_se;
+  uint32_t v_ah;
+  uint32_t v_al;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_decode_sos[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_sos[0].v_c;
+    v_i = self->private_impl.c_decode_sos[0].v_i;
+    v_k = self->private_impl.c_decode_sos[0].v_k;
+    v_x = self->private_impl.c_decode_sos[0].v_x;
+    v_n = self->private_impl.c_decode_sos[0].v_n;
+    v_ss = self->private_impl.c_decode_sos[0].v_ss;
+    v_se = self->private_impl.c_decode_sos[0].v_se;
+    v_ah = self->private_impl.c_decode_sos[0].v_ah;
+    v_al = self->private_impl.c_decode_sos[0].v_al;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    v_i = 0;
+    v_k = 0;
+    v_x = 0;
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      uint16_t t_1;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+        t_1 = wuffs_base__load_u16be(b_rptr_src);
+        b_rptr_src += 2;
+      } else {
+        self->private_impl.c_decode_sos[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_0 = self->private_impl.c_decode_sos[0].scratch & 0xFF;
+          self->private_impl.c_decode_sos[0].scratch >>= 8;
+          self->private_impl.c_decode_sos[0].scratch <<= 8;
+          self->private_impl.c_decode_sos[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << (56 - t_0);
+          if (t_0 == 8) {
+            t_1 = self->private_impl.c_decode_sos[0].scratch >> (64 - 16);
+            break;
+          }
+          t_0 += 8;
+          self->private_impl.c_decode_sos[0].scratch |= ((uint64_t)(t_0));
+        }
+      }
+      v_n = ((uint32_t)(t_1));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_2 = *b_rptr_src++;
+      v_c = t_2;
+    }
+    if ((v_c < 1) ||
+        (((uint32_t)(v_c)) > self->private_impl.f_num_components)) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+      goto exit;
+    }
+    self->private_impl.f_scan_num_components = ((uint32_t)((v_c & 3)));
+    if (v_n != (6 + (2 * self->private_impl.f_scan_num_components))) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+      goto exit;
+    }
+    while (v_i < self->private_impl.f_scan_num_components) {
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_3 = *b_rptr_src++;
+        v_c = t_3;
+      }
+      if (self->private_impl.f_comp_id[0] == v_c) {
+        v_k = 0;
+      } else if ((self->private_impl.f_num_components == 3) &&
+                 (self->private_impl.f_comp_id[1] == v_c)) {
+        v_k = 1;
+      } else if ((self->private_impl.f_num_components == 3) &&
+                 (self->private_impl.f_comp_id[2] == v_c)) {
+        v_k = 2;
+      } else {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+        goto exit;
+      }
+      if (v_i > 0) {
+        if (v_k <= self->private_impl.f_scan_comp[v_i - 1]) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+          goto exit;
+        }
+      }
+      self->private_impl.f_scan_comp[v_i] = v_k;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_4 = *b_rptr_src++;
+        v_c = t_4;
+      }
+      if (((v_c >> 4) > 3) || ((v_c & 15) > 3)) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+        goto exit;
+      }
+      self->private_impl.f_scan_td[v_i] = ((uint32_t)(((v_c >> 4) & 3)));
+      self->private_impl.f_scan_ta[v_i] = ((uint32_t)((v_c & 3)));
+      v_i += 1;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_5 = *b_rptr_src++;
+      v_ss = ((uint32_t)(t_5));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_6 = *b_rptr_src++;
+      v_se = ((uint32_t)(t_6));
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_7 = *b_rptr_src++;
+      v_c = t_7;
+    }
+    v_ah = ((uint32_t)((v_c >> 4)));
+    v_al = ((uint32_t)((v_c & 15)));
+    if (self->private_impl.f_progressive) {
+      if (v_ss == 0) {
+        if (v_se != 0) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+          goto exit;
+        }
+      } else {
+        if ((self->private_impl.f_scan_num_components != 1) || (v_se < v_ss) ||
+            (v_se > 63)) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+          goto exit;
+        }
+      }
+      if ((v_ah != 0) && (v_ah != (v_al + 1))) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+        goto exit;
+      }
+      if (v_ah > 13) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+        goto exit;
+      }
+      if (v_al > 13) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_SOS_MARKER;
+        goto exit;
+      }
+      self->private_impl.f_ss = (v_ss & 63);
+      self->private_impl.f_se = (v_se & 63);
+      self->private_impl.f_ah = v_ah;
+      self->private_impl.f_al = v_al;
+    } else {
+      self->private_impl.f_ss = 0;
+      self->private_impl.f_se = 63;
+      self->private_impl.f_ah = 0;
+      self->private_impl.f_al = 0;
+    }
+    v_i = 0;
+    while (v_i < self->private_impl.f_scan_num_components) {
+      if (!self->private_impl
+               .f_seen_quant[self->private_impl.f_comp_q
+                                 [self->private_impl.f_scan_comp[v_i]]]) {
+        status = WUFFS_JPEG__ERROR_MISSING_JPEG_QUANTIZATION_TABLE;
+        goto exit;
+      }
+      if (self->private_impl.f_ss == 0) {
+        if (self->private_impl.f_ah == 0) {
+          v_x = self->private_impl.f_scan_td[v_i];
+          if (!self->private_impl.f_seen_huff[v_x]) {
+            status = WUFFS_JPEG__ERROR_MISSING_JPEG_HUFFMAN_TABLE;
+            goto exit;
+          }
+        }
+      }
+      if ((self->private_impl.f_se > 0) &&
+          !self->private_impl
+               .f_seen_huff[self->private_impl.f_scan_ta[v_i] + 4]) {
+        status = WUFFS_JPEG__ERROR_MISSING_JPEG_HUFFMAN_TABLE;
+        goto exit;
+      }
+      v_i += 1;
+    }
+    self->private_impl.f_bits = 0;
+    self->private_impl.f_n_bits = 0;
+    self->private_impl.f_eob_run = 0;
+    self->private_impl.f_next_restart = 0;
+    self->private_impl.f_restarts_to_go = self->private_impl.f_restart_interval;
+    self->private_impl.f_dc_pred[0] = 0;
+    self->private_impl.f_dc_pred[1] = 0;
+    self->private_impl.f_dc_pred[2] = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_sos[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_sos[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_sos[0].v_c = v_c;
+  self->private_impl.c_decode_sos[0].v_i = v_i;
+  self->private_impl.c_decode_sos[0].v_k = v_k;
+  self->private_impl.c_decode_sos[0].v_x = v_x;
+  self->private_impl.c_decode_sos[0].v_n = v_n;
+  self->private_impl.c_decode_sos[0].v_ss = v_ss;
+  self->private_impl.c_decode_sos[0].v_se = v_se;
+  self->private_impl.c_decode_sos[0].v_ah = v_ah;
+  self->private_impl.c_decode_sos[0].v_al = v_al;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_scan(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_c;
+  uint32_t v_n_x;
+  uint32_t v_n_y;
+  uint32_t v_mx;
+  uint32_t v_my;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_i;
+  uint32_t v_si;
+  uint32_t v_sv;
+  uint32_t v_h;
+  uint32_t v_v;
+  uint32_t v_hc;
+  uint32_t v_vc;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_scan[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_scan[0].v_c;
+    v_n_x = self->private_impl.c_decode_scan[0].v_n_x;
+    v_n_y = self->private_impl.c_decode_scan[0].v_n_y;
+    v_mx = self->private_impl.c_decode_scan[0].v_mx;
+    v_my = self->private_impl.c_decode_scan[0].v_my;
+    v_x = self->private_impl.c_decode_scan[0].v_x;
+    v_y = self->private_impl.c_decode_scan[0].v_y;
+    v_i = self->private_impl.c_decode_scan[0].v_i;
+    v_si = self->private_impl.c_decode_scan[0].v_si;
+    v_sv = self->private_impl.c_decode_scan[0].v_sv;
+    v_h = self->private_impl.c_decode_scan[0].v_h;
+    v_v = self->private_impl.c_decode_scan[0].v_v;
+    v_hc = self->private_impl.c_decode_scan[0].v_hc;
+    v_vc = self->private_impl.c_decode_scan[0].v_vc;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    v_n_x = 0;
+    v_n_y = 0;
+    v_mx = 0;
+    v_my = 0;
+    v_x = 0;
+    v_y = 0;
+    v_i = 0;
+    v_si = 0;
+    v_sv = 0;
+    v_h = 0;
+    v_v = 0;
+    v_hc = 1;
+    v_vc = 1;
+    if (self->private_impl.f_scan_num_components == 1) {
+      v_c = self->private_impl.f_scan_comp[0];
+      v_n_x = ((self->private_impl.f_plane_w[v_c] + 7) / 8);
+      v_n_y = ((self->private_impl.f_plane_h[v_c] + 7) / 8);
+    } else {
+      v_n_x = self->private_impl.f_mcus_x;
+      v_n_y = self->private_impl.f_mcus_y;
+    }
+    while (v_my < v_n_y) {
+      v_y = v_my;
+      v_mx = 0;
+      while (v_mx < v_n_x) {
+        v_x = v_mx;
+        if (self->private_impl.f_restart_interval > 0) {
+          if (self->private_impl.f_restarts_to_go == 0) {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+            status = wuffs_jpeg__decoder__decode_restart(self, a_src);
+            if (status) {
+              goto suspend;
+            }
+            self->private_impl.f_restarts_to_go =
+                self->private_impl.f_restart_interval;
+          }
+          if (self->private_impl.f_restarts_to_go > 0) {
+            self->private_impl.f_restarts_to_go -= 1;
+          }
+        }
+        if (self->private_impl.f_scan_num_components == 1) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          status = wuffs_jpeg__decoder__decode_block(self, a_dst, a_workbuf,
+                                                     a_src, 0, v_x, v_y);
+          if (status) {
+            goto suspend;
+          }
+        } else {
+          v_i = 0;
+          while (v_i < self->private_impl.f_scan_num_components) {
+            v_si = v_i;
+            v_c = self->private_impl.f_scan_comp[v_si];
+            v_hc = self->private_impl.f_comp_h[v_c];
+            v_vc = self->private_impl.f_comp_v[v_c];
+            v_v = 0;
+            while (v_v < v_vc) {
+              v_sv = v_v;
+              v_h = 0;
+              while (v_h < v_hc) {
+                WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+                status = wuffs_jpeg__decoder__decode_block(
+                    self, a_dst, a_workbuf, a_src, v_si, ((v_x * v_hc) + v_h),
+                    ((v_y * v_vc) + v_sv));
+                if (status) {
+                  goto suspend;
+                }
+                v_h += 1;
+              }
+              v_v = (((uint32_t)(v_sv)) + 1);
+            }
+            v_i = (((uint32_t)(v_si)) + 1);
+          }
+        }
+        v_mx = (((uint32_t)(v_x)) + 1);
+      }
+      v_my = (((uint32_t)(v_y)) + 1);
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_scan[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_scan[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_scan[0].v_c = v_c;
+  self->private_impl.c_decode_scan[0].v_n_x = v_n_x;
+  self->private_impl.c_decode_scan[0].v_n_y = v_n_y;
+  self->private_impl.c_decode_scan[0].v_mx = v_mx;
+  self->private_impl.c_decode_scan[0].v_my = v_my;
+  self->private_impl.c_decode_scan[0].v_x = v_x;
+  self->private_impl.c_decode_scan[0].v_y = v_y;
+  self->private_impl.c_decode_scan[0].v_i = v_i;
+  self->private_impl.c_decode_scan[0].v_si = v_si;
+  self->private_impl.c_decode_scan[0].v_sv = v_sv;
+  self->private_impl.c_decode_scan[0].v_h = v_h;
+  self->private_impl.c_decode_scan[0].v_v = v_v;
+  self->private_impl.c_decode_scan[0].v_hc = v_hc;
+  self->private_impl.c_decode_scan[0].v_vc = v_vc;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_restart(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_restart[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    self->private_impl.f_bits = 0;
+    self->private_impl.f_n_bits = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_jpeg__decoder__read_marker(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    if (((uint32_t)(self->private_impl.f_marker)) !=
+        (208 + self->private_impl.f_next_restart)) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_RST_MARKER;
+      goto exit;
+    }
+    self->private_impl.f_marker = 0;
+    self->private_impl.f_next_restart =
+        ((self->private_impl.f_next_restart + 1) & 7);
+    self->private_impl.f_eob_run = 0;
+    self->private_impl.f_dc_pred[0] = 0;
+    self->private_impl.f_dc_pred[1] = 0;
+    self->private_impl.f_dc_pred[2] = 0;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_restart[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_restart[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_block(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint32_t a_i,
+    uint32_t a_bx,
+    uint32_t a_by) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_c;
+  uint64_t v_o;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_block[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_decode_block[0].v_c;
+    v_o = self->private_impl.c_decode_block[0].v_o;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = self->private_impl.f_scan_comp[a_i];
+    v_o = 0;
+    if (!self->private_impl.f_progressive) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_jpeg__decoder__decode_block_sequential(
+          self, a_src, v_c, self->private_impl.f_scan_td[a_i],
+          self->private_impl.f_scan_ta[a_i]);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_jpeg__decoder__idct(self, a_dst, v_c, a_bx, a_by);
+      status = WUFFS_JPEG__STATUS_OK;
+      goto ok;
+    }
+    v_o = (self->private_impl.f_workbuf_offset[v_c] +
+           ((((((uint64_t)(a_by)) *
+               ((uint64_t)(self->private_impl.f_blocks_w[v_c]))) +
+              ((uint64_t)(a_bx))) &
+             1073741823) *
+            128));
+    wuffs_jpeg__decoder__load_block(self, a_workbuf, v_o);
+    if (self->private_impl.f_ss == 0) {
+      if (self->private_impl.f_ah == 0) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_jpeg__decoder__decode_dc_first(
+            self, a_src, v_c, self->private_impl.f_scan_td[a_i]);
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_jpeg__decoder__decode_dc_refine(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+      }
+    } else {
+      if (self->private_impl.f_ah == 0) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_jpeg__decoder__decode_ac_first(
+            self, a_src, self->private_impl.f_scan_ta[a_i]);
+        if (status) {
+          goto suspend;
+        }
+      } else {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        status = wuffs_jpeg__decoder__decode_ac_refine(
+            self, a_src, self->private_impl.f_scan_ta[a_i]);
+        if (status) {
+          goto suspend;
+        }
+      }
+    }
+    wuffs_jpeg__decoder__store_block(self, a_workbuf, v_o);
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_block[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_block[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_block[0].v_c = v_c;
+  self->private_impl.c_decode_block[0].v_o = v_o;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_block_sequential(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_c,
+    uint32_t a_td,
+    uint32_t a_ta) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_k;
+  uint32_t v_r;
+  uint32_t v_s;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_block_sequential[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_k = self->private_impl.c_decode_block_sequential[0].v_k;
+    v_r = self->private_impl.c_decode_block_sequential[0].v_r;
+    v_s = self->private_impl.c_decode_block_sequential[0].v_s;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_k = 0;
+    v_r = 0;
+    v_s = 0;
+    while (v_k < 64) {
+      self->private_impl.f_block[v_k] = 0;
+      v_k += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_jpeg__decoder__decode_huffman(self, a_td);
+    if (self->private_impl.f_bad_huffman) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE;
+      goto exit;
+    }
+    v_s = (self->private_impl.f_symbol & 15);
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_jpeg__decoder__receive_extend(self, v_s);
+    wuffs_jpeg__decoder__set_dc_pred(
+        self, a_c,
+        self->private_impl.f_dc_pred[a_c] + self->private_impl.f_value);
+    self->private_impl.f_block[0] = self->private_impl.f_dc_pred[a_c];
+    v_k = 1;
+  label_0_continue:;
+    while (v_k <= 63) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_jpeg__decoder__decode_huffman(self, a_ta + 4);
+      if (self->private_impl.f_bad_huffman) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_r = (self->private_impl.f_symbol >> 4);
+      v_s = (self->private_impl.f_symbol & 15);
+      if (v_s == 0) {
+        if (v_r != 15) {
+          goto label_0_break;
+        }
+        v_k += 16;
+        goto label_0_continue;
+      }
+      v_k += v_r;
+      if (v_k > 63) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_COEFFICIENT;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_jpeg__decoder__receive_extend(self, v_s);
+      self->private_impl.f_block[wuffs_jpeg__zigzag[v_k]] =
+          self->private_impl.f_value;
+      v_k += 1;
+    }
+  label_0_break:;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_block_sequential[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_block_sequential[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_decode_block_sequential[0].v_k = v_k;
+  self->private_impl.c_decode_block_sequential[0].v_r = v_r;
+  self->private_impl.c_decode_block_sequential[0].v_s = v_s;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dc_first(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_c,
+    uint32_t a_td) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_dc_first[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_jpeg__decoder__decode_huffman(self, a_td);
+    if (self->private_impl.f_bad_huffman) {
+      status = WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_jpeg__decoder__receive_extend(self, self->private_impl.f_symbol & 15);
+    wuffs_jpeg__decoder__set_dc_pred(
+        self, a_c,
+        self->private_impl.f_dc_pred[a_c] + self->private_impl.f_value);
+    wuffs_jpeg__decoder__set_coefficient(
+        self, 0,
+        self->private_impl.f_dc_pred[a_c] *
+            (((int32_t)(1)) << self->private_impl.f_al));
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dc_first[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dc_first[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_dc_refine(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  int32_t v_v;
+  int32_t v_p1;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_dc_refine[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_v = self->private_impl.c_decode_dc_refine[0].v_v;
+    v_p1 = self->private_impl.c_decode_dc_refine[0].v_p1;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_v = 0;
+    v_p1 = 1;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_jpeg__decoder__take_bits(self, 1);
+    if (self->private_impl.f_taken != 0) {
+      v_v = self->private_impl.f_block[0];
+      v_p1 = (((int32_t)(1)) << self->private_impl.f_al);
+      if ((((uint32_t)((v_v + 65536))) & ((uint32_t)(v_p1))) == 0) {
+        wuffs_jpeg__decoder__set_coefficient(self, 0, v_v + v_p1);
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_dc_refine[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_dc_refine[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_dc_refine[0].v_v = v_v;
+  self->private_impl.c_decode_dc_refine[0].v_p1 = v_p1;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_ac_first(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_ta) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_k;
+  uint32_t v_r;
+  uint32_t v_s;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_ac_first[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_k = self->private_impl.c_decode_ac_first[0].v_k;
+    v_r = self->private_impl.c_decode_ac_first[0].v_r;
+    v_s = self->private_impl.c_decode_ac_first[0].v_s;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_k = 0;
+    v_r = 0;
+    v_s = 0;
+    if (self->private_impl.f_eob_run > 0) {
+      self->private_impl.f_eob_run -= 1;
+      status = WUFFS_JPEG__STATUS_OK;
+      goto ok;
+    }
+    v_k = self->private_impl.f_ss;
+  label_0_continue:;
+    while (v_k <= self->private_impl.f_se) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_jpeg__decoder__decode_huffman(self, a_ta + 4);
+      if (self->private_impl.f_bad_huffman) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_r = (self->private_impl.f_symbol >> 4);
+      v_s = (self->private_impl.f_symbol & 15);
+      if (v_s == 0) {
+        if (v_r != 15) {
+          self->private_impl.f_eob_run = ((((uint32_t)(1)) << v_r) - 1);
+          if (v_r > 0) {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+            status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+            if (status) {
+              goto suspend;
+            }
+            wuffs_jpeg__decoder__take_bits(self, v_r);
+            self->private_impl.f_eob_run =
+                (((((uint32_t)(1)) << v_r) - 1) + self->private_impl.f_taken);
+          }
+          goto label_0_break;
+        }
+        v_k += 16;
+        goto label_0_continue;
+      }
+      v_k += v_r;
+      if (v_k > 63) {
+        status = WUFFS_JPEG__ERROR_BAD_JPEG_COEFFICIENT;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_jpeg__decoder__receive_extend(self, v_s);
+      wuffs_jpeg__decoder__set_coefficient(
+          self, ((uint32_t)(wuffs_jpeg__zigzag[v_k])),
+          self->private_impl.f_value *
+              (((int32_t)(1)) << self->private_impl.f_al));
+      v_k += 1;
+    }
+  label_0_break:;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_ac_first[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_ac_first[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_ac_first[0].v_k = v_k;
+  self->private_impl.c_decode_ac_first[0].v_r = v_r;
+  self->private_impl.c_decode_ac_first[0].v_s = v_s;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__decode_ac_refine(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_ta) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint32_t v_k;
+  uint32_t v_r;
+  uint32_t v_s;
+  uint32_t v_z;
+  int32_t v_p1;
+  int32_t v_nv;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_ac_refine[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_k = self->private_impl.c_decode_ac_refine[0].v_k;
+    v_r = self->private_impl.c_decode_ac_refine[0].v_r;
+    v_s = self->private_impl.c_decode_ac_refine[0].v_s;
+    v_z = self->private_impl.c_decode_ac_refine[0].v_z;
+    v_p1 = self->private_impl.c_decode_ac_refine[0].v_p1;
+    v_nv = self->private_impl.c_decode_ac_refine[0].v_nv;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_k = 0;
+    v_r = 0;
+    v_s = 0;
+    v_z = 0;
+    v_p1 = (((int32_t)(1)) << self->private_impl.f_al);
+    v_nv = 0;
+    v_k = self->private_impl.f_ss;
+    if (self->private_impl.f_eob_run == 0) {
+      while (v_k <= self->private_impl.f_se) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+        status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_jpeg__decoder__decode_huffman(self, a_ta + 4);
+        if (self->private_impl.f_bad_huffman) {
+          status = WUFFS_JPEG__ERROR_BAD_JPEG_HUFFMAN_CODE;
+          goto exit;
+        }
+        v_r = (self->private_impl.f_symbol >> 4);
+        v_s = (self->private_impl.f_symbol & 15);
+        v_nv = 0;
+        if (v_s != 0) {
+          if (v_s != 1) {
+            status = WUFFS_JPEG__ERROR_BAD_JPEG_COEFFICIENT;
+            goto exit;
+          }
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+          status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+          if (status) {
+            goto suspend;
+          }
+          wuffs_jpeg__decoder__take_bits(self, 1);
+          if (self->private_impl.f_taken != 0) {
+            v_nv = v_p1;
+          } else {
+            v_nv = (0 - v_p1);
+          }
+        } else if (v_r != 15) {
+          self->private_impl.f_eob_run = (((uint32_t)(1)) << v_r);
+          if (v_r > 0) {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+            status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+            if (status) {
+              goto suspend;
+            }
+            wuffs_jpeg__decoder__take_bits(self, v_r);
+            self->private_impl.f_eob_run =
+                ((((uint32_t)(1)) << v_r) + self->private_impl.f_taken);
+          }
+          goto label_0_break;
+        }
+        while (v_k <= self->private_impl.f_se) {
+          v_z = ((uint32_t)(wuffs_jpeg__zigzag[v_k]));
+          if (self->private_impl.f_block[v_z] != 0) {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+            status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+            if (status) {
+              goto suspend;
+            }
+            wuffs_jpeg__decoder__take_bits(self, 1);
+            if (self->private_impl.f_taken != 0) {
+              wuffs_jpeg__decoder__refine(self, v_z);
+            }
+          } else {
+            if (v_r == 0) {
+              goto label_1_break;
+            }
+            v_r -= 1;
+          }
+          v_k += 1;
+        }
+      label_1_break:;
+        if (v_k > self->private_impl.f_se) {
+          goto label_0_break;
+        }
+        if (v_nv != 0) {
+          self->private_impl.f_block[wuffs_jpeg__zigzag[v_k]] = v_nv;
+        }
+        v_k += 1;
+      }
+    label_0_break:;
+    }
+    if (self->private_impl.f_eob_run > 0) {
+      self->private_impl.f_eob_run -= 1;
+      while (v_k <= self->private_impl.f_se) {
+        v_z = ((uint32_t)(wuffs_jpeg__zigzag[v_k]));
+        if (self->private_impl.f_block[v_z] != 0) {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+          status = wuffs_jpeg__decoder__fill_bits(self, a_src);
+          if (status) {
+            goto suspend;
+          }
+          wuffs_jpeg__decoder__take_bits(self, 1);
+          if (self->private_impl.f_taken != 0) {
+            wuffs_jpeg__decoder__refine(self, v_z);
+          }
+        }
+        v_k += 1;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_ac_refine[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_ac_refine[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_ac_refine[0].v_k = v_k;
+  self->private_impl.c_decode_ac_refine[0].v_r = v_r;
+  self->private_impl.c_decode_ac_refine[0].v_s = v_s;
+  self->private_impl.c_decode_ac_refine[0].v_z = v_z;
+  self->private_impl.c_decode_ac_refine[0].v_p1 = v_p1;
+  self->private_impl.c_decode_ac_refine[0].v_nv = v_nv;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static void wuffs_jpeg__decoder__refine(wuffs_jpeg__decoder* self,
+                                        uint32_t a_i) {
+  int32_t v_v;
+  int32_t v_p1;
+
+  v_v = self->private_impl.f_block[a_i];
+  v_p1 = (((int32_t)(1)) << self->private_impl.f_al);
+  if ((((uint32_t)((v_v + 65536))) & ((uint32_t)(v_p1))) == 0) {
+    if (v_v >= 0) {
+      wuffs_jpeg__decoder__set_coefficient(self, a_i, v_v + v_p1);
+    } else {
+      wuffs_jpeg__decoder__set_coefficient(self, a_i, v_v - v_p1);
+    }
+  }
+}
+
+static void wuffs_jpeg__decoder__set_coefficient(wuffs_jpeg__decoder* self,
+                                                 uint32_t a_i,
+                                                 int32_t a_v) {
+  if (a_v < -32768) {
+    self->private_impl.f_block[a_i] = -32768;
+  } else if (a_v > 32767) {
+    self->private_impl.f_block[a_i] = 32767;
+  } else {
+    self->private_impl.f_block[a_i] = a_v;
+  }
+}
+
+static void wuffs_jpeg__decoder__set_dc_pred(wuffs_jpeg__decoder* self,
+                                             uint32_t a_c,
+                                             int32_t a_v) {
+  if (a_v < -32768) {
+    self->private_impl.f_dc_pred[a_c] = -32768;
+  } else if (a_v > 32767) {
+    self->private_impl.f_dc_pred[a_c] = 32767;
+  } else {
+    self->private_impl.f_dc_pred[a_c] = a_v;
+  }
+}
+
+static void wuffs_jpeg__decoder__load_block(wuffs_jpeg__decoder* self,
+                                            wuffs_base__slice_u8 a_workbuf,
+                                            uint64_t a_offset) {
+  uint32_t v_i;
+  uint32_t v_x;
+
+  v_i = 0;
+  v_x = 0;
+  if (a_offset <= ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_wbuf, .len = 128}),
+        wuffs_base__slice_u8__subslice_i(a_workbuf, a_offset));
+  }
+  while (v_i < 64) {
+    v_x = (((uint32_t)(self->private_impl.f_wbuf[2 * v_i])) |
+           (((uint32_t)(self->private_impl.f_wbuf[(2 * v_i) + 1])) << 8));
+    if (v_x >= 32768) {
+      self->private_impl.f_block[v_i] = (((int32_t)(v_x)) - 65536);
+    } else {
+      self->private_impl.f_block[v_i] = ((int32_t)(v_x));
+    }
+    v_i += 1;
+  }
+}
+
+static void wuffs_jpeg__decoder__store_block(wuffs_jpeg__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset) {
+  uint32_t v_i;
+  uint32_t v_x;
+
+  v_i = 0;
+  v_x = 0;
+  while (v_i < 64) {
+    v_x = (((uint32_t)((self->private_impl.f_block[v_i] + 65536))) & 65535);
+    self->private_impl.f_wbuf[2 * v_i] = ((uint8_t)((v_x & 255)));
+    self->private_impl.f_wbuf[(2 * v_i) + 1] = ((uint8_t)((v_x >> 8)));
+    v_i += 1;
+  }
+  if (a_offset <= ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(a_workbuf, a_offset),
+        ((wuffs_base__slice_u8){.ptr = self->private_impl.f_wbuf, .len = 128}));
+  }
+}
+
+static wuffs_jpeg__status wuffs_jpeg__decoder__fill_bits(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_jpeg__status status = WUFFS_JPEG__STATUS_OK;
+
+  uint8_t v_c;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_fill_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_fill_bits[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    while (self->private_impl.f_n_bits < 16) {
+      v_c = 0;
+      if (self->private_impl.f_marker == 0) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_0 = *b_rptr_src++;
+          v_c = t_0;
+        }
+        if (v_c == 255) {
+          {
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint8_t t_1 = *b_rptr_src++;
+            v_c = t_1;
+          }
+          while (v_c == 255) {
+            {
+              WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint8_t t_2 = *b_rptr_src++;
+              v_c = t_2;
+            }
+          }
+          if (v_c == 0) {
+            v_c = 255;
+          } else {
+            self->private_impl.f_marker = v_c;
+            v_c = 0;
+          }
+        }
+      }
+      self->private_impl.f_bits =
+          (((self->private_impl.f_bits & 65535) << 8) | ((uint32_t)(v_c)));
+      self->private_impl.f_n_bits = ((self->private_impl.f_n_bits & 15) + 8);
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_bits[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_bits[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_JPEG__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_JPEG__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_jpeg__decoder__decode_huffman(wuffs_jpeg__decoder* self,
+                                                uint32_t a_t) {
+  uint32_t v_n;
+  uint32_t v_b;
+  uint32_t v_e;
+  uint32_t v_l;
+  uint32_t v_j;
+  uint32_t v_x;
+
+  v_n = self->private_impl.f_n_bits;
+  v_b = 0;
+  v_e = 0;
+  v_l = 0;
+  v_j = 0;
+  v_x = 0;
+  if (v_n < 16) {
+    self->private_impl.f_bad_huffman = true;
+    return;
+  }
+  v_b = ((self->private_impl.f_bits >> (v_n - 16)) & 65535);
+  v_e = ((uint32_t)(self->private_impl.f_huff_lut[a_t][v_b >> 8]));
+  if (v_e != 0) {
+    v_l = ((v_e >> 8) & 15);
+    self->private_impl.f_symbol = (v_e & 255);
+  } else {
+    v_j = 9;
+    while (v_j <= 16) {
+      v_x = (v_b >> (16 - v_j));
+      if (v_x < self->private_impl.f_huff_end[a_t][v_j]) {
+        if (v_x < self->private_impl.f_huff_min[a_t][v_j]) {
+          goto label_0_break;
+        }
+        self->private_impl.f_symbol =
+            ((uint32_t)(self->private_impl.f_huff_values
+                            [a_t]
+                            [(self->private_impl.f_huff_valptr[a_t][v_j] +
+                              (v_x - self->private_impl.f_huff_min[a_t][v_j])) &
+                             255]));
+        v_l = v_j;
+        goto label_0_break;
+      }
+      v_j += 1;
+    }
+  label_0_break:;
+    if (v_l == 0) {
+      self->private_impl.f_bad_huffman = true;
+      return;
+    }
+  }
+  if (v_n < v_l) {
+    self->private_impl.f_bad_huffman = true;
+    return;
+  }
+  v_n -= v_l;
+  self->private_impl.f_n_bits = v_n;
+  self->private_impl.f_bits &= ((((uint32_t)(1)) << v_n) - 1);
+}
+
+static void wuffs_jpeg__decoder__take_bits(wuffs_jpeg__decoder* self,
+                                           uint32_t a_n) {
+  uint32_t v_nb;
+
+  v_nb = self->private_impl.f_n_bits;
+  if (v_nb < a_n) {
+    self->private_impl.f_taken = 0;
+    return;
+  }
+  v_nb -= a_n;
+  self->private_impl.f_taken =
+      ((self->private_impl.f_bits >> v_nb) & ((((uint32_t)(1)) << a_n) - 1));
+  self->private_impl.f_n_bits = v_nb;
+  self->private_impl.f_bits &= ((((uint32_t)(1)) << v_nb) - 1);
+}
+
+static void wuffs_jpeg__decoder__receive_extend(wuffs_jpeg__decoder* self,
+                                                uint32_t a_s) {
+  uint32_t v_x;
+
+  v_x = 0;
+  wuffs_jpeg__decoder__take_bits(self, a_s);
+  v_x = self->private_impl.f_taken;
+  if (v_x < ((((uint32_t)(1)) << a_s) >> 1)) {
+    self->private_impl.f_value =
+        ((((int32_t)(v_x)) - (((int32_t)(1)) << a_s)) + 1);
+  } else {
+    self->private_impl.f_value = ((int32_t)(v_x));
+  }
+}
+
+static void wuffs_jpeg__decoder__render_progressive(
+    wuffs_jpeg__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint32_t v_c;
+  uint32_t v_n_x;
+  uint32_t v_n_y;
+  uint32_t v_bx;
+  uint32_t v_by;
+  uint32_t v_sc;
+  uint32_t v_y;
+
+  v_c = 0;
+  v_n_x = 0;
+  v_n_y = 0;
+  v_bx = 0;
+  v_by = 0;
+  v_sc = 0;
+  v_y = 0;
+  while (v_c < self->private_impl.f_num_components) {
+    v_sc = v_c;
+    v_n_x = ((self->private_impl.f_plane_w[v_sc] + 7) / 8);
+    v_n_y = ((self->private_impl.f_plane_h[v_sc] + 7) / 8);
+    v_by = 0;
+    while (v_by < v_n_y) {
+      v_y = v_by;
+      v_bx = 0;
+      while (v_bx < v_n_x) {
+        wuffs_jpeg__decoder__load_block(
+            self, a_workbuf,
+            self->private_impl.f_workbuf_offset[v_sc] +
+                (((((uint64_t)(v_y)) *
+                   ((uint64_t)(self->private_impl.f_blocks_w[v_sc]))) +
+                  ((uint64_t)(v_bx))) *
+                 128));
+        wuffs_jpeg__decoder__idct(self, a_dst, v_sc, v_bx, v_y);
+        v_bx += 1;
+      }
+      v_by = (((uint32_t)(v_y)) + 1);
+    }
+    v_c = (((uint32_t)(v_sc)) + 1);
+  }
+}
+
+static void wuffs_jpeg__decoder__idct(wuffs_jpeg__decoder* self,
+                                      wuffs_base__slice_u8 a_dst,
+                                      uint32_t a_c,
+                                      uint32_t a_bx,
+                                      uint32_t a_by) {
+  uint32_t v_i;
+  uint32_t v_q;
+  int64_t v_x;
+  uint64_t v_y;
+  uint64_t v_pw;
+  uint64_t v_ph;
+  uint64_t v_x0;
+  uint64_t v_y0;
+  uint64_t v_n;
+  uint64_t v_o;
+  uint64_t v_i0;
+  uint64_t v_i1;
+
+  v_i = 0;
+  v_q = self->private_impl.f_comp_q[a_c];
+  v_x = 0;
+  v_y = 0;
+  v_pw = ((uint64_t)(self->private_impl.f_plane_w[a_c]));
+  v_ph = ((uint64_t)(self->private_impl.f_plane_h[a_c]));
+  v_x0 = (((uint64_t)(a_bx)) * 8);
+  v_y0 = (((uint64_t)(a_by)) * 8);
+  v_n = 0;
+  v_o = 0;
+  v_i0 = 0;
+  v_i1 = 0;
+  while (v_i < 64) {
+    v_x = (((int64_t)(self->private_impl.f_block[v_i])) *
+           ((int64_t)(self->private_impl.f_quant[v_q][v_i])));
+    if (v_x < -32768) {
+      self->private_impl.f_coefficients[v_i] = -32768;
+    } else if (v_x > 32767) {
+      self->private_impl.f_coefficients[v_i] = 32767;
+    } else {
+      self->private_impl.f_coefficients[v_i] = ((int32_t)(v_x));
+    }
+    v_i += 1;
+  }
+  v_i = 0;
+  while (v_i < 8) {
+    wuffs_jpeg__decoder__idct_column(self, v_i);
+    v_i += 1;
+  }
+  v_i = 0;
+  while (v_i < 8) {
+    wuffs_jpeg__decoder__idct_row(self, v_i);
+    v_i += 1;
+  }
+  if ((v_pw <= v_x0) || (v_ph <= v_y0)) {
+    return;
+  }
+  v_n = 8;
+  if ((v_pw - v_x0) < 8) {
+    v_n = (v_pw - v_x0);
+  }
+  while (v_y < 8) {
+    if ((v_y0 + v_y) >= v_ph) {
+      goto label_0_break;
+    }
+    v_o =
+        (self->private_impl.f_plane_offset[a_c] + ((v_y0 + v_y) * v_pw) + v_x0);
+    v_i0 = (8 * v_y);
+    v_i1 = (v_i0 + v_n);
+    if ((v_o <= ((uint64_t)(a_dst.len))) && (v_i0 <= v_i1)) {
+      wuffs_base__slice_u8__copy_from_slice(
+          wuffs_base__slice_u8__subslice_i(a_dst, v_o),
+          wuffs_base__slice_u8__subslice_ij(
+              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_pixels,
+                                      .len = 64}),
+              v_i0, v_i1));
+    }
+    v_y += 1;
+  }
+label_0_break:;
+}
+
+static void wuffs_jpeg__decoder__idct_column(wuffs_jpeg__decoder* self,
+                                             uint32_t a_x) {
+  int64_t v_a0;
+  int64_t v_a1;
+  int64_t v_a2;
+  int64_t v_a3;
+  int64_t v_a4;
+  int64_t v_a5;
+  int64_t v_a6;
+  int64_t v_a7;
+  int64_t v_e1;
+  int64_t v_e2;
+  int64_t v_e3;
+  int64_t v_e0;
+  int64_t v_e4;
+  int64_t v_t10;
+  int64_t v_t13;
+  int64_t v_t11;
+  int64_t v_t12;
+  int64_t v_z1;
+  int64_t v_z2;
+  int64_t v_z3;
+  int64_t v_z4;
+  int64_t v_z5;
+  int64_t v_z3b;
+  int64_t v_z4b;
+  int64_t v_z1b;
+  int64_t v_z2b;
+  int64_t v_o0;
+  int64_t v_o1;
+  int64_t v_o2;
+  int64_t v_o3;
+
+  v_a0 = ((int64_t)(self->private_impl.f_coefficients[0 + a_x]));
+  v_a1 = ((int64_t)(self->private_impl.f_coefficients[8 + a_x]));
+  v_a2 = ((int64_t)(self->private_impl.f_coefficients[16 + a_x]));
+  v_a3 = ((int64_t)(self->private_impl.f_coefficients[24 + a_x]));
+  v_a4 = ((int64_t)(self->private_impl.f_coefficients[32 + a_x]));
+  v_a5 = ((int64_t)(self->private_impl.f_coefficients[40 + a_x]));
+  v_a6 = ((int64_t)(self->private_impl.f_coefficients[48 + a_x]));
+  v_a7 = ((int64_t)(self->private_impl.f_coefficients[56 + a_x]));
+  v_e1 = ((v_a2 + v_a6) * 4433);
+  v_e2 = (v_e1 - (v_a6 * 15137));
+  v_e3 = (v_e1 + (v_a2 * 6270));
+  v_e0 = ((v_a0 + v_a4) * 8192);
+  v_e4 = ((v_a0 - v_a4) * 8192);
+  v_t10 = (v_e0 + v_e3);
+  v_t13 = (v_e0 - v_e3);
+  v_t11 = (v_e4 + v_e2);
+  v_t12 = (v_e4 - v_e2);
+  v_z1 = (v_a7 + v_a1);
+  v_z2 = (v_a5 + v_a3);
+  v_z3 = (v_a7 + v_a3);
+  v_z4 = (v_a5 + v_a1);
+  v_z5 = ((v_z3 + v_z4) * 9633);
+  v_z3b = ((v_z3 * -16069) + v_z5);
+  v_z4b = ((v_z4 * -3196) + v_z5);
+  v_z1b = (v_z1 * -7373);
+  v_z2b = (v_z2 * -20995);
+  v_o0 = (((v_a7 * 2446) + v_z1b) + v_z3b);
+  v_o1 = (((v_a5 * 16819) + v_z2b) + v_z4b);
+  v_o2 = (((v_a3 * 25172) + v_z2b) + v_z3b);
+  v_o3 = (((v_a1 * 12299) + v_z1b) + v_z4b);
+  self->private_impl.f_ws[0 + a_x] = (((v_t10 + v_o3) + 1024) >> 11);
+  self->private_impl.f_ws[56 + a_x] = (((v_t10 - v_o3) + 1024) >> 11);
+  self->private_impl.f_ws[8 + a_x] = (((v_t11 + v_o2) + 1024) >> 11);
+  self->private_impl.f_ws[48 + a_x] = (((v_t11 - v_o2) + 1024) >> 11);
+  self->private_impl.f_ws[16 + a_x] = (((v_t12 + v_o1) + 1024) >> 11);
+  self->private_impl.f_ws[40 + a_x] = (((v_t12 - v_o1) + 1024) >> 11);
+  self->private_impl.f_ws[24 + a_x] = (((v_t13 + v_o0) + 1024) >> 11);
+  self->private_impl.f_ws[32 + a_x] = (((v_t13 - v_o0) + 1024) >> 11);
+}
+
+static void wuffs_jpeg__decoder__idct_row(wuffs_jpeg__decoder* self,
+                                          uint32_t a_y) {
+  uint32_t v_r;
+  int64_t v_a0;
+  int64_t v_a1;
+  int64_t v_a2;
+  int64_t v_a3;
+  int64_t v_a4;
+  int64_t v_a5;
+  int64_t v_a6;
+  int64_t v_a7;
+  int64_t v_e1;
+  int64_t v_e2;
+  int64_t v_e3;
+  int64_t v_e0;
+  int64_t v_e4;
+  int64_t v_t10;
+  int64_t v_t13;
+  int64_t v_t11;
+  int64_t v_t12;
+  int64_t v_z1;
+  int64_t v_z2;
+  int64_t v_z3;
+  int64_t v_z4;
+  int64_t v_z5;
+  int64_t v_z3b;
+  int64_t v_z4b;
+  int64_t v_z1b;
+  int64_t v_z2b;
+  int64_t v_o0;
+  int64_t v_o1;
+  int64_t v_o2;
+  int64_t v_o3;
+
+  v_r = (8 * a_y);
+  v_a0 = self->private_impl.f_ws[v_r + 0];
+  v_a1 = self->private_impl.f_ws[v_r + 1];
+  v_a2 = self->private_impl.f_ws[v_r + 2];
+  v_a3 = self->private_impl.f_ws[v_r + 3];
+  v_a4 = self->private_impl.f_ws[v_r + 4];
+  v_a5 = self->private_impl.f_ws[v_r + 5];
+  v_a6 = self->private_impl.f_ws[v_r + 6];
+  v_a7 = self->private_impl.f_ws[v_r + 7];
+  v_e1 = ((v_a2 + v_a6) * 4433);
+  v_e2 = (v_e1 - (v_a6 * 15137));
+  v_e3 = (v_e1 + (v_a2 * 6270));
+  v_e0 = ((v_a0 + v_a4) * 8192);
+  v_e4 = ((v_a0 - v_a4) * 8192);
+  v_t10 = (v_e0 + v_e3);
+  v_t13 = (v_e0 - v_e3);
+  v_t11 = (v_e4 + v_e2);
+  v_t12 = (v_e4 - v_e2);
+  v_z1 = (v_a7 + v_a1);
+  v_z2 = (v_a5 + v_a3);
+  v_z3 = (v_a7 + v_a3);
+  v_z4 = (v_a5 + v_a1);
+  v_z5 = ((v_z3 + v_z4) * 9633);
+  v_z3b = ((v_z3 * -16069) + v_z5);
+  v_z4b = ((v_z4 * -3196) + v_z5);
+  v_z1b = (v_z1 * -7373);
+  v_z2b = (v_z2 * -20995);
+  v_o0 = (((v_a7 * 2446) + v_z1b) + v_z3b);
+  v_o1 = (((v_a5 * 16819) + v_z2b) + v_z4b);
+  v_o2 = (((v_a3 * 25172) + v_z2b) + v_z3b);
+  v_o3 = (((v_a1 * 12299) + v_z1b) + v_z4b);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 0,
+                                 ((v_t10 + v_o3) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 7,
+                                 ((v_t10 - v_o3) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 1,
+                                 ((v_t11 + v_o2) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 6,
+                                 ((v_t11 - v_o2) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 2,
+                                 ((v_t12 + v_o1) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 5,
+                                 ((v_t12 - v_o1) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 3,
+                                 ((v_t13 + v_o0) + 131072) >> 18);
+  wuffs_jpeg__decoder__put_pixel(self, v_r + 4,
+                                 ((v_t13 - v_o0) + 131072) >> 18);
+}
+
+static void wuffs_jpeg__decoder__put_pixel(wuffs_jpeg__decoder* self,
+                                           uint32_t a_i,
+                                           int64_t a_v) {
+  if (a_v < -128) {
+    self->private_impl.f_pixels[a_i] = 0;
+  } else if (a_v > 127) {
+    self->private_impl.f_pixels[a_i] = 255;
+  } else {
+    self->private_impl.f_pixels[a_i] = ((uint8_t)((a_v + 128)));
+  }
+}
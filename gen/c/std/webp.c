@@ -0,0 +1,3900 @@
+#ifndef WUFFS_WEBP_H
+#define WUFFS_WEBP_H
+
+// Code generated by wuffs-c. DO NOT EDIT.
+
+#ifndef WUFFS_BASE_HEADER_H
+#define WUFFS_BASE_HEADER_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A freestanding build, with WUFFS_CONFIG__FREESTANDING defined, needs only
+// these three headers, which even a freestanding C implementation provides.
+// Otherwise, the generated code also uses <string.h>'s memcpy, memmove and
+// memset.
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#ifndef WUFFS_CONFIG__FREESTANDING
+#include <string.h>
+#endif
+
+// Wuffs requires a word size of at least 32 bits because it assumes that
+// converting a u32 to usize will never overflow. For example, the size of a
+// decoded image is often represented, explicitly or implicitly in an image
+// file, as a u32, and it is convenient to compare that to a buffer size.
+//
+// Similarly, the word size is at most 64 bits because it assumes that
+// converting a usize to u64 will never overflow.
+//
+// __WORDSIZE comes from glibc's <stdint.h>, which a freestanding build does
+// not use, so fall back to the compiler-defined __SIZEOF_SIZE_T__.
+#if defined(__WORDSIZE)
+#if __WORDSIZE < 32
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __WORDSIZE > 64
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#elif defined(__SIZEOF_SIZE_T__)
+#if __SIZEOF_SIZE_T__ < 4
+#error "Wuffs requires a word size of at least 32 bits"
+#elif __SIZEOF_SIZE_T__ > 8
+#error "Wuffs requires a word size of at most 64 bits"
+#endif
+#endif
+
+// WUFFS_VERSION is the major.minor version number as a uint32. The major
+// number is the high 16 bits. The minor number is the low 16 bits.
+//
+// The intention is to bump the version number at least on every API / ABI
+// backwards incompatible change.
+//
+// For now, the API and ABI are simply unstable and can change at any time.
+//
+// TODO: don't hard code this in base-header.h.
+#define WUFFS_VERSION (0x00001)
+
+// ---------------- I/O
+
+// wuffs_base__slice_u8 is a 1-dimensional buffer (a pointer and length).
+//
+// A value with all fields NULL or zero is a valid, empty slice.
+typedef struct {
+  uint8_t* ptr;
+  size_t len;
+} wuffs_base__slice_u8;
+
+// wuffs_base__buf1 is a 1-dimensional buffer (a pointer and length), plus
+// additional indexes into that buffer, plus an opened / closed flag.
+//
+// A value with all fields NULL or zero is a valid, empty buffer.
+typedef struct {
+  uint8_t* ptr;  // Pointer.
+  size_t len;    // Length.
+  size_t wi;     // Write index. Invariant: wi <= len.
+  size_t ri;     // Read  index. Invariant: ri <= wi.
+  bool closed;   // No further writes are expected.
+} wuffs_base__buf1;
+
+// wuffs_base__limit1 provides a limited view of a 1-dimensional byte stream:
+// its first N bytes. That N can be greater than a buffer's current read or
+// write capacity. N decreases naturally over time as bytes are read from or
+// written to the stream.
+//
+// A value with all fields NULL or zero is a valid, unlimited view.
+typedef struct wuffs_base__limit1 {
+  uint64_t* ptr_to_len;             // Pointer to N.
+  struct wuffs_base__limit1* next;  // Linked list of limits.
+} wuffs_base__limit1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__reader1;
+
+typedef struct {
+  // TODO: move buf into private_impl? As it is, it looks like users can modify
+  // the buf field to point to a different buffer, which can turn the limit and
+  // mark fields into dangling pointers.
+  wuffs_base__buf1* buf;
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    wuffs_base__limit1 limit;
+    uint8_t* mark;
+  } private_impl;
+} wuffs_base__writer1;
+
+// ---------------- Images
+
+// WUFFS_BASE__PIXEL_FORMAT__ETC are how a pixbuf's pixels are laid out in
+// memory. Most are packed, one pixel after another:
+//  - INDEXED is 1 byte per pixel, a palette index.
+//  - RGBA is 4 bytes per pixel, in R, G, B, A order, non-premultiplied.
+//  - BGRA is 4 bytes per pixel, in B, G, R, A order, non-premultiplied.
+//  - RGB565 is 2 bytes per pixel, a little-endian uint16_t whose high 5 bits
+//    are R, middle 6 bits are G and low 5 bits are B.
+//  - Y is 1 byte per pixel, a luma (gray) value.
+//
+// Others are planar, one plane after another, each plane holding one byte per
+// sample, one sample after another:
+//  - YCBCR is three planes, Y, Cb and Cr, as per JFIF (JPEG). The Cb and Cr
+//    planes may be chroma subsampled, as per the image config's sampling
+//    factors.
+#define WUFFS_BASE__PIXEL_FORMAT__INDEXED 0
+#define WUFFS_BASE__PIXEL_FORMAT__RGBA 1
+#define WUFFS_BASE__PIXEL_FORMAT__BGRA 2
+#define WUFFS_BASE__PIXEL_FORMAT__RGB565 3
+#define WUFFS_BASE__PIXEL_FORMAT__Y 4
+#define WUFFS_BASE__PIXEL_FORMAT__YCBCR 5
+
+// wuffs_base__pixel_format__bytes_per_pixel returns the number of bytes per
+// pixel of a WUFFS_BASE__PIXEL_FORMAT__ETC value, or 0 if it is not one. For
+// planar pixel formats, it is the number of bytes per sample in each plane.
+static inline uint32_t wuffs_base__pixel_format__bytes_per_pixel(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+      return 2;
+  }
+  return 0;
+}
+
+// wuffs_base__pixel_format__num_planes returns the number of planes of a
+// WUFFS_BASE__PIXEL_FORMAT__ETC value, which is 1 for packed pixel formats,
+// or 0 if it is not one.
+static inline uint32_t wuffs_base__pixel_format__num_planes(
+    uint32_t pixel_format) {
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__INDEXED:
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565:
+    case WUFFS_BASE__PIXEL_FORMAT__Y:
+      return 1;
+    case WUFFS_BASE__PIXEL_FORMAT__YCBCR:
+      return 3;
+  }
+  return 0;
+}
+
+#define WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES 4
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t w;
+    uint32_t h;
+    uint32_t pixfmt;
+    // sampling[p]'s high and low 4 bits are the p'th plane's horizontal and
+    // vertical sampling factors, each in the range [1, 4]. A plane whose
+    // factors are the maximum over all planes has one sample per pixel.
+    uint8_t sampling[WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES];
+  } private_impl;
+} wuffs_base__image_config;
+
+static inline void wuffs_base__image_config__invalidate(
+    wuffs_base__image_config* c) {
+  if (c) {
+    *c = ((wuffs_base__image_config){});
+  }
+}
+
+static inline bool wuffs_base__image_config__valid(
+    wuffs_base__image_config* c) {
+  if (!c || !(c->private_impl.flags & 1)) {
+    return false;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t p;
+  for (p = 0; p < n; p++) {
+    uint32_t h = c->private_impl.sampling[p] >> 4;
+    uint32_t v = c->private_impl.sampling[p] & 15;
+    if ((h < 1) || (4 < h) || (v < 1) || (4 < v)) {
+      return false;
+    }
+  }
+  uint64_t wh = ((uint64_t)c->private_impl.w) * ((uint64_t)c->private_impl.h);
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  // Both w and h are less than 2^32, bpp is at most 4 and there are at most 4
+  // planes, none larger than w * h samples, so n * wh * bpp cannot overflow a
+  // uint64_t.
+  return (bpp > 0) && (n * wh * bpp <= ((uint64_t)SIZE_MAX));
+}
+
+static inline uint32_t wuffs_base__image_config__width(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__image_config__height(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__image_config__pixel_format returns the
+// WUFFS_BASE__PIXEL_FORMAT__ETC value that the decoder will write.
+static inline uint32_t wuffs_base__image_config__pixel_format(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c) ? c->private_impl.pixfmt : 0;
+}
+
+// wuffs_base__image_config__num_planes returns the number of planes in the
+// pixbuf, which is 1 for packed pixel formats.
+static inline uint32_t wuffs_base__image_config__num_planes(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__valid(c)
+             ? wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt)
+             : 0;
+}
+
+// wuffs_base__image_config__plane_width returns the width, in samples, of the
+// p'th plane. A chroma subsampled plane's width is the image's width times the
+// plane's horizontal sampling factor divided by the maximum horizontal
+// sampling factor, rounded up. It returns 0 if there is no such plane.
+static inline uint32_t wuffs_base__image_config__plane_width(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t h = c->private_impl.sampling[i] >> 4;
+    max = (max > h) ? max : h;
+  }
+  uint64_t h = c->private_impl.sampling[p] >> 4;
+  return (uint32_t)(((c->private_impl.w * h) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_height is like
+// wuffs_base__image_config__plane_width, but for the vertical dimension.
+static inline uint32_t wuffs_base__image_config__plane_height(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  if (p >= wuffs_base__image_config__num_planes(c)) {
+    return 0;
+  }
+  uint32_t n = wuffs_base__pixel_format__num_planes(c->private_impl.pixfmt);
+  uint32_t max = 1;
+  uint32_t i;
+  for (i = 0; i < n; i++) {
+    uint32_t v = c->private_impl.sampling[i] & 15;
+    max = (max > v) ? max : v;
+  }
+  uint64_t v = c->private_impl.sampling[p] & 15;
+  return (uint32_t)(((c->private_impl.h * v) + (max - 1)) / max);
+}
+
+// wuffs_base__image_config__plane_offset returns the offset, in bytes, of the
+// p'th plane in the pixbuf. The planes are consecutive, with no padding, and
+// each plane's rows are consecutive, with no padding.
+static inline size_t wuffs_base__image_config__plane_offset(
+    wuffs_base__image_config* c,
+    uint32_t p) {
+  uint32_t n = wuffs_base__image_config__num_planes(c);
+  if (p > n) {
+    return 0;
+  }
+  uint64_t bpp =
+      wuffs_base__pixel_format__bytes_per_pixel(c->private_impl.pixfmt);
+  uint64_t offset = 0;
+  uint32_t i;
+  for (i = 0; i < p; i++) {
+    offset += ((uint64_t)wuffs_base__image_config__plane_width(c, i)) *
+              ((uint64_t)wuffs_base__image_config__plane_height(c, i)) * bpp;
+  }
+  return (size_t)offset;
+}
+
+// wuffs_base__image_config__pixbuf_size returns the size, in bytes, of the
+// pixbuf, summed over all of its planes.
+static inline size_t wuffs_base__image_config__pixbuf_size(
+    wuffs_base__image_config* c) {
+  return wuffs_base__image_config__plane_offset(
+      c, wuffs_base__image_config__num_planes(c));
+}
+
+// wuffs_base__image_config__initialize sets the image config. An unknown
+// pixel_format, one that is not a WUFFS_BASE__PIXEL_FORMAT__ETC value, gives
+// an invalid image config. Every plane is given sampling factors of 1, so that
+// no plane is subsampled.
+static inline void wuffs_base__image_config__initialize(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.pixfmt = pixel_format;
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = 0x11;
+  }
+}
+
+// wuffs_base__image_config__initialize_planar is like
+// wuffs_base__image_config__initialize, but also sets the planes' sampling
+// factors. The (8 * p)'th to (8 * p + 7)'th bits of sampling, counting from
+// the least significant bit, are the p'th plane's factors, arranged like a
+// JPEG SOF marker's component sampling factors: the high 4 bits are the
+// horizontal factor and the low 4 bits are the vertical factor. Factors
+// outside the range [1, 4] give an invalid image config.
+static inline void wuffs_base__image_config__initialize_planar(
+    wuffs_base__image_config* c,
+    uint32_t width,
+    uint32_t height,
+    uint32_t pixel_format,
+    uint32_t sampling) {
+  if (!c) {
+    return;
+  }
+  wuffs_base__image_config__initialize(c, width, height, pixel_format);
+  int i;
+  for (i = 0; i < WUFFS_BASE__IMAGE_CONFIG__MAX_PLANES; i++) {
+    c->private_impl.sampling[i] = (uint8_t)(sampling >> (8 * i));
+  }
+}
+
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC are how to dispose of an animation
+// frame's pixels, after showing the frame and before showing the next one:
+//  - NONE means to leave them in place, so that the next frame is drawn on
+//    top of them.
+//  - RESTORE_BACKGROUND means to restore the frame's rect to the background.
+//  - RESTORE_PREVIOUS means to restore the frame's rect to what it was before
+//    the frame was drawn.
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE 0
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_BACKGROUND 1
+#define WUFFS_BASE__FRAME_CONFIG__DISPOSAL__RESTORE_PREVIOUS 2
+
+// wuffs_base__frame_config is the configuration of one frame of a (possibly
+// animated) image: its rect within the image, how long to show it and how to
+// dispose of it, and its palette of 256 (R, G, B) entries.
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so.
+  struct {
+    uint32_t flags;
+    uint32_t x;
+    uint32_t y;
+    uint32_t w;
+    uint32_t h;
+    uint32_t delay_ms;
+    uint8_t disposal;
+    uint8_t transparent_index;
+    uint8_t palette[3 * 256];
+  } private_impl;
+} wuffs_base__frame_config;
+
+static inline void wuffs_base__frame_config__invalidate(
+    wuffs_base__frame_config* c) {
+  if (c) {
+    *c = ((wuffs_base__frame_config){});
+  }
+}
+
+static inline bool wuffs_base__frame_config__valid(
+    wuffs_base__frame_config* c) {
+  return c && (c->private_impl.flags & 1);
+}
+
+static inline uint32_t wuffs_base__frame_config__x(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.x : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__y(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.y : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__width(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.w : 0;
+}
+
+static inline uint32_t wuffs_base__frame_config__height(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.h : 0;
+}
+
+// wuffs_base__frame_config__delay_ms returns how long to show the frame for,
+// in milliseconds, before showing the next frame.
+static inline uint32_t wuffs_base__frame_config__delay_ms(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.delay_ms : 0;
+}
+
+// wuffs_base__frame_config__disposal returns one of the
+// WUFFS_BASE__FRAME_CONFIG__DISPOSAL__ETC values.
+static inline uint8_t wuffs_base__frame_config__disposal(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) ? c->private_impl.disposal : 0;
+}
+
+static inline bool wuffs_base__frame_config__has_transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__valid(c) && (c->private_impl.flags & 2);
+}
+
+// wuffs_base__frame_config__transparent_index returns the palette index of
+// the transparent color. It is only meaningful if
+// wuffs_base__frame_config__has_transparent_index returns true.
+static inline uint8_t wuffs_base__frame_config__transparent_index(
+    wuffs_base__frame_config* c) {
+  return wuffs_base__frame_config__has_transparent_index(c)
+             ? c->private_impl.transparent_index
+             : 0;
+}
+
+// wuffs_base__frame_config__palette returns the frame's palette: 256 (R, G,
+// B) entries, 3 bytes each.
+static inline wuffs_base__slice_u8 wuffs_base__frame_config__palette(
+    wuffs_base__frame_config* c) {
+  if (!wuffs_base__frame_config__valid(c)) {
+    return ((wuffs_base__slice_u8){});
+  }
+  return ((wuffs_base__slice_u8){
+      .ptr = c->private_impl.palette,
+      .len = sizeof(c->private_impl.palette),
+  });
+}
+
+// wuffs_base__frame_config__initialize sets the frame config. A
+// transparent_index of 256 or more means that the frame has no transparent
+// color. At most 3 * 256 bytes of the palette are used, and if it is shorter,
+// the remaining entries are black.
+static inline void wuffs_base__frame_config__initialize(
+    wuffs_base__frame_config* c,
+    uint32_t x,
+    uint32_t y,
+    uint32_t width,
+    uint32_t height,
+    uint32_t delay_ms,
+    uint32_t disposal,
+    uint32_t transparent_index,
+    wuffs_base__slice_u8 palette) {
+  if (!c) {
+    return;
+  }
+  c->private_impl.flags = 1;
+  c->private_impl.x = x;
+  c->private_impl.y = y;
+  c->private_impl.w = width;
+  c->private_impl.h = height;
+  c->private_impl.delay_ms = delay_ms;
+  c->private_impl.disposal = (uint8_t)(disposal);
+  c->private_impl.transparent_index = 0;
+  if (transparent_index < 256) {
+    c->private_impl.flags |= 2;
+    c->private_impl.transparent_index = (uint8_t)(transparent_index);
+  }
+  size_t i;
+  for (i = 0; i < sizeof(c->private_impl.palette); i++) {
+    c->private_impl.palette[i] = (i < palette.len) ? palette.ptr[i] : 0;
+  }
+}
+
+#endif  // WUFFS_BASE_HEADER_H
+
+  // ---------------- Use Declarations
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// ---------------- Status Codes
+
+// Status codes are int32_t values:
+//  - the sign bit indicates a non-recoverable status code: an error
+//  - bits 10-30 hold the packageid: a namespace
+//  - bits 8-9 are reserved
+//  - bits 0-7 are a package-namespaced numeric code
+//
+// Do not manipulate these bits directly. Use the API functions such as
+// wuffs_webp__status__is_error instead.
+typedef int32_t wuffs_webp__status;
+
+#define wuffs_webp__packageid 1889273  // 0x001CD3F9
+
+#define WUFFS_WEBP__STATUS_OK 0                                   // 0x00000000
+#define WUFFS_WEBP__ERROR_BAD_WUFFS_VERSION -2147483647           // 0x80000001
+#define WUFFS_WEBP__ERROR_BAD_RECEIVER -2147483646                // 0x80000002
+#define WUFFS_WEBP__ERROR_BAD_ARGUMENT -2147483645                // 0x80000003
+#define WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED -2147483644      // 0x80000004
+#define WUFFS_WEBP__ERROR_INVALID_I_O_OPERATION -2147483643       // 0x80000005
+#define WUFFS_WEBP__ERROR_CLOSED_FOR_WRITES -2147483642           // 0x80000006
+#define WUFFS_WEBP__ERROR_UNEXPECTED_EOF -2147483641              // 0x80000007
+#define WUFFS_WEBP__SUSPENSION_SHORT_READ 8                       // 0x00000008
+#define WUFFS_WEBP__SUSPENSION_SHORT_WRITE 9                      // 0x00000009
+#define WUFFS_WEBP__ERROR_CANNOT_RETURN_A_SUSPENSION -2147483638  // 0x8000000A
+#define WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE -2147483637       // 0x8000000B
+#define WUFFS_WEBP__SUSPENSION_END_OF_DATA 12                     // 0x0000000C
+#define WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION 13                // 0x0000000D
+
+#define WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE -212868096        // 0xF34FE400
+#define WUFFS_WEBP__ERROR_BAD_WEBP_BACKWARD_REFERENCE -212868095  // 0xF34FE401
+#define WUFFS_WEBP__ERROR_BAD_WEBP_COLOR_CACHE -212868094         // 0xF34FE402
+#define WUFFS_WEBP__ERROR_BAD_WEBP_HEADER -212868093              // 0xF34FE403
+#define WUFFS_WEBP__ERROR_BAD_WEBP_SIGNATURE -212868092           // 0xF34FE404
+#define WUFFS_WEBP__ERROR_BAD_WEBP_TRANSFORM -212868091           // 0xF34FE405
+#define WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_HUFFMAN_TABLE_SIZE \
+  -212868090                                                       // 0xF34FE406
+#define WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_ANIMATION -212868089    // 0xF34FE407
+#define WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_COMPRESSION -212868088  // 0xF34FE408
+#define WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_PIXEL_FORMAT \
+  -212868087  // 0xF34FE409
+#define WUFFS_WEBP__ERROR_UNSUPPORTED_NUMBER_OF_WEBP_HUFFMAN_GROUPS \
+  -212868086  // 0xF34FE40A
+#define WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_GROUP \
+  -212868085  // 0xF34FE40B
+
+bool wuffs_webp__status__is_error(wuffs_webp__status s);
+
+const char* wuffs_webp__status__string(wuffs_webp__status s);
+
+// ---------------- Public Consts
+
+// ---------------- Structs
+
+typedef struct {
+  // Do not access the private_impl's fields directly. There is no API/ABI
+  // compatibility or safety guarantee if you do so. Instead, use the
+  // wuffs_webp__decoder__etc functions.
+  //
+  // In C++, these fields would be "private", but C does not support that.
+  //
+  // It is a struct, not a struct*, so that it can be stack allocated.
+  struct {
+    wuffs_webp__status status;
+    uint32_t magic;
+
+    uint32_t f_width;
+    uint32_t f_height;
+    uint32_t f_pixel_format;
+    uint32_t f_bytes_per_pixel;
+    uint8_t f_call_sequence;
+    uint64_t f_workbuf_offset[5];
+    uint32_t f_chunk_length;
+    uint32_t f_bits;
+    uint32_t f_n_bits;
+    uint32_t f_n_transforms;
+    uint32_t f_transforms[4];
+    bool f_seen_transform[4];
+    uint32_t f_transform_width[4];
+    uint32_t f_transform_bits[4];
+    uint32_t f_color_indexing_width_bits;
+    uint32_t f_xsize;
+    uint32_t f_palette_size;
+    uint8_t f_palette[1024];
+    uint32_t f_cache_bits;
+    uint32_t f_cache[2048];
+    bool f_has_entropy;
+    uint32_t f_entropy_bits;
+    uint32_t f_entropy_width;
+    uint32_t f_n_groups;
+    uint16_t f_huffs[65536];
+    uint32_t f_huff_offsets[1281];
+    uint32_t f_huff_top;
+    uint8_t f_code_lengths[2328];
+    uint32_t f_symbol;
+    uint32_t f_taken;
+    bool f_bad_huffman;
+
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_fourcc;
+      uint32_t v_length;
+      uint8_t v_flags;
+      uint32_t v_canvas_width;
+      uint32_t v_canvas_height;
+      uint32_t v_w;
+      uint8_t v_c;
+      uint32_t v_v;
+      uint64_t scratch;
+    } c_decode_config[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_frame_config[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint32_t v_cb;
+      uint32_t v_b;
+      uint32_t v_h;
+      uint64_t v_m;
+      uint64_t v_i;
+      uint32_t v_x;
+      uint32_t v_p;
+    } c_decode_frame[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_t;
+      uint32_t v_b;
+      uint32_t v_wb;
+      uint32_t v_w;
+      uint32_t v_i;
+      uint64_t v_o;
+      uint32_t v_p;
+    } c_decode_transforms[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_cb;
+    } c_decode_color_cache_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+    } c_decode_sub_image[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_g;
+      uint32_t v_n;
+    } c_decode_huffman_groups[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint32_t v_i;
+      uint32_t v_s0;
+      uint32_t v_s1;
+      uint32_t v_n_lengths;
+      uint32_t v_max_symbol;
+      uint32_t v_nb;
+      uint32_t v_symbol;
+      uint8_t v_prev;
+      uint32_t v_c;
+      uint8_t v_rep_symbol;
+      uint32_t v_rep_count;
+    } c_decode_huffman_code[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint8_t v_c;
+    } c_fill_bits[1];
+    struct {
+      uint32_t coro_susp_point;
+      uint64_t v_n;
+      uint64_t v_w;
+      uint64_t v_i;
+      uint32_t v_x;
+      uint32_t v_y;
+      uint32_t v_g5;
+      uint32_t v_p;
+      uint32_t v_green;
+      uint32_t v_red;
+      uint32_t v_blue;
+      uint64_t v_length;
+      uint32_t v_dist;
+      uint64_t v_d;
+      uint32_t v_v;
+      uint64_t v_e;
+      uint64_t v_j;
+    } c_decode_pixels[1];
+  } private_impl;
+} wuffs_webp__decoder;
+
+// ---------------- Public Initializer Prototypes
+
+// wuffs_webp__decoder__initialize is an initializer function.
+//
+// It should be called before any other wuffs_webp__decoder__* function.
+//
+// Pass WUFFS_VERSION and 0 for wuffs_version and for_internal_use_only.
+void wuffs_webp__decoder__initialize(wuffs_webp__decoder* self,
+                                     uint32_t wuffs_version,
+                                     uint32_t for_internal_use_only);
+
+// ---------------- Public Function Prototypes
+
+void wuffs_webp__decoder__set_pixel_format(wuffs_webp__decoder* self,
+                                           uint32_t a_pixel_format);
+
+wuffs_webp__status wuffs_webp__decoder__decode_config(
+    wuffs_webp__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame_config(
+    wuffs_webp__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src);
+
+uint64_t wuffs_webp__decoder__workbuf_size(wuffs_webp__decoder* self);
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif  // WUFFS_WEBP_H
+
+// C HEADER ENDS HERE.
+
+#ifndef WUFFS_BASE_IMPL_H
+#define WUFFS_BASE_IMPL_H
+
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// wuffs_base__empty_struct is used when a Wuffs function returns an empty
+// struct. In C, if a function f returns void, you can't say "x = f()", but in
+// Wuffs, if a function g returns empty, you can say "y = g()".
+typedef struct {
+} wuffs_base__empty_struct;
+
+#define WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(x) (void)(x)
+
+// WUFFS_BASE__MAGIC is a magic number to check that initializers are called.
+// It's not foolproof, given C doesn't automatically zero memory before use,
+// but it should catch 99.99% of cases.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("wuffs").
+#define WUFFS_BASE__MAGIC (0x3CCB6C71U)
+
+// WUFFS_BASE__ALREADY_ZEROED is passed from a container struct's initializer
+// to a containee struct's initializer when the container has already zeroed
+// the containee's memory.
+//
+// Its (non-zero) value is arbitrary, based on md5sum("zeroed").
+#define WUFFS_BASE__ALREADY_ZEROED (0x68602EF1U)
+
+// Denote intentional fallthroughs for -Wimplicit-fallthrough.
+//
+// The order matters here. Clang also defines "__GNUC__".
+#if defined(__clang__) && __cplusplus >= 201103L
+#define WUFFS_BASE__FALLTHROUGH [[clang::fallthrough]]
+#elif !defined(__clang__) && defined(__GNUC__) && (__GNUC__ >= 7)
+#define WUFFS_BASE__FALLTHROUGH __attribute__((fallthrough))
+#else
+#define WUFFS_BASE__FALLTHROUGH
+#endif
+
+// Use switch cases for coroutine suspension points, similar to the technique
+// in https://www.chiark.greenend.org.uk/~sgtatham/coroutines.html
+//
+// We use trivial macros instead of an explicit assignment and case statement
+// so that clang-format doesn't get confused by the unusual "case"s.
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0 case 0:;
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT(n) \
+  coro_susp_point = n;                            \
+  WUFFS_BASE__FALLTHROUGH;                        \
+  case n:;
+
+#define WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(n) \
+  if (status < 0) {                                             \
+    goto exit;                                                  \
+  } else if (status == 0) {                                     \
+    goto ok;                                                    \
+  }                                                             \
+  coro_susp_point = n;                                          \
+  goto suspend;                                                 \
+  case n:;
+
+// Clang also defines "__GNUC__".
+#if defined(__GNUC__)
+#define WUFFS_BASE__LIKELY(expr) (__builtin_expect(!!(expr), 1))
+#define WUFFS_BASE__UNLIKELY(expr) (__builtin_expect(!!(expr), 0))
+#else
+#define WUFFS_BASE__LIKELY(expr) (expr)
+#define WUFFS_BASE__UNLIKELY(expr) (expr)
+#endif
+
+// Uncomment this #include for printf-debugging.
+// #include <stdio.h>
+
+// ---------------- Static Inline Functions
+//
+// The helpers below are functions, instead of macros, because their arguments
+// can be an expression that we shouldn't evaluate more than once.
+//
+// They are in base-impl.h and hence copy/pasted into every generated C file,
+// instead of being in some "base.c" file, since a design goal is that users of
+// the generated C code can often just #include a single .c file, such as
+// "gif.c", without having to additionally include or otherwise build and link
+// a "base.c" file.
+//
+// They are static, so that linking multiple wuffs .o files won't complain about
+// duplicate function definitions.
+//
+// They are explicitly marked inline, even if modern compilers don't use the
+// inline attribute to guide optimizations such as inlining, to avoid the
+// -Wunused-function warning, and we like to compile with -Wall -Werror.
+
+// The generated code calls wuffs_base__memcpy, wuffs_base__memmove and
+// wuffs_base__memset instead of calling <string.h>'s functions directly. When
+// WUFFS_CONFIG__FREESTANDING is defined, such as by "wuffs gen -freestanding",
+// they are simple loops, so that the code needs no C library and can be
+// compiled with "-ffreestanding -nostdlib". Otherwise, they are <string.h>'s
+// (typically well optimized) functions.
+#ifdef WUFFS_CONFIG__FREESTANDING
+
+static inline void* wuffs_base__memcpy(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  for (; n > 0; n--) {
+    *d++ = *s++;
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memmove(void* dst, const void* src, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  const uint8_t* s = (const uint8_t*)(src);
+  if (d <= s) {
+    for (; n > 0; n--) {
+      *d++ = *s++;
+    }
+  } else {
+    for (d += n, s += n; n > 0; n--) {
+      *--d = *--s;
+    }
+  }
+  return dst;
+}
+
+static inline void* wuffs_base__memset(void* dst, int c, size_t n) {
+  uint8_t* d = (uint8_t*)(dst);
+  for (; n > 0; n--) {
+    *d++ = (uint8_t)(c);
+  }
+  return dst;
+}
+
+#else
+
+#define wuffs_base__memcpy memcpy
+#define wuffs_base__memmove memmove
+#define wuffs_base__memset memset
+
+#endif  // WUFFS_CONFIG__FREESTANDING
+
+static inline uint16_t wuffs_base__load_u16be(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 8) | ((uint16_t)(p[1]) << 0);
+}
+
+static inline uint16_t wuffs_base__load_u16le(uint8_t* p) {
+  return ((uint16_t)(p[0]) << 0) | ((uint16_t)(p[1]) << 8);
+}
+
+static inline uint32_t wuffs_base__load_u32be(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 24) | ((uint32_t)(p[1]) << 16) |
+         ((uint32_t)(p[2]) << 8) | ((uint32_t)(p[3]) << 0);
+}
+
+static inline uint32_t wuffs_base__load_u32le(uint8_t* p) {
+  return ((uint32_t)(p[0]) << 0) | ((uint32_t)(p[1]) << 8) |
+         ((uint32_t)(p[2]) << 16) | ((uint32_t)(p[3]) << 24);
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_i(
+    wuffs_base__slice_u8 s,
+    uint64_t i) {
+  if ((i <= SIZE_MAX) && (i <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = s.len - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_j(
+    wuffs_base__slice_u8 s,
+    uint64_t j) {
+  if ((j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){.ptr = s.ptr, .len = j});
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__subslice_ij(
+    wuffs_base__slice_u8 s,
+    uint64_t i,
+    uint64_t j) {
+  if ((i <= j) && (j <= SIZE_MAX) && (j <= s.len)) {
+    return ((wuffs_base__slice_u8){
+        .ptr = s.ptr + i,
+        .len = j - i,
+    });
+  }
+  return ((wuffs_base__slice_u8){});
+}
+
+// wuffs_base__slice_u8__prefix returns up to the first up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8__prefix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__suffix returns up to the last up_to bytes of s.
+static inline wuffs_base__slice_u8 wuffs_base__slice_u8_suffix(
+    wuffs_base__slice_u8 s,
+    uint64_t up_to) {
+  if ((uint64_t)(s.len) > up_to) {
+    s.ptr += (uint64_t)(s.len) - up_to;
+    s.len = up_to;
+  }
+  return s;
+}
+
+// wuffs_base__slice_u8__copy_from_slice calls memmove(dst.ptr, src.ptr,
+// length), via wuffs_base__memmove, where length is the minimum of dst.len
+// and src.len.
+//
+// Passing a wuffs_base__slice_u8 with all fields NULL or zero (a valid, empty
+// slice) is valid and results in a no-op.
+static inline uint64_t wuffs_base__slice_u8__copy_from_slice(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src) {
+  size_t length = dst.len < src.len ? dst.len : src.len;
+  if (length > 0) {
+    wuffs_base__memmove(dst.ptr, src.ptr, length);
+  }
+  return length;
+}
+
+// wuffs_base__slice_u8__swizzle_from_palette converts the palette indexes in
+// src to pixels in dst, whose layout is a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+// It converts n pixels, where n is the minimum of src.len and the number of
+// whole pixels that fit in dst, and returns n. An unknown pixel_format
+// converts no pixels.
+//
+// The palette has up to 256 (R, G, B) entries, 3 bytes each. If it is
+// shorter, the remaining entries are black.
+//
+// For the INDEXED pixel format, the indexes are copied as is. For the other
+// pixel formats, a pixel whose index is transparent_index is left unchanged,
+// so that it shows what was drawn there before. A transparent_index of 256 or
+// more means that there is no transparent color.
+static inline uint64_t wuffs_base__slice_u8__swizzle_from_palette(
+    wuffs_base__slice_u8 dst,
+    wuffs_base__slice_u8 src,
+    wuffs_base__slice_u8 palette,
+    uint32_t transparent_index,
+    uint32_t pixel_format) {
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  if (bpp == 0) {
+    return 0;
+  }
+  size_t n = dst.len / bpp;
+  if (n > src.len) {
+    n = src.len;
+  }
+  if (pixel_format == WUFFS_BASE__PIXEL_FORMAT__INDEXED) {
+    if (n > 0) {
+      wuffs_base__memmove(dst.ptr, src.ptr, n);
+    }
+    return n;
+  }
+
+  uint8_t* d = dst.ptr;
+  size_t i;
+  for (i = 0; i < n; i++, d += bpp) {
+    uint32_t index = src.ptr[i];
+    if (index == transparent_index) {
+      continue;
+    }
+    uint8_t r = 0;
+    uint8_t g = 0;
+    uint8_t b = 0;
+    if ((3 * (size_t)(index)) + 2 < palette.len) {
+      r = palette.ptr[3 * index + 0];
+      g = palette.ptr[3 * index + 1];
+      b = palette.ptr[3 * index + 2];
+    }
+    switch (pixel_format) {
+      case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+        d[0] = r;
+        d[1] = g;
+        d[2] = b;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+        d[0] = b;
+        d[1] = g;
+        d[2] = r;
+        d[3] = 0xFF;
+        break;
+      case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+        uint16_t x =
+            (uint16_t)(((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                       ((uint16_t)(b >> 3) << 0));
+        d[0] = (uint8_t)(x >> 0);
+        d[1] = (uint8_t)(x >> 8);
+        break;
+      }
+    }
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_history32(
+    uint8_t** ptr_ptr,
+    uint8_t* start,  // May be NULL, meaning an unmarked writer1.
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  if (!start || !distance) {
+    return 0;
+  }
+  uint8_t* ptr = *ptr_ptr;
+  if ((size_t)(ptr - start) < (size_t)(distance)) {
+    return 0;
+  }
+  start = ptr - distance;
+  size_t n = end - ptr;
+  if ((size_t)(length) > n) {
+    length = n;
+  } else {
+    n = length;
+  }
+  // TODO: unrolling by 3 seems best for the std/deflate benchmarks, but that
+  // is mostly because 3 is the minimum length for the deflate format. This
+  // function implementation shouldn't overfit to that one format. Perhaps the
+  // copy_from_history32 Wuffs method should also take an unroll hint argument,
+  // and the cgen can look if that argument is the constant expression '3'.
+  //
+  // See also wuffs_base__writer1__copy_from_history32__bco below.
+  //
+  // Alternatively, or additionally, have a sloppy_copy_from_history32 method
+  // that copies 8 bytes at a time, possibly writing more than length bytes?
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+// wuffs_base__writer1__copy_from_history32__bco is a Bounds Check Optimized
+// version of the wuffs_base__writer1__copy_from_history32 function above. The
+// caller needs to prove that:
+//  - start    != NULL
+//  - distance >  0
+//  - distance <= (*ptr_ptr - start)
+//  - length   <= (end      - *ptr_ptr)
+static inline uint32_t wuffs_base__writer1__copy_from_history32__bco(
+    uint8_t** ptr_ptr,
+    uint8_t* start,
+    uint8_t* end,
+    uint32_t distance,
+    uint32_t length) {
+  uint8_t* ptr = *ptr_ptr;
+  start = ptr - distance;
+  uint32_t n = length;
+  for (; n >= 3; n -= 3) {
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+    *ptr++ = *start++;
+  }
+  for (; n; n--) {
+    *ptr++ = *start++;
+  }
+  *ptr_ptr = ptr;
+  return length;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_reader32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    uint8_t** ptr_rptr,
+    uint8_t* rend,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = length;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  uint8_t* rptr = *ptr_rptr;
+  if (n > rend - rptr) {
+    n = rend - rptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, rptr, n);
+    *ptr_wptr += n;
+    *ptr_rptr += n;
+  }
+  return n;
+}
+
+static inline uint64_t wuffs_base__writer1__copy_from_slice(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+static inline uint32_t wuffs_base__writer1__copy_from_slice32(
+    uint8_t** ptr_wptr,
+    uint8_t* wend,
+    wuffs_base__slice_u8 src,
+    uint32_t length) {
+  uint8_t* wptr = *ptr_wptr;
+  size_t n = src.len;
+  if (n > length) {
+    n = length;
+  }
+  if (n > wend - wptr) {
+    n = wend - wptr;
+  }
+  if (n > 0) {
+    wuffs_base__memmove(wptr, src.ptr, n);
+    *ptr_wptr += n;
+  }
+  return n;
+}
+
+// Note that the *__limit and *__mark methods are private (in base-impl.h) not
+// public (in base-header.h). We assume that, at the boundary between user code
+// and Wuffs code, the reader1 and writer1's private_impl fields (including
+// limit and mark) are NULL. Otherwise, some internal assumptions break down.
+// For example, limits could be represented as pointers, even though
+// conceptually they are counts, but that pointer-to-count correspondence
+// becomes invalid if a buffer is re-used (e.g. on resuming a coroutine).
+//
+// Admittedly, some of the Wuffs test code calls these methods, but that test
+// code is still Wuffs code, not user code. Other Wuffs test code modifies
+// private_impl fields directly.
+
+static inline wuffs_base__reader1 wuffs_base__reader1__limit(
+    wuffs_base__reader1* o,
+    uint64_t* ptr_to_len) {
+  wuffs_base__reader1 ret = *o;
+  ret.private_impl.limit.ptr_to_len = ptr_to_len;
+  ret.private_impl.limit.next = &o->private_impl.limit;
+  return ret;
+}
+
+static inline wuffs_base__empty_struct wuffs_base__reader1__mark(
+    wuffs_base__reader1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+// TODO: static inline wuffs_base__writer1 wuffs_base__writer1__limit()
+
+static inline wuffs_base__empty_struct wuffs_base__writer1__mark(
+    wuffs_base__writer1* o,
+    uint8_t* mark) {
+  o->private_impl.mark = mark;
+  return ((wuffs_base__empty_struct){});
+}
+
+static const char* wuffs_base__status__strings[14] = {
+    "ok",
+    "bad wuffs version",
+    "bad receiver",
+    "bad argument",
+    "initializer not called",
+    "invalid I/O operation",
+    "closed for writes",
+    "unexpected EOF",
+    "short read",
+    "short write",
+    "cannot return a suspension",
+    "invalid call sequence",
+    "end of data",
+    "end of animation",
+};
+
+#endif  // WUFFS_BASE_IMPL_H
+
+// ---------------- Status Codes Implementations
+
+bool wuffs_webp__status__is_error(wuffs_webp__status s) {
+  return s < 0;
+}
+
+const char* wuffs_webp__status__strings[12] = {
+    "webp: bad WebP Huffman code",
+    "webp: bad WebP backward reference",
+    "webp: bad WebP color cache",
+    "webp: bad WebP header",
+    "webp: bad WebP signature",
+    "webp: bad WebP transform",
+    "webp: unsupported WebP Huffman table size",
+    "webp: unsupported WebP animation",
+    "webp: unsupported WebP compression",
+    "webp: unsupported WebP pixel format",
+    "webp: unsupported number of WebP Huffman groups",
+    "webp: internal error: inconsistent Huffman group",
+};
+
+const char* wuffs_webp__status__string(wuffs_webp__status s) {
+  const char** a = NULL;
+  uint32_t n = 0;
+  switch ((s >> 10) & 0x1FFFFF) {
+    case 0:
+      a = wuffs_base__status__strings;
+      n = 14;
+      break;
+    case wuffs_webp__packageid:
+      a = wuffs_webp__status__strings;
+      n = 12;
+      break;
+  }
+  uint32_t i = s & 0xFF;
+  return i < n ? a[i] : "unknown status";
+}
+
+// ---------------- Private Consts
+
+static const uint8_t wuffs_webp__code_order[19] = {
+    17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+};
+
+static const uint8_t wuffs_webp__distance_map[120] = {
+    24,  7,   23,  25,  40, 6,   39,  41,  22,  26,  38,  42,  56,  5,   55,
+    57,  21,  27,  54,  58, 37,  43,  72,  4,   71,  73,  20,  28,  53,  59,
+    70,  74,  36,  44,  88, 69,  75,  52,  60,  3,   87,  89,  19,  29,  86,
+    90,  35,  45,  68,  76, 85,  91,  51,  61,  104, 2,   103, 105, 18,  30,
+    102, 106, 34,  46,  84, 92,  67,  77,  101, 107, 50,  62,  120, 1,   119,
+    121, 83,  93,  17,  31, 100, 108, 66,  78,  118, 122, 33,  47,  117, 123,
+    49,  63,  99,  109, 82, 94,  0,   116, 124, 65,  79,  16,  32,  98,  110,
+    48,  115, 125, 81,  95, 64,  114, 126, 97,  111, 80,  113, 127, 96,  112,
+};
+
+// ---------------- Private Initializer Prototypes
+
+// ---------------- Private Function Prototypes
+
+static void wuffs_webp__decoder__compute_workbuf_offsets(
+    wuffs_webp__decoder* self);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_transforms(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_color_cache_bits(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_sub_image(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint64_t a_offset,
+    uint32_t a_width,
+    uint32_t a_height);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_huffman_groups(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_huffman_code(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_k,
+    uint32_t a_n_symbols);
+
+static wuffs_webp__status wuffs_webp__decoder__build_huffman(
+    wuffs_webp__decoder* self,
+    uint32_t a_k,
+    uint32_t a_n_symbols);
+
+static void wuffs_webp__decoder__decode_huffman(wuffs_webp__decoder* self,
+                                                uint32_t a_k);
+
+static wuffs_webp__status wuffs_webp__decoder__fill_bits(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src);
+
+static void wuffs_webp__decoder__take_bits(wuffs_webp__decoder* self,
+                                           uint32_t a_n);
+
+static wuffs_webp__status wuffs_webp__decoder__decode_pixels(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint64_t a_offset,
+    uint32_t a_width,
+    uint32_t a_height);
+
+static void wuffs_webp__decoder__decode_prefix(wuffs_webp__decoder* self,
+                                               uint32_t a_p);
+
+static void wuffs_webp__decoder__insert_color_cache(wuffs_webp__decoder* self,
+                                                    uint32_t a_pixel);
+
+static uint32_t wuffs_webp__decoder__load_pixel(wuffs_webp__decoder* self,
+                                                wuffs_base__slice_u8 a_workbuf,
+                                                uint64_t a_offset);
+
+static void wuffs_webp__decoder__store_pixel(wuffs_webp__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset,
+                                             uint32_t a_pixel);
+
+static void wuffs_webp__decoder__apply_inverse_transforms(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_webp__decoder__inverse_predictor(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf);
+
+static uint32_t wuffs_webp__decoder__predict(wuffs_webp__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset,
+                                             uint32_t a_w,
+                                             uint32_t a_mode,
+                                             uint32_t a_l,
+                                             uint32_t a_t);
+
+static void wuffs_webp__decoder__inverse_color(wuffs_webp__decoder* self,
+                                               wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_webp__decoder__inverse_subtract_green(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_webp__decoder__inverse_color_indexing(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf);
+
+static void wuffs_webp__decoder__convert_pixels(wuffs_webp__decoder* self,
+                                                wuffs_base__slice_u8 a_dst,
+                                                wuffs_base__slice_u8 a_workbuf);
+
+static uint32_t wuffs_webp__decoder__add_pixels(wuffs_webp__decoder* self,
+                                                uint32_t a_a,
+                                                uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__average2(wuffs_webp__decoder* self,
+                                              uint32_t a_a,
+                                              uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__select(wuffs_webp__decoder* self,
+                                            uint32_t a_l,
+                                            uint32_t a_t,
+                                            uint32_t a_tl);
+
+static uint32_t wuffs_webp__decoder__channel_distance(wuffs_webp__decoder* self,
+                                                      uint32_t a_a,
+                                                      uint32_t a_b,
+                                                      uint32_t a_s);
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_full(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_c);
+
+static uint32_t wuffs_webp__decoder__clamp_full_channel(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_c,
+    uint32_t a_s);
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_half(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b);
+
+static uint32_t wuffs_webp__decoder__clamp_half_channel(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_s);
+
+static int32_t wuffs_webp__decoder__to_i8(wuffs_webp__decoder* self,
+                                          uint32_t a_x);
+
+static uint32_t wuffs_webp__decoder__color_transform_add(
+    wuffs_webp__decoder* self,
+    uint32_t a_c,
+    int32_t a_t,
+    int32_t a_x);
+
+// ---------------- Initializer Implementations
+
+void wuffs_webp__decoder__initialize(wuffs_webp__decoder* self,
+                                     uint32_t wuffs_version,
+                                     uint32_t for_internal_use_only) {
+  if (!self) {
+    return;
+  }
+  if (wuffs_version != WUFFS_VERSION) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_WUFFS_VERSION;
+    return;
+  }
+  if (for_internal_use_only != WUFFS_BASE__ALREADY_ZEROED) {
+    wuffs_base__memset(self, 0, sizeof(*self));
+  }
+  self->private_impl.magic = WUFFS_BASE__MAGIC;
+  self->private_impl.f_pixel_format = 1;
+  self->private_impl.f_bytes_per_pixel = 4;
+}
+
+// ---------------- Function Implementations
+
+void wuffs_webp__decoder__set_pixel_format(wuffs_webp__decoder* self,
+                                           uint32_t a_pixel_format) {
+  if (!self) {
+    return;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return;
+  }
+  if (a_pixel_format > 3) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+    return;
+  }
+
+  if (self->private_impl.f_call_sequence != 0) {
+    return;
+  }
+  self->private_impl.f_pixel_format = a_pixel_format;
+  if (a_pixel_format == 0) {
+    self->private_impl.f_bytes_per_pixel = 1;
+  } else if (a_pixel_format == 3) {
+    self->private_impl.f_bytes_per_pixel = 2;
+  } else {
+    self->private_impl.f_bytes_per_pixel = 4;
+  }
+}
+
+wuffs_webp__status wuffs_webp__decoder__decode_config(
+    wuffs_webp__decoder* self,
+    wuffs_base__image_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_fourcc;
+  uint32_t v_length;
+  uint8_t v_flags;
+  uint32_t v_canvas_width;
+  uint32_t v_canvas_height;
+  uint32_t v_w;
+  uint8_t v_c;
+  uint32_t v_v;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_config[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_fourcc = self->private_impl.c_decode_config[0].v_fourcc;
+    v_length = self->private_impl.c_decode_config[0].v_length;
+    v_flags = self->private_impl.c_decode_config[0].v_flags;
+    v_canvas_width = self->private_impl.c_decode_config[0].v_canvas_width;
+    v_canvas_height = self->private_impl.c_decode_config[0].v_canvas_height;
+    v_w = self->private_impl.c_decode_config[0].v_w;
+    v_c = self->private_impl.c_decode_config[0].v_c;
+    v_v = self->private_impl.c_decode_config[0].v_v;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_fourcc = 0;
+    v_length = 0;
+    v_flags = 0;
+    v_canvas_width = 0;
+    v_canvas_height = 0;
+    v_w = 0;
+    v_c = 0;
+    v_v = 0;
+    if (self->private_impl.f_call_sequence >= 1) {
+      status = WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    uint32_t t_1;
+    if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+      t_1 = wuffs_base__load_u32le(b_rptr_src);
+      b_rptr_src += 4;
+    } else {
+      self->private_impl.c_decode_config[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint32_t t_0 = self->private_impl.c_decode_config[0].scratch >> 56;
+        self->private_impl.c_decode_config[0].scratch <<= 8;
+        self->private_impl.c_decode_config[0].scratch >>= 8;
+        self->private_impl.c_decode_config[0].scratch |=
+            ((uint64_t)(*b_rptr_src++)) << t_0;
+        if (t_0 == 24) {
+          t_1 = self->private_impl.c_decode_config[0].scratch;
+          break;
+        }
+        t_0 += 8;
+        self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_0))
+                                                         << 56;
+      }
+    }
+    if (t_1 != 1179011410) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_SIGNATURE;
+      goto exit;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    self->private_impl.c_decode_config[0].scratch = 4;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    if (self->private_impl.c_decode_config[0].scratch >
+        b_rend_src - b_rptr_src) {
+      self->private_impl.c_decode_config[0].scratch -= b_rend_src - b_rptr_src;
+      b_rptr_src = b_rend_src;
+      goto short_read_src;
+    }
+    b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    uint32_t t_3;
+    if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+      t_3 = wuffs_base__load_u32le(b_rptr_src);
+      b_rptr_src += 4;
+    } else {
+      self->private_impl.c_decode_config[0].scratch = 0;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      while (true) {
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint32_t t_2 = self->private_impl.c_decode_config[0].scratch >> 56;
+        self->private_impl.c_decode_config[0].scratch <<= 8;
+        self->private_impl.c_decode_config[0].scratch >>= 8;
+        self->private_impl.c_decode_config[0].scratch |=
+            ((uint64_t)(*b_rptr_src++)) << t_2;
+        if (t_2 == 24) {
+          t_3 = self->private_impl.c_decode_config[0].scratch;
+          break;
+        }
+        t_2 += 8;
+        self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_2))
+                                                         << 56;
+      }
+    }
+    if (t_3 != 1346520407) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_SIGNATURE;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+      uint32_t t_5;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_5 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(8);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_4 = self->private_impl.c_decode_config[0].scratch >> 56;
+          self->private_impl.c_decode_config[0].scratch <<= 8;
+          self->private_impl.c_decode_config[0].scratch >>= 8;
+          self->private_impl.c_decode_config[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_4;
+          if (t_4 == 24) {
+            t_5 = self->private_impl.c_decode_config[0].scratch;
+            break;
+          }
+          t_4 += 8;
+          self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_4))
+                                                           << 56;
+        }
+      }
+      v_fourcc = t_5;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(9);
+      uint32_t t_7;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_7 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(10);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_6 = self->private_impl.c_decode_config[0].scratch >> 56;
+          self->private_impl.c_decode_config[0].scratch <<= 8;
+          self->private_impl.c_decode_config[0].scratch >>= 8;
+          self->private_impl.c_decode_config[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_6;
+          if (t_6 == 24) {
+            t_7 = self->private_impl.c_decode_config[0].scratch;
+            break;
+          }
+          t_6 += 8;
+          self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_6))
+                                                           << 56;
+        }
+      }
+      v_length = t_7;
+    }
+    if (v_fourcc == 1480085590) {
+      if (v_length < 10) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+        goto exit;
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(11);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_8 = *b_rptr_src++;
+        v_flags = t_8;
+      }
+      if ((v_flags & 2) != 0) {
+        status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_ANIMATION;
+        goto exit;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(12);
+      self->private_impl.c_decode_config[0].scratch = 3;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(13);
+      if (self->private_impl.c_decode_config[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(14);
+        uint16_t t_10;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+          t_10 = wuffs_base__load_u16le(b_rptr_src);
+          b_rptr_src += 2;
+        } else {
+          self->private_impl.c_decode_config[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(15);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_9 = self->private_impl.c_decode_config[0].scratch >> 56;
+            self->private_impl.c_decode_config[0].scratch <<= 8;
+            self->private_impl.c_decode_config[0].scratch >>= 8;
+            self->private_impl.c_decode_config[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_9;
+            if (t_9 == 8) {
+              t_10 = self->private_impl.c_decode_config[0].scratch;
+              break;
+            }
+            t_9 += 8;
+            self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_9))
+                                                             << 56;
+          }
+        }
+        v_w = ((uint32_t)(t_10));
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(16);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_11 = *b_rptr_src++;
+        v_w |= (((uint32_t)(t_11)) << 16);
+      }
+      v_canvas_width = (v_w + 1);
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(17);
+        uint16_t t_13;
+        if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 2)) {
+          t_13 = wuffs_base__load_u16le(b_rptr_src);
+          b_rptr_src += 2;
+        } else {
+          self->private_impl.c_decode_config[0].scratch = 0;
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(18);
+          while (true) {
+            if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+              goto short_read_src;
+            }
+            uint32_t t_12 = self->private_impl.c_decode_config[0].scratch >> 56;
+            self->private_impl.c_decode_config[0].scratch <<= 8;
+            self->private_impl.c_decode_config[0].scratch >>= 8;
+            self->private_impl.c_decode_config[0].scratch |=
+                ((uint64_t)(*b_rptr_src++)) << t_12;
+            if (t_12 == 8) {
+              t_13 = self->private_impl.c_decode_config[0].scratch;
+              break;
+            }
+            t_12 += 8;
+            self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_12))
+                                                             << 56;
+          }
+        }
+        v_w = ((uint32_t)(t_13));
+      }
+      {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(19);
+        if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+          goto short_read_src;
+        }
+        uint8_t t_14 = *b_rptr_src++;
+        v_w |= (((uint32_t)(t_14)) << 16);
+      }
+      v_canvas_height = (v_w + 1);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(20);
+      self->private_impl.c_decode_config[0].scratch = (v_length - 10);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(21);
+      if (self->private_impl.c_decode_config[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(22);
+      self->private_impl.c_decode_config[0].scratch = (v_length & 1);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(23);
+      if (self->private_impl.c_decode_config[0].scratch >
+          b_rend_src - b_rptr_src) {
+        self->private_impl.c_decode_config[0].scratch -=
+            b_rend_src - b_rptr_src;
+        b_rptr_src = b_rend_src;
+        goto short_read_src;
+      }
+      b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+      while (true) {
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(24);
+          uint32_t t_16;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_16 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_config[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(25);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_15 =
+                  self->private_impl.c_decode_config[0].scratch >> 56;
+              self->private_impl.c_decode_config[0].scratch <<= 8;
+              self->private_impl.c_decode_config[0].scratch >>= 8;
+              self->private_impl.c_decode_config[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_15;
+              if (t_15 == 24) {
+                t_16 = self->private_impl.c_decode_config[0].scratch;
+                break;
+              }
+              t_15 += 8;
+              self->private_impl.c_decode_config[0].scratch |=
+                  ((uint64_t)(t_15)) << 56;
+            }
+          }
+          v_fourcc = t_16;
+        }
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(26);
+          uint32_t t_18;
+          if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+            t_18 = wuffs_base__load_u32le(b_rptr_src);
+            b_rptr_src += 4;
+          } else {
+            self->private_impl.c_decode_config[0].scratch = 0;
+            WUFFS_BASE__COROUTINE_SUSPENSION_POINT(27);
+            while (true) {
+              if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+                goto short_read_src;
+              }
+              uint32_t t_17 =
+                  self->private_impl.c_decode_config[0].scratch >> 56;
+              self->private_impl.c_decode_config[0].scratch <<= 8;
+              self->private_impl.c_decode_config[0].scratch >>= 8;
+              self->private_impl.c_decode_config[0].scratch |=
+                  ((uint64_t)(*b_rptr_src++)) << t_17;
+              if (t_17 == 24) {
+                t_18 = self->private_impl.c_decode_config[0].scratch;
+                break;
+              }
+              t_17 += 8;
+              self->private_impl.c_decode_config[0].scratch |=
+                  ((uint64_t)(t_17)) << 56;
+            }
+          }
+          v_length = t_18;
+        }
+        if ((v_fourcc == 1278758998) || (v_fourcc == 540561494)) {
+          goto label_0_break;
+        }
+        if (v_fourcc == 1213221953) {
+          status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_COMPRESSION;
+          goto exit;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(28);
+        self->private_impl.c_decode_config[0].scratch = v_length;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(29);
+        if (self->private_impl.c_decode_config[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_config[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(30);
+        self->private_impl.c_decode_config[0].scratch = (v_length & 1);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(31);
+        if (self->private_impl.c_decode_config[0].scratch >
+            b_rend_src - b_rptr_src) {
+          self->private_impl.c_decode_config[0].scratch -=
+              b_rend_src - b_rptr_src;
+          b_rptr_src = b_rend_src;
+          goto short_read_src;
+        }
+        b_rptr_src += self->private_impl.c_decode_config[0].scratch;
+      }
+    label_0_break:;
+    }
+    if (v_fourcc == 540561494) {
+      status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_COMPRESSION;
+      goto exit;
+    } else if (v_fourcc != 1278758998) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+      goto exit;
+    }
+    if (v_length < 5) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(32);
+      if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+        goto short_read_src;
+      }
+      uint8_t t_19 = *b_rptr_src++;
+      v_c = t_19;
+    }
+    if (v_c != 47) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+      goto exit;
+    }
+    {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(33);
+      uint32_t t_21;
+      if (WUFFS_BASE__LIKELY(b_rend_src - b_rptr_src >= 4)) {
+        t_21 = wuffs_base__load_u32le(b_rptr_src);
+        b_rptr_src += 4;
+      } else {
+        self->private_impl.c_decode_config[0].scratch = 0;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(34);
+        while (true) {
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint32_t t_20 = self->private_impl.c_decode_config[0].scratch >> 56;
+          self->private_impl.c_decode_config[0].scratch <<= 8;
+          self->private_impl.c_decode_config[0].scratch >>= 8;
+          self->private_impl.c_decode_config[0].scratch |=
+              ((uint64_t)(*b_rptr_src++)) << t_20;
+          if (t_20 == 24) {
+            t_21 = self->private_impl.c_decode_config[0].scratch;
+            break;
+          }
+          t_20 += 8;
+          self->private_impl.c_decode_config[0].scratch |= ((uint64_t)(t_20))
+                                                           << 56;
+        }
+      }
+      v_v = t_21;
+    }
+    if ((v_v >> 29) != 0) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+      goto exit;
+    }
+    self->private_impl.f_width = ((v_v & 16383) + 1);
+    self->private_impl.f_height = (((v_v >> 14) & 16383) + 1);
+    self->private_impl.f_chunk_length = (v_length - 5);
+    if ((v_canvas_width != 0) &&
+        ((v_canvas_width != self->private_impl.f_width) ||
+         (v_canvas_height != self->private_impl.f_height))) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HEADER;
+      goto exit;
+    }
+    if (self->private_impl.f_pixel_format == 0) {
+      status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_PIXEL_FORMAT;
+      goto exit;
+    }
+    wuffs_webp__decoder__compute_workbuf_offsets(self);
+    wuffs_base__image_config__initialize(a_dst, self->private_impl.f_width,
+                                         self->private_impl.f_height,
+                                         self->private_impl.f_pixel_format);
+    self->private_impl.f_call_sequence = 1;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_config[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_config[0].v_fourcc = v_fourcc;
+  self->private_impl.c_decode_config[0].v_length = v_length;
+  self->private_impl.c_decode_config[0].v_flags = v_flags;
+  self->private_impl.c_decode_config[0].v_canvas_width = v_canvas_width;
+  self->private_impl.c_decode_config[0].v_canvas_height = v_canvas_height;
+  self->private_impl.c_decode_config[0].v_w = v_w;
+  self->private_impl.c_decode_config[0].v_c = v_c;
+  self->private_impl.c_decode_config[0].v_v = v_v;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  self->private_impl.status = status;
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_webp__decoder__compute_workbuf_offsets(
+    wuffs_webp__decoder* self) {
+  uint64_t v_n;
+  uint64_t v_m;
+
+  v_n = 0;
+  v_m = 0;
+  v_n = (((uint64_t)(self->private_impl.f_width)) *
+         ((uint64_t)(self->private_impl.f_height)));
+  v_m = (((((uint64_t)(self->private_impl.f_width)) + 3) >> 2) *
+         ((((uint64_t)(self->private_impl.f_height)) + 3) >> 2));
+  self->private_impl.f_workbuf_offset[0] = 0;
+  self->private_impl.f_workbuf_offset[1] = (4 * v_n);
+  self->private_impl.f_workbuf_offset[2] = (4 * (v_n + v_m));
+  self->private_impl.f_workbuf_offset[3] = (4 * (v_n + (2 * v_m)));
+  if (v_m < 256) {
+    v_m = 256;
+  }
+  self->private_impl.f_workbuf_offset[4] = (4 * (v_n + (3 * v_m)));
+}
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame_config(
+    wuffs_webp__decoder* self,
+    wuffs_base__frame_config* a_dst,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  if (!a_dst) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+    return WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+  }
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame_config[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+      }
+    } else if (self->private_impl.f_call_sequence != 1) {
+      status = WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    wuffs_base__frame_config__initialize(
+        a_dst, 0, 0, self->private_impl.f_width, self->private_impl.f_height, 0,
+        0, 256,
+        wuffs_base__slice_u8__subslice_j(
+            ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palette,
+                                    .len = 1024}),
+            0));
+    self->private_impl.f_call_sequence = 2;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame_config[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame_config[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+uint64_t wuffs_webp__decoder__workbuf_size(wuffs_webp__decoder* self) {
+  if (!self) {
+    return 0;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return 0;
+  }
+
+  return self->private_impl.f_workbuf_offset[4];
+}
+
+wuffs_webp__status wuffs_webp__decoder__decode_frame(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src) {
+  if (!self) {
+    return WUFFS_WEBP__ERROR_BAD_RECEIVER;
+  }
+  if (self->private_impl.magic != WUFFS_BASE__MAGIC) {
+    self->private_impl.status = WUFFS_WEBP__ERROR_INITIALIZER_NOT_CALLED;
+  }
+  if (self->private_impl.status < 0) {
+    return self->private_impl.status;
+  }
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint64_t v_n;
+  uint32_t v_cb;
+  uint32_t v_b;
+  uint32_t v_h;
+  uint64_t v_m;
+  uint64_t v_i;
+  uint32_t v_x;
+  uint32_t v_p;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_frame[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_decode_frame[0].v_n;
+    v_cb = self->private_impl.c_decode_frame[0].v_cb;
+    v_b = self->private_impl.c_decode_frame[0].v_b;
+    v_h = self->private_impl.c_decode_frame[0].v_h;
+    v_m = self->private_impl.c_decode_frame[0].v_m;
+    v_i = self->private_impl.c_decode_frame[0].v_i;
+    v_x = self->private_impl.c_decode_frame[0].v_x;
+    v_p = self->private_impl.c_decode_frame[0].v_p;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n = 0;
+    v_cb = 0;
+    v_b = 2;
+    v_h = 0;
+    v_m = 0;
+    v_i = 0;
+    v_x = 0;
+    v_p = 0;
+    v_n = (((uint64_t)(self->private_impl.f_width)) *
+           ((uint64_t)(self->private_impl.f_height)) *
+           ((uint64_t)(self->private_impl.f_bytes_per_pixel)));
+    if (((uint64_t)(a_dst.len)) < v_n) {
+      status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+      goto exit;
+    }
+    if (((uint64_t)(a_workbuf.len)) < self->private_impl.f_workbuf_offset[4]) {
+      status = WUFFS_WEBP__ERROR_BAD_ARGUMENT;
+      goto exit;
+    }
+    if (self->private_impl.f_call_sequence == 3) {
+      while (true) {
+        status = WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT_MAYBE_SUSPEND(1);
+      }
+    } else if ((self->private_impl.f_call_sequence != 1) &&
+               (self->private_impl.f_call_sequence != 2)) {
+      status = WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE;
+      goto exit;
+    }
+    self->private_impl.f_xsize = self->private_impl.f_width;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_webp__decoder__decode_transforms(self, a_workbuf, a_src);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_webp__decoder__decode_color_cache_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    v_cb = self->private_impl.f_cache_bits;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_webp__decoder__take_bits(self, 1);
+    self->private_impl.f_has_entropy = false;
+    self->private_impl.f_n_groups = 1;
+    if (self->private_impl.f_taken != 0) {
+      wuffs_webp__decoder__take_bits(self, 3);
+      v_b = ((self->private_impl.f_taken & 7) + 2);
+      self->private_impl.f_entropy_bits = v_b;
+      self->private_impl.f_entropy_width =
+          (((self->private_impl.f_xsize + (((uint32_t)(1)) << v_b)) - 1) >>
+           v_b);
+      v_h = (((self->private_impl.f_height + (((uint32_t)(1)) << v_b)) - 1) >>
+             v_b);
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_webp__decoder__decode_sub_image(
+          self, a_workbuf, a_src, self->private_impl.f_workbuf_offset[3],
+          self->private_impl.f_entropy_width, v_h);
+      if (status) {
+        goto suspend;
+      }
+      v_m = (((uint64_t)(self->private_impl.f_entropy_width)) *
+             ((uint64_t)(v_h)));
+      v_i = 0;
+      v_x = 0;
+      while (v_i < v_m) {
+        v_p = wuffs_webp__decoder__load_pixel(
+            self, a_workbuf,
+            self->private_impl.f_workbuf_offset[3] + (4 * v_i));
+        if (v_x < ((v_p >> 8) & 65535)) {
+          v_x = ((v_p >> 8) & 65535);
+        }
+        v_i += 1;
+      }
+      if (v_x >= 256) {
+        status = WUFFS_WEBP__ERROR_UNSUPPORTED_NUMBER_OF_WEBP_HUFFMAN_GROUPS;
+        goto exit;
+      }
+      self->private_impl.f_n_groups = (v_x + 1);
+      self->private_impl.f_has_entropy = true;
+    }
+    self->private_impl.f_cache_bits = v_cb;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+    status = wuffs_webp__decoder__decode_huffman_groups(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    status = wuffs_webp__decoder__decode_pixels(self, a_workbuf, a_src, 0,
+                                                self->private_impl.f_xsize,
+                                                self->private_impl.f_height);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_webp__decoder__apply_inverse_transforms(self, a_workbuf);
+    wuffs_webp__decoder__convert_pixels(self, a_dst, a_workbuf);
+    self->private_impl.f_call_sequence = 3;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_frame[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_frame[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_frame[0].v_n = v_n;
+  self->private_impl.c_decode_frame[0].v_cb = v_cb;
+  self->private_impl.c_decode_frame[0].v_b = v_b;
+  self->private_impl.c_decode_frame[0].v_h = v_h;
+  self->private_impl.c_decode_frame[0].v_m = v_m;
+  self->private_impl.c_decode_frame[0].v_i = v_i;
+  self->private_impl.c_decode_frame[0].v_x = v_x;
+  self->private_impl.c_decode_frame[0].v_p = v_p;
+
+  goto exit;
+exit:
+  self->private_impl.status = status;
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_transforms(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_t;
+  uint32_t v_b;
+  uint32_t v_wb;
+  uint32_t v_w;
+  uint32_t v_i;
+  uint64_t v_o;
+  uint32_t v_p;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_transforms[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_t = self->private_impl.c_decode_transforms[0].v_t;
+    v_b = self->private_impl.c_decode_transforms[0].v_b;
+    v_wb = self->private_impl.c_decode_transforms[0].v_wb;
+    v_w = self->private_impl.c_decode_transforms[0].v_w;
+    v_i = self->private_impl.c_decode_transforms[0].v_i;
+    v_o = self->private_impl.c_decode_transforms[0].v_o;
+    v_p = self->private_impl.c_decode_transforms[0].v_p;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_t = 0;
+    v_b = 2;
+    v_wb = 0;
+    v_w = 0;
+    v_i = 0;
+    v_o = 0;
+    v_p = 0;
+    self->private_impl.f_n_transforms = 0;
+    while (true) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_webp__decoder__take_bits(self, 1);
+      if (self->private_impl.f_taken == 0) {
+        goto label_0_break;
+      }
+      wuffs_webp__decoder__take_bits(self, 2);
+      v_t = (self->private_impl.f_taken & 3);
+      if (self->private_impl.f_seen_transform[v_t] ||
+          (self->private_impl.f_n_transforms >= 4)) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_TRANSFORM;
+        goto exit;
+      }
+      self->private_impl.f_seen_transform[v_t] = true;
+      self->private_impl.f_transforms[self->private_impl.f_n_transforms] = v_t;
+      self->private_impl.f_n_transforms += 1;
+      self->private_impl.f_transform_width[v_t] = self->private_impl.f_xsize;
+      if (v_t <= 1) {
+        wuffs_webp__decoder__take_bits(self, 3);
+        v_b = ((self->private_impl.f_taken & 7) + 2);
+        self->private_impl.f_transform_bits[v_t] = v_b;
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_webp__decoder__decode_sub_image(
+            self, a_workbuf, a_src,
+            self->private_impl.f_workbuf_offset[v_t + 1],
+            (((self->private_impl.f_xsize + (((uint32_t)(1)) << v_b)) - 1) >>
+             v_b),
+            (((self->private_impl.f_height + (((uint32_t)(1)) << v_b)) - 1) >>
+             v_b));
+        if (status) {
+          goto suspend;
+        }
+      } else if (v_t == 3) {
+        wuffs_webp__decoder__take_bits(self, 8);
+        self->private_impl.f_palette_size =
+            ((self->private_impl.f_taken & 255) + 1);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_webp__decoder__decode_sub_image(
+            self, a_workbuf, a_src, self->private_impl.f_workbuf_offset[3],
+            self->private_impl.f_palette_size, 1);
+        if (status) {
+          goto suspend;
+        }
+        v_p = 0;
+        v_i = 0;
+        while (v_i < 256) {
+          if (v_i < self->private_impl.f_palette_size) {
+            v_o = (self->private_impl.f_workbuf_offset[3] +
+                   (4 * ((uint64_t)(v_i))));
+            v_p = wuffs_webp__decoder__add_pixels(
+                self, v_p,
+                wuffs_webp__decoder__load_pixel(self, a_workbuf, v_o));
+          } else {
+            v_p = 0;
+          }
+          self->private_impl.f_palette[(4 * v_i) + 0] =
+              ((uint8_t)((v_p & 255)));
+          self->private_impl.f_palette[(4 * v_i) + 1] =
+              ((uint8_t)(((v_p >> 8) & 255)));
+          self->private_impl.f_palette[(4 * v_i) + 2] =
+              ((uint8_t)(((v_p >> 16) & 255)));
+          self->private_impl.f_palette[(4 * v_i) + 3] =
+              ((uint8_t)((v_p >> 24)));
+          v_i += 1;
+        }
+        if (self->private_impl.f_palette_size <= 2) {
+          v_wb = 3;
+        } else if (self->private_impl.f_palette_size <= 4) {
+          v_wb = 2;
+        } else if (self->private_impl.f_palette_size <= 16) {
+          v_wb = 1;
+        } else {
+          v_wb = 0;
+        }
+        self->private_impl.f_color_indexing_width_bits = v_wb;
+        v_w = self->private_impl.f_xsize;
+        if (v_w > 0) {
+          self->private_impl.f_xsize = (((v_w - 1) >> v_wb) + 1);
+        }
+      }
+    }
+  label_0_break:;
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_transforms[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_transforms[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_transforms[0].v_t = v_t;
+  self->private_impl.c_decode_transforms[0].v_b = v_b;
+  self->private_impl.c_decode_transforms[0].v_wb = v_wb;
+  self->private_impl.c_decode_transforms[0].v_w = v_w;
+  self->private_impl.c_decode_transforms[0].v_i = v_i;
+  self->private_impl.c_decode_transforms[0].v_o = v_o;
+  self->private_impl.c_decode_transforms[0].v_p = v_p;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_color_cache_bits(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_cb;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_color_cache_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_cb = self->private_impl.c_decode_color_cache_bits[0].v_cb;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_cb = 0;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_webp__decoder__take_bits(self, 1);
+    self->private_impl.f_cache_bits = 0;
+    if (self->private_impl.f_taken != 0) {
+      wuffs_webp__decoder__take_bits(self, 4);
+      v_cb = (self->private_impl.f_taken & 15);
+      if ((v_cb < 1) || (11 < v_cb)) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_COLOR_CACHE;
+        goto exit;
+      }
+      self->private_impl.f_cache_bits = v_cb;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_color_cache_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_color_cache_bits[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_decode_color_cache_bits[0].v_cb = v_cb;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_sub_image(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint64_t a_offset,
+    uint32_t a_width,
+    uint32_t a_height) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_sub_image[0].coro_susp_point;
+  if (coro_susp_point) {
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__decode_color_cache_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    self->private_impl.f_has_entropy = false;
+    self->private_impl.f_n_groups = 1;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+    status = wuffs_webp__decoder__decode_huffman_groups(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+    status = wuffs_webp__decoder__decode_pixels(self, a_workbuf, a_src,
+                                                a_offset, a_width, a_height);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_sub_image[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_sub_image[0].coro_susp_point = coro_susp_point;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_huffman_groups(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_g;
+  uint32_t v_n;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_huffman_groups[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_g = self->private_impl.c_decode_huffman_groups[0].v_g;
+    v_n = self->private_impl.c_decode_huffman_groups[0].v_n;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_g = 0;
+    v_n = 0;
+    self->private_impl.f_huff_top = 0;
+    v_n = 280;
+    if (self->private_impl.f_cache_bits > 0) {
+      v_n = (280 + (((uint32_t)(1)) << self->private_impl.f_cache_bits));
+    }
+    while (v_g < self->private_impl.f_n_groups) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__decode_huffman_code(self, a_src,
+                                                        ((5 * v_g) + 0), v_n);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_webp__decoder__decode_huffman_code(self, a_src,
+                                                        ((5 * v_g) + 1), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_webp__decoder__decode_huffman_code(self, a_src,
+                                                        ((5 * v_g) + 2), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+      status = wuffs_webp__decoder__decode_huffman_code(self, a_src,
+                                                        ((5 * v_g) + 3), 256);
+      if (status) {
+        goto suspend;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+      status = wuffs_webp__decoder__decode_huffman_code(self, a_src,
+                                                        ((5 * v_g) + 4), 40);
+      if (status) {
+        goto suspend;
+      }
+      v_g += 1;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_huffman_groups[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_huffman_groups[0].coro_susp_point =
+      coro_susp_point;
+  self->private_impl.c_decode_huffman_groups[0].v_g = v_g;
+  self->private_impl.c_decode_huffman_groups[0].v_n = v_n;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_huffman_code(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src,
+    uint32_t a_k,
+    uint32_t a_n_symbols) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_i;
+  uint32_t v_s0;
+  uint32_t v_s1;
+  uint32_t v_n_lengths;
+  uint32_t v_max_symbol;
+  uint32_t v_nb;
+  uint32_t v_symbol;
+  uint8_t v_prev;
+  uint32_t v_c;
+  uint8_t v_rep_symbol;
+  uint32_t v_rep_count;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_huffman_code[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_i = self->private_impl.c_decode_huffman_code[0].v_i;
+    v_s0 = self->private_impl.c_decode_huffman_code[0].v_s0;
+    v_s1 = self->private_impl.c_decode_huffman_code[0].v_s1;
+    v_n_lengths = self->private_impl.c_decode_huffman_code[0].v_n_lengths;
+    v_max_symbol = self->private_impl.c_decode_huffman_code[0].v_max_symbol;
+    v_nb = self->private_impl.c_decode_huffman_code[0].v_nb;
+    v_symbol = self->private_impl.c_decode_huffman_code[0].v_symbol;
+    v_prev = self->private_impl.c_decode_huffman_code[0].v_prev;
+    v_c = self->private_impl.c_decode_huffman_code[0].v_c;
+    v_rep_symbol = self->private_impl.c_decode_huffman_code[0].v_rep_symbol;
+    v_rep_count = self->private_impl.c_decode_huffman_code[0].v_rep_count;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_i = 0;
+    v_s0 = 0;
+    v_s1 = 0;
+    v_n_lengths = 0;
+    v_max_symbol = 0;
+    v_nb = 0;
+    v_symbol = 0;
+    v_prev = 0;
+    v_c = 0;
+    v_rep_symbol = 0;
+    v_rep_count = 0;
+    while (v_i < a_n_symbols) {
+      self->private_impl.f_code_lengths[v_i] = 0;
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_webp__decoder__take_bits(self, 1);
+    if (self->private_impl.f_taken != 0) {
+      wuffs_webp__decoder__take_bits(self, 1);
+      v_c = (self->private_impl.f_taken & 1);
+      wuffs_webp__decoder__take_bits(self, 1);
+      if (self->private_impl.f_taken == 0) {
+        wuffs_webp__decoder__take_bits(self, 1);
+      } else {
+        wuffs_webp__decoder__take_bits(self, 8);
+      }
+      v_s0 = (self->private_impl.f_taken & 255);
+      if (v_s0 >= a_n_symbols) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+      self->private_impl.f_code_lengths[v_s0] = 1;
+      if (v_c != 0) {
+        wuffs_webp__decoder__take_bits(self, 8);
+        v_s1 = (self->private_impl.f_taken & 255);
+        if (v_s1 >= a_n_symbols) {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+          goto exit;
+        }
+        self->private_impl.f_code_lengths[v_s1] = 1;
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+      status = wuffs_webp__decoder__build_huffman(self, a_k, a_n_symbols);
+      if (status) {
+        goto suspend;
+      }
+      status = WUFFS_WEBP__STATUS_OK;
+      goto ok;
+    }
+    wuffs_webp__decoder__take_bits(self, 4);
+    v_n_lengths = ((self->private_impl.f_taken & 15) + 4);
+    v_i = 0;
+    while (v_i < 19) {
+      self->private_impl.f_code_lengths[v_i] = 0;
+      v_i += 1;
+    }
+    v_i = 0;
+    while (v_i < v_n_lengths) {
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_webp__decoder__take_bits(self, 3);
+      self->private_impl.f_code_lengths[wuffs_webp__code_order[v_i]] =
+          ((uint8_t)((self->private_impl.f_taken & 7)));
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+    status = wuffs_webp__decoder__build_huffman(self, 1280, 19);
+    if (status) {
+      goto suspend;
+    }
+    v_i = 0;
+    while (v_i < a_n_symbols) {
+      self->private_impl.f_code_lengths[v_i] = 0;
+      v_i += 1;
+    }
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+    status = wuffs_webp__decoder__fill_bits(self, a_src);
+    if (status) {
+      goto suspend;
+    }
+    wuffs_webp__decoder__take_bits(self, 1);
+    v_max_symbol = a_n_symbols;
+    if (self->private_impl.f_taken != 0) {
+      wuffs_webp__decoder__take_bits(self, 3);
+      v_nb = (2 + (2 * (self->private_impl.f_taken & 7)));
+      wuffs_webp__decoder__take_bits(self, v_nb);
+      v_max_symbol = (2 + self->private_impl.f_taken);
+      if (v_max_symbol > a_n_symbols) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+    }
+    v_symbol = 0;
+    v_prev = 8;
+  label_0_continue:;
+    while (v_symbol < a_n_symbols) {
+      if (v_max_symbol == 0) {
+        goto label_0_break;
+      }
+      v_max_symbol -= 1;
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_webp__decoder__decode_huffman(self, 1280);
+      if (self->private_impl.f_bad_huffman) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_c = self->private_impl.f_symbol;
+      if (v_c < 16) {
+        self->private_impl.f_code_lengths[v_symbol] = ((uint8_t)(v_c));
+        if (v_c != 0) {
+          v_prev = ((uint8_t)(v_c));
+        }
+        v_symbol += 1;
+        goto label_0_continue;
+      }
+      if (v_c == 16) {
+        wuffs_webp__decoder__take_bits(self, 2);
+        v_rep_symbol = v_prev;
+        v_rep_count = (3 + (self->private_impl.f_taken & 3));
+      } else if (v_c == 17) {
+        wuffs_webp__decoder__take_bits(self, 3);
+        v_rep_symbol = 0;
+        v_rep_count = (3 + (self->private_impl.f_taken & 7));
+      } else {
+        wuffs_webp__decoder__take_bits(self, 7);
+        v_rep_symbol = 0;
+        v_rep_count = (11 + (self->private_impl.f_taken & 127));
+      }
+      while (v_rep_count > 0) {
+        if (v_symbol >= a_n_symbols) {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+          goto exit;
+        }
+        self->private_impl.f_code_lengths[v_symbol] = v_rep_symbol;
+        v_symbol += 1;
+        v_rep_count -= 1;
+      }
+    }
+  label_0_break:;
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+    status = wuffs_webp__decoder__build_huffman(self, a_k, a_n_symbols);
+    if (status) {
+      goto suspend;
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_huffman_code[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_huffman_code[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_huffman_code[0].v_i = v_i;
+  self->private_impl.c_decode_huffman_code[0].v_s0 = v_s0;
+  self->private_impl.c_decode_huffman_code[0].v_s1 = v_s1;
+  self->private_impl.c_decode_huffman_code[0].v_n_lengths = v_n_lengths;
+  self->private_impl.c_decode_huffman_code[0].v_max_symbol = v_max_symbol;
+  self->private_impl.c_decode_huffman_code[0].v_nb = v_nb;
+  self->private_impl.c_decode_huffman_code[0].v_symbol = v_symbol;
+  self->private_impl.c_decode_huffman_code[0].v_prev = v_prev;
+  self->private_impl.c_decode_huffman_code[0].v_c = v_c;
+  self->private_impl.c_decode_huffman_code[0].v_rep_symbol = v_rep_symbol;
+  self->private_impl.c_decode_huffman_code[0].v_rep_count = v_rep_count;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__build_huffman(
+    wuffs_webp__decoder* self,
+    uint32_t a_k,
+    uint32_t a_n_symbols) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint32_t v_counts[16];
+  uint32_t v_offsets[16];
+  uint32_t v_i;
+  uint32_t v_l;
+  uint32_t v_n_used;
+  uint32_t v_remaining;
+  uint32_t v_top;
+  uint32_t v_o;
+
+  wuffs_base__memset(v_counts, 0, sizeof(v_counts));
+  wuffs_base__memset(v_offsets, 0, sizeof(v_offsets));
+  v_i = 0;
+  v_l = 0;
+  v_n_used = 0;
+  v_remaining = 0;
+  v_top = 0;
+  v_o = 0;
+  while (v_i < a_n_symbols) {
+    v_l = ((uint32_t)(self->private_impl.f_code_lengths[v_i]));
+    if (v_counts[v_l] < v_i) {
+      v_counts[v_l] += 1;
+    } else {
+      v_counts[v_l] = (v_i + 1);
+    }
+    v_i += 1;
+  }
+  if (a_n_symbols <= v_counts[0]) {
+    status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+    goto exit;
+  }
+  v_n_used = (a_n_symbols - v_counts[0]);
+  if (self->private_impl.f_huff_top > 65519) {
+    status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_HUFFMAN_TABLE_SIZE;
+    goto exit;
+  }
+  v_top = self->private_impl.f_huff_top;
+  if (v_n_used > (65519 - v_top)) {
+    status = WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_HUFFMAN_TABLE_SIZE;
+    goto exit;
+  }
+  self->private_impl.f_huff_offsets[a_k] = v_top;
+  self->private_impl.f_huff_top = ((v_n_used + v_top) + 16);
+  if (v_n_used > 1) {
+    v_remaining = 1;
+    v_l = 1;
+    while (true) {
+      if (v_remaining > 16384) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_remaining <<= 1;
+      if (v_remaining < v_counts[v_l]) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_remaining -= v_counts[v_l];
+      if (v_l >= 15) {
+        goto label_0_break;
+      }
+      v_l += 1;
+    }
+  label_0_break:;
+    if (v_remaining != 0) {
+      status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+      goto exit;
+    }
+  }
+  if (v_n_used == 1) {
+    self->private_impl.f_huffs[v_top] = 1;
+  } else {
+    self->private_impl.f_huffs[v_top] = 0;
+  }
+  v_o = (v_top + 16);
+  v_l = 1;
+  while (true) {
+    self->private_impl.f_huffs[(v_top + v_l) & 65535] =
+        ((uint16_t)(v_counts[v_l]));
+    v_offsets[v_l] = (v_o & 65535);
+    v_o = ((v_o & 65535) + v_counts[v_l]);
+    if (v_l >= 15) {
+      goto label_1_break;
+    }
+    v_l += 1;
+  }
+label_1_break:;
+  v_i = 0;
+  while (v_i < a_n_symbols) {
+    v_l = ((uint32_t)(self->private_impl.f_code_lengths[v_i]));
+    if (v_l != 0) {
+      self->private_impl.f_huffs[v_offsets[v_l]] = ((uint16_t)(v_i));
+      v_offsets[v_l] = ((v_offsets[v_l] + 1) & 65535);
+    }
+    v_i += 1;
+  }
+  goto exit;
+exit:
+  return status;
+}
+
+static void wuffs_webp__decoder__decode_huffman(wuffs_webp__decoder* self,
+                                                uint32_t a_k) {
+  uint32_t v_top;
+  uint32_t v_bits;
+  uint32_t v_n;
+  uint32_t v_code;
+  uint32_t v_first;
+  uint32_t v_index;
+  uint32_t v_count;
+  uint32_t v_l;
+
+  v_top = 0;
+  v_bits = 0;
+  v_n = 0;
+  v_code = 0;
+  v_first = 0;
+  v_index = 0;
+  v_count = 0;
+  v_l = 0;
+  v_top = self->private_impl.f_huff_offsets[a_k];
+  if (self->private_impl.f_huffs[v_top] != 0) {
+    self->private_impl.f_symbol =
+        ((uint32_t)(self->private_impl.f_huffs[(v_top + 16) & 65535]));
+    return;
+  }
+  v_bits = self->private_impl.f_bits;
+  v_n = self->private_impl.f_n_bits;
+  v_l = 1;
+  while (true) {
+    if (v_n <= 0) {
+      goto label_0_break;
+    }
+    v_code |= (v_bits & 1);
+    v_bits >>= 1;
+    v_n -= 1;
+    v_count = ((uint32_t)(self->private_impl.f_huffs[(v_top + v_l) & 65535]));
+    if (v_code < v_first) {
+      goto label_0_break;
+    }
+    if ((v_code - v_first) < v_count) {
+      self->private_impl.f_symbol =
+          ((uint32_t)(self->private_impl
+                          .f_huffs[(v_top + 16 + v_index + (v_code - v_first)) &
+                                   65535]));
+      self->private_impl.f_bits = v_bits;
+      self->private_impl.f_n_bits = v_n;
+      return;
+    }
+    if (v_l >= 15) {
+      goto label_0_break;
+    }
+    v_l += 1;
+    v_index = ((v_index + v_count) & 65535);
+    v_first = (((v_first + v_count) << 1) & 65535);
+    v_code = ((v_code << 1) & 65535);
+  }
+label_0_break:;
+  self->private_impl.f_bad_huffman = true;
+}
+
+static wuffs_webp__status wuffs_webp__decoder__fill_bits(
+    wuffs_webp__decoder* self,
+    wuffs_base__reader1 a_src) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint8_t v_c;
+
+  uint8_t* b_rptr_src = NULL;
+  uint8_t* b_rstart_src = NULL;
+  uint8_t* b_rend_src = NULL;
+  if (a_src.buf) {
+    b_rptr_src = a_src.buf->ptr + a_src.buf->ri;
+    b_rstart_src = b_rptr_src;
+    uint64_t len = a_src.buf->wi - a_src.buf->ri;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len && (len > *lim->ptr_to_len)) {
+        len = *lim->ptr_to_len;
+      }
+    }
+    b_rend_src = b_rptr_src + len;
+  }
+
+  uint32_t coro_susp_point = self->private_impl.c_fill_bits[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_c = self->private_impl.c_fill_bits[0].v_c;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_c = 0;
+    while (self->private_impl.f_n_bits < 24) {
+      v_c = 0;
+      if (self->private_impl.f_chunk_length > 0) {
+        self->private_impl.f_chunk_length -= 1;
+        {
+          WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+          if (WUFFS_BASE__UNLIKELY(b_rptr_src == b_rend_src)) {
+            goto short_read_src;
+          }
+          uint8_t t_0 = *b_rptr_src++;
+          v_c = t_0;
+        }
+      }
+      if (self->private_impl.f_n_bits < 24) {
+        self->private_impl.f_bits |=
+            (((uint32_t)(v_c)) << self->private_impl.f_n_bits);
+        self->private_impl.f_n_bits += 8;
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_fill_bits[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_fill_bits[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_fill_bits[0].v_c = v_c;
+
+  goto exit;
+exit:
+  if (a_src.buf) {
+    size_t n = b_rptr_src - (a_src.buf->ptr + a_src.buf->ri);
+    a_src.buf->ri += n;
+    wuffs_base__limit1* lim;
+    for (lim = &a_src.private_impl.limit; lim; lim = lim->next) {
+      if (lim->ptr_to_len) {
+        *lim->ptr_to_len -= n;
+      }
+    }
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rstart_src);
+    WUFFS_BASE__IGNORE_POTENTIALLY_UNUSED_VARIABLE(b_rend_src);
+  }
+
+  return status;
+
+short_read_src:
+  if (a_src.buf && a_src.buf->closed && !a_src.private_impl.limit.ptr_to_len) {
+    status = WUFFS_WEBP__ERROR_UNEXPECTED_EOF;
+    goto exit;
+  }
+  status = WUFFS_WEBP__SUSPENSION_SHORT_READ;
+  goto suspend;
+}
+
+static void wuffs_webp__decoder__take_bits(wuffs_webp__decoder* self,
+                                           uint32_t a_n) {
+  uint32_t v_nb;
+
+  v_nb = self->private_impl.f_n_bits;
+  if (v_nb < a_n) {
+    self->private_impl.f_taken = 0;
+    return;
+  }
+  self->private_impl.f_taken =
+      (self->private_impl.f_bits & ((((uint32_t)(1)) << a_n) - 1));
+  self->private_impl.f_bits >>= a_n;
+  self->private_impl.f_n_bits = (v_nb - a_n);
+}
+
+static wuffs_webp__status wuffs_webp__decoder__decode_pixels(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf,
+    wuffs_base__reader1 a_src,
+    uint64_t a_offset,
+    uint32_t a_width,
+    uint32_t a_height) {
+  wuffs_webp__status status = WUFFS_WEBP__STATUS_OK;
+
+  uint64_t v_n;
+  uint64_t v_w;
+  uint64_t v_i;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_g5;
+  uint32_t v_p;
+  uint32_t v_green;
+  uint32_t v_red;
+  uint32_t v_blue;
+  uint64_t v_length;
+  uint32_t v_dist;
+  uint64_t v_d;
+  uint32_t v_v;
+  uint64_t v_e;
+  uint64_t v_j;
+
+  uint32_t coro_susp_point =
+      self->private_impl.c_decode_pixels[0].coro_susp_point;
+  if (coro_susp_point) {
+    v_n = self->private_impl.c_decode_pixels[0].v_n;
+    v_w = self->private_impl.c_decode_pixels[0].v_w;
+    v_i = self->private_impl.c_decode_pixels[0].v_i;
+    v_x = self->private_impl.c_decode_pixels[0].v_x;
+    v_y = self->private_impl.c_decode_pixels[0].v_y;
+    v_g5 = self->private_impl.c_decode_pixels[0].v_g5;
+    v_p = self->private_impl.c_decode_pixels[0].v_p;
+    v_green = self->private_impl.c_decode_pixels[0].v_green;
+    v_red = self->private_impl.c_decode_pixels[0].v_red;
+    v_blue = self->private_impl.c_decode_pixels[0].v_blue;
+    v_length = self->private_impl.c_decode_pixels[0].v_length;
+    v_dist = self->private_impl.c_decode_pixels[0].v_dist;
+    v_d = self->private_impl.c_decode_pixels[0].v_d;
+    v_v = self->private_impl.c_decode_pixels[0].v_v;
+    v_e = self->private_impl.c_decode_pixels[0].v_e;
+    v_j = self->private_impl.c_decode_pixels[0].v_j;
+  } else {
+  }
+  switch (coro_susp_point) {
+    WUFFS_BASE__COROUTINE_SUSPENSION_POINT_0;
+
+    v_n = 0;
+    v_w = 1;
+    v_i = 0;
+    v_x = 0;
+    v_y = 0;
+    v_g5 = 0;
+    v_p = 0;
+    v_green = 0;
+    v_red = 0;
+    v_blue = 0;
+    v_length = 0;
+    v_dist = 0;
+    v_d = 0;
+    v_v = 0;
+    v_e = 0;
+    v_j = 0;
+    if (a_width <= 0) {
+      status = WUFFS_WEBP__STATUS_OK;
+      goto ok;
+    }
+    v_w = ((uint64_t)(a_width));
+    v_n = (v_w * ((uint64_t)(a_height)));
+    while (v_i < v_n) {
+      if (self->private_impl.f_has_entropy) {
+        v_p = wuffs_webp__decoder__load_pixel(
+            self, a_workbuf,
+            self->private_impl.f_workbuf_offset[3] +
+                (4 *
+                 ((((uint64_t)((v_y >> self->private_impl.f_entropy_bits))) *
+                   ((uint64_t)(self->private_impl.f_entropy_width))) +
+                  ((uint64_t)((v_x >> self->private_impl.f_entropy_bits))))));
+        v_p = ((v_p >> 8) & 65535);
+        if (v_p >= self->private_impl.f_n_groups) {
+          status = WUFFS_WEBP__ERROR_INTERNAL_ERROR_INCONSISTENT_HUFFMAN_GROUP;
+          goto exit;
+        }
+        v_g5 = (5 * (v_p & 255));
+      }
+      WUFFS_BASE__COROUTINE_SUSPENSION_POINT(1);
+      status = wuffs_webp__decoder__fill_bits(self, a_src);
+      if (status) {
+        goto suspend;
+      }
+      wuffs_webp__decoder__decode_huffman(self, v_g5 + 0);
+      if (self->private_impl.f_bad_huffman) {
+        status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+        goto exit;
+      }
+      v_green = self->private_impl.f_symbol;
+      if (v_green < 256) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(2);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_huffman(self, v_g5 + 1);
+        v_red = (self->private_impl.f_symbol & 255);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(3);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_huffman(self, v_g5 + 2);
+        v_blue = (self->private_impl.f_symbol & 255);
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(4);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_huffman(self, v_g5 + 3);
+        if (self->private_impl.f_bad_huffman) {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+          goto exit;
+        }
+        v_p = (((self->private_impl.f_symbol & 255) << 24) | (v_red << 16) |
+               (v_green << 8) | v_blue);
+        wuffs_webp__decoder__store_pixel(self, a_workbuf, a_offset + (4 * v_i),
+                                         v_p);
+        wuffs_webp__decoder__insert_color_cache(self, v_p);
+        v_i += 1;
+      } else if (v_green < 280) {
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(5);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_prefix(self, v_green - 256);
+        v_length = ((uint64_t)(self->private_impl.f_taken));
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(6);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_huffman(self, v_g5 + 4);
+        if (self->private_impl.f_bad_huffman) {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_HUFFMAN_CODE;
+          goto exit;
+        }
+        WUFFS_BASE__COROUTINE_SUSPENSION_POINT(7);
+        status = wuffs_webp__decoder__fill_bits(self, a_src);
+        if (status) {
+          goto suspend;
+        }
+        wuffs_webp__decoder__decode_prefix(self,
+                                           self->private_impl.f_symbol & 63);
+        v_dist = self->private_impl.f_taken;
+        if (v_dist > 120) {
+          v_d = ((uint64_t)((v_dist - 120)));
+        } else if (v_dist > 0) {
+          v_v = ((uint32_t)(wuffs_webp__distance_map[v_dist - 1]));
+          v_d = ((((uint64_t)((v_v >> 4))) * v_w) + 8);
+          v_e = ((uint64_t)((v_v & 15)));
+          if (v_d > v_e) {
+            v_d -= v_e;
+          } else {
+            v_d = 1;
+          }
+        } else {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_BACKWARD_REFERENCE;
+          goto exit;
+        }
+        v_j = 0;
+        while (v_j < v_length) {
+          if ((v_i < v_d) || (v_i >= v_n)) {
+            status = WUFFS_WEBP__ERROR_BAD_WEBP_BACKWARD_REFERENCE;
+            goto exit;
+          }
+          v_p = wuffs_webp__decoder__load_pixel(self, a_workbuf,
+                                                a_offset + (4 * (v_i - v_d)));
+          wuffs_webp__decoder__store_pixel(self, a_workbuf,
+                                           a_offset + (4 * v_i), v_p);
+          wuffs_webp__decoder__insert_color_cache(self, v_p);
+          v_i += 1;
+          v_j += 1;
+        }
+      } else {
+        if (self->private_impl.f_cache_bits == 0) {
+          status = WUFFS_WEBP__ERROR_BAD_WEBP_COLOR_CACHE;
+          goto exit;
+        }
+        v_p = self->private_impl.f_cache[(v_green - 280) & 2047];
+        wuffs_webp__decoder__store_pixel(self, a_workbuf, a_offset + (4 * v_i),
+                                         v_p);
+        wuffs_webp__decoder__insert_color_cache(self, v_p);
+        v_i += 1;
+      }
+      if (self->private_impl.f_has_entropy) {
+        v_y = ((uint32_t)((((v_i & 536870911) / v_w) & 16383)));
+        v_x = ((uint32_t)(((v_i & 536870911) % v_w)));
+      }
+    }
+
+    goto ok;
+  ok:
+    self->private_impl.c_decode_pixels[0].coro_susp_point = 0;
+    goto exit;
+  }
+
+  goto suspend;
+suspend:
+  self->private_impl.c_decode_pixels[0].coro_susp_point = coro_susp_point;
+  self->private_impl.c_decode_pixels[0].v_n = v_n;
+  self->private_impl.c_decode_pixels[0].v_w = v_w;
+  self->private_impl.c_decode_pixels[0].v_i = v_i;
+  self->private_impl.c_decode_pixels[0].v_x = v_x;
+  self->private_impl.c_decode_pixels[0].v_y = v_y;
+  self->private_impl.c_decode_pixels[0].v_g5 = v_g5;
+  self->private_impl.c_decode_pixels[0].v_p = v_p;
+  self->private_impl.c_decode_pixels[0].v_green = v_green;
+  self->private_impl.c_decode_pixels[0].v_red = v_red;
+  self->private_impl.c_decode_pixels[0].v_blue = v_blue;
+  self->private_impl.c_decode_pixels[0].v_length = v_length;
+  self->private_impl.c_decode_pixels[0].v_dist = v_dist;
+  self->private_impl.c_decode_pixels[0].v_d = v_d;
+  self->private_impl.c_decode_pixels[0].v_v = v_v;
+  self->private_impl.c_decode_pixels[0].v_e = v_e;
+  self->private_impl.c_decode_pixels[0].v_j = v_j;
+
+  goto exit;
+exit:
+  return status;
+}
+
+static void wuffs_webp__decoder__decode_prefix(wuffs_webp__decoder* self,
+                                               uint32_t a_p) {
+  uint32_t v_e;
+
+  v_e = 0;
+  if (a_p < 4) {
+    self->private_impl.f_taken = (a_p + 1);
+    return;
+  }
+  v_e = (((a_p - 2) >> 1) & 15);
+  wuffs_webp__decoder__take_bits(self, v_e);
+  self->private_impl.f_taken =
+      ((((2 + (a_p & 1)) << v_e) + self->private_impl.f_taken + 1) & 16777215);
+}
+
+static void wuffs_webp__decoder__insert_color_cache(wuffs_webp__decoder* self,
+                                                    uint32_t a_pixel) {
+  uint64_t v_h;
+
+  v_h = 0;
+  if (self->private_impl.f_cache_bits == 0) {
+    return;
+  }
+  v_h = ((((uint64_t)(506832829)) * ((uint64_t)(a_pixel))) & 4294967295);
+  self->private_impl
+      .f_cache[(v_h >> (32 - self->private_impl.f_cache_bits)) & 2047] =
+      a_pixel;
+}
+
+static uint32_t wuffs_webp__decoder__load_pixel(wuffs_webp__decoder* self,
+                                                wuffs_base__slice_u8 a_workbuf,
+                                                uint64_t a_offset) {
+  uint8_t v_px[4];
+
+  wuffs_base__memset(v_px, 0, sizeof(v_px));
+  if (a_offset <= ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}),
+        wuffs_base__slice_u8__subslice_i(a_workbuf, a_offset));
+  }
+  return (((uint32_t)(v_px[0])) | (((uint32_t)(v_px[1])) << 8) |
+          (((uint32_t)(v_px[2])) << 16) | (((uint32_t)(v_px[3])) << 24));
+}
+
+static void wuffs_webp__decoder__store_pixel(wuffs_webp__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset,
+                                             uint32_t a_pixel) {
+  uint8_t v_px[4];
+
+  wuffs_base__memset(v_px, 0, sizeof(v_px));
+  v_px[0] = ((uint8_t)((a_pixel & 255)));
+  v_px[1] = ((uint8_t)(((a_pixel >> 8) & 255)));
+  v_px[2] = ((uint8_t)(((a_pixel >> 16) & 255)));
+  v_px[3] = ((uint8_t)((a_pixel >> 24)));
+  if (a_offset <= ((uint64_t)(a_workbuf.len))) {
+    wuffs_base__slice_u8__copy_from_slice(
+        wuffs_base__slice_u8__subslice_i(a_workbuf, a_offset),
+        ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}));
+  }
+}
+
+static void wuffs_webp__decoder__apply_inverse_transforms(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint32_t v_i;
+  uint32_t v_t;
+
+  v_i = self->private_impl.f_n_transforms;
+  v_t = 0;
+  while (v_i > 0) {
+    v_i -= 1;
+    v_t = self->private_impl.f_transforms[v_i & 3];
+    if (v_t == 0) {
+      wuffs_webp__decoder__inverse_predictor(self, a_workbuf);
+    } else if (v_t == 1) {
+      wuffs_webp__decoder__inverse_color(self, a_workbuf);
+    } else if (v_t == 2) {
+      wuffs_webp__decoder__inverse_subtract_green(self, a_workbuf);
+    } else {
+      wuffs_webp__decoder__inverse_color_indexing(self, a_workbuf);
+    }
+  }
+}
+
+static void wuffs_webp__decoder__inverse_predictor(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint32_t v_w;
+  uint32_t v_b;
+  uint32_t v_bw;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint64_t v_o;
+  uint32_t v_mode;
+  uint32_t v_l;
+  uint32_t v_t;
+  uint32_t v_pred;
+
+  v_w = self->private_impl.f_transform_width[0];
+  v_b = self->private_impl.f_transform_bits[0];
+  v_bw = 0;
+  v_x = 0;
+  v_y = 0;
+  v_o = 0;
+  v_mode = 0;
+  v_l = 0;
+  v_t = 0;
+  v_pred = 0;
+  if (v_w > 0) {
+    v_bw = (((v_w - 1) >> v_b) + 1);
+  }
+  while (v_y < self->private_impl.f_height) {
+    v_x = 0;
+    while (v_x < v_w) {
+      v_o = (4 * ((((uint64_t)(v_y)) * ((uint64_t)(v_w))) + ((uint64_t)(v_x))));
+      if (v_o >= 4) {
+        v_l = wuffs_webp__decoder__load_pixel(self, a_workbuf, v_o - 4);
+      }
+      if (v_o >= (4 * ((uint64_t)(v_w)))) {
+        v_t = wuffs_webp__decoder__load_pixel(self, a_workbuf,
+                                              v_o - (4 * ((uint64_t)(v_w))));
+      }
+      if (v_y == 0) {
+        if (v_x == 0) {
+          v_pred = 4278190080;
+        } else {
+          v_pred = v_l;
+        }
+      } else if (v_x == 0) {
+        v_pred = v_t;
+      } else {
+        v_mode =
+            ((wuffs_webp__decoder__load_pixel(
+                  self, a_workbuf,
+                  self->private_impl.f_workbuf_offset[1] +
+                      (4 * ((((uint64_t)((v_y >> v_b))) * ((uint64_t)(v_bw))) +
+                            ((uint64_t)((v_x >> v_b)))))) >>
+              8) &
+             15);
+        v_pred = wuffs_webp__decoder__predict(self, a_workbuf, v_o, v_w, v_mode,
+                                              v_l, v_t);
+      }
+      wuffs_webp__decoder__store_pixel(
+          self, a_workbuf, v_o,
+          wuffs_webp__decoder__add_pixels(
+              self, wuffs_webp__decoder__load_pixel(self, a_workbuf, v_o),
+              v_pred));
+      v_x += 1;
+    }
+    v_y += 1;
+  }
+}
+
+static uint32_t wuffs_webp__decoder__predict(wuffs_webp__decoder* self,
+                                             wuffs_base__slice_u8 a_workbuf,
+                                             uint64_t a_offset,
+                                             uint32_t a_w,
+                                             uint32_t a_mode,
+                                             uint32_t a_l,
+                                             uint32_t a_t) {
+  uint64_t v_u;
+  uint32_t v_tl;
+  uint32_t v_tr;
+
+  v_u = 0;
+  v_tl = 0;
+  v_tr = 0;
+  if (a_offset < (4 * (((uint64_t)(a_w)) + 1))) {
+    return 0;
+  }
+  v_u = (a_offset - (4 * (((uint64_t)(a_w)) + 1)));
+  v_tl = wuffs_webp__decoder__load_pixel(self, a_workbuf, v_u);
+  v_tr = wuffs_webp__decoder__load_pixel(self, a_workbuf, v_u + 8);
+  if (a_mode == 1) {
+    return a_l;
+  } else if (a_mode == 2) {
+    return a_t;
+  } else if (a_mode == 3) {
+    return v_tr;
+  } else if (a_mode == 4) {
+    return v_tl;
+  } else if (a_mode == 5) {
+    return wuffs_webp__decoder__average2(
+        self, wuffs_webp__decoder__average2(self, a_l, v_tr), a_t);
+  } else if (a_mode == 6) {
+    return wuffs_webp__decoder__average2(self, a_l, v_tl);
+  } else if (a_mode == 7) {
+    return wuffs_webp__decoder__average2(self, a_l, a_t);
+  } else if (a_mode == 8) {
+    return wuffs_webp__decoder__average2(self, v_tl, a_t);
+  } else if (a_mode == 9) {
+    return wuffs_webp__decoder__average2(self, a_t, v_tr);
+  } else if (a_mode == 10) {
+    return wuffs_webp__decoder__average2(
+        self, wuffs_webp__decoder__average2(self, a_l, v_tl),
+        wuffs_webp__decoder__average2(self, a_t, v_tr));
+  } else if (a_mode == 11) {
+    return wuffs_webp__decoder__select(self, a_l, a_t, v_tl);
+  } else if (a_mode == 12) {
+    return wuffs_webp__decoder__clamp_add_subtract_full(self, a_l, a_t, v_tl);
+  } else if (a_mode == 13) {
+    return wuffs_webp__decoder__clamp_add_subtract_half(
+        self, wuffs_webp__decoder__average2(self, a_l, a_t), v_tl);
+  }
+  return 4278190080;
+}
+
+static void wuffs_webp__decoder__inverse_color(wuffs_webp__decoder* self,
+                                               wuffs_base__slice_u8 a_workbuf) {
+  uint32_t v_w;
+  uint32_t v_b;
+  uint32_t v_bw;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint64_t v_o;
+  uint32_t v_m;
+  uint32_t v_p;
+  int32_t v_green;
+  uint32_t v_red;
+  uint32_t v_blue;
+
+  v_w = self->private_impl.f_transform_width[1];
+  v_b = self->private_impl.f_transform_bits[1];
+  v_bw = 0;
+  v_x = 0;
+  v_y = 0;
+  v_o = 0;
+  v_m = 0;
+  v_p = 0;
+  v_green = 0;
+  v_red = 0;
+  v_blue = 0;
+  if (v_w > 0) {
+    v_bw = (((v_w - 1) >> v_b) + 1);
+  }
+  while (v_y < self->private_impl.f_height) {
+    v_x = 0;
+    while (v_x < v_w) {
+      v_o = (4 * ((((uint64_t)(v_y)) * ((uint64_t)(v_w))) + ((uint64_t)(v_x))));
+      v_m = wuffs_webp__decoder__load_pixel(
+          self, a_workbuf,
+          self->private_impl.f_workbuf_offset[2] +
+              (4 * ((((uint64_t)((v_y >> v_b))) * ((uint64_t)(v_bw))) +
+                    ((uint64_t)((v_x >> v_b))))));
+      v_p = wuffs_webp__decoder__load_pixel(self, a_workbuf, v_o);
+      v_green = wuffs_webp__decoder__to_i8(self, (v_p >> 8) & 255);
+      v_red = ((v_p >> 16) & 255);
+      v_blue = (v_p & 255);
+      v_red = wuffs_webp__decoder__color_transform_add(
+          self, v_red, wuffs_webp__decoder__to_i8(self, v_m & 255), v_green);
+      v_blue = wuffs_webp__decoder__color_transform_add(
+          self, v_blue, wuffs_webp__decoder__to_i8(self, (v_m >> 8) & 255),
+          v_green);
+      v_blue = wuffs_webp__decoder__color_transform_add(
+          self, v_blue, wuffs_webp__decoder__to_i8(self, (v_m >> 16) & 255),
+          wuffs_webp__decoder__to_i8(self, v_red));
+      wuffs_webp__decoder__store_pixel(
+          self, a_workbuf, v_o, (v_p & 4278255360) | (v_red << 16) | v_blue);
+      v_x += 1;
+    }
+    v_y += 1;
+  }
+}
+
+static void wuffs_webp__decoder__inverse_subtract_green(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint64_t v_n;
+  uint64_t v_i;
+  uint32_t v_p;
+  uint32_t v_g;
+
+  v_n = 0;
+  v_i = 0;
+  v_p = 0;
+  v_g = 0;
+  v_n = (((uint64_t)(self->private_impl.f_transform_width[2])) *
+         ((uint64_t)(self->private_impl.f_height)));
+  while (v_i < v_n) {
+    v_p =
+        wuffs_webp__decoder__load_pixel(self, a_workbuf, 4 * (v_i & 268435455));
+    v_g = ((v_p >> 8) & 255);
+    wuffs_webp__decoder__store_pixel(
+        self, a_workbuf, 4 * (v_i & 268435455),
+        wuffs_webp__decoder__add_pixels(self, v_p, (v_g << 16) | v_g));
+    v_i += 1;
+  }
+}
+
+static void wuffs_webp__decoder__inverse_color_indexing(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint32_t v_w;
+  uint32_t v_wb;
+  uint32_t v_pw;
+  uint32_t v_bpp;
+  uint32_t v_x;
+  uint32_t v_y;
+  uint32_t v_p;
+  uint32_t v_idx;
+  uint8_t v_px[4];
+  uint64_t v_o;
+
+  v_w = self->private_impl.f_transform_width[3];
+  v_wb = self->private_impl.f_color_indexing_width_bits;
+  v_pw = self->private_impl.f_xsize;
+  v_bpp = 8;
+  v_x = 0;
+  v_y = 0;
+  v_p = 0;
+  v_idx = 0;
+  wuffs_base__memset(v_px, 0, sizeof(v_px));
+  v_o = 0;
+  if (v_wb == 0) {
+    v_bpp = 8;
+  } else if (v_wb == 1) {
+    v_bpp = 4;
+  } else if (v_wb == 2) {
+    v_bpp = 2;
+  } else {
+    v_bpp = 1;
+  }
+  self->private_impl.f_xsize = v_w;
+  v_y = self->private_impl.f_height;
+  while (v_y > 0) {
+    v_y -= 1;
+    v_x = v_w;
+    while (v_x > 0) {
+      v_x -= 1;
+      v_p = wuffs_webp__decoder__load_pixel(
+          self, a_workbuf,
+          4 * ((((uint64_t)(v_y)) * ((uint64_t)(v_pw))) +
+               ((uint64_t)((v_x >> v_wb)))));
+      v_idx =
+          (((v_p >> 8) >> ((v_x & ((((uint32_t)(1)) << v_wb) - 1)) * v_bpp)) &
+           ((((uint32_t)(1)) << v_bpp) - 1) & 255);
+      wuffs_base__slice_u8__copy_from_slice(
+          ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}),
+          wuffs_base__slice_u8__subslice_i(
+              ((wuffs_base__slice_u8){.ptr = self->private_impl.f_palette,
+                                      .len = 1024}),
+              4 * v_idx));
+      v_o = (4 * ((((uint64_t)(v_y)) * ((uint64_t)(v_w))) + ((uint64_t)(v_x))));
+      if (v_o <= ((uint64_t)(a_workbuf.len))) {
+        wuffs_base__slice_u8__copy_from_slice(
+            wuffs_base__slice_u8__subslice_i(a_workbuf, v_o),
+            ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}));
+      }
+    }
+  }
+}
+
+static void wuffs_webp__decoder__convert_pixels(
+    wuffs_webp__decoder* self,
+    wuffs_base__slice_u8 a_dst,
+    wuffs_base__slice_u8 a_workbuf) {
+  uint64_t v_n;
+  uint64_t v_i;
+  uint32_t v_p;
+  uint8_t v_px[4];
+  uint64_t v_bpp;
+  uint32_t v_c;
+
+  v_n = 0;
+  v_i = 0;
+  v_p = 0;
+  wuffs_base__memset(v_px, 0, sizeof(v_px));
+  v_bpp = ((uint64_t)(self->private_impl.f_bytes_per_pixel));
+  v_c = 0;
+  v_n = (((uint64_t)(self->private_impl.f_width)) *
+         ((uint64_t)(self->private_impl.f_height)));
+  if (self->private_impl.f_pixel_format == 2) {
+    if ((4 * v_n) <= ((uint64_t)(a_workbuf.len))) {
+      wuffs_base__slice_u8__copy_from_slice(
+          a_dst, wuffs_base__slice_u8__subslice_j(a_workbuf, 4 * v_n));
+    }
+    return;
+  }
+  while (v_i < v_n) {
+    v_p = wuffs_webp__decoder__load_pixel(self, a_workbuf, 4 * v_i);
+    if (self->private_impl.f_pixel_format == 1) {
+      v_px[0] = ((uint8_t)(((v_p >> 16) & 255)));
+      v_px[1] = ((uint8_t)(((v_p >> 8) & 255)));
+      v_px[2] = ((uint8_t)((v_p & 255)));
+      v_px[3] = ((uint8_t)((v_p >> 24)));
+    } else {
+      v_c = (((((v_p >> 16) & 255) >> 3) << 11) |
+             ((((v_p >> 8) & 255) >> 2) << 5) | ((v_p & 255) >> 3));
+      v_px[0] = ((uint8_t)((v_c & 255)));
+      v_px[1] = ((uint8_t)((v_c >> 8)));
+    }
+    if ((v_i * v_bpp) <= ((uint64_t)(a_dst.len))) {
+      wuffs_base__slice_u8__copy_from_slice(
+          wuffs_base__slice_u8__subslice_i(a_dst, v_i * v_bpp),
+          wuffs_base__slice_u8__subslice_j(
+              ((wuffs_base__slice_u8){.ptr = v_px, .len = 4}), v_bpp));
+    }
+    v_i += 1;
+  }
+}
+
+static uint32_t wuffs_webp__decoder__add_pixels(wuffs_webp__decoder* self,
+                                                uint32_t a_a,
+                                                uint32_t a_b) {
+  return ((((a_a & 4278255360) + (a_b & 4278255360)) & 4278255360) |
+          (((a_a & 16711935) + (a_b & 16711935)) & 16711935));
+}
+
+static uint32_t wuffs_webp__decoder__average2(wuffs_webp__decoder* self,
+                                              uint32_t a_a,
+                                              uint32_t a_b) {
+  return ((((a_a ^ a_b) & 4278124286) >> 1) + (a_a & a_b));
+}
+
+static uint32_t wuffs_webp__decoder__select(wuffs_webp__decoder* self,
+                                            uint32_t a_l,
+                                            uint32_t a_t,
+                                            uint32_t a_tl) {
+  uint32_t v_pl;
+  uint32_t v_pt;
+
+  v_pl = 0;
+  v_pt = 0;
+  v_pl = (wuffs_webp__decoder__channel_distance(self, a_t, a_tl, 0) +
+          wuffs_webp__decoder__channel_distance(self, a_t, a_tl, 8) +
+          wuffs_webp__decoder__channel_distance(self, a_t, a_tl, 16) +
+          wuffs_webp__decoder__channel_distance(self, a_t, a_tl, 24));
+  v_pt = (wuffs_webp__decoder__channel_distance(self, a_l, a_tl, 0) +
+          wuffs_webp__decoder__channel_distance(self, a_l, a_tl, 8) +
+          wuffs_webp__decoder__channel_distance(self, a_l, a_tl, 16) +
+          wuffs_webp__decoder__channel_distance(self, a_l, a_tl, 24));
+  if (v_pl < v_pt) {
+    return a_l;
+  }
+  return a_t;
+}
+
+static uint32_t wuffs_webp__decoder__channel_distance(wuffs_webp__decoder* self,
+                                                      uint32_t a_a,
+                                                      uint32_t a_b,
+                                                      uint32_t a_s) {
+  uint32_t v_u;
+  uint32_t v_v;
+
+  v_u = ((a_a >> a_s) & 255);
+  v_v = ((a_b >> a_s) & 255);
+  if (v_u > v_v) {
+    return (v_u - v_v);
+  }
+  return (v_v - v_u);
+}
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_full(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_c) {
+  return (
+      (wuffs_webp__decoder__clamp_full_channel(self, a_a, a_b, a_c, 0) << 0) |
+      (wuffs_webp__decoder__clamp_full_channel(self, a_a, a_b, a_c, 8) << 8) |
+      (wuffs_webp__decoder__clamp_full_channel(self, a_a, a_b, a_c, 16) << 16) |
+      (wuffs_webp__decoder__clamp_full_channel(self, a_a, a_b, a_c, 24) << 24));
+}
+
+static uint32_t wuffs_webp__decoder__clamp_full_channel(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_c,
+    uint32_t a_s) {
+  uint32_t v_v;
+  uint32_t v_w;
+
+  v_v = 0;
+  v_w = 0;
+  v_v = (((a_a >> a_s) & 255) + ((a_b >> a_s) & 255));
+  v_w = ((a_c >> a_s) & 255);
+  if (v_v < v_w) {
+    return 0;
+  }
+  v_v -= v_w;
+  if (v_v > 255) {
+    return 255;
+  }
+  return v_v;
+}
+
+static uint32_t wuffs_webp__decoder__clamp_add_subtract_half(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b) {
+  return ((wuffs_webp__decoder__clamp_half_channel(self, a_a, a_b, 0) << 0) |
+          (wuffs_webp__decoder__clamp_half_channel(self, a_a, a_b, 8) << 8) |
+          (wuffs_webp__decoder__clamp_half_channel(self, a_a, a_b, 16) << 16) |
+          (wuffs_webp__decoder__clamp_half_channel(self, a_a, a_b, 24) << 24));
+}
+
+static uint32_t wuffs_webp__decoder__clamp_half_channel(
+    wuffs_webp__decoder* self,
+    uint32_t a_a,
+    uint32_t a_b,
+    uint32_t a_s) {
+  uint32_t v_u;
+  uint32_t v_d;
+  uint32_t v_w;
+
+  v_u = 0;
+  v_d = 0;
+  v_w = 0;
+  v_u = ((a_a >> a_s) & 255);
+  v_d = (wuffs_webp__decoder__channel_distance(self, a_a, a_b, a_s) / 2);
+  if (v_u < ((a_b >> a_s) & 255)) {
+    if (v_u < v_d) {
+      return 0;
+    }
+    return (v_u - v_d);
+  }
+  v_w = (v_u + v_d);
+  if (v_w > 255) {
+    return 255;
+  }
+  return v_w;
+}
+
+static int32_t wuffs_webp__decoder__to_i8(wuffs_webp__decoder* self,
+                                          uint32_t a_x) {
+  if (a_x >= 128) {
+    return (((int32_t)(a_x)) - 256);
+  }
+  return ((int32_t)(a_x));
+}
+
+static uint32_t wuffs_webp__decoder__color_transform_add(
+    wuffs_webp__decoder* self,
+    uint32_t a_c,
+    int32_t a_t,
+    int32_t a_x) {
+  int32_t v_v;
+
+  v_v = 0;
+  v_v = ((((int32_t)(a_c)) + ((a_t * a_x) >> 5)) + 512);
+  return (((uint32_t)(v_v)) & 255);
+}
@@ -36,6 +36,7 @@ const (
 	KArg
 	KAssert
 	KAssign
+	KCase
 	KConst
 	KExpr
 	KField
@@ -44,10 +45,12 @@ const (
 	KIf
 	KIterate
 	KJump
+	KLemma
 	KPackageID
 	KRet
 	KStatus
 	KStruct
+	KSwitch
 	KTypeExpr
 	KUse
 	KVar
@@ -67,6 +70,7 @@ var kindStrings = [...]string{
 	KArg:       "KArg",
 	KAssert:    "KAssert",
 	KAssign:    "KAssign",
+	KCase:      "KCase",
 	KConst:     "KConst",
 	KExpr:      "KExpr",
 	KField:     "KField",
@@ -75,10 +79,12 @@ var kindStrings = [...]string{
 	KIf:        "KIf",
 	KIterate:   "KIterate",
 	KJump:      "KJump",
+	KLemma:     "KLemma",
 	KPackageID: "KPackageID",
 	KRet:       "KRet",
 	KStatus:    "KStatus",
 	KStruct:    "KStruct",
+	KSwitch:    "KSwitch",
 	KTypeExpr:  "KTypeExpr",
 	KUse:       "KUse",
 	KVar:       "KVar",
@@ -155,8 +161,9 @@ type Node struct {
 	// kind          id0           id1           id2           kind
 	// ------------------------------------------------------------
 	// Arg           .             .             name          Arg
-	// Assert        keyword       .             lit(reason)   Assert
+	// Assert        keyword       pkg           lit(reason)   Assert
 	// Assign        operator      .             .             Assign
+	// Case          .             .             .             Case
 	// Const         .             pkg           name          Const
 	// Expr          operator      pkg           literal/ident Expr
 	// Field         .             .             name          Field
@@ -165,10 +172,12 @@ type Node struct {
 	// If            .             .             .             If
 	// Iterate       .             label         .             Iterate
 	// Jump          keyword       label         .             Jump
+	// Lemma         .             pkg           lit(name)     Lemma
 	// PackageID     .             .             lit(pkgID)    PackageID
 	// Ret           keyword       .             .             Ret
 	// Status        keyword       pkg           lit(message)  Status
 	// Struct        .             pkg           name          Struct
+	// Switch        .             .             .             Switch
 	// TypeExpr      decorator     pkg           name          TypeExpr
 	// Use           .             .             lit(path)     Use
 	// Var           operator      .             name          Var
@@ -193,6 +202,7 @@ func (n *Node) SetTypeChecked()   { n.flags |= FlagsTypeChecked }
 func (n *Node) Arg() *Arg             { return (*Arg)(n) }
 func (n *Node) Assert() *Assert       { return (*Assert)(n) }
 func (n *Node) Assign() *Assign       { return (*Assign)(n) }
+func (n *Node) Case() *Case           { return (*Case)(n) }
 func (n *Node) Const() *Const         { return (*Const)(n) }
 func (n *Node) Expr() *Expr           { return (*Expr)(n) }
 func (n *Node) Field() *Field         { return (*Field)(n) }
@@ -201,11 +211,13 @@ func (n *Node) Func() *Func           { return (*Func)(n) }
 func (n *Node) If() *If               { return (*If)(n) }
 func (n *Node) Iterate() *Iterate     { return (*Iterate)(n) }
 func (n *Node) Jump() *Jump           { return (*Jump)(n) }
+func (n *Node) Lemma() *Lemma         { return (*Lemma)(n) }
 func (n *Node) PackageID() *PackageID { return (*PackageID)(n) }
 func (n *Node) Raw() *Raw             { return (*Raw)(n) }
 func (n *Node) Ret() *Ret             { return (*Ret)(n) }
 func (n *Node) Status() *Status       { return (*Status)(n) }
 func (n *Node) Struct() *Struct       { return (*Struct)(n) }
+func (n *Node) Switch() *Switch       { return (*Switch)(n) }
 func (n *Node) TypeExpr() *TypeExpr   { return (*TypeExpr)(n) }
 func (n *Node) Use() *Use             { return (*Use)(n) }
 func (n *Node) Var() *Var             { return (*Var)(n) }
@@ -259,7 +271,7 @@ func (n *Raw) SetPackage(tm *t.Map, pkg t.ID) error {
 		default:
 			return nil
 
-		case KConst, KFunc, KStatus, KStruct:
+		case KConst, KFunc, KLemma, KStatus, KStruct:
 			// No-op.
 
 		case KExpr:
@@ -274,12 +286,16 @@ func (n *Raw) SetPackage(tm *t.Map, pkg t.ID) error {
 			if o.id0.Key() != 0 {
 				return nil
 			}
-			// TODO: Add signed integer types, as per the builtin.Types var?
-			//
-			// Or, don't hard code these, and instead require built-in types to
-			// have qualified names, such as "builtin.u8" or "base.reader1"?
+			// TODO: don't hard code these, and instead require built-in types
+			// to have qualified names, such as "builtin.u8" or "base.reader1"?
 			switch o.id2.Key() {
-			case t.KeyU8, t.KeyU16, t.KeyU32, t.KeyU64, t.KeyBool, t.KeyStatus, t.KeyReader1, t.KeyWriter1:
+			case t.KeyI8, t.KeyI16, t.KeyI32, t.KeyI64,
+				t.KeyU8, t.KeyU16, t.KeyU32, t.KeyU64,
+				t.KeyBool, t.KeyStatus, t.KeyReader1, t.KeyWriter1:
+				return nil
+			}
+			// The image_config and frame_config built-in types are not keys.
+			if s := o.id2.Str(tm); s == "image_config" || s == "frame_config" {
 				return nil
 			}
 		}
@@ -332,6 +348,11 @@ const MaxExprDepth = 255
 //
 // For lists, like "$(0, 1, 2)", ID0 is IDDollar.
 //
+// For computed lists, like "$(f(i:0..255))", ID0 is IDDollar, ID2 is the
+// function name, List0 holds one Arg (the range's start) and RHS is the
+// range's end. The checker replaces a const's computed list with the plain
+// list of f's results.
+//
 // For statuses, like `error "foo"` and `suspension bar."baz"`, ID0 is the
 // keyword, ID1 is the package and ID2 is the message.
 type Expr Node
@@ -389,8 +410,9 @@ func NewExpr(flags Flags, operator t.ID, statusPkg t.ID, ident t.ID, lhs *Node,
 	}
 }
 
-// Assert is "assert RHS via ID2(args)", "pre etc", "inv etc" or "post etc":
+// Assert is "assert RHS via ID1.ID2(args)", "pre etc", "inv etc" or "post etc":
 //  - ID0:   <IDAssert|IDPre|IDInv|IDPost>
+//  - ID1:   <0|pkg> reason package
 //  - ID2:   <string literal> reason
 //  - RHS:   <Expr>
 //  - List0: <Arg> reason arguments
@@ -398,15 +420,16 @@ type Assert Node
 
 func (n *Assert) Node() *Node      { return (*Node)(n) }
 func (n *Assert) Keyword() t.ID    { return n.id0 }
-func (n *Assert) Reason() t.ID     { return n.id2 }
+func (n *Assert) Reason() t.QID    { return t.QID{n.id1, n.id2} }
 func (n *Assert) Condition() *Expr { return n.rhs.Expr() }
 func (n *Assert) Args() []*Node    { return n.list0 }
 
-func NewAssert(keyword t.ID, condition *Expr, reason t.ID, args []*Node) *Assert {
+func NewAssert(keyword t.ID, condition *Expr, reason t.QID, args []*Node) *Assert {
 	return &Assert{
 		kind:  KAssert,
 		id0:   keyword,
-		id2:   reason,
+		id1:   reason[0],
+		id2:   reason[1],
 		rhs:   condition.Node(),
 		list0: args,
 	}
@@ -581,6 +604,59 @@ func NewIf(condition *Expr, elseIf *If, bodyIfTrue []*Node, bodyIfFalse []*Node)
 	}
 }
 
+// Switch is "switch MHS { List0 else { List1 } }":
+//  - MHS:   <Expr>
+//  - List0: <Case> cases
+//  - List1: <Statement> else body
+//
+// The else body is mandatory, although it may be empty.
+type Switch Node
+
+func (n *Switch) Node() *Node       { return (*Node)(n) }
+func (n *Switch) Subject() *Expr    { return n.mhs.Expr() }
+func (n *Switch) Cases() []*Node    { return n.list0 }
+func (n *Switch) BodyElse() []*Node { return n.list1 }
+
+func NewSwitch(subject *Expr, cases []*Node, bodyElse []*Node) *Switch {
+	return &Switch{
+		kind:  KSwitch,
+		mhs:   subject.Node(),
+		list0: cases,
+		list1: bodyElse,
+	}
+}
+
+// Case is "case LHS { List0 }" or "case LHS..MHS { List0 }", one arm of a
+// switch statement:
+//  - LHS:   <Expr> minimum, inclusive
+//  - MHS:   <nil|Expr> maximum, inclusive
+//  - List0: <Statement> body
+//
+// A nil MHS means that the case matches the single value LHS.
+type Case Node
+
+func (n *Case) Node() *Node   { return (*Node)(n) }
+func (n *Case) Min() *Expr    { return n.lhs.Expr() }
+func (n *Case) Body() []*Node { return n.list0 }
+
+func (n *Case) Max() *Expr {
+	if n.mhs == nil {
+		return n.lhs.Expr()
+	}
+	return n.mhs.Expr()
+}
+
+func (n *Case) IsRange() bool { return n.mhs != nil }
+
+func NewCase(min *Expr, max *Expr, body []*Node) *Case {
+	return &Case{
+		kind:  KCase,
+		lhs:   min.Node(),
+		mhs:   max.Node(),
+		list0: body,
+	}
+}
+
 // Ret is "return LHS" or "yield LHS":
 //  - ID0:   <IDReturn|IDYield>
 //  - LHS:   <nil|Expr>
@@ -763,6 +839,7 @@ const MaxBodyDepth = 255
 //  - Iterate
 //  - Jump
 //  - Ret
+//  - Switch
 //  - Var
 //  - While
 type Func Node
@@ -798,6 +875,41 @@ func NewFunc(flags Flags, filename string, line uint32, receiverName t.ID, funcN
 	}
 }
 
+// Lemma is "lemma ID2: RHS; List0 { List1 }":
+//  - FlagsPublic      is "pub" vs "pri"
+//  - ID1:   <0|pkg> (set by calling SetPackage)
+//  - ID2:   lit(name)
+//  - RHS:   <Expr> conclusion
+//  - List0: <Expr> premises
+//  - List1: <Assert> proof steps
+//
+// The identifiers in the conclusion and premises are metavariables, bound
+// when the lemma is invoked via an assertion's reason.
+type Lemma Node
+
+func (n *Lemma) Node() *Node         { return (*Node)(n) }
+func (n *Lemma) Public() bool        { return n.flags&FlagsPublic != 0 }
+func (n *Lemma) Filename() string    { return n.filename }
+func (n *Lemma) Line() uint32        { return n.line }
+func (n *Lemma) QID() t.QID          { return t.QID{n.id1, n.id2} }
+func (n *Lemma) Name() t.ID          { return n.id2 }
+func (n *Lemma) Conclusion() *Expr   { return n.rhs.Expr() }
+func (n *Lemma) Premises() []*Node   { return n.list0 }
+func (n *Lemma) ProofSteps() []*Node { return n.list1 }
+
+func NewLemma(flags Flags, filename string, line uint32, name t.ID, conclusion *Expr, premises []*Node, proofSteps []*Node) *Lemma {
+	return &Lemma{
+		kind:     KLemma,
+		flags:    flags,
+		filename: filename,
+		line:     line,
+		id2:      name,
+		rhs:      conclusion.Node(),
+		list0:    premises,
+		list1:    proofSteps,
+	}
+}
+
 // Status is "error ID2" or "suspension ID2":
 //  - FlagsPublic      is "pub" vs "pri"
 //  - ID0:   <IDError|IDSuspension>
@@ -839,6 +951,8 @@ func (n *Const) QID() t.QID       { return t.QID{n.id1, n.id2} }
 func (n *Const) XType() *TypeExpr { return n.lhs.TypeExpr() }
 func (n *Const) Value() *Expr     { return n.rhs.Expr() }
 
+func (n *Const) SetValue(x *Expr) { n.rhs = x.Node() }
+
 func NewConst(flags Flags, filename string, line uint32, name t.ID, xType *TypeExpr, value *Expr) *Const {
 	return &Const{
 		kind:     KConst,
@@ -99,6 +99,19 @@ func (n *Expr) appendStr(buf []byte, tm *t.Map, parenthesize bool, depth uint32)
 				buf = append(buf, tm.ByID(n.id2)...)
 
 			case t.KeyDollar:
+				if n.id2 != 0 {
+					arg := n.list0[0].Arg()
+					buf = append(buf, "$("...)
+					buf = append(buf, tm.ByID(n.id2)...)
+					buf = append(buf, '(')
+					buf = append(buf, tm.ByID(arg.Name())...)
+					buf = append(buf, ':')
+					buf = arg.Value().appendStr(buf, tm, false, depth)
+					buf = append(buf, ".."...)
+					buf = n.rhs.Expr().appendStr(buf, tm, false, depth)
+					buf = append(buf, "))"...)
+					break
+				}
 				buf = append(buf, "$("...)
 				for i, o := range n.list0 {
 					if i != 0 {
@@ -154,6 +167,7 @@ var opStrings = [256]string{
 	t.KeyXUnaryNot:   "not ",
 	t.KeyXUnaryRef:   "ref ",
 	t.KeyXUnaryDeref: "deref ",
+	t.KeyXUnaryTilde: "~",
 
 	t.KeyXBinaryPlus:        " + ",
 	t.KeyXBinaryMinus:       " - ",
@@ -165,6 +179,7 @@ var opStrings = [256]string{
 	t.KeyXBinaryAmpHat:      " &^ ",
 	t.KeyXBinaryPipe:        " | ",
 	t.KeyXBinaryHat:         " ^ ",
+	t.KeyXBinaryPercent:     " % ",
 	t.KeyXBinaryNotEq:       " != ",
 	t.KeyXBinaryLessThan:    " < ",
 	t.KeyXBinaryLessEq:      " <= ",
@@ -50,6 +50,7 @@ var StatusList = [...]Status{
 	{t.IDError, "cannot return a suspension"},
 	{t.IDError, "invalid call sequence"},
 	{t.IDSuspension, "end of data"},
+	{t.IDSuspension, "end of animation"},
 }
 
 var StatusMap = map[string]Status{}
@@ -74,6 +75,7 @@ var Types = []string{
 	"reader1",
 	"writer1",
 	"image_config",
+	"frame_config",
 }
 
 var Funcs = []string{
@@ -129,7 +131,11 @@ var Funcs = []string{
 	"writer1.mark()()",
 	"writer1.since_mark()(ret[] u8)",
 
-	"image_config.initialize!(width u32, height u32, color_model u32)()",
+	"image_config.initialize!(width u32, height u32, pixel_format u32)()",
+	"image_config.initialize_planar!(width u32, height u32, pixel_format u32, sampling u32)()",
+
+	"frame_config.initialize!(x u32, y u32, width u32, height u32, delay_ms u32, " +
+		"disposal u32, transparent_index u32, palette[] u8)()",
 }
 
 const (
@@ -146,4 +152,9 @@ var SliceFuncs = []string{
 	"T.length()(ret u64)",
 	"T.prefix(up_to u64)(ret T)",
 	"T.suffix(up_to u64)(ret T)",
+
+	// swizzle_from_palette converts the palette indexes in s to pixels in the
+	// receiver. The pixel_format is a WUFFS_BASE__PIXEL_FORMAT__ETC value, as
+	// in the C base library.
+	"T.swizzle_from_palette(s T, palette T, transparent_index u32[..256], pixel_format u32[..3])(ret u64)",
 }
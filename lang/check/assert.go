@@ -268,13 +268,28 @@ func proveBinaryOpConstValues(op t.Key, lMin *big.Int, lMax *big.Int, rMin *big.
 }
 
 func (q *checker) proveBinaryOp(op t.Key, lhs *a.Expr, rhs *a.Expr) error {
+	err := q.proveBinaryOpDirectly(op, lhs, rhs)
+	if err == errFailed && q.c != nil && q.c.opts.TransitivityDepth > 0 {
+		chain, ok := q.proveBinaryOpTransitively(op, lhs, rhs, q.c.opts.TransitivityDepth, nil)
+		if !ok {
+			return errFailed
+		}
+		if f := q.c.opts.TransitivityReport; f != nil {
+			f(q.errFilename, q.errLine, lhs.Str(q.tm)+" "+chain)
+		}
+		return nil
+	}
+	return err
+}
+
+func (q *checker) proveBinaryOpDirectly(op t.Key, lhs *a.Expr, rhs *a.Expr) error {
 	lcv := lhs.ConstValue()
 	if lcv != nil {
 		rMin, rMax, err := q.bcheckExpr(rhs, 0)
 		if err != nil {
 			return err
 		}
-		if proveBinaryOpConstValues(op, lcv, lcv, rMin, rMax) {
+		if rMin != nil && rMax != nil && proveBinaryOpConstValues(op, lcv, lcv, rMin, rMax) {
 			return nil
 		}
 	}
@@ -284,7 +299,7 @@ func (q *checker) proveBinaryOp(op t.Key, lhs *a.Expr, rhs *a.Expr) error {
 		if err != nil {
 			return err
 		}
-		if proveBinaryOpConstValues(op, lMin, lMax, rcv, rcv) {
+		if lMin != nil && lMax != nil && proveBinaryOpConstValues(op, lMin, lMax, rcv, rcv) {
 			return nil
 		}
 	}
@@ -316,10 +331,123 @@ func (q *checker) proveBinaryOp(op t.Key, lhs *a.Expr, rhs *a.Expr) error {
 				}
 			}
 		}
+
+		if rcv != nil {
+			if factCV := x.RHS().Expr().ConstValue(); factCV != nil && proveBinaryOpFromInequality(factOp, factCV, op, rcv) {
+				return nil
+			}
+		}
 	}
 	return errFailed
 }
 
+// proveBinaryOpFromInequality returns whether "x op rcv" follows from the fact
+// "x factOp factCV". This is useful when x has no bounds, such as for a
+// lemma's metavariable, as otherwise the facts.refine method already applies
+// that fact to x's bounds.
+func proveBinaryOpFromInequality(factOp t.Key, factCV *big.Int, op t.Key, rcv *big.Int) bool {
+	switch factOp {
+	case t.KeyXBinaryLessThan, t.KeyXBinaryLessEq:
+		xMax := factCV
+		if factOp == t.KeyXBinaryLessThan {
+			xMax = sub1(factCV)
+		}
+		switch op {
+		case t.KeyXBinaryNotEq, t.KeyXBinaryLessThan:
+			return xMax.Cmp(rcv) < 0
+		case t.KeyXBinaryLessEq:
+			return xMax.Cmp(rcv) <= 0
+		}
+	case t.KeyXBinaryGreaterEq, t.KeyXBinaryGreaterThan:
+		xMin := factCV
+		if factOp == t.KeyXBinaryGreaterThan {
+			xMin = add1(factCV)
+		}
+		switch op {
+		case t.KeyXBinaryNotEq, t.KeyXBinaryGreaterThan:
+			return xMin.Cmp(rcv) > 0
+		case t.KeyXBinaryGreaterEq:
+			return xMin.Cmp(rcv) >= 0
+		}
+	}
+	return false
+}
+
+// proveBinaryOpTransitively searches the facts for a chain like "lhs < x0",
+// "x0 <= x1", etc., of up to depth intermediate expressions, such that the
+// last link, like "x1 < rhs", is provable directly. On success, it returns the
+// chain after the lhs, like "< x0 <= x1 < rhs".
+//
+// The op must be an inequality. Each link in the chain must be in the same
+// direction, or an equality, and at least one link must be strict if the op
+// is strict.
+func (q *checker) proveBinaryOpTransitively(op t.Key, lhs *a.Expr, rhs *a.Expr, depth int, visited []*a.Expr) (chain string, ok bool) {
+	if depth <= 0 {
+		return "", false
+	}
+	less := false
+	switch op {
+	case t.KeyXBinaryLessThan, t.KeyXBinaryLessEq:
+		less = true
+	case t.KeyXBinaryGreaterEq, t.KeyXBinaryGreaterThan:
+		// No-op.
+	default:
+		return "", false
+	}
+
+loop:
+	for _, x := range q.facts {
+		linkOp, other := otherHandSide(x, lhs)
+		if other == nil || other.Eq(rhs) {
+			continue
+		}
+		for _, v := range visited {
+			if other.Eq(v) {
+				continue loop
+			}
+		}
+
+		remainingOp := op
+		switch linkOp.Key() {
+		case t.KeyXBinaryEqEq:
+			// No-op.
+		case t.KeyXBinaryLessThan, t.KeyXBinaryLessEq:
+			if !less {
+				continue
+			}
+			if linkOp.Key() == t.KeyXBinaryLessThan {
+				remainingOp = t.KeyXBinaryLessEq
+			}
+		case t.KeyXBinaryGreaterEq, t.KeyXBinaryGreaterThan:
+			if less {
+				continue
+			}
+			if linkOp.Key() == t.KeyXBinaryGreaterThan {
+				remainingOp = t.KeyXBinaryGreaterEq
+			}
+		default:
+			continue
+		}
+
+		link := linkOp.AmbiguousForm().Str(q.tm) + " " + other.Str(q.tm) + " "
+		if q.proveBinaryOpDirectly(remainingOp, other, rhs) == nil {
+			return link + inequalityStrings[0xFF&remainingOp] + " " + rhs.Str(q.tm), true
+		}
+		if rest, ok := q.proveBinaryOpTransitively(
+			remainingOp, other, rhs, depth-1, append(visited, lhs)); ok {
+			return link + rest, true
+		}
+	}
+	return "", false
+}
+
+var inequalityStrings = [256]string{
+	t.KeyXBinaryLessThan:    "<",
+	t.KeyXBinaryLessEq:      "<=",
+	t.KeyXBinaryGreaterEq:   ">=",
+	t.KeyXBinaryGreaterThan: ">",
+}
+
 // opImpliesOp returns whether the first op implies the second. For example,
 // knowing "x < y" implies that "x != y" and "x <= y".
 func opImpliesOp(op0 t.Key, op1 t.Key) bool {
@@ -329,6 +457,8 @@ func opImpliesOp(op0 t.Key, op1 t.Key) bool {
 	switch op0 {
 	case t.KeyXBinaryLessThan:
 		return op1 == t.KeyXBinaryNotEq || op1 == t.KeyXBinaryLessEq
+	case t.KeyXBinaryEqEq:
+		return op1 == t.KeyXBinaryLessEq || op1 == t.KeyXBinaryGreaterEq
 	case t.KeyXBinaryGreaterThan:
 		return op1 == t.KeyXBinaryNotEq || op1 == t.KeyXBinaryGreaterEq
 	}
@@ -17,6 +17,9 @@ package check
 import (
 	"fmt"
 	"math/big"
+	"sort"
+
+	"github.com/google/wuffs/lang/interval"
 
 	a "github.com/google/wuffs/lang/ast"
 	t "github.com/google/wuffs/lang/token"
@@ -203,18 +206,27 @@ func bcheckField(tm *t.Map, n *a.Field) error {
 	}
 	if nMin == nil {
 		if n.DefaultValue() != nil {
-			return fmt.Errorf("check: explicit default value %v for field %q of non-numeric innermost type %q",
-				n.DefaultValue().ConstValue(), n.Name().Str(tm), innTyp.Str(tm))
+			return fmt.Errorf("check: explicit default value %s for field %q of non-numeric innermost type %q",
+				n.DefaultValue().Str(tm), n.Name().Str(tm), innTyp.Str(tm))
 		}
 		return nil
 	}
-	dv := zero
-	if o := n.DefaultValue(); o != nil {
-		dv = o.ConstValue()
+	dvs := []*big.Int{zero}
+	if o := n.DefaultValue(); o == nil {
+		// No-op.
+	} else if o.Operator().Key() == t.KeyDollar {
+		dvs = dvs[:0]
+		for _, x := range o.Args() {
+			dvs = append(dvs, x.Expr().ConstValue())
+		}
+	} else {
+		dvs[0] = o.ConstValue()
 	}
-	if dv.Cmp(nMin) < 0 || dv.Cmp(nMax) > 0 {
-		return fmt.Errorf("check: default value %v is not within bounds [%v..%v] for field %q",
-			dv, nMin, nMax, n.Name().Str(tm))
+	for _, dv := range dvs {
+		if dv.Cmp(nMin) < 0 || dv.Cmp(nMax) > 0 {
+			return fmt.Errorf("check: default value %v is not within bounds [%v..%v] for field %q",
+				dv, nMin, nMax, n.Name().Str(tm))
+		}
 	}
 	return nil
 }
@@ -299,6 +311,9 @@ func (q *checker) bcheckStatement(n *a.Node) error {
 	case a.KRet:
 		// TODO.
 
+	case a.KSwitch:
+		return q.bcheckSwitch(n.Switch())
+
 	case a.KVar:
 		return q.bcheckVar(n.Var())
 
@@ -343,11 +358,18 @@ func (q *checker) bcheckAssert(n *a.Assert) error {
 		if cv.Cmp(one) == 0 {
 			err = nil
 		}
-	} else if reasonID := n.Reason(); reasonID != 0 {
-		if reasonFunc := q.reasonMap[reasonID.Key()]; reasonFunc != nil {
+	} else if reason := n.Reason(); reason[1] != 0 {
+		if reason[0] != 0 {
+			// A used package's lemma, such as foo."bar".
+			if lemma := q.c.lemmas[reason]; lemma != nil {
+				err = q.applyLemma(lemma, n)
+			} else {
+				err = fmt.Errorf("no such reason %s", reason.Str(q.tm))
+			}
+		} else if reasonFunc := q.reasonMap[reason[1].Key()]; reasonFunc != nil {
 			err = reasonFunc(q, n)
 		} else {
-			err = fmt.Errorf("no such reason %s", reasonID.Str(q.tm))
+			err = fmt.Errorf("no such reason %s", reason.Str(q.tm))
 		}
 	} else if condition.Operator().IsBinaryOp() && condition.Operator().Key() != t.KeyAs {
 		err = q.proveBinaryOp(condition.Operator().Key(), condition.LHS().Expr(), condition.RHS().Expr())
@@ -495,7 +517,8 @@ func (q *checker) bcheckAssignment2(lhs *a.Expr, lTyp *a.TypeExpr, op t.ID, rhs
 
 // terminates returns whether a block of statements terminates. In other words,
 // whether the block is non-empty and its final statement is a "return",
-// "break", "continue" or an "if-else" chain where all branches terminate.
+// "break", "continue", an "if-else" chain where all branches terminate or a
+// "switch" where all cases, including the else, terminate.
 //
 // TODO: strengthen this to include "while" statements? For inspiration, the Go
 // spec has https://golang.org/ref/spec#Terminating_statements
@@ -522,6 +545,14 @@ func terminates(body []*a.Node) bool {
 			return true
 		case a.KRet:
 			return n.Ret().Keyword().Key() == t.KeyReturn
+		case a.KSwitch:
+			n := n.Switch()
+			for _, o := range n.Cases() {
+				if !terminates(o.Case().Body()) {
+					return false
+				}
+			}
+			return terminates(n.BodyElse())
 		}
 		return false
 	}
@@ -609,6 +640,89 @@ func (q *checker) bcheckIf(n *a.If) error {
 	return q.unify(branches)
 }
 
+func (q *checker) bcheckSwitch(n *a.Switch) error {
+	// TODO: check that n.Subject() has no side effects.
+	subject := n.Subject()
+	if _, _, err := q.bcheckExpr(subject, 0); err != nil {
+		return err
+	}
+	sMin, sMax, err := typeBounds(q.tm, subject.MType())
+	if err != nil {
+		return err
+	}
+	if sMin == nil || sMax == nil {
+		return fmt.Errorf("check: switch subject %q, of type %q, has no bounds",
+			subject.Str(q.tm), subject.MType().Str(q.tm))
+	}
+
+	// Check that each case is within the subject's type's bounds, and that no
+	// two cases overlap. Overlapping cases would be duplicate C case labels.
+	cases := append([]*a.Node(nil), n.Cases()...)
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Case().Min().ConstValue().Cmp(cases[j].Case().Min().ConstValue()) < 0
+	})
+	for i, o := range cases {
+		o := o.Case()
+		cMin, cMax := o.Min().ConstValue(), o.Max().ConstValue()
+		if cMin.Cmp(sMin) < 0 || cMax.Cmp(sMax) > 0 {
+			q.errFilename, q.errLine = o.Node().Raw().FilenameLine()
+			return fmt.Errorf("check: case %v..%v is not within bounds [%v..%v] for switch subject %q",
+				cMin, cMax, sMin, sMax, subject.Str(q.tm))
+		}
+		if i > 0 {
+			if prevMax := cases[i-1].Case().Max().ConstValue(); cMin.Cmp(prevMax) <= 0 {
+				q.errFilename, q.errLine = o.Node().Raw().FilenameLine()
+				return fmt.Errorf("check: duplicate case value %v for switch subject %q",
+					cMin, subject.Str(q.tm))
+			}
+		}
+	}
+
+	snap := snapshot(q.facts)
+	branches := [][]*a.Expr(nil)
+	for _, o := range n.Cases() {
+		o := o.Case()
+		q.errFilename, q.errLine = o.Node().Raw().FilenameLine()
+
+		// Check the case body, assuming that the subject is in [min..max].
+		q.facts = append(q.facts[:0], snap...)
+		if subject.Pure() {
+			if !o.IsRange() {
+				q.facts.appendFact(makeSwitchFact(t.IDXBinaryEqEq, subject, o.Min()))
+			} else {
+				if o.Min().ConstValue().Cmp(sMin) > 0 {
+					q.facts.appendFact(makeSwitchFact(t.IDXBinaryGreaterEq, subject, o.Min()))
+				}
+				if o.Max().ConstValue().Cmp(sMax) < 0 {
+					q.facts.appendFact(makeSwitchFact(t.IDXBinaryLessEq, subject, o.Max()))
+				}
+			}
+		}
+		if err := q.bcheckBlock(o.Body()); err != nil {
+			return err
+		}
+		if !terminates(o.Body()) {
+			branches = append(branches, snapshot(q.facts))
+		}
+	}
+
+	// Check the else body, assuming nothing new.
+	q.facts = append(q.facts[:0], snap...)
+	if err := q.bcheckBlock(n.BodyElse()); err != nil {
+		return err
+	}
+	if !terminates(n.BodyElse()) {
+		branches = append(branches, snapshot(q.facts))
+	}
+	return q.unify(branches)
+}
+
+func makeSwitchFact(op t.ID, subject *a.Expr, value *a.Expr) *a.Expr {
+	o := a.NewExpr(a.FlagsTypeChecked, op, 0, 0, subject.Node(), nil, value.Node(), nil)
+	o.SetMType(typeExprBool)
+	return o
+}
+
 func (q *checker) bcheckWhile(n *a.While) error {
 	// Check the pre and inv conditions on entry.
 	for _, o := range n.Asserts() {
@@ -729,6 +843,12 @@ func (q *checker) bcheckExpr(n *a.Expr, depth uint32) (*big.Int, *big.Int, error
 	}
 	depth++
 
+	if n.ConstValue() == nil && n.MType().IsIdeal() {
+		// A non-constant ideal expression, such as a lemma's metavariable, is
+		// an integer in ℤ and has no bounds.
+		return nil, nil, nil
+	}
+
 	nMin, nMax, err := q.bcheckExpr1(n, depth)
 	if err != nil {
 		return nil, nil, err
@@ -997,6 +1117,12 @@ func (q *checker) bcheckExprUnaryOp(n *a.Expr, depth uint32) (*big.Int, *big.Int
 		return neg(rMax), neg(rMin), nil
 	case t.KeyXUnaryNot:
 		return zero, one, nil
+	case t.KeyXUnaryTilde:
+		// For an unsigned integer type whose maximum value is m, ~x equals (m
+		// - x), or equivalently, ((-x - 1) + (m + 1)).
+		m1 := add1(numTypeBounds[n.MType().QID()[1].Key()][1])
+		z := interval.IntRange{rMin, rMax}.Not().Add(interval.IntRange{m1, m1})
+		return z[0], z[1], nil
 	case t.KeyXUnaryRef, t.KeyXUnaryDeref:
 		return q.bcheckTypeExpr(n.MType())
 	}
@@ -1044,18 +1170,21 @@ func (q *checker) bcheckExprBinaryOp1(op t.Key, lhs *a.Expr, lMin *big.Int, lMax
 		return nMin, nMax, nil
 
 	case t.KeyXBinaryStar:
-		// TODO: handle multiplication by negative numbers. Note that this
-		// might reverse the inequality: if 0 < a < b but c < 0 then a*c > b*c.
-		if lMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: multiply op argument %q is possibly negative", lhs.Str(q.tm))
-		}
-		if rMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: multiply op argument %q is possibly negative", rhs.Str(q.tm))
-		}
-		return big.NewInt(0).Mul(lMin, rMin), big.NewInt(0).Mul(lMax, rMax), nil
+		// Multiplying by a negative number might reverse the inequality: if 0
+		// < a < b but c < 0 then a*c > b*c. interval.IntRange.Mul handles
+		// that.
+		z := interval.IntRange{lMin, lMax}.Mul(interval.IntRange{rMin, rMax})
+		return z[0], z[1], nil
 
 	case t.KeyXBinarySlash:
-		// TODO.
+		if rMin.Sign() <= 0 {
+			return nil, nil, fmt.Errorf("check: divide op argument %q is possibly non-positive", rhs.Str(q.tm))
+		}
+		z, ok := interval.IntRange{lMin, lMax}.Quo(interval.IntRange{rMin, rMax})
+		if !ok {
+			return nil, nil, fmt.Errorf("check: internal error: bad divide op bounds")
+		}
+		return z[0], z[1], nil
 
 	case t.KeyXBinaryShiftL:
 		if lMin.Sign() < 0 {
@@ -1070,12 +1199,21 @@ func (q *checker) bcheckExprBinaryOp1(op t.Key, lhs *a.Expr, lMin *big.Int, lMax
 		return big.NewInt(0).Lsh(lMin, uint(rMin.Uint64())), big.NewInt(0).Lsh(lMax, uint(rMax.Uint64())), nil
 
 	case t.KeyXBinaryShiftR:
-		if lMin.Sign() < 0 {
-			return nil, nil, fmt.Errorf("check: shift op argument %q is possibly negative", lhs.Str(q.tm))
-		}
 		if rMin.Sign() < 0 {
 			return nil, nil, fmt.Errorf("check: shift op argument %q is possibly negative", rhs.Str(q.tm))
 		}
+		if lMin.Sign() < 0 {
+			// Shifting a signed integer right is an arithmetic shift, rounding
+			// towards negative infinity.
+			if rMax.Cmp(maxIntBits) > 0 {
+				rMax = maxIntBits
+			}
+			z, ok := interval.IntRange{lMin, lMax}.Rsh(interval.IntRange{rMin, rMax})
+			if !ok {
+				return nil, nil, fmt.Errorf("check: internal error: bad shift op bounds")
+			}
+			return z[0], z[1], nil
+		}
 		if rMin.Cmp(maxIntBits) >= 0 {
 			return zero, zero, nil
 		}
@@ -1100,16 +1238,18 @@ func (q *checker) bcheckExprBinaryOp1(op t.Key, lhs *a.Expr, lMin *big.Int, lMax
 		if rMax.Cmp(numTypeBounds[t.KeyU64][1]) > 0 {
 			return nil, nil, fmt.Errorf("check: bitwise op argument %q is possibly too large", rhs.Str(q.tm))
 		}
-		z := (*big.Int)(nil)
+		f := interval.IntRange.And
 		switch op {
-		case t.KeyXBinaryAmp:
-			z = min(lMax, rMax)
-		case t.KeyXBinaryPipe, t.KeyXBinaryHat:
-			z = max(lMax, rMax)
+		case t.KeyXBinaryPipe:
+			f = interval.IntRange.Or
+		case t.KeyXBinaryHat:
+			f = interval.IntRange.Xor
+		}
+		z, ok := f(interval.IntRange{lMin, lMax}, interval.IntRange{rMin, rMax})
+		if !ok {
+			return nil, nil, fmt.Errorf("check: internal error: bad bitwise op bounds")
 		}
-		// Return [0, z rounded up to the next power-of-2-minus-1]. This is
-		// conservative, but works fine in practice.
-		return zero, bitMask(z.BitLen()), nil
+		return z[0], z[1], nil
 
 	case t.KeyXBinaryAmpHat:
 		// TODO.
@@ -1121,7 +1261,11 @@ func (q *checker) bcheckExprBinaryOp1(op t.Key, lhs *a.Expr, lMin *big.Int, lMax
 		if rMin.Sign() <= 0 {
 			return nil, nil, fmt.Errorf("check: modulus op argument %q is possibly non-positive", rhs.Str(q.tm))
 		}
-		return zero, big.NewInt(0).Sub(rMax, one), nil
+		z, ok := interval.IntRange{lMin, lMax}.Rem(interval.IntRange{rMin, rMax})
+		if !ok {
+			return nil, nil, fmt.Errorf("check: internal error: bad modulus op bounds")
+		}
+		return z[0], z[1], nil
 
 	case t.KeyXBinaryNotEq, t.KeyXBinaryLessThan, t.KeyXBinaryLessEq, t.KeyXBinaryEqEq,
 		t.KeyXBinaryGreaterEq, t.KeyXBinaryGreaterThan, t.KeyXBinaryAnd, t.KeyXBinaryOr:
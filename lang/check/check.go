@@ -60,7 +60,21 @@ func (e *Error) Error() string {
 	return string(b)
 }
 
-func Check(tm *t.Map, files []*a.File, resolveUse func(usePath string) ([]byte, error)) (*Checker, error) {
+type Options struct {
+	// TransitivityDepth is the maximum number of intermediate expressions in
+	// a chain of facts, such as "a < c" and "c <= d", that the checker
+	// searches for when it cannot otherwise prove an assertion such as "a <
+	// b". The last link of the chain, such as "d <= b", must be provable
+	// directly. Zero, the default, disables the search.
+	TransitivityDepth int
+
+	// TransitivityReport, if non-nil, is called for each assertion proven by
+	// that search, with the chain of expressions used, such as "a < c <= d <=
+	// b".
+	TransitivityReport func(filename string, line uint32, chain string)
+}
+
+func Check(tm *t.Map, files []*a.File, resolveUse func(usePath string) ([]byte, error), opts *Options) (*Checker, error) {
 	for _, f := range files {
 		if f == nil {
 			return nil, errors.New("check: Check given a nil *ast.File")
@@ -84,16 +98,25 @@ func Check(tm *t.Map, files []*a.File, resolveUse func(usePath string) ([]byte,
 		}
 	}
 	c := &Checker{
-		tm:           tm,
-		resolveUse:   resolveUse,
-		reasonMap:    rMap,
-		packageID:    base38.Max + 1,
-		consts:       map[t.QID]*a.Const{},
-		funcs:        map[t.QQID]*a.Func{},
-		localVars:    map[t.QQID]typeMap{},
-		statuses:     map[t.QID]*a.Status{},
-		structs:      map[t.QID]*a.Struct{},
-		useBaseNames: map[t.ID]struct{}{},
+		tm:               tm,
+		resolveUse:       resolveUse,
+		reasonMap:        rMap,
+		packageID:        base38.Max + 1,
+		consts:           map[t.QID]*a.Const{},
+		computeFuncs:     map[t.QQID]bool{},
+		evaluatingConsts: map[*a.Const]bool{},
+		suspendibleCalls: map[t.QQID][]suspendibleCall{},
+		recursionDepths:  map[t.QQID]uint32{},
+		callees:          map[t.QQID][]*a.Func{},
+		funcs:            map[t.QQID]*a.Func{},
+		lemmas:           map[t.QID]*a.Lemma{},
+		localVars:        map[t.QQID]typeMap{},
+		statuses:         map[t.QID]*a.Status{},
+		structs:          map[t.QID]*a.Struct{},
+		useBaseNames:     map[t.ID]struct{}{},
+	}
+	if opts != nil {
+		c.opts = *opts
 	}
 
 	for _, phase := range phases {
@@ -127,12 +150,15 @@ var phases = [...]struct {
 	{a.KUse, (*Checker).checkUse},
 	{a.KStatus, (*Checker).checkStatus},
 	{a.KConst, (*Checker).checkConst},
+	{a.KLemma, (*Checker).checkLemma},
 	{a.KStruct, (*Checker).checkStructDecl},
 	{a.KInvalid, (*Checker).checkStructCycles},
 	{a.KStruct, (*Checker).checkStructFields},
 	{a.KFunc, (*Checker).checkFuncSignature},
 	{a.KFunc, (*Checker).checkFuncContract},
 	{a.KFunc, (*Checker).checkFuncBody},
+	{a.KFunc, (*Checker).checkFuncRecursion},
+	{a.KConst, (*Checker).checkConstComputed},
 	{a.KStruct, (*Checker).checkFieldMethodCollisions},
 	// TODO: check consts, funcs, structs and uses for name collisions.
 }
@@ -145,16 +171,34 @@ type Checker struct {
 	tm         *t.Map
 	resolveUse func(usePath string) ([]byte, error)
 	reasonMap  reasonMap
+	opts       Options
 
 	packageID      uint32
 	otherPackageID *a.PackageID
 
 	consts    map[t.QID]*a.Const
 	funcs     map[t.QQID]*a.Func
+	lemmas    map[t.QID]*a.Lemma
 	localVars map[t.QQID]typeMap
 	statuses  map[t.QID]*a.Status
 	structs   map[t.QID]*a.Struct
 
+	// computeFuncs are the functions used to compute const lists, like the f
+	// in "$(f(i:0..255))". evaluatingConsts are those consts whose elements
+	// are being evaluated, and is used to detect cycles.
+	computeFuncs     map[t.QQID]bool
+	evaluatingConsts map[*a.Const]bool
+
+	// suspendibleCalls are, for each function, the calls it makes to this
+	// package's suspendible functions. recursionDepths are, for each function
+	// that calls itself, the maximum number of simultaneous activations.
+	suspendibleCalls map[t.QQID][]suspendibleCall
+	recursionDepths  map[t.QQID]uint32
+
+	// callees are, for each function, the distinct functions in this package
+	// that it calls, suspendible or not.
+	callees map[t.QQID][]*a.Func
+
 	// useBaseNames are the base names of packages referred to by `use
 	// "foo/bar"` lines. The keys are `bar`, not `"foo/bar"`.
 	useBaseNames map[t.ID]struct{}
@@ -262,6 +306,17 @@ func (c *Checker) checkUse(node *a.Node) error {
 			} else {
 				c.funcs[qqid] = n
 			}
+		case a.KLemma:
+			// A used package's lemmas were proven when checking that package,
+			// and its interface file does not contain the proofs.
+			n := n.Lemma()
+			if err := c.checkLemmaName(n); err != nil {
+				return err
+			}
+			if _, err := c.tcheckLemma(n); err != nil {
+				return fmt.Errorf("%v in lemma %s", err, n.QID().Str(c.tm))
+			}
+			c.registerLemma(n)
 		case a.KStatus:
 			n := n.Status()
 			qid := n.QID()
@@ -349,7 +404,9 @@ func (c *Checker) checkConst(node *a.Node) error {
 	if nMin == nil || nMax == nil {
 		return fmt.Errorf("check: invalid const type %q for %s", n.XType().Str(c.tm), qid.Str(c.tm))
 	}
-	if err := c.checkConstElement(n.Value(), nMin, nMax, nLists); err != nil {
+	if v := n.Value(); v.Operator().Key() == t.KeyDollar && v.Ident() != 0 {
+		// A computed list's elements are checked by checkConstComputed.
+	} else if err := c.checkConstElement(v, nMin, nMax, nLists); err != nil {
 		return fmt.Errorf("check: %v for %s", err, qid.Str(c.tm))
 	}
 	n.Node().SetTypeChecked()
@@ -435,11 +492,7 @@ func (c *Checker) checkFields(fields []*a.Node, banPtrTypes bool) error {
 				f.XType().Str(c.tm), f.Name().Str(c.tm))
 		}
 		if dv := f.DefaultValue(); dv != nil {
-			if f.XType().Decorator() != 0 {
-				return fmt.Errorf("check: cannot set default value for type %q for field %q",
-					f.XType().Str(c.tm), f.Name().Str(c.tm))
-			}
-			if err := q.tcheckExpr(dv, 0); err != nil {
+			if err := q.tcheckFieldDefaultValue(f, dv); err != nil {
 				return err
 			}
 		}
@@ -453,6 +506,49 @@ func (c *Checker) checkFields(fields []*a.Node, banPtrTypes bool) error {
 	return nil
 }
 
+// tcheckFieldDefaultValue checks a field's default value, dv. For an array
+// typed field, dv is either a constant, the default value of every element,
+// or a list such as "$(1, 2, 3)" with one constant per element of a
+// one-dimensional array.
+func (q *checker) tcheckFieldDefaultValue(f *a.Field, dv *a.Expr) error {
+	typ := f.XType()
+	for typ.Decorator().Key() == t.KeyOpenBracket {
+		typ = typ.Inner()
+	}
+	if typ.Decorator() != 0 {
+		return fmt.Errorf("check: cannot set default value for type %q for field %q",
+			f.XType().Str(q.tm), f.Name().Str(q.tm))
+	}
+	if err := q.tcheckExpr(dv, 0); err != nil {
+		return err
+	}
+
+	elems := []*a.Node{dv.Node()}
+	if dv.Operator().Key() == t.KeyDollar {
+		if dv.Ident() != 0 {
+			return fmt.Errorf("check: computed list default value %q not allowed for field %q",
+				dv.Str(q.tm), f.Name().Str(q.tm))
+		}
+		typ := f.XType()
+		if typ.Decorator().Key() != t.KeyOpenBracket || typ.Inner().Decorator() != 0 {
+			return fmt.Errorf("check: list default value %q needs a one-dimensional array type for field %q",
+				dv.Str(q.tm), f.Name().Str(q.tm))
+		}
+		if n := typ.ArrayLength().ConstValue(); n.Cmp(big.NewInt(int64(len(dv.Args())))) != 0 {
+			return fmt.Errorf("check: list default value has %d elements but field %q has %v",
+				len(dv.Args()), f.Name().Str(q.tm), n)
+		}
+		elems = dv.Args()
+	}
+	for _, o := range elems {
+		if o.Expr().ConstValue() == nil {
+			return fmt.Errorf("check: default value %q is not constant for field %q",
+				o.Expr().Str(q.tm), f.Name().Str(q.tm))
+		}
+	}
+	return nil
+}
+
 func (c *Checker) checkFuncSignature(node *a.Node) error {
 	n := node.Func()
 	if err := c.checkFields(n.In().Fields(), false); err != nil {
@@ -608,6 +704,142 @@ func (c *Checker) checkFuncBody(node *a.Node) error {
 	return nil
 }
 
+// maxRecursionDepth is the maximum number of simultaneous activations of a
+// recursive suspendible function. Each activation needs its own coroutine
+// state in the receiver struct.
+const maxRecursionDepth = 256
+
+type suspendibleCall struct {
+	callee   *a.Func
+	call     *a.Expr
+	filename string
+	line     uint32
+}
+
+// RecursionDepth returns the maximum number of simultaneous activations of
+// the function f: one plus the upper bound of its depth argument if f calls
+// itself, or one otherwise.
+func (c *Checker) RecursionDepth(f *a.Func) uint32 {
+	if d := c.recursionDepths[f.QQID()]; d != 0 {
+		return d
+	}
+	return 1
+}
+
+// Callees returns the functions in this package that f calls directly.
+func (c *Checker) Callees(f *a.Func) []*a.Func {
+	return c.callees[f.QQID()]
+}
+
+func (c *Checker) addCallee(caller t.QQID, f *a.Func) {
+	for _, o := range c.callees[caller] {
+		if o == f {
+			return
+		}
+	}
+	c.callees[caller] = append(c.callees[caller], f)
+}
+
+// checkFuncRecursion checks that a suspendible function only calls itself
+// directly, not via other functions, and with a bounded recursion depth.
+//
+// A recursive suspendible function needs a depth argument whose type has an
+// upper bound, such as u32[..7], and each recursive call must pass
+// "depth:in.depth + 1". The bounds checker then proves at each recursive call
+// site that the depth stays within that bound.
+func (c *Checker) checkFuncRecursion(node *a.Node) error {
+	n := node.Func()
+	if !n.Suspendible() {
+		return nil
+	}
+	qqid := n.QQID()
+	for _, o := range c.suspendibleCalls[qqid] {
+		err := error(nil)
+		if o.callee == n {
+			err = c.checkRecursiveCall(n, o.call)
+		} else if c.callsReach(o.callee, n, map[*a.Func]bool{}) {
+			err = fmt.Errorf("check: %s and %s are mutually recursive",
+				qqid.Str(c.tm), o.callee.QQID().Str(c.tm))
+		}
+		if err != nil {
+			return &Error{
+				Err:      err,
+				Filename: o.filename,
+				Line:     o.line,
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkRecursiveCall(n *a.Func, call *a.Expr) error {
+	depthName := c.tm.ByName("depth")
+	depthField := (*a.Field)(nil)
+	for _, o := range n.In().Fields() {
+		if o := o.Field(); o.Name() == depthName && depthName != 0 {
+			depthField = o
+			break
+		}
+	}
+	if depthField == nil {
+		return fmt.Errorf("check: recursive function %s has no depth argument", n.QQID().Str(c.tm))
+	}
+
+	typ := depthField.XType()
+	if !typ.IsUnsignedInteger() || !typ.IsRefined() || typ.Max() == nil {
+		return fmt.Errorf("check: recursive function %s's depth argument needs a refined type "+
+			"with an upper bound, such as u32[..7]", n.QQID().Str(c.tm))
+	}
+	max := typ.Max().ConstValue()
+	if max.Cmp(big.NewInt(maxRecursionDepth-1)) > 0 {
+		return fmt.Errorf("check: recursive function %s's depth argument bound %v is larger than %d",
+			n.QQID().Str(c.tm), max, maxRecursionDepth-1)
+	}
+
+	for _, o := range call.Args() {
+		if o := o.Arg(); o.Name() == depthName {
+			if !isInDepthPlusOne(o.Value(), depthName) {
+				return fmt.Errorf("check: recursive call %q does not pass depth:in.depth + 1",
+					call.Str(c.tm))
+			}
+			break
+		}
+	}
+	c.recursionDepths[n.QQID()] = uint32(max.Int64()) + 1
+	return nil
+}
+
+// isInDepthPlusOne returns whether n is "in.depth + 1".
+func isInDepthPlusOne(n *a.Expr, depthName t.ID) bool {
+	if n.Operator().Key() != t.KeyXBinaryPlus {
+		return false
+	}
+	if cv := n.RHS().Expr().ConstValue(); cv == nil || cv.Cmp(one) != 0 {
+		return false
+	}
+	lhs := n.LHS().Expr()
+	return lhs.Operator().Key() == t.KeyDot && lhs.Ident() == depthName &&
+		lhs.LHS().Expr().Operator() == 0 && lhs.LHS().Expr().Ident().Key() == t.KeyIn
+}
+
+// callsReach returns whether f calls target, directly or indirectly, via
+// suspendible calls.
+func (c *Checker) callsReach(f *a.Func, target *a.Func, visited map[*a.Func]bool) bool {
+	if f == target {
+		return true
+	}
+	if visited[f] {
+		return false
+	}
+	visited[f] = true
+	for _, o := range c.suspendibleCalls[f.QQID()] {
+		if c.callsReach(o.callee, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Checker) checkFieldMethodCollisions(node *a.Node) error {
 	n := node.Struct()
 	for _, o := range n.Fields() {
@@ -56,6 +56,40 @@ func compareToWuffsfmt(tm *t.Map, tokens []t.Token, comments []string, src strin
 	return nil
 }
 
+// checkSource tokenizes, parses and checks src, as the sole file of a
+// package.
+func checkSource(tm *t.Map, src string, resolveUse func(usePath string) ([]byte, error), opts *Options) (*Checker, error) {
+	const filename = "test.wuffs"
+	tokens, _, err := t.Tokenize(tm, filename, []byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("Tokenize: %v", err)
+	}
+	file, err := parse.Parse(tm, filename, tokens, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Parse: %v", err)
+	}
+	c, err := Check(tm, []*a.File{file}, resolveUse, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Check: %v", err)
+	}
+	return c, nil
+}
+
+// checkError returns a non-nil error if err does not match want, which is
+// either "" for no error or a substring of the expected error message.
+func checkError(err error, want string) error {
+	if err == nil {
+		if want != "" {
+			return fmt.Errorf("got nil error, want %q", want)
+		}
+		return nil
+	}
+	if got := err.Error(); want == "" || !strings.Contains(got, want) {
+		return fmt.Errorf("got %q, want %q", got, want)
+	}
+	return nil
+}
+
 func TestCheck(tt *testing.T) {
 	const filename = "test.wuffs"
 	src := strings.TrimSpace(`
@@ -65,6 +99,10 @@ func TestCheck(tt *testing.T) {
 			i i32,
 		)
 
+		pri lemma "a < c: a < b; b <= c": a < c; a < b; b <= c {
+			assert a < c via "a < b: a < c; c <= b"(c:b)
+		}
+
 		pri func foo.bar()() {
 			var x u8
 			var y i32 = +2
@@ -85,6 +123,18 @@ func TestCheck(tt *testing.T) {
 
 			assert true
 
+			switch x {
+			case 0 {
+				y = 1
+			}
+			case 1..9 {
+				assert x >= 1
+				y = 2
+			}
+			else {
+			}
+			}
+
 			while:label p == q,
 				pre true,
 				inv true,
@@ -112,7 +162,7 @@ func TestCheck(tt *testing.T) {
 		tt.Fatalf("compareToWuffsfmt: %v", err)
 	}
 
-	c, err := Check(tm, []*a.File{file}, nil)
+	c, err := Check(tm, []*a.File{file}, nil, nil)
 	if err != nil {
 		tt.Fatalf("Check: %v", err)
 	}
@@ -213,7 +263,7 @@ func TestConstValues(tt *testing.T) {
 			continue
 		}
 
-		c, err := Check(tm, []*a.File{file}, nil)
+		c, err := Check(tm, []*a.File{file}, nil, nil)
 		if err != nil {
 			tt.Errorf("%q: Check: %v", s, err)
 			continue
@@ -247,6 +297,311 @@ func TestConstValues(tt *testing.T) {
 	}
 }
 
+func TestComputedConst(tt *testing.T) {
+	const funcs = "pri func sq(i u32[..15])(x u32) {\n\treturn in.i * in.i\n}\n" +
+		"pri func half(i i32[-4..4])(x i32) {\n\treturn in.i / 2\n}\n" +
+		"pri func rev(i u8)(x u8) {\n\tvar v u8 = in.i\n\tvar r u8\n\tvar k u32\n" +
+		"\twhile k < 8 {\n\t\tr = ((r & 0x7F) << 1) | (v & 1)\n\t\tv = v >> 1\n\t\tk += 1\n\t}\n" +
+		"\treturn r\n}\n" +
+		"pri func imp!(i u32[..15])(x u32) {\n\treturn 0\n}\n"
+	testCases := map[string]string{
+		"pri const t[4] u32 = $(sq(i:0..3))":     "$(0, 1, 4, 9)",
+		"pri const t[3] u32 = $(sq(i:1..3))":     "$(1, 4, 9)",
+		"pri const t[4] u8 = $(rev(i:0..3))":     "$(0, 128, 64, 192)",
+		"pri const t[5] i32 = $(half(i:-2..2))":  "$(-1, 0, 0, 0, 1)",
+		"pri const t[4] u8[..5] = $(sq(i:0..3))": "is not within [0..5]",
+		"pri const t[5] u32 = $(sq(i:0..3))":     "does not have 5 elements",
+		"pri const t[17] u32 = $(sq(i:0..16))":   "is not within sq's argument bounds",
+		"pri const t[4] u32 = $(nope(i:0..3))":   "no free-standing function nope",
+		"pri const t[4] u32 = $(sq(j:0..3))":     "has no argument named j",
+		"pri const t[4] u32 = $(imp(i:0..3))":    "is not pure",
+
+		"pri func sq1(i u32[..3])(x u32) {\n\treturn s[in.i] + 1\n}\n" +
+			"pri const t[4] u32 = $(sq1(i:0..3))\npri const s[4] u32 = $(sq(i:0..3))": "$(1, 2, 5, 10)",
+		"pri func loop(i u32[..2])(x u32) {\n\treturn t[in.i]\n}\n" +
+			"pri const t[3] u32 = $(loop(i:0..2))": "refers to itself",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\n" + funcs + s + "\n"
+
+		c, err := checkSource(tm, src, nil, nil)
+		if !strings.HasPrefix(want, "$(") {
+			if err := checkError(err, want); err != nil {
+				tt.Errorf("%q: %v", s, err)
+			}
+			continue
+		}
+		if err != nil {
+			tt.Errorf("%q: %v", s, err)
+			continue
+		}
+		if got := c.consts[t.QID{0, tm.ByName("t")}].Value().Str(tm); got != want {
+			tt.Errorf("%q: got %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestRecursion(tt *testing.T) {
+	const guard = "\tif in.depth < 7 {\n\t\tthis.f?(depth:in.depth + 1)\n\t}\n"
+	testCases := map[string]string{
+		"pri func foo.f?(depth u32[..7])() {\n" + guard + "}\n":   "",
+		"pri func foo.f?(d u32[..7])() {\n\tthis.f?(d:0)\n}\n":    "has no depth argument",
+		"pri func foo.f?(depth u32)() {\n" + guard + "}\n":        "needs a refined type",
+		"pri func foo.f?(depth u32[..256])() {\n" + guard + "}\n": "is larger than 255",
+
+		"pri func foo.f?(depth u32[..7])() {\n\tthis.f?(depth:in.depth + 1)\n}\n": "not within bounds",
+		"pri func foo.f?(depth u32[..7])() {\n\tthis.f?(depth:in.depth)\n}\n":     "does not pass depth:in.depth + 1",
+		"pri func foo.f?(depth u32[..7])() {\n\tthis.f?(depth:7)\n}\n":            "does not pass depth:in.depth + 1",
+
+		"pri func foo.f?()() {\n\tthis.g?()\n}\npri func foo.g?()() {\n\tthis.f?()\n}\n": "mutually recursive",
+		"pri func foo.f?()() {\n\tthis.g?()\n}\npri func foo.g?()() {\n\tthis.h?()\n}\n" +
+			"pri func foo.h?()() {\n}\n": "",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npub struct foo?()\n" + s
+
+		c, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+		if err != nil || want != "" {
+			continue
+		}
+
+		for _, o := range c.funcs {
+			wantDepth := uint32(1)
+			if o.FuncName().Str(tm) == "f" && len(o.In().Fields()) == 1 {
+				wantDepth = 8
+			}
+			if got := c.RecursionDepth(o); got != wantDepth {
+				tt.Errorf("%q: RecursionDepth(%s): got %d, want %d", s, o.QQID().Str(tm), got, wantDepth)
+			}
+		}
+	}
+}
+
+func TestCallees(tt *testing.T) {
+	src := "packageid \"test\"\npub struct foo?()\n" +
+		"pub func foo.f?()() {\n\tthis.g!()\n\tthis.h?()\n\tthis.g!()\n}\n" +
+		"pri func foo.g!()() {\n}\n" +
+		"pri func foo.h?()() {\n\tthis.g!()\n}\n" +
+		"pri func foo.unused!()() {\n}\n"
+
+	tm := &t.Map{}
+	c, err := checkSource(tm, src, nil, nil)
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	want := map[string]string{
+		"foo.f":      "foo.g, foo.h",
+		"foo.g":      "",
+		"foo.h":      "foo.g",
+		"foo.unused": "",
+	}
+	for _, o := range c.funcs {
+		name := o.QQID().Str(tm)
+		callees := []string(nil)
+		for _, callee := range c.Callees(o) {
+			callees = append(callees, callee.QQID().Str(tm))
+		}
+		if got := strings.Join(callees, ", "); got != want[name] {
+			tt.Errorf("Callees(%s): got %q, want %q", name, got, want[name])
+		}
+	}
+}
+
+func TestFieldDefaultValue(tt *testing.T) {
+	testCases := map[string]string{
+		"x u8":                    "",
+		"x u8 = 3":                "",
+		"x[4] u8 = 3":             "",
+		"x[2][3] u8[..9] = 9":     "",
+		"x[4] u8 = $(1, 2, 3, 4)": "",
+		"x[4] u8 = $(1, 2, 3)":    "list default value has 3 elements",
+		"x[2] u8 = $(1, 256)":     "is not within bounds",
+		"x[4] u8[..9] = 10":       "is not within bounds",
+		"x[2][2] u8 = $(1, 2)":    "needs a one-dimensional array type",
+		"x[2] bar":                "",
+		"x[2][3] bar":             "",
+		"x[2] bar = 0":            "non-numeric innermost type",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npub struct foo?(\n\t" + s + ",\n)\npri struct bar(\n\ty u32,\n)\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
+func TestSwitch(tt *testing.T) {
+	testCases := map[string]string{
+		"switch x { case 1 { assert x == 1; }; else { }; }":    "",
+		"switch x { case 1..9 { assert x >= 1; }; else { }; }": "",
+		"switch x { case 1..9 { assert x <= 9; }; else { }; }": "",
+		"switch x { case 1..9 { assert x <= 8; }; else { }; }": "cannot prove",
+		"switch x { case 1 { }; else { assert x == 1; }; }":    "cannot prove",
+		"switch x { case 1..9 { }; case 9 { }; else { }; }":    "duplicate case value 9",
+		"switch x { case 0..256 { }; else { }; }":              "not within bounds",
+		"switch x { case 9..1 { }; else { }; }":                "is empty",
+		"switch x { case y { }; else { }; }":                   "is not constant",
+		"switch b { case 0 { }; else { }; }":                   "does not have a numeric type",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npri func foo()() {\n\tvar x u8\n\tvar y u8\n\tvar b bool\n\t" + s + "\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
+func TestLemma(tt *testing.T) {
+	const trans = "pri lemma \"trans\": a < d; a < b; b < c; c < d {\n" +
+		"\tassert a < c via \"a < b: a < c; c < b\"(c:b)\n" +
+		"\tassert a < d via \"a < b: a < c; c < b\"(c:c)\n" +
+		"}\n"
+	testCases := map[string]string{
+		trans: "",
+		trans + "assert x < w via \"trans\"(b:y, c:z)":                     "",
+		trans + "assert x <= w via \"trans\"(b:y, c:z)":                    "cannot prove",
+		trans + "assert x < w via \"trans\"(b:y, c:x)":                     "cannot prove",
+		trans + "assert x < w via \"trans\"(b:y)":                          "no argument for c",
+		"pri lemma \"lt\": a < 20; a < 10\n":                               "",
+		"pri lemma \"lt\": a < 10; a < 20\n":                               "cannot prove",
+		"pri lemma \"lt\": a < c; a < b\n":                                 "cannot prove",
+		"pri lemma \"lt\": a + b; a < b\n":                                 "is not a comparison",
+		"pri lemma \"lt\": a.b < c\n":                                      "invalid lemma term",
+		"pri lemma \"a < b: b > a\": a < b; b > a\n":                       "duplicates a built-in reason",
+		"pri lemma \"lt\": a < b; a < b\npri lemma \"lt\": a < b; a < b\n": "duplicate lemma",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		lemmas, body := s, ""
+		if i := strings.Index(s, "}\n"); i >= 0 {
+			lemmas, body = s[:i+2], s[i+2:]
+		}
+		src := "packageid \"test\"\n" + lemmas + "pri func foo()() {\n" +
+			"\tvar x u8\n\tvar y u8\n\tvar z u8\n\tvar w u8\n" +
+			"\tif (x < y) and (y < z) and (z < w) {\n\t\t" + body + "\n\t}\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
+func TestTransitivity(tt *testing.T) {
+	testCases := []struct {
+		depth     int
+		s         string
+		wantChain string
+	}{
+		{2, "if (in.x < in.y) and (in.y <= 9) { assert in.x < 10; }", "in.x < in.y <= 10"},
+		{2, "if (in.x < in.y) and (in.y < in.z) { assert in.x < in.z; }", "in.x < in.y <= in.z"},
+		{2, "if (in.x <= in.y) and (in.y == in.z) { assert in.x <= in.z; }", "in.x <= in.y <= in.z"},
+		{2, "if (in.x > in.y) and (in.y > in.z) { assert in.x > in.z; }", "in.x > in.y >= in.z"},
+		{2, "if (in.x <= in.y) and (in.y <= in.z) { assert in.x < in.z; }", ""},
+		{2, "if (in.x < in.y) and (in.y > in.z) { assert in.x < in.z; }", ""},
+		{2, "if (in.x < in.y) and (in.y < in.z) and (in.z < in.w) { assert in.x < in.w; }", "in.x < in.y < in.z <= in.w"},
+		{1, "if (in.x < in.y) and (in.y < in.z) and (in.z < in.w) { assert in.x < in.w; }", ""},
+		{0, "if (in.x < in.y) and (in.y < in.z) { assert in.x < in.z; }", ""},
+	}
+
+	tm := &t.Map{}
+	for _, tc := range testCases {
+		src := "packageid \"test\"\npri func foo(x u8, y u8, z u8, w u8)() {\n\t" + tc.s + "\n}\n"
+
+		gotChain := ""
+		_, err := checkSource(tm, src, nil, &Options{
+			TransitivityDepth: tc.depth,
+			TransitivityReport: func(filename string, line uint32, chain string) {
+				gotChain = chain
+			},
+		})
+		if tc.wantChain == "" {
+			if err == nil || !strings.Contains(err.Error(), "cannot prove") {
+				tt.Errorf("depth=%d, %q: Check: got %v, want \"cannot prove\"", tc.depth, tc.s, err)
+			}
+		} else if err != nil {
+			tt.Errorf("depth=%d, %q: Check: %v", tc.depth, tc.s, err)
+		} else if gotChain != tc.wantChain {
+			tt.Errorf("depth=%d, %q: chain: got %q, want %q", tc.depth, tc.s, gotChain, tc.wantChain)
+		}
+	}
+}
+
+func TestBitwiseBounds(tt *testing.T) {
+	testCases := map[string]string{
+		"var z u8[0x30..0x3F] = (in.x & 0x0F) ^ 0x30": "",
+		"var z u8[0x31..0x3F] = (in.x & 0x0F) ^ 0x30": "not within bounds",
+		"var z u8[0x10..0x1F] = (in.x & 0x0F) | 0x10": "",
+		"var z u8[..0x05] = (in.x | 0xF0) & 0x05":     "",
+		"var z u8[0xF0..] = ~(in.x & 0x0F)":           "",
+		"var z u8[0xF1..] = ~(in.x & 0x0F)":           "not within bounds",
+		"var z u8[..9] = in.x % 10":                   "",
+		"var z u8[..8] = in.x % 10":                   "not within bounds",
+		"var z u8[3..4] = ((in.x & 1) + 13) % 5":      "",
+		"var z u8[0xF0..0xF0] = ~(0x0F as u8)":        "",
+		"var z u8 = ~in.b":                            "does not have an unsigned integer type",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npri func foo(x u8, b bool)() {\n\t" + s + "\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
+func TestSignedBounds(tt *testing.T) {
+	testCases := map[string]string{
+		"var z i32[-300..300] = in.x * 3":                   "",
+		"var z i32[-299..300] = in.x * 3":                   "not within bounds",
+		"var z i32[-300..300] = in.x * -3":                  "",
+		"var z i32[-300..299] = in.x * -3":                  "not within bounds",
+		"var z i32[-10000..10000] = in.x * in.x":            "",
+		"var z i32[0..10000] = in.x * in.x":                 "not within bounds",
+		"var z i32[-13..12] = in.x >> 3":                    "",
+		"var z i32[-12..12] = in.x >> 3":                    "not within bounds",
+		"var z i32[-1..0] = (in.x - 100) >> 8":              "",
+		"var z i32[-100..99] = (in.x * 0x2000) >> 13":       "",
+		"var z i32 = in.x >> (in.x - 1)":                    "possibly negative",
+		"var z i32[-33..33] = in.x / 3":                     "",
+		"var z i32[-33..32] = in.x / 3":                     "not within bounds",
+		"var z i32 = in.x / -3":                             "possibly non-positive",
+		"var z i32 = in.x / in.x":                           "possibly non-positive",
+		"var z i32[-100..100] = ((in.x as i64) * 3) as i32": "not within bounds",
+		"var z i32[-300..300] = ((in.x as i64) * 3) as i32": "",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npri func foo(x i32[-100..99])() {\n\t" + s + "\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
 func TestBitMask(tt *testing.T) {
 	testCases := [][2]uint64{
 		{0, 0},
@@ -285,6 +640,46 @@ func TestBitMask(tt *testing.T) {
 	}
 }
 
+func TestFrameConfig(tt *testing.T) {
+	const args = "x:0, y:0, width:1, height:1, delay_ms:0, disposal:0, transparent_index:256"
+	testCases := map[string]string{
+		"in.dst.initialize!(" + args + ", palette:in.p)":        "",
+		"in.dst.initialize!(" + args + ")":                      "has 8 arguments but 7 were given",
+		"in.dst.initialize!(" + args + ", palette:0)":           "cannot assign",
+		"in.dst.initialize!(width:1, height:1, pixel_format:0)": "has 8 arguments but 3 were given",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npri func foo!(dst ptr frame_config, p[] u8)() {\n\t" + s + "\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
+func TestSwizzleFromPalette(tt *testing.T) {
+	testCases := map[string]string{
+		"in.dst.swizzle_from_palette(s:in.s, palette:in.p, transparent_index:256, pixel_format:3)":  "",
+		"in.dst.swizzle_from_palette(s:in.s, palette:in.p, transparent_index:256, pixel_format:4)":  "not within bounds",
+		"in.dst.swizzle_from_palette(s:in.s, palette:in.p, transparent_index:257, pixel_format:0)":  "not within bounds",
+		"in.dst.swizzle_from_palette(s:in.s, palette:in.p, transparent_index:0, pixel_format:in.f)": "not within bounds",
+		"in.dst.swizzle_from_palette(s:in.s, palette:in.p, transparent_index:0)":                    "has 4 arguments but 3 were given",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\npri func foo!(dst[] u8, s[] u8, p[] u8, f u32)() {\n\t" + s + "\n}\n"
+
+		_, err := checkSource(tm, src, nil, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
+
 func TestBuiltInTypeMap(tt *testing.T) {
 	if got, want := len(builtInTypeMap), len(builtin.Types); got != want {
 		tt.Fatalf("lengths: got %d, want %d", got, want)
@@ -300,3 +695,71 @@ func TestBuiltInTypeMap(tt *testing.T) {
 		}
 	}
 }
+
+func TestUseBuiltInTypes(tt *testing.T) {
+	const usedSrc = "packageid \"used\"\n" +
+		"pub struct decoder?()\n" +
+		"pub func decoder.decode_config?(dst ptr image_config, src reader1, x i32)() {\n}\n"
+	resolveUse := func(usePath string) ([]byte, error) {
+		if usePath != "used.wuffs" {
+			return nil, fmt.Errorf("unexpected use path %q", usePath)
+		}
+		return []byte(usedSrc), nil
+	}
+
+	// The used package's built-in types, such as image_config and i32, are
+	// not qualified by that package's name, so that the arguments' types
+	// match.
+	const src = "packageid \"test\"\n" +
+		"use \"used\"\n" +
+		"pub struct foo?(d used.decoder)\n" +
+		"pub func foo.bar?(dst ptr image_config, src reader1)() {\n" +
+		"\tvar x i32\n" +
+		"\tthis.d.decode_config?(dst:in.dst, src:in.src, x:x)\n" +
+		"}\n"
+
+	tm := &t.Map{}
+	if _, err := checkSource(tm, src, resolveUse, nil); err != nil {
+		tt.Fatal(err)
+	}
+}
+
+func TestUseLemma(tt *testing.T) {
+	// Both used packages, and the test package, have a lemma named "lt".
+	const usedSrc = "pub lemma \"lt\": a < c; a < b; b < c\n"
+	resolveUse := func(usePath string) ([]byte, error) {
+		switch usePath {
+		case "used1.wuffs":
+			return []byte("packageid \"usd1\"\n" + usedSrc), nil
+		case "used2.wuffs":
+			return []byte("packageid \"usd2\"\n" + usedSrc), nil
+		}
+		return nil, fmt.Errorf("unexpected use path %q", usePath)
+	}
+
+	testCases := map[string]string{
+		"assert x < z via used1.\"lt\"(b:y)": "",
+		"assert x < z via used2.\"lt\"(b:y)": "",
+		"assert x <= y via \"lt\"()":         "",
+		"assert x < z via \"lt\"(b:y)":       "cannot prove",
+		"assert x < w via used1.\"lt\"(b:y)": "cannot prove",
+		"assert x < z via used3.\"lt\"(b:y)": "no such reason used3.\"lt\"",
+		"assert x < z via used1.\"gt\"(b:y)": "no such reason used1.\"gt\"",
+	}
+
+	tm := &t.Map{}
+	for s, want := range testCases {
+		src := "packageid \"test\"\n" +
+			"use \"used1\"\n" +
+			"use \"used2\"\n" +
+			"pri lemma \"lt\": a <= b; a < b\n" +
+			"pri func foo()() {\n" +
+			"\tvar x u8\n\tvar y u8\n\tvar z u8\n\tvar w u8\n" +
+			"\tif (x < y) and (y < z) {\n\t\t" + s + "\n\t}\n}\n"
+
+		_, err := checkSource(tm, src, resolveUse, nil)
+		if err := checkError(err, want); err != nil {
+			tt.Errorf("%q: %v", s, err)
+		}
+	}
+}
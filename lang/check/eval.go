@@ -0,0 +1,499 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+// This file evaluates pure, free-standing Wuffs functions at compile time, to
+// compute the elements of const lists like "$(f(i:0..255))".
+//
+// The functions have already been type and bounds checked, so that every
+// intermediate value fits in its Wuffs type, and big.Int arithmetic gives the
+// same results as the generated C code would at run time.
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// maxEvalSteps bounds the number of statements and loop iterations executed
+// per element of a computed list.
+const maxEvalSteps = 1 << 20
+
+var errEvalTooManySteps = errors.New("too many steps")
+
+func (c *Checker) checkConstComputed(node *a.Node) error {
+	n := node.Const()
+	if x := n.Value(); x.Operator().Key() != t.KeyDollar || x.Ident() == 0 {
+		return nil
+	}
+	if err := c.evalConstComputed(n); err != nil {
+		return &Error{
+			Err:      fmt.Errorf("%v in const %s", err, n.QID().Str(c.tm)),
+			Filename: n.Filename(),
+			Line:     n.Line(),
+		}
+	}
+	return nil
+}
+
+// CompileTimeOnly returns whether f is a private function that is only used to
+// compute const lists, and so does not need to be compiled.
+func (c *Checker) CompileTimeOnly(f *a.Func) bool {
+	return !f.Public() && c.computeFuncs[f.QQID()]
+}
+
+// evalConstComputed replaces n's computed list value with the equivalent
+// plain list. It is a no-op if n's value is already a plain list, which can
+// happen if another computed list's function refers to n and so n was
+// evaluated first.
+func (c *Checker) evalConstComputed(n *a.Const) error {
+	x := n.Value()
+	if x.Operator().Key() != t.KeyDollar || x.Ident() == 0 {
+		return nil
+	}
+	if c.evaluatingConsts[n] {
+		return fmt.Errorf("check: computed const %s refers to itself", n.QID().Str(c.tm))
+	}
+	c.evaluatingConsts[n] = true
+	defer delete(c.evaluatingConsts, n)
+
+	typ := n.XType()
+	if typ.Decorator().Key() != t.KeyOpenBracket || typ.Inner().Decorator() != 0 {
+		return fmt.Errorf("check: computed list for %s needs a one-dimensional array type",
+			n.QID().Str(c.tm))
+	}
+	length := typ.ArrayLength().ConstValue()
+	nMin, nMax, err := (&checker{c: c, tm: c.tm}).bcheckTypeExpr(typ.Inner())
+	if err != nil {
+		return err
+	}
+
+	fName := x.Ident()
+	f := c.funcs[t.QQID{0, 0, fName}]
+	if f == nil {
+		return fmt.Errorf("check: no free-standing function %s", fName.Str(c.tm))
+	}
+	if !f.Pure() {
+		return fmt.Errorf("check: function %s is not pure", fName.Str(c.tm))
+	}
+	in, out := f.In().Fields(), f.Out().Fields()
+	if len(in) != 1 || len(out) != 1 ||
+		!in[0].Field().XType().IsNumType() || !out[0].Field().XType().IsNumType() {
+		return fmt.Errorf("check: function %s does not take and return exactly one number",
+			fName.Str(c.tm))
+	}
+	arg := x.Args()[0].Arg()
+	if arg.Name() != in[0].Field().Name() {
+		return fmt.Errorf("check: function %s has no argument named %s",
+			fName.Str(c.tm), arg.Name().Str(c.tm))
+	}
+	iMin, iMax, err := (&checker{c: c, tm: c.tm}).bcheckTypeExpr(in[0].Field().XType())
+	if err != nil {
+		return err
+	}
+
+	lo, hi := arg.Value().ConstValue(), x.RHS().Expr().ConstValue()
+	if lo.Cmp(iMin) < 0 || hi.Cmp(iMax) > 0 {
+		return fmt.Errorf("check: range %v..%v is not within %s's argument bounds [%v..%v]",
+			lo, hi, fName.Str(c.tm), iMin, iMax)
+	}
+	if m := big.NewInt(0).Sub(hi, lo); m.Add(m, one).Cmp(length) != 0 {
+		return fmt.Errorf("check: range %v..%v does not have %v elements", lo, hi, length)
+	}
+
+	c.computeFuncs[f.QQID()] = true
+	elems := []*a.Node(nil)
+	for i := big.NewInt(0).Set(lo); i.Cmp(hi) <= 0; i.Add(i, one) {
+		e := &evaluator{
+			c:     c,
+			f:     f,
+			steps: maxEvalSteps,
+			in:    map[t.ID]*big.Int{arg.Name(): big.NewInt(0).Set(i)},
+			vars:  map[t.ID]*big.Int{},
+		}
+		v, err := e.call()
+		if err == errEvalTooManySteps {
+			return fmt.Errorf("check: evaluating %s(%s:%v) took more than %d steps",
+				fName.Str(c.tm), arg.Name().Str(c.tm), i, maxEvalSteps)
+		} else if err != nil {
+			return fmt.Errorf("%v, evaluating %s(%s:%v)", err, fName.Str(c.tm), arg.Name().Str(c.tm), i)
+		}
+		if v.Cmp(nMin) < 0 || v.Cmp(nMax) > 0 {
+			return fmt.Errorf("check: %s(%s:%v) = %v is not within [%v..%v]",
+				fName.Str(c.tm), arg.Name().Str(c.tm), i, v, nMin, nMax)
+		}
+		id, err := c.tm.Insert(v.String())
+		if err != nil {
+			return err
+		}
+		o := a.NewExpr(a.FlagsTypeChecked, 0, 0, id, nil, nil, nil, nil)
+		o.SetConstValue(v)
+		o.SetMType(typeExprIdeal)
+		elems = append(elems, o.Node())
+	}
+
+	list := a.NewExpr(a.FlagsTypeChecked, t.IDDollar, 0, 0, nil, nil, nil, elems)
+	list.SetMType(typeExprList)
+	n.SetValue(list)
+	return nil
+}
+
+// evaluator is the state of one compile time call to a function f.
+type evaluator struct {
+	c     *Checker
+	f     *a.Func
+	steps int
+	in    map[t.ID]*big.Int
+	vars  map[t.ID]*big.Int
+
+	// jumpTarget and jumpKeyword are the pending break or continue, if any.
+	jumpTarget  a.Loop
+	jumpKeyword t.Key
+
+	// ret is the pending return value, if any.
+	ret *big.Int
+}
+
+func (e *evaluator) call() (*big.Int, error) {
+	if err := e.evalBlock(e.f.Body()); err != nil {
+		return nil, err
+	}
+	if e.ret == nil {
+		return nil, fmt.Errorf("check: function %s did not return a value", e.f.FuncName().Str(e.c.tm))
+	}
+	return e.ret, nil
+}
+
+func (e *evaluator) evalBlock(block []*a.Node) error {
+	for _, o := range block {
+		if err := e.evalStatement(o); err != nil {
+			return err
+		}
+		if e.ret != nil || e.jumpTarget != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (e *evaluator) evalStatement(n *a.Node) error {
+	if e.steps--; e.steps < 0 {
+		return errEvalTooManySteps
+	}
+
+	switch n.Kind() {
+	case a.KAssert:
+		// No-op. The assertion was proven when checking the function.
+		return nil
+
+	case a.KAssign:
+		n := n.Assign()
+		lhs := n.LHS()
+		if lhs.Operator() != 0 || lhs.Ident().IsNumLiteral() || lhs.GlobalIdent() {
+			break
+		}
+		v, err := e.evalExpr(n.RHS())
+		if err != nil {
+			return err
+		}
+		if op := n.Operator(); op.Key() != t.KeyEq {
+			l, ok := e.vars[lhs.Ident()]
+			if !ok {
+				break
+			}
+			if v, err = e.evalBinaryOp(op.BinaryForm().Key(), l, v, lhs.MType()); err != nil {
+				return err
+			}
+		}
+		e.vars[lhs.Ident()] = v
+		return nil
+
+	case a.KIf:
+		for n := n.If(); n != nil; n = n.ElseIf() {
+			cond, err := e.evalExpr(n.Condition())
+			if err != nil {
+				return err
+			}
+			if cond.Sign() != 0 {
+				return e.evalBlock(n.BodyIfTrue())
+			} else if n.ElseIf() == nil {
+				return e.evalBlock(n.BodyIfFalse())
+			}
+		}
+		return nil
+
+	case a.KJump:
+		n := n.Jump()
+		e.jumpTarget = n.JumpTarget()
+		e.jumpKeyword = n.Keyword().Key()
+		return nil
+
+	case a.KRet:
+		n := n.Ret()
+		if n.Keyword().Key() != t.KeyReturn || n.Value() == nil {
+			break
+		}
+		v, err := e.evalExpr(n.Value())
+		if err != nil {
+			return err
+		}
+		e.ret = v
+		return nil
+
+	case a.KSwitch:
+		n := n.Switch()
+		subject, err := e.evalExpr(n.Subject())
+		if err != nil {
+			return err
+		}
+		for _, o := range n.Cases() {
+			o := o.Case()
+			if subject.Cmp(o.Min().ConstValue()) >= 0 && subject.Cmp(o.Max().ConstValue()) <= 0 {
+				return e.evalBlock(o.Body())
+			}
+		}
+		return e.evalBlock(n.BodyElse())
+
+	case a.KVar:
+		n := n.Var()
+		if n.IterateVariable() {
+			break
+		}
+		v := zero
+		if n.Value() != nil {
+			var err error
+			if v, err = e.evalExpr(n.Value()); err != nil {
+				return err
+			}
+		}
+		e.vars[n.Name()] = v
+		return nil
+
+	case a.KWhile:
+		n := n.While()
+		for {
+			if e.steps--; e.steps < 0 {
+				return errEvalTooManySteps
+			}
+			cond, err := e.evalExpr(n.Condition())
+			if err != nil {
+				return err
+			}
+			if cond.Sign() == 0 {
+				return nil
+			}
+			if err := e.evalBlock(n.Body()); err != nil {
+				return err
+			}
+			if e.ret != nil {
+				return nil
+			}
+			if e.jumpTarget == a.Loop(n) {
+				e.jumpTarget = nil
+				if e.jumpKeyword == t.KeyBreak {
+					return nil
+				}
+			} else if e.jumpTarget != nil {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("check: cannot evaluate %v statement at compile time", n.Kind())
+}
+
+func (e *evaluator) evalExpr(n *a.Expr) (*big.Int, error) {
+	if cv := n.ConstValue(); cv != nil {
+		return cv, nil
+	}
+
+	switch op := n.Operator(); op.Flags() & (t.FlagsUnaryOp | t.FlagsBinaryOp | t.FlagsAssociativeOp) {
+	case t.FlagsUnaryOp:
+		v, err := e.evalExpr(n.RHS().Expr())
+		if err != nil {
+			return nil, err
+		}
+		switch op.Key() {
+		case t.KeyXUnaryPlus:
+			return v, nil
+		case t.KeyXUnaryMinus:
+			return big.NewInt(0).Neg(v), nil
+		case t.KeyXUnaryNot:
+			return btoi(v.Sign() == 0), nil
+		case t.KeyXUnaryTilde:
+			typeMax := numTypeBounds[n.MType().QID()[1].Key()][1]
+			return big.NewInt(0).Sub(typeMax, v), nil
+		}
+
+	case t.FlagsBinaryOp:
+		l, err := e.evalExpr(n.LHS().Expr())
+		if err != nil {
+			return nil, err
+		}
+		switch op.Key() {
+		case t.KeyXBinaryAs:
+			// The bounds checker has proven that l fits in the new type.
+			return l, nil
+		case t.KeyXBinaryAnd:
+			if l.Sign() == 0 {
+				return zero, nil
+			}
+		case t.KeyXBinaryOr:
+			if l.Sign() != 0 {
+				return one, nil
+			}
+		}
+		r, err := e.evalExpr(n.RHS().Expr())
+		if err != nil {
+			return nil, err
+		}
+		return e.evalBinaryOp(op.Key(), l, r, n.MType())
+
+	case t.FlagsAssociativeOp:
+		args := n.Args()
+		z, err := e.evalExpr(args[0].Expr())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range args[1:] {
+			r, err := e.evalExpr(o.Expr())
+			if err != nil {
+				return nil, err
+			}
+			if z, err = e.evalBinaryOp(op.AmbiguousForm().BinaryForm().Key(), z, r, n.MType()); err != nil {
+				return nil, err
+			}
+		}
+		return z, nil
+
+	default:
+		switch op.Key() {
+		case 0:
+			id := n.Ident()
+			if n.GlobalIdent() {
+				if o := e.c.consts[t.QID{0, id}]; o != nil {
+					if cv := o.Value().ConstValue(); cv != nil {
+						return cv, nil
+					}
+				}
+			} else if v, ok := e.vars[id]; ok {
+				return v, nil
+			}
+
+		case t.KeyDot:
+			if lhs := n.LHS().Expr(); lhs.Operator() == 0 && lhs.Ident().Key() == t.KeyIn {
+				if v, ok := e.in[n.Ident()]; ok {
+					return v, nil
+				}
+			}
+
+		case t.KeyOpenBracket:
+			if v, err := e.evalIndex(n); v != nil || err != nil {
+				return v, err
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("check: cannot evaluate expression %q at compile time", n.Str(e.c.tm))
+}
+
+// evalIndex evaluates "LHS[RHS]" where LHS is a global const list. It returns
+// (nil, nil) if LHS is not such a list.
+func (e *evaluator) evalIndex(n *a.Expr) (*big.Int, error) {
+	lhs := n.LHS().Expr()
+	if lhs.Operator() != 0 || !lhs.GlobalIdent() {
+		return nil, nil
+	}
+	o := e.c.consts[t.QID{0, lhs.Ident()}]
+	if o == nil {
+		return nil, nil
+	}
+	if err := e.c.evalConstComputed(o); err != nil {
+		return nil, err
+	}
+	i, err := e.evalExpr(n.RHS().Expr())
+	if err != nil {
+		return nil, err
+	}
+	args := o.Value().Args()
+	if i.Sign() < 0 || i.Cmp(big.NewInt(int64(len(args)))) >= 0 {
+		return nil, fmt.Errorf("check: index %v out of range for %q", i, lhs.Str(e.c.tm))
+	}
+	if cv := args[i.Int64()].Expr().ConstValue(); cv != nil {
+		return cv, nil
+	}
+	return nil, nil
+}
+
+// evalBinaryOp is like evalConstValueBinaryOp, except that division rounds
+// towards zero, as in C, and the ~+ operator wraps around modulo typ's range.
+func (e *evaluator) evalBinaryOp(op t.Key, l *big.Int, r *big.Int, typ *a.TypeExpr) (*big.Int, error) {
+	switch op {
+	case t.KeyXBinaryPlus:
+		return big.NewInt(0).Add(l, r), nil
+	case t.KeyXBinaryMinus:
+		return big.NewInt(0).Sub(l, r), nil
+	case t.KeyXBinaryStar:
+		return big.NewInt(0).Mul(l, r), nil
+	case t.KeyXBinarySlash:
+		if r.Sign() == 0 {
+			return nil, fmt.Errorf("check: division by zero")
+		}
+		return big.NewInt(0).Quo(l, r), nil
+	case t.KeyXBinaryPercent:
+		if r.Sign() == 0 {
+			return nil, fmt.Errorf("check: division by zero")
+		}
+		return big.NewInt(0).Rem(l, r), nil
+	case t.KeyXBinaryShiftL:
+		return big.NewInt(0).Lsh(l, uint(r.Uint64())), nil
+	case t.KeyXBinaryShiftR:
+		return big.NewInt(0).Rsh(l, uint(r.Uint64())), nil
+	case t.KeyXBinaryAmp:
+		return big.NewInt(0).And(l, r), nil
+	case t.KeyXBinaryAmpHat:
+		return big.NewInt(0).AndNot(l, r), nil
+	case t.KeyXBinaryPipe:
+		return big.NewInt(0).Or(l, r), nil
+	case t.KeyXBinaryHat:
+		return big.NewInt(0).Xor(l, r), nil
+	case t.KeyXBinaryTildePlus:
+		if typ == nil || !typ.IsUnsignedInteger() {
+			break
+		}
+		z := big.NewInt(0).Add(l, r)
+		return z.And(z, numTypeBounds[typ.QID()[1].Key()][1]), nil
+	case t.KeyXBinaryNotEq:
+		return btoi(l.Cmp(r) != 0), nil
+	case t.KeyXBinaryLessThan:
+		return btoi(l.Cmp(r) < 0), nil
+	case t.KeyXBinaryLessEq:
+		return btoi(l.Cmp(r) <= 0), nil
+	case t.KeyXBinaryEqEq:
+		return btoi(l.Cmp(r) == 0), nil
+	case t.KeyXBinaryGreaterEq:
+		return btoi(l.Cmp(r) >= 0), nil
+	case t.KeyXBinaryGreaterThan:
+		return btoi(l.Cmp(r) > 0), nil
+	case t.KeyXBinaryAnd:
+		return btoi((l.Sign() != 0) && (r.Sign() != 0)), nil
+	case t.KeyXBinaryOr:
+		return btoi((l.Sign() != 0) || (r.Sign() != 0)), nil
+	}
+	return nil, fmt.Errorf("check: cannot evaluate operator %q at compile time", e.c.tm.ByKey(op))
+}
@@ -0,0 +1,292 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"fmt"
+
+	a "github.com/google/wuffs/lang/ast"
+	t "github.com/google/wuffs/lang/token"
+)
+
+// checkLemma type checks and proves a lemma, and then registers it as a
+// reason that later assertions can use via its name. A lemma's proof can only
+// use built-in reasons and previously registered lemmas, so that lemmas are
+// never circular.
+func (c *Checker) checkLemma(node *a.Node) error {
+	n := node.Lemma()
+	if err := c.checkLemmaName(n); err != nil {
+		return err
+	}
+	q, err := c.tcheckLemma(n)
+	if err != nil {
+		return &Error{
+			Err:      fmt.Errorf("%v in lemma %s", err, n.Name().Str(c.tm)),
+			Filename: n.Filename(),
+			Line:     n.Line(),
+		}
+	}
+	if err := q.proveLemma(n); err != nil {
+		return &Error{
+			Err:      fmt.Errorf("%v in lemma %s", err, n.Name().Str(c.tm)),
+			Filename: q.errFilename,
+			Line:     q.errLine,
+			TMap:     c.tm,
+			Facts:    q.facts,
+		}
+	}
+	c.registerLemma(n)
+	n.Node().SetTypeChecked()
+	return nil
+}
+
+func (c *Checker) checkLemmaName(n *a.Lemma) error {
+	qid := n.QID()
+	if other, ok := c.lemmas[qid]; ok {
+		return &Error{
+			Err:           fmt.Errorf("check: duplicate lemma %s", qid.Str(c.tm)),
+			Filename:      n.Filename(),
+			Line:          n.Line(),
+			OtherFilename: other.Filename(),
+			OtherLine:     other.Line(),
+		}
+	}
+	if _, ok := c.reasonMap[qid[1].Key()]; ok && qid[0] == 0 {
+		return &Error{
+			Err:      fmt.Errorf("check: lemma %s duplicates a built-in reason", qid.Str(c.tm)),
+			Filename: n.Filename(),
+			Line:     n.Line(),
+		}
+	}
+	return nil
+}
+
+func (c *Checker) registerLemma(n *a.Lemma) {
+	c.lemmas[n.QID()] = n
+	if n.QID()[0] != 0 {
+		// A used package's lemma is only invoked by its qualified name, such
+		// as `via foo."bar"`, so that it cannot clash with this package's
+		// lemmas or another used package's.
+		return
+	}
+	c.reasonMap[n.Name().Key()] = func(q *checker, o *a.Assert) error {
+		return q.applyLemma(n, o)
+	}
+}
+
+// tcheckLemma type checks a lemma's conclusion, premises and proof steps. Every
+// identifier in the conclusion and premises is a metavariable, an integer in
+// ℤ, and so has the ideal type.
+func (c *Checker) tcheckLemma(n *a.Lemma) (*checker, error) {
+	q := &checker{
+		c:         c,
+		tm:        c.tm,
+		reasonMap: c.reasonMap,
+		localVars: typeMap{},
+	}
+	exprs := append([]*a.Node{n.Conclusion().Node()}, n.Premises()...)
+	for _, o := range exprs {
+		if err := q.declareMetavariables(o.Expr(), 0); err != nil {
+			return nil, err
+		}
+	}
+	for _, o := range exprs {
+		o := o.Expr()
+		if err := q.tcheckExpr(o, 0); err != nil {
+			return nil, err
+		}
+		if !comparisonOps[0xFF&o.Operator().Key()] {
+			return nil, fmt.Errorf("check: lemma term %q is not a comparison", o.Str(q.tm))
+		}
+	}
+	for _, o := range n.ProofSteps() {
+		if err := q.tcheckAssert(o.Assert()); err != nil {
+			return nil, err
+		}
+		o.SetTypeChecked()
+	}
+	return q, nil
+}
+
+func (q *checker) declareMetavariables(n *a.Expr, depth uint32) error {
+	if depth > a.MaxExprDepth {
+		return fmt.Errorf("check: expression recursion depth too large")
+	}
+	depth++
+
+	switch op := n.Operator(); {
+	case op == 0:
+		if id := n.Ident(); id.IsNumLiteral() {
+			return nil
+		} else if id.IsIdent() {
+			q.localVars[id] = typeExprIdeal
+			return nil
+		}
+	case op.IsXUnaryOp():
+		return q.declareMetavariables(n.RHS().Expr(), depth)
+	case op.IsXBinaryOp() && op.Key() != t.KeyXBinaryAs:
+		if err := q.declareMetavariables(n.LHS().Expr(), depth); err != nil {
+			return err
+		}
+		return q.declareMetavariables(n.RHS().Expr(), depth)
+	case op.IsXAssociativeOp():
+		for _, o := range n.Args() {
+			if err := q.declareMetavariables(o.Expr(), depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("check: invalid lemma term %q", n.Str(q.tm))
+}
+
+// proveLemma proves that a lemma's conclusion follows from its premises, after
+// applying its proof steps.
+func (q *checker) proveLemma(n *a.Lemma) error {
+	q.errFilename, q.errLine = n.Filename(), n.Line()
+	for _, o := range n.Premises() {
+		o, err := simplify(q.tm, o.Expr())
+		if err != nil {
+			return err
+		}
+		q.facts.appendFact(o)
+	}
+	for _, o := range n.ProofSteps() {
+		q.errFilename, q.errLine = o.Raw().FilenameLine()
+		if err := q.bcheckAssert(o.Assert()); err != nil {
+			return err
+		}
+	}
+	q.errFilename, q.errLine = n.Filename(), n.Line()
+	return q.bcheckAssert(a.NewAssert(t.IDAssert, n.Conclusion(), t.QID{}, nil))
+}
+
+// applyLemma is the reason function for a lemma. It matches the lemma's
+// conclusion against the assertion's condition, binding metavariables, and
+// binds any remaining metavariables from the assertion's arguments. Each
+// premise, with its metavariables substituted, must then be provable.
+func (q *checker) applyLemma(n *a.Lemma, o *a.Assert) error {
+	bindings := map[t.ID]*a.Expr{}
+	if !matchLemmaTerm(bindings, n.Conclusion(), o.Condition()) {
+		return errFailed
+	}
+	for _, p := range n.Premises() {
+		x, err := q.substituteLemmaTerm(bindings, p.Expr(), o.Args())
+		if err != nil {
+			return err
+		}
+		op, lhs, rhs := parseBinaryOp(x)
+		if err := proveReasonRequirement(q, op, lhs, rhs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchLemmaTerm returns whether x matches the pattern, binding any unbound
+// metavariables in the pattern. A metavariable that is already bound must be
+// bound to an expression equal to x.
+func matchLemmaTerm(bindings map[t.ID]*a.Expr, pattern *a.Expr, x *a.Expr) bool {
+	if pattern == nil || x == nil {
+		return pattern == x
+	}
+	if cv := pattern.ConstValue(); cv != nil {
+		xcv := x.ConstValue()
+		return xcv != nil && xcv.Cmp(cv) == 0
+	}
+	if pattern.Operator() == 0 {
+		id := pattern.Ident()
+		if b := bindings[id]; b != nil {
+			return b.Eq(x)
+		}
+		bindings[id] = x
+		return true
+	}
+	if pattern.Operator() != x.Operator() ||
+		!matchLemmaTerm(bindings, pattern.LHS().Expr(), x.LHS().Expr()) ||
+		!matchLemmaTerm(bindings, pattern.RHS().Expr(), x.RHS().Expr()) {
+		return false
+	}
+	pArgs, xArgs := pattern.Args(), x.Args()
+	if len(pArgs) != len(xArgs) {
+		return false
+	}
+	for i := range pArgs {
+		if !matchLemmaTerm(bindings, pArgs[i].Expr(), xArgs[i].Expr()) {
+			return false
+		}
+	}
+	return true
+}
+
+// substituteLemmaTerm returns the pattern with its metavariables replaced by
+// their bindings. Metavariables that are not yet bound are looked up in args.
+func (q *checker) substituteLemmaTerm(bindings map[t.ID]*a.Expr, pattern *a.Expr, args []*a.Node) (*a.Expr, error) {
+	if pattern == nil || pattern.ConstValue() != nil {
+		return pattern, nil
+	}
+	if pattern.Operator() == 0 {
+		id := pattern.Ident()
+		if b := bindings[id]; b != nil {
+			return b, nil
+		}
+		if b := argValue(q.tm, args, id.Str(q.tm)); b != nil {
+			bindings[id] = b
+			return b, nil
+		}
+		return nil, fmt.Errorf("no argument for %s", id.Str(q.tm))
+	}
+
+	lhs, err := q.substituteLemmaTerm(bindings, pattern.LHS().Expr(), args)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := q.substituteLemmaTerm(bindings, pattern.RHS().Expr(), args)
+	if err != nil {
+		return nil, err
+	}
+	xArgs := []*a.Node(nil)
+	for _, o := range pattern.Args() {
+		x, err := q.substituteLemmaTerm(bindings, o.Expr(), args)
+		if err != nil {
+			return nil, err
+		}
+		xArgs = append(xArgs, x.Node())
+	}
+
+	x := a.NewExpr(a.FlagsTypeChecked, pattern.Operator(), 0, 0, lhs.Node(), nil, rhs.Node(), xArgs)
+	typ := pattern.MType()
+	if typ.IsIdeal() {
+		// Take the type of the substituted sub-expressions, if not ideal, so
+		// that bounds checking the result uses their bounds.
+		for _, o := range append([]*a.Node{lhs.Node(), rhs.Node()}, xArgs...) {
+			if o != nil && !o.Expr().MType().IsIdeal() {
+				typ = o.Expr().MType()
+				break
+			}
+		}
+	}
+	x.SetMType(typ)
+	if lhs != nil && rhs != nil && pattern.Operator().IsXBinaryOp() {
+		if lcv, rcv := lhs.ConstValue(), rhs.ConstValue(); lcv != nil && rcv != nil {
+			cv, err := evalConstValueBinaryOp(q.tm, x, lcv, rcv)
+			if err != nil {
+				return nil, err
+			}
+			x.SetConstValue(cv)
+		}
+	}
+	return x, nil
+}
@@ -47,6 +47,7 @@ var (
 	typeExprReader1     = a.NewTypeExpr(0, 0, t.IDReader1, nil, nil, nil)
 	typeExprWriter1     = a.NewTypeExpr(0, 0, t.IDWriter1, nil, nil, nil)
 	typeExprImageConfig = a.NewTypeExpr(0, 0, t.IDImageConfig, nil, nil, nil)
+	typeExprFrameConfig = a.NewTypeExpr(0, 0, t.IDFrameConfig, nil, nil, nil)
 
 	typeExprSliceU8 = a.NewTypeExpr(t.IDColon, 0, 0, nil, nil, typeExprU8)
 
@@ -69,6 +70,7 @@ var builtInTypeMap = typeMap{
 	t.IDReader1:     typeExprReader1,
 	t.IDWriter1:     typeExprWriter1,
 	t.IDImageConfig: typeExprImageConfig,
+	t.IDFrameConfig: typeExprFrameConfig,
 }
 
 func (c *Checker) builtInFunc(qqid t.QQID) (*a.Func, error) {
@@ -78,6 +80,9 @@ func (c *Checker) builtInFunc(qqid t.QQID) (*a.Func, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := c.tcheckBuiltInRefinements(c.builtInFuncs); err != nil {
+			return nil, err
+		}
 	}
 	return c.builtInFuncs[qqid], nil
 }
@@ -89,6 +94,9 @@ func (c *Checker) builtInSliceFunc(qqid t.QQID) (*a.Func, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := c.tcheckBuiltInRefinements(c.builtInSliceFuncs); err != nil {
+			return nil, err
+		}
 	}
 	return c.builtInSliceFuncs[qqid], nil
 }
@@ -132,6 +140,26 @@ func parseBuiltInFuncs(tm *t.Map, ss []string, generic bool) (map[t.QQID]*a.Func
 	return m, nil
 }
 
+// tcheckBuiltInRefinements type checks the bounds of the built-in funcs'
+// refined argument types, such as the 3 in "pixel_format u32[..3]", so that
+// the bounds checker can compare a call's arguments against them.
+func (c *Checker) tcheckBuiltInRefinements(m map[t.QQID]*a.Func) error {
+	q := &checker{
+		c:  c,
+		tm: c.tm,
+	}
+	for _, f := range m {
+		for _, o := range f.In().Fields() {
+			if typ := o.Field().XType(); typ.IsRefined() {
+				if err := q.tcheckTypeExpr(typ, 0); err != nil {
+					return fmt.Errorf("%v in built-in func %s", err, f.QQID().Str(c.tm))
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Checker) resolveFunc(typ *a.TypeExpr) (*a.Func, error) {
 	if typ.Decorator().Key() != t.KeyOpenParen {
 		return nil, fmt.Errorf("check: resolveFunc cannot look up non-func TypeExpr %q", typ.Str(c.tm))
@@ -47,6 +47,16 @@ func (q *checker) tcheckVars(block []*a.Node) error {
 				return err
 			}
 
+		case a.KSwitch:
+			for _, c := range o.Switch().Cases() {
+				if err := q.tcheckVars(c.Case().Body()); err != nil {
+					return err
+				}
+			}
+			if err := q.tcheckVars(o.Switch().BodyElse()); err != nil {
+				return err
+			}
+
 		case a.KVar:
 			o := o.Var()
 			name := o.Name()
@@ -166,6 +176,11 @@ func (q *checker) tcheckStatement(n *a.Node) error {
 			// This needs the context of what func we're in.
 		}
 
+	case a.KSwitch:
+		if err := q.tcheckSwitch(n.Switch()); err != nil {
+			return err
+		}
+
 	case a.KVar:
 		n := n.Var()
 		if !n.XType().Node().TypeChecked() {
@@ -221,6 +236,53 @@ func (q *checker) tcheckStatement(n *a.Node) error {
 	return nil
 }
 
+func (q *checker) tcheckSwitch(n *a.Switch) error {
+	subject := n.Subject()
+	if err := q.tcheckExpr(subject, 0); err != nil {
+		return err
+	}
+	sTyp := subject.MType()
+	if !sTyp.IsNumType() {
+		return fmt.Errorf("check: switch subject %q, of type %q, does not have a numeric type",
+			subject.Str(q.tm), sTyp.Str(q.tm))
+	}
+	for _, o := range n.Cases() {
+		o := o.Case()
+		q.errFilename, q.errLine = o.Node().Raw().FilenameLine()
+		for _, v := range [2]*a.Expr{o.Min(), o.Max()} {
+			if v.Node().TypeChecked() {
+				// For a single value case, Min and Max are the same Expr.
+				continue
+			}
+			if err := q.tcheckExpr(v, 0); err != nil {
+				return err
+			}
+			if v.ConstValue() == nil {
+				return fmt.Errorf("check: case value %q is not constant", v.Str(q.tm))
+			}
+			if err := q.tcheckEq(0, subject, sTyp, v, v.MType()); err != nil {
+				return err
+			}
+		}
+		if o.Min().ConstValue().Cmp(o.Max().ConstValue()) > 0 {
+			return fmt.Errorf("check: case range %v..%v is empty",
+				o.Min().ConstValue(), o.Max().ConstValue())
+		}
+		for _, p := range o.Body() {
+			if err := q.tcheckStatement(p); err != nil {
+				return err
+			}
+		}
+		o.Node().SetTypeChecked()
+	}
+	for _, o := range n.BodyElse() {
+		if err := q.tcheckStatement(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (q *checker) tcheckAssert(n *a.Assert) error {
 	cond := n.Condition()
 	if err := q.tcheckExpr(cond, 0); err != nil {
@@ -431,13 +493,25 @@ func (q *checker) tcheckExprOther(n *a.Expr, depth uint32) error {
 		// require a TypeExpr being able to express function and method types.
 
 		// TODO: delete this hack that only matches "foo.decode(etc)".
-		if isThatMethod(q.tm, n, q.tm.ByName("decode").Key(), 3) {
+		if isThatMethod(q.tm, n, q.tm.ByName("decode").Key(), 3) ||
+			(isThatMethod(q.tm, n, q.tm.ByName("decode").Key(), 2) &&
+				n.Args()[1].Arg().Name().Str(q.tm) == "dummy") {
 			foo := n.LHS().Expr().LHS().Expr()
 			if err := q.tcheckExpr(foo, depth); err != nil {
 				return err
 			}
 			n.LHS().SetTypeChecked()
 			n.LHS().Expr().SetMType(typeExprPlaceholder) // HACK.
+			if q.astFunc != nil {
+				// Record the call, as tcheckExprCall would, for the call
+				// graph. The callee is only found if it is in this package.
+				if typ := foo.MType().Pointee(); typ.Decorator() == 0 {
+					qid := typ.QID()
+					if f := q.c.funcs[t.QQID{qid[0], qid[1], q.tm.ByName("decode")}]; f != nil {
+						q.c.addCallee(q.astFunc.QQID(), f)
+					}
+				}
+			}
 			for _, o := range n.Args() {
 				if err := q.tcheckArg(o.Arg(), nil, nil, depth); err != nil {
 					return err
@@ -541,6 +615,22 @@ func (q *checker) tcheckExprOther(n *a.Expr, depth uint32) error {
 		return nil
 
 	case t.KeyDollar:
+		if n.Ident() != 0 {
+			// n is a computed list, like "$(f(i:0..255))". Its elements are
+			// evaluated later, by checkConstComputed, once f is checked.
+			arg := n.Args()[0].Arg()
+			for _, o := range [...]*a.Expr{arg.Value(), n.RHS().Expr()} {
+				if err := q.tcheckExpr(o, depth); err != nil {
+					return err
+				}
+				if o.ConstValue() == nil {
+					return fmt.Errorf("check: computed list range %q is not constant", o.Str(q.tm))
+				}
+			}
+			arg.Node().SetTypeChecked()
+			n.SetMType(typeExprList)
+			return nil
+		}
 		for _, o := range n.Args() {
 			o := o.Expr()
 			if err := q.tcheckExpr(o, depth); err != nil {
@@ -568,6 +658,18 @@ func (q *checker) tcheckExprCall(n *a.Expr, depth uint32) error {
 		return fmt.Errorf("check: %q has effect %q but %q has effect %q",
 			n.Str(q.tm), ne, f.QQID().Str(q.tm), fe)
 	}
+	if q.astFunc != nil && q.c.funcs[f.QQID()] == f {
+		qqid := q.astFunc.QQID()
+		if f.Suspendible() {
+			q.c.suspendibleCalls[qqid] = append(q.c.suspendibleCalls[qqid], suspendibleCall{
+				callee:   f,
+				call:     n,
+				filename: q.errFilename,
+				line:     q.errLine,
+			})
+		}
+		q.c.addCallee(qqid, f)
+	}
 
 	genericType := (*a.TypeExpr)(nil)
 	if f.Receiver() == (t.QID{0, t.IDDiamond}) {
@@ -778,6 +880,17 @@ func (q *checker) tcheckExprUnaryOp(n *a.Expr, depth uint32) error {
 		n.SetMType(typeExprBool)
 		return nil
 
+	case t.KeyXUnaryTilde:
+		if !rTyp.IsUnsignedInteger() {
+			return fmt.Errorf("check: unary %q: %q, of type %q, does not have an unsigned integer type",
+				n.Operator().AmbiguousForm().Str(q.tm), rhs.Str(q.tm), rTyp.Str(q.tm))
+		}
+		if cv := rhs.ConstValue(); cv != nil {
+			n.SetConstValue(big.NewInt(0).Sub(numTypeBounds[rTyp.QID()[1].Key()][1], cv))
+		}
+		n.SetMType(rTyp.Unrefined())
+		return nil
+
 	case t.KeyXUnaryRef:
 		// TODO.
 
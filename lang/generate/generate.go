@@ -22,21 +22,28 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/google/wuffs/lang/check"
 	"github.com/google/wuffs/lang/parse"
 
+	cf "github.com/google/wuffs/cmd/commonflags"
+
 	a "github.com/google/wuffs/lang/ast"
 	t "github.com/google/wuffs/lang/token"
 )
 
 type Generator func(packageName string, tm *t.Map, c *check.Checker, files []*a.File) ([]byte, error)
 
-func Do(args []string, g Generator) error {
-	flags := flag.FlagSet{}
+// Do runs g on the Wuffs files named by args, after parsing the flags in args.
+// The flags common to every generator, such as -package_name, are added to
+// flags, which can also hold generator-specific flags.
+func Do(flags *flag.FlagSet, args []string, g Generator) error {
 	packageName := flags.String("package_name", "", "the package name of the Wuffs input code")
+	transitivityDepth := flags.Int("transitivity_depth", cf.TransitivityDepthDefault, cf.TransitivityDepthUsage)
+	transitivityReport := flags.Bool("transitivity_report", cf.TransitivityReportDefault, cf.TransitivityReportUsage)
 	if err := flags.Parse(args); err != nil {
 		return err
 	}
@@ -44,14 +51,30 @@ func Do(args []string, g Generator) error {
 	if pkgName == "" {
 		return fmt.Errorf("prohibited package name %q", *packageName)
 	}
+	if *transitivityDepth < cf.TransitivityDepthMin || cf.TransitivityDepthMax < *transitivityDepth {
+		return fmt.Errorf("bad -transitivity_depth flag value %d, outside the range [%d..%d]",
+			*transitivityDepth, cf.TransitivityDepthMin, cf.TransitivityDepthMax)
+	}
 
 	tm := &t.Map{}
-	files, err := parseFiles(tm, flags.Args())
+	files, pkgTransitivityDepth, err := parseFiles(tm, flags.Args())
 	if err != nil {
 		return err
 	}
 
-	c, err := check.Check(tm, files, resolveUse)
+	opts := &check.Options{
+		TransitivityDepth: *transitivityDepth,
+	}
+	if opts.TransitivityDepth < pkgTransitivityDepth {
+		opts.TransitivityDepth = pkgTransitivityDepth
+	}
+	if *transitivityReport {
+		opts.TransitivityReport = func(filename string, line uint32, chain string) {
+			fmt.Fprintf(os.Stderr, "%s:%d: proved by transitivity: %s\n", filename, line, chain)
+		}
+	}
+
+	c, err := check.Check(tm, files, resolveUse, opts)
 	if err != nil {
 		return err
 	}
@@ -89,24 +112,37 @@ func checkPackageName(s string) string {
 	return s
 }
 
-func parseFiles(tm *t.Map, filenames []string) (files []*a.File, err error) {
+// parseFiles is like ParseFiles, reading from stdin if there are no
+// filenames, but also returns the package's transitivity depth: the maximum
+// over its files' transitivity depth comments.
+func parseFiles(tm *t.Map, filenames []string) (files []*a.File, transitivityDepth int, err error) {
 	if len(filenames) == 0 {
 		const filename = "stdin"
 		src, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		tokens, _, err := t.Tokenize(tm, filename, src)
+		f, depth, err := parseFile(tm, filename, src, nil)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+		return []*a.File{f}, depth, nil
+	}
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, 0, err
 		}
-		f, err := parse.Parse(tm, filename, tokens, nil)
+		f, depth, err := parseFile(tm, filename, src, nil)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+		files = append(files, f)
+		if transitivityDepth < depth {
+			transitivityDepth = depth
 		}
-		return []*a.File{f}, nil
 	}
-	return ParseFiles(tm, filenames, nil)
+	return files, transitivityDepth, nil
 }
 
 func ParseFiles(tm *t.Map, filenames []string, opts *parse.Options) (files []*a.File, err error) {
@@ -115,11 +151,7 @@ func ParseFiles(tm *t.Map, filenames []string, opts *parse.Options) (files []*a.
 		if err != nil {
 			return nil, err
 		}
-		tokens, _, err := t.Tokenize(tm, filename, src)
-		if err != nil {
-			return nil, err
-		}
-		f, err := parse.Parse(tm, filename, tokens, opts)
+		f, _, err := parseFile(tm, filename, src, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -128,6 +160,38 @@ func ParseFiles(tm *t.Map, filenames []string, opts *parse.Options) (files []*a.
 	return files, nil
 }
 
+// transitivityDepthPrefix starts a comment line, such as "// !! wuffs
+// transitivity_depth: 2", that opts a package in to the checker's search for
+// proofs by transitivity, as if the -transitivity_depth flag was at least 2.
+// It is typically near the top of one of the package's files.
+const transitivityDepthPrefix = "// !! wuffs transitivity_depth:"
+
+func parseFile(tm *t.Map, filename string, src []byte, opts *parse.Options) (f *a.File, transitivityDepth int, err error) {
+	tokens, comments, err := t.Tokenize(tm, filename, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	for line, c := range comments {
+		if !strings.HasPrefix(c, transitivityDepthPrefix) {
+			continue
+		}
+		s := strings.TrimSpace(c[len(transitivityDepthPrefix):])
+		depth, err := strconv.Atoi(s)
+		if err != nil || depth < cf.TransitivityDepthMin || cf.TransitivityDepthMax < depth {
+			return nil, 0, fmt.Errorf("%s:%d: bad transitivity_depth value %q, outside the range [%d..%d]",
+				filename, line, s, cf.TransitivityDepthMin, cf.TransitivityDepthMax)
+		}
+		if transitivityDepth < depth {
+			transitivityDepth = depth
+		}
+	}
+	f, err = parse.Parse(tm, filename, tokens, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, transitivityDepth, nil
+}
+
 func resolveUse(usePath string) ([]byte, error) {
 	wuffsRoot, err := WuffsRoot()
 	if err != nil {
@@ -0,0 +1,57 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	t "github.com/google/wuffs/lang/token"
+)
+
+func TestTransitivityDepthComment(tt *testing.T) {
+	testCases := []struct {
+		comments string
+		want     int
+		wantErr  string
+	}{
+		{"", 0, ""},
+		{"// transitivity_depth: 2\n", 0, ""},
+		{"// !! wuffs transitivity_depth: 2\n", 2, ""},
+		{"// !! wuffs transitivity_depth:1\n", 1, ""},
+		{"// !! wuffs transitivity_depth: 1\n// !! wuffs transitivity_depth: 3\n", 3, ""},
+		{"// !! wuffs transitivity_depth: 5\n", 0, "outside the range"},
+		{"// !! wuffs transitivity_depth: -1\n", 0, "outside the range"},
+		{"// !! wuffs transitivity_depth: two\n", 0, "outside the range"},
+	}
+
+	for _, tc := range testCases {
+		src := tc.comments + "packageid \"test\"\n"
+		_, got, err := parseFile(&t.Map{}, "test.wuffs", []byte(src), nil)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				tt.Errorf("%q: got error %v, want %q", tc.comments, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			tt.Errorf("%q: %v", tc.comments, err)
+			continue
+		}
+		if got != tc.want {
+			tt.Errorf("%q: got %d, want %d", tc.comments, got, tc.want)
+		}
+	}
+}
@@ -471,6 +471,186 @@ func (x IntRange) Quo(y IntRange) (z IntRange, ok bool) {
 	return ret.toIntRange(), true
 }
 
+// Rem returns z = x % y. Like the big.Int.Rem method (and unlike the
+// big.Int.Mod method), it truncates towards zero: the sign of a non-zero z is
+// the sign of x.
+//
+// ok is false (and z will be IntRange{nil, nil}) if x is non-empty and y
+// contains zero, as it's invalid to divide by zero. Otherwise, ok is true.
+func (x IntRange) Rem(y IntRange) (z IntRange, ok bool) {
+	if x.Empty() || y.Empty() {
+		return empty(), true
+	}
+	if y.ContainsZero() {
+		return IntRange{}, false
+	}
+	if x.justZero() {
+		return IntRange{big.NewInt(0), big.NewInt(0)}, true
+	}
+
+	// The sign of y does not affect x % y, so replace y by its absolute value,
+	// the interval [m, n].
+	m, n := y[0], y[1]
+	if y.ContainsNegative() {
+		m = big.NewInt(0).Neg(y[1])
+		n = nil
+		if y[0] != nil {
+			n = big.NewInt(0).Neg(y[0])
+		}
+	}
+
+	ret := newBiggerIntPair()
+
+	// Split x into negative, zero and positive parts.
+	negX, posX, negXEmpty, zeroX, posXEmpty := x.split()
+
+	if zeroX {
+		ret[0] = biggerInt{i: big.NewInt(0)}
+		ret[1] = biggerInt{i: big.NewInt(0)}
+	}
+
+	if !negXEmpty {
+		// x is negative, so x % y is non-positive, and equals -((-x) % y).
+		p := IntRange{big.NewInt(0).Neg(negX[1]), nil}
+		if negX[0] != nil {
+			p[1] = big.NewInt(0).Neg(negX[0])
+		}
+		r := p.remPositive(m, n)
+		ret.raiseMax(biggerInt{i: big.NewInt(0).Neg(r[0])})
+		if r[1] == nil {
+			ret.lowerMin(biggerInt{extra: -1})
+		} else {
+			ret.lowerMin(biggerInt{i: big.NewInt(0).Neg(r[1])})
+		}
+	}
+
+	if !posXEmpty {
+		// x is positive, so x % y is non-negative.
+		r := posX.remPositive(m, n)
+		ret.lowerMin(biggerInt{i: r[0]})
+		if r[1] == nil {
+			ret.raiseMax(biggerInt{extra: +1})
+		} else {
+			ret.raiseMax(biggerInt{i: r[1]})
+		}
+	}
+
+	return ret.toIntRange(), true
+}
+
+// remPositive returns x % y for a non-empty, positive x and a y in the
+// interval [m, n], where m is positive and a nil n means +∞.
+func (x IntRange) remPositive(m *big.Int, n *big.Int) IntRange {
+	if x[1] == nil {
+		// x can be an arbitrarily large multiple of m, or one less than an
+		// arbitrarily large multiple of n.
+		if n == nil {
+			return IntRange{big.NewInt(0), nil}
+		}
+		return IntRange{big.NewInt(0), big.NewInt(0).Sub(n, one)}
+	}
+	if m.Cmp(x[1]) > 0 {
+		// Every y is greater than every x, so x % y is x.
+		return x
+	}
+
+	// Every y greater than x[1] gives the same x % y, namely x, so that we can
+	// replace any larger (or infinite) n by (x[1] + 1).
+	if xp1 := big.NewInt(0).Add(x[1], one); n == nil || n.Cmp(xp1) > 0 {
+		n = xp1
+	}
+	return IntRange{remMin(x[0], x[1], m, n), remMax(x[0], x[1], m, n)}
+}
+
+// remMaxIterations bounds the loops in remMin and remMax, which otherwise run
+// in time proportional to the square root of x's maximum. Giving up early
+// produces a looser, but still valid, bound.
+const remMaxIterations = 1024
+
+// remMin returns the minimum of (i % j), for i in the interval [x0, x1] and j
+// in the interval [m, n], where 0 < x0 <= x1, 0 < m <= x1 and n <= (x1 + 1).
+//
+// Algorithm: for a given q, the j such that (x0 / j) == q form a contiguous
+// block. Within that block, (i % j) is zero if some multiple of j is in the
+// interval [x0, x1], which is when (x1 / (q + 1)) is at least the block's
+// lowest j. Otherwise, (i % j) is minimized at i = x0, where it equals (x0 -
+// q*j), and at the block's highest j.
+func remMin(x0 *big.Int, x1 *big.Int, m *big.Int, n *big.Int) *big.Int {
+	zero := big.NewInt(0)
+	ret := (*big.Int)(nil)
+	j := big.NewInt(0).Set(n)
+	for iteration := 0; j.Cmp(m) >= 0; iteration++ {
+		if iteration == remMaxIterations {
+			return zero
+		}
+		q := bigIntQuo(x0, j)
+		qp1 := big.NewInt(0).Add(q, one)
+
+		// lo is the block's lowest j.
+		lo := bigIntQuo(x0, qp1)
+		lo.Add(lo, one)
+		if lo.Cmp(m) < 0 {
+			lo.Set(m)
+		}
+		if lo.Cmp(bigIntQuo(x1, qp1)) <= 0 {
+			return zero
+		}
+
+		r := bigIntMul(q, j)
+		r.Sub(x0, r)
+		if ret == nil || ret.Cmp(r) > 0 {
+			ret = r
+		}
+		j.Sub(lo, one)
+	}
+	return ret
+}
+
+// remMax returns the maximum of (i % j), for i in the interval [x0, x1] and j
+// in the interval [m, n], where 0 < x0 <= x1, 0 < m <= x1 and n <= (x1 + 1).
+//
+// Algorithm: for a given q, the j such that (x1 / j) == q form a contiguous
+// block. If (q * j) > x0 for the block's highest j, then i = (q*j - 1) gives
+// the largest possible remainder, (j - 1), for that j and all smaller j.
+// Otherwise, (i % j) is maximized at i = x1, where it equals (x1 - q*j), and at
+// the block's lowest j.
+func remMax(x0 *big.Int, x1 *big.Int, m *big.Int, n *big.Int) *big.Int {
+	if n.Cmp(x1) > 0 {
+		// j can be greater than x1, and so (i % j) can be x1 itself.
+		return big.NewInt(0).Set(x1)
+	}
+	ret := (*big.Int)(nil)
+	j := big.NewInt(0).Set(n)
+	for iteration := 0; j.Cmp(m) >= 0; iteration++ {
+		jm1 := big.NewInt(0).Sub(j, one)
+		if ret != nil && ret.Cmp(jm1) >= 0 {
+			break
+		}
+		if iteration == remMaxIterations {
+			return jm1
+		}
+		q := bigIntQuo(x1, j)
+		if bigIntMul(q, j).Cmp(x0) > 0 {
+			return jm1
+		}
+
+		// lo is the block's lowest j.
+		lo := bigIntQuo(x1, big.NewInt(0).Add(q, one))
+		lo.Add(lo, one)
+		if lo.Cmp(m) < 0 {
+			lo.Set(m)
+		}
+
+		r := bigIntMul(q, lo)
+		r.Sub(x1, r)
+		if ret == nil || ret.Cmp(r) < 0 {
+			ret = r
+		}
+		j.Sub(lo, one)
+	}
+	return ret
+}
+
 // Rsh returns z = x >> y.
 //
 // ok is false (and z will be IntRange{nil, nil}) if x is non-empty and y
@@ -646,6 +826,66 @@ func (x IntRange) Or(y IntRange) (z IntRange, ok bool) {
 	return IntRange{zMin, zMax}, true
 }
 
+// Xor returns z = x ^ y.
+//
+// ok is false (and z will be IntRange{nil, nil}) if x or y contains at least
+// one negative value. Otherwise, ok is true.
+//
+// TODO: implement bit-wise operations (with tight bounds) on negative
+// integers. In that case, we could drop the "ok" return value.
+func (x IntRange) Xor(y IntRange) (z IntRange, ok bool) {
+	if x.Empty() || y.Empty() {
+		return empty(), true
+	}
+	if x.ContainsNegative() || y.ContainsNegative() {
+		return IntRange{}, false
+	}
+
+	if x[1] != nil && y[1] != nil {
+		return IntRange{x.xorMin(y), x.xorMax(y)}, true
+	}
+
+	// Keep zMax as nil, which means that (x ^ y) can be arbitrarily large.
+	//
+	// To calculate zMin, replace any infinite upper bound by a finite value f,
+	// equal to right-filling all of the bits of every finite bound. If the
+	// integers xx and yy are in the intervals x and y, and exactly one of them
+	// is greater than f, then (xx ^ yy) is also greater than f, and so is not
+	// the minimum. If both of them are greater than f, then the two intervals
+	// overlap, and so the minimum is zero, which the replaced intervals also
+	// produce.
+	f := big.NewInt(0)
+	for _, i := range [4]*big.Int{x[0], x[1], y[0], y[1]} {
+		if i != nil && f.Cmp(i) < 0 {
+			f.Set(i)
+		}
+	}
+	bitFillRight(f)
+	if x[1] == nil {
+		x[1] = f
+	}
+	if y[1] == nil {
+		y[1] = f
+	}
+	return IntRange{x.xorMin(y), nil}, true
+}
+
+// Not returns z = ^x, the bit-wise complement of x, which equals (-x - 1).
+// Unlike other bit-wise operations, there is no "ok" return value, as Not is
+// equally well defined for negative integers.
+func (x IntRange) Not() (z IntRange) {
+	if x.Empty() {
+		return empty()
+	}
+	if x[1] != nil {
+		z[0] = big.NewInt(0).Not(x[1])
+	}
+	if x[0] != nil {
+		z[1] = big.NewInt(0).Not(x[0])
+	}
+	return z
+}
+
 // The andMax and orMax algorithms are tricky.
 //
 // First, some notation. Let x and y be intervals, and in math notation, denote
@@ -916,3 +1156,82 @@ func bitFillRight(i *big.Int) {
 	i.Lsh(i, uint(n))
 	i.Sub(i, one)
 }
+
+// The xorMin and xorMax algorithms are from "Hacker's Delight", section 4-3.
+// Both x and y must be non-empty, non-negative and have finite bounds.
+//
+// For xorMin, for each bit m, from high to low, where exactly one of xMin and
+// yMin have that bit set, try setting that bit (and clearing the lower bits)
+// in the other minimum. This cancels that bit in the result, and is possible
+// if the other minimum remains no greater than its maximum.
+//
+// For xorMax, for each bit m, from high to low, where xMax and yMax both have
+// that bit set, try clearing that bit (and setting the lower bits) in one of
+// the maximums. This sets all lower bits in the result, and is possible if
+// that maximum remains no less than its minimum.
+func (x IntRange) xorMin(y IntRange) *big.Int {
+	xMin := big.NewInt(0).Set(x[0])
+	yMin := big.NewInt(0).Set(y[0])
+	i := big.NewInt(0)
+	for b := bitLen(x[1], y[1]) - 1; b >= 0; b-- {
+		if xMin.Bit(b) == yMin.Bit(b) {
+			continue
+		}
+		lo, hi := xMin, x[1]
+		if xMin.Bit(b) != 0 {
+			lo, hi = yMin, y[1]
+		}
+		// i = (lo | m) &^ (m - 1), where m is (1 << b).
+		i.Rsh(lo, uint(b))
+		i.SetBit(i, 0, 1)
+		i.Lsh(i, uint(b))
+		if i.Cmp(hi) <= 0 {
+			lo.Set(i)
+		}
+	}
+	return xMin.Xor(xMin, yMin)
+}
+
+func (x IntRange) xorMax(y IntRange) *big.Int {
+	xMax := big.NewInt(0).Set(x[1])
+	yMax := big.NewInt(0).Set(y[1])
+	i := big.NewInt(0)
+	for b := bitLen(x[1], y[1]) - 1; b >= 0; b-- {
+		if xMax.Bit(b) == 0 || yMax.Bit(b) == 0 {
+			continue
+		}
+		// i = (xMax - m) | (m - 1), where m is (1 << b).
+		setLowBits(i, xMax, b)
+		if i.Cmp(x[0]) >= 0 {
+			xMax.Set(i)
+			continue
+		}
+		setLowBits(i, yMax, b)
+		if i.Cmp(y[0]) >= 0 {
+			yMax.Set(i)
+		}
+	}
+	return xMax.Xor(xMax, yMax)
+}
+
+// bitLen returns the larger of i's and j's bit lengths.
+func bitLen(i *big.Int, j *big.Int) int {
+	n := i.BitLen()
+	if m := j.BitLen(); n < m {
+		n = m
+	}
+	if n > 0xFFFF {
+		panic("interval: input is too large")
+	}
+	return n
+}
+
+// setLowBits sets dst to src, with bit b cleared and all lower bits set. Bit b
+// of src must be set.
+func setLowBits(dst *big.Int, src *big.Int, b int) {
+	dst.Rsh(src, uint(b))
+	dst.SetBit(dst, 0, 0)
+	dst.Lsh(dst, uint(b))
+	mask := big.NewInt(0).Lsh(one, uint(b))
+	dst.Or(dst, mask.Sub(mask, one))
+}
@@ -422,10 +422,12 @@ var intOperators = map[string]func(IntRange, IntRange) (IntRange, bool){
 	"-":  func(x IntRange, y IntRange) (z IntRange, ok bool) { return x.Sub(y), true },
 	"*":  func(x IntRange, y IntRange) (z IntRange, ok bool) { return x.Mul(y), true },
 	"/":  IntRange.Quo,
+	"%":  IntRange.Rem,
 	"<<": IntRange.Lsh,
 	">>": IntRange.Rsh,
 	"&":  IntRange.And,
 	"|":  IntRange.Or,
+	"^":  IntRange.Xor,
 }
 
 var intOperatorsKeys []string
@@ -595,6 +597,66 @@ func TestOpQuo(tt *testing.T) {
 	)
 }
 
+func TestOpRem(tt *testing.T) {
+	testOp(tt,
+		"[   3,    3]   %  [  -5,   -5]  ==  [   3,    3]",
+		"[   3,    3]   %  [   0,    0]  ==  invalid",
+		"[   0,    0]   %  [  -7,    7]  ==  invalid",
+		"[   0,    2]   %  [   0,    5]  ==  invalid",
+		"[   3,    6]   %  [  10,   15]  ==  [   3,    6]",
+		"[   3,   +∞)   %  [  -4,   -2]  ==  [   0,    3]",
+		"[   3,   +∞)   %  [  10,   15]  ==  [   0,   14]",
+		"[   3,   +∞)   %  (  -∞,   15]  ==  invalid",
+		"[   3,    6]   %  (  -∞,   15]  ==  invalid",
+		"[   3,    6]   %  (  -∞,   +∞)  ==  invalid",
+		"(  -∞,   +∞)   %  (  -∞,   +∞)  ==  invalid",
+		"(  -∞,   +∞)   %  [   1,    2]  ==  [  -1,    1]",
+		"(  -∞,   +∞)   %  [   0,    0]  ==  invalid",
+		"[   3,    6]   %  [...empty..]  ==  [...empty..]",
+		"[...empty..]   %  [  10,   15]  ==  [...empty..]",
+		"[...empty..]   %  [...empty..]  ==  [...empty..]",
+		"(  -∞,   +∞)   %  [...empty..]  ==  [...empty..]",
+
+		"[   1,    4]   %  [ -11,  -10]  ==  [   1,    4]",
+
+		"[   1,    4]   %  [  -6,    2]  ==  invalid",
+
+		"[  -3,   -1]   %  [   1,    3]  ==  [  -2,    0]",
+		"[  -3,    0]   %  [   1,    3]  ==  [  -2,    0]",
+		"[  -3,    1]   %  [   1,    3]  ==  [  -2,    1]",
+		"[  -3,    4]   %  [   1,    3]  ==  [  -2,    2]",
+		"[  -1,    4]   %  [   1,    3]  ==  [  -1,    2]",
+		"[   0,    4]   %  [   1,    3]  ==  [   0,    2]",
+		"[   1,    4]   %  [   1,    3]  ==  [   0,    2]",
+
+		"[  -3,   -1]   %  [   2,    3]  ==  [  -2,    0]",
+		"[  -3,    0]   %  [   2,    3]  ==  [  -2,    0]",
+		"[  -3,    1]   %  [   2,    3]  ==  [  -2,    1]",
+		"[  -3,    4]   %  [   2,    3]  ==  [  -2,    2]",
+		"[  -1,    4]   %  [   2,    3]  ==  [  -1,    2]",
+		"[   0,    4]   %  [   2,    3]  ==  [   0,    2]",
+		"[   1,    4]   %  [   2,    3]  ==  [   0,    2]",
+
+		"[  -9,   +∞)   %  [   2,   +∞)  ==  [  -9,   +∞)",
+		"[  -1,   +∞)   %  [   2,   +∞)  ==  [  -1,   +∞)",
+		"[   0,   +∞)   %  [   2,   +∞)  ==  [   0,   +∞)",
+		"[   1,   +∞)   %  [   2,   +∞)  ==  [   0,   +∞)",
+		"[   7,   +∞)   %  [   2,   +∞)  ==  [   0,   +∞)",
+		"[  -1,    1]   %  (  -∞,   +∞)  ==  invalid",
+		"[   0,    0]   %  (  -∞,   +∞)  ==  invalid",
+		"[   1,    1]   %  (  -∞,   +∞)  ==  invalid",
+
+		"[   5,    5]   %  [   3,    3]  ==  [   2,    2]",
+		"[   5,    6]   %  [   3,    4]  ==  [   0,    2]",
+		"[   7,    8]   %  [   5,    6]  ==  [   1,    3]",
+		"[  12,   13]   %  [   5,    5]  ==  [   2,    3]",
+		"[  13,   15]   %  [   5,    5]  ==  [   0,    4]",
+		"[  13,   15]   %  [  14,   +∞)  ==  [   0,   15]",
+		"[  13,   13]   %  [  14,   +∞)  ==  [  13,   13]",
+		"[ -13,  -12]   %  [  -5,   -5]  ==  [  -3,   -2]",
+	)
+}
+
 func TestOpLsh(tt *testing.T) {
 	testOp(tt,
 		"[   3,    3]  <<  [  -5,   -5]  ==  invalid",
@@ -828,3 +890,77 @@ func TestOpOr(tt *testing.T) {
 		"[   5,    9]   |  [  12,   +∞)  ==  [  12,   +∞)",
 	)
 }
+
+func TestOpXor(tt *testing.T) {
+	testOp(tt,
+		"[   3,    3]   ^  [  -5,   -5]  ==  invalid",
+		"[   3,    3]   ^  [   0,    0]  ==  [   3,    3]",
+		"[   0,    0]   ^  [  -7,    7]  ==  invalid",
+		"[   0,    2]   ^  [   0,    5]  ==  [   0,    7]",
+		"[   3,    6]   ^  [  10,   15]  ==  [   8,   15]",
+		"[   3,   +∞)   ^  [  -4,   -2]  ==  invalid",
+		"[   3,   +∞)   ^  [  10,   15]  ==  [   0,   +∞)",
+		"[   3,   +∞)   ^  (  -∞,   15]  ==  invalid",
+		"[   3,    6]   ^  (  -∞,   15]  ==  invalid",
+		"[   3,    6]   ^  (  -∞,   +∞)  ==  invalid",
+		"(  -∞,   +∞)   ^  (  -∞,   +∞)  ==  invalid",
+		"(  -∞,   +∞)   ^  [   1,    2]  ==  invalid",
+		"(  -∞,   +∞)   ^  [   0,    0]  ==  invalid",
+		"[   3,    6]   ^  [...empty..]  ==  [...empty..]",
+		"[...empty..]   ^  [  10,   15]  ==  [...empty..]",
+		"[...empty..]   ^  [...empty..]  ==  [...empty..]",
+		"(  -∞,   +∞)   ^  [...empty..]  ==  [...empty..]",
+
+		"[   1,    4]   ^  [ -11,  -10]  ==  invalid",
+
+		"[   1,    4]   ^  [  -6,    2]  ==  invalid",
+
+		"[  -3,   -1]   ^  [   0,    3]  ==  invalid",
+		"[  -1,    4]   ^  [   0,    3]  ==  invalid",
+		"[   0,    4]   ^  [   0,    3]  ==  [   0,    7]",
+		"[   1,    4]   ^  [   0,    3]  ==  [   0,    7]",
+
+		"[   0,   +∞)   ^  [   2,   +∞)  ==  [   0,   +∞)",
+		"[   1,   +∞)   ^  [   2,   +∞)  ==  [   0,   +∞)",
+		"[   7,   +∞)   ^  [   2,   +∞)  ==  [   0,   +∞)",
+
+		"[   1,    3]   ^  [   4,    9]  ==  [   4,   11]",
+		"[   3,    4]   ^  [   5,    6]  ==  [   1,    6]",
+		"[   4,    5]   ^  [   6,    7]  ==  [   2,    3]",
+		"[   7,    7]   ^  [  12,   14]  ==  [   9,   11]",
+
+		"[   5,    6]   ^  [   8,   +∞)  ==  [   8,   +∞)",
+		"[   5,    9]   ^  [   8,   +∞)  ==  [   0,   +∞)",
+		"[   5,    6]   ^  [  12,   +∞)  ==  [   8,   +∞)",
+	)
+}
+
+func TestOpNot(tt *testing.T) {
+	testCases := []struct {
+		x, want string
+	}{
+		{"[   3,    3]", "[  -4,   -4]"},
+		{"[  -4,    6]", "[  -7,    3]"},
+		{"[   0,   +∞)", "(  -∞,   -1]"},
+		{"(  -∞,   -5]", "[   4,   +∞)"},
+		{"(  -∞,   +∞)", "(  -∞,   +∞)"},
+		{"[...empty..]", "[...empty..]"},
+	}
+	for _, tc := range testCases {
+		x, _, err := parseInterval(tc.x)
+		if err != nil {
+			tt.Fatalf("%q: %v", tc.x, err)
+		}
+		want, _, err := parseInterval(tc.want)
+		if err != nil {
+			tt.Fatalf("%q: %v", tc.want, err)
+		}
+		if got := x.Not(); !got.Eq(want) {
+			tt.Errorf("%v: got %v, want %v", x, got, want)
+		}
+		// Not is its own inverse.
+		if got := x.Not().Not(); !got.Eq(x) {
+			tt.Errorf("%v: double Not: got %v", x, got)
+		}
+	}
+}
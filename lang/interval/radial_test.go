@@ -38,8 +38,8 @@ package interval
 // If x and y are "small" radialInput values or one of the two "smallest large"
 // radialInput values, i.e. x and y are in the range [-16, +16], then (x op y)
 // will always be a "small" radialOutput value, for the common binary
-// operators: add, subtract, multiply, divide, left-shift, right-shift, and,
-// or.
+// operators: add, subtract, multiply, divide, remainder, left-shift,
+// right-shift, and, or, xor.
 //
 // Both of these radialInput and radialOutput types are encoded as an int32:
 //  - math.MinInt32 (which equals -1 << 31) encodes a NaN.
@@ -395,15 +395,87 @@ func (x radialInput) Or(y radialInput) radialOutPair {
 	}
 }
 
+func (x radialInput) Xor(y radialInput) radialOutPair {
+	if x == radialNaN || y == radialNaN {
+		return radialOutPair{radialNaN, radialNaN}
+	}
+	if x < 0 || y < 0 {
+		// TODO: handle negative numbers.
+		return radialOutPair{radialNaN, radialNaN}
+	}
+	ox := x.canonicalize()
+	oy := y.canonicalize()
+
+	// r is a power of 2, so that its binary representation contains one "1"
+	// digit, and that digit is not shared with any "small" value <= riRadius.
+	// A "large" value's bits above riRadius's are never cancelled by xor-ing
+	// with a "small" value.
+	const r = riRadius + 1
+
+	if ox <= +riRadius {
+		if oy <= +riRadius {
+			return radialOutPair{ox ^ oy, ox ^ oy}
+		} else {
+			return radialOutPair{r, roLargePos}
+		}
+	} else {
+		if oy <= +riRadius {
+			return radialOutPair{r, roLargePos}
+		} else {
+			return radialOutPair{0, roLargePos}
+		}
+	}
+}
+
+func (x radialInput) Rem(y radialInput) radialOutPair {
+	if x == radialNaN || y == radialNaN || y == 0 {
+		return radialOutPair{radialNaN, radialNaN}
+	}
+	ox := x.canonicalize()
+	oy := y.canonicalize()
+
+	switch {
+	case ox < -riRadius:
+		switch {
+		case oy < -riRadius, oy > +riRadius:
+			return radialOutPair{roLargeNeg, 0}
+		default:
+			if oy < 0 {
+				oy = -oy
+			}
+			return radialOutPair{1 - oy, 0}
+		}
+	case ox > +riRadius:
+		switch {
+		case oy < -riRadius, oy > +riRadius:
+			return radialOutPair{0, roLargePos}
+		default:
+			if oy < 0 {
+				oy = -oy
+			}
+			return radialOutPair{0, oy - 1}
+		}
+	default:
+		switch {
+		case oy < -riRadius, oy > +riRadius:
+			return radialOutPair{ox, ox}
+		default:
+			return radialOutPair{ox % oy, ox % oy}
+		}
+	}
+}
+
 var riOperators = map[string]func(radialInput, radialInput) radialOutPair{
 	"+":  radialInput.Add,
 	"-":  radialInput.Sub,
 	"*":  radialInput.Mul,
 	"/":  radialInput.Quo,
+	"%":  radialInput.Rem,
 	"<<": radialInput.Lsh,
 	">>": radialInput.Rsh,
 	"&":  radialInput.And,
 	"|":  radialInput.Or,
+	"^":  radialInput.Xor,
 }
 
 func bruteForce(x IntRange, y IntRange, opKey string) (z IntRange, ok bool) {
@@ -251,6 +251,54 @@ func (p *parser) parseTopLevelDecl() (*a.Node, error) {
 			p.src = p.src[1:]
 			return a.NewStatus(flags, p.filename, line, keyword, message).Node(), nil
 
+		case t.KeyLemma:
+			p.src = p.src[1:]
+			name := p.peek1()
+			if !name.IsStrLiteral() {
+				got := p.tm.ByID(name)
+				return nil, fmt.Errorf(`parse: expected string literal, got %q at %s:%d`, got, p.filename, p.line())
+			}
+			p.src = p.src[1:]
+			if x := p.peek1().Key(); x != t.KeyColon {
+				got := p.tm.ByKey(x)
+				return nil, fmt.Errorf(`parse: expected ":", got %q at %s:%d`, got, p.filename, p.line())
+			}
+			p.src = p.src[1:]
+			conclusion, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			// The premises are separated by explicit ";"s on the same line.
+			// An implicit ";", at the end of the line, ends the lemma.
+			premises := []*a.Node(nil)
+			for len(p.src) > 1 && p.src[0].ID.Key() == t.KeySemicolon && p.src[0].Line == p.src[1].Line {
+				p.src = p.src[1:]
+				premise, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				premises = append(premises, premise.Node())
+			}
+			proofSteps := []*a.Node(nil)
+			if p.peek1().Key() == t.KeyOpenCurly {
+				proofSteps, err = p.parseBlock()
+				if err != nil {
+					return nil, err
+				}
+				for _, o := range proofSteps {
+					if o.Kind() != a.KAssert || o.Assert().Keyword().Key() != t.KeyAssert {
+						filename, line := o.Raw().FilenameLine()
+						return nil, fmt.Errorf(`parse: expected "assert" in lemma proof at %s:%d`, filename, line)
+					}
+				}
+			}
+			if x := p.peek1().Key(); x != t.KeySemicolon {
+				got := p.tm.ByKey(x)
+				return nil, fmt.Errorf(`parse: expected (implicit) ";", got %q at %s:%d`, got, p.filename, p.line())
+			}
+			p.src = p.src[1:]
+			return a.NewLemma(flags, p.filename, line, name, conclusion, premises, proofSteps).Node(), nil
+
 		case t.KeyStruct:
 			p.src = p.src[1:]
 			name, err := p.parseIdent()
@@ -369,7 +417,11 @@ func (p *parser) parseFieldNode() (*a.Node, error) {
 	defaultValue := (*a.Expr)(nil)
 	if p.peek1().Key() == t.KeyEq {
 		p.src = p.src[1:]
-		defaultValue, err = p.parseExpr()
+		if p.peek1().Key() == t.KeyDollar {
+			defaultValue, err = p.parseDollarExpr()
+		} else {
+			defaultValue, err = p.parseExpr()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -543,12 +595,23 @@ func (p *parser) parseAssertNode() (*a.Node, error) {
 		if err != nil {
 			return nil, err
 		}
-		reason, args := t.ID(0), []*a.Node(nil)
+		reason, args := t.QID{}, []*a.Node(nil)
 		if p.peek1().Key() == t.KeyVia {
 			p.src = p.src[1:]
-			reason = p.peek1()
-			if !reason.IsStrLiteral() {
-				got := p.tm.ByID(reason)
+			// A used package's lemma is qualified by that package's name, as
+			// in `via foo."bar"(etc)`.
+			if x := p.peek1(); x.IsIdent() {
+				p.src = p.src[1:]
+				if p.peek1().Key() != t.KeyDot {
+					got := p.tm.ByID(p.peek1())
+					return nil, fmt.Errorf(`parse: expected ".", got %q at %s:%d`, got, p.filename, p.line())
+				}
+				p.src = p.src[1:]
+				reason[0] = x
+			}
+			reason[1] = p.peek1()
+			if !reason[1].IsStrLiteral() {
+				got := p.tm.ByID(reason[1])
 				return nil, fmt.Errorf(`parse: expected string literal, got %q at %s:%d`, got, p.filename, p.line())
 			}
 			p.src = p.src[1:]
@@ -655,6 +718,10 @@ func (p *parser) parseStatement1() (*a.Node, error) {
 		}
 		return a.NewRet(x, value).Node(), nil
 
+	case t.KeySwitch:
+		o, err := p.parseSwitch()
+		return o.Node(), err
+
 	case t.KeyVar:
 		p.src = p.src[1:]
 		return p.parseVar(false)
@@ -744,6 +811,74 @@ func (p *parser) parseIf() (*a.If, error) {
 	return a.NewIf(condition, elseIf, bodyIfTrue, bodyIfFalse), nil
 }
 
+func (p *parser) parseSwitch() (*a.Switch, error) {
+	if x := p.peek1().Key(); x != t.KeySwitch {
+		got := p.tm.ByKey(x)
+		return nil, fmt.Errorf(`parse: expected "switch", got %q at %s:%d`, got, p.filename, p.line())
+	}
+	p.src = p.src[1:]
+	subject, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if x := p.peek1().Key(); x != t.KeyOpenCurly {
+		got := p.tm.ByKey(x)
+		return nil, fmt.Errorf(`parse: expected "{", got %q at %s:%d`, got, p.filename, p.line())
+	}
+	p.src = p.src[1:]
+
+	cases, bodyElse := []*a.Node(nil), []*a.Node(nil)
+	for {
+		if x := p.peek1().Key(); x == t.KeyElse {
+			p.src = p.src[1:]
+			if bodyElse, err = p.parseBlock(); err != nil {
+				return nil, err
+			}
+			break
+		} else if x != t.KeyCase {
+			got := p.tm.ByKey(x)
+			return nil, fmt.Errorf(`parse: expected "case" or "else", got %q at %s:%d`,
+				got, p.filename, p.line())
+		}
+		line := p.line()
+		p.src = p.src[1:]
+		min, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		max := (*a.Expr)(nil)
+		if p.peek1().Key() == t.KeyDotDot {
+			p.src = p.src[1:]
+			if max, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		body, err := p.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+		c := a.NewCase(min, max, body)
+		c.Node().Raw().SetFilenameLine(p.filename, line)
+		cases = append(cases, c.Node())
+
+		if x := p.peek1().Key(); x != t.KeySemicolon {
+			got := p.tm.ByKey(x)
+			return nil, fmt.Errorf(`parse: expected (implicit) ";", got %q at %s:%d`, got, p.filename, p.line())
+		}
+		p.src = p.src[1:]
+	}
+
+	if p.peek1().Key() == t.KeySemicolon {
+		p.src = p.src[1:]
+	}
+	if x := p.peek1().Key(); x != t.KeyCloseCurly {
+		got := p.tm.ByKey(x)
+		return nil, fmt.Errorf(`parse: expected "}", got %q at %s:%d`, got, p.filename, p.line())
+	}
+	p.src = p.src[1:]
+	return a.NewSwitch(subject, cases, bodyElse), nil
+}
+
 func (p *parser) parseArgNode() (*a.Node, error) {
 	name, err := p.parseIdent()
 	if err != nil {
@@ -814,6 +949,10 @@ func (p *parser) parseDollarExpr() (*a.Expr, error) {
 		return nil, fmt.Errorf(`parse: expected "$", got %q at %s:%d`, got, p.filename, p.line())
 	}
 	p.src = p.src[1:]
+	if len(p.src) >= 3 && p.src[0].ID.Key() == t.KeyOpenParen &&
+		p.src[1].IsIdent() && p.src[2].ID.Key() == t.KeyOpenParen {
+		return p.parseComputedDollarExpr()
+	}
 	args, err := p.parseList(t.KeyCloseParen, (*parser).parseExprNode)
 	if err != nil {
 		return nil, err
@@ -821,6 +960,49 @@ func (p *parser) parseDollarExpr() (*a.Expr, error) {
 	return a.NewExpr(0, t.IDDollar, 0, 0, nil, nil, nil, args), nil
 }
 
+// parseComputedDollarExpr parses the "(f(i:lo..hi))" that follows the "$" of
+// a computed list. A plain list's elements cannot be function calls, so the
+// "(", identifier, "(" prefix is unambiguous.
+func (p *parser) parseComputedDollarExpr() (*a.Expr, error) {
+	p.src = p.src[1:]
+	funcName, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	p.src = p.src[1:]
+	argName, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	if x := p.peek1().Key(); x != t.KeyColon {
+		got := p.tm.ByKey(x)
+		return nil, fmt.Errorf(`parse: expected ":", got %q at %s:%d`, got, p.filename, p.line())
+	}
+	p.src = p.src[1:]
+	lo, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if x := p.peek1().Key(); x != t.KeyDotDot {
+		got := p.tm.ByKey(x)
+		return nil, fmt.Errorf(`parse: expected "..", got %q at %s:%d`, got, p.filename, p.line())
+	}
+	p.src = p.src[1:]
+	hi, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < 2; i++ {
+		if x := p.peek1().Key(); x != t.KeyCloseParen {
+			got := p.tm.ByKey(x)
+			return nil, fmt.Errorf(`parse: expected ")", got %q at %s:%d`, got, p.filename, p.line())
+		}
+		p.src = p.src[1:]
+	}
+	arg := a.NewArg(argName, lo)
+	return a.NewExpr(0, t.IDDollar, 0, funcName, nil, nil, hi.Node(), []*a.Node{arg.Node()}), nil
+}
+
 func (p *parser) parseTryExpr() (*a.Expr, error) {
 	if x := p.peek1().Key(); x != t.KeyTry {
 		got := p.tm.ByKey(x)
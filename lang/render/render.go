@@ -143,6 +143,12 @@ func Render(w io.Writer, tm *t.Map, src []t.Token, comments []string) (err error
 				// operator looks unary instead of binary.
 				prevIsTightRight = prevID.Flags()&flagsCIL == 0
 			}
+			// The ":" token's tight-right-ness is also context dependent. For
+			// "x[i:j]" or "(c:width)", it is tight-right. For the "lemma
+			// \"name\": etc" declaration, it is not.
+			if tok.ID.Key() == t.KeyColon && prevID.IsStrLiteral() {
+				prevIsTightRight = false
+			}
 
 			prevID = tok.ID
 		}
@@ -220,6 +220,7 @@ const (
 	KeyHat       = Key(IDHat >> KeyShift)
 	KeyPercent   = Key(IDPercent >> KeyShift)
 	KeyTildePlus = Key(IDTildePlus >> KeyShift)
+	KeyTilde     = Key(IDTilde >> KeyShift)
 
 	KeyNotEq       = Key(IDNotEq >> KeyShift)
 	KeyLessThan    = Key(IDLessThan >> KeyShift)
@@ -263,6 +264,9 @@ const (
 	KeyTry        = Key(IDTry >> KeyShift)
 	KeyIterate    = Key(IDIterate >> KeyShift)
 	KeyYield      = Key(IDYield >> KeyShift)
+	KeySwitch     = Key(IDSwitch >> KeyShift)
+	KeyCase       = Key(IDCase >> KeyShift)
+	KeyLemma      = Key(IDLemma >> KeyShift)
 
 	KeyFalse = Key(IDFalse >> KeyShift)
 	KeyTrue  = Key(IDTrue >> KeyShift)
@@ -326,12 +330,14 @@ const (
 	KeyHighBits          = Key(IDHighBits >> KeyShift)
 	KeyUnreadU8          = Key(IDUnreadU8 >> KeyShift)
 	KeyIsMarked          = Key(IDIsMarked >> KeyShift)
+	KeyFrameConfig       = Key(IDFrameConfig >> KeyShift)
 
 	KeyXUnaryPlus  = Key(IDXUnaryPlus >> KeyShift)
 	KeyXUnaryMinus = Key(IDXUnaryMinus >> KeyShift)
 	KeyXUnaryNot   = Key(IDXUnaryNot >> KeyShift)
 	KeyXUnaryRef   = Key(IDXUnaryRef >> KeyShift)
 	KeyXUnaryDeref = Key(IDXUnaryDeref >> KeyShift)
+	KeyXUnaryTilde = Key(IDXUnaryTilde >> KeyShift)
 
 	KeyXBinaryPlus        = Key(IDXBinaryPlus >> KeyShift)
 	KeyXBinaryMinus       = Key(IDXBinaryMinus >> KeyShift)
@@ -412,6 +418,7 @@ const (
 	IDHat       = ID(0x3A<<KeyShift | FlagsBinaryOp | FlagsAssociativeOp)
 	IDPercent   = ID(0x3B<<KeyShift | FlagsBinaryOp | FlagsAssociativeOp)
 	IDTildePlus = ID(0x3C<<KeyShift | FlagsBinaryOp) // TODO: FlagsAssociativeOp?
+	IDTilde     = ID(0x3D<<KeyShift | FlagsUnaryOp | FlagsTightRight)
 
 	IDNotEq       = ID(0x40<<KeyShift | FlagsBinaryOp)
 	IDLessThan    = ID(0x41<<KeyShift | FlagsBinaryOp)
@@ -455,6 +462,9 @@ const (
 	IDTry        = ID(0x67<<KeyShift | FlagsOther)
 	IDIterate    = ID(0x68<<KeyShift | FlagsOther)
 	IDYield      = ID(0x69<<KeyShift | FlagsOther)
+	IDSwitch     = ID(0x6A<<KeyShift | FlagsOther)
+	IDCase       = ID(0x6B<<KeyShift | FlagsOther)
+	IDLemma      = ID(0x6C<<KeyShift | FlagsOther)
 
 	IDFalse = ID(0x70<<KeyShift | FlagsLiteral | FlagsImplicitSemicolon)
 	IDTrue  = ID(0x71<<KeyShift | FlagsLiteral | FlagsImplicitSemicolon)
@@ -518,6 +528,7 @@ const (
 	IDHighBits          = ID(0xAF<<KeyShift | FlagsIdent | FlagsImplicitSemicolon)
 	IDUnreadU8          = ID(0xB0<<KeyShift | FlagsIdent | FlagsImplicitSemicolon)
 	IDIsMarked          = ID(0xB1<<KeyShift | FlagsIdent | FlagsImplicitSemicolon)
+	IDFrameConfig       = ID(0xB2<<KeyShift | FlagsIdent | FlagsImplicitSemicolon)
 )
 
 // The IDXFoo IDs are not returned by the tokenizer. They are used by the
@@ -531,6 +542,7 @@ const (
 	IDXUnaryNot   = ID(0xD2<<KeyShift | FlagsUnaryOp)
 	IDXUnaryRef   = ID(0xD3<<KeyShift | FlagsUnaryOp)
 	IDXUnaryDeref = ID(0xD4<<KeyShift | FlagsUnaryOp)
+	IDXUnaryTilde = ID(0xD5<<KeyShift | FlagsUnaryOp)
 
 	IDXBinaryPlus        = ID(0xD8<<KeyShift | FlagsBinaryOp)
 	IDXBinaryMinus       = ID(0xD9<<KeyShift | FlagsBinaryOp)
@@ -624,7 +636,8 @@ var builtInsByKey = [nBuiltInKeys]struct {
 	KeyPipe:      {"|", IDPipe},
 	KeyHat:       {"^", IDHat},
 	KeyPercent:   {"%", IDPercent},
-	KeyTildePlus: {"~+", IDPercent},
+	KeyTildePlus: {"~+", IDTildePlus},
+	KeyTilde:     {"~", IDTilde},
 
 	KeyNotEq:       {"!=", IDNotEq},
 	KeyLessThan:    {"<", IDLessThan},
@@ -667,6 +680,9 @@ var builtInsByKey = [nBuiltInKeys]struct {
 	KeyTry:        {"try", IDTry},
 	KeyIterate:    {"iterate", IDIterate},
 	KeyYield:      {"yield", IDYield},
+	KeySwitch:     {"switch", IDSwitch},
+	KeyCase:       {"case", IDCase},
+	KeyLemma:      {"lemma", IDLemma},
 
 	KeyFalse: {"false", IDFalse},
 	KeyTrue:  {"true", IDTrue},
@@ -732,6 +748,7 @@ var builtInsByKey = [nBuiltInKeys]struct {
 	KeyHighBits:          {"high_bits", IDHighBits},
 	KeyUnreadU8:          {"unread_u8", IDUnreadU8},
 	KeyIsMarked:          {"is_marked", IDIsMarked},
+	KeyFrameConfig:       {"frame_config", IDFrameConfig},
 }
 
 var builtInsByName = map[string]ID{}
@@ -832,6 +849,7 @@ var lexers = [256][]suffixLexer{
 	'~': {
 		{"+=", IDTildePlusEq},
 		{"+", IDTildePlus},
+		{"", IDTilde},
 	},
 }
 
@@ -841,6 +859,7 @@ var ambiguousForms = [256]ID{
 	KeyXUnaryNot:   IDNot,
 	KeyXUnaryRef:   IDRef,
 	KeyXUnaryDeref: IDDeref,
+	KeyXUnaryTilde: IDTilde,
 
 	KeyXBinaryPlus:        IDPlus,
 	KeyXBinaryMinus:       IDMinus,
@@ -879,6 +898,7 @@ var unaryForms = [256]ID{
 	KeyNot:   IDXUnaryNot,
 	KeyRef:   IDXUnaryRef,
 	KeyDeref: IDXUnaryDeref,
+	KeyTilde: IDXUnaryTilde,
 }
 
 var binaryForms = [256]ID{
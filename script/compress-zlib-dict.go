@@ -0,0 +1,63 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// compress-zlib-dict.go applies zlib-compression with a preset dictionary,
+// read from the file named by the -dict flag. The zlib header's FDICT flag is
+// set and its DICTID is the Adler-32 checksum of that whole file.
+//
+// Usage: go run compress-zlib-dict.go -dict=foo.dict < foo.txt > foo.zlib
+
+import (
+	"compress/zlib"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	dict = flag.String("dict", "", "filename of the preset dictionary")
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func main1() error {
+	flag.Parse()
+	if *dict == "" {
+		return errors.New("no -dict flag given")
+	}
+	d, err := ioutil.ReadFile(*dict)
+	if err != nil {
+		return err
+	}
+	w, err := zlib.NewWriterLevelDict(os.Stdout, zlib.BestCompression, d)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, os.Stdin); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build ignore
+
+package main
+
+// extract-ycbcr-planes.go extracts the 1-byte-per-sample planes of a JPEG
+// image: the Y plane for gray images, or the Y, Cb and Cr planes, back to
+// back, for color images. Each plane is tightly packed, with no padding at the
+// end of each row, and the chroma planes are not upsampled.
+//
+// Go's image/jpeg package's IDCT is not libjpeg's, so its samples can differ
+// by 1 from those of other decoders.
+//
+// Usage: go run extract-ycbcr-planes.go foo.jpeg
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	if err := main1(); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func main1() error {
+	for _, a := range os.Args[1:] {
+		planes, ext, err := decode(a)
+		if err != nil {
+			return err
+		}
+		b := a[:len(a)-len(filepath.Ext(a))]
+		if err := ioutil.WriteFile(b+ext, planes, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decode(filename string) (planes []byte, ext string, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	m, err := jpeg.Decode(f)
+	if err != nil {
+		return nil, "", err
+	}
+	switch m := m.(type) {
+	case *image.Gray:
+		r := m.Rect
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			i := m.PixOffset(r.Min.X, y)
+			planes = append(planes, m.Pix[i:i+r.Dx()]...)
+		}
+		return planes, ".y", nil
+
+	case *image.YCbCr:
+		r := m.Rect
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			i := m.YOffset(r.Min.X, y)
+			planes = append(planes, m.Y[i:i+r.Dx()]...)
+		}
+		cw, ch := r.Dx(), r.Dy()
+		switch m.SubsampleRatio {
+		case image.YCbCrSubsampleRatio444:
+		case image.YCbCrSubsampleRatio422:
+			cw = (cw + 1) / 2
+		case image.YCbCrSubsampleRatio420:
+			cw, ch = (cw+1)/2, (ch+1)/2
+		case image.YCbCrSubsampleRatio440:
+			ch = (ch + 1) / 2
+		default:
+			return nil, "", fmt.Errorf("unsupported subsample ratio %v", m.SubsampleRatio)
+		}
+		for _, p := range [][]byte{m.Cb, m.Cr} {
+			for y := 0; y < ch; y++ {
+				planes = append(planes, p[y*m.CStride:y*m.CStride+cw]...)
+			}
+		}
+		return planes, ".ycbcr", nil
+	}
+	return nil, "", fmt.Errorf("decoded image type: got %T, want *image.Gray or *image.YCbCr", m)
+}
@@ -61,7 +61,10 @@ const char* mimic_gif_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
   }
 
   // Copy the pixel data from the GifFileType* f to the dst buffer, since the
-  // former is free'd at the end of this function.
+  // former is free'd at the end of this function. Like wuffs_gif_decode in
+  // test/c/std/gif.c, the frame is placed at its rect within an all-zero
+  // canvas the size of the image (the logical screen), clipped to that canvas.
+  // DGifSlurp has already de-interlaced the frame's rows.
   //
   // In theory, this mimic_gif_decode function might be faster overall if the
   // DGifSlurp call above decoded the pixel data directly into dst instead of
@@ -71,15 +74,34 @@ const char* mimic_gif_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
   // complicates supporting both versions 4 and 5 of giflib. That commit was
   // therefore rolled back.
   struct SavedImage* si = &f->SavedImages[0];
-  size_t num_src =
-      (size_t)(si->ImageDesc.Width) * (size_t)(si->ImageDesc.Height);
+  size_t width = (size_t)(f->SWidth);
+  size_t height = (size_t)(f->SHeight);
   size_t num_dst = dst->len - dst->wi;
-  if (num_dst < num_src) {
+  if ((height > 0) && (num_dst / height < width)) {
     ret = "GIF image's pixel data won't fit in the dst buffer";
     goto cleanup1;
   }
-  memmove(dst->ptr + dst->wi, si->RasterBits, num_src);
-  dst->wi += num_src;
+  uint8_t* canvas = dst->ptr + dst->wi;
+  memset(canvas, 0, width * height);
+
+  size_t frame_left = (size_t)(si->ImageDesc.Left);
+  size_t frame_top = (size_t)(si->ImageDesc.Top);
+  size_t frame_width = (size_t)(si->ImageDesc.Width);
+  size_t frame_height = (size_t)(si->ImageDesc.Height);
+  size_t n = 0;
+  if (frame_left < width) {
+    n = width - frame_left;
+    if (n > frame_width) {
+      n = frame_width;
+    }
+  }
+  size_t y;
+  for (y = 0; (n > 0) && (y < frame_height) && (frame_top + y < height);
+       y++) {
+    memmove(canvas + (frame_top + y) * width + frame_left,
+            si->RasterBits + y * frame_width, n);
+  }
+  dst->wi += width * height;
 
 cleanup1:;
 #if defined(GIFLIB_MAJOR) && (GIFLIB_MAJOR >= 5)
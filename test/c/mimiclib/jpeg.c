@@ -0,0 +1,128 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <setjmp.h>
+#include <stdio.h>
+
+#include "jpeglib.h"
+
+typedef struct {
+  struct jpeg_error_mgr pub;
+  jmp_buf jmp;
+} mimic_jpeg_error_mgr;
+
+void mimic_jpeg_error_exit(j_common_ptr cinfo) {
+  longjmp(((mimic_jpeg_error_mgr*)(cinfo->err))->jmp, 1);
+}
+
+const char* mimic_jpeg_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  // These are volatile because they are modified after the setjmp call and
+  // read after a longjmp back to it.
+  const char* volatile ret = NULL;
+  JSAMPLE* volatile samples = NULL;
+
+  struct jpeg_decompress_struct cinfo;
+  mimic_jpeg_error_mgr jerr;
+  cinfo.err = jpeg_std_error(&jerr.pub);
+  jerr.pub.error_exit = mimic_jpeg_error_exit;
+  jpeg_create_decompress(&cinfo);
+  if (setjmp(jerr.jmp)) {
+    if (!ret) {
+      ret = "libjpeg failed";
+    }
+    goto cleanup;
+  }
+  jpeg_mem_src(&cinfo, src->ptr + src->ri, src->wi - src->ri);
+  jpeg_read_header(&cinfo, TRUE);
+
+  // Like wuffs_jpeg_decode in test/c/std/jpeg.c, decode to planes of Y, Cb
+  // and Cr samples, without color conversion or chroma upsampling. The islow
+  // IDCT is libjpeg's default, but set it explicitly: the other IDCTs give
+  // noticeably different samples.
+  cinfo.raw_data_out = TRUE;
+  cinfo.dct_method = JDCT_ISLOW;
+  jpeg_start_decompress(&cinfo);
+
+  int nc = cinfo.num_components;
+  if ((nc != 1) && (nc != 3)) {
+    ret = "unsupported JPEG component count";
+    goto cleanup;
+  }
+
+  // jpeg_read_raw_data decodes one iMCU row (max_v_samp_factor * DCTSIZE
+  // rows of the full size image) at a time, into whole blocks of samples.
+  // Each plane's offset and size in dst is as per the Wuffs image config.
+  JSAMPROW rows[3][4 * DCTSIZE];
+  JSAMPARRAY planes[3];
+  size_t plane_offset[3];
+  size_t total = 0;
+  size_t stride[3];
+  size_t samples_size = 0;
+  int c;
+  for (c = 0; c < nc; c++) {
+    jpeg_component_info* comp = &cinfo.comp_info[c];
+    plane_offset[c] = total;
+    total += (size_t)(comp->downsampled_width) * comp->downsampled_height;
+    stride[c] = comp->width_in_blocks * DCTSIZE;
+    samples_size += stride[c] * comp->v_samp_factor * DCTSIZE;
+  }
+  if (total > dst->len - dst->wi) {
+    ret = "JPEG image's sample data won't fit in the dst buffer";
+    goto cleanup;
+  }
+  samples = malloc(samples_size);
+  if (!samples) {
+    ret = "out of memory";
+    goto cleanup;
+  }
+  JSAMPLE* p = samples;
+  for (c = 0; c < nc; c++) {
+    int r;
+    for (r = 0; r < cinfo.comp_info[c].v_samp_factor * DCTSIZE; r++) {
+      rows[c][r] = p;
+      p += stride[c];
+    }
+    planes[c] = rows[c];
+  }
+
+  JDIMENSION row_group = cinfo.max_v_samp_factor * DCTSIZE;
+  JDIMENSION y;
+  for (y = 0; y < cinfo.output_height; y += row_group) {
+    if (jpeg_read_raw_data(&cinfo, planes, row_group) != row_group) {
+      ret = "jpeg_read_raw_data failed";
+      goto cleanup;
+    }
+    for (c = 0; c < nc; c++) {
+      jpeg_component_info* comp = &cinfo.comp_info[c];
+      size_t w = comp->downsampled_width;
+      size_t h = comp->downsampled_height;
+      size_t cy0 = (y / row_group) * comp->v_samp_factor * DCTSIZE;
+      int r;
+      for (r = 0; r < comp->v_samp_factor * DCTSIZE; r++) {
+        if (cy0 + r >= h) {
+          break;
+        }
+        memcpy(dst->ptr + dst->wi + plane_offset[c] + (cy0 + r) * w,
+               rows[c][r], w);
+      }
+    }
+  }
+  jpeg_finish_decompress(&cinfo);
+  dst->wi += total;
+
+cleanup:;
+  free(samples);
+  jpeg_destroy_decompress(&cinfo);
+  return ret;
+}
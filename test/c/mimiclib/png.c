@@ -0,0 +1,94 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include "png.h"
+
+void mimic_png_read_func(png_structp p, png_bytep ptr, png_size_t len) {
+  wuffs_base__buf1* src = (wuffs_base__buf1*)(png_get_io_ptr(p));
+  if (len > src->wi - src->ri) {
+    png_error(p, "unexpected EOF");
+  }
+  memmove(ptr, src->ptr + src->ri, len);
+  src->ri += len;
+}
+
+const char* mimic_png_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  // These are volatile because they are modified after the setjmp call and
+  // read after a longjmp back to it.
+  const char* volatile ret = NULL;
+  png_bytep* volatile rows = NULL;
+
+  png_structp p =
+      png_create_read_struct(PNG_LIBPNG_VER_STRING, NULL, NULL, NULL);
+  if (!p) {
+    ret = "png_create_read_struct failed";
+    goto cleanup0;
+  }
+  png_infop info = png_create_info_struct(p);
+  if (!info) {
+    ret = "png_create_info_struct failed";
+    goto cleanup1;
+  }
+  if (setjmp(png_jmpbuf(p))) {
+    if (!ret) {
+      ret = "libpng failed";
+    }
+    goto cleanup1;
+  }
+  png_set_read_fn(p, src, mimic_png_read_func);
+  png_read_info(p, info);
+
+  // Like wuffs_png_decode in test/c/std/png.c, convert every PNG image to 8
+  // bits per channel RGBA. Palette and low bit depth gray images are expanded
+  // to 8 bits, tRNS chunks become an alpha channel and 16 bit samples are
+  // truncated to their high byte.
+  png_set_expand(p);
+  png_set_strip_16(p);
+  png_set_gray_to_rgb(p);
+  png_set_filler(p, 0xFF, PNG_FILLER_AFTER);
+  png_set_interlace_handling(p);
+  png_read_update_info(p, info);
+
+  size_t width = (size_t)(png_get_image_width(p, info));
+  size_t height = (size_t)(png_get_image_height(p, info));
+  size_t row_bytes = (size_t)(png_get_rowbytes(p, info));
+  if (row_bytes != 4 * width) {
+    ret = "PNG image was not converted to RGBA";
+    goto cleanup1;
+  }
+  size_t num_dst = dst->len - dst->wi;
+  if ((height > 0) && (num_dst / height < row_bytes)) {
+    ret = "PNG image's pixel data won't fit in the dst buffer";
+    goto cleanup1;
+  }
+
+  rows = malloc(height * sizeof(png_bytep));
+  if (!rows) {
+    ret = "out of memory";
+    goto cleanup1;
+  }
+  size_t y;
+  for (y = 0; y < height; y++) {
+    rows[y] = dst->ptr + dst->wi + y * row_bytes;
+  }
+  png_read_image(p, rows);
+  png_read_end(p, NULL);
+  dst->wi += height * row_bytes;
+
+cleanup1:;
+  free(rows);
+  png_destroy_read_struct(&p, info ? &info : NULL, NULL);
+cleanup0:;
+  return ret;
+}
@@ -0,0 +1,648 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror bmp.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+There are no mimic tests for BMP. Instead, the BMP test images are compared to
+their PNG equivalents, decoded by Wuffs' PNG decoder.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench".
+*/
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/bmp.c"
+#include "../testlib/testlib.c"
+
+// ---------------- BMP Tests
+
+const char* wuffs_bmp_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_bmp__status s =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_bmp__status__string(s);
+  }
+
+  // Decode the image as RGBA, the default pixel format.
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "BMP image's pixel data won't fit in the dst buffer";
+  }
+  s = wuffs_bmp__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
+  if (s) {
+    return wuffs_bmp__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// wuffs_png_decode is like wuffs_bmp_decode, but for PNG images.
+const char* wuffs_png_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "PNG image's pixel data won't fit in the dst buffer";
+  }
+  s = wuffs_png__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+bool do_test_wuffs_bmp_decode(const char* filename,
+                              const char* palette_filename,
+                              const char* indexes_filename,
+                              uint64_t rlimit) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_bmp__decoder__set_pixel_format(&dec,
+                                       WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+
+  {
+    wuffs_base__image_config ic = {{0}};
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_bmp__status status =
+        wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_BMP__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_bmp__status__string(status));
+      return false;
+    }
+
+    // The bricks-etc.bmp images are 160 × 120.
+    if (wuffs_base__image_config__width(&ic) != 160) {
+      FAIL("width: got %" PRIu32 ", want 160",
+           wuffs_base__image_config__width(&ic));
+      return false;
+    }
+    if (wuffs_base__image_config__height(&ic) != 120) {
+      FAIL("height: got %" PRIu32 ", want 120",
+           wuffs_base__image_config__height(&ic));
+      return false;
+    }
+  }
+
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 160 * 120};
+  memset(canvas.ptr, 0, canvas.len);
+
+  int num_iters = 0;
+  uint64_t rlim = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    size_t old_ri = src.ri;
+
+    wuffs_bmp__status status =
+        wuffs_bmp__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status == WUFFS_BMP__STATUS_OK) {
+      break;
+    }
+    if (status != WUFFS_BMP__SUSPENSION_SHORT_READ) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_bmp__status__string(status), WUFFS_BMP__SUSPENSION_SHORT_READ,
+           wuffs_bmp__status__string(WUFFS_BMP__SUSPENSION_SHORT_READ));
+      return false;
+    }
+
+    if (src.ri < old_ri) {
+      FAIL("read index src.ri went backwards");
+      return false;
+    }
+    if (src.ri == old_ri) {
+      FAIL("no progress was made");
+      return false;
+    }
+  }
+  got.wi = canvas.len;
+
+  if (rlimit) {
+    if (num_iters <= 1) {
+      FAIL("num_iters: got %d, want > 1", num_iters);
+      return false;
+    }
+  } else {
+    if (num_iters != 1) {
+      FAIL("num_iters: got %d, want 1", num_iters);
+      return false;
+    }
+  }
+
+  // A NULL palette_filename means a gray image, whose palette is the identity.
+  //
+  // TODO: provide a public API for getting the palette.
+  if (palette_filename) {
+    wuffs_base__buf1 pal_got = {.ptr = dec.private_impl.f_palette,
+                                .len = 3 * 256};
+    wuffs_base__buf1 pal_want = {.ptr = global_palette_buffer,
+                                 .len = 3 * 256};
+    pal_got.wi = 3 * 256;
+    if (!read_file(&pal_want, palette_filename)) {
+      return false;
+    }
+    if (!buf1s_equal("palette ", &pal_got, &pal_want)) {
+      return false;
+    }
+  }
+
+  wuffs_base__buf1 ind_want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&ind_want, indexes_filename)) {
+    return false;
+  }
+  if (!buf1s_equal("indexes ", &got, &ind_want)) {
+    return false;
+  }
+
+  {
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_bmp__status status =
+        wuffs_bmp__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status != WUFFS_BMP__SUSPENSION_END_OF_ANIMATION) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_bmp__status__string(status),
+           WUFFS_BMP__SUSPENSION_END_OF_ANIMATION,
+           wuffs_bmp__status__string(WUFFS_BMP__SUSPENSION_END_OF_ANIMATION));
+      return false;
+    }
+  }
+
+  return true;
+}
+
+// do_test_wuffs_bmp_decode_same checks that a BMP (or ICO) file and a PNG
+// file decode to the same RGBA pixels.
+bool do_test_wuffs_bmp_decode_same(const char* bmp_filename,
+                                   const char* png_filename) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, png_filename)) {
+    return false;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&want, &src);
+  if (msg) {
+    FAIL("%s: %s", png_filename, msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, bmp_filename)) {
+    return false;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  msg = wuffs_bmp_decode(&got, &src);
+  if (msg) {
+    FAIL("%s: %s", bmp_filename, msg);
+    return false;
+  }
+
+  return buf1s_equal("", &got, &want);
+}
+
+// do_test_wuffs_bmp_decode_rgba checks that a small BMP (or ICO) file decodes
+// to the given RGBA pixels.
+bool do_test_wuffs_bmp_decode_rgba(const char* filename,
+                                   uint8_t* want_ptr,
+                                   size_t want_len) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+  const char* msg = wuffs_bmp_decode(&got, &src);
+  if (msg) {
+    FAIL("%s", msg);
+    return false;
+  }
+
+  wuffs_base__buf1 want = {
+      .ptr = want_ptr, .len = want_len, .wi = want_len, .closed = true};
+  return buf1s_equal("", &got, &want);
+}
+
+void test_wuffs_bmp_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.bmp")) {
+    return;
+  }
+
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The image's width and height are still 0, so an empty dst is long enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status),
+         WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_bmp__status__string(WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_bmp__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_bmp__status__string(status),
+         WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_bmp__status__string(WUFFS_BMP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_bmp_decode_bitfields() {
+  CHECK_FOCUS(__func__);
+  // See test/data/artificial/bmp-bitfields.bmp.commentary.txt.
+  uint8_t want[4 * 2 * 2] = {
+      0xFF, 0x00, 0x00, 0xFF, 0x80, 0x40, 0x00, 0x55,
+      0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xAA,
+  };
+  do_test_wuffs_bmp_decode_rgba("../../data/artificial/bmp-bitfields.bmp",
+                                want, sizeof(want));
+}
+
+void test_wuffs_bmp_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/bricks-color.bmp",
+                                "../../data/bricks-color.png");
+}
+
+void test_wuffs_bmp_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode("../../data/bricks-dither.bmp",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 0);
+}
+
+void test_wuffs_bmp_decode_bricks_dither_many_big_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode("../../data/bricks-dither.bmp",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 4096);
+}
+
+void test_wuffs_bmp_decode_bricks_dither_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode("../../data/bricks-dither.bmp",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13);
+}
+
+void test_wuffs_bmp_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode("../../data/bricks-gray.bmp", NULL,
+                           "../../data/bricks-gray.indexes", 0);
+}
+
+void test_wuffs_bmp_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode("../../data/bricks-nodither.bmp",
+                           "../../data/bricks-nodither.palette",
+                           "../../data/bricks-nodither.indexes", 0);
+}
+
+void test_wuffs_bmp_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/harvesters.bmp",
+                                "../../data/harvesters.png");
+}
+
+void test_wuffs_bmp_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/hat.bmp", "../../data/hat.png");
+}
+
+void test_wuffs_bmp_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/hibiscus.bmp",
+                                "../../data/hibiscus.png");
+}
+
+void test_wuffs_bmp_decode_ico_bmp() {
+  CHECK_FOCUS(__func__);
+  // See test/data/artificial/ico-bmp.ico.commentary.txt.
+  uint8_t want[4 * 3 * 2] = {
+      0x00, 0x00, 0xFF, 0x00, 0x00, 0xFF, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0xFF,
+      0xFF, 0x00, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0x00, 0xFF, 0x00,
+  };
+  do_test_wuffs_bmp_decode_rgba("../../data/artificial/ico-bmp.ico", want,
+                                sizeof(want));
+}
+
+void test_wuffs_bmp_decode_ico_png() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/artificial/ico-png.ico",
+                                "../../data/pjw-thumbnail.png");
+}
+
+void test_wuffs_bmp_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return;
+  }
+
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_BMP__ERROR_BAD_BMP_SIGNATURE) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status), WUFFS_BMP__ERROR_BAD_BMP_SIGNATURE,
+         wuffs_bmp__status__string(WUFFS_BMP__ERROR_BAD_BMP_SIGNATURE));
+    return;
+  }
+}
+
+void test_wuffs_bmp_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_same("../../data/pjw-thumbnail.bmp",
+                                "../../data/pjw-thumbnail.png");
+}
+
+void test_wuffs_bmp_decode_rgb555() {
+  CHECK_FOCUS(__func__);
+  // See test/data/artificial/bmp-rgb555.bmp.commentary.txt.
+  uint8_t want[4 * 3 * 2] = {
+      0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0xFF, 0x84, 0x84, 0x84, 0xFF,
+      0xFF, 0x00, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0x00, 0xFF, 0xFF,
+  };
+  do_test_wuffs_bmp_decode_rgba("../../data/artificial/bmp-rgb555.bmp", want,
+                                sizeof(want));
+}
+
+void test_wuffs_bmp_decode_rle4() {
+  CHECK_FOCUS(__func__);
+  // See test/data/artificial/bmp-rle4.bmp.commentary.txt.
+  uint8_t want[4 * 6 * 3] = {
+      0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+      0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x00, 0xFF,
+      0x00, 0x00, 0xFF, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0xFF,
+      0x10, 0x20, 0x30, 0xFF, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00,
+      0xFF, 0x00, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0xFF,
+      0x00, 0xFF, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x00,
+  };
+  do_test_wuffs_bmp_decode_rgba("../../data/artificial/bmp-rle4.bmp", want,
+                                sizeof(want));
+}
+
+bool do_test_wuffs_bmp_decode_pixel_format(uint32_t pixel_format) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.bmp")) {
+    return false;
+  }
+
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_bmp__decoder__set_pixel_format(&dec, pixel_format);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_BMP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status));
+    return false;
+  }
+  if (!check_pixel_format_config(&ic, pixel_format, 160 * 120)) {
+    return false;
+  }
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  status = wuffs_bmp__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_BMP__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status));
+    return false;
+  }
+  got.wi = pixbuf_size;
+
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_indexed_rgba(&want, "../../data/bricks-dither.palette",
+                         "../../data/bricks-dither.indexes")) {
+    return false;
+  }
+  return pixel_format_equal(&got, &want, pixel_format);
+}
+
+void test_wuffs_bmp_decode_pixel_format_bgra() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__BGRA);
+}
+
+void test_wuffs_bmp_decode_pixel_format_rgb565() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGB565);
+}
+
+void test_wuffs_bmp_decode_pixel_format_rgba() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_bmp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGBA);
+}
+
+void test_wuffs_bmp_set_pixel_format_unsupported() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.bmp")) {
+    return;
+  }
+
+  // A 24 bits per pixel image has no palette, so it cannot be decoded as
+  // indexes.
+  wuffs_bmp__decoder dec;
+  wuffs_bmp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_bmp__decoder__set_pixel_format(&dec,
+                                       WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_bmp__status status =
+      wuffs_bmp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_BMP__ERROR_UNSUPPORTED_BMP_PIXEL_FORMAT) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_bmp__status__string(status),
+         WUFFS_BMP__ERROR_UNSUPPORTED_BMP_PIXEL_FORMAT,
+         wuffs_bmp__status__string(
+             WUFFS_BMP__ERROR_UNSUPPORTED_BMP_PIXEL_FORMAT));
+    return;
+  }
+}
+
+// ---------------- BMP Benches
+
+bool do_bench_bmp_decode(const char* (*decode_func)(wuffs_base__buf1*,
+                                                    wuffs_base__buf1*),
+                         const char* filename,
+                         uint64_t reps) {
+  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  for (i = 0; i < reps; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(reps, n_bytes);
+  return true;
+}
+
+void bench_wuffs_bmp_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/pjw-thumbnail.bmp",
+                      100000);
+}
+
+void bench_wuffs_bmp_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/bricks-dither.bmp", 5000);
+}
+
+void bench_wuffs_bmp_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/hat.bmp", 500);
+}
+
+void bench_wuffs_bmp_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_bmp_decode(wuffs_bmp_decode, "../../data/harvesters.bmp", 50);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_bmp_call_sequence,                          //
+    test_wuffs_bmp_decode_bitfields,                       //
+    test_wuffs_bmp_decode_bricks_color,                    //
+    test_wuffs_bmp_decode_bricks_dither,                   //
+    test_wuffs_bmp_decode_bricks_dither_many_big_reads,    //
+    test_wuffs_bmp_decode_bricks_dither_many_small_reads,  //
+    test_wuffs_bmp_decode_bricks_gray,                     //
+    test_wuffs_bmp_decode_bricks_nodither,                 //
+    test_wuffs_bmp_decode_harvesters,                      //
+    test_wuffs_bmp_decode_hat,                             //
+    test_wuffs_bmp_decode_hibiscus,                        //
+    test_wuffs_bmp_decode_ico_bmp,                         //
+    test_wuffs_bmp_decode_ico_png,                         //
+    test_wuffs_bmp_decode_input_is_a_png,                  //
+    test_wuffs_bmp_decode_pjw_thumbnail,                   //
+    test_wuffs_bmp_decode_pixel_format_bgra,               //
+    test_wuffs_bmp_decode_pixel_format_rgb565,             //
+    test_wuffs_bmp_decode_pixel_format_rgba,               //
+    test_wuffs_bmp_decode_rgb555,                          //
+    test_wuffs_bmp_decode_rle4,                            //
+    test_wuffs_bmp_set_pixel_format_unsupported,           //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_bmp_decode_1k,     //
+    bench_wuffs_bmp_decode_10k,    //
+    bench_wuffs_bmp_decode_100k,   //
+    bench_wuffs_bmp_decode_1000k,  //
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "std/bmp.c";
+  return test_main(argc, argv, tests, benches);
+}
@@ -161,6 +161,30 @@ void test_wuffs_crc32_ieee_pi() {
   }
 }
 
+void test_wuffs_crc32_ieee_reset() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_crc32__ieee checksum;
+  wuffs_crc32__ieee__initialize(&checksum, WUFFS_VERSION, 0);
+  wuffs_crc32__ieee__update(&checksum, ((wuffs_base__slice_u8){
+                                           .ptr = (uint8_t*)("midsummer"),
+                                           .len = 9,
+                                       }));
+  wuffs_crc32__ieee__reset(&checksum);
+
+  // 0x16E010BE is the checksum of "3.14", as per test_wuffs_crc32_ieee_pi.
+  uint32_t got =
+      wuffs_crc32__ieee__update(&checksum, ((wuffs_base__slice_u8){
+                                               .ptr = (uint8_t*)("3.14"),
+                                               .len = 4,
+                                           }));
+  uint32_t want = 0x16E010BE;
+  if (got != want) {
+    FAIL("got 0x%08" PRIX32 ", want 0x%08" PRIX32 "\n", got, want);
+    return;
+  }
+}
+
 // ---------------- CRC32 Benches
 
 uint32_t global_wuffs_crc32_unused_u32;
@@ -219,6 +243,7 @@ proc tests[] = {
 
     test_wuffs_crc32_ieee_golden,  //
     test_wuffs_crc32_ieee_pi,      //
+    test_wuffs_crc32_ieee_reset,   //
 
     NULL,
 };
@@ -70,6 +70,15 @@ golden_test deflate_deflate_backref_crosses_blocks_gt = {
         "deflate-backref-crosses-blocks.deflate",
 };
 
+golden_test deflate_deflate_distance_code_single_gt = {
+    .want_filename =
+        "../../data/artificial/"
+        "deflate-distance-code-single.deflate.decompressed",
+    .src_filename =
+        "../../data/artificial/"
+        "deflate-distance-code-single.deflate",
+};
+
 golden_test deflate_deflate_distance_32768_gt = {
     .want_filename =
         "../../data/artificial/"
@@ -152,6 +161,12 @@ void test_wuffs_deflate_decode_deflate_backref_crosses_blocks() {
                     &deflate_deflate_backref_crosses_blocks_gt, 0, 0);
 }
 
+void test_wuffs_deflate_decode_deflate_distance_code_single() {
+  CHECK_FOCUS(__func__);
+  do_test_buf1_buf1(wuffs_deflate_decode,
+                    &deflate_deflate_distance_code_single_gt, 0, 0);
+}
+
 void test_wuffs_deflate_decode_deflate_distance_32768() {
   CHECK_FOCUS(__func__);
   do_test_buf1_buf1(wuffs_deflate_decode, &deflate_deflate_distance_32768_gt, 0,
@@ -258,6 +273,58 @@ void test_wuffs_deflate_decode_split_src() {
   }
 }
 
+void test_wuffs_deflate_decode_reuse() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+
+  // Decode alternating DEFLATE streams with the one decoder, initialized only
+  // once. Each stream's final block ends with padding bits, and its history
+  // must not leak into the next stream.
+  golden_test* gts[2] = {&deflate_romeo_gt, &deflate_midsummer_gt};
+
+  wuffs_deflate__decoder dec;
+  wuffs_deflate__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  int i;
+  for (i = 0; i < 4; i++) {
+    golden_test* gt = gts[i & 1];
+    src.ri = 0;
+    src.wi = 0;
+    src.closed = false;
+    if (!read_file(&src, gt->src_filename)) {
+      return;
+    }
+    src.ri = gt->src_offset0;
+    src.wi = gt->src_offset1;
+    want.wi = 0;
+    want.closed = false;
+    if (!read_file(&want, gt->want_filename)) {
+      return;
+    }
+    got.wi = 0;
+
+    wuffs_base__writer1 dst_writer = {.buf = &got};
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_deflate__status s =
+        wuffs_deflate__decoder__decode(&dec, dst_writer, src_reader);
+    if (s != WUFFS_DEFLATE__STATUS_OK) {
+      FAIL("i=%d: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", i, s,
+           wuffs_deflate__status__string(s), WUFFS_DEFLATE__STATUS_OK,
+           wuffs_deflate__status__string(WUFFS_DEFLATE__STATUS_OK));
+      return;
+    }
+
+    char prefix[64];
+    snprintf(prefix, 64, "i=%d: ", i);
+    if (!buf1s_equal(prefix, &got, &want)) {
+      return;
+    }
+  }
+}
+
 bool do_test_wuffs_deflate_history(int i,
                                    golden_test* gt,
                                    wuffs_base__buf1* src,
@@ -553,6 +620,12 @@ void test_mimic_deflate_decode_deflate_backref_crosses_blocks() {
                     &deflate_deflate_backref_crosses_blocks_gt, 0, 0);
 }
 
+void test_mimic_deflate_decode_deflate_distance_code_single() {
+  CHECK_FOCUS(__func__);
+  do_test_buf1_buf1(mimic_deflate_decode,
+                    &deflate_deflate_distance_code_single_gt, 0, 0);
+}
+
 void test_mimic_deflate_decode_deflate_distance_32768() {
   CHECK_FOCUS(__func__);
   do_test_buf1_buf1(mimic_deflate_decode, &deflate_deflate_distance_32768_gt, 0,
@@ -630,12 +703,14 @@ proc tests[] = {
 
     test_wuffs_deflate_decode_256_bytes,                       //
     test_wuffs_deflate_decode_deflate_backref_crosses_blocks,  //
+    test_wuffs_deflate_decode_deflate_distance_code_single,    //
     test_wuffs_deflate_decode_deflate_distance_32768,          //
     test_wuffs_deflate_decode_midsummer,                       //
     test_wuffs_deflate_decode_pi,                              //
     test_wuffs_deflate_decode_pi_many_big_reads,               //
     test_wuffs_deflate_decode_pi_many_medium_reads,            //
     test_wuffs_deflate_decode_pi_many_small_writes_reads,      //
+    test_wuffs_deflate_decode_reuse,                           //
     test_wuffs_deflate_decode_romeo,                           //
     test_wuffs_deflate_decode_romeo_fixed,                     //
     test_wuffs_deflate_decode_split_src,                       //
@@ -647,6 +722,7 @@ proc tests[] = {
 
     test_mimic_deflate_decode_256_bytes,                       //
     test_mimic_deflate_decode_deflate_backref_crosses_blocks,  //
+    test_mimic_deflate_decode_deflate_distance_code_single,    //
     test_mimic_deflate_decode_deflate_distance_32768,          //
     test_mimic_deflate_decode_midsummer,                       //
     test_mimic_deflate_decode_pi,                              //
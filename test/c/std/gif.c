@@ -175,7 +175,6 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
                               uint64_t src_size,
                               const char* want_filename,
                               uint64_t want_size,
-                              uint64_t wlimit,
                               uint64_t rlimit) {
   wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
   wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
@@ -208,15 +207,9 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
   wuffs_gif__lzw_decoder__initialize(&dec, WUFFS_VERSION, 0);
   wuffs_gif__lzw_decoder__set_literal_width(&dec, literal_width);
   int num_iters = 0;
-  uint64_t wlim = 0;
   uint64_t rlim = 0;
   while (true) {
     num_iters++;
-    wuffs_base__writer1 got_writer = {.buf = &got};
-    if (wlimit) {
-      wlim = wlimit;
-      got_writer.private_impl.limit.ptr_to_len = &wlim;
-    }
     wuffs_base__reader1 src_reader = {.buf = &src};
     if (rlimit) {
       rlim = rlimit;
@@ -226,7 +219,21 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
     size_t old_ri = src.ri;
 
     wuffs_gif__status status =
-        wuffs_gif__lzw_decoder__decode(&dec, got_writer, src_reader);
+        wuffs_gif__lzw_decoder__decode(&dec, src_reader);
+
+    // The decoded bytes, if any, are in dec's output buffer, and must be
+    // consumed before calling decode again.
+    if ((status == WUFFS_GIF__STATUS_OK) ||
+        (status == WUFFS_GIF__SUSPENSION_SHORT_WRITE)) {
+      size_t n = dec.private_impl.f_output_wi;
+      if (n > got.len - got.wi) {
+        FAIL("got buffer is too small");
+        return false;
+      }
+      memmove(got.ptr + got.wi, dec.private_impl.f_output, n);
+      got.wi += n;
+    }
+
     if (status == WUFFS_GIF__STATUS_OK) {
       if (src.ri != src.wi) {
         FAIL("decode returned \"ok\" but src was not exhausted");
@@ -246,10 +253,6 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
       return false;
     }
 
-    if (got.wi < old_wi) {
-      FAIL("write index got.wi went backwards");
-      return false;
-    }
     if (src.ri < old_ri) {
       FAIL("read index src.ri went backwards");
       return false;
@@ -260,7 +263,10 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
     }
   }
 
-  if (wlimit || rlimit) {
+  // The output buffer holds 8192 bytes, and decode suspends when it is half
+  // full, so there should be more than one iteration for long outputs, even
+  // without a read limit.
+  if (rlimit || (want_size > 8192)) {
     if (num_iters <= 1) {
       FAIL("num_iters: got %d, want > 1", num_iters);
       return false;
@@ -278,39 +284,37 @@ bool do_test_wuffs_lzw_decode(const char* src_filename,
 void test_wuffs_lzw_decode_many_big_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-gray.indexes.giflzw", 14731,
-                           "../../data/bricks-gray.indexes", 19200, 0, 4096);
+                           "../../data/bricks-gray.indexes", 19200, 4096);
 }
 
-void test_wuffs_lzw_decode_many_small_writes_reads() {
+void test_wuffs_lzw_decode_many_small_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-gray.indexes.giflzw", 14731,
-                           "../../data/bricks-gray.indexes", 19200, 41, 43);
+                           "../../data/bricks-gray.indexes", 19200, 43);
 }
 
 void test_wuffs_lzw_decode_bricks_dither() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-dither.indexes.giflzw", 14923,
-                           "../../data/bricks-dither.indexes", 19200, 0, 0);
+                           "../../data/bricks-dither.indexes", 19200, 0);
 }
 
 void test_wuffs_lzw_decode_bricks_nodither() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/bricks-nodither.indexes.giflzw", 13382,
-                           "../../data/bricks-nodither.indexes", 19200, 0, 0);
+                           "../../data/bricks-nodither.indexes", 19200, 0);
 }
 
 void test_wuffs_lzw_decode_pi() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_lzw_decode("../../data/pi.txt.giflzw", 50550,
-                           "../../data/pi.txt", 100003, 0, 0);
+                           "../../data/pi.txt", 100003, 0);
 }
 
 // ---------------- LZW Benches
 
 bool do_bench_wuffs_lzw_decode(const char* filename, uint64_t reps) {
-  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
   wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
-  wuffs_base__writer1 dst_writer = {.buf = &dst};
   wuffs_base__reader1 src_reader = {.buf = &src};
 
   if (!read_file(&src, filename)) {
@@ -330,17 +334,21 @@ bool do_bench_wuffs_lzw_decode(const char* filename, uint64_t reps) {
   uint64_t n_bytes = 0;
   uint64_t i;
   for (i = 0; i < reps; i++) {
-    dst.wi = 0;
     src.ri = 1;  // Skip the literal width.
     wuffs_gif__lzw_decoder dec;
     wuffs_gif__lzw_decoder__initialize(&dec, WUFFS_VERSION, 0);
-    wuffs_gif__status s =
-        wuffs_gif__lzw_decoder__decode(&dec, dst_writer, src_reader);
-    if (s) {
-      FAIL("decode: %" PRIi32 " (%s)", s, wuffs_gif__status__string(s));
-      return false;
+    while (true) {
+      wuffs_gif__status s = wuffs_gif__lzw_decoder__decode(&dec, src_reader);
+      if ((s != WUFFS_GIF__STATUS_OK) &&
+          (s != WUFFS_GIF__SUSPENSION_SHORT_WRITE)) {
+        FAIL("decode: %" PRIi32 " (%s)", s, wuffs_gif__status__string(s));
+        return false;
+      }
+      n_bytes += dec.private_impl.f_output_wi;
+      if (s == WUFFS_GIF__STATUS_OK) {
+        break;
+      }
     }
-    n_bytes += dst.wi;
   }
   bench_finish(reps, n_bytes);
   return true;
@@ -362,24 +370,33 @@ const char* wuffs_gif_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
   wuffs_gif__decoder dec;
   wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
   wuffs_base__image_config ic = {{0}};
-  wuffs_base__writer1 dst_writer = {.buf = dst};
   wuffs_base__reader1 src_reader = {.buf = src};
   wuffs_gif__status s =
       wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
   if (s) {
     return wuffs_gif__status__string(s);
   }
-  s = wuffs_gif__decoder__decode_frame(&dec, dst_writer, src_reader);
+
+  // Decode the first frame onto an all-zero canvas the size of the image.
+  size_t n = (size_t)(wuffs_base__image_config__width(&ic)) *
+             (size_t)(wuffs_base__image_config__height(&ic));
+  if (n > dst->len - dst->wi) {
+    return "GIF image's pixel data won't fit in the dst buffer";
+  }
+  memset(dst->ptr + dst->wi, 0, n);
+  s = wuffs_gif__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
   if (s) {
     return wuffs_gif__status__string(s);
   }
+  dst->wi += n;
   return NULL;
 }
 
 bool do_test_wuffs_gif_decode(const char* filename,
                               const char* palette_filename,
                               const char* indexes_filename,
-                              uint64_t wlimit,
                               uint64_t rlimit) {
   wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
   wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
@@ -402,7 +419,8 @@ bool do_test_wuffs_gif_decode(const char* filename,
       return false;
     }
 
-    // bricks-dither.gif is a 160 × 120 static (not animated) GIF.
+    // bricks-dither.gif, and its interlaced variant, is a 160 × 120 static
+    // (not animated) GIF.
     if (wuffs_base__image_config__width(&ic) != 160) {
       FAIL("width: got %" PRIu32 ", want 160",
            wuffs_base__image_config__width(&ic));
@@ -420,56 +438,44 @@ bool do_test_wuffs_gif_decode(const char* filename,
     }
   }
 
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 160 * 120};
+  memset(canvas.ptr, 0, canvas.len);
+
   int num_iters = 0;
-  uint64_t wlim = 0;
   uint64_t rlim = 0;
   while (true) {
     num_iters++;
-    wuffs_base__writer1 got_writer = {.buf = &got};
-    if (wlimit) {
-      wlim = wlimit;
-      got_writer.private_impl.limit.ptr_to_len = &wlim;
-    }
     wuffs_base__reader1 src_reader = {.buf = &src};
     if (rlimit) {
       rlim = rlimit;
       src_reader.private_impl.limit.ptr_to_len = &rlim;
     }
-    size_t old_wi = got.wi;
     size_t old_ri = src.ri;
 
     wuffs_gif__status status =
-        wuffs_gif__decoder__decode_frame(&dec, got_writer, src_reader);
+        wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
     if (status == WUFFS_GIF__STATUS_OK) {
       break;
     }
-    if ((status != WUFFS_GIF__SUSPENSION_SHORT_READ) &&
-        (status != WUFFS_GIF__SUSPENSION_SHORT_WRITE)) {
-      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32
-           " (%s) or %" PRIi32 " (%s)",
-           status, wuffs_gif__status__string(status),
-           WUFFS_GIF__SUSPENSION_SHORT_READ,
-           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_SHORT_READ),
-           WUFFS_GIF__SUSPENSION_SHORT_WRITE,
-           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_SHORT_WRITE));
+    if (status != WUFFS_GIF__SUSPENSION_SHORT_READ) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_gif__status__string(status), WUFFS_GIF__SUSPENSION_SHORT_READ,
+           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_SHORT_READ));
       return false;
     }
 
-    if (got.wi < old_wi) {
-      FAIL("write index got.wi went backwards");
-      return false;
-    }
     if (src.ri < old_ri) {
       FAIL("read index src.ri went backwards");
       return false;
     }
-    if ((got.wi == old_wi) && (src.ri == old_ri)) {
+    if (src.ri == old_ri) {
       FAIL("no progress was made");
       return false;
     }
   }
+  got.wi = canvas.len;
 
-  if (wlimit || rlimit) {
+  if (rlimit) {
     if (num_iters <= 1) {
       FAIL("num_iters: got %d, want > 1", num_iters);
       return false;
@@ -505,18 +511,19 @@ bool do_test_wuffs_gif_decode(const char* filename,
       FAIL("decode_frame returned \"ok\" but src was exhausted");
       return false;
     }
-    wuffs_base__writer1 got_writer = {.buf = &got};
     wuffs_base__reader1 src_reader = {.buf = &src};
     wuffs_gif__status status =
-        wuffs_gif__decoder__decode_frame(&dec, got_writer, src_reader);
-    if (status != WUFFS_GIF__SUSPENSION_END_OF_DATA) {
+        wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status != WUFFS_GIF__SUSPENSION_END_OF_ANIMATION) {
       FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
-           wuffs_gif__status__string(status), WUFFS_GIF__SUSPENSION_END_OF_DATA,
-           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_END_OF_DATA));
+           wuffs_gif__status__string(status),
+           WUFFS_GIF__SUSPENSION_END_OF_ANIMATION,
+           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_END_OF_ANIMATION));
       return false;
     }
     if (src.ri != src.wi) {
-      FAIL("decode_frame returned \"end of data\" but src was not exhausted");
+      FAIL("decode_frame returned \"end of animation\" but src was not "
+           "exhausted");
       return false;
     }
   }
@@ -537,11 +544,12 @@ void test_wuffs_gif_call_sequence() {
   wuffs_gif__decoder dec;
   wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
 
-  wuffs_base__writer1 got_writer = {.buf = &got};
+  // The image's width and height are still 0, so an empty dst is long enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
   wuffs_base__reader1 src_reader = {.buf = &src};
 
   wuffs_gif__status status =
-      wuffs_gif__decoder__decode_frame(&dec, got_writer, src_reader);
+      wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
   if (status != WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE) {
     FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
          wuffs_gif__status__string(status),
@@ -549,6 +557,18 @@ void test_wuffs_gif_call_sequence() {
          wuffs_gif__status__string(WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE));
     return;
   }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_gif__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_gif__status__string(status),
+         WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_gif__status__string(WUFFS_GIF__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
 }
 
 void test_wuffs_gif_decode_animated() {
@@ -565,7 +585,6 @@ void test_wuffs_gif_decode_animated() {
   wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
 
   wuffs_base__image_config ic = {{0}};
-  wuffs_base__writer1 got_writer = {.buf = &got};
   wuffs_base__reader1 src_reader = {.buf = &src};
 
   wuffs_gif__status status =
@@ -576,6 +595,23 @@ void test_wuffs_gif_decode_animated() {
     return;
   }
 
+  // animated-red-blue.gif is 64 × 48. Each frame is decoded onto the same
+  // canvas, the size of the image, and should only change the pixels in that
+  // frame's rect. The prev buffer holds the canvas before each frame.
+  const uint32_t width = 64;
+  const uint32_t height = 48;
+  if ((wuffs_base__image_config__width(&ic) != width) ||
+      (wuffs_base__image_config__height(&ic) != height)) {
+    FAIL("dimensions: got %" PRIu32 " × %" PRIu32 ", want %" PRIu32
+         " × %" PRIu32,
+         wuffs_base__image_config__width(&ic),
+         wuffs_base__image_config__height(&ic), width, height);
+    return;
+  }
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = width * height};
+  uint8_t* prev = global_want_buffer;
+  memset(canvas.ptr, 0xEE, canvas.len);
+
   // animated-red-blue.gif's num_loops should be 3. The value explicitly in the
   // wire format is 0x0002, but that value means "repeat 2 times after the
   // first play", so the total number of loops is 3.
@@ -586,30 +622,165 @@ void test_wuffs_gif_decode_animated() {
     return;
   }
 
-  // animated-red-blue.gif should have 4 frames.
+  // animated-red-blue.gif should have 4 frames. The first has a Local Color
+  // Table, whose entry 1 is (0x02, 0x02, 0x02). The others use the Global
+  // Color Table, whose entry 1 is (0xFF, 0xFF, 0xFF).
+  struct {
+    uint32_t x, y, width, height, delay_ms;
+    int32_t transparent_index;  // -1 means no transparent color.
+    uint8_t palette_1;
+  } want[4] = {
+      {0, 0, 64, 48, 100, -1, 0x02},
+      {15, 31, 37, 9, 200, 2, 0xFF},
+      {15, 0, 49, 40, 300, 2, 0xFF},
+      {15, 0, 49, 40, 400, 129, 0xFF},
+  };
+  int i;
+  for (i = 0; i < 4; i++) {
+    wuffs_base__frame_config fc = {{0}};
+    status = wuffs_gif__decoder__decode_frame_config(&dec, &fc, src_reader);
+    if (status != WUFFS_GIF__STATUS_OK) {
+      FAIL("decode_frame_config #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return;
+    }
+    uint32_t got_rect[4] = {
+        wuffs_base__frame_config__x(&fc),
+        wuffs_base__frame_config__y(&fc),
+        wuffs_base__frame_config__width(&fc),
+        wuffs_base__frame_config__height(&fc),
+    };
+    uint32_t want_rect[4] = {want[i].x, want[i].y, want[i].width,
+                             want[i].height};
+    int j;
+    for (j = 0; j < 4; j++) {
+      if (got_rect[j] != want_rect[j]) {
+        FAIL("frame #%d rect[%d]: got %" PRIu32 ", want %" PRIu32, i, j,
+             got_rect[j], want_rect[j]);
+        return;
+      }
+    }
+    if (wuffs_base__frame_config__delay_ms(&fc) != want[i].delay_ms) {
+      FAIL("frame #%d delay_ms: got %" PRIu32 ", want %" PRIu32, i,
+           wuffs_base__frame_config__delay_ms(&fc), want[i].delay_ms);
+      return;
+    }
+    if (wuffs_base__frame_config__disposal(&fc) !=
+        WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE) {
+      FAIL("frame #%d disposal: got %d, want %d", i,
+           wuffs_base__frame_config__disposal(&fc),
+           WUFFS_BASE__FRAME_CONFIG__DISPOSAL__NONE);
+      return;
+    }
+    int32_t got_ti = wuffs_base__frame_config__has_transparent_index(&fc)
+                         ? wuffs_base__frame_config__transparent_index(&fc)
+                         : -1;
+    if (got_ti != want[i].transparent_index) {
+      FAIL("frame #%d transparent_index: got %" PRIi32 ", want %" PRIi32, i,
+           got_ti, want[i].transparent_index);
+      return;
+    }
+    wuffs_base__slice_u8 palette = wuffs_base__frame_config__palette(&fc);
+    if ((palette.len != 3 * 256) || (palette.ptr[3] != want[i].palette_1)) {
+      FAIL("frame #%d palette: bad entry 1", i);
+      return;
+    }
+
+    memmove(prev, canvas.ptr, canvas.len);
+    status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status != WUFFS_GIF__STATUS_OK) {
+      FAIL("decode_frame #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return;
+    }
+    uint32_t x;
+    uint32_t y;
+    for (y = 0; y < height; y++) {
+      for (x = 0; x < width; x++) {
+        bool in_rect = (want[i].x <= x) && (x < want[i].x + want[i].width) &&
+                       (want[i].y <= y) && (y < want[i].y + want[i].height);
+        if (!in_rect && (canvas.ptr[y * width + x] != prev[y * width + x])) {
+          FAIL("decode_frame #%d: pixel (%" PRIu32 ", %" PRIu32
+               ") outside the frame rect was changed",
+               i, x, y);
+          return;
+        }
+      }
+    }
+  }
+
+  // The first frame covers the whole image, so no canvas pixel should still
+  // hold its initial value.
+  uint32_t j;
+  for (j = 0; j < canvas.len; j++) {
+    if (canvas.ptr[j] == 0xEE) {
+      FAIL("pixel #%" PRIu32 " was never written", j);
+      return;
+    }
+  }
+
+  // There should be no more frames, no matter how many times we ask.
+  for (i = 0; i < 2; i++) {
+    wuffs_base__frame_config fc = {{0}};
+    status = wuffs_gif__decoder__decode_frame_config(&dec, &fc, src_reader);
+    if (status != WUFFS_GIF__SUSPENSION_END_OF_ANIMATION) {
+      FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+           status, wuffs_gif__status__string(status),
+           WUFFS_GIF__SUSPENSION_END_OF_ANIMATION,
+           wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_END_OF_ANIMATION));
+      return;
+    }
+  }
+}
+
+void test_wuffs_gif_decode_animated_without_frame_configs() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/animated-red-blue.gif")) {
+    return;
+  }
+
+  wuffs_gif__decoder dec;
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_gif__status status =
+      wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+
+  wuffs_base__slice_u8 canvas = {
+      .ptr = got.ptr,
+      .len = (size_t)(wuffs_base__image_config__width(&ic)) *
+             (size_t)(wuffs_base__image_config__height(&ic)),
+  };
+
+  // Calling decode_frame without decode_frame_config skips each frame's
+  // config.
   int i;
   for (i = 0; i < 4; i++) {
-    got.wi = 0;
-    status = wuffs_gif__decoder__decode_frame(&dec, got_writer, src_reader);
+    status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
     if (status != WUFFS_GIF__STATUS_OK) {
       FAIL("decode_frame #%d: got %" PRIi32 " (%s)", i, status,
            wuffs_gif__status__string(status));
       return;
     }
-    // TODO: check that the frame top/left/width/height is:
-    //  -  0, 0,64,48 for frame #0
-    //  - 15,31,37, 9 for frame #1
-    //  - 15, 0,49,40 for frame #2
-    //  - 15, 0,49,40 for frame #3
   }
 
-  // There should be no more frames.
-  got.wi = 0;
-  status = wuffs_gif__decoder__decode_frame(&dec, got_writer, src_reader);
-  if (status != WUFFS_GIF__SUSPENSION_END_OF_DATA) {
+  status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_GIF__SUSPENSION_END_OF_ANIMATION) {
     FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
-         wuffs_gif__status__string(status), WUFFS_GIF__SUSPENSION_END_OF_DATA,
-         wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_END_OF_DATA));
+         wuffs_gif__status__string(status),
+         WUFFS_GIF__SUSPENSION_END_OF_ANIMATION,
+         wuffs_gif__status__string(WUFFS_GIF__SUSPENSION_END_OF_ANIMATION));
     return;
   }
 }
@@ -618,32 +789,143 @@ void test_wuffs_gif_decode_input_is_a_gif() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 0, 0);
+                           "../../data/bricks-dither.indexes", 0);
 }
 
 void test_wuffs_gif_decode_input_is_a_gif_many_big_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 0, 4096);
+                           "../../data/bricks-dither.indexes", 4096);
 }
 
 void test_wuffs_gif_decode_input_is_a_gif_many_medium_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 0, 787);
+                           "../../data/bricks-dither.indexes", 787);
   // The magic 787 tickles being in the middle of a decode_extension skip32
   // call.
   //
   // TODO: has 787 changed since we decode the image_config separately?
 }
 
-void test_wuffs_gif_decode_input_is_a_gif_many_small_writes_reads() {
+void test_wuffs_gif_decode_input_is_a_gif_many_small_reads() {
   CHECK_FOCUS(__func__);
   do_test_wuffs_gif_decode("../../data/bricks-dither.gif",
                            "../../data/bricks-dither.palette",
-                           "../../data/bricks-dither.indexes", 11, 13);
+                           "../../data/bricks-dither.indexes", 13);
+}
+
+void test_wuffs_gif_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.interlaced.gif",
+                           "../../data/bricks-dither.interlaced.palette",
+                           "../../data/bricks-dither.interlaced.indexes", 0);
+}
+
+void test_wuffs_gif_decode_interlaced_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode("../../data/bricks-dither.interlaced.gif",
+                           "../../data/bricks-dither.interlaced.palette",
+                           "../../data/bricks-dither.interlaced.indexes", 13);
+}
+
+void test_wuffs_gif_decode_frame_out_of_bounds() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/artificial/gif-frame-out-of-bounds.gif")) {
+    return;
+  }
+
+  wuffs_gif__decoder dec;
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_gif__status status =
+      wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  if ((wuffs_base__image_config__width(&ic) != 4) ||
+      (wuffs_base__image_config__height(&ic) != 3)) {
+    FAIL("dimensions: got %" PRIu32 " × %" PRIu32 ", want 4 × 3",
+         wuffs_base__image_config__width(&ic),
+         wuffs_base__image_config__height(&ic));
+    return;
+  }
+
+  // The frame's rect, (2, 1) to (6, 4), should be clipped to the image's, (0,
+  // 0) to (4, 3).
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_gif__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  uint32_t got_rect[4] = {
+      wuffs_base__frame_config__x(&fc),
+      wuffs_base__frame_config__y(&fc),
+      wuffs_base__frame_config__width(&fc),
+      wuffs_base__frame_config__height(&fc),
+  };
+  uint32_t want_rect[4] = {2, 1, 2, 2};
+  int i;
+  for (i = 0; i < 4; i++) {
+    if (got_rect[i] != want_rect[i]) {
+      FAIL("rect[%d]: got %" PRIu32 ", want %" PRIu32, i, got_rect[i],
+           want_rect[i]);
+      return;
+    }
+  }
+
+  // A dst shorter than width * height is rejected.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 4 * 3 - 1};
+  status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_GIF__ERROR_BAD_ARGUMENT) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status), WUFFS_GIF__ERROR_BAD_ARGUMENT,
+         wuffs_gif__status__string(WUFFS_GIF__ERROR_BAD_ARGUMENT));
+    return;
+  }
+
+  // The previous error is sticky, so start again.
+  src.ri = 0;
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  status = wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+
+  canvas.len = 4 * 3;
+  memset(canvas.ptr, 0, canvas.len);
+  status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  got.wi = canvas.len;
+
+  uint8_t want_array[4 * 3] = {
+      0, 0, 0, 0,  //
+      0, 0, 1, 2,  //
+      0, 0, 5, 6,  //
+  };
+  wuffs_base__buf1 want = {
+      .ptr = want_array, .len = 4 * 3, .wi = 4 * 3, .closed = true};
+  if (!buf1s_equal("", &got, &want)) {
+    return;
+  }
 }
 
 void test_wuffs_gif_decode_input_is_a_png() {
@@ -670,6 +952,203 @@ void test_wuffs_gif_decode_input_is_a_png() {
   }
 }
 
+bool do_test_wuffs_gif_decode_pixel_format(uint32_t pixel_format) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.gif")) {
+    return false;
+  }
+
+  wuffs_gif__decoder dec;
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_gif__decoder__set_pixel_format(&dec, pixel_format);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_gif__status status =
+      wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return false;
+  }
+  if (!check_pixel_format_config(&ic, pixel_format, 160 * 120)) {
+    return false;
+  }
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  status = wuffs_gif__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return false;
+  }
+  got.wi = pixbuf_size;
+
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_indexed_rgba(&want, "../../data/bricks-dither.palette",
+                         "../../data/bricks-dither.indexes")) {
+    return false;
+  }
+  return pixel_format_equal(&got, &want, pixel_format);
+}
+
+void test_wuffs_gif_decode_pixel_format_bgra() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__BGRA);
+}
+
+void test_wuffs_gif_decode_pixel_format_rgb565() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGB565);
+}
+
+void test_wuffs_gif_decode_pixel_format_rgba() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gif_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGBA);
+}
+
+void test_wuffs_gif_decode_pixel_format_transparency() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/animated-red-blue.gif")) {
+    return;
+  }
+
+  // Decode the animation twice, in lockstep: as palette indexes and as BGRA.
+  wuffs_gif__decoder dec_indexed;
+  wuffs_gif__decoder__initialize(&dec_indexed, WUFFS_VERSION, 0);
+  wuffs_base__buf1 src_indexed = src;
+  wuffs_base__reader1 src_reader_indexed = {.buf = &src_indexed};
+
+  wuffs_gif__decoder dec_bgra;
+  wuffs_gif__decoder__initialize(&dec_bgra, WUFFS_VERSION, 0);
+  wuffs_gif__decoder__set_pixel_format(&dec_bgra,
+                                       WUFFS_BASE__PIXEL_FORMAT__BGRA);
+  wuffs_base__buf1 src_bgra = src;
+  wuffs_base__reader1 src_reader_bgra = {.buf = &src_bgra};
+
+  wuffs_base__image_config ic = {{0}};
+  wuffs_gif__status status =
+      wuffs_gif__decoder__decode_config(&dec_indexed, &ic, src_reader_indexed);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  status = wuffs_gif__decoder__decode_config(&dec_bgra, &ic, src_reader_bgra);
+  if (status != WUFFS_GIF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status));
+    return;
+  }
+  size_t n = (size_t)(wuffs_base__image_config__width(&ic)) *
+             (size_t)(wuffs_base__image_config__height(&ic));
+
+  // The three canvases are the indexes, the BGRA pixels and the BGRA pixels
+  // before each frame.
+  wuffs_base__slice_u8 canvas_indexed = {.ptr = global_got_buffer, .len = n};
+  wuffs_base__slice_u8 canvas_bgra = {.ptr = global_want_buffer, .len = 4 * n};
+  uint8_t* prev_bgra = global_want_buffer + 4 * n;
+  memset(canvas_indexed.ptr, 0, canvas_indexed.len);
+  memset(canvas_bgra.ptr, 0, canvas_bgra.len);
+
+  int num_transparent = 0;
+  int i;
+  for (i = 0; i < 4; i++) {
+    wuffs_base__frame_config fc = {{0}};
+    status = wuffs_gif__decoder__decode_frame_config(&dec_indexed, &fc,
+                                                     src_reader_indexed);
+    if (status != WUFFS_GIF__STATUS_OK) {
+      FAIL("decode_frame_config #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return;
+    }
+    status = wuffs_gif__decoder__decode_frame(&dec_indexed, canvas_indexed,
+                                              src_reader_indexed);
+    if (status != WUFFS_GIF__STATUS_OK) {
+      FAIL("decode_frame #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return;
+    }
+    memmove(prev_bgra, canvas_bgra.ptr, canvas_bgra.len);
+    status = wuffs_gif__decoder__decode_frame(&dec_bgra, canvas_bgra,
+                                              src_reader_bgra);
+    if (status != WUFFS_GIF__STATUS_OK) {
+      FAIL("decode_frame #%d: got %" PRIi32 " (%s)", i, status,
+           wuffs_gif__status__string(status));
+      return;
+    }
+
+    // Within the frame rect, a transparent pixel leaves the BGRA canvas
+    // unchanged, and other pixels are the palette's colors.
+    uint32_t x0 = wuffs_base__frame_config__x(&fc);
+    uint32_t y0 = wuffs_base__frame_config__y(&fc);
+    uint32_t x1 = x0 + wuffs_base__frame_config__width(&fc);
+    uint32_t y1 = y0 + wuffs_base__frame_config__height(&fc);
+    uint32_t width = wuffs_base__image_config__width(&ic);
+    wuffs_base__slice_u8 palette = wuffs_base__frame_config__palette(&fc);
+    uint32_t x;
+    uint32_t y;
+    for (y = y0; y < y1; y++) {
+      for (x = x0; x < x1; x++) {
+        size_t j = y * width + x;
+        uint8_t index = canvas_indexed.ptr[j];
+        uint8_t want[4];
+        if (wuffs_base__frame_config__has_transparent_index(&fc) &&
+            (index == wuffs_base__frame_config__transparent_index(&fc))) {
+          num_transparent++;
+          memmove(want, prev_bgra + 4 * j, 4);
+        } else {
+          uint8_t* p = palette.ptr + 3 * index;
+          uint8_t rgba[4] = {p[0], p[1], p[2], 0xFF};
+          want_pixel(want, rgba, WUFFS_BASE__PIXEL_FORMAT__BGRA);
+        }
+        if (memcmp(want, canvas_bgra.ptr + 4 * j, 4)) {
+          FAIL("frame #%d: pixel (%" PRIu32 ", %" PRIu32 "): BGRA mismatch", i,
+               x, y);
+          return;
+        }
+      }
+    }
+  }
+
+  if (num_transparent == 0) {
+    FAIL("num_transparent: got 0, want > 0");
+    return;
+  }
+}
+
+void test_wuffs_gif_set_pixel_format_bad_argument() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.gif")) {
+    return;
+  }
+
+  wuffs_gif__decoder dec;
+  wuffs_gif__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  // 4 is not a WUFFS_BASE__PIXEL_FORMAT__ETC value.
+  wuffs_gif__decoder__set_pixel_format(&dec, 4);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_gif__status status =
+      wuffs_gif__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_GIF__ERROR_BAD_ARGUMENT) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_gif__status__string(status), WUFFS_GIF__ERROR_BAD_ARGUMENT,
+         wuffs_gif__status__string(WUFFS_GIF__ERROR_BAD_ARGUMENT));
+    return;
+  }
+}
+
   // ---------------- Mimic Tests
 
 #ifdef WUFFS_MIMIC
@@ -735,6 +1214,16 @@ void test_mimic_gif_decode_hibiscus() {
   do_test_mimic_gif_decode("../../data/hibiscus.gif");
 }
 
+void test_mimic_gif_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_gif_decode("../../data/bricks-dither.interlaced.gif");
+}
+
+void test_mimic_gif_decode_frame_out_of_bounds() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_gif_decode("../../data/artificial/gif-frame-out-of-bounds.gif");
+}
+
 void test_mimic_gif_decode_pjw_thumbnail() {
   CHECK_FOCUS(__func__);
   do_test_mimic_gif_decode("../../data/pjw-thumbnail.gif");
@@ -834,29 +1323,40 @@ proc tests[] = {
     test_basic_status_strings,          //
     test_basic_sub_struct_initializer,  //
 
-    test_wuffs_lzw_decode_many_big_reads,           //
-    test_wuffs_lzw_decode_many_small_writes_reads,  //
-    test_wuffs_lzw_decode_bricks_dither,            //
-    test_wuffs_lzw_decode_bricks_nodither,          //
-    test_wuffs_lzw_decode_pi,                       //
-
-    test_wuffs_gif_call_sequence,                                  //
-    test_wuffs_gif_decode_animated,                                //
-    test_wuffs_gif_decode_input_is_a_gif,                          //
-    test_wuffs_gif_decode_input_is_a_gif_many_big_reads,           //
-    test_wuffs_gif_decode_input_is_a_gif_many_medium_reads,        //
-    test_wuffs_gif_decode_input_is_a_gif_many_small_writes_reads,  //
-    test_wuffs_gif_decode_input_is_a_png,                          //
+    test_wuffs_lzw_decode_many_big_reads,    //
+    test_wuffs_lzw_decode_many_small_reads,  //
+    test_wuffs_lzw_decode_bricks_dither,     //
+    test_wuffs_lzw_decode_bricks_nodither,   //
+    test_wuffs_lzw_decode_pi,                //
+
+    test_wuffs_gif_call_sequence,                            //
+    test_wuffs_gif_decode_animated,                          //
+    test_wuffs_gif_decode_animated_without_frame_configs,    //
+    test_wuffs_gif_decode_frame_out_of_bounds,               //
+    test_wuffs_gif_decode_input_is_a_gif,                    //
+    test_wuffs_gif_decode_input_is_a_gif_many_big_reads,     //
+    test_wuffs_gif_decode_input_is_a_gif_many_medium_reads,  //
+    test_wuffs_gif_decode_input_is_a_gif_many_small_reads,   //
+    test_wuffs_gif_decode_input_is_a_png,                    //
+    test_wuffs_gif_decode_interlaced,                        //
+    test_wuffs_gif_decode_interlaced_many_small_reads,       //
+    test_wuffs_gif_decode_pixel_format_bgra,                 //
+    test_wuffs_gif_decode_pixel_format_rgb565,               //
+    test_wuffs_gif_decode_pixel_format_rgba,                 //
+    test_wuffs_gif_decode_pixel_format_transparency,         //
+    test_wuffs_gif_set_pixel_format_bad_argument,            //
 
 #ifdef WUFFS_MIMIC
 
-    test_mimic_gif_decode_bricks_dither,    //
-    test_mimic_gif_decode_bricks_gray,      //
-    test_mimic_gif_decode_bricks_nodither,  //
-    test_mimic_gif_decode_harvesters,       //
-    test_mimic_gif_decode_hat,              //
-    test_mimic_gif_decode_hibiscus,         //
-    test_mimic_gif_decode_pjw_thumbnail,    //
+    test_mimic_gif_decode_bricks_dither,        //
+    test_mimic_gif_decode_bricks_gray,          //
+    test_mimic_gif_decode_bricks_nodither,      //
+    test_mimic_gif_decode_frame_out_of_bounds,  //
+    test_mimic_gif_decode_harvesters,           //
+    test_mimic_gif_decode_hat,                  //
+    test_mimic_gif_decode_hibiscus,             //
+    test_mimic_gif_decode_interlaced,           //
+    test_mimic_gif_decode_pjw_thumbnail,        //
 
 #endif  // WUFFS_MIMIC
 
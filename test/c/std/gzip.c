@@ -50,11 +50,29 @@ the first "./a.out" with "./a.out -bench". Combine these changes with the
 
 // ---------------- Golden Tests
 
+golden_test gzip_header_fields_gt = {
+    .want_filename =
+        "../../data/artificial/"
+        "gzip-header-fields.gz.decompressed",
+    .src_filename =
+        "../../data/artificial/"
+        "gzip-header-fields.gz",
+};
+
 golden_test gzip_midsummer_gt = {
     .want_filename = "../../data/midsummer.txt",    //
     .src_filename = "../../data/midsummer.txt.gz",  //
 };
 
+golden_test gzip_multiple_members_gt = {
+    .want_filename =
+        "../../data/artificial/"
+        "gzip-multiple-members.gz.decompressed",
+    .src_filename =
+        "../../data/artificial/"
+        "gzip-multiple-members.gz",
+};
+
 golden_test gzip_pi_gt = {
     .want_filename = "../../data/pi.txt",    //
     .src_filename = "../../data/pi.txt.gz",  //
@@ -87,6 +105,10 @@ const char* wuffs_gzip_decode(wuffs_base__buf1* dst,
         wuffs_gzip__decoder__decode(&dec, dst_writer, src_reader);
 
     if (s == WUFFS_GZIP__STATUS_OK) {
+      // Any remaining src data is the next member of a multi-member stream.
+      if (src->ri < src->wi) {
+        continue;
+      }
       return NULL;
     }
     if ((wlimit && (s == WUFFS_GZIP__SUSPENSION_SHORT_WRITE)) ||
@@ -180,6 +202,270 @@ void test_wuffs_gzip_checksum_verify_good() {
   do_test_wuffs_gzip_checksum(false, 0);
 }
 
+bool check_slice(const char* prefix,
+                 wuffs_base__slice_u8 got,
+                 const char* want_ptr,
+                 size_t want_len) {
+  if ((got.len != want_len) || memcmp(got.ptr, want_ptr, want_len)) {
+    FAIL("%s: got \"%.*s\" (length %zu), want \"%.*s\" (length %zu)",
+         prefix, (int)(got.len), got.ptr, got.len, (int)(want_len), want_ptr,
+         want_len);
+    return false;
+  }
+  return true;
+}
+
+void test_wuffs_gzip_decode_header_fields() {
+  CHECK_FOCUS(__func__);
+  do_test_buf1_buf1(wuffs_gzip_decode, &gzip_header_fields_gt, 0, 0);
+}
+
+void test_wuffs_gzip_decode_multiple_members() {
+  CHECK_FOCUS(__func__);
+  do_test_buf1_buf1(wuffs_gzip_decode, &gzip_multiple_members_gt, 0, 0);
+}
+
+void test_wuffs_gzip_decode_multiple_members_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_buf1_buf1(wuffs_gzip_decode, &gzip_multiple_members_gt, 0, 3);
+}
+
+bool do_test_wuffs_gzip_header_checksum(bool ignore_checksum,
+                                        bool bad_checksum) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, gzip_header_fields_gt.src_filename)) {
+    return false;
+  }
+
+  // Flip a bit in the header's CRC-16, which is at offset 0x28. See
+  // test/data/artificial/gzip-header-fields.gz.commentary.txt.
+  if (src.wi < 0x2A) {
+    FAIL("source file was too short");
+    return false;
+  }
+  if (bad_checksum) {
+    src.ptr[0x28] ^= 1;
+  }
+
+  wuffs_gzip__decoder dec;
+  wuffs_gzip__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_gzip__decoder__set_ignore_checksum(&dec, ignore_checksum);
+  wuffs_base__writer1 got_writer = {.buf = &got};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_gzip__status want = (bad_checksum && !ignore_checksum)
+                                ? WUFFS_GZIP__ERROR_BAD_GZIP_HEADER_CHECKSUM
+                                : WUFFS_GZIP__STATUS_OK;
+  wuffs_gzip__status status =
+      wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+  if (status != want) {
+    FAIL("got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_gzip__status__string(status), want,
+         wuffs_gzip__status__string(want));
+    return false;
+  }
+  return true;
+}
+
+void test_wuffs_gzip_header_checksum_ignore() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_header_checksum(true, true);
+}
+
+void test_wuffs_gzip_header_checksum_verify_bad() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_header_checksum(false, true);
+}
+
+void test_wuffs_gzip_header_checksum_verify_good() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_gzip_header_checksum(false, false);
+}
+
+void test_wuffs_gzip_header_extra_max() {
+  CHECK_FOCUS(__func__);
+
+  // A header with a 2000 byte FEXTRA, whose i'th byte is (i & 0xFF), followed
+  // by an empty payload: an empty fixed Huffman block, then the CRC-32 and
+  // length of zero bytes. Only the first 1024 bytes are kept.
+  uint8_t src_array[10 + 2 + 2000 + 10] = {
+      0x1F, 0x8B, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xD0, 0x07,
+  };
+  size_t i;
+  for (i = 0; i < 2000; i++) {
+    src_array[12 + i] = (uint8_t)i;
+  }
+  memcpy(src_array + 2012, "\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00", 10);
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = src_array,
+                          .len = sizeof(src_array),
+                          .wi = sizeof(src_array),
+                          .closed = true};
+  wuffs_gzip__decoder dec;
+  wuffs_gzip__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__writer1 got_writer = {.buf = &got};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+  wuffs_gzip__status status =
+      wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+  if (status != WUFFS_GZIP__STATUS_OK) {
+    FAIL("decode: got %" PRIi32 " (%s)", status,
+         wuffs_gzip__status__string(status));
+    return;
+  }
+  wuffs_base__slice_u8 extra = wuffs_gzip__decoder__extra(&dec);
+  if (extra.len != 1024) {
+    FAIL("extra.len: got %zu, want 1024", extra.len);
+    return;
+  }
+  for (i = 0; i < extra.len; i++) {
+    if (extra.ptr[i] != (uint8_t)i) {
+      FAIL("extra[%zu]: got 0x%02X, want 0x%02X", i, extra.ptr[i], (uint8_t)i);
+      return;
+    }
+  }
+}
+
+void test_wuffs_gzip_header_fields() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, gzip_header_fields_gt.src_filename)) {
+    return;
+  }
+
+  // Decode the src data 1 byte at a time, so that reading the header
+  // suspends, and resumes, at every byte.
+  wuffs_gzip__decoder dec;
+  wuffs_gzip__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__writer1 got_writer = {.buf = &got};
+  wuffs_gzip__status status;
+  while (true) {
+    uint64_t rlim = 1;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    src_reader.private_impl.limit.ptr_to_len = &rlim;
+    status = wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+    if (status != WUFFS_GZIP__SUSPENSION_SHORT_READ) {
+      break;
+    }
+  }
+  if (status != WUFFS_GZIP__STATUS_OK) {
+    FAIL("decode: got %" PRIi32 " (%s)", status,
+         wuffs_gzip__status__string(status));
+    return;
+  }
+
+  uint32_t mtime = wuffs_gzip__decoder__mtime(&dec);
+  if (mtime != 0x5BB5D3A0) {
+    FAIL("mtime: got 0x%08" PRIX32 ", want 0x5BB5D3A0", mtime);
+    return;
+  }
+  // The FNAME's ISO 8859-1 "\xE9" is "\xC3\xA9" in UTF-8.
+  if (!check_slice("name", wuffs_gzip__decoder__name(&dec),
+                   "caf\xC3\xA9.txt", 9)) {
+    return;
+  }
+  if (!check_slice("comment", wuffs_gzip__decoder__comment(&dec),
+                   "A comment.", 10)) {
+    return;
+  }
+  if (!check_slice("extra", wuffs_gzip__decoder__extra(&dec),
+                   "Wu\x04\x00"
+                   "ffs!",
+                   8)) {
+    return;
+  }
+}
+
+void test_wuffs_gzip_header_fields_multiple_members() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, gzip_multiple_members_gt.src_filename)) {
+    return;
+  }
+
+  wuffs_gzip__decoder dec;
+  wuffs_gzip__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__writer1 got_writer = {.buf = &got};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  const char* want_names[2] = {"one.txt", "two.txt"};
+  int i;
+  for (i = 0; i < 2; i++) {
+    wuffs_gzip__status status =
+        wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+    if (status != WUFFS_GZIP__STATUS_OK) {
+      FAIL("member #%d: decode: got %" PRIi32 " (%s)", i, status,
+           wuffs_gzip__status__string(status));
+      return;
+    }
+    uint32_t mtime = wuffs_gzip__decoder__mtime(&dec);
+    if (mtime != 0x5BB5D3A0 + i) {
+      FAIL("member #%d: mtime: got 0x%08" PRIX32 ", want 0x%08" PRIX32, i,
+           mtime, 0x5BB5D3A0 + i);
+      return;
+    }
+    if (!check_slice("name", wuffs_gzip__decoder__name(&dec), want_names[i],
+                     7) ||
+        !check_slice("comment", wuffs_gzip__decoder__comment(&dec), "", 0)) {
+      return;
+    }
+  }
+  if (src.ri != src.wi) {
+    FAIL("src.ri: got %zu, want %zu", src.ri, src.wi);
+    return;
+  }
+}
+
+void test_wuffs_gzip_header_name_max() {
+  CHECK_FOCUS(__func__);
+
+  // A header with a 200 byte FNAME of ISO 8859-1 "\xE9" characters, followed
+  // by an empty payload: an empty fixed Huffman block, then the CRC-32 and
+  // length of zero bytes. In UTF-8, each character is 2 bytes, so only 127
+  // fit in NAME_MAX (255) bytes.
+  uint8_t src_array[10 + 201 + 10] = {
+      0x1F, 0x8B, 0x08, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+  };
+  memset(src_array + 10, 0xE9, 200);
+  memcpy(src_array + 211, "\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00", 10);
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = src_array,
+                          .len = sizeof(src_array),
+                          .wi = sizeof(src_array),
+                          .closed = true};
+  wuffs_gzip__decoder dec;
+  wuffs_gzip__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__writer1 got_writer = {.buf = &got};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+  wuffs_gzip__status status =
+      wuffs_gzip__decoder__decode(&dec, got_writer, src_reader);
+  if (status != WUFFS_GZIP__STATUS_OK) {
+    FAIL("decode: got %" PRIi32 " (%s)", status,
+         wuffs_gzip__status__string(status));
+    return;
+  }
+  wuffs_base__slice_u8 name = wuffs_gzip__decoder__name(&dec);
+  if (name.len != 254) {
+    FAIL("name.len: got %zu, want 254", name.len);
+    return;
+  }
+  size_t i;
+  for (i = 0; i < name.len; i += 2) {
+    if ((name.ptr[i] != 0xC3) || (name.ptr[i + 1] != 0xA9)) {
+      FAIL("name[%zu:%zu]: got 0x%02X 0x%02X, want 0xC3 0xA9", i, i + 2,
+           name.ptr[i], name.ptr[i + 1]);
+      return;
+    }
+  }
+}
+
 void test_wuffs_gzip_decode_midsummer() {
   CHECK_FOCUS(__func__);
   do_test_buf1_buf1(wuffs_gzip_decode, &gzip_midsummer_gt, 0, 0);
@@ -244,12 +530,22 @@ void bench_mimic_gzip_decode_100k() {
 // The empty comments forces clang-format to place one element per line.
 proc tests[] = {
 
-    test_wuffs_gzip_checksum_ignore,       //
-    test_wuffs_gzip_checksum_verify_bad1,  //
-    test_wuffs_gzip_checksum_verify_bad7,  //
-    test_wuffs_gzip_checksum_verify_good,  //
-    test_wuffs_gzip_decode_midsummer,      //
-    test_wuffs_gzip_decode_pi,             //
+    test_wuffs_gzip_checksum_ignore,                           //
+    test_wuffs_gzip_checksum_verify_bad1,                      //
+    test_wuffs_gzip_checksum_verify_bad7,                      //
+    test_wuffs_gzip_checksum_verify_good,                      //
+    test_wuffs_gzip_decode_header_fields,                      //
+    test_wuffs_gzip_decode_midsummer,                          //
+    test_wuffs_gzip_decode_multiple_members,                   //
+    test_wuffs_gzip_decode_multiple_members_many_small_reads,  //
+    test_wuffs_gzip_decode_pi,                                 //
+    test_wuffs_gzip_header_checksum_ignore,                    //
+    test_wuffs_gzip_header_checksum_verify_bad,                //
+    test_wuffs_gzip_header_checksum_verify_good,               //
+    test_wuffs_gzip_header_extra_max,                          //
+    test_wuffs_gzip_header_fields,                             //
+    test_wuffs_gzip_header_fields_multiple_members,            //
+    test_wuffs_gzip_header_name_max,                           //
 
 #ifdef WUFFS_MIMIC
 
@@ -0,0 +1,726 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror jpeg.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC -ljpeg
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/jpeg.c"
+#include "../testlib/testlib.c"
+#ifdef WUFFS_MIMIC
+#include "../mimiclib/jpeg.c"
+#endif
+
+// The workbuf is only used by progressive JPEGs: 128 bytes per 8 × 8 block.
+#define WORKBUF_SIZE (16 * 1024 * 1024)
+
+uint8_t global_workbuf_buffer[WORKBUF_SIZE];
+
+// ---------------- JPEG Tests
+
+const char* wuffs_jpeg_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_jpeg__decoder dec;
+  wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_jpeg__status s =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_jpeg__status__string(s);
+  }
+
+  // Decode the image as planes of Y, Cb and Cr samples, one after another.
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "JPEG image's sample data won't fit in the dst buffer";
+  }
+  uint64_t workbuf_size = wuffs_jpeg__decoder__workbuf_size(&dec);
+  if (workbuf_size > WORKBUF_SIZE) {
+    return "JPEG image's coefficients won't fit in the workbuf";
+  }
+  s = wuffs_jpeg__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      ((wuffs_base__slice_u8){.ptr = global_workbuf_buffer,
+                              .len = workbuf_size}),
+      src_reader);
+  if (s) {
+    return wuffs_jpeg__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// samples_within_tolerance is like buf1s_equal, except that corresponding
+// samples may differ by up to tolerance. Different JPEG decoders can use
+// different IDCT implementations, which round differently.
+bool samples_within_tolerance(wuffs_base__buf1* got,
+                              wuffs_base__buf1* want,
+                              int tolerance) {
+  if (got->wi != want->wi) {
+    FAIL("samples_within_tolerance: wi: got %zu, want %zu", got->wi,
+         want->wi);
+    return false;
+  }
+  size_t i;
+  for (i = 0; i < got->wi; i++) {
+    int d = (int)(got->ptr[i]) - (int)(want->ptr[i]);
+    if ((d < -tolerance) || (tolerance < d)) {
+      FAIL("samples_within_tolerance: sample #%zu: got 0x%02X, want 0x%02X",
+           i, got->ptr[i], want->ptr[i]);
+      return false;
+    }
+  }
+  return true;
+}
+
+bool do_test_wuffs_jpeg_decode(const char* filename,
+                               const char* samples_filename,
+                               uint32_t want_pixel_format,
+                               uint32_t want_chroma_width,
+                               uint32_t want_chroma_height,
+                               uint64_t rlimit) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  wuffs_jpeg__decoder dec;
+  wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+
+  {
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_jpeg__status status =
+        wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_JPEG__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_jpeg__status__string(status));
+      return false;
+    }
+
+    if (wuffs_base__image_config__pixel_format(&ic) != want_pixel_format) {
+      FAIL("pixel_format: got %" PRIu32 ", want %" PRIu32,
+           wuffs_base__image_config__pixel_format(&ic), want_pixel_format);
+      return false;
+    }
+    // Plane 1 is the Cb plane, if there is one. Its size depends on the
+    // chroma subsampling.
+    uint32_t w = wuffs_base__image_config__plane_width(&ic, 1);
+    uint32_t h = wuffs_base__image_config__plane_height(&ic, 1);
+    if ((w != want_chroma_width) || (h != want_chroma_height)) {
+      FAIL("chroma plane size: got %" PRIu32 " × %" PRIu32
+           ", want %" PRIu32 " × %" PRIu32,
+           w, h, want_chroma_width, want_chroma_height);
+      return false;
+    }
+  }
+
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+  uint64_t workbuf_size = wuffs_jpeg__decoder__workbuf_size(&dec);
+  if (workbuf_size > WORKBUF_SIZE) {
+    FAIL("workbuf_size: got %" PRIu64 ", want <= %d", workbuf_size,
+         WORKBUF_SIZE);
+    return false;
+  }
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  wuffs_base__slice_u8 workbuf = {.ptr = global_workbuf_buffer,
+                                  .len = workbuf_size};
+  memset(canvas.ptr, 0, canvas.len);
+
+  int num_iters = 0;
+  uint64_t rlim = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    size_t old_ri = src.ri;
+
+    wuffs_jpeg__status status =
+        wuffs_jpeg__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+    if (status == WUFFS_JPEG__STATUS_OK) {
+      break;
+    }
+    if (status != WUFFS_JPEG__SUSPENSION_SHORT_READ) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_jpeg__status__string(status),
+           WUFFS_JPEG__SUSPENSION_SHORT_READ,
+           wuffs_jpeg__status__string(WUFFS_JPEG__SUSPENSION_SHORT_READ));
+      return false;
+    }
+
+    if (src.ri < old_ri) {
+      FAIL("read index src.ri went backwards");
+      return false;
+    }
+    if (src.ri == old_ri) {
+      FAIL("no progress was made");
+      return false;
+    }
+  }
+  got.wi = canvas.len;
+
+  if (rlimit) {
+    if (num_iters <= 1) {
+      FAIL("num_iters: got %d, want > 1", num_iters);
+      return false;
+    }
+  } else {
+    if (num_iters != 1) {
+      FAIL("num_iters: got %d, want 1", num_iters);
+      return false;
+    }
+  }
+
+  // The samples files were generated by Go's image/jpeg package, whose IDCT
+  // differs from libjpeg's (and Wuffs') by up to 1.
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&want, samples_filename)) {
+    return false;
+  }
+  if (!samples_within_tolerance(&got, &want, 1)) {
+    return false;
+  }
+
+  {
+    if (src.ri != src.wi) {
+      FAIL("decode_frame returned \"ok\" but src was not exhausted");
+      return false;
+    }
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_jpeg__status status =
+        wuffs_jpeg__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+    if (status != WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_jpeg__status__string(status),
+           WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION,
+           wuffs_jpeg__status__string(WUFFS_JPEG__SUSPENSION_END_OF_ANIMATION));
+      return false;
+    }
+  }
+
+  return true;
+}
+
+// do_test_wuffs_jpeg_decode_same checks that two JPEG files, e.g. progressive
+// and sequential variants of the same image, decode to the same samples.
+// Such variants encode the same DCT coefficients, so the samples should match
+// exactly.
+bool do_test_wuffs_jpeg_decode_same(const char* filename0,
+                                    const char* filename1) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, filename0)) {
+    return false;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_jpeg_decode(&want, &src);
+  if (msg) {
+    FAIL("%s: %s", filename0, msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, filename1)) {
+    return false;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  msg = wuffs_jpeg_decode(&got, &src);
+  if (msg) {
+    FAIL("%s: %s", filename1, msg);
+    return false;
+  }
+
+  return buf1s_equal("", &got, &want);
+}
+
+void test_wuffs_jpeg_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.jpeg")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec;
+  wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The image's width and height are still 0, so empty slices are long
+  // enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
+  wuffs_base__slice_u8 workbuf = {.ptr = global_workbuf_buffer, .len = 0};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+  if (status != WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status),
+         WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_jpeg__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_jpeg__status__string(status),
+         WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_bad_argument() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.progressive.jpeg")) {
+    return;
+  }
+
+  int i;
+  for (i = 0; i < 2; i++) {
+    src.ri = 0;
+    wuffs_jpeg__decoder dec;
+    wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+    wuffs_base__image_config ic = {{0}};
+    wuffs_base__reader1 src_reader = {.buf = &src};
+
+    wuffs_jpeg__status status =
+        wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_JPEG__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_jpeg__status__string(status));
+      return;
+    }
+
+    // The 160 × 120 4:2:0 image has 80 + 20 + 20 = 120 blocks of 64
+    // coefficients.
+    uint64_t workbuf_size = wuffs_jpeg__decoder__workbuf_size(&dec);
+    if (workbuf_size != 120 * 128 * 4) {
+      FAIL("workbuf_size: got %" PRIu64 ", want %d", workbuf_size,
+           120 * 128 * 4);
+      return;
+    }
+
+    // Make either the dst or the workbuf one byte too short.
+    size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+    wuffs_base__slice_u8 canvas = {.ptr = got.ptr,
+                                   .len = pixbuf_size - (i == 0 ? 1 : 0)};
+    wuffs_base__slice_u8 workbuf = {.ptr = global_workbuf_buffer,
+                                    .len = workbuf_size - (i == 1 ? 1 : 0)};
+    status =
+        wuffs_jpeg__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+    if (status != WUFFS_JPEG__ERROR_BAD_ARGUMENT) {
+      FAIL("i=%d: decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+           i, status, wuffs_jpeg__status__string(status),
+           WUFFS_JPEG__ERROR_BAD_ARGUMENT,
+           wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_BAD_ARGUMENT));
+      return;
+    }
+  }
+}
+
+void test_wuffs_jpeg_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/bricks-color.jpeg",
+                            "../../data/bricks-color.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 80, 60, 0);
+}
+
+void test_wuffs_jpeg_decode_bricks_color_many_big_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/bricks-color.jpeg",
+                            "../../data/bricks-color.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 80, 60, 4096);
+}
+
+void test_wuffs_jpeg_decode_bricks_color_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/bricks-color.jpeg",
+                            "../../data/bricks-color.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 80, 60, 13);
+}
+
+void test_wuffs_jpeg_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/bricks-gray.jpeg",
+                            "../../data/bricks-gray.y",
+                            WUFFS_BASE__PIXEL_FORMAT__Y, 0, 0, 0);
+}
+
+void test_wuffs_jpeg_decode_hat_422() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/hat.422.jpeg",
+                            "../../data/hat.422.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 45, 112, 0);
+}
+
+void test_wuffs_jpeg_decode_hat_444_progressive() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/hat.444.progressive.jpeg",
+                            "../../data/hat.444.progressive.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 90, 112, 0);
+}
+
+void test_wuffs_jpeg_decode_hat_444_progressive_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode("../../data/hat.444.progressive.jpeg",
+                            "../../data/hat.444.progressive.ycbcr",
+                            WUFFS_BASE__PIXEL_FORMAT__YCBCR, 90, 112, 13);
+}
+
+void test_wuffs_jpeg_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  wuffs_jpeg__decoder dec;
+  wuffs_jpeg__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_jpeg__status status =
+      wuffs_jpeg__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_JPEG__ERROR_BAD_JPEG_HEADER) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_jpeg__status__string(status), WUFFS_JPEG__ERROR_BAD_JPEG_HEADER,
+         wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_BAD_JPEG_HEADER));
+    return;
+  }
+}
+
+void test_wuffs_jpeg_decode_progressive_color() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode_same("../../data/bricks-color.jpeg",
+                                 "../../data/bricks-color.progressive.jpeg");
+}
+
+void test_wuffs_jpeg_decode_progressive_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode_same("../../data/bricks-gray.jpeg",
+                                 "../../data/bricks-gray.progressive.jpeg");
+}
+
+void test_wuffs_jpeg_decode_restart_markers() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_jpeg_decode_same("../../data/bricks-color.jpeg",
+                                 "../../data/bricks-color.restart.jpeg");
+}
+
+void test_wuffs_jpeg_decode_truncated() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.jpeg")) {
+    return;
+  }
+  // Cut the file in half, part way through the entropy-coded data.
+  src.wi /= 2;
+
+  const char* msg = wuffs_jpeg_decode(&got, &src);
+  const char* want =
+      wuffs_jpeg__status__string(WUFFS_JPEG__ERROR_UNEXPECTED_EOF);
+  if (!msg || strcmp(msg, want)) {
+    FAIL("got \"%s\", want \"%s\"", msg ? msg : "", want);
+    return;
+  }
+}
+
+  // ---------------- Mimic Tests
+
+#ifdef WUFFS_MIMIC
+
+bool do_test_mimic_jpeg_decode(const char* filename) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  const char* got_msg = wuffs_jpeg_decode(&got, &src);
+  if (got_msg) {
+    FAIL("%s", got_msg);
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* want_msg = mimic_jpeg_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s", want_msg);
+    return false;
+  }
+
+  // Wuffs implements libjpeg's islow IDCT, but libjpeg-turbo's SIMD code
+  // paths are not guaranteed to round identically, so allow a tolerance.
+  return samples_within_tolerance(&got, &want, 1);
+}
+
+void test_mimic_jpeg_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-color.jpeg");
+}
+
+void test_mimic_jpeg_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-gray.jpeg");
+}
+
+void test_mimic_jpeg_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/harvesters.jpeg");
+}
+
+void test_mimic_jpeg_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hat.jpeg");
+}
+
+void test_mimic_jpeg_decode_hat_422() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hat.422.jpeg");
+}
+
+void test_mimic_jpeg_decode_hat_444_progressive() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hat.444.progressive.jpeg");
+}
+
+void test_mimic_jpeg_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/hibiscus.jpeg");
+}
+
+void test_mimic_jpeg_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/pjw-thumbnail.jpeg");
+}
+
+void test_mimic_jpeg_decode_progressive_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-color.progressive.jpeg");
+}
+
+void test_mimic_jpeg_decode_progressive_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-gray.progressive.jpeg");
+}
+
+void test_mimic_jpeg_decode_restart_markers() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_jpeg_decode("../../data/bricks-color.restart.jpeg");
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- JPEG Benches
+
+bool do_bench_jpeg_decode(const char* (*decode_func)(wuffs_base__buf1*,
+                                                     wuffs_base__buf1*),
+                          const char* filename,
+                          uint64_t reps) {
+  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  for (i = 0; i < reps; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(reps, n_bytes);
+  return true;
+}
+
+void bench_wuffs_jpeg_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/pjw-thumbnail.jpeg",
+                       100000);
+}
+
+void bench_wuffs_jpeg_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/hat.jpeg", 5000);
+}
+
+void bench_wuffs_jpeg_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/hibiscus.jpeg", 500);
+}
+
+void bench_wuffs_jpeg_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode, "../../data/harvesters.jpeg", 50);
+}
+
+void bench_wuffs_jpeg_decode_progressive() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(wuffs_jpeg_decode,
+                       "../../data/bricks-color.progressive.jpeg", 2000);
+}
+
+  // ---------------- Mimic Benches
+
+#ifdef WUFFS_MIMIC
+
+void bench_mimic_jpeg_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/pjw-thumbnail.jpeg",
+                       100000);
+}
+
+void bench_mimic_jpeg_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/hat.jpeg", 5000);
+}
+
+void bench_mimic_jpeg_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/hibiscus.jpeg", 500);
+}
+
+void bench_mimic_jpeg_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode, "../../data/harvesters.jpeg", 50);
+}
+
+void bench_mimic_jpeg_decode_progressive() {
+  CHECK_FOCUS(__func__);
+  do_bench_jpeg_decode(mimic_jpeg_decode,
+                       "../../data/bricks-color.progressive.jpeg", 2000);
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_jpeg_call_sequence,                                //
+    test_wuffs_jpeg_decode_bad_argument,                          //
+    test_wuffs_jpeg_decode_bricks_color,                          //
+    test_wuffs_jpeg_decode_bricks_color_many_big_reads,           //
+    test_wuffs_jpeg_decode_bricks_color_many_small_reads,         //
+    test_wuffs_jpeg_decode_bricks_gray,                           //
+    test_wuffs_jpeg_decode_hat_422,                               //
+    test_wuffs_jpeg_decode_hat_444_progressive,                   //
+    test_wuffs_jpeg_decode_hat_444_progressive_many_small_reads,  //
+    test_wuffs_jpeg_decode_input_is_a_png,                        //
+    test_wuffs_jpeg_decode_progressive_color,                     //
+    test_wuffs_jpeg_decode_progressive_gray,                      //
+    test_wuffs_jpeg_decode_restart_markers,                       //
+    test_wuffs_jpeg_decode_truncated,                             //
+
+#ifdef WUFFS_MIMIC
+
+    test_mimic_jpeg_decode_bricks_color,         //
+    test_mimic_jpeg_decode_bricks_gray,          //
+    test_mimic_jpeg_decode_harvesters,           //
+    test_mimic_jpeg_decode_hat,                  //
+    test_mimic_jpeg_decode_hat_422,              //
+    test_mimic_jpeg_decode_hat_444_progressive,  //
+    test_mimic_jpeg_decode_hibiscus,             //
+    test_mimic_jpeg_decode_pjw_thumbnail,        //
+    test_mimic_jpeg_decode_progressive_color,    //
+    test_mimic_jpeg_decode_progressive_gray,     //
+    test_mimic_jpeg_decode_restart_markers,      //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_jpeg_decode_1k,           //
+    bench_wuffs_jpeg_decode_10k,          //
+    bench_wuffs_jpeg_decode_100k,         //
+    bench_wuffs_jpeg_decode_1000k,        //
+    bench_wuffs_jpeg_decode_progressive,  //
+
+#ifdef WUFFS_MIMIC
+
+    bench_mimic_jpeg_decode_1k,           //
+    bench_mimic_jpeg_decode_10k,          //
+    bench_mimic_jpeg_decode_100k,         //
+    bench_mimic_jpeg_decode_1000k,        //
+    bench_mimic_jpeg_decode_progressive,  //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "std/jpeg.c";
+  return test_main(argc, argv, tests, benches);
+}
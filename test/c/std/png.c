@@ -0,0 +1,773 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror png.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+Add the "wuffs mimic cflags" (everything after the colon below) to the C
+compiler flags (after the .c file) to run the mimic tests.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench". Combine these changes with the
+"wuffs mimic cflags" to run the mimic benchmarks.
+*/
+
+// !! wuffs mimic cflags: -DWUFFS_MIMIC -lpng
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../../../gen/c/std/png.c"
+#include "../testlib/testlib.c"
+#ifdef WUFFS_MIMIC
+#include "../mimiclib/png.c"
+#endif
+
+// ---------------- PNG Tests
+
+const char* wuffs_png_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+
+  // Decode the image as RGBA, the default pixel format.
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "PNG image's pixel data won't fit in the dst buffer";
+  }
+  s = wuffs_png__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+bool do_test_wuffs_png_decode(const char* filename,
+                              const char* palette_filename,
+                              const char* indexes_filename,
+                              uint64_t rlimit) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_png__decoder__set_pixel_format(&dec,
+                                       WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+
+  {
+    wuffs_base__image_config ic = {{0}};
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_png__status status =
+        wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_PNG__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_png__status__string(status));
+      return false;
+    }
+
+    // The bricks-etc.png images, and their interlaced variants, are 160 × 120.
+    if (wuffs_base__image_config__width(&ic) != 160) {
+      FAIL("width: got %" PRIu32 ", want 160",
+           wuffs_base__image_config__width(&ic));
+      return false;
+    }
+    if (wuffs_base__image_config__height(&ic) != 120) {
+      FAIL("height: got %" PRIu32 ", want 120",
+           wuffs_base__image_config__height(&ic));
+      return false;
+    }
+  }
+
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 160 * 120};
+  memset(canvas.ptr, 0, canvas.len);
+
+  int num_iters = 0;
+  uint64_t rlim = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    size_t old_ri = src.ri;
+
+    wuffs_png__status status =
+        wuffs_png__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status == WUFFS_PNG__STATUS_OK) {
+      break;
+    }
+    if (status != WUFFS_PNG__SUSPENSION_SHORT_READ) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_png__status__string(status), WUFFS_PNG__SUSPENSION_SHORT_READ,
+           wuffs_png__status__string(WUFFS_PNG__SUSPENSION_SHORT_READ));
+      return false;
+    }
+
+    if (src.ri < old_ri) {
+      FAIL("read index src.ri went backwards");
+      return false;
+    }
+    if (src.ri == old_ri) {
+      FAIL("no progress was made");
+      return false;
+    }
+  }
+  got.wi = canvas.len;
+
+  if (rlimit) {
+    if (num_iters <= 1) {
+      FAIL("num_iters: got %d, want > 1", num_iters);
+      return false;
+    }
+  } else {
+    if (num_iters != 1) {
+      FAIL("num_iters: got %d, want 1", num_iters);
+      return false;
+    }
+  }
+
+  // A NULL palette_filename means a gray image, whose palette is the identity.
+  //
+  // TODO: provide a public API for getting the palette.
+  if (palette_filename) {
+    wuffs_base__buf1 pal_got = {.ptr = dec.private_impl.f_palette,
+                                .len = 3 * 256};
+    wuffs_base__buf1 pal_want = {.ptr = global_palette_buffer,
+                                 .len = 3 * 256};
+    pal_got.wi = 3 * 256;
+    if (!read_file(&pal_want, palette_filename)) {
+      return false;
+    }
+    if (!buf1s_equal("palette ", &pal_got, &pal_want)) {
+      return false;
+    }
+  }
+
+  wuffs_base__buf1 ind_want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&ind_want, indexes_filename)) {
+    return false;
+  }
+  if (!buf1s_equal("indexes ", &got, &ind_want)) {
+    return false;
+  }
+
+  {
+    if (src.ri != src.wi) {
+      FAIL("decode_frame returned \"ok\" but src was not exhausted");
+      return false;
+    }
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_png__status status =
+        wuffs_png__decoder__decode_frame(&dec, canvas, src_reader);
+    if (status != WUFFS_PNG__SUSPENSION_END_OF_ANIMATION) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_png__status__string(status),
+           WUFFS_PNG__SUSPENSION_END_OF_ANIMATION,
+           wuffs_png__status__string(WUFFS_PNG__SUSPENSION_END_OF_ANIMATION));
+      return false;
+    }
+  }
+
+  return true;
+}
+
+// do_test_wuffs_png_decode_same checks that two PNG files, e.g. interlaced
+// and non-interlaced variants of the same image, decode to the same RGBA
+// pixels.
+bool do_test_wuffs_png_decode_same(const char* filename0,
+                                   const char* filename1) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, filename0)) {
+    return false;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&want, &src);
+  if (msg) {
+    FAIL("%s: %s", filename0, msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, filename1)) {
+    return false;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  msg = wuffs_png_decode(&got, &src);
+  if (msg) {
+    FAIL("%s: %s", filename1, msg);
+    return false;
+  }
+
+  return buf1s_equal("", &got, &want);
+}
+
+void test_wuffs_png_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return;
+  }
+
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The image's width and height are still 0, so an empty dst is long enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status),
+         WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_png__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_png__status__string(status),
+         WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_bad_checksum() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return;
+  }
+  // Flip a bit in the final byte, part of the IEND chunk's CRC-32 checksum.
+  if (src.wi == 0) {
+    FAIL("src size: got 0, want > 0");
+    return;
+  }
+  src.ptr[src.wi - 1] ^= 0x01;
+
+  int i;
+  for (i = 0; i < 2; i++) {
+    bool ignore_checksum = i != 0;
+    src.ri = 0;
+    wuffs_png__decoder dec;
+    wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+    wuffs_png__decoder__set_ignore_checksum(&dec, ignore_checksum);
+    wuffs_base__image_config ic = {{0}};
+    wuffs_base__reader1 src_reader = {.buf = &src};
+
+    wuffs_png__status status =
+        wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_PNG__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_png__status__string(status));
+      return;
+    }
+
+    wuffs_base__slice_u8 canvas = {
+        .ptr = got.ptr, .len = wuffs_base__image_config__pixbuf_size(&ic)};
+    status = wuffs_png__decoder__decode_frame(&dec, canvas, src_reader);
+    wuffs_png__status want = ignore_checksum
+                                 ? WUFFS_PNG__STATUS_OK
+                                 : WUFFS_PNG__ERROR_CHECKSUM_MISMATCH;
+    if (status != want) {
+      FAIL("ignore_checksum=%d: decode_frame: got %" PRIi32 " (%s), want %" PRIi32
+           " (%s)",
+           ignore_checksum, status, wuffs_png__status__string(status), want,
+           wuffs_png__status__string(want));
+      return;
+    }
+  }
+}
+
+void test_wuffs_png_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-dither.png",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 0);
+}
+
+void test_wuffs_png_decode_bricks_dither_many_big_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-dither.png",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 4096);
+}
+
+void test_wuffs_png_decode_bricks_dither_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-dither.png",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13);
+}
+
+void test_wuffs_png_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-gray.png", NULL,
+                           "../../data/bricks-gray.indexes", 0);
+}
+
+void test_wuffs_png_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-nodither.png",
+                           "../../data/bricks-nodither.palette",
+                           "../../data/bricks-nodither.indexes", 0);
+}
+
+void test_wuffs_png_decode_depth16() {
+  CHECK_FOCUS(__func__);
+  // The 16-bit samples' high bytes are bricks-color.png's 8-bit samples.
+  do_test_wuffs_png_decode_same("../../data/bricks-color.png",
+                                "../../data/bricks-color.depth16.png");
+}
+
+void test_wuffs_png_decode_gray2_trns_interlaced() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src,
+                 "../../data/artificial/png-gray2-trns.interlaced.png")) {
+    return;
+  }
+  const char* msg = wuffs_png_decode(&got, &src);
+  if (msg) {
+    FAIL("%s", msg);
+    return;
+  }
+
+  // The 3 × 2 image's 2-bit gray values are {0, 1, 2} and {3, 2, 1}, and the
+  // tRNS chunk makes gray value 2 transparent.
+  uint8_t want_array[4 * 3 * 2] = {
+      0x00, 0x00, 0x00, 0xFF, 0x55, 0x55, 0x55, 0xFF, 0xAA, 0xAA, 0xAA, 0x00,
+      0xFF, 0xFF, 0xFF, 0xFF, 0xAA, 0xAA, 0xAA, 0x00, 0x55, 0x55, 0x55, 0xFF,
+  };
+  wuffs_base__buf1 want = {.ptr = want_array,
+                           .len = sizeof(want_array),
+                           .wi = sizeof(want_array),
+                           .closed = true};
+  if (!buf1s_equal("", &got, &want)) {
+    return;
+  }
+}
+
+void test_wuffs_png_decode_input_is_a_gif() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.gif")) {
+    return;
+  }
+
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__ERROR_BAD_PNG_SIGNATURE) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status), WUFFS_PNG__ERROR_BAD_PNG_SIGNATURE,
+         wuffs_png__status__string(WUFFS_PNG__ERROR_BAD_PNG_SIGNATURE));
+    return;
+  }
+}
+
+void test_wuffs_png_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-dither.interlaced.png",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 0);
+}
+
+void test_wuffs_png_decode_interlaced_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode("../../data/bricks-dither.interlaced.png",
+                           "../../data/bricks-dither.palette",
+                           "../../data/bricks-dither.indexes", 13);
+}
+
+void test_wuffs_png_decode_interlaced_truecolor() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_same("../../data/bricks-color.png",
+                                "../../data/bricks-color.interlaced.png");
+}
+
+bool do_test_wuffs_png_decode_pixel_format(uint32_t pixel_format) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return false;
+  }
+
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_png__decoder__set_pixel_format(&dec, pixel_format);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status));
+    return false;
+  }
+  if (!check_pixel_format_config(&ic, pixel_format, 160 * 120)) {
+    return false;
+  }
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  status = wuffs_png__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_PNG__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status));
+    return false;
+  }
+  got.wi = pixbuf_size;
+
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (!read_indexed_rgba(&want, "../../data/bricks-dither.palette",
+                         "../../data/bricks-dither.indexes")) {
+    return false;
+  }
+  return pixel_format_equal(&got, &want, pixel_format);
+}
+
+void test_wuffs_png_decode_pixel_format_bgra() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__BGRA);
+}
+
+void test_wuffs_png_decode_pixel_format_rgb565() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGB565);
+}
+
+void test_wuffs_png_decode_pixel_format_rgba() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_png_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGBA);
+}
+
+void test_wuffs_png_set_pixel_format_unsupported() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-color.png")) {
+    return;
+  }
+
+  // A truecolor image has no palette, so it cannot be decoded as indexes.
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_png__decoder__set_pixel_format(&dec,
+                                       WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_png__status status =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_PNG__ERROR_UNSUPPORTED_PNG_PIXEL_FORMAT) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_png__status__string(status),
+         WUFFS_PNG__ERROR_UNSUPPORTED_PNG_PIXEL_FORMAT,
+         wuffs_png__status__string(
+             WUFFS_PNG__ERROR_UNSUPPORTED_PNG_PIXEL_FORMAT));
+    return;
+  }
+}
+
+  // ---------------- Mimic Tests
+
+#ifdef WUFFS_MIMIC
+
+bool do_test_mimic_png_decode(const char* filename) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  const char* got_msg = wuffs_png_decode(&got, &src);
+  if (got_msg) {
+    FAIL("%s", got_msg);
+    return false;
+  }
+
+  src.ri = 0;
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* want_msg = mimic_png_decode(&want, &src);
+  if (want_msg) {
+    FAIL("%s", want_msg);
+    return false;
+  }
+
+  return buf1s_equal("", &got, &want);
+}
+
+void test_mimic_png_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-color.png");
+}
+
+void test_mimic_png_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-dither.png");
+}
+
+void test_mimic_png_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-gray.png");
+}
+
+void test_mimic_png_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-nodither.png");
+}
+
+void test_mimic_png_decode_depth16() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-color.depth16.png");
+}
+
+void test_mimic_png_decode_gray2_trns_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode(
+      "../../data/artificial/png-gray2-trns.interlaced.png");
+}
+
+void test_mimic_png_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/harvesters.png");
+}
+
+void test_mimic_png_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/hat.png");
+}
+
+void test_mimic_png_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/hibiscus.png");
+}
+
+void test_mimic_png_decode_interlaced() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-dither.interlaced.png");
+}
+
+void test_mimic_png_decode_interlaced_truecolor() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/bricks-color.interlaced.png");
+}
+
+void test_mimic_png_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_mimic_png_decode("../../data/pjw-thumbnail.png");
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- PNG Benches
+
+bool do_bench_png_decode(const char* (*decode_func)(wuffs_base__buf1*,
+                                                    wuffs_base__buf1*),
+                         const char* filename,
+                         uint64_t reps) {
+  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  for (i = 0; i < reps; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(reps, n_bytes);
+  return true;
+}
+
+void bench_wuffs_png_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/pjw-thumbnail.png", 100000);
+}
+
+void bench_wuffs_png_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/hat.png", 5000);
+}
+
+void bench_wuffs_png_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/hibiscus.png", 500);
+}
+
+void bench_wuffs_png_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(wuffs_png_decode, "../../data/harvesters.png", 50);
+}
+
+  // ---------------- Mimic Benches
+
+#ifdef WUFFS_MIMIC
+
+void bench_mimic_png_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/pjw-thumbnail.png", 100000);
+}
+
+void bench_mimic_png_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/hat.png", 5000);
+}
+
+void bench_mimic_png_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/hibiscus.png", 500);
+}
+
+void bench_mimic_png_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_png_decode(mimic_png_decode, "../../data/harvesters.png", 50);
+}
+
+#endif  // WUFFS_MIMIC
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_png_call_sequence,                          //
+    test_wuffs_png_decode_bad_checksum,                    //
+    test_wuffs_png_decode_bricks_dither,                   //
+    test_wuffs_png_decode_bricks_dither_many_big_reads,    //
+    test_wuffs_png_decode_bricks_dither_many_small_reads,  //
+    test_wuffs_png_decode_bricks_gray,                     //
+    test_wuffs_png_decode_bricks_nodither,                 //
+    test_wuffs_png_decode_depth16,                         //
+    test_wuffs_png_decode_gray2_trns_interlaced,           //
+    test_wuffs_png_decode_input_is_a_gif,                  //
+    test_wuffs_png_decode_interlaced,                      //
+    test_wuffs_png_decode_interlaced_many_small_reads,     //
+    test_wuffs_png_decode_interlaced_truecolor,            //
+    test_wuffs_png_decode_pixel_format_bgra,               //
+    test_wuffs_png_decode_pixel_format_rgb565,             //
+    test_wuffs_png_decode_pixel_format_rgba,               //
+    test_wuffs_png_set_pixel_format_unsupported,           //
+
+#ifdef WUFFS_MIMIC
+
+    test_mimic_png_decode_bricks_color,            //
+    test_mimic_png_decode_bricks_dither,           //
+    test_mimic_png_decode_bricks_gray,             //
+    test_mimic_png_decode_bricks_nodither,         //
+    test_mimic_png_decode_depth16,                 //
+    test_mimic_png_decode_gray2_trns_interlaced,   //
+    test_mimic_png_decode_harvesters,              //
+    test_mimic_png_decode_hat,                     //
+    test_mimic_png_decode_hibiscus,                //
+    test_mimic_png_decode_interlaced,              //
+    test_mimic_png_decode_interlaced_truecolor,    //
+    test_mimic_png_decode_pjw_thumbnail,           //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_png_decode_1k,     //
+    bench_wuffs_png_decode_10k,    //
+    bench_wuffs_png_decode_100k,   //
+    bench_wuffs_png_decode_1000k,  //
+
+#ifdef WUFFS_MIMIC
+
+    bench_mimic_png_decode_1k,     //
+    bench_mimic_png_decode_10k,    //
+    bench_mimic_png_decode_100k,   //
+    bench_mimic_png_decode_1000k,  //
+
+#endif  // WUFFS_MIMIC
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "std/png.c";
+  return test_main(argc, argv, tests, benches);
+}
@@ -0,0 +1,686 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror tiff.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+There are no mimic tests for TIFF. Instead, the TIFF test images are compared
+to their PNG equivalents, decoded by Wuffs' PNG decoder.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench".
+*/
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/tiff.c"
+#include "../testlib/testlib.c"
+
+// ---------------- TIFF Tests
+
+// seek_src re-positions src after a "mispositioned read" suspension. All of
+// the file is in src, so this only has to change src->ri.
+bool seek_src(wuffs_tiff__decoder* dec, wuffs_base__buf1* src) {
+  uint64_t pos = wuffs_tiff__decoder__seek_position(dec);
+  if (pos > src->wi) {
+    return false;
+  }
+  src->ri = pos;
+  return true;
+}
+
+const char* wuffs_tiff_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_tiff__status s;
+  while (true) {
+    s = wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+    if (s != WUFFS_TIFF__SUSPENSION_MISPOSITIONED_READ) {
+      break;
+    }
+    if (!seek_src(&dec, src)) {
+      return "TIFF seek position is out of bounds";
+    }
+  }
+  if (s) {
+    return wuffs_tiff__status__string(s);
+  }
+
+  // Decode the image as RGBA, the default pixel format.
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "TIFF image's pixel data won't fit in the dst buffer";
+  }
+  while (true) {
+    s = wuffs_tiff__decoder__decode_frame(
+        &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+        src_reader);
+    if (s != WUFFS_TIFF__SUSPENSION_MISPOSITIONED_READ) {
+      break;
+    }
+    if (!seek_src(&dec, src)) {
+      return "TIFF seek position is out of bounds";
+    }
+  }
+  if (s) {
+    return wuffs_tiff__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// wuffs_png_decode is like wuffs_tiff_decode, but for PNG images.
+const char* wuffs_png_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "PNG image's pixel data won't fit in the dst buffer";
+  }
+  s = wuffs_png__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// do_decode_frame calls decode_frame, re-positioning src after every
+// "mispositioned read" suspension. A non-zero rlimit limits each read to that
+// many bytes, so that decoding also suspends with "short read" and resumes.
+wuffs_tiff__status do_decode_frame(wuffs_tiff__decoder* dec,
+                                   wuffs_base__slice_u8 canvas,
+                                   wuffs_base__buf1* src,
+                                   uint64_t rlimit,
+                                   int* num_short_reads) {
+  uint64_t rlim = 0;
+  while (true) {
+    wuffs_base__reader1 src_reader = {.buf = src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    wuffs_tiff__status status =
+        wuffs_tiff__decoder__decode_frame(dec, canvas, src_reader);
+    if (status == WUFFS_TIFF__SUSPENSION_MISPOSITIONED_READ) {
+      if (!seek_src(dec, src)) {
+        return WUFFS_TIFF__ERROR_BAD_ARGUMENT;
+      }
+    } else if ((status == WUFFS_TIFF__SUSPENSION_SHORT_READ) && rlimit) {
+      (*num_short_reads)++;
+    } else {
+      return status;
+    }
+  }
+}
+
+// do_test_wuffs_tiff_decode checks that a TIFF file and a PNG file decode to
+// the same RGBA pixels.
+bool do_test_wuffs_tiff_decode(const char* tiff_filename,
+                               const char* png_filename,
+                               uint64_t rlimit) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, png_filename)) {
+    return false;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&want, &src);
+  if (msg) {
+    FAIL("%s: %s", png_filename, msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, tiff_filename)) {
+    return false;
+  }
+
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  {
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_tiff__status status =
+        wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+    if (status != WUFFS_TIFF__STATUS_OK) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_tiff__status__string(status));
+      return false;
+    }
+  }
+
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+  if (pixbuf_size != want.wi) {
+    FAIL("pixbuf_size: got %zu, want %zu", pixbuf_size, want.wi);
+    return false;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  memset(canvas.ptr, 0, canvas.len);
+
+  int num_short_reads = 0;
+  wuffs_tiff__status status =
+      do_decode_frame(&dec, canvas, &src, rlimit, &num_short_reads);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return false;
+  }
+  got.wi = canvas.len;
+
+  if (rlimit && (num_short_reads == 0)) {
+    FAIL("num_short_reads: got 0, want > 0");
+    return false;
+  }
+
+  if (!buf1s_equal("", &got, &want)) {
+    return false;
+  }
+
+  status = do_decode_frame(&dec, canvas, &src, 0, &num_short_reads);
+  if (status != WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status),
+         WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION,
+         wuffs_tiff__status__string(WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION));
+    return false;
+  }
+  return true;
+}
+
+// do_test_wuffs_tiff_decode_error checks that decoding a TIFF file, held in
+// memory, fails with the given status.
+bool do_test_wuffs_tiff_decode_error(uint8_t* src_ptr,
+                                     size_t src_len,
+                                     wuffs_tiff__status want) {
+  wuffs_base__buf1 src = {
+      .ptr = src_ptr, .len = src_len, .wi = src_len, .closed = true};
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_tiff_decode(&got, &src);
+  if (msg != wuffs_tiff__status__string(want)) {
+    FAIL("got \"%s\", want \"%s\"", msg, wuffs_tiff__status__string(want));
+    return false;
+  }
+  return true;
+}
+
+void test_wuffs_tiff_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-gray.tiff")) {
+    return;
+  }
+
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The image's width and height are still 0, so an empty dst is long enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_tiff__status status =
+      wuffs_tiff__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status),
+         WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_tiff__status__string(WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_tiff__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_tiff__status__string(status),
+         WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_tiff__status__string(WUFFS_TIFF__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_tiff_decode_bad_offset() {
+  CHECK_FOCUS(__func__);
+  // A 1 × 1 gray image whose StripOffsets and StripByteCounts add up to more
+  // than 4 GiB, the largest possible TIFF file.
+  uint8_t src[] = {
+      0x49, 0x49, 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00,  // Header.
+      0x06, 0x00,                                      // 6 entries.
+      0x00, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00,  // ImageWidth...
+      0x01, 0x00, 0x00, 0x00,                          // ...1.
+      0x01, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00,  // ImageLength...
+      0x01, 0x00, 0x00, 0x00,                          // ...1.
+      0x03, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00,  // Compression...
+      0x01, 0x00, 0x00, 0x00,                          // ...none.
+      0x06, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00,  // Photometric...
+      0x01, 0x00, 0x00, 0x00,                          // ...BlackIsZero.
+      0x11, 0x01, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00,  // StripOffsets...
+      0x00, 0x00, 0x00, 0xF0,                          // ...0xF0000000.
+      0x17, 0x01, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00,  // StripByteCounts...
+      0x00, 0x00, 0x00, 0x20,                          // ...0x20000000.
+      0x00, 0x00, 0x00, 0x00,                          // No next IFD.
+  };
+  do_test_wuffs_tiff_decode_error(src, sizeof(src),
+                                  WUFFS_TIFF__ERROR_BAD_TIFF_TAG);
+}
+
+void test_wuffs_tiff_decode_be_packbits() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/artificial/tiff-be-packbits.tiff",
+                            "../../data/hat.png", 0);
+}
+
+void test_wuffs_tiff_decode_be_packbits_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/artificial/tiff-be-packbits.tiff",
+                            "../../data/hat.png", 13);
+}
+
+void test_wuffs_tiff_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/bricks-color.tiff",
+                            "../../data/bricks-color.png", 0);
+}
+
+void test_wuffs_tiff_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/bricks-gray.tiff",
+                            "../../data/bricks-gray.png", 0);
+}
+
+void test_wuffs_tiff_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/harvesters.tiff",
+                            "../../data/harvesters.png", 0);
+}
+
+void test_wuffs_tiff_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/hat.tiff", "../../data/hat.png", 0);
+}
+
+void test_wuffs_tiff_decode_hat_many_big_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/hat.tiff", "../../data/hat.png", 4096);
+}
+
+void test_wuffs_tiff_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/hibiscus.tiff",
+                            "../../data/hibiscus.png", 0);
+}
+
+void test_wuffs_tiff_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, "../../data/bricks-dither.png")) {
+    return;
+  }
+  do_test_wuffs_tiff_decode_error(src.ptr, src.wi,
+                                  WUFFS_TIFF__ERROR_BAD_TIFF_SIGNATURE);
+}
+
+void test_wuffs_tiff_decode_lzw_tiled() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/artificial/tiff-lzw-tiled.tiff",
+                            "../../data/bricks-gray.png", 0);
+}
+
+void test_wuffs_tiff_decode_lzw_tiled_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/artificial/tiff-lzw-tiled.tiff",
+                            "../../data/bricks-gray.png", 13);
+}
+
+void test_wuffs_tiff_decode_multiple_frames() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, "../../data/pjw-thumbnail.png")) {
+    return;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&want, &src);
+  if (msg) {
+    FAIL("pjw-thumbnail.png: %s", msg);
+    return;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, "../../data/artificial/tiff-multiple-frames.tiff")) {
+    return;
+  }
+
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  // See test/data/artificial/tiff-multiple-frames.tiff.commentary.txt. The
+  // IFDs and their pixel data are in file order, so no seeking is needed.
+  wuffs_tiff__status status =
+      wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return;
+  }
+
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+  if (pixbuf_size != want.wi) {
+    FAIL("pixbuf_size: got %zu, want %zu", pixbuf_size, want.wi);
+    return;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  memset(canvas.ptr, 0, canvas.len);
+
+  status = wuffs_tiff__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_frame #0: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return;
+  }
+  got.wi = pixbuf_size;
+  if (!buf1s_equal("frame #0: ", &got, &want)) {
+    return;
+  }
+
+  // The second frame is 2 × 2 pixels, with associated (premultiplied)
+  // alpha. It overwrites the top-left corner of the first frame.
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_tiff__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_frame_config #1: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return;
+  }
+  uint32_t x = wuffs_base__frame_config__x(&fc);
+  uint32_t y = wuffs_base__frame_config__y(&fc);
+  uint32_t w = wuffs_base__frame_config__width(&fc);
+  uint32_t h = wuffs_base__frame_config__height(&fc);
+  if ((x != 0) || (y != 0) || (w != 2) || (h != 2)) {
+    FAIL("frame_config #1: got (%" PRIu32 ", %" PRIu32 ", %" PRIu32
+         ", %" PRIu32 "), want (0, 0, 2, 2)",
+         x, y, w, h);
+    return;
+  }
+
+  status = wuffs_tiff__decoder__decode_frame(&dec, canvas, src_reader);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_frame #1: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return;
+  }
+  uint8_t want_corner[2 * 4 * 3] = {
+      0xFF, 0x00, 0x00, 0xFF, 0x80, 0x40, 0x00, 0x80, 0xFF, 0xFF, 0xFF, 0xFF,
+      0x00, 0x00, 0x00, 0x00, 0x40, 0x80, 0xBF, 0x40, 0xFF, 0xFF, 0xFF, 0xFF,
+  };
+  int i;
+  for (i = 0; i < 2; i++) {
+    wuffs_base__buf1 row_got = {.ptr = got.ptr + (i * 32 * 4),
+                                .len = 3 * 4,
+                                .wi = 3 * 4};
+    wuffs_base__buf1 row_want = {.ptr = want_corner + (i * 3 * 4),
+                                 .len = 3 * 4,
+                                 .wi = 3 * 4};
+    if (!buf1s_equal("frame #1: ", &row_got, &row_want)) {
+      return;
+    }
+  }
+
+  status = wuffs_tiff__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION) {
+    FAIL("decode_frame_config #2: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_tiff__status__string(status),
+         WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION,
+         wuffs_tiff__status__string(WUFFS_TIFF__SUSPENSION_END_OF_ANIMATION));
+    return;
+  }
+}
+
+void test_wuffs_tiff_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode("../../data/pjw-thumbnail.tiff",
+                            "../../data/pjw-thumbnail.png", 0);
+}
+
+bool do_test_wuffs_tiff_decode_pixel_format(uint32_t pixel_format) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, "../../data/hat.png")) {
+    return false;
+  }
+  wuffs_base__buf1 rgba = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&rgba, &src);
+  if (msg) {
+    FAIL("hat.png: %s", msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, "../../data/hat.tiff")) {
+    return false;
+  }
+
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_tiff__decoder__set_pixel_format(&dec, pixel_format);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_tiff__status status =
+      wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return false;
+  }
+  if (!check_pixel_format_config(&ic, pixel_format, 90 * 112)) {
+    return false;
+  }
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+
+  // The hat.tiff pixel data, at the start of the file, is before the IFD.
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  int num_short_reads = 0;
+  status = do_decode_frame(&dec, canvas, &src, 0, &num_short_reads);
+  if (status != WUFFS_TIFF__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status));
+    return false;
+  }
+  got.wi = pixbuf_size;
+
+  return pixel_format_equal(&got, &rgba, pixel_format);
+}
+
+void test_wuffs_tiff_decode_pixel_format_bgra() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__BGRA);
+}
+
+void test_wuffs_tiff_decode_pixel_format_rgb565() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGB565);
+}
+
+void test_wuffs_tiff_decode_pixel_format_rgba() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_tiff_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGBA);
+}
+
+void test_wuffs_tiff_set_pixel_format_unsupported() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, "../../data/hat.tiff")) {
+    return;
+  }
+
+  // An RGB image has no palette, so it cannot be decoded as indexes.
+  wuffs_tiff__decoder dec;
+  wuffs_tiff__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_tiff__decoder__set_pixel_format(&dec,
+                                        WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_tiff__status status =
+      wuffs_tiff__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_TIFF__ERROR_UNSUPPORTED_TIFF_PIXEL_FORMAT) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_tiff__status__string(status),
+         WUFFS_TIFF__ERROR_UNSUPPORTED_TIFF_PIXEL_FORMAT,
+         wuffs_tiff__status__string(
+             WUFFS_TIFF__ERROR_UNSUPPORTED_TIFF_PIXEL_FORMAT));
+    return;
+  }
+}
+
+// ---------------- TIFF Benches
+
+bool do_bench_tiff_decode(const char* (*decode_func)(wuffs_base__buf1*,
+                                                     wuffs_base__buf1*),
+                          const char* filename,
+                          uint64_t reps) {
+  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  for (i = 0; i < reps; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(reps, n_bytes);
+  return true;
+}
+
+void bench_wuffs_tiff_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_tiff_decode(wuffs_tiff_decode, "../../data/pjw-thumbnail.tiff",
+                       100000);
+}
+
+void bench_wuffs_tiff_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_tiff_decode(wuffs_tiff_decode, "../../data/bricks-gray.tiff", 5000);
+}
+
+void bench_wuffs_tiff_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_tiff_decode(wuffs_tiff_decode, "../../data/hat.tiff", 500);
+}
+
+void bench_wuffs_tiff_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_tiff_decode(wuffs_tiff_decode, "../../data/harvesters.tiff", 50);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_tiff_call_sequence,                        //
+    test_wuffs_tiff_decode_bad_offset,                    //
+    test_wuffs_tiff_decode_be_packbits,                   //
+    test_wuffs_tiff_decode_be_packbits_many_small_reads,  //
+    test_wuffs_tiff_decode_bricks_color,                  //
+    test_wuffs_tiff_decode_bricks_gray,                   //
+    test_wuffs_tiff_decode_harvesters,                    //
+    test_wuffs_tiff_decode_hat,                           //
+    test_wuffs_tiff_decode_hat_many_big_reads,            //
+    test_wuffs_tiff_decode_hibiscus,                      //
+    test_wuffs_tiff_decode_input_is_a_png,                //
+    test_wuffs_tiff_decode_lzw_tiled,                     //
+    test_wuffs_tiff_decode_lzw_tiled_many_small_reads,    //
+    test_wuffs_tiff_decode_multiple_frames,               //
+    test_wuffs_tiff_decode_pixel_format_bgra,             //
+    test_wuffs_tiff_decode_pixel_format_rgb565,           //
+    test_wuffs_tiff_decode_pixel_format_rgba,             //
+    test_wuffs_tiff_decode_pjw_thumbnail,                 //
+    test_wuffs_tiff_set_pixel_format_unsupported,         //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_tiff_decode_1k,     //
+    bench_wuffs_tiff_decode_10k,    //
+    bench_wuffs_tiff_decode_100k,   //
+    bench_wuffs_tiff_decode_1000k,  //
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "std/tiff.c";
+  return test_main(argc, argv, tests, benches);
+}
@@ -0,0 +1,589 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test" or "wuffs
+bench" commands. These commands take an optional "-mimic" flag to check that
+Wuffs' output mimics (i.e. exactly matches) other libraries' output, such as
+giflib for GIF, libpng for PNG, etc.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror webp.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+
+There are no mimic tests for WebP. Instead, the lossless WebP test images are
+compared to their PNG equivalents, decoded by Wuffs' PNG decoder.
+
+To manually run the benchmarks, replace "-Wall -Werror" with "-O3" and replace
+the first "./a.out" with "./a.out -bench".
+*/
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../gen/c/std/crc32.c"
+#include "../../../gen/c/std/deflate.c"
+#include "../../../gen/c/std/zlib.c"
+#include "../../../gen/c/std/png.c"
+#include "../../../gen/c/std/webp.c"
+#include "../testlib/testlib.c"
+
+// The workbuf holds 4 bytes per pixel, plus the transforms' sub-images.
+#define WORKBUF_SIZE (16 * 1024 * 1024)
+
+uint8_t global_workbuf_buffer[WORKBUF_SIZE];
+
+// ---------------- WebP Tests
+
+const char* wuffs_webp_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_webp__status s =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_webp__status__string(s);
+  }
+
+  // Decode the image as RGBA, the default pixel format.
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "WebP image's pixel data won't fit in the dst buffer";
+  }
+  uint64_t workbuf_size = wuffs_webp__decoder__workbuf_size(&dec);
+  if (workbuf_size > WORKBUF_SIZE) {
+    return "WebP image's pixels won't fit in the workbuf";
+  }
+  s = wuffs_webp__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      ((wuffs_base__slice_u8){.ptr = global_workbuf_buffer,
+                              .len = workbuf_size}),
+      src_reader);
+  if (s) {
+    return wuffs_webp__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// wuffs_png_decode is like wuffs_webp_decode, but for PNG images.
+const char* wuffs_png_decode(wuffs_base__buf1* dst, wuffs_base__buf1* src) {
+  wuffs_png__decoder dec;
+  wuffs_png__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = src};
+  wuffs_png__status s =
+      wuffs_png__decoder__decode_config(&dec, &ic, src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+
+  size_t n = wuffs_base__image_config__pixbuf_size(&ic);
+  if (n > dst->len - dst->wi) {
+    return "PNG image's pixel data won't fit in the dst buffer";
+  }
+  s = wuffs_png__decoder__decode_frame(
+      &dec, ((wuffs_base__slice_u8){.ptr = dst->ptr + dst->wi, .len = n}),
+      src_reader);
+  if (s) {
+    return wuffs_png__status__string(s);
+  }
+  dst->wi += n;
+  return NULL;
+}
+
+// do_test_wuffs_webp_decode checks that a lossless WebP file and a PNG file
+// decode to the same RGBA pixels. A non-zero rlimit limits each read to that
+// many bytes, so that decoding suspends and resumes.
+bool do_test_wuffs_webp_decode(const char* webp_filename,
+                               const char* png_filename,
+                               uint64_t rlimit) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, png_filename)) {
+    return false;
+  }
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&want, &src);
+  if (msg) {
+    FAIL("%s: %s", png_filename, msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, webp_filename)) {
+    return false;
+  }
+
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+
+  int num_iters = 0;
+  uint64_t rlim = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    size_t old_ri = src.ri;
+
+    wuffs_webp__status status =
+        wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+    if (status == WUFFS_WEBP__STATUS_OK) {
+      break;
+    }
+    if ((status != WUFFS_WEBP__SUSPENSION_SHORT_READ) || !rlimit) {
+      FAIL("decode_config: got %" PRIi32 " (%s)", status,
+           wuffs_webp__status__string(status));
+      return false;
+    }
+    if (src.ri < old_ri) {
+      FAIL("read index src.ri went backwards");
+      return false;
+    }
+  }
+
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+  if (pixbuf_size != want.wi) {
+    FAIL("pixbuf_size: got %zu, want %zu", pixbuf_size, want.wi);
+    return false;
+  }
+  uint64_t workbuf_size = wuffs_webp__decoder__workbuf_size(&dec);
+  if (workbuf_size > WORKBUF_SIZE) {
+    FAIL("workbuf_size: got %" PRIu64 ", want <= %d", workbuf_size,
+         WORKBUF_SIZE);
+    return false;
+  }
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  wuffs_base__slice_u8 workbuf = {.ptr = global_workbuf_buffer,
+                                  .len = workbuf_size};
+  memset(canvas.ptr, 0, canvas.len);
+
+  num_iters = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    if (rlimit) {
+      rlim = rlimit;
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+    size_t old_ri = src.ri;
+
+    wuffs_webp__status status =
+        wuffs_webp__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+    if (status == WUFFS_WEBP__STATUS_OK) {
+      break;
+    }
+    if (status != WUFFS_WEBP__SUSPENSION_SHORT_READ) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_webp__status__string(status),
+           WUFFS_WEBP__SUSPENSION_SHORT_READ,
+           wuffs_webp__status__string(WUFFS_WEBP__SUSPENSION_SHORT_READ));
+      return false;
+    }
+
+    if (src.ri < old_ri) {
+      FAIL("read index src.ri went backwards");
+      return false;
+    }
+    if (src.ri == old_ri) {
+      FAIL("no progress was made");
+      return false;
+    }
+  }
+  got.wi = canvas.len;
+
+  if (rlimit) {
+    if (num_iters <= 1) {
+      FAIL("num_iters: got %d, want > 1", num_iters);
+      return false;
+    }
+  } else {
+    if (num_iters != 1) {
+      FAIL("num_iters: got %d, want 1", num_iters);
+      return false;
+    }
+  }
+
+  if (!buf1s_equal("", &got, &want)) {
+    return false;
+  }
+
+  {
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    wuffs_webp__status status =
+        wuffs_webp__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+    if (status != WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION) {
+      FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_webp__status__string(status),
+           WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION,
+           wuffs_webp__status__string(WUFFS_WEBP__SUSPENSION_END_OF_ANIMATION));
+      return false;
+    }
+  }
+
+  return true;
+}
+
+// do_test_wuffs_webp_decode_config_error checks that decode_config rejects a
+// file with the given status.
+bool do_test_wuffs_webp_decode_config_error(const char* filename,
+                                            wuffs_webp__status want) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != want) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status), want,
+         wuffs_webp__status__string(want));
+    return false;
+  }
+  return true;
+}
+
+void test_wuffs_webp_call_sequence() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.lossless.webp")) {
+    return;
+  }
+
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The image's width and height are still 0, so an empty dst is long enough.
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = 0};
+  wuffs_base__slice_u8 workbuf = {.ptr = global_workbuf_buffer, .len = 0};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+  if (status != WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_webp__status__string(WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+
+  // The previous error is sticky, so re-initialize the decoder.
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_base__frame_config fc = {{0}};
+  status = wuffs_webp__decoder__decode_frame_config(&dec, &fc, src_reader);
+  if (status != WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE) {
+    FAIL("decode_frame_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)",
+         status, wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE,
+         wuffs_webp__status__string(WUFFS_WEBP__ERROR_INVALID_CALL_SEQUENCE));
+    return;
+  }
+}
+
+void test_wuffs_webp_decode_bricks_color() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-color.lossless.webp",
+                            "../../data/bricks-color.png", 0);
+}
+
+void test_wuffs_webp_decode_bricks_dither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-dither.lossless.webp",
+                            "../../data/bricks-dither.png", 0);
+}
+
+void test_wuffs_webp_decode_bricks_dither_many_big_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-dither.lossless.webp",
+                            "../../data/bricks-dither.png", 4096);
+}
+
+void test_wuffs_webp_decode_bricks_dither_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-dither.lossless.webp",
+                            "../../data/bricks-dither.png", 13);
+}
+
+void test_wuffs_webp_decode_bricks_gray() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-gray.lossless.webp",
+                            "../../data/bricks-gray.png", 0);
+}
+
+void test_wuffs_webp_decode_bricks_nodither() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/bricks-nodither.lossless.webp",
+                            "../../data/bricks-nodither.png", 0);
+}
+
+void test_wuffs_webp_decode_harvesters() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/harvesters.lossless.webp",
+                            "../../data/harvesters.png", 0);
+}
+
+void test_wuffs_webp_decode_hat() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/hat.lossless.webp",
+                            "../../data/hat.png", 0);
+}
+
+void test_wuffs_webp_decode_hibiscus() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/hibiscus.lossless.webp",
+                            "../../data/hibiscus.png", 0);
+}
+
+void test_wuffs_webp_decode_input_is_a_png() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_config_error("../../data/bricks-dither.png",
+                                         WUFFS_WEBP__ERROR_BAD_WEBP_SIGNATURE);
+}
+
+void test_wuffs_webp_decode_lossy() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_config_error(
+      "../../data/bricks-color.lossy.webp",
+      WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_COMPRESSION);
+}
+
+void test_wuffs_webp_decode_pjw_thumbnail() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/pjw-thumbnail.lossless.webp",
+                            "../../data/pjw-thumbnail.png", 0);
+}
+
+void test_wuffs_webp_decode_vp8x() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode("../../data/artificial/webp-vp8x.webp",
+                            "../../data/pjw-thumbnail.png", 0);
+}
+
+bool do_test_wuffs_webp_decode_pixel_format(uint32_t pixel_format) {
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, "../../data/hibiscus.png")) {
+    return false;
+  }
+  wuffs_base__buf1 rgba = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  const char* msg = wuffs_png_decode(&rgba, &src);
+  if (msg) {
+    FAIL("%s", msg);
+    return false;
+  }
+
+  src.wi = 0;
+  src.ri = 0;
+  src.closed = false;
+  if (!read_file(&src, "../../data/hibiscus.lossless.webp")) {
+    return false;
+  }
+
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_webp__decoder__set_pixel_format(&dec, pixel_format);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_config: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return false;
+  }
+  if (!check_pixel_format_config(&ic, pixel_format, (rgba.wi / 4))) {
+    return false;
+  }
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(&ic);
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__slice_u8 canvas = {.ptr = got.ptr, .len = pixbuf_size};
+  wuffs_base__slice_u8 workbuf = {
+      .ptr = global_workbuf_buffer,
+      .len = wuffs_webp__decoder__workbuf_size(&dec)};
+  status = wuffs_webp__decoder__decode_frame(&dec, canvas, workbuf, src_reader);
+  if (status != WUFFS_WEBP__STATUS_OK) {
+    FAIL("decode_frame: got %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status));
+    return false;
+  }
+  got.wi = pixbuf_size;
+
+  return pixel_format_equal(&got, &rgba, pixel_format);
+}
+
+void test_wuffs_webp_decode_pixel_format_bgra() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__BGRA);
+}
+
+void test_wuffs_webp_decode_pixel_format_rgb565() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGB565);
+}
+
+void test_wuffs_webp_decode_pixel_format_rgba() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_webp_decode_pixel_format(WUFFS_BASE__PIXEL_FORMAT__RGBA);
+}
+
+void test_wuffs_webp_set_pixel_format_unsupported() {
+  CHECK_FOCUS(__func__);
+
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, "../../data/bricks-dither.lossless.webp")) {
+    return;
+  }
+
+  // Even a color indexed WebP image's palette is not fixed: the indexes can
+  // be further transformed. It cannot be decoded as indexes.
+  wuffs_webp__decoder dec;
+  wuffs_webp__decoder__initialize(&dec, WUFFS_VERSION, 0);
+  wuffs_webp__decoder__set_pixel_format(&dec,
+                                        WUFFS_BASE__PIXEL_FORMAT__INDEXED);
+  wuffs_base__image_config ic = {{0}};
+  wuffs_base__reader1 src_reader = {.buf = &src};
+
+  wuffs_webp__status status =
+      wuffs_webp__decoder__decode_config(&dec, &ic, src_reader);
+  if (status != WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_PIXEL_FORMAT) {
+    FAIL("decode_config: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_webp__status__string(status),
+         WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_PIXEL_FORMAT,
+         wuffs_webp__status__string(
+             WUFFS_WEBP__ERROR_UNSUPPORTED_WEBP_PIXEL_FORMAT));
+    return;
+  }
+}
+
+// ---------------- WebP Benches
+
+bool do_bench_webp_decode(const char* (*decode_func)(wuffs_base__buf1*,
+                                                     wuffs_base__buf1*),
+                          const char* filename,
+                          uint64_t reps) {
+  wuffs_base__buf1 dst = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+
+  if (!read_file(&src, filename)) {
+    return false;
+  }
+
+  bench_start();
+  uint64_t n_bytes = 0;
+  uint64_t i;
+  for (i = 0; i < reps; i++) {
+    dst.wi = 0;
+    src.ri = 0;
+    const char* error_msg = decode_func(&dst, &src);
+    if (error_msg) {
+      FAIL("%s", error_msg);
+      return false;
+    }
+    n_bytes += dst.wi;
+  }
+  bench_finish(reps, n_bytes);
+  return true;
+}
+
+void bench_wuffs_webp_decode_1k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode,
+                       "../../data/pjw-thumbnail.lossless.webp", 100000);
+}
+
+void bench_wuffs_webp_decode_10k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode,
+                       "../../data/bricks-dither.lossless.webp", 5000);
+}
+
+void bench_wuffs_webp_decode_100k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode, "../../data/hat.lossless.webp",
+                       500);
+}
+
+void bench_wuffs_webp_decode_1000k() {
+  CHECK_FOCUS(__func__);
+  do_bench_webp_decode(wuffs_webp_decode,
+                       "../../data/harvesters.lossless.webp", 50);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_webp_call_sequence,                          //
+    test_wuffs_webp_decode_bricks_color,                    //
+    test_wuffs_webp_decode_bricks_dither,                   //
+    test_wuffs_webp_decode_bricks_dither_many_big_reads,    //
+    test_wuffs_webp_decode_bricks_dither_many_small_reads,  //
+    test_wuffs_webp_decode_bricks_gray,                     //
+    test_wuffs_webp_decode_bricks_nodither,                 //
+    test_wuffs_webp_decode_harvesters,                      //
+    test_wuffs_webp_decode_hat,                             //
+    test_wuffs_webp_decode_hibiscus,                        //
+    test_wuffs_webp_decode_input_is_a_png,                  //
+    test_wuffs_webp_decode_lossy,                           //
+    test_wuffs_webp_decode_pixel_format_bgra,               //
+    test_wuffs_webp_decode_pixel_format_rgb565,             //
+    test_wuffs_webp_decode_pixel_format_rgba,               //
+    test_wuffs_webp_decode_pjw_thumbnail,                   //
+    test_wuffs_webp_decode_vp8x,                            //
+    test_wuffs_webp_set_pixel_format_unsupported,           //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    bench_wuffs_webp_decode_1k,     //
+    bench_wuffs_webp_decode_10k,    //
+    bench_wuffs_webp_decode_100k,   //
+    bench_wuffs_webp_decode_1000k,  //
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "std/webp.c";
+  return test_main(argc, argv, tests, benches);
+}
@@ -301,6 +301,116 @@ void test_wuffs_zlib_checksum_verify_good() {
   do_test_wuffs_zlib_checksum(false, 0);
 }
 
+// do_test_wuffs_zlib_dict decodes a zlib stream after setting its preset
+// dictionary, if dict_filename is non-NULL, and checks that decoding ends with
+// want_status and, if that is ok, gives the contents of want_filename.
+bool do_test_wuffs_zlib_dict(const char* src_filename,
+                             const char* dict_filename,
+                             const char* want_filename,
+                             wuffs_zlib__status want_status,
+                             uint64_t wlimit,
+                             uint64_t rlimit) {
+  wuffs_zlib__decoder dec;
+  wuffs_zlib__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  // The dictionary is copied by set_dictionary, so that the want buffer can
+  // be re-used afterwards.
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  if (dict_filename) {
+    if (!read_file(&want, dict_filename)) {
+      return false;
+    }
+    wuffs_zlib__decoder__set_dictionary(
+        &dec, ((wuffs_base__slice_u8){.ptr = want.ptr, .len = want.wi}));
+    want.wi = 0;
+    want.closed = false;
+  }
+  if (!read_file(&want, want_filename)) {
+    return false;
+  }
+
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&src, src_filename)) {
+    return false;
+  }
+
+  wuffs_zlib__status status;
+  while (true) {
+    wuffs_base__writer1 got_writer = {.buf = &got};
+    uint64_t wlim = wlimit;
+    if (wlimit) {
+      got_writer.private_impl.limit.ptr_to_len = &wlim;
+    }
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    uint64_t rlim = rlimit;
+    if (rlimit) {
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+
+    status = wuffs_zlib__decoder__decode(&dec, got_writer, src_reader);
+    if ((wlimit && (status == WUFFS_ZLIB__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (status == WUFFS_ZLIB__SUSPENSION_SHORT_READ))) {
+      continue;
+    }
+    break;
+  }
+  if (status != want_status) {
+    FAIL("got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+         wuffs_zlib__status__string(status), want_status,
+         wuffs_zlib__status__string(want_status));
+    return false;
+  }
+  if (status != WUFFS_ZLIB__STATUS_OK) {
+    return true;
+  }
+  return buf1s_equal("", &got, &want);
+}
+
+void test_wuffs_zlib_decode_dict_bad() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict("../../data/artificial/zlib-dict-midsummer.zlib",
+                          "../../data/pi.txt", "../../data/midsummer.txt",
+                          WUFFS_ZLIB__ERROR_BAD_ZLIB_DICTIONARY, 0, 0);
+}
+
+void test_wuffs_zlib_decode_dict_midsummer() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict("../../data/artificial/zlib-dict-midsummer.zlib",
+                          "../../data/midsummer.txt",
+                          "../../data/midsummer.txt", WUFFS_ZLIB__STATUS_OK,
+                          0, 0);
+}
+
+void test_wuffs_zlib_decode_dict_missing() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict("../../data/artificial/zlib-dict-midsummer.zlib",
+                          NULL, "../../data/midsummer.txt",
+                          WUFFS_ZLIB__ERROR_MISSING_ZLIB_DICTIONARY, 0, 0);
+}
+
+void test_wuffs_zlib_decode_dict_pi() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict("../../data/artificial/zlib-dict-pi.zlib",
+                          "../../data/pi.txt", "../../data/pi.txt",
+                          WUFFS_ZLIB__STATUS_OK, 0, 0);
+}
+
+void test_wuffs_zlib_decode_dict_pi_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict("../../data/artificial/zlib-dict-pi.zlib",
+                          "../../data/pi.txt", "../../data/pi.txt",
+                          WUFFS_ZLIB__STATUS_OK, 41, 43);
+}
+
+void test_wuffs_zlib_decode_dict_unexpected() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_zlib_dict(zlib_midsummer_gt.src_filename,
+                          "../../data/midsummer.txt",
+                          "../../data/midsummer.txt",
+                          WUFFS_ZLIB__ERROR_UNEXPECTED_ZLIB_DICTIONARY, 0, 0);
+}
+
 void test_wuffs_zlib_decode_midsummer() {
   CHECK_FOCUS(__func__);
   do_test_buf1_buf1(wuffs_zlib_decode, &zlib_midsummer_gt, 0, 0);
@@ -415,11 +525,17 @@ proc tests[] = {
     test_wuffs_adler32_golden,  //
     test_wuffs_adler32_pi,      //
 
-    test_wuffs_zlib_checksum_ignore,       //
-    test_wuffs_zlib_checksum_verify_bad,   //
-    test_wuffs_zlib_checksum_verify_good,  //
-    test_wuffs_zlib_decode_midsummer,      //
-    test_wuffs_zlib_decode_pi,             //
+    test_wuffs_zlib_checksum_ignore,                         //
+    test_wuffs_zlib_checksum_verify_bad,                     //
+    test_wuffs_zlib_checksum_verify_good,                    //
+    test_wuffs_zlib_decode_dict_bad,                         //
+    test_wuffs_zlib_decode_dict_midsummer,                   //
+    test_wuffs_zlib_decode_dict_missing,                     //
+    test_wuffs_zlib_decode_dict_pi,                          //
+    test_wuffs_zlib_decode_dict_pi_many_small_writes_reads,  //
+    test_wuffs_zlib_decode_dict_unexpected,                  //
+    test_wuffs_zlib_decode_midsummer,                        //
+    test_wuffs_zlib_decode_pi,                               //
 
 #ifdef WUFFS_MIMIC
 
@@ -0,0 +1,194 @@
+// Copyright 2018 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test program is typically run indirectly, by the "wuffs test
+test/wuffs/recursion" command. It tests the generated C code for recursive
+coroutines, which have one coroutine state per depth.
+
+To manually run this test:
+
+for cc in clang gcc; do
+  $cc -std=c99 -Wall -Werror recursion.c && ./a.out
+  rm -f a.out
+done
+
+Each edition should print "PASS", amongst other information, and exit(0).
+*/
+
+// If building this program in an environment that doesn't easily accomodate
+// relative includes, you can use the script/inline-c-relative-includes.go
+// program to generate a stand-alone C file.
+#include "../../../../gen/c/test/wuffs/recursion.c"
+#include "../../testlib/testlib.c"
+
+// ---------------- Recursion Tests
+
+// do_test_wuffs_recursion_decode decodes src, a tree as described by the
+// decoder.decode method's doc comment, and checks the output and status. If
+// want_deepest_suspensions is non-zero, it also checks which depths the
+// decoder suspended at: bit d is set if, at some suspension, the deepest
+// suspended coroutine state was that of depth d.
+bool do_test_wuffs_recursion_decode(const char* src_str,
+                                    const char* want_str,
+                                    wuffs_recursion__status want_status,
+                                    uint32_t want_deepest_suspensions,
+                                    uint64_t wlimit,
+                                    uint64_t rlimit) {
+  wuffs_base__buf1 got = {.ptr = global_got_buffer, .len = BUFFER_SIZE};
+  wuffs_base__buf1 src = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  size_t src_len = strlen(src_str);
+  memcpy(src.ptr, src_str, src_len);
+  src.wi = src_len;
+  src.closed = true;
+
+  wuffs_recursion__decoder dec;
+  wuffs_recursion__decoder__initialize(&dec, WUFFS_VERSION, 0);
+
+  uint32_t got_deepest_suspensions = 0;
+  int num_iters = 0;
+  while (true) {
+    num_iters++;
+    wuffs_base__writer1 got_writer = {.buf = &got};
+    uint64_t wlim = wlimit;
+    if (wlimit) {
+      got_writer.private_impl.limit.ptr_to_len = &wlim;
+    }
+    wuffs_base__reader1 src_reader = {.buf = &src};
+    uint64_t rlim = rlimit;
+    if (rlimit) {
+      src_reader.private_impl.limit.ptr_to_len = &rlim;
+    }
+
+    wuffs_recursion__status status =
+        wuffs_recursion__decoder__decode(&dec, got_writer, src_reader);
+    if ((wlimit && (status == WUFFS_RECURSION__SUSPENSION_SHORT_WRITE)) ||
+        (rlimit && (status == WUFFS_RECURSION__SUSPENSION_SHORT_READ))) {
+      // Peek at the decoder's private state, which this test (but not
+      // regular code) may do, to find the deepest suspended coroutine.
+      int d;
+      for (d = 7; d >= 0; d--) {
+        if (dec.private_impl.c_decode_node[d].coro_susp_point) {
+          got_deepest_suspensions |= 1 << d;
+          break;
+        }
+      }
+      if (num_iters > 100) {
+        FAIL("too many iterations");
+        return false;
+      }
+      continue;
+    }
+    if (status != want_status) {
+      FAIL("status: got %" PRIi32 " (%s), want %" PRIi32 " (%s)", status,
+           wuffs_recursion__status__string(status), want_status,
+           wuffs_recursion__status__string(want_status));
+      return false;
+    }
+    break;
+  }
+
+  if ((wlimit || rlimit) && (num_iters <= 1)) {
+    FAIL("num_iters: got %d, want > 1", num_iters);
+    return false;
+  }
+  if (want_deepest_suspensions &&
+      (got_deepest_suspensions != want_deepest_suspensions)) {
+    FAIL("deepest suspensions: got 0x%02" PRIX32 ", want 0x%02" PRIX32,
+         got_deepest_suspensions, want_deepest_suspensions);
+    return false;
+  }
+
+  wuffs_base__buf1 want = {.ptr = global_want_buffer, .len = BUFFER_SIZE};
+  size_t want_len = strlen(want_str);
+  memcpy(want.ptr, want_str, want_len);
+  want.wi = want_len;
+  return buf1s_equal("", &got, &want);
+}
+
+void test_wuffs_recursion_decode_bad_node() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_recursion_decode("21x", "01", WUFFS_RECURSION__ERROR_BAD_NODE,
+                                 0, 0, 0);
+}
+
+void test_wuffs_recursion_decode_basic() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_recursion_decode("321000110", "012321123",
+                                 WUFFS_RECURSION__STATUS_OK, 0, 0, 0);
+}
+
+void test_wuffs_recursion_decode_deepest() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_recursion_decode("11111110", "01234567",
+                                 WUFFS_RECURSION__STATUS_OK, 0, 0, 0);
+}
+
+void test_wuffs_recursion_decode_deepest_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  // Reading one byte at a time suspends at every depth but the root's, as
+  // each node's first byte is read after its parent has read all that it
+  // was given.
+  do_test_wuffs_recursion_decode("11111110", "01234567",
+                                 WUFFS_RECURSION__STATUS_OK, 0xFE, 0, 1);
+}
+
+void test_wuffs_recursion_decode_many_small_writes_reads() {
+  CHECK_FOCUS(__func__);
+  // Each node's n local variable, its remaining number of children, has to
+  // survive its children's suspensions, at depths 1, 2 and 3.
+  do_test_wuffs_recursion_decode("321000110", "012321123",
+                                 WUFFS_RECURSION__STATUS_OK, 0x0E, 1, 1);
+}
+
+void test_wuffs_recursion_decode_too_deep() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_recursion_decode("111111110", "01234567",
+                                 WUFFS_RECURSION__ERROR_NESTING_TOO_DEEP, 0, 0,
+                                 0);
+}
+
+void test_wuffs_recursion_decode_too_deep_many_small_reads() {
+  CHECK_FOCUS(__func__);
+  do_test_wuffs_recursion_decode("111111110", "01234567",
+                                 WUFFS_RECURSION__ERROR_NESTING_TOO_DEEP, 0xFE,
+                                 0, 1);
+}
+
+// ---------------- Manifest
+
+// The empty comments forces clang-format to place one element per line.
+proc tests[] = {
+
+    test_wuffs_recursion_decode_bad_node,                   //
+    test_wuffs_recursion_decode_basic,                      //
+    test_wuffs_recursion_decode_deepest,                    //
+    test_wuffs_recursion_decode_deepest_many_small_reads,   //
+    test_wuffs_recursion_decode_many_small_writes_reads,    //
+    test_wuffs_recursion_decode_too_deep,                   //
+    test_wuffs_recursion_decode_too_deep_many_small_reads,  //
+
+    NULL,
+};
+
+// The empty comments forces clang-format to place one element per line.
+proc benches[] = {
+
+    NULL,
+};
+
+int main(int argc, char** argv) {
+  proc_filename = "recursion.c";
+  return test_main(argc, argv, tests, benches);
+}
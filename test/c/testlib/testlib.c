@@ -401,6 +401,112 @@ bool buf1s_equal(const char* prefix,
   return false;
 }
 
+// want_pixel writes, to dst, the RGBA pixel rgba[0:4] in the given pixel
+// format, and returns the number of bytes written. The dst and rgba pointers
+// can be equal, to convert in place.
+size_t want_pixel(uint8_t* dst, uint8_t* rgba, uint32_t pixel_format) {
+  uint8_t r = rgba[0];
+  uint8_t g = rgba[1];
+  uint8_t b = rgba[2];
+  uint8_t a = rgba[3];
+  switch (pixel_format) {
+    case WUFFS_BASE__PIXEL_FORMAT__RGBA:
+      dst[0] = r;
+      dst[1] = g;
+      dst[2] = b;
+      dst[3] = a;
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__BGRA:
+      dst[0] = b;
+      dst[1] = g;
+      dst[2] = r;
+      dst[3] = a;
+      return 4;
+    case WUFFS_BASE__PIXEL_FORMAT__RGB565: {
+      uint16_t x = ((uint16_t)(r >> 3) << 11) | ((uint16_t)(g >> 2) << 5) |
+                   ((uint16_t)(b >> 3) << 0);
+      dst[0] = (uint8_t)(x >> 0);
+      dst[1] = (uint8_t)(x >> 8);
+      return 2;
+    }
+  }
+  return 0;
+}
+
+// check_pixel_format_config checks that ic, decoded after asking for the given
+// pixel format, has that pixel format and a pixbuf_size for num_pixels pixels.
+bool check_pixel_format_config(wuffs_base__image_config* ic,
+                               uint32_t pixel_format,
+                               size_t num_pixels) {
+  if (!ic) {
+    FAIL("check_pixel_format_config: NULL argument");
+    return false;
+  }
+  if (wuffs_base__image_config__pixel_format(ic) != pixel_format) {
+    FAIL("pixel_format: got %" PRIu32 ", want %" PRIu32,
+         wuffs_base__image_config__pixel_format(ic), pixel_format);
+    return false;
+  }
+  size_t bpp = wuffs_base__pixel_format__bytes_per_pixel(pixel_format);
+  size_t pixbuf_size = wuffs_base__image_config__pixbuf_size(ic);
+  if (pixbuf_size != num_pixels * bpp) {
+    FAIL("pixbuf_size: got %zu, want %zu", pixbuf_size, num_pixels * bpp);
+    return false;
+  }
+  return true;
+}
+
+// read_indexed_rgba writes, to dst, the opaque RGBA pixels for the palette
+// (256 R, G, B entries) and indexes test data at the two paths. It uses the
+// global_palette_buffer and global_src_buffer as scratch space.
+bool read_indexed_rgba(wuffs_base__buf1* dst,
+                       const char* palette_path,
+                       const char* indexes_path) {
+  if (!dst || !palette_path || !indexes_path) {
+    FAIL("read_indexed_rgba: NULL argument");
+    return false;
+  }
+  wuffs_base__buf1 pal = {.ptr = global_palette_buffer, .len = 3 * 256};
+  if (!read_file(&pal, palette_path)) {
+    return false;
+  }
+  wuffs_base__buf1 ind = {.ptr = global_src_buffer, .len = BUFFER_SIZE};
+  if (!read_file(&ind, indexes_path)) {
+    return false;
+  }
+  if (dst->len - dst->wi < 4 * ind.wi) {
+    FAIL("read_indexed_rgba: dst buffer is too short");
+    return false;
+  }
+  size_t i;
+  for (i = 0; i < ind.wi; i++) {
+    uint8_t* p = pal.ptr + 3 * ind.ptr[i];
+    dst->ptr[dst->wi++] = p[0];
+    dst->ptr[dst->wi++] = p[1];
+    dst->ptr[dst->wi++] = p[2];
+    dst->ptr[dst->wi++] = 0xFF;
+  }
+  return true;
+}
+
+// pixel_format_equal converts want, holding RGBA pixels, to the given pixel
+// format, in place, and then checks that it equals got.
+bool pixel_format_equal(wuffs_base__buf1* got,
+                        wuffs_base__buf1* want,
+                        uint32_t pixel_format) {
+  if (!got || !want) {
+    FAIL("pixel_format_equal: NULL argument");
+    return false;
+  }
+  size_t n = 0;
+  size_t i;
+  for (i = 0; i + 4 <= want->wi; i += 4) {
+    n += want_pixel(want->ptr + n, want->ptr + i, pixel_format);
+  }
+  want->wi = n;
+  return buf1s_equal("", got, want);
+}
+
 // throughput_counter is whether to count dst or src bytes, or neither, when
 // calculating a benchmark's MB/s throughput number.
 //
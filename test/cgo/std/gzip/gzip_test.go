@@ -0,0 +1,105 @@
+// Copyright 2017 The Wuffs Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wuffs_cgo
+// +build wuffs_cgo
+
+// This test imports the generated gen/cgo/std/gzip package. Run it with
+// "wuffs test -langs=c,cgo std/gzip", which generates that package first.
+
+package gzip_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/wuffs/gen/cgo/std/gzip"
+)
+
+func testReader(t *testing.T, filename string, wrap func(io.Reader) io.Reader) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(filename + ".decompressed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gzip.NewReader(wrap(bytes.NewReader(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes %q, want %d bytes %q", len(got), got, len(want), want)
+	}
+}
+
+func identity(r io.Reader) io.Reader { return r }
+
+func TestReaderHeaderFields(t *testing.T) {
+	testReader(t, "../../../data/artificial/gzip-header-fields.gz", identity)
+}
+
+func TestReaderMultipleMembers(t *testing.T) {
+	testReader(t, "../../../data/artificial/gzip-multiple-members.gz", identity)
+}
+
+func TestReaderMultipleMembersOneByteReads(t *testing.T) {
+	testReader(t, "../../../data/artificial/gzip-multiple-members.gz", iotest.OneByteReader)
+}
+
+func TestReaderTrailingGarbage(t *testing.T) {
+	src, err := ioutil.ReadFile("../../../data/artificial/gzip-multiple-members.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = append(src, "garbage"...)
+
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := ioutil.ReadAll(r); err != gzip.ErrorBadGzipHeader {
+		t.Fatalf("got %v, want %v", err, gzip.ErrorBadGzipHeader)
+	}
+}
+
+func TestReaderBadHeaderChecksum(t *testing.T) {
+	src, err := ioutil.ReadFile("../../../data/artificial/gzip-header-fields.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a bit in the header's CRC-16, which is at offset 0x28. See
+	// test/data/artificial/gzip-header-fields.gz.commentary.txt.
+	src[0x28] ^= 1
+
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := ioutil.ReadAll(r); err != gzip.ErrorBadGzipHeaderChecksum {
+		t.Fatalf("got %v, want %v", err, gzip.ErrorBadGzipHeaderChecksum)
+	}
+}